@@ -0,0 +1,303 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+)
+
+// Authentication headers
+const (
+	HeaderAPIKey    = "X-API-Key"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderSignature = "X-Signature"
+)
+
+// defaultSignatureMaxSkew is used when the configuration leaves the skew unset
+const defaultSignatureMaxSkew = 5 * time.Minute
+
+// Scope is the level of access granted to an API key. Scopes are
+// hierarchical: admin includes trade and trade includes read.
+type Scope int
+
+const (
+	ScopeRead Scope = iota + 1
+	ScopeTrade
+	ScopeAdmin
+)
+
+// String returns the configuration name of the scope
+func (s Scope) String() string {
+	switch s {
+	case ScopeRead:
+		return "read"
+	case ScopeTrade:
+		return "trade"
+	case ScopeAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseScope converts a configured scope name to a Scope
+func ParseScope(name string) (Scope, error) {
+	switch name {
+	case "read":
+		return ScopeRead, nil
+	case "trade":
+		return ScopeTrade, nil
+	case "admin":
+		return ScopeAdmin, nil
+	default:
+		return 0, fmt.Errorf("unknown scope: %s", name)
+	}
+}
+
+var (
+	errMissingAPIKey    = errors.New("missing API key")
+	errInvalidAPIKey    = errors.New("invalid API key")
+	errMissingSignature = errors.New("request signature required for this key")
+	errInvalidTimestamp = errors.New("invalid or expired request timestamp")
+	errInvalidSignature = errors.New("invalid request signature")
+	errReplayedRequest  = errors.New("request signature already used")
+)
+
+// APIKey is an authenticated caller
+type APIKey struct {
+	Name   string
+	Scope  Scope
+	key    string
+	secret string
+}
+
+type apiKeyContextKey struct{}
+
+// authentication is the outcome of authenticating a request, kept in its
+// context so that a signature is verified, and recorded against replays,
+// only once
+type authentication struct {
+	key *APIKey
+	err error
+}
+
+type authenticationContextKey struct{}
+
+// APIKeyFromContext returns the API key that authenticated the request, if any
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key, ok
+}
+
+// authenticator verifies API keys and HMAC request signatures
+type authenticator struct {
+	enabled bool
+	keys    []*APIKey
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // signature -> expiry, used to reject replays
+}
+
+// newAuthenticator builds an authenticator from the server auth configuration.
+// Each key is granted the highest scope it lists; unknown scope names are
+// rejected by config.Validate and ignored here, so a key without any valid
+// scope can never authenticate.
+func newAuthenticator(cfg config.AuthConfig) *authenticator {
+	a := &authenticator{
+		enabled: cfg.Enabled,
+		maxSkew: cfg.SignatureMaxSkew,
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}
+	if a.maxSkew <= 0 {
+		a.maxSkew = defaultSignatureMaxSkew
+	}
+
+	for _, keyCfg := range cfg.APIKeys {
+		key := &APIKey{Name: keyCfg.Name, key: keyCfg.Key, secret: keyCfg.Secret}
+		for _, name := range keyCfg.Scopes {
+			if scope, err := ParseScope(name); err == nil && scope > key.Scope {
+				key.Scope = scope
+			}
+		}
+		if key.Scope == 0 || key.key == "" {
+			continue
+		}
+		a.keys = append(a.keys, key)
+	}
+
+	return a
+}
+
+// authenticate identifies the caller and, for keys with a secret, verifies the
+// request signature. The request body is restored after hashing.
+func (a *authenticator) authenticate(r *http.Request) (*APIKey, error) {
+	if r.Header.Get(HeaderAPIKey) == "" {
+		return nil, errMissingAPIKey
+	}
+
+	key := a.identify(r)
+	if key == nil {
+		return nil, errInvalidAPIKey
+	}
+
+	if key.secret == "" {
+		return key, nil
+	}
+	if err := a.verifySignature(r, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// identify returns the configured key matching the X-API-Key header without
+// checking the request signature, or nil if there is none
+func (a *authenticator) identify(r *http.Request) *APIKey {
+	provided := r.Header.Get(HeaderAPIKey)
+	if provided == "" {
+		return nil
+	}
+
+	var key *APIKey
+	for _, candidate := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(candidate.key), []byte(provided)) == 1 {
+			key = candidate
+		}
+	}
+	return key
+}
+
+func (a *authenticator) verifySignature(r *http.Request, key *APIKey) error {
+	timestamp := r.Header.Get(HeaderTimestamp)
+	signature := r.Header.Get(HeaderSignature)
+	if timestamp == "" || signature == "" {
+		return errMissingSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidTimestamp
+	}
+	now := a.now()
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > a.maxSkew || skew < -a.maxSkew {
+		return errInvalidTimestamp
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxRequestBodyBytes+1))
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := SignRequest(key.secret, r.Method, r.URL.RequestURI(), timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errInvalidSignature
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for sig, expiry := range a.seen {
+		if now.After(expiry) {
+			delete(a.seen, sig)
+		}
+	}
+	if _, used := a.seen[signature]; used {
+		return errReplayedRequest
+	}
+	a.seen[signature] = time.Unix(seconds, 0).Add(a.maxSkew)
+
+	return nil
+}
+
+// SignRequest returns the hex HMAC-SHA256 signature a client sends in the
+// X-Signature header. The signed payload is the method, request URI, unix
+// timestamp and hex SHA-256 of the body, separated by newlines.
+func SignRequest(secret, method, requestURI, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", method, requestURI, timestamp, hex.EncodeToString(bodyHash[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requireScope wraps a handler so it only runs for callers holding at least
+// the given scope. Every authenticated request is written to the audit log.
+func (s *Server) requireScope(scope Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.auth.enabled {
+			next(w, r)
+			return
+		}
+
+		r, key, err := s.authenticateRequest(r)
+		if err != nil {
+			s.logger.Warn("API authentication failed",
+				logging.WithString("method", r.Method),
+				logging.WithString("path", r.URL.Path),
+				logging.WithString("remote_addr", r.RemoteAddr),
+				logging.WithError(err),
+			)
+			s.respondError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		if key.Scope < scope {
+			s.respondError(recorder, http.StatusForbidden,
+				fmt.Sprintf("API key %s has %s scope, %s required", key.Name, key.Scope, scope))
+		} else {
+			next(recorder, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+		}
+
+		s.logger.Info("API audit",
+			logging.WithString("api_key", key.Name),
+			logging.WithString("key_scope", key.Scope.String()),
+			logging.WithString("required_scope", scope.String()),
+			logging.WithString("method", r.Method),
+			logging.WithString("path", r.URL.Path),
+			logging.WithString("remote_addr", r.RemoteAddr),
+			logging.WithInt("status", recorder.status),
+		)
+	}
+}
+
+// authenticateRequest authenticates r, or returns the outcome of an earlier
+// authentication of it, along with r carrying that outcome
+func (s *Server) authenticateRequest(r *http.Request) (*http.Request, *APIKey, error) {
+	if result, ok := r.Context().Value(authenticationContextKey{}).(authentication); ok {
+		return r, result.key, result.err
+	}
+	key, err := s.auth.authenticate(r)
+	r = r.WithContext(context.WithValue(r.Context(), authenticationContextKey{}, authentication{key: key, err: err}))
+	return r, key, err
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
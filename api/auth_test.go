@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+)
+
+// recordingLogger keeps log entries so tests can inspect the audit trail
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{}
+}
+
+func (l *recordingLogger) record(level, msg string, fields []zap.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: enc.Fields})
+}
+
+func (l *recordingLogger) Debug(msg string, fields ...zap.Field)   { l.record("debug", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields ...zap.Field)    { l.record("info", msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...zap.Field)    { l.record("warn", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...zap.Field)   { l.record("error", msg, fields) }
+func (l *recordingLogger) Fatal(msg string, fields ...zap.Field)   { l.record("fatal", msg, fields) }
+func (l *recordingLogger) With(fields ...zap.Field) logging.Logger { return l }
+func (l *recordingLogger) Sync() error                             { return nil }
+
+func (l *recordingLogger) find(msg string) []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var found []logEntry
+	for _, entry := range l.entries {
+		if entry.msg == msg {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+func authConfig(keys ...config.APIKeyConfig) *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			Auth: config.AuthConfig{
+				Enabled:          true,
+				SignatureMaxSkew: time.Minute,
+				APIKeys:          keys,
+			},
+		},
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	for _, scope := range []Scope{ScopeRead, ScopeTrade, ScopeAdmin} {
+		parsed, err := ParseScope(scope.String())
+		require.NoError(t, err)
+		assert.Equal(t, scope, parsed)
+	}
+
+	_, err := ParseScope("write")
+	assert.Error(t, err)
+	assert.True(t, ScopeAdmin > ScopeTrade && ScopeTrade > ScopeRead)
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	a := newAPITestWithConfig(t, authConfig(
+		config.APIKeyConfig{Name: "dashboard", Key: "read-key", Scopes: []string{"read"}},
+		config.APIKeyConfig{Name: "bot", Key: "trade-key", Scopes: []string{"read", "trade"}},
+		config.APIKeyConfig{Name: "ops", Key: "admin-key", Scopes: []string{"admin"}},
+	))
+
+	// Health checks stay public
+	rec := httptest.NewRecorder()
+	a.server.router.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	a.do("GET", "/api/v1/portfolio", "/api/v1/portfolio", nil, http.StatusUnauthorized)
+
+	a.header = http.Header{HeaderAPIKey: {"unknown-key"}}
+	a.do("GET", "/api/v1/portfolio", "/api/v1/portfolio", nil, http.StatusUnauthorized)
+
+	// Trade keys may create portfolios but not delete them
+	a.header = http.Header{HeaderAPIKey: {"trade-key"}}
+	a.createPortfolio("main", nil)
+	a.do("DELETE", "/api/v1/portfolio/main", "/api/v1/portfolio/{portfolioId}", nil, http.StatusForbidden)
+
+	// Read-only keys are rejected on every mutating endpoint
+	a.header = http.Header{HeaderAPIKey: {"read-key"}}
+	a.do("GET", "/api/v1/portfolio/main", "/api/v1/portfolio/{portfolioId}", nil, http.StatusOK)
+	a.do("POST", "/api/v1/portfolio", "/api/v1/portfolio", map[string]interface{}{
+		"id": "other", "name": "Other", "riskProfile": map[string]interface{}{"type": "moderate"},
+	}, http.StatusForbidden)
+	a.do("POST", "/api/v1/portfolio/main/assets", "/api/v1/portfolio/{portfolioId}/assets",
+		map[string]interface{}{"symbol": "ETH", "amount": 1, "price": 3500}, http.StatusForbidden)
+	a.do("POST", "/api/v1/portfolio/main/rebalance", "/api/v1/portfolio/{portfolioId}/rebalance", nil, http.StatusForbidden)
+	a.do("POST", "/api/v1/defi/strategies/mean_reversion/execute", "/api/v1/defi/strategies/{strategyId}/execute",
+		map[string]interface{}{"portfolioId": "main"}, http.StatusForbidden)
+	a.do("POST", "/api/v1/agents/arbitrage-1/execute", "/api/v1/agents/{agentId}/execute",
+		map[string]interface{}{"task": "scan"}, http.StatusForbidden)
+	a.do("DELETE", "/api/v1/portfolio/main", "/api/v1/portfolio/{portfolioId}", nil, http.StatusForbidden)
+
+	a.header = http.Header{HeaderAPIKey: {"admin-key"}}
+	a.do("DELETE", "/api/v1/portfolio/main", "/api/v1/portfolio/{portfolioId}", nil, http.StatusNoContent)
+}
+
+func TestSignedRequests(t *testing.T) {
+	a := newAPITestWithConfig(t, authConfig(config.APIKeyConfig{
+		Name: "bot", Key: "signed-key", Secret: "s3cret", Scopes: []string{"trade"},
+	}))
+	now := time.Unix(1_700_000_000, 0)
+	a.server.auth.now = func() time.Time { return now }
+
+	body := []byte(`{"id":"signed","name":"Signed","initialCash":1000,"riskProfile":{"type":"moderate"}}`)
+	send := func(timestamp time.Time, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/portfolio", bytes.NewReader(body))
+		req.Header.Set(HeaderAPIKey, "signed-key")
+		if signature != "" {
+			req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+			req.Header.Set(HeaderSignature, signature)
+		}
+		rec := httptest.NewRecorder()
+		a.server.router.ServeHTTP(rec, req)
+		return rec
+	}
+	sign := func(timestamp time.Time) string {
+		return SignRequest("s3cret", "POST", "/api/v1/portfolio", strconv.FormatInt(timestamp.Unix(), 10), body)
+	}
+
+	rec := send(now, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "keys with a secret must sign requests")
+
+	rec = send(now, SignRequest("wrong", "POST", "/api/v1/portfolio", strconv.FormatInt(now.Unix(), 10), body))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	stale := now.Add(-2 * time.Minute)
+	rec = send(stale, sign(stale))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The body is still readable by the handler after signature verification
+	rec = send(now, sign(now))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created Portfolio
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "signed", created.ID)
+
+	rec = send(now, sign(now))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "replayed signatures are rejected")
+	assert.Contains(t, rec.Body.String(), errReplayedRequest.Error())
+}
+
+func TestAuditLog(t *testing.T) {
+	a := newAPITestWithConfig(t, authConfig(
+		config.APIKeyConfig{Name: "dashboard", Key: "read-key", Scopes: []string{"read"}},
+	))
+	logger := newRecordingLogger()
+	a.server.logger = logger
+
+	a.header = http.Header{HeaderAPIKey: {"read-key"}}
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusOK)
+	a.do("DELETE", "/api/v1/portfolio/main", "/api/v1/portfolio/{portfolioId}", nil, http.StatusForbidden)
+	a.header = nil
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusUnauthorized)
+
+	audit := logger.find("API audit")
+	require.Len(t, audit, 2)
+	assert.Equal(t, "dashboard", audit[0].fields["api_key"])
+	assert.Equal(t, "/api/v1/agents", audit[0].fields["path"])
+	assert.EqualValues(t, http.StatusOK, audit[0].fields["status"])
+	assert.Equal(t, "DELETE", audit[1].fields["method"])
+	assert.Equal(t, "admin", audit[1].fields["required_scope"])
+	assert.EqualValues(t, http.StatusForbidden, audit[1].fields["status"])
+
+	failures := logger.find("API authentication failed")
+	require.Len(t, failures, 1)
+	assert.Equal(t, "warn", failures[0].level)
+}
+
+func TestCORSUsesConfiguredOrigins(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Server.CORS = config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", HeaderAPIKey},
+	}
+	a := newAPITestWithConfig(t, cfg)
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	a.server.router.ServeHTTP(rec, req)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Content-Type, X-API-Key", rec.Header().Get("Access-Control-Allow-Headers"))
+
+	req = httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	a.server.router.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
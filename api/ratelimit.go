@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// rateLimitMiddleware enforces the configured request limits per route and
+// client, rejecting requests over the limit with 429 and Retry-After
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.limits.Enabled() || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		r, client := s.rateLimitClient(r)
+
+		decision := s.limits.Allow(r.Method, route, client)
+		if s.monitor != nil {
+			s.monitor.RecordRateLimit("api", route, decision.Allowed)
+		}
+		ratelimit.SetHeaders(w, decision)
+
+		if !decision.Allowed {
+			s.logger.Warn("API rate limit exceeded",
+				logging.WithString("client", client),
+				logging.WithString("method", r.Method),
+				logging.WithString("route", route),
+				logging.WithInt("retry_after_seconds", decision.RetryAfterSeconds()),
+			)
+			s.respondError(w, http.StatusTooManyRequests,
+				fmt.Sprintf("Rate limit exceeded, retry in %d seconds", decision.RetryAfterSeconds()))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitClient identifies the bucket owner: the API key that
+// authenticates the request, or the peer IP address for anonymous requests
+// and keys that fail authentication, so that rotating bogus keys or
+// signatures does not yield fresh buckets. The returned request carries the
+// authentication outcome for requireScope.
+func (s *Server) rateLimitClient(r *http.Request) (*http.Request, string) {
+	if s.auth.enabled && r.Header.Get(HeaderAPIKey) != "" {
+		var key *APIKey
+		var err error
+		if r, key, err = s.authenticateRequest(r); err == nil {
+			return r, "key:" + key.Name
+		}
+	}
+	return r, "ip:" + ratelimit.ClientIP(r)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+)
+
+func TestRateLimiting(t *testing.T) {
+	cfg := authConfig(
+		config.APIKeyConfig{Name: "dashboard", Key: "read-key", Scopes: []string{"read"}},
+		config.APIKeyConfig{Name: "bot", Key: "trade-key", Scopes: []string{"trade"}},
+		config.APIKeyConfig{Name: "signer", Key: "signer-key", Secret: "s3cret", Scopes: []string{"read"}},
+	)
+	cfg.Server.RateLimit = config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerSecond: 0.01,
+		Burst:             2,
+		Routes: map[string]config.RateLimitRule{
+			"GET /api/v1/agents": {RequestsPerSecond: 0.5, Burst: 1},
+		},
+	}
+	a := newAPITestWithConfig(t, cfg)
+
+	a.header = http.Header{HeaderAPIKey: {"read-key"}}
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusOK)
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusTooManyRequests)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/agents", nil)
+	req.Header.Set(HeaderAPIKey, "read-key")
+	a.server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+
+	// Other routes use the default bucket
+	a.do("GET", "/api/v1/portfolio", "/api/v1/portfolio", nil, http.StatusOK)
+	a.do("GET", "/api/v1/portfolio/missing", "/api/v1/portfolio/{portfolioId}", nil, http.StatusNotFound)
+	a.do("GET", "/api/v1/portfolio", "/api/v1/portfolio", nil, http.StatusTooManyRequests)
+
+	// Each API key has its own buckets
+	a.header = http.Header{HeaderAPIKey: {"trade-key"}}
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusOK)
+
+	// A signing key is limited by its own bucket once the signature checks
+	// out; the rate limiter's check is not mistaken for a replay
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	a.header = http.Header{
+		HeaderAPIKey:    {"signer-key"},
+		HeaderTimestamp: {timestamp},
+		HeaderSignature: {SignRequest("s3cret", "GET", "/api/v1/agents", timestamp, nil)},
+	}
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusOK)
+
+	// Without a valid signature it is limited by IP, like unknown keys, so
+	// rotating keys or signatures does not help
+	a.header = http.Header{HeaderAPIKey: {"signer-key"}, HeaderTimestamp: {timestamp}, HeaderSignature: {"forged"}}
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusUnauthorized)
+	a.header = http.Header{HeaderAPIKey: {"bogus-1"}}
+	a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusTooManyRequests)
+}
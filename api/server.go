@@ -3,21 +3,36 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/agent"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/ratelimit"
 	"github.com/gorilla/mux"
 )
 
+// maxRequestBodyBytes caps the size of JSON request bodies
+const maxRequestBodyBytes = 1 << 20
+
+// validTimeframes lists the timeframes accepted by the market data endpoint
+var validTimeframes = map[string]bool{
+	"1m": true, "5m": true, "15m": true, "1h": true, "4h": true, "1d": true, "1w": true,
+}
+
 // Server represents the API server
 type Server struct {
 	router     *mux.Router
@@ -25,19 +40,36 @@ type Server struct {
 	logger     logging.Logger
 	monitor    *monitoring.Monitor
 	portfolios *portfolio.PortfolioManager
+	market     *market.Data
+	strategies *defi.AdvancedStrategyEngine
+	agents     *agent.Manager
+	cors       config.CORSConfig
+	auth       *authenticator
+	limits     *ratelimit.Policy
 	startTime  time.Time
 	mu         sync.RWMutex
 }
 
-// NewServer creates a new API server
-func NewServer(cfg *config.Config, logger logging.Logger, monitor *monitoring.Monitor, portfolios *portfolio.PortfolioManager) *Server {
+// NewServer creates a new API server. marketData, strategies and agents may be
+// nil, in which case the corresponding endpoints report no data.
+func NewServer(cfg *config.Config, logger logging.Logger, monitor *monitoring.Monitor, portfolios *portfolio.PortfolioManager,
+	marketData *market.Data, strategies *defi.AdvancedStrategyEngine, agents *agent.Manager) *Server {
 	s := &Server{
 		router:     mux.NewRouter(),
 		logger:     logger,
 		monitor:    monitor,
 		portfolios: portfolios,
+		market:     marketData,
+		strategies: strategies,
+		agents:     agents,
+		cors:       cfg.Server.CORS,
+		auth:       newAuthenticator(cfg.Server.Auth),
+		limits:     ratelimit.NewPolicy(cfg.Server.RateLimit),
 		startTime:  time.Now(),
 	}
+	if s.auth.enabled && len(s.auth.keys) == 0 {
+		logger.Warn("API authentication is enabled but no API keys are configured; protected endpoints will reject all requests")
+	}
 
 	s.setupRoutes()
 	return s
@@ -53,33 +85,35 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/docs", s.docsHandler).Methods("GET")
 	s.router.HandleFunc("/api/openapi.yaml", s.openapiHandler).Methods("GET")
 
-	// API v1 routes
+	// API v1 routes. Reads need the read scope, anything that moves funds or
+	// changes state needs trade, and deleting portfolios needs admin.
 	apiV1 := s.router.PathPrefix("/api/v1").Subrouter()
 
-	// Portfolio endpoints
-	apiV1.HandleFunc("/portfolio", s.listPortfolios).Methods("GET")
-	apiV1.HandleFunc("/portfolio", s.createPortfolio).Methods("POST")
-	apiV1.HandleFunc("/portfolio/{portfolioId}", s.getPortfolio).Methods("GET")
-	apiV1.HandleFunc("/portfolio/{portfolioId}", s.deletePortfolio).Methods("DELETE")
-	apiV1.HandleFunc("/portfolio/{portfolioId}/assets", s.getPortfolioAssets).Methods("GET")
-	apiV1.HandleFunc("/portfolio/{portfolioId}/assets", s.addAssetToPortfolio).Methods("POST")
-	apiV1.HandleFunc("/portfolio/{portfolioId}/rebalance", s.rebalancePortfolio).Methods("POST")
-	apiV1.HandleFunc("/portfolio/{portfolioId}/risk", s.getPortfolioRisk).Methods("GET")
+	// Portfolio routes
+	apiV1.HandleFunc("/portfolio", s.requireScope(ScopeRead, s.listPortfolios)).Methods("GET")
+	apiV1.HandleFunc("/portfolio", s.requireScope(ScopeTrade, s.createPortfolio)).Methods("POST")
+	apiV1.HandleFunc("/portfolio/{portfolioId}", s.requireScope(ScopeRead, s.getPortfolio)).Methods("GET")
+	apiV1.HandleFunc("/portfolio/{portfolioId}", s.requireScope(ScopeAdmin, s.deletePortfolio)).Methods("DELETE")
+	apiV1.HandleFunc("/portfolio/{portfolioId}/assets", s.requireScope(ScopeRead, s.getPortfolioAssets)).Methods("GET")
+	apiV1.HandleFunc("/portfolio/{portfolioId}/assets", s.requireScope(ScopeTrade, s.addAssetToPortfolio)).Methods("POST")
+	apiV1.HandleFunc("/portfolio/{portfolioId}/rebalance", s.requireScope(ScopeTrade, s.rebalancePortfolio)).Methods("POST")
+	apiV1.HandleFunc("/portfolio/{portfolioId}/risk", s.requireScope(ScopeRead, s.getPortfolioRisk)).Methods("GET")
 
-	// Market endpoints
-	apiV1.HandleFunc("/market/data", s.getMarketData).Methods("GET")
+	// Market data routes
+	apiV1.HandleFunc("/market/data", s.requireScope(ScopeRead, s.getMarketData)).Methods("GET")
 
-	// DeFi endpoints
-	apiV1.HandleFunc("/defi/strategies", s.listStrategies).Methods("GET")
-	apiV1.HandleFunc("/defi/strategies/{strategyId}/execute", s.executeStrategy).Methods("POST")
+	// DeFi strategy routes
+	apiV1.HandleFunc("/defi/strategies", s.requireScope(ScopeRead, s.listStrategies)).Methods("GET")
+	apiV1.HandleFunc("/defi/strategies/{strategyId}/execute", s.requireScope(ScopeTrade, s.executeStrategy)).Methods("POST")
 
-	// Agent endpoints
-	apiV1.HandleFunc("/agents", s.listAgents).Methods("GET")
-	apiV1.HandleFunc("/agents/{agentId}/execute", s.executeAgent).Methods("POST")
+	// Agent routes
+	apiV1.HandleFunc("/agents", s.requireScope(ScopeRead, s.listAgents)).Methods("GET")
+	apiV1.HandleFunc("/agents/{agentId}/execute", s.requireScope(ScopeTrade, s.executeAgent)).Methods("POST")
 
 	// Middleware
 	s.router.Use(s.loggingMiddleware)
 	s.router.Use(s.corsMiddleware)
+	s.router.Use(s.rateLimitMiddleware)
 }
 
 // Start starts the API server
@@ -163,28 +197,13 @@ func (s *Server) openapiHandler(w http.ResponseWriter, r *http.Request) {
 // Portfolio handlers
 func (s *Server) listPortfolios(w http.ResponseWriter, r *http.Request) {
 	portfolios := s.portfolios.ListPortfolios()
+	sort.Slice(portfolios, func(i, j int) bool {
+		return portfolios[i].ID < portfolios[j].ID
+	})
 
-	// Convert to API response format
-	var response []Portfolio
+	response := make([]Portfolio, 0, len(portfolios))
 	for _, p := range portfolios {
-		stats := p.GetPortfolioStats()
-		response = append(response, Portfolio{
-			ID:          p.ID,
-			Name:        p.Name,
-			TotalValue:  toFloat(stats.TotalValue),
-			CashBalance: toFloat(stats.CashBalance),
-			RiskProfile: RiskProfile{
-				Type:              string(p.RiskProfile.Type),
-				MaxDrawdown:       p.RiskProfile.MaxDrawdown,
-				MaxPositionSize:   p.RiskProfile.MaxPositionSize,
-				MaxLeverage:       p.RiskProfile.MaxLeverage,
-				TargetAllocations: p.RiskProfile.TargetAllocations,
-			},
-			Assets:        []Asset{},    // Would need conversion
-			Positions:     []Position{}, // Would need conversion
-			LastRebalance: p.LastRebalance,
-			CreatedAt:     time.Now(), // Would need to track creation time
-		})
+		response = append(response, toAPIPortfolio(p))
 	}
 
 	s.respondJSON(w, http.StatusOK, response)
@@ -192,8 +211,11 @@ func (s *Server) listPortfolios(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) createPortfolio(w http.ResponseWriter, r *http.Request) {
 	var req CreatePortfolioRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
@@ -206,84 +228,352 @@ func (s *Server) createPortfolio(w http.ResponseWriter, r *http.Request) {
 		TargetAllocations: req.RiskProfile.TargetAllocations,
 	}
 
-	p, err := s.portfolios.CreatePortfolio(req.ID, req.Name, riskProfile)
+	p, err := s.portfolios.CreatePortfolio(req.ID, req.Name, riskProfile, big.NewFloat(req.InitialCash))
 	if err != nil {
-		s.respondError(w, http.StatusBadRequest, err.Error())
+		s.respondDomainError(w, err)
 		return
 	}
 
-	// Set initial cash if provided
-	if req.InitialCash > 0 {
-		p.CashBalance = big.NewFloat(req.InitialCash)
+	s.respondJSON(w, http.StatusCreated, toAPIPortfolio(p))
+}
+
+func (s *Server) getPortfolio(w http.ResponseWriter, r *http.Request) {
+	p, err := s.portfolios.GetPortfolio(mux.Vars(r)["portfolioId"])
+	if err != nil {
+		s.respondDomainError(w, err)
+		return
 	}
 
-	stats := p.GetPortfolioStats()
-	response := Portfolio{
-		ID:          p.ID,
-		Name:        p.Name,
-		TotalValue:  toFloat(stats.TotalValue),
-		CashBalance: toFloat(stats.CashBalance),
-		RiskProfile: RiskProfile{
-			Type:              string(p.RiskProfile.Type),
-			MaxDrawdown:       p.RiskProfile.MaxDrawdown,
-			MaxPositionSize:   p.RiskProfile.MaxPositionSize,
-			MaxLeverage:       p.RiskProfile.MaxLeverage,
-			TargetAllocations: p.RiskProfile.TargetAllocations,
-		},
-		Assets:        []Asset{},
-		Positions:     []Position{},
-		LastRebalance: p.LastRebalance,
-		CreatedAt:     time.Now(),
+	s.respondJSON(w, http.StatusOK, toAPIPortfolio(p))
+}
+
+func (s *Server) deletePortfolio(w http.ResponseWriter, r *http.Request) {
+	if err := s.portfolios.DeletePortfolio(mux.Vars(r)["portfolioId"]); err != nil {
+		s.respondDomainError(w, err)
+		return
 	}
 
-	s.respondJSON(w, http.StatusCreated, response)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) getPortfolio(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	portfolioID := vars["portfolioId"]
+func (s *Server) getPortfolioAssets(w http.ResponseWriter, r *http.Request) {
+	p, err := s.portfolios.GetPortfolio(mux.Vars(r)["portfolioId"])
+	if err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	allocation := p.GetAssetAllocation()
+	assets := p.GetAssets()
+	response := make([]Asset, 0, len(assets))
+	for _, asset := range assets {
+		response = append(response, toAPIAsset(asset, allocation))
+	}
+
+	s.respondJSON(w, http.StatusOK, response)
+}
 
-	p, err := s.portfolios.GetPortfolio(portfolioID)
+func (s *Server) addAssetToPortfolio(w http.ResponseWriter, r *http.Request) {
+	p, err := s.portfolios.GetPortfolio(mux.Vars(r)["portfolioId"])
 	if err != nil {
-		s.respondError(w, http.StatusNotFound, "Portfolio not found")
+		s.respondDomainError(w, err)
 		return
 	}
 
-	stats := p.GetPortfolioStats()
-	response := Portfolio{
-		ID:          p.ID,
-		Name:        p.Name,
-		TotalValue:  toFloat(stats.TotalValue),
-		CashBalance: toFloat(stats.CashBalance),
-		RiskProfile: RiskProfile{
-			Type:              string(p.RiskProfile.Type),
-			MaxDrawdown:       p.RiskProfile.MaxDrawdown,
-			MaxPositionSize:   p.RiskProfile.MaxPositionSize,
-			MaxLeverage:       p.RiskProfile.MaxLeverage,
-			TargetAllocations: p.RiskProfile.TargetAllocations,
-		},
-		Assets:        []Asset{},    // Would need conversion
-		Positions:     []Position{}, // Would need conversion
-		LastRebalance: p.LastRebalance,
-		CreatedAt:     time.Now(), // Would need to track creation time
+	var req AddAssetRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	amount := big.NewFloat(*req.Amount)
+	price := big.NewFloat(*req.PriceUSD)
+	asset := &portfolio.Asset{
+		Symbol:     strings.ToUpper(req.Symbol),
+		Name:       req.Name,
+		Amount:     amount,
+		PriceUSD:   price,
+		ValueUSD:   new(big.Float).Mul(amount, price),
+		APY:        req.APY,
+		RiskScore:  req.RiskScore,
+		LastUpdate: time.Now().UTC(),
+	}
+	if err := p.AddAsset(asset); err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	s.respondJSON(w, http.StatusCreated, toAPIAsset(asset, p.GetAssetAllocation()))
+}
+
+func (s *Server) rebalancePortfolio(w http.ResponseWriter, r *http.Request) {
+	portfolioID := mux.Vars(r)["portfolioId"]
+
+	actions, err := s.portfolios.RebalancePortfolio(r.Context(), portfolioID)
+	if err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	response := RebalanceResponse{
+		PortfolioID: portfolioID,
+		Actions:     make([]RebalanceAction, 0, len(actions)),
+		Timestamp:   time.Now().UTC(),
+	}
+	for _, action := range actions {
+		response.Actions = append(response.Actions, RebalanceAction{
+			Asset:  action.Asset,
+			Action: string(action.Action),
+			Amount: toFloat(action.Amount),
+			Reason: action.Reason,
+		})
 	}
 
 	s.respondJSON(w, http.StatusOK, response)
 }
 
-func (s *Server) deletePortfolio(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	portfolioID := vars["portfolioId"]
+func (s *Server) getPortfolioRisk(w http.ResponseWriter, r *http.Request) {
+	assessment, err := s.portfolios.RiskManager(mux.Vars(r)["portfolioId"])
+	if err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, RiskAssessment{
+		PortfolioID:     assessment.PortfolioID,
+		TotalRiskScore:  assessment.TotalRiskScore,
+		MaxDrawdown:     assessment.MaxDrawdown,
+		Volatility:      assessment.Volatility,
+		SharpeRatio:     assessment.SharpeRatio,
+		Concentration:   assessment.Concentration,
+		RiskLevel:       string(assessment.RiskLevel),
+		Recommendations: nonNilStrings(assessment.Recommendations),
+		AssessmentTime:  assessment.AssessmentTime.UTC(),
+	})
+}
 
-	if err := s.portfolios.DeletePortfolio(portfolioID); err != nil {
-		s.respondError(w, http.StatusNotFound, "Portfolio not found")
+// Market handlers
+func (s *Server) getMarketData(w http.ResponseWriter, r *http.Request) {
+	if s.market == nil {
+		s.respondError(w, http.StatusServiceUnavailable, "Market data is not available")
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	query := r.URL.Query()
+	symbols := parseSymbols(query["symbols"])
+	if len(symbols) == 0 {
+		s.respondError(w, http.StatusBadRequest, "Query parameter 'symbols' is required")
+		return
+	}
+
+	timeframe := query.Get("timeframe")
+	if timeframe == "" {
+		timeframe = "1h"
+	}
+	if !validTimeframes[timeframe] {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid timeframe %q", timeframe))
+		return
+	}
+
+	updatedAt := s.market.GetLastUpdate().UTC()
+	response := make([]MarketData, 0, len(symbols))
+	for _, symbol := range symbols {
+		price, ok := s.market.GetPrice(symbol)
+		if !ok {
+			s.respondError(w, http.StatusNotFound, fmt.Sprintf("No market data for symbol %s", symbol))
+			return
+		}
+		response = append(response, MarketData{
+			Symbol:    price.Symbol,
+			Price:     price.Price,
+			Change24h: price.Change24h,
+			Volume24h: price.Volume,
+			Timestamp: updatedAt,
+		})
+	}
+
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+// DeFi handlers
+func (s *Server) listStrategies(w http.ResponseWriter, r *http.Request) {
+	if s.strategies == nil {
+		s.respondJSON(w, http.StatusOK, []Strategy{})
+		return
+	}
+
+	ids := make([]string, 0, len(s.strategies.Strategies))
+	for id := range s.strategies.Strategies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	response := make([]Strategy, 0, len(ids))
+	for _, id := range ids {
+		response = append(response, toAPIStrategy(s.strategies.Strategies[id]))
+	}
+
+	s.respondJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) executeStrategy(w http.ResponseWriter, r *http.Request) {
+	strategyID := mux.Vars(r)["strategyId"]
+
+	var strategy *defi.AdvancedTradingStrategy
+	if s.strategies != nil {
+		strategy = s.strategies.Strategies[strategyID]
+	}
+	if strategy == nil {
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Strategy %s not found", strategyID))
+		return
+	}
+
+	var req ExecuteStrategyRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if !strategy.IsActive {
+		s.respondError(w, http.StatusConflict, fmt.Sprintf("Strategy %s is not active", strategyID))
+		return
+	}
+
+	p, err := s.portfolios.GetPortfolio(req.PortfolioID)
+	if err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	capital := *req.Capital
+	cash := toFloat(p.GetPortfolioStats().CashBalance)
+	if capital > cash {
+		s.respondError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("Capital %.2f exceeds portfolio cash balance %.2f", capital, cash))
+		return
+	}
+
+	if len(strategy.Parameters.TargetAssets) == 0 {
+		s.respondError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Strategy %s has no target assets", strategyID))
+		return
+	}
+	asset := strategy.Parameters.TargetAssets[0]
+
+	var price float64
+	if s.market != nil {
+		if data, ok := s.market.GetPrice(asset); ok {
+			price = data.Price
+		}
+	}
+	if price <= 0 {
+		s.respondError(w, http.StatusUnprocessableEntity, fmt.Sprintf("No market price for %s", asset))
+		return
+	}
+
+	notional := s.strategies.CalculatePositionSize(strategy, capital)
+	if notional <= 0 {
+		s.respondError(w, http.StatusUnprocessableEntity, "Strategy sized the position to zero")
+		return
+	}
+
+	executedAt := time.Now().UTC()
+	position := &portfolio.Position{
+		ID:           fmt.Sprintf("%s-%d", strategy.ID, executedAt.UnixNano()),
+		Asset:        asset,
+		Type:         portfolio.PositionLong,
+		Size:         big.NewFloat(notional / price),
+		EntryPrice:   big.NewFloat(price),
+		CurrentPrice: big.NewFloat(price),
+		Pnl:          big.NewFloat(0),
+		Leverage:     1,
+	}
+	if err := p.BuyPosition(position, big.NewFloat(notional)); err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	risk := strategy.RiskParameters
+	s.respondJSON(w, http.StatusOK, StrategyExecutionResult{
+		StrategyID:      strategy.ID,
+		PortfolioID:     p.ID,
+		ExecutedAt:      executedAt,
+		PositionsOpened: []Position{toAPIPosition(position)},
+		EstimatedReturn: notional * risk.TakeProfitPercent,
+		RiskMetrics: map[string]interface{}{
+			"positionValue":   notional,
+			"stopLossPrice":   price * (1 - risk.StopLossPercent),
+			"takeProfitPrice": price * (1 + risk.TakeProfitPercent),
+			"maxLoss":         notional * risk.StopLossPercent,
+			"valueAtRisk":     risk.ValueAtRisk,
+		},
+	})
+}
+
+// Agent handlers
+func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
+	if s.agents == nil {
+		s.respondJSON(w, http.StatusOK, []Agent{})
+		return
+	}
+
+	agents := s.agents.GetAgents()
+	response := make([]Agent, 0, len(agents))
+	for _, a := range agents {
+		response = append(response, toAPIAgent(a))
+	}
+
+	s.respondJSON(w, http.StatusOK, response)
 }
 
-// Other handlers would be implemented similarly...
+func (s *Server) executeAgent(w http.ResponseWriter, r *http.Request) {
+	agentID := mux.Vars(r)["agentId"]
+	if s.agents == nil {
+		s.respondError(w, http.StatusNotFound, fmt.Sprintf("Agent %s not found", agentID))
+		return
+	}
+
+	var req ExecuteAgentRequest
+	if !s.decodeJSON(w, r, &req) {
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	parameters := req.Parameters
+	if req.PortfolioID != "" {
+		if _, err := s.portfolios.GetPortfolio(req.PortfolioID); err != nil {
+			s.respondDomainError(w, err)
+			return
+		}
+		if parameters == nil {
+			parameters = make(map[string]interface{})
+		}
+		parameters["portfolioId"] = req.PortfolioID
+	}
+
+	result, err := s.agents.ExecuteTask(agentID, req.Task, parameters)
+	if err != nil {
+		s.respondDomainError(w, err)
+		return
+	}
+
+	s.respondJSON(w, http.StatusOK, AgentExecutionResult{
+		AgentID:      result.AgentID,
+		Task:         result.Task,
+		ExecutedAt:   result.ExecutedAt.UTC(),
+		Result:       map[string]interface{}{"output": result.Output},
+		ActionsTaken: nonNilStrings(result.ActionsTaken),
+		Success:      true,
+	})
+}
 
 // Helper methods
 func (s *Server) respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -305,6 +595,34 @@ func (s *Server) respondError(w http.ResponseWriter, status int, message string)
 	})
 }
 
+// respondDomainError maps errors from the portfolio and agent managers to HTTP statuses
+func (s *Server) respondDomainError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, portfolio.ErrPortfolioNotFound), errors.Is(err, agent.ErrAgentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, portfolio.ErrPortfolioExists), errors.Is(err, portfolio.ErrAssetExists),
+		errors.Is(err, agent.ErrAgentInactive):
+		status = http.StatusConflict
+	case errors.Is(err, portfolio.ErrNoTargetAllocations), errors.Is(err, portfolio.ErrInsufficientCash):
+		status = http.StatusUnprocessableEntity
+	default:
+		s.logger.Error("API request failed", logging.WithError(err))
+	}
+
+	s.respondError(w, status, err.Error())
+}
+
+// decodeJSON decodes a request body, responding with 400 when it is not valid JSON
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		s.respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -319,11 +637,27 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// corsMiddleware applies the configured CORS policy. Only configured origins
+// are echoed back; "*" must be listed explicitly to allow any origin.
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if origin := r.Header.Get("Origin"); origin != "" {
+			for _, allowed := range s.cors.AllowedOrigins {
+				if allowed == "*" || allowed == origin {
+					w.Header().Set("Access-Control-Allow-Origin", allowed)
+					if allowed != "*" {
+						w.Header().Add("Vary", "Origin")
+					}
+					break
+				}
+			}
+		}
+		if len(s.cors.AllowedMethods) > 0 {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(s.cors.AllowedMethods, ", "))
+		}
+		if len(s.cors.AllowedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(s.cors.AllowedHeaders, ", "))
+		}
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -343,6 +677,142 @@ func toFloat(bf *big.Float) float64 {
 	return f
 }
 
+// nonNilStrings returns an empty slice in place of nil so arrays encode as []
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// parseSymbols accepts both repeated and comma-separated symbols query values
+func parseSymbols(values []string) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, symbol := range strings.Split(value, ",") {
+			symbol = strings.ToUpper(strings.TrimSpace(symbol))
+			if symbol == "" || seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+func toAPIPortfolio(p *portfolio.Portfolio) Portfolio {
+	stats := p.GetPortfolioStats()
+
+	assets := make([]Asset, 0, stats.AssetCount)
+	for _, asset := range p.GetAssets() {
+		assets = append(assets, toAPIAsset(asset, stats.AssetAllocation))
+	}
+
+	positions := make([]Position, 0, stats.TotalPositions)
+	for _, position := range p.GetPositions() {
+		positions = append(positions, toAPIPosition(position))
+	}
+
+	return Portfolio{
+		ID:          p.ID,
+		Name:        p.Name,
+		TotalValue:  toFloat(stats.TotalValue),
+		CashBalance: toFloat(stats.CashBalance),
+		RiskProfile: RiskProfile{
+			Type:              string(p.RiskProfile.Type),
+			MaxDrawdown:       p.RiskProfile.MaxDrawdown,
+			MaxPositionSize:   p.RiskProfile.MaxPositionSize,
+			MaxLeverage:       p.RiskProfile.MaxLeverage,
+			TargetAllocations: p.RiskProfile.TargetAllocations,
+		},
+		Assets:        assets,
+		Positions:     positions,
+		LastRebalance: p.LastRebalance.UTC(),
+		CreatedAt:     p.CreatedAt().UTC(),
+	}
+}
+
+func toAPIAsset(asset *portfolio.Asset, allocation map[string]float64) Asset {
+	return Asset{
+		Symbol:     asset.Symbol,
+		Name:       asset.Name,
+		Amount:     toFloat(asset.Amount),
+		ValueUSD:   toFloat(asset.ValueUSD),
+		PriceUSD:   toFloat(asset.PriceUSD),
+		Allocation: allocation[asset.Symbol],
+		APY:        asset.APY,
+		RiskScore:  asset.RiskScore,
+		LastUpdate: asset.LastUpdate.UTC(),
+	}
+}
+
+func toAPIPosition(position *portfolio.Position) Position {
+	result := Position{
+		ID:           position.ID,
+		Asset:        position.Asset,
+		Type:         string(position.Type),
+		Size:         toFloat(position.Size),
+		EntryPrice:   toFloat(position.EntryPrice),
+		CurrentPrice: toFloat(position.CurrentPrice),
+		Pnl:          toFloat(position.Pnl),
+		PnlPercent:   position.PnlPercent,
+		Leverage:     position.Leverage,
+		Status:       string(position.Status),
+		OpenedAt:     position.OpenedAt.UTC(),
+	}
+	if position.ClosedAt != nil {
+		closedAt := position.ClosedAt.UTC()
+		result.ClosedAt = &closedAt
+	}
+	return result
+}
+
+func toAPIStrategy(strategy *defi.AdvancedTradingStrategy) Strategy {
+	// Bucket the tolerated drawdown into the spec's risk levels
+	riskLevel := "high"
+	switch maxDrawdown := strategy.RiskParameters.MaxDrawdown; {
+	case maxDrawdown <= 0.10:
+		riskLevel = "low"
+	case maxDrawdown <= 0.20:
+		riskLevel = "medium"
+	}
+
+	return Strategy{
+		ID:             strategy.ID,
+		Name:           strategy.Name,
+		Description:    strategy.Description,
+		RiskLevel:      riskLevel,
+		ExpectedReturn: strategy.RiskParameters.TakeProfitPercent,
+		MaxDrawdown:    strategy.RiskParameters.MaxDrawdown,
+		Parameters:     strategy.Parameters,
+	}
+}
+
+func toAPIAgent(a agent.Agent) Agent {
+	status := "error"
+	switch strings.ToLower(a.Status) {
+	case "active":
+		status = "active"
+	case "idle", "inactive", "stopped":
+		status = "inactive"
+	}
+
+	result := Agent{
+		ID:           a.ID,
+		Name:         a.Name,
+		Description:  a.Description,
+		Capabilities: nonNilStrings(a.Capabilities),
+		Status:       status,
+	}
+	if !a.LastActive.IsZero() {
+		lastActive := a.LastActive.UTC()
+		result.LastActive = &lastActive
+	}
+	return result
+}
+
 // API request/response types
 // These types match the OpenAPI specification
 
@@ -400,72 +870,113 @@ type Asset struct {
 }
 
 type Position struct {
-	ID           string    `json:"id"`
-	Asset        string    `json:"asset"`
-	Type         string    `json:"type"`
-	Size         float64   `json:"size"`
-	EntryPrice   float64   `json:"entryPrice"`
-	CurrentPrice float64   `json:"currentPrice,omitempty"`
-	Pnl          float64   `json:"pnl,omitempty"`
-	PnlPercent   float64   `json:"pnlPercent,omitempty"`
-	Leverage     float64   `json:"leverage,omitempty"`
-	Status       string    `json:"status,omitempty"`
-	OpenedAt     time.Time `json:"openedAt,omitempty"`
-	ClosedAt     time.Time `json:"closedAt,omitempty"`
-}
-
-// Placeholder handlers for unimplemented endpoints
-func (s *Server) getPortfolioAssets(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, []Asset{})
-}
-
-func (s *Server) addAssetToPortfolio(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusCreated, Asset{})
-}
-
-func (s *Server) rebalancePortfolio(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"portfolioId": mux.Vars(r)["portfolioId"],
-		"actions":     []interface{}{},
-		"timestamp":   time.Now(),
-	})
-}
-
-func (s *Server) getPortfolioRisk(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"portfolioId":     mux.Vars(r)["portfolioId"],
-		"totalRiskScore":  0.3,
-		"riskLevel":       "medium",
-		"recommendations": []string{"Portfolio is well-diversified"},
-		"assessmentTime":  time.Now(),
-	})
-}
-
-func (s *Server) getMarketData(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, []interface{}{})
-}
-
-func (s *Server) listStrategies(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, []interface{}{})
-}
-
-func (s *Server) executeStrategy(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"strategyId": mux.Vars(r)["strategyId"],
-		"executedAt": time.Now(),
-		"success":    true,
-	})
-}
-
-func (s *Server) listAgents(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, []interface{}{})
+	ID           string     `json:"id"`
+	Asset        string     `json:"asset"`
+	Type         string     `json:"type"`
+	Size         float64    `json:"size"`
+	EntryPrice   float64    `json:"entryPrice"`
+	CurrentPrice float64    `json:"currentPrice,omitempty"`
+	Pnl          float64    `json:"pnl,omitempty"`
+	PnlPercent   float64    `json:"pnlPercent,omitempty"`
+	Leverage     float64    `json:"leverage,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	OpenedAt     time.Time  `json:"openedAt,omitempty"`
+	ClosedAt     *time.Time `json:"closedAt,omitempty"`
+}
+
+// AddAssetRequest is the body of POST /portfolio/{portfolioId}/assets; required
+// numeric fields are pointers so a missing value can be told apart from zero
+type AddAssetRequest struct {
+	Symbol    string   `json:"symbol"`
+	Name      string   `json:"name"`
+	Amount    *float64 `json:"amount"`
+	PriceUSD  *float64 `json:"priceUSD"`
+	APY       float64  `json:"apy,omitempty"`
+	RiskScore float64  `json:"riskScore,omitempty"`
+}
+
+type RebalanceResponse struct {
+	PortfolioID string            `json:"portfolioId"`
+	Actions     []RebalanceAction `json:"actions"`
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+type RebalanceAction struct {
+	Asset  string  `json:"asset"`
+	Action string  `json:"action"`
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+type RiskAssessment struct {
+	PortfolioID     string    `json:"portfolioId"`
+	TotalRiskScore  float64   `json:"totalRiskScore"`
+	MaxDrawdown     float64   `json:"maxDrawdown"`
+	Volatility      float64   `json:"volatility"`
+	SharpeRatio     float64   `json:"sharpeRatio"`
+	Concentration   float64   `json:"concentration"`
+	RiskLevel       string    `json:"riskLevel"`
+	Recommendations []string  `json:"recommendations"`
+	AssessmentTime  time.Time `json:"assessmentTime"`
+}
+
+type MarketData struct {
+	Symbol    string    `json:"symbol"`
+	Price     float64   `json:"price"`
+	Change24h float64   `json:"change24h"`
+	Volume24h float64   `json:"volume24h"`
+	MarketCap float64   `json:"marketCap,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
-func (s *Server) executeAgent(w http.ResponseWriter, r *http.Request) {
-	s.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"agentId":      mux.Vars(r)["agentId"],
-		"executedAt":   time.Now(),
-		"success":      true,
-		"actionsTaken": []string{"Task completed successfully"},
-	})
+type Strategy struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	RiskLevel      string      `json:"riskLevel"`
+	MinCapital     float64     `json:"minCapital,omitempty"`
+	MaxCapital     float64     `json:"maxCapital,omitempty"`
+	ExpectedReturn float64     `json:"expectedReturn"`
+	MaxDrawdown    float64     `json:"maxDrawdown"`
+	Parameters     interface{} `json:"parameters"`
+}
+
+// ExecuteStrategyRequest is the body of POST /defi/strategies/{strategyId}/execute
+type ExecuteStrategyRequest struct {
+	PortfolioID string                 `json:"portfolioId"`
+	Capital     *float64               `json:"capital"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type StrategyExecutionResult struct {
+	StrategyID      string                 `json:"strategyId"`
+	PortfolioID     string                 `json:"portfolioId"`
+	ExecutedAt      time.Time              `json:"executedAt"`
+	PositionsOpened []Position             `json:"positionsOpened"`
+	EstimatedReturn float64                `json:"estimatedReturn"`
+	RiskMetrics     map[string]interface{} `json:"riskMetrics"`
+}
+
+type Agent struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Capabilities []string   `json:"capabilities"`
+	Status       string     `json:"status"`
+	LastActive   *time.Time `json:"lastActive,omitempty"`
+}
+
+type ExecuteAgentRequest struct {
+	Task        string                 `json:"task"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	PortfolioID string                 `json:"portfolioId,omitempty"`
+}
+
+type AgentExecutionResult struct {
+	AgentID      string                 `json:"agentId"`
+	Task         string                 `json:"task"`
+	ExecutedAt   time.Time              `json:"executedAt"`
+	Result       map[string]interface{} `json:"result"`
+	ActionsTaken []string               `json:"actionsTaken"`
+	Success      bool                   `json:"success"`
 }
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/agent"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+)
+
+// openAPISpec validates JSON responses against the schemas in openapi.yaml
+type openAPISpec struct {
+	doc map[string]interface{}
+}
+
+func loadSpec(t *testing.T) *openAPISpec {
+	data, err := os.ReadFile("openapi.yaml")
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	return &openAPISpec{doc: doc}
+}
+
+func (s *openAPISpec) lookup(keys ...string) map[string]interface{} {
+	node := s.doc
+	for _, key := range keys {
+		next, ok := node[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// responseSchema returns the schema documented for an operation's response
+// status, following references to shared component responses
+func (s *openAPISpec) responseSchema(path, method string, status int) map[string]interface{} {
+	response := s.lookup("paths", path, strings.ToLower(method), "responses", strconv.Itoa(status))
+	if ref, ok := response["$ref"].(string); ok {
+		response = s.lookup("components", "responses", strings.TrimPrefix(ref, "#/components/responses/"))
+	}
+	content, _ := response["content"].(map[string]interface{})
+	mediaType, _ := content["application/json"].(map[string]interface{})
+	schema, _ := mediaType["schema"].(map[string]interface{})
+	return schema
+}
+
+func (s *openAPISpec) resolve(schema map[string]interface{}) map[string]interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		return s.lookup("components", "schemas", strings.TrimPrefix(ref, "#/components/schemas/"))
+	}
+	return schema
+}
+
+func (s *openAPISpec) validate(t *testing.T, schema map[string]interface{}, value interface{}, path string) {
+	t.Helper()
+	schema = s.resolve(schema)
+	require.NotNil(t, schema, "unresolved schema at %s", path)
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		require.True(t, ok, "%s: expected object, got %T", path, value)
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, field := range required {
+				assert.Contains(t, object, field, "%s: missing required field", path)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for key, child := range object {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				s.validate(t, property, child, path+"."+key)
+			} else if additional != nil {
+				s.validate(t, additional, child, path+"."+key)
+			} else if properties != nil {
+				t.Errorf("%s: unexpected field %q", path, key)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		require.True(t, ok, "%s: expected array, got %T", path, value)
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		for i, item := range items {
+			s.validate(t, itemSchema, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	case "string":
+		str, ok := value.(string)
+		require.True(t, ok, "%s: expected string, got %T", path, value)
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			assert.Contains(t, enum, str, "%s: value not in enum", path)
+		}
+		if schema["format"] == "date-time" {
+			_, err := time.Parse(time.RFC3339, str)
+			assert.NoError(t, err, "%s: invalid date-time", path)
+		}
+	case "number":
+		_, ok := value.(float64)
+		assert.True(t, ok, "%s: expected number, got %T", path, value)
+	case "integer":
+		number, ok := value.(float64)
+		assert.True(t, ok && number == math.Trunc(number), "%s: expected integer, got %v", path, value)
+	case "boolean":
+		_, ok := value.(bool)
+		assert.True(t, ok, "%s: expected boolean, got %T", path, value)
+	}
+}
+
+type apiTest struct {
+	t          *testing.T
+	spec       *openAPISpec
+	server     *Server
+	portfolios *portfolio.PortfolioManager
+	agents     *agent.Manager
+	strategies *defi.AdvancedStrategyEngine
+	header     http.Header // sent with every request
+}
+
+func newAPITest(t *testing.T) *apiTest {
+	return newAPITestWithConfig(t, &config.Config{})
+}
+
+func newAPITestWithConfig(t *testing.T, cfg *config.Config) *apiTest {
+	logger, err := logging.NewLogger(&config.LoggingConfig{
+		Level:  "error",
+		Format: "console",
+		Output: "stdout",
+	})
+	require.NoError(t, err)
+	monitor := monitoring.NewMonitor(&config.MonitoringConfig{Enabled: false}, logger)
+
+	marketData := &market.Data{
+		Prices: map[string]market.PriceData{
+			"ETH": {Symbol: "ETH", Price: 3500, Change24h: 2.5, Volume: 1.2e9},
+			"BTC": {Symbol: "BTC", Price: 65000, Change24h: 1.8, Volume: 25e9},
+		},
+		History: market.NewPriceHistory(market.DefaultHistorySize),
+	}
+
+	strategies := defi.NewAdvancedStrategyEngine()
+	meanReversion := defi.MeanReversionStrategy()
+	strategies.Strategies[meanReversion.ID] = meanReversion
+	paused := defi.TrendFollowingStrategy()
+	paused.IsActive = false
+	strategies.Strategies[paused.ID] = paused
+
+	portfolios := portfolio.NewPortfolioManager(logger, monitor)
+	agents := agent.NewManager()
+
+	return &apiTest{
+		t:          t,
+		spec:       loadSpec(t),
+		server:     NewServer(cfg, logger, monitor, portfolios, marketData, strategies, agents),
+		portfolios: portfolios,
+		agents:     agents,
+		strategies: strategies,
+	}
+}
+
+// do sends a request, checks the response against the documented schema for
+// route and returns the decoded body
+func (a *apiTest) do(method, target, route string, body interface{}, wantStatus int) interface{} {
+	a.t.Helper()
+
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(a.t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range a.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	rec := httptest.NewRecorder()
+	a.server.router.ServeHTTP(rec, req)
+
+	require.Equal(a.t, wantStatus, rec.Code, "%s %s: %s", method, target, rec.Body.String())
+	if rec.Code == http.StatusNoContent {
+		return nil
+	}
+
+	var decoded interface{}
+	require.NoError(a.t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+
+	schema := a.spec.responseSchema(route, method, wantStatus)
+	require.NotNil(a.t, schema, "%s %s: status %d is not documented", method, route, wantStatus)
+	a.spec.validate(a.t, schema, decoded, "response")
+
+	return decoded
+}
+
+func (a *apiTest) createPortfolio(id string, targets map[string]float64) {
+	a.t.Helper()
+	a.do("POST", "/api/v1/portfolio", "/api/v1/portfolio", map[string]interface{}{
+		"id":          id,
+		"name":        "Test " + id,
+		"initialCash": 10000,
+		"riskProfile": map[string]interface{}{
+			"type":              "moderate",
+			"targetAllocations": targets,
+		},
+	}, http.StatusCreated)
+}
+
+func TestPortfolioEndpoints(t *testing.T) {
+	a := newAPITest(t)
+	const route = "/api/v1/portfolio"
+
+	created := a.do("POST", route, route, map[string]interface{}{
+		"id":          "main",
+		"name":        "Main",
+		"initialCash": 5000,
+		"riskProfile": map[string]interface{}{"type": "moderate"},
+	}, http.StatusCreated).(map[string]interface{})
+	assert.Equal(t, 5000.0, created["cashBalance"])
+
+	// Duplicate IDs conflict, malformed bodies are rejected before validation
+	a.do("POST", route, route, map[string]interface{}{
+		"id": "main", "name": "Again", "riskProfile": map[string]interface{}{"type": "moderate"},
+	}, http.StatusConflict)
+	a.do("POST", route, route, "{not json", http.StatusBadRequest)
+	a.do("POST", route, route, map[string]interface{}{"id": "x", "name": "X"}, http.StatusUnprocessableEntity)
+	a.do("POST", route, route, map[string]interface{}{
+		"id": "x", "name": "X", "riskProfile": map[string]interface{}{"type": "reckless"},
+	}, http.StatusUnprocessableEntity)
+	a.do("POST", route, route, map[string]interface{}{
+		"id": "x", "name": "X", "riskProfile": map[string]interface{}{
+			"type": "moderate", "targetAllocations": map[string]float64{"BTC": 70, "ETH": 50},
+		},
+	}, http.StatusUnprocessableEntity)
+
+	list := a.do("GET", route, route, nil, http.StatusOK).([]interface{})
+	assert.Len(t, list, 1)
+
+	a.do("GET", route+"/main", route+"/{portfolioId}", nil, http.StatusOK)
+	a.do("GET", route+"/missing", route+"/{portfolioId}", nil, http.StatusNotFound)
+	a.do("DELETE", route+"/main", route+"/{portfolioId}", nil, http.StatusNoContent)
+	a.do("DELETE", route+"/main", route+"/{portfolioId}", nil, http.StatusNotFound)
+}
+
+func TestAssetEndpoints(t *testing.T) {
+	a := newAPITest(t)
+	a.createPortfolio("main", nil)
+	const route = "/api/v1/portfolio/{portfolioId}/assets"
+
+	asset := a.do("POST", "/api/v1/portfolio/main/assets", route, map[string]interface{}{
+		"symbol": "btc", "name": "Bitcoin", "amount": 0.1, "priceUSD": 50000,
+	}, http.StatusCreated).(map[string]interface{})
+	assert.Equal(t, "BTC", asset["symbol"])
+	assert.InDelta(t, 5000.0, asset["valueUSD"], 1e-6)
+	assert.InDelta(t, 33.333, asset["allocation"], 1e-3)
+
+	a.do("POST", "/api/v1/portfolio/main/assets", route, map[string]interface{}{
+		"symbol": "BTC", "name": "Bitcoin", "amount": 1, "priceUSD": 50000,
+	}, http.StatusConflict)
+	a.do("POST", "/api/v1/portfolio/main/assets", route, map[string]interface{}{
+		"symbol": "ETH", "name": "Ether", "amount": 1,
+	}, http.StatusUnprocessableEntity)
+	a.do("POST", "/api/v1/portfolio/missing/assets", route, map[string]interface{}{
+		"symbol": "ETH", "name": "Ether", "amount": 1, "priceUSD": 3500,
+	}, http.StatusNotFound)
+
+	assets := a.do("GET", "/api/v1/portfolio/main/assets", route, nil, http.StatusOK).([]interface{})
+	require.Len(t, assets, 1)
+
+	p := a.do("GET", "/api/v1/portfolio/main", "/api/v1/portfolio/{portfolioId}", nil, http.StatusOK).(map[string]interface{})
+	assert.Len(t, p["assets"], 1)
+	assert.InDelta(t, 15000.0, p["totalValue"], 1e-6)
+}
+
+func TestRebalanceAndRiskEndpoints(t *testing.T) {
+	a := newAPITest(t)
+	a.createPortfolio("balanced", map[string]float64{"BTC": 50, "CASH": 50})
+	a.createPortfolio("untargeted", nil)
+	const rebalance = "/api/v1/portfolio/{portfolioId}/rebalance"
+
+	response := a.do("POST", "/api/v1/portfolio/balanced/rebalance", rebalance, nil, http.StatusOK).(map[string]interface{})
+	actions := response["actions"].([]interface{})
+	require.Len(t, actions, 2)
+	first := actions[0].(map[string]interface{})
+	assert.Equal(t, "BTC", first["asset"])
+	assert.Equal(t, "buy", first["action"])
+	assert.InDelta(t, 5000.0, first["amount"], 1e-6)
+
+	a.do("POST", "/api/v1/portfolio/untargeted/rebalance", rebalance, nil, http.StatusUnprocessableEntity)
+	a.do("POST", "/api/v1/portfolio/missing/rebalance", rebalance, nil, http.StatusNotFound)
+
+	const risk = "/api/v1/portfolio/{portfolioId}/risk"
+	assessment := a.do("GET", "/api/v1/portfolio/balanced/risk", risk, nil, http.StatusOK).(map[string]interface{})
+	assert.Equal(t, "balanced", assessment["portfolioId"])
+	assert.NotEmpty(t, assessment["recommendations"])
+	a.do("GET", "/api/v1/portfolio/missing/risk", risk, nil, http.StatusNotFound)
+}
+
+func TestMarketDataEndpoint(t *testing.T) {
+	a := newAPITest(t)
+	const route = "/api/v1/market/data"
+
+	data := a.do("GET", route+"?symbols=eth,BTC&timeframe=4h", route, nil, http.StatusOK).([]interface{})
+	require.Len(t, data, 2)
+	assert.Equal(t, "ETH", data[0].(map[string]interface{})["symbol"])
+	assert.Equal(t, 65000.0, data[1].(map[string]interface{})["price"])
+
+	a.do("GET", route+"?symbols=ETH&symbols=BTC", route, nil, http.StatusOK)
+	a.do("GET", route, route, nil, http.StatusBadRequest)
+	a.do("GET", route+"?symbols=ETH&timeframe=2h", route, nil, http.StatusBadRequest)
+	a.do("GET", route+"?symbols=DOGE", route, nil, http.StatusNotFound)
+}
+
+func TestStrategyEndpoints(t *testing.T) {
+	a := newAPITest(t)
+	a.createPortfolio("main", nil)
+
+	strategies := a.do("GET", "/api/v1/defi/strategies", "/api/v1/defi/strategies", nil, http.StatusOK).([]interface{})
+	require.Len(t, strategies, 2)
+	assert.Equal(t, "mean_reversion_eth", strategies[0].(map[string]interface{})["id"])
+	assert.Equal(t, "low", strategies[0].(map[string]interface{})["riskLevel"])
+
+	const route = "/api/v1/defi/strategies/{strategyId}/execute"
+	result := a.do("POST", "/api/v1/defi/strategies/mean_reversion_eth/execute", route, map[string]interface{}{
+		"portfolioId": "main", "capital": 1000,
+	}, http.StatusOK).(map[string]interface{})
+	positions := result["positionsOpened"].([]interface{})
+	require.Len(t, positions, 1)
+	position := positions[0].(map[string]interface{})
+	assert.Equal(t, "ETH", position["asset"])
+	assert.Equal(t, "open", position["status"])
+	// Kelly sizing is capped at 10% of the committed capital
+	assert.InDelta(t, 100.0/3500, position["size"], 1e-9)
+
+	p, err := a.portfolios.GetPortfolio("main")
+	require.NoError(t, err)
+	assert.Len(t, p.GetOpenPositions(), 1)
+	assert.InDelta(t, 9900.0, toFloat(p.GetPortfolioStats().CashBalance), 1e-6)
+
+	a.do("POST", "/api/v1/defi/strategies/unknown/execute", route, map[string]interface{}{
+		"portfolioId": "main", "capital": 1000,
+	}, http.StatusNotFound)
+	a.do("POST", "/api/v1/defi/strategies/trend_following_btc/execute", route, map[string]interface{}{
+		"portfolioId": "main", "capital": 1000,
+	}, http.StatusConflict)
+	a.do("POST", "/api/v1/defi/strategies/mean_reversion_eth/execute", route, map[string]interface{}{
+		"portfolioId": "main",
+	}, http.StatusUnprocessableEntity)
+	a.do("POST", "/api/v1/defi/strategies/mean_reversion_eth/execute", route, map[string]interface{}{
+		"portfolioId": "main", "capital": 1e6,
+	}, http.StatusUnprocessableEntity)
+	a.do("POST", "/api/v1/defi/strategies/mean_reversion_eth/execute", route, map[string]interface{}{
+		"portfolioId": "missing", "capital": 1000,
+	}, http.StatusNotFound)
+}
+
+func TestAgentEndpoints(t *testing.T) {
+	a := newAPITest(t)
+	a.createPortfolio("main", nil)
+
+	agents := a.do("GET", "/api/v1/agents", "/api/v1/agents", nil, http.StatusOK).([]interface{})
+	assert.Len(t, agents, len(a.agents.GetAgents()))
+
+	const route = "/api/v1/agents/{agentId}/execute"
+	result := a.do("POST", "/api/v1/agents/arbitrage-1/execute", route, map[string]interface{}{
+		"task": "scan", "portfolioId": "main",
+	}, http.StatusOK).(map[string]interface{})
+	assert.Equal(t, true, result["success"])
+	assert.NotEmpty(t, result["actionsTaken"])
+
+	a.do("POST", "/api/v1/agents/hedera-arbitrage-001/execute", route, map[string]interface{}{
+		"task": "scan",
+	}, http.StatusOK)
+
+	// yield-1 starts idle
+	a.do("POST", "/api/v1/agents/yield-1/execute", route, map[string]interface{}{"task": "scan"}, http.StatusConflict)
+	a.do("POST", "/api/v1/agents/unknown/execute", route, map[string]interface{}{"task": "scan"}, http.StatusNotFound)
+	a.do("POST", "/api/v1/agents/arbitrage-1/execute", route, map[string]interface{}{}, http.StatusUnprocessableEntity)
+	a.do("POST", "/api/v1/agents/arbitrage-1/execute", route, map[string]interface{}{
+		"task": "scan", "portfolioId": "missing",
+	}, http.StatusNotFound)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validRiskTypes lists the risk profile types defined in openapi.yaml
+var validRiskTypes = map[string]bool{
+	"conservative": true,
+	"moderate":     true,
+	"aggressive":   true,
+}
+
+// validate checks a CreatePortfolioRequest against the CreatePortfolioRequest schema
+func (req *CreatePortfolioRequest) validate() error {
+	if strings.TrimSpace(req.ID) == "" {
+		return fmt.Errorf("field 'id' is required")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("field 'name' is required")
+	}
+	if req.InitialCash < 0 {
+		return fmt.Errorf("field 'initialCash' must not be negative")
+	}
+	return req.RiskProfile.validate()
+}
+
+// validate checks a RiskProfile against the RiskProfile schema
+func (rp *RiskProfile) validate() error {
+	if rp.Type == "" {
+		return fmt.Errorf("field 'riskProfile.type' is required")
+	}
+	if !validRiskTypes[rp.Type] {
+		return fmt.Errorf("field 'riskProfile.type' must be one of conservative, moderate, aggressive")
+	}
+	if rp.MaxDrawdown < 0 || rp.MaxPositionSize < 0 || rp.MaxLeverage < 0 {
+		return fmt.Errorf("risk profile limits must not be negative")
+	}
+
+	total := 0.0
+	for asset, percent := range rp.TargetAllocations {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("target allocation for %s must be between 0 and 100", asset)
+		}
+		total += percent
+	}
+	if total > 100 {
+		return fmt.Errorf("target allocations sum to %.2f%%, above 100%%", total)
+	}
+	return nil
+}
+
+// validate checks an AddAssetRequest against the Asset schema
+func (req *AddAssetRequest) validate() error {
+	if strings.TrimSpace(req.Symbol) == "" {
+		return fmt.Errorf("field 'symbol' is required")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("field 'name' is required")
+	}
+	if req.Amount == nil {
+		return fmt.Errorf("field 'amount' is required")
+	}
+	if *req.Amount < 0 {
+		return fmt.Errorf("field 'amount' must not be negative")
+	}
+	if req.PriceUSD == nil {
+		return fmt.Errorf("field 'priceUSD' is required")
+	}
+	if *req.PriceUSD <= 0 {
+		return fmt.Errorf("field 'priceUSD' must be positive")
+	}
+	return nil
+}
+
+// validate checks an ExecuteStrategyRequest against the ExecuteStrategyRequest schema
+func (req *ExecuteStrategyRequest) validate() error {
+	if strings.TrimSpace(req.PortfolioID) == "" {
+		return fmt.Errorf("field 'portfolioId' is required")
+	}
+	if req.Capital == nil {
+		return fmt.Errorf("field 'capital' is required")
+	}
+	if *req.Capital <= 0 {
+		return fmt.Errorf("field 'capital' must be positive")
+	}
+	return nil
+}
+
+// validate checks an ExecuteAgentRequest against the ExecuteAgentRequest schema
+func (req *ExecuteAgentRequest) validate() error {
+	if strings.TrimSpace(req.Task) == "" {
+		return fmt.Errorf("field 'task' is required")
+	}
+	return nil
+}
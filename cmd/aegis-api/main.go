@@ -10,10 +10,15 @@ import (
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/api"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/agent"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/backtest"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -35,6 +40,7 @@ This server exposes the OpenAPI specification and handles all API requests.`,
 
 	rootCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
 	rootCmd.Flags().StringVarP(&cfgFile, "config", "c", "config/config.yaml", "Configuration file path")
+	rootCmd.AddCommand(backtest.NewCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -71,11 +77,34 @@ func runServer(cmd *cobra.Command, args []string) {
 	// Initialize monitoring
 	monitor := monitoring.NewMonitor(&cfg.Monitoring, logger)
 
-	// Initialize portfolio manager
+	// Open persistent storage
+	repo, err := storage.Open(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer repo.Close()
+
+	// Initialize portfolio manager and restore persisted portfolios
 	portfolioManager := portfolio.NewPortfolioManager(logger, monitor)
+	if err := portfolioManager.AttachRepository(ctx, repo); err != nil {
+		log.Fatalf("Failed to load portfolios: %v", err)
+	}
+
+	// Market data, strategies and agents exposed through the API
+	marketData := market.NewData()
+	strategyEngine := defi.NewAdvancedStrategyEngine()
+	strategyEngine.History = marketData.History
+	for _, strategy := range []*defi.AdvancedTradingStrategy{
+		defi.MeanReversionStrategy(),
+		defi.TrendFollowingStrategy(),
+		defi.StatisticalArbitrageStrategy(),
+	} {
+		strategyEngine.Strategies[strategy.ID] = strategy
+	}
+	agentManager := agent.NewManager()
 
 	// Create API server
-	apiServer := api.NewServer(cfg, logger, monitor, portfolioManager)
+	apiServer := api.NewServer(cfg, logger, monitor, portfolioManager, marketData, strategyEngine, agentManager)
 
 	// Start API server
 	logger.Info("Starting Aegis API server",
@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/core/mcp"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
 )
 
 // setupWallet handles the creation or loading of the wallet.
@@ -60,6 +63,21 @@ func main() {
 		log.Fatalf("Failed to create MCP server: %v", err)
 	}
 
+	// Export rate limiter decisions through the monitoring metrics endpoint
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	logger, err := logging.NewLogger(&cfg.Logging)
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	monitor := monitoring.NewMonitor(&cfg.Monitoring, logger)
+	if err := monitor.Start(ctx); err != nil {
+		log.Fatalf("Failed to start monitoring: %v", err)
+	}
+	server.SetMonitor(monitor)
+
 	log.Printf("MCP server created successfully with config: %s", "config/mcp_manifest.yaml")
 	log.Printf("Server info: %+v", server)
 
@@ -84,4 +102,7 @@ func main() {
 	if err := server.Stop(shutdownCtx); err != nil {
 		log.Printf("Error during server shutdown: %v", err)
 	}
+	if err := monitor.Stop(shutdownCtx); err != nil {
+		log.Printf("Error during monitoring shutdown: %v", err)
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/backtest"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/tui"
 )
 
@@ -51,6 +52,7 @@ Built with 100% Golang and Bubble Tea TUI framework.`,
 		newMarketCommand(),
 		newAgentCommand(),
 		newConfigCommand(),
+		backtest.NewCommand(),
 	)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/big"
 	"math/rand"
 	"os"
 	"os/signal"
@@ -17,7 +18,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/pkg/mcpclient"
 )
 
@@ -27,6 +30,9 @@ type DeFiAgentTerminal struct {
 	updateTimer    timer.Model
 	defiAgent      *defi.DeFiAgent
 	strategyEngine *defi.StrategyEngine
+	arbitrage      *defi.ArbitrageScanner
+	refreshing     bool
+	market         *market.Data
 	priceClient    *mcpclient.CoinGeckoClient
 	messages       []string
 	isRunning      bool
@@ -88,12 +94,9 @@ type WalletData struct {
 
 // ArbitrageOpportunity represents a detected arbitrage opportunity
 type ArbitrageOpportunity struct {
-	Pair      string
-	ExchangeA string
-	ExchangeB string
-	PriceA    float64
-	PriceB    float64
-	Profit    float64
+	Route     string // tokens visited, e.g. "WETH → USDC → WETH"
+	Venues    string
+	Profit    float64 // USD after gas
 	ProfitPct float64
 }
 
@@ -115,6 +118,15 @@ func NewDeFiAgentTerminal() DeFiAgentTerminal {
 	strategyEngine.AddStrategy(defi.ArbitrageStrategy())
 	strategyEngine.AddStrategy(defi.YieldFarmingStrategy())
 
+	// Scan the pools read from the DEX managers; the strategy engine sees
+	// every scan
+	arbitrage := defi.NewArbitrageScanner()
+	arbitrage.SetToken("WETH", 18, 0)
+	arbitrage.SetToken("USDC", 6, 1)
+	arbitrage.Sinks = []defi.ArbitrageSink{strategyEngine}
+	data := market.NewData()
+	connectArbitrage(arbitrage, data)
+
 	// Initialize CoinGecko client for real price data
 	priceClient := mcpclient.NewCoinGeckoClient()
 
@@ -165,6 +177,8 @@ func NewDeFiAgentTerminal() DeFiAgentTerminal {
 		Model:          ti,
 		updateTimer:    updateTimer,
 		strategyEngine: strategyEngine,
+		arbitrage:      arbitrage,
+		market:         data,
 		priceClient:    priceClient,
 		messages:       []string{},
 		isRunning:      true,
@@ -281,7 +295,18 @@ func (m DeFiAgentTerminal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case timer.TickMsg:
 		m.updateMarketData()
 		m.updateTimer, cmd = m.updateTimer.Update(msg)
+		if m.arbitrage != nil && !m.refreshing {
+			m.refreshing = true
+			cmd = tea.Batch(cmd, refreshArbitrage(m.arbitrage))
+		}
 		return m, cmd
+	case arbitrageRefreshedMsg:
+		m.refreshing = false
+		if msg.err != nil {
+			log.Printf("Failed to refresh arbitrage pools: %v", msg.err)
+		}
+		m.updateArbitrageOpportunities()
+		return m, nil
 	}
 
 	m.Model, cmd = m.Model.Update(msg)
@@ -481,8 +506,8 @@ func (m DeFiAgentTerminal) renderMarket() string {
 	var arbitrageSection string
 	if len(m.marketData.ArbitrageOps) > 0 {
 		for _, opp := range m.marketData.ArbitrageOps {
-			arbitrageSection += fmt.Sprintf("     %s: %s $%.2f vs %s $%.2f (+%.2f%%)\n",
-				opp.Pair, opp.ExchangeA, opp.PriceA, opp.ExchangeB, opp.PriceB, opp.ProfitPct)
+			arbitrageSection += fmt.Sprintf("     %s via %s: $%.2f (+%.2f%%)\n",
+				opp.Route, opp.Venues, opp.Profit, opp.ProfitPct)
 		}
 	} else {
 		arbitrageSection = "     No active arbitrage opportunities detected\n"
@@ -725,7 +750,14 @@ func (m *DeFiAgentTerminal) updateMarketData() {
 			continue
 		}
 
-		// Update market data with real prices
+		// Update market data with real prices, and share them with the
+		// WebSocket feed
+		m.market.SetPrice(market.PriceData{
+			Symbol:    strings.TrimSuffix(symbol, "/USD"),
+			Price:     priceData.Price,
+			Change24h: priceData.Change24h,
+			Volume:    priceData.Volume24h,
+		})
 		switch symbol {
 		case "ETH/USD":
 			m.marketData.ETHPrice = priceData.Price
@@ -750,7 +782,7 @@ func (m *DeFiAgentTerminal) updateMarketData() {
 		m.marketData.GasPrice = 80
 	}
 
-	// Update arbitrage opportunities
+	// Rescan the pools last refreshed at the new prices
 	m.updateArbitrageOpportunities()
 }
 
@@ -787,22 +819,69 @@ func (m *DeFiAgentTerminal) simulateRealisticPrices() {
 	m.marketData.USDTPrice = 0.9995 + rand.Float64()*0.001
 }
 
-// updateArbitrageOpportunities simulates finding new arbitrage opportunities
+// connectArbitrage reads the scanner's WETH/USDC pools on chain when a
+// wallet is configured, and publishes its scans and data over the market
+// data WebSocket when that is enabled
+func connectArbitrage(arbitrage *defi.ArbitrageScanner, data *market.Data) {
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		log.Printf("Failed to load configuration, arbitrage scans have no pools: %v", err)
+		return
+	}
+
+	if cfg.Blockchain.PrivateKey != "" {
+		cm, err := defi.NewContractManagerFromConfig(nil, cfg.Blockchain, nil)
+		if err != nil {
+			log.Printf("Failed to connect arbitrage pools: %v", err)
+		} else {
+			arbitrage.Sources = append(arbitrage.Sources, defi.NewDEXPoolSource(cm, "WETH/USDC"))
+		}
+	}
+
+	if cfg.MarketData.WebSocket.Enabled {
+		ws := market.NewWebSocketService(data)
+		go func() {
+			if err := ws.Start(cfg.MarketData.WebSocket.Port); err != nil {
+				log.Printf("WebSocket service stopped: %v", err)
+			}
+		}()
+		arbitrage.Sinks = append(arbitrage.Sinks, defi.WebSocketArbitrageFeed(ws))
+	}
+}
+
+// arbitrageRefreshTimeout bounds one refresh of the arbitrage pools
+const arbitrageRefreshTimeout = 10 * time.Second
+
+// arbitrageRefreshedMsg reports that a refresh of the arbitrage pools ended
+type arbitrageRefreshedMsg struct {
+	err error
+}
+
+// refreshArbitrage reloads the scanner's pools off the UI loop
+func refreshArbitrage(arbitrage *defi.ArbitrageScanner) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), arbitrageRefreshTimeout)
+		defer cancel()
+		return arbitrageRefreshedMsg{err: arbitrage.Refresh(ctx)}
+	}
+}
+
+// updateArbitrageOpportunities rescans the pools last refreshed at the
+// current ETH and gas prices
 func (m *DeFiAgentTerminal) updateArbitrageOpportunities() {
-	// Clear existing opportunities
 	m.marketData.ArbitrageOps = []ArbitrageOpportunity{}
+	if m.arbitrage == nil {
+		return
+	}
 
-	// Simulate finding new opportunities
-	if rand.Float64() > 0.3 { // 70% chance of finding an opportunity
-		profitPct := 0.1 + rand.Float64()*0.5 // 0.1% to 0.6% profit
+	m.arbitrage.SetTokenPrice("WETH", m.marketData.ETHPrice)
+	m.arbitrage.GasPrice = new(big.Int).Mul(big.NewInt(int64(m.marketData.GasPrice)), big.NewInt(1_000_000_000))
+	for _, opp := range m.arbitrage.Scan() {
 		m.marketData.ArbitrageOps = append(m.marketData.ArbitrageOps, ArbitrageOpportunity{
-			Pair:      "ETH/USDC",
-			ExchangeA: "Uniswap",
-			ExchangeB: "PancakeSwap",
-			PriceA:    m.marketData.ETHPrice * 0.998,
-			PriceB:    m.marketData.ETHPrice * 1.002,
-			Profit:    124.50,
-			ProfitPct: profitPct,
+			Route:     strings.Join(opp.Path, " → "),
+			Venues:    strings.Join(opp.Venues, ", "),
+			Profit:    opp.ProfitUSD,
+			ProfitPct: opp.ProfitPct * 100,
 		})
 	}
 }
@@ -12,6 +12,9 @@ import (
 
 	"os"
 
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/ratelimit"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"gopkg.in/yaml.v3"
@@ -23,17 +26,20 @@ type MCPServer struct {
 	config          *Config
 	wasmEngine      *WASMEngine
 	registeredTools []string
+	limits          *ratelimit.Policy
+	monitor         *monitoring.Monitor
 }
 
 // Config holds MCP server configuration
 type Config struct {
-	Host           string        `yaml:"host"`
-	Port           int           `yaml:"port"`
-	MaxConnections int           `yaml:"max_connections"`
-	Timeout        time.Duration `yaml:"timeout"`
-	LLMConfig      LLMConfig     `yaml:"llm_config"`
-	Modules        []Module      `yaml:"modules"`
-	IPFS           IPFSConfig    `yaml:"ipfs"`
+	Host           string                 `yaml:"host"`
+	Port           int                    `yaml:"port"`
+	MaxConnections int                    `yaml:"max_connections"`
+	Timeout        time.Duration          `yaml:"timeout"`
+	LLMConfig      LLMConfig              `yaml:"llm_config"`
+	Modules        []Module               `yaml:"modules"`
+	IPFS           IPFSConfig             `yaml:"ipfs"`
+	RateLimit      config.RateLimitConfig `yaml:"rate_limit"`
 }
 
 type IPFSConfig struct {
@@ -103,6 +109,9 @@ func NewServer(configPath string) (*MCPServer, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if err := config.RateLimit.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid rate limit config: %w", err)
+	}
 
 	return NewMCPServer(&config), nil
 }
@@ -163,9 +172,15 @@ func NewMCPServer(config *Config) *MCPServer {
 		config:          config,
 		wasmEngine:      wasmEngine,
 		registeredTools: registeredTools,
+		limits:          ratelimit.NewPolicy(config.RateLimit),
 	}
 }
 
+// SetMonitor exports rate limiter decisions through monitor
+func (s *MCPServer) SetMonitor(monitor *monitoring.Monitor) {
+	s.monitor = monitor
+}
+
 // Start begins the MCP server
 func (s *MCPServer) Start() error {
 	log.Println("Starting MCP server")
@@ -279,12 +294,52 @@ func (s *MCPServer) Start() error {
 
 	server := &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: s.rateLimit(mux),
 	}
 
 	return server.ListenAndServe()
 }
 
+// rateLimit rejects requests over the configured per-client limits with 429
+// before they reach the MCP handler or load a WASM module
+func (s *MCPServer) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.limits.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := mcpRoute(r.URL.Path)
+		client := ratelimit.ClientIP(r)
+		decision := s.limits.Allow(r.Method, route, client)
+		if s.monitor != nil {
+			s.monitor.RecordRateLimit("mcp", route, decision.Allowed)
+		}
+		ratelimit.SetHeaders(w, decision)
+
+		if !decision.Allowed {
+			log.Printf("Rate limit exceeded for %s on %s %s", client, r.Method, route)
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// mcpRoute maps a request path to the route template used for rate limits
+// and metrics, so tool names do not create unbounded label values
+func mcpRoute(path string) string {
+	switch {
+	case path == "/tools":
+		return "/tools"
+	case strings.HasPrefix(path, "/tools/"):
+		return "/tools/{name}"
+	default:
+		return "/"
+	}
+}
+
 // Stop gracefully shuts down the MCP server
 func (s *MCPServer) Stop(ctx context.Context) error {
 	log.Println("Initiating MCP server shutdown")
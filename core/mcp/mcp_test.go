@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/ratelimit"
+)
+
+// rateLimitDecisions reads the exported rate limiter counter for a decision
+func rateLimitDecisions(t *testing.T, server, route, decision string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != "aegis_rate_limit_decisions_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["server"] == server && labels["route"] == route && labels["decision"] == decision {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestRateLimitMCPRoutes(t *testing.T) {
+	logger, err := logging.NewLogger(&config.LoggingConfig{Level: "info", Format: "console", Output: "stdout"})
+	require.NoError(t, err)
+
+	s := &MCPServer{
+		config: &Config{},
+		limits: ratelimit.NewPolicy(config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerSecond: 0.01,
+			Burst:             1,
+		}),
+	}
+	s.SetMonitor(monitoring.NewMonitor(&config.MonitoringConfig{Enabled: true}, logger))
+
+	served := 0
+	handler := s.rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+	call := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, call("/tools/swap").Code)
+
+	// Tool names share the route's bucket, so the second call is rejected before loading a module
+	rec := call("/tools/quote")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	assert.Equal(t, 1, served)
+
+	assert.Equal(t, 1.0, rateLimitDecisions(t, "mcp", "/tools/{name}", "allowed"))
+	assert.Equal(t, 1.0, rateLimitDecisions(t, "mcp", "/tools/{name}", "rejected"))
+}
@@ -1,14 +1,27 @@
 package agent
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/pkg/mcpclient"
 )
 
+var (
+	// ErrAgentNotFound is returned when no agent has the requested ID
+	ErrAgentNotFound = errors.New("agent not found")
+
+	// ErrAgentInactive is returned when a task is sent to an agent that is not active
+	ErrAgentInactive = errors.New("agent is not active")
+)
+
 type Manager struct {
 	agents       []Agent
 	hederaClient *mcpclient.HederaClient
+	mu           sync.RWMutex
 }
 
 type Agent struct {
@@ -17,6 +30,17 @@ type Agent struct {
 	Description  string
 	Status       string
 	Capabilities []string
+	LastActive   time.Time
+	Hedera       bool // tasks are dispatched through the Hedera agent network
+}
+
+// TaskResult describes the outcome of an agent task
+type TaskResult struct {
+	AgentID      string
+	Task         string
+	Output       string
+	ActionsTaken []string
+	ExecutedAt   time.Time
 }
 
 func NewManager() *Manager {
@@ -51,6 +75,8 @@ func NewManager() *Manager {
 			Description:  hederaAgent.Description,
 			Status:       hederaAgent.Status,
 			Capabilities: hederaAgent.Capabilities,
+			LastActive:   hederaAgent.LastSeen,
+			Hedera:       true,
 		})
 	}
 
@@ -61,27 +87,85 @@ func NewManager() *Manager {
 }
 
 func (m *Manager) GetAgents() []Agent {
-	return m.agents
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	agents := make([]Agent, len(m.agents))
+	copy(agents, m.agents)
+	return agents
+}
+
+// GetAgent returns the agent with the given ID
+func (m *Manager) GetAgent(id string) (Agent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, agent := range m.agents {
+		if agent.ID == id {
+			return agent, nil
+		}
+	}
+	return Agent{}, fmt.Errorf("%w: %s", ErrAgentNotFound, id)
 }
 
 func (m *Manager) StartAgent(id string) error {
+	return m.setStatus(id, "Active")
+}
+
+func (m *Manager) StopAgent(id string) error {
+	return m.setStatus(id, "Idle")
+}
+
+func (m *Manager) setStatus(id, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for i := range m.agents {
 		if m.agents[i].ID == id {
-			m.agents[i].Status = "Active"
+			m.agents[i].Status = status
 			return nil
 		}
 	}
-	return fmt.Errorf("agent not found: %s", id)
+	return fmt.Errorf("%w: %s", ErrAgentNotFound, id)
 }
 
-func (m *Manager) StopAgent(id string) error {
+// ExecuteTask runs a task on an active agent, dispatching Hedera agents through the Hedera client
+func (m *Manager) ExecuteTask(agentID, task string, parameters map[string]interface{}) (*TaskResult, error) {
+	agent, err := m.GetAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(agent.Status, "Active") {
+		return nil, fmt.Errorf("%w: %s is %s", ErrAgentInactive, agentID, strings.ToLower(agent.Status))
+	}
+
+	result := &TaskResult{
+		AgentID:    agentID,
+		Task:       task,
+		ExecutedAt: time.Now(),
+	}
+
+	if agent.Hedera {
+		output, err := m.ExecuteHederaTask(agentID, task, parameters)
+		if err != nil {
+			return nil, err
+		}
+		result.Output = output
+		result.ActionsTaken = []string{"Dispatched task to Hedera agent " + agentID}
+	} else {
+		result.Output = fmt.Sprintf("Agent %s accepted task '%s'", agentID, task)
+		result.ActionsTaken = []string{"Queued task for " + agent.Name}
+	}
+
+	m.mu.Lock()
 	for i := range m.agents {
-		if m.agents[i].ID == id {
-			m.agents[i].Status = "Idle"
-			return nil
+		if m.agents[i].ID == agentID {
+			m.agents[i].LastActive = result.ExecutedAt
 		}
 	}
-	return fmt.Errorf("agent not found: %s", id)
+	m.mu.Unlock()
+
+	return result, nil
 }
 
 func (m *Manager) CoordinateHederaAgents(task string, requiredCapabilities []string) ([]Agent, error) {
@@ -0,0 +1,654 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+)
+
+// Config controls the simulated account used during a backtest
+type Config struct {
+	InitialCapital float64 // starting quote balance
+	FeeRate        float64 // fraction of notional charged per fill
+	QuoteAsset     string  // asset the account is denominated in
+	HistorySize    int     // candles retained per symbol for indicators
+
+	// RiskLimits are the portfolio-wide limits advanced strategies trade
+	// under. Nil replays strategies under their own RiskParameters only.
+	RiskLimits *defi.RiskLimits
+}
+
+// DefaultConfig returns a backtest configuration with sensible defaults
+func DefaultConfig() Config {
+	return Config{
+		InitialCapital: 10000,
+		FeeRate:        0.003,
+		QuoteAsset:     "USDC",
+		HistorySize:    market.DefaultHistorySize,
+	}
+}
+
+// stablecoins are valued at par when no price series is supplied
+var stablecoins = map[string]bool{
+	"USD":  true,
+	"USDC": true,
+	"USDT": true,
+	"DAI":  true,
+}
+
+// Side is the direction of a simulated fill
+type Side string
+
+const (
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+)
+
+// Trade is a single simulated fill
+type Trade struct {
+	Time        time.Time `json:"time"`
+	StrategyID  string    `json:"strategy_id"`
+	Asset       string    `json:"asset"`
+	Side        Side      `json:"side"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	Fee         float64   `json:"fee"`
+	RealizedPnL float64   `json:"realized_pnl"`
+	Reason      string    `json:"reason"`
+}
+
+// EquityPoint is the account value at a simulated time step
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Result holds the output of a backtest run
+type Result struct {
+	Start          time.Time
+	End            time.Time
+	Steps          int
+	InitialCapital float64
+	FinalEquity    float64
+	Trades         []Trade
+	Equity         []EquityPoint
+	Stats          map[string]*defi.StrategyPerformanceStats
+	Performance    *defi.StrategyPerformance
+}
+
+// Backtester replays historical candles through the strategy engines on a simulated clock
+type Backtester struct {
+	cfg Config
+}
+
+// New creates a backtester
+func New(cfg Config) *Backtester {
+	defaults := DefaultConfig()
+	if cfg.InitialCapital <= 0 {
+		cfg.InitialCapital = defaults.InitialCapital
+	}
+	if cfg.QuoteAsset == "" {
+		cfg.QuoteAsset = defaults.QuoteAsset
+	}
+	if cfg.HistorySize <= 0 {
+		cfg.HistorySize = defaults.HistorySize
+	}
+	return &Backtester{cfg: cfg}
+}
+
+// Run replays candles through a StrategyEngine holding the given strategies
+func (b *Backtester) Run(ctx context.Context, candles []market.Candle, strategies ...*defi.TradingStrategy) (*Result, error) {
+	if len(strategies) == 0 {
+		return nil, fmt.Errorf("no strategies to backtest")
+	}
+
+	s := b.newSession(candles)
+	engine := defi.NewStrategyEngine()
+	engine.Clock = s.clock
+	engine.History = s.history
+	engine.Executor = &actionExecutor{session: s}
+
+	ids := make([]string, 0, len(strategies))
+	for _, strategy := range strategies {
+		if err := engine.AddStrategy(strategy); err != nil {
+			return nil, err
+		}
+		ids = append(ids, strategy.ID)
+	}
+
+	if err := s.replay(ctx, candles, ids, engine.EvaluateStrategies); err != nil {
+		return nil, err
+	}
+	return s.result(ids), nil
+}
+
+// RunAdvanced replays candles through an AdvancedStrategyEngine holding the given strategies.
+// Stop-loss and take-profit levels from each strategy's RiskParameters are enforced on every step.
+func (b *Backtester) RunAdvanced(ctx context.Context, candles []market.Candle, strategies ...*defi.AdvancedTradingStrategy) (*Result, error) {
+	if len(strategies) == 0 {
+		return nil, fmt.Errorf("no strategies to backtest")
+	}
+
+	s := b.newSession(candles)
+	engine := defi.NewAdvancedStrategyEngine()
+	engine.Clock = s.clock
+	engine.History = s.history
+	engine.RiskManager.RiskLimits = b.cfg.RiskLimits
+	executor := &tradeExecutor{session: s}
+	engine.Executor = executor
+
+	ids := make([]string, 0, len(strategies))
+	for _, strategy := range strategies {
+		if _, exists := engine.Strategies[strategy.ID]; exists {
+			return nil, fmt.Errorf("strategy with ID %s already exists", strategy.ID)
+		}
+		if len(strategy.Parameters.TargetAssets) == 0 {
+			return nil, fmt.Errorf("strategy %s has no target assets", strategy.ID)
+		}
+		if strategy.PerformanceStats == nil {
+			strategy.PerformanceStats = &defi.StrategyPerformanceStats{}
+		}
+		engine.Strategies[strategy.ID] = strategy
+		ids = append(ids, strategy.ID)
+	}
+
+	step := func() {
+		for _, id := range ids {
+			executor.applyExitLevels(engine.Strategies[id])
+		}
+		s.syncPortfolio(engine.Portfolio)
+		engine.EvaluateStrategies()
+	}
+
+	if err := s.replay(ctx, candles, ids, step); err != nil {
+		return nil, err
+	}
+
+	result := s.result(ids)
+	for _, id := range ids {
+		engine.Strategies[id].PerformanceStats = result.Stats[id]
+	}
+	return result, nil
+}
+
+// session is the mutable state of a single backtest run
+type session struct {
+	cfg       Config
+	clock     *defi.SimulatedClock
+	history   *market.PriceHistory
+	cash      float64
+	prices    map[string]float64
+	positions map[string]map[string]*holding // strategy -> asset -> holding
+	realized  map[string]float64
+	trades    []Trade
+	times     []time.Time
+	equity    []float64
+	curves    map[string][]float64
+}
+
+// holding is an open quantity of an asset with its total cost basis
+type holding struct {
+	quantity  float64
+	costBasis float64
+}
+
+func (b *Backtester) newSession(candles []market.Candle) *session {
+	start := time.Unix(0, 0).UTC()
+	if len(candles) > 0 {
+		start = candles[0].Time
+	}
+
+	return &session{
+		cfg:       b.cfg,
+		clock:     defi.NewSimulatedClock(start),
+		history:   market.NewPriceHistory(b.cfg.HistorySize),
+		cash:      b.cfg.InitialCapital,
+		prices:    make(map[string]float64),
+		positions: make(map[string]map[string]*holding),
+		realized:  make(map[string]float64),
+		curves:    make(map[string][]float64),
+	}
+}
+
+// replay walks the candles one timestamp at a time, calling step after each is applied
+func (s *session) replay(ctx context.Context, candles []market.Candle, ids []string, step func()) error {
+	if len(candles) == 0 {
+		return fmt.Errorf("no price data to replay")
+	}
+
+	ordered := make([]market.Candle, len(candles))
+	copy(ordered, candles)
+	sortCandles(ordered)
+
+	for i := 0; i < len(ordered); {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		at := ordered[i].Time
+		j := i
+		for j < len(ordered) && ordered[j].Time.Equal(at) {
+			j++
+		}
+
+		s.clock.Set(at)
+		s.applyCandles(ordered[i:j])
+		step()
+		s.record(at, ids)
+
+		i = j
+	}
+
+	return nil
+}
+
+// applyCandles pushes a time step into the history and updates mark prices.
+// Plain asset series take precedence over venue-qualified pair series.
+func (s *session) applyCandles(candles []market.Candle) {
+	venue := make(map[string]float64)
+	for _, c := range candles {
+		s.history.Add(c)
+		if base, ok := venueBase(c.Symbol); ok {
+			venue[base] = c.Close
+			continue
+		}
+		s.prices[c.Symbol] = c.Close
+	}
+
+	for base, price := range venue {
+		if s.history.Len(base) == 0 {
+			s.prices[base] = price
+		}
+	}
+}
+
+// venueBase extracts the base asset from a "venue:BASE/QUOTE" symbol
+func venueBase(symbol string) (string, bool) {
+	_, pair, found := strings.Cut(symbol, ":")
+	if !found {
+		return "", false
+	}
+	base, _, _ := strings.Cut(pair, "/")
+	return base, true
+}
+
+// price returns the mark price of an asset
+func (s *session) price(asset string) (float64, bool) {
+	if p, ok := s.prices[asset]; ok && p > 0 {
+		return p, true
+	}
+	if stablecoins[asset] {
+		return 1, true
+	}
+	return 0, false
+}
+
+// fillPrice returns the execution price of base on a venue, falling back to the mark price
+func (s *session) fillPrice(base, quote, venue string) (float64, bool) {
+	if venue != "" {
+		if c, ok := s.history.Latest(market.VenueSymbol(venue, base+"/"+quote)); ok && c.Close > 0 {
+			return c.Close, true
+		}
+	}
+	return s.price(base)
+}
+
+func (s *session) holding(strategyID, asset string) *holding {
+	byAsset, ok := s.positions[strategyID]
+	if !ok {
+		byAsset = make(map[string]*holding)
+		s.positions[strategyID] = byAsset
+	}
+	h, ok := byAsset[asset]
+	if !ok {
+		h = &holding{}
+		byAsset[asset] = h
+	}
+	return h
+}
+
+// buy spends up to notional of quote on asset at price
+func (s *session) buy(strategyID, asset string, notional, price float64, reason string) error {
+	if price <= 0 {
+		return fmt.Errorf("no price for %s", asset)
+	}
+	notional = math.Min(notional, s.cash)
+	if notional <= 0 {
+		return fmt.Errorf("insufficient cash")
+	}
+
+	fee := notional * s.cfg.FeeRate
+	quantity := (notional - fee) / price
+
+	h := s.holding(strategyID, asset)
+	h.quantity += quantity
+	h.costBasis += notional
+	s.cash -= notional
+
+	s.trades = append(s.trades, Trade{
+		Time:       s.clock.Now(),
+		StrategyID: strategyID,
+		Asset:      asset,
+		Side:       SideBuy,
+		Quantity:   quantity,
+		Price:      price,
+		Fee:        fee,
+		Reason:     reason,
+	})
+	return nil
+}
+
+// sell disposes of up to quantity of asset at price, realizing P&L against average cost
+func (s *session) sell(strategyID, asset string, quantity, price float64, reason string) error {
+	h := s.holding(strategyID, asset)
+	quantity = math.Min(quantity, h.quantity)
+	if quantity <= 0 {
+		return fmt.Errorf("no %s position to sell", asset)
+	}
+	if price <= 0 {
+		return fmt.Errorf("no price for %s", asset)
+	}
+
+	gross := quantity * price
+	fee := gross * s.cfg.FeeRate
+	cost := h.costBasis * quantity / h.quantity
+	pnl := gross - fee - cost
+
+	h.quantity -= quantity
+	h.costBasis -= cost
+	if h.quantity <= 1e-12 {
+		h.quantity, h.costBasis = 0, 0
+	}
+	s.cash += gross - fee
+	s.realized[strategyID] += pnl
+
+	s.trades = append(s.trades, Trade{
+		Time:        s.clock.Now(),
+		StrategyID:  strategyID,
+		Asset:       asset,
+		Side:        SideSell,
+		Quantity:    quantity,
+		Price:       price,
+		Fee:         fee,
+		RealizedPnL: pnl,
+		Reason:      reason,
+	})
+	return nil
+}
+
+// hasPosition reports whether a strategy holds any asset
+func (s *session) hasPosition(strategyID string) bool {
+	for _, h := range s.positions[strategyID] {
+		if h.quantity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// marketValue returns the mark-to-market value and cost basis of a strategy's holdings
+func (s *session) marketValue(strategyID string) (value, cost float64) {
+	for asset, h := range s.positions[strategyID] {
+		if h.quantity == 0 {
+			continue
+		}
+		if p, ok := s.price(asset); ok {
+			value += h.quantity * p
+		}
+		cost += h.costBasis
+	}
+	return value, cost
+}
+
+func (s *session) totalEquity() float64 {
+	equity := s.cash
+	for id := range s.positions {
+		value, _ := s.marketValue(id)
+		equity += value
+	}
+	return equity
+}
+
+// record appends the account and per-strategy equity for a step
+func (s *session) record(at time.Time, ids []string) {
+	s.times = append(s.times, at)
+	s.equity = append(s.equity, s.totalEquity())
+
+	for _, id := range ids {
+		value, cost := s.marketValue(id)
+		pnl := s.realized[id] + value - cost
+		s.curves[id] = append(s.curves[id], s.cfg.InitialCapital+pnl)
+	}
+}
+
+// syncPortfolio mirrors the simulated account into the engine's portfolio for position sizing
+func (s *session) syncPortfolio(pm *defi.PortfolioManager) {
+	pm.Assets = map[string]*defi.PortfolioAsset{
+		s.cfg.QuoteAsset: {Symbol: s.cfg.QuoteAsset, Amount: s.cash, Value: s.cash},
+	}
+	for _, byAsset := range s.positions {
+		for asset, h := range byAsset {
+			if h.quantity == 0 {
+				continue
+			}
+			p, _ := s.price(asset)
+			existing, ok := pm.Assets[asset]
+			if !ok {
+				existing = &defi.PortfolioAsset{Symbol: asset}
+				pm.Assets[asset] = existing
+			}
+			existing.Amount += h.quantity
+			existing.Value += h.quantity * p
+		}
+	}
+}
+
+// result assembles trade statistics and performance metrics
+func (s *session) result(ids []string) *Result {
+	ppy := periodsPerYear(s.times)
+
+	result := &Result{
+		Steps:          len(s.times),
+		InitialCapital: s.cfg.InitialCapital,
+		Trades:         s.trades,
+		Equity:         make([]EquityPoint, len(s.times)),
+		Stats:          make(map[string]*defi.StrategyPerformanceStats, len(ids)),
+	}
+	for i, at := range s.times {
+		result.Equity[i] = EquityPoint{Time: at, Equity: s.equity[i]}
+	}
+	if len(s.times) > 0 {
+		result.Start = s.times[0]
+		result.End = s.times[len(s.times)-1]
+		result.FinalEquity = s.equity[len(s.equity)-1]
+	}
+
+	for _, id := range ids {
+		stats := &defi.StrategyPerformanceStats{}
+		for _, trade := range s.trades {
+			if trade.StrategyID == id {
+				addTrade(stats, trade)
+			}
+		}
+		finishStats(stats)
+
+		curve := s.curves[id]
+		returns := periodReturns(curve)
+		stats.SharpeRatio = sharpeRatio(returns, ppy)
+		stats.SortinoRatio = sortinoRatio(returns, ppy)
+		stats.MaxDrawdown = maxDrawdown(curve)
+		result.Stats[id] = stats
+	}
+
+	overall := &defi.StrategyPerformanceStats{}
+	for _, trade := range s.trades {
+		addTrade(overall, trade)
+	}
+	finishStats(overall)
+
+	returns := periodReturns(s.equity)
+	performance := &defi.StrategyPerformance{
+		Volatility:   sampleStdDev(returns) * math.Sqrt(ppy),
+		SharpeRatio:  sharpeRatio(returns, ppy),
+		SortinoRatio: sortinoRatio(returns, ppy),
+		MaxDrawdown:  maxDrawdown(s.equity),
+		WinRate:      overall.WinRate,
+	}
+	if s.cfg.InitialCapital > 0 {
+		performance.TotalReturn = result.FinalEquity/s.cfg.InitialCapital - 1
+	}
+	if len(returns) > 0 && performance.TotalReturn > -1 {
+		performance.AnnualizedReturn = math.Pow(1+performance.TotalReturn, ppy/float64(len(returns))) - 1
+	}
+	// Profit factor is left at zero when there are no losing trades
+	if overall.TotalLoss > 0 {
+		performance.ProfitFactor = overall.TotalProfit / overall.TotalLoss
+	}
+	result.Performance = performance
+
+	return result
+}
+
+// addTrade folds a fill into trade statistics; only sells realize P&L
+func addTrade(stats *defi.StrategyPerformanceStats, trade Trade) {
+	stats.TotalTrades++
+	if trade.Side != SideSell {
+		return
+	}
+
+	switch {
+	case trade.RealizedPnL > 0:
+		stats.WinningTrades++
+		stats.TotalProfit += trade.RealizedPnL
+		stats.MaxProfit = math.Max(stats.MaxProfit, trade.RealizedPnL)
+	case trade.RealizedPnL < 0:
+		loss := -trade.RealizedPnL
+		stats.LosingTrades++
+		stats.TotalLoss += loss
+		stats.MaxLoss = math.Max(stats.MaxLoss, loss)
+	}
+}
+
+func finishStats(stats *defi.StrategyPerformanceStats) {
+	if stats.WinningTrades > 0 {
+		stats.AvgProfit = stats.TotalProfit / float64(stats.WinningTrades)
+	}
+	if stats.LosingTrades > 0 {
+		stats.AvgLoss = stats.TotalLoss / float64(stats.LosingTrades)
+	}
+	if closed := stats.WinningTrades + stats.LosingTrades; closed > 0 {
+		stats.WinRate = float64(stats.WinningTrades) / float64(closed)
+	}
+}
+
+// actionExecutor fills StrategyEngine swap actions against the simulated account
+type actionExecutor struct {
+	session *session
+}
+
+func (e *actionExecutor) ExecuteAction(strategy *defi.TradingStrategy, action defi.StrategyAction) error {
+	if action.Type != defi.ActionSwap {
+		return fmt.Errorf("action type %s is not simulated", action.Type)
+	}
+
+	from, _ := action.Parameters["from_token"].(string)
+	to, _ := action.Parameters["to_token"].(string)
+	amount, _ := action.Parameters["amount"].(float64)
+	if from == "" || to == "" || amount <= 0 {
+		return fmt.Errorf("swap action %s requires from_token, to_token and amount", action.ID)
+	}
+
+	s := e.session
+	switch {
+	case stablecoins[from] && !stablecoins[to]:
+		price, ok := s.fillPrice(to, from, action.Target)
+		if !ok {
+			return fmt.Errorf("no price for %s", to)
+		}
+		notional := amount
+		if limit := strategy.Parameters.MaxPositionSize; limit > 0 {
+			notional = math.Min(notional, limit*s.totalEquity())
+		}
+		return s.buy(strategy.ID, to, notional, price, action.ID)
+	case !stablecoins[from] && stablecoins[to]:
+		price, ok := s.fillPrice(from, to, action.Target)
+		if !ok {
+			return fmt.Errorf("no price for %s", from)
+		}
+		return s.sell(strategy.ID, from, amount, price, action.ID)
+	default:
+		return fmt.Errorf("swap %s -> %s is not simulated", from, to)
+	}
+}
+
+// tradeExecutor fills AdvancedStrategyEngine entries and exits against the simulated account
+type tradeExecutor struct {
+	session *session
+}
+
+func (e *tradeExecutor) OpenPosition(strategy *defi.AdvancedTradingStrategy, size float64) error {
+	asset := strategy.Parameters.TargetAssets[0]
+	price, ok := e.session.price(asset)
+	if !ok {
+		return fmt.Errorf("no price for %s", asset)
+	}
+	return e.session.buy(strategy.ID, asset, size, price, "entry")
+}
+
+func (e *tradeExecutor) ClosePosition(strategy *defi.AdvancedTradingStrategy) error {
+	return e.closeAll(strategy.ID, "exit")
+}
+
+func (e *tradeExecutor) HasOpenPosition(strategyID string) bool {
+	return e.session.hasPosition(strategyID)
+}
+
+// closeAll sells every holding of a strategy in asset order
+func (e *tradeExecutor) closeAll(strategyID, reason string) error {
+	s := e.session
+	assets := make([]string, 0, len(s.positions[strategyID]))
+	for asset, h := range s.positions[strategyID] {
+		if h.quantity > 0 {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Strings(assets)
+
+	for _, asset := range assets {
+		price, ok := s.price(asset)
+		if !ok {
+			return fmt.Errorf("no price for %s", asset)
+		}
+		if err := s.sell(strategyID, asset, s.positions[strategyID][asset].quantity, price, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyExitLevels closes a strategy's position when its stop-loss or take-profit is reached
+func (e *tradeExecutor) applyExitLevels(strategy *defi.AdvancedTradingStrategy) {
+	if !e.session.hasPosition(strategy.ID) {
+		return
+	}
+
+	value, cost := e.session.marketValue(strategy.ID)
+	if cost <= 0 {
+		return
+	}
+	change := value/cost - 1
+
+	risk := strategy.RiskParameters
+	switch {
+	case risk.StopLossPercent > 0 && change <= -risk.StopLossPercent:
+		_ = e.closeAll(strategy.ID, "stop_loss")
+	case risk.TakeProfitPercent > 0 && change >= risk.TakeProfitPercent:
+		_ = e.closeAll(strategy.ID, "take_profit")
+	}
+}
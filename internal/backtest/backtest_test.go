@@ -0,0 +1,190 @@
+package backtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := `timestamp,price,volume
+1704067200,2000,10
+1704070800,2010.5,12
+`
+	candles, err := ReadCSV(strings.NewReader(data), "ETH")
+	require.NoError(t, err)
+	require.Len(t, candles, 2)
+
+	assert.Equal(t, "ETH", candles[0].Symbol)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), candles[0].Time)
+	assert.Equal(t, 2000.0, candles[0].Open)
+	assert.Equal(t, 2010.5, candles[1].Close)
+	assert.Equal(t, 12.0, candles[1].Volume)
+}
+
+func TestReadCSV_Errors(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("timestamp,close\n2024-01-01,100\n"), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing symbol")
+
+	_, err = ReadCSV(strings.NewReader("timestamp,symbol,volume\n2024-01-01,ETH,5\n"), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing close price")
+
+	_, err = ReadCSV(strings.NewReader("timestamp,symbol,close\nyesterday,ETH,5\n"), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timestamp")
+}
+
+func TestLoadCandles(t *testing.T) {
+	candles, err := LoadCandles("testdata/eth_hourly.jsonl", "ETH")
+	require.NoError(t, err)
+	require.Len(t, candles, 8)
+	assert.Equal(t, "ETH", candles[0].Symbol)
+	assert.Equal(t, 2010.0, candles[0].High)
+
+	candles, err = LoadCandles("testdata/eth_usdc_dex.csv", "")
+	require.NoError(t, err)
+	require.Len(t, candles, 10)
+	// Same-timestamp rows are ordered by symbol
+	assert.Equal(t, "sushiswap:ETH/USDC", candles[0].Symbol)
+	assert.Equal(t, "uniswap:ETH/USDC", candles[1].Symbol)
+
+	_, err = LoadCandles("testdata/prices.parquet", "ETH")
+	require.Error(t, err)
+}
+
+func TestStats(t *testing.T) {
+	equity := []float64{100, 120, 90, 130}
+
+	assert.InDelta(t, 0.25, maxDrawdown(equity), 1e-9)
+	assert.InDeltaSlice(t, []float64{0.2, -0.25, 130.0/90.0 - 1}, periodReturns(equity), 1e-9)
+	assert.Zero(t, sharpeRatio([]float64{0.01, 0.01, 0.01}, 365))
+	assert.Zero(t, sortinoRatio([]float64{0.01, 0.02}, 365))
+	assert.Greater(t, sortinoRatio([]float64{0.02, -0.01, 0.03}, 365), 0.0)
+
+	hourly := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC),
+	}
+	assert.Equal(t, 365.0*24, periodsPerYear(hourly))
+}
+
+func TestBacktester_Run(t *testing.T) {
+	candles, err := LoadCandles("testdata/eth_usdc_dex.csv", "")
+	require.NoError(t, err)
+
+	bt := New(DefaultConfig())
+	result, err := bt.Run(context.Background(), candles, defi.ArbitrageStrategy())
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, result.Steps)
+	assert.Len(t, result.Equity, 5)
+
+	// Spreads above 1% open at 01:00 and 03:00, filled at the uniswap price
+	require.Len(t, result.Trades, 2)
+	assert.Equal(t, SideBuy, result.Trades[0].Side)
+	assert.Equal(t, 2010.0, result.Trades[0].Price)
+	assert.Equal(t, 2060.0, result.Trades[1].Price)
+	// Notional is capped at MaxPositionSize of equity
+	assert.InDelta(t, 500*0.997/2010, result.Trades[0].Quantity, 1e-9)
+
+	stats := result.Stats["arbitrage_eth_usdc"]
+	require.NotNil(t, stats)
+	assert.Equal(t, 2, stats.TotalTrades)
+	assert.Zero(t, stats.WinRate)
+}
+
+func TestBacktester_RunAdvanced(t *testing.T) {
+	candles, err := LoadCandles("testdata/eth_hourly.jsonl", "ETH")
+	require.NoError(t, err)
+
+	strategy := &defi.AdvancedTradingStrategy{
+		ID:   "test_momentum",
+		Name: "Test Momentum",
+		Parameters: defi.AdvancedStrategyParameters{
+			TargetAssets: []string{"ETH", "USDC"},
+		},
+		RiskParameters: defi.RiskParameters{
+			StopLossPercent:   0.04,
+			TakeProfitPercent: 0.03,
+		},
+		EntryConditions: []defi.AdvancedCondition{
+			{ID: "volume", Metric: "volume_ratio", Operator: ">", Threshold: 0.5, Weight: 1.0, Metadata: map[string]interface{}{"asset": "ETH"}},
+		},
+		ExitConditions: []defi.AdvancedCondition{
+			{ID: "volume_spike", Metric: "volume_ratio", Operator: ">", Threshold: 2.0, Weight: 1.0, Metadata: map[string]interface{}{"asset": "ETH"}},
+		},
+		PositionSizing: defi.PositionSizingModel{
+			Type:          defi.PositionSizingFixed,
+			FixedFraction: &defi.FixedFraction{Fraction: 0.5},
+		},
+		IsActive: true,
+	}
+
+	bt := New(DefaultConfig())
+	result, err := bt.RunAdvanced(context.Background(), candles, strategy)
+	require.NoError(t, err)
+
+	reasons := make([]string, len(result.Trades))
+	for i, trade := range result.Trades {
+		reasons[i] = trade.Reason
+	}
+	assert.Equal(t, []string{"entry", "take_profit", "entry", "stop_loss", "entry"}, reasons)
+	assert.InDelta(t, 5000.0, result.Trades[0].Quantity*result.Trades[0].Price+result.Trades[0].Fee, 1e-6)
+
+	stats := result.Stats["test_momentum"]
+	require.NotNil(t, stats)
+	assert.Equal(t, 5, stats.TotalTrades)
+	assert.Equal(t, 1, stats.WinningTrades)
+	assert.Equal(t, 1, stats.LosingTrades)
+	assert.Equal(t, 0.5, stats.WinRate)
+	assert.Greater(t, stats.MaxDrawdown, 0.0)
+	assert.Same(t, stats, strategy.PerformanceStats)
+
+	// Replays are deterministic
+	again, err := New(DefaultConfig()).RunAdvanced(context.Background(), candles, strategy)
+	require.NoError(t, err)
+	assert.Equal(t, result.Trades, again.Trades)
+	assert.Equal(t, result.FinalEquity, again.FinalEquity)
+}
+
+func TestBacktester_Errors(t *testing.T) {
+	bt := New(Config{})
+
+	_, err := bt.Run(context.Background(), nil)
+	require.Error(t, err)
+
+	_, err = bt.Run(context.Background(), nil, defi.ArbitrageStrategy())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no price data")
+
+	_, err = bt.RunAdvanced(context.Background(), nil, &defi.AdvancedTradingStrategy{ID: "empty"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no target assets")
+}
+
+func TestCommand(t *testing.T) {
+	var out strings.Builder
+	cmd := NewCommand()
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--data", "testdata/eth_hourly.jsonl", "--symbol", "ETH", "--strategy", "trend_following", "--trades"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "Initial capital:   10000.00")
+	assert.Contains(t, out.String(), "Trades:")
+
+	cmd = NewCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--data", "testdata/eth_hourly.jsonl", "--strategy", "momentum"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown strategy "momentum"`)
+}
@@ -0,0 +1,111 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi"
+)
+
+// strategies maps CLI strategy names to their constructors
+var strategies = map[string]func() interface{}{
+	"arbitrage":       func() interface{} { return defi.ArbitrageStrategy() },
+	"mean_reversion":  func() interface{} { return defi.MeanReversionStrategy() },
+	"trend_following": func() interface{} { return defi.TrendFollowingStrategy() },
+	"stat_arb":        func() interface{} { return defi.StatisticalArbitrageStrategy() },
+}
+
+// NewCommand returns the backtest subcommand the CLIs share
+func NewCommand() *cobra.Command {
+	cfg := DefaultConfig()
+	var (
+		dataPath     string
+		symbol       string
+		strategyName string
+		showTrades   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backtest",
+		Short: "Replay historical prices through a trading strategy",
+		Long: `Replay recorded OHLCV or price data (CSV or JSONL) through a strategy on a
+simulated clock and report the trade log and performance statistics.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newStrategy, ok := strategies[strategyName]
+			if !ok {
+				return fmt.Errorf("unknown strategy %q (available: %s)", strategyName, strings.Join(strategyNames(), ", "))
+			}
+
+			candles, err := LoadCandles(dataPath, symbol)
+			if err != nil {
+				return err
+			}
+
+			bt := New(cfg)
+			var result *Result
+			switch strategy := newStrategy().(type) {
+			case *defi.TradingStrategy:
+				result, err = bt.Run(context.Background(), candles, strategy)
+			case *defi.AdvancedTradingStrategy:
+				result, err = bt.RunAdvanced(context.Background(), candles, strategy)
+			}
+			if err != nil {
+				return err
+			}
+
+			printResult(cmd.OutOrStdout(), result, showTrades)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&dataPath, "data", "d", "", "Price data file (.csv or .jsonl)")
+	cmd.Flags().StringVar(&symbol, "symbol", "", "Symbol for rows without a symbol column")
+	cmd.Flags().StringVarP(&strategyName, "strategy", "s", "mean_reversion", "Strategy to backtest ("+strings.Join(strategyNames(), ", ")+")")
+	cmd.Flags().Float64Var(&cfg.InitialCapital, "capital", cfg.InitialCapital, "Initial capital in the quote asset")
+	cmd.Flags().Float64Var(&cfg.FeeRate, "fee", cfg.FeeRate, "Fee rate charged per fill")
+	cmd.Flags().BoolVar(&showTrades, "trades", false, "Print the full trade log")
+	_ = cmd.MarkFlagRequired("data")
+
+	return cmd
+}
+
+func strategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printResult(w io.Writer, result *Result, showTrades bool) {
+	perf := result.Performance
+
+	fmt.Fprintf(w, "Period:            %s -> %s (%d steps)\n", result.Start.Format("2006-01-02 15:04"), result.End.Format("2006-01-02 15:04"), result.Steps)
+	fmt.Fprintf(w, "Initial capital:   %.2f\n", result.InitialCapital)
+	fmt.Fprintf(w, "Final equity:      %.2f\n", result.FinalEquity)
+	fmt.Fprintf(w, "Total return:      %.2f%%\n", perf.TotalReturn*100)
+	fmt.Fprintf(w, "Annualized return: %.2f%%\n", perf.AnnualizedReturn*100)
+	fmt.Fprintf(w, "Volatility:        %.2f%%\n", perf.Volatility*100)
+	fmt.Fprintf(w, "Sharpe ratio:      %.2f\n", perf.SharpeRatio)
+	fmt.Fprintf(w, "Sortino ratio:     %.2f\n", perf.SortinoRatio)
+	fmt.Fprintf(w, "Max drawdown:      %.2f%%\n", perf.MaxDrawdown*100)
+	fmt.Fprintf(w, "Win rate:          %.2f%%\n", perf.WinRate*100)
+	fmt.Fprintf(w, "Trades:            %d\n", len(result.Trades))
+
+	if !showTrades {
+		return
+	}
+
+	fmt.Fprintln(w)
+	for _, trade := range result.Trades {
+		fmt.Fprintf(w, "%s  %-4s %-6s qty=%.6f price=%.2f fee=%.2f pnl=%.2f (%s)\n",
+			trade.Time.Format("2006-01-02 15:04"), trade.Side, trade.Asset,
+			trade.Quantity, trade.Price, trade.Fee, trade.RealizedPnL, trade.Reason)
+	}
+}
@@ -0,0 +1,234 @@
+package backtest
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+)
+
+// timestampLayouts are the accepted textual timestamp formats
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// LoadCandles reads a CSV or JSONL price file, choosing the format from the extension.
+// defaultSymbol is used for rows that do not carry a symbol column.
+func LoadCandles(path, defaultSymbol string) ([]market.Candle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price data: %w", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSV(file, defaultSymbol)
+	case ".jsonl", ".ndjson":
+		return ReadJSONL(file, defaultSymbol)
+	default:
+		return nil, fmt.Errorf("unsupported price data format: %s", filepath.Ext(path))
+	}
+}
+
+// ReadCSV parses OHLCV rows from CSV with a header line. A single "price" column
+// may stand in for open/high/low/close.
+func ReadCSV(r io.Reader, defaultSymbol string) ([]market.Candle, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	var candles []market.Candle
+	line := 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(row) {
+				fields[name] = strings.TrimSpace(row[i])
+			}
+		}
+
+		candle, err := parseRecord(fields, defaultSymbol)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		candles = append(candles, candle)
+	}
+
+	sortCandles(candles)
+	return candles, nil
+}
+
+// ReadJSONL parses one JSON object per line using the same field names as ReadCSV
+func ReadJSONL(r io.Reader, defaultSymbol string) ([]market.Candle, error) {
+	scanner := bufio.NewScanner(r)
+
+	var candles []market.Candle
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		fields := make(map[string]string, len(raw))
+		for key, value := range raw {
+			switch v := value.(type) {
+			case string:
+				fields[strings.ToLower(key)] = v
+			case float64:
+				fields[strings.ToLower(key)] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+
+		candle, err := parseRecord(fields, defaultSymbol)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		candles = append(candles, candle)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sortCandles(candles)
+	return candles, nil
+}
+
+// parseRecord converts a field map into a candle
+func parseRecord(fields map[string]string, defaultSymbol string) (market.Candle, error) {
+	var candle market.Candle
+
+	ts := firstField(fields, "timestamp", "time", "date")
+	if ts == "" {
+		return candle, fmt.Errorf("missing timestamp")
+	}
+	at, err := parseTimestamp(ts)
+	if err != nil {
+		return candle, err
+	}
+	candle.Time = at
+
+	candle.Symbol = firstField(fields, "symbol", "asset")
+	if candle.Symbol == "" {
+		candle.Symbol = defaultSymbol
+	}
+	if candle.Symbol == "" {
+		return candle, fmt.Errorf("missing symbol")
+	}
+
+	if price := fields["price"]; price != "" && fields["close"] == "" {
+		p, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return candle, fmt.Errorf("invalid price %q", price)
+		}
+		candle.Open, candle.High, candle.Low, candle.Close = p, p, p, p
+	} else {
+		values := []*float64{&candle.Open, &candle.High, &candle.Low, &candle.Close}
+		for i, name := range []string{"open", "high", "low", "close"} {
+			raw := fields[name]
+			if raw == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return candle, fmt.Errorf("invalid %s %q", name, raw)
+			}
+			*values[i] = v
+		}
+		if candle.Close == 0 {
+			return candle, fmt.Errorf("missing close price")
+		}
+		if candle.Open == 0 {
+			candle.Open = candle.Close
+		}
+		if candle.High == 0 {
+			candle.High = max(candle.Open, candle.Close)
+		}
+		if candle.Low == 0 {
+			candle.Low = min(candle.Open, candle.Close)
+		}
+	}
+
+	if raw := fields["volume"]; raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return candle, fmt.Errorf("invalid volume %q", raw)
+		}
+		candle.Volume = v
+	}
+
+	return candle, nil
+}
+
+// parseTimestamp accepts RFC3339-style strings and unix seconds or milliseconds
+func parseTimestamp(value string) (time.Time, error) {
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		if n > 1e12 {
+			return time.UnixMilli(int64(n)).UTC(), nil
+		}
+		return time.Unix(int64(n), 0).UTC(), nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+func firstField(fields map[string]string, names ...string) string {
+	for _, name := range names {
+		if v := fields[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sortCandles orders candles by time, then symbol, so replays are deterministic
+func sortCandles(candles []market.Candle) {
+	sort.SliceStable(candles, func(i, j int) bool {
+		if !candles[i].Time.Equal(candles[j].Time) {
+			return candles[i].Time.Before(candles[j].Time)
+		}
+		return candles[i].Symbol < candles[j].Symbol
+	})
+}
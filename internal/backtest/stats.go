@@ -0,0 +1,112 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// periodReturns converts an equity curve into simple per-period returns
+func periodReturns(equity []float64) []float64 {
+	if len(equity) < 2 {
+		return nil
+	}
+
+	returns := make([]float64, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1] != 0 {
+			returns = append(returns, equity[i]/equity[i-1]-1)
+		}
+	}
+	return returns
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// sampleStdDev returns the sample standard deviation of values
+func sampleStdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - m) * (v - m)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
+
+// sharpeRatio returns the annualized Sharpe ratio of per-period returns (zero risk-free rate)
+func sharpeRatio(returns []float64, periodsPerYear float64) float64 {
+	sd := sampleStdDev(returns)
+	if sd == 0 {
+		return 0
+	}
+	return mean(returns) / sd * math.Sqrt(periodsPerYear)
+}
+
+// sortinoRatio returns the annualized Sortino ratio using downside deviation below zero
+func sortinoRatio(returns []float64, periodsPerYear float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+
+	downside := 0.0
+	for _, r := range returns {
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	dd := math.Sqrt(downside / float64(len(returns)))
+	if dd == 0 {
+		return 0
+	}
+	return mean(returns) / dd * math.Sqrt(periodsPerYear)
+}
+
+// maxDrawdown returns the largest peak-to-trough decline as a fraction of the peak
+func maxDrawdown(equity []float64) float64 {
+	peak := 0.0
+	worst := 0.0
+	for _, v := range equity {
+		if v > peak {
+			peak = v
+		}
+		if peak > 0 {
+			if dd := (peak - v) / peak; dd > worst {
+				worst = dd
+			}
+		}
+	}
+	return worst
+}
+
+// periodsPerYear infers the annualization factor from the median bar interval
+func periodsPerYear(times []time.Time) float64 {
+	if len(times) < 2 {
+		return 365
+	}
+
+	intervals := make([]time.Duration, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); d > 0 {
+			intervals = append(intervals, d)
+		}
+	}
+	if len(intervals) == 0 {
+		return 365
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	median := intervals[len(intervals)/2]
+	return float64(365*24*time.Hour) / float64(median)
+}
@@ -27,11 +27,13 @@ type Config struct {
 
 // ServerConfig contains server-related configuration
 type ServerConfig struct {
-	Host           string        `json:"host" yaml:"host" env:"SERVER_HOST"`
-	Port           int           `json:"port" yaml:"port" env:"SERVER_PORT"`
-	MaxConnections int           `json:"max_connections" yaml:"max_connections" env:"MAX_CONNECTIONS"`
-	Timeout        time.Duration `json:"timeout" yaml:"timeout" env:"SERVER_TIMEOUT"`
-	CORS           CORSConfig    `json:"cors" yaml:"cors"`
+	Host           string          `json:"host" yaml:"host" env:"SERVER_HOST"`
+	Port           int             `json:"port" yaml:"port" env:"SERVER_PORT"`
+	MaxConnections int             `json:"max_connections" yaml:"max_connections" env:"MAX_CONNECTIONS"`
+	Timeout        time.Duration   `json:"timeout" yaml:"timeout" env:"SERVER_TIMEOUT"`
+	CORS           CORSConfig      `json:"cors" yaml:"cors"`
+	Auth           AuthConfig      `json:"auth" yaml:"auth"`
+	RateLimit      RateLimitConfig `json:"rate_limit" yaml:"rate_limit"`
 }
 
 // CORSConfig contains CORS configuration
@@ -41,9 +43,46 @@ type CORSConfig struct {
 	AllowedHeaders []string `json:"allowed_headers" yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
 }
 
+// AuthConfig contains API authentication configuration
+type AuthConfig struct {
+	Enabled          bool           `json:"enabled" yaml:"enabled" env:"AUTH_ENABLED"`
+	SignatureMaxSkew time.Duration  `json:"signature_max_skew" yaml:"signature_max_skew"`
+	APIKeys          []APIKeyConfig `json:"api_keys" yaml:"api_keys"`
+}
+
+// APIKeyConfig describes a single API key and the scopes it grants.
+// Keys with a secret must sign every request with HMAC-SHA256.
+type APIKeyConfig struct {
+	Name   string   `json:"name" yaml:"name"`
+	Key    string   `json:"key" yaml:"key"`
+	Secret string   `json:"secret" yaml:"secret"`
+	Scopes []string `json:"scopes" yaml:"scopes"`
+}
+
+// RateLimitConfig configures token-bucket request limits. Each client (API key
+// or IP address) gets its own bucket per configured route; routes without an
+// entry share the default bucket. Route keys are "METHOD /path/template" or a
+// bare path template matching every method.
+type RateLimitConfig struct {
+	Enabled           bool                     `json:"enabled" yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	RequestsPerSecond float64                  `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int                      `json:"burst" yaml:"burst"`
+	Routes            map[string]RateLimitRule `json:"routes" yaml:"routes"`
+}
+
+// RateLimitRule is the refill rate and bucket size for one route
+type RateLimitRule struct {
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int     `json:"burst" yaml:"burst"`
+}
+
+// ValidAPIScopes lists the scopes an API key may be granted
+var ValidAPIScopes = []string{"read", "trade", "admin"}
+
 // DatabaseConfig contains database configuration
 type DatabaseConfig struct {
-	Driver   string `json:"driver" yaml:"driver" env:"DB_DRIVER"`
+	Driver   string `json:"driver" yaml:"driver" env:"DB_DRIVER"` // "file" or "memory"
+	Path     string `json:"path" yaml:"path" env:"DB_PATH"`
 	Host     string `json:"host" yaml:"host" env:"DB_HOST"`
 	Port     int    `json:"port" yaml:"port" env:"DB_PORT"`
 	Name     string `json:"name" yaml:"name" env:"DB_NAME"`
@@ -60,8 +99,32 @@ type BlockchainConfig struct {
 	GasLimit       uint64          `json:"gas_limit" yaml:"gas_limit" env:"GAS_LIMIT"`
 	Confirmations  int             `json:"confirmations" yaml:"confirmations" env:"CONFIRMATIONS"`
 	PrivateKey     string          `json:"private_key" yaml:"private_key" env:"PRIVATE_KEY"`
+	Gas            GasConfig       `json:"gas" yaml:"gas"`
+
+	// BundleExecutor is the address of the account's own bundle executor, an
+	// aggregate3 contract only the account may call. Bundles are refused
+	// without one.
+	BundleExecutor string `json:"bundle_executor" yaml:"bundle_executor" env:"BUNDLE_EXECUTOR"`
+}
+
+// GasConfig selects how transaction fees are priced. GasPrice in
+// BlockchainConfig is the fallback price when the node cannot suggest one and
+// GasLimit the smallest gas limit a transaction is sent with. Fees are in gwei;
+// zero caps and multipliers mean unset.
+type GasConfig struct {
+	Strategy             string  `json:"strategy" yaml:"strategy" env:"GAS_STRATEGY"`
+	Percentile           float64 `json:"percentile" yaml:"percentile"`
+	HistoryBlocks        uint64  `json:"history_blocks" yaml:"history_blocks"`
+	MaxFeePerGas         float64 `json:"max_fee_per_gas" yaml:"max_fee_per_gas"`
+	MaxPriorityFeePerGas float64 `json:"max_priority_fee_per_gas" yaml:"max_priority_fee_per_gas"`
+	GasLimitMultiplier   float64 `json:"gas_limit_multiplier" yaml:"gas_limit_multiplier"`
 }
 
+// ValidGasStrategies lists the supported fee pricing strategies. "percentile"
+// uses GasConfig.Percentile of recent priority fees and "fixed" always pays
+// BlockchainConfig.GasPrice.
+var ValidGasStrategies = []string{"fast", "standard", "economy", "percentile", "fixed"}
+
 // NetworkConfig contains configuration for a specific blockchain network
 type NetworkConfig struct {
 	Name     string `json:"name" yaml:"name"`
@@ -154,13 +217,27 @@ var DefaultConfig = Config{
 		MaxConnections: 100,
 		Timeout:        30 * time.Second,
 		CORS: CORSConfig{
-			AllowedOrigins: []string{"*"},
+			AllowedOrigins: []string{"http://localhost:3000"},
 			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders: []string{"*"},
+			AllowedHeaders: []string{"Content-Type", "X-API-Key", "X-Timestamp", "X-Signature"},
+		},
+		Auth: AuthConfig{
+			Enabled:          true,
+			SignatureMaxSkew: 5 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:           true,
+			RequestsPerSecond: 10,
+			Burst:             20,
+			Routes: map[string]RateLimitRule{
+				"POST /api/v1/defi/strategies/{strategyId}/execute": {RequestsPerSecond: 1, Burst: 5},
+				"POST /api/v1/agents/{agentId}/execute":             {RequestsPerSecond: 1, Burst: 5},
+			},
 		},
 	},
 	Database: DatabaseConfig{
-		Driver:   "postgres",
+		Driver:   "file",
+		Path:     "data/aegis.db",
 		Host:     "localhost",
 		Port:     5432,
 		Name:     "aegis_defi",
@@ -173,6 +250,12 @@ var DefaultConfig = Config{
 		GasPrice:       25,
 		GasLimit:       21000,
 		Confirmations:  3,
+		Gas: GasConfig{
+			Strategy:           "standard",
+			HistoryBlocks:      20,
+			MaxFeePerGas:       300,
+			GasLimitMultiplier: 1.2,
+		},
 		Networks: []NetworkConfig{
 			{
 				Name:     "ethereum",
@@ -375,6 +458,14 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	if err := c.Server.Auth.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Server.RateLimit.Validate(); err != nil {
+		return err
+	}
+
 	if c.Agents.MaxConcurrent <= 0 {
 		return fmt.Errorf("max concurrent agents must be positive")
 	}
@@ -387,6 +478,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("gas limit must be positive")
 	}
 
+	if err := c.Blockchain.Gas.Validate(); err != nil {
+		return err
+	}
+
 	// Validate risk parameters
 	if c.Agents.Risk.MaxPositionSize <= 0 || c.Agents.Risk.MaxPositionSize > 1 {
 		return fmt.Errorf("max position size must be between 0 and 1")
@@ -407,6 +502,110 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validate checks that API keys are named, unique and only grant known scopes
+func (a *AuthConfig) validate() error {
+	if a.SignatureMaxSkew < 0 {
+		return fmt.Errorf("signature max skew must not be negative")
+	}
+
+	names := make(map[string]bool, len(a.APIKeys))
+	keys := make(map[string]bool, len(a.APIKeys))
+	for _, apiKey := range a.APIKeys {
+		if apiKey.Name == "" {
+			return fmt.Errorf("api key name must be set")
+		}
+		if apiKey.Key == "" {
+			return fmt.Errorf("api key %s has no key value", apiKey.Name)
+		}
+		if names[apiKey.Name] {
+			return fmt.Errorf("duplicate api key name: %s", apiKey.Name)
+		}
+		if keys[apiKey.Key] {
+			return fmt.Errorf("api key %s reuses the key value of another entry", apiKey.Name)
+		}
+		names[apiKey.Name] = true
+		keys[apiKey.Key] = true
+
+		if len(apiKey.Scopes) == 0 {
+			return fmt.Errorf("api key %s has no scopes", apiKey.Name)
+		}
+		for _, scope := range apiKey.Scopes {
+			if !isValidAPIScope(scope) {
+				return fmt.Errorf("api key %s has invalid scope: %s", apiKey.Name, scope)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the default and per-route limits are usable when
+// rate limiting is enabled
+func (r *RateLimitConfig) Validate() error {
+	if !r.Enabled {
+		return nil
+	}
+
+	if err := (RateLimitRule{RequestsPerSecond: r.RequestsPerSecond, Burst: r.Burst}).validate(); err != nil {
+		return fmt.Errorf("default rate limit: %w", err)
+	}
+	for route, rule := range r.Routes {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rate limit for %s: %w", route, err)
+		}
+	}
+
+	return nil
+}
+
+func (r RateLimitRule) validate() error {
+	if r.RequestsPerSecond <= 0 {
+		return fmt.Errorf("requests per second must be positive")
+	}
+	if r.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	return nil
+}
+
+// Validate checks the gas strategy name and that caps and multipliers are sane
+func (g *GasConfig) Validate() error {
+	if g.Strategy != "" && !isValidGasStrategy(g.Strategy) {
+		return fmt.Errorf("unknown gas strategy %q (valid: %s)", g.Strategy, strings.Join(ValidGasStrategies, ", "))
+	}
+	if g.Strategy == "percentile" && (g.Percentile <= 0 || g.Percentile > 100) {
+		return fmt.Errorf("gas percentile must be in (0, 100]")
+	}
+	if g.MaxFeePerGas < 0 || g.MaxPriorityFeePerGas < 0 {
+		return fmt.Errorf("gas fee caps must not be negative")
+	}
+	if g.MaxFeePerGas > 0 && g.MaxPriorityFeePerGas > g.MaxFeePerGas {
+		return fmt.Errorf("max priority fee per gas must not exceed max fee per gas")
+	}
+	if g.GasLimitMultiplier != 0 && g.GasLimitMultiplier < 1 {
+		return fmt.Errorf("gas limit multiplier must be at least 1")
+	}
+	return nil
+}
+
+func isValidGasStrategy(strategy string) bool {
+	for _, valid := range ValidGasStrategies {
+		if strategy == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidAPIScope(scope string) bool {
+	for _, valid := range ValidAPIScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
 // Save saves the configuration to a file
 func (c *Config) Save(filePath string) error {
 	var data []byte
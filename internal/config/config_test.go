@@ -292,3 +292,105 @@ func TestConfigStructure(t *testing.T) {
 		t.Errorf("Expected health check interval 300s, got %v", config.Monitoring.HealthCheck)
 	}
 }
+
+func TestAuthConfigValidation(t *testing.T) {
+	config := DefaultConfig
+
+	if !config.Server.Auth.Enabled {
+		t.Error("Expected API authentication to be enabled by default")
+	}
+	for _, origin := range config.Server.CORS.AllowedOrigins {
+		if origin == "*" {
+			t.Error("Expected default CORS origins not to include a wildcard")
+		}
+	}
+
+	config.Server.Auth.APIKeys = []APIKeyConfig{
+		{Name: "dashboard", Key: "read-key", Scopes: []string{"read"}},
+		{Name: "bot", Key: "trade-key", Secret: "secret", Scopes: []string{"read", "trade"}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Valid API keys should not fail validation: %v", err)
+	}
+
+	invalid := map[string]APIKeyConfig{
+		"missing name":   {Key: "other-key", Scopes: []string{"read"}},
+		"missing key":    {Name: "other", Scopes: []string{"read"}},
+		"duplicate name": {Name: "dashboard", Key: "other-key", Scopes: []string{"read"}},
+		"duplicate key":  {Name: "other", Key: "read-key", Scopes: []string{"read"}},
+		"no scopes":      {Name: "other", Key: "other-key"},
+		"unknown scope":  {Name: "other", Key: "other-key", Scopes: []string{"write"}},
+	}
+	for name, apiKey := range invalid {
+		config.Server.Auth.APIKeys = []APIKeyConfig{
+			{Name: "dashboard", Key: "read-key", Scopes: []string{"read"}},
+			apiKey,
+		}
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected validation error for %s", name)
+		}
+	}
+}
+
+func TestRateLimitConfigValidation(t *testing.T) {
+	config := DefaultConfig
+	if !config.Server.RateLimit.Enabled {
+		t.Error("Expected rate limiting to be enabled by default")
+	}
+
+	limits := RateLimitConfig{Enabled: true, RequestsPerSecond: 5, Burst: 10}
+	if err := limits.Validate(); err != nil {
+		t.Errorf("Valid rate limit should not fail validation: %v", err)
+	}
+
+	limits.Burst = 0
+	if err := limits.Validate(); err == nil {
+		t.Error("Expected validation error for zero burst")
+	}
+	limits.Burst = 10
+
+	limits.Routes = map[string]RateLimitRule{"POST /tools/{name}": {RequestsPerSecond: 0, Burst: 1}}
+	if err := limits.Validate(); err == nil {
+		t.Error("Expected validation error for route without a refill rate")
+	}
+
+	limits.Enabled = false
+	if err := limits.Validate(); err != nil {
+		t.Errorf("Disabled rate limit should not be validated: %v", err)
+	}
+}
+
+func TestGasConfigValidation(t *testing.T) {
+	config := DefaultConfig
+	if config.Blockchain.Gas.Strategy != "standard" {
+		t.Errorf("Expected standard gas strategy by default, got %q", config.Blockchain.Gas.Strategy)
+	}
+	if err := config.Blockchain.Gas.Validate(); err != nil {
+		t.Errorf("Default gas config should be valid: %v", err)
+	}
+
+	// Unset values fall back to defaults
+	if err := (&GasConfig{}).Validate(); err != nil {
+		t.Errorf("Empty gas config should be valid: %v", err)
+	}
+
+	valid := GasConfig{Strategy: "percentile", Percentile: 75, MaxFeePerGas: 100, MaxPriorityFeePerGas: 5, GasLimitMultiplier: 1.5}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Valid gas config should not fail validation: %v", err)
+	}
+
+	invalid := map[string]GasConfig{
+		"unknown strategy":     {Strategy: "instant"},
+		"missing percentile":   {Strategy: "percentile"},
+		"percentile over 100":  {Strategy: "percentile", Percentile: 150},
+		"negative cap":         {MaxFeePerGas: -1},
+		"tip cap over fee cap": {MaxFeePerGas: 10, MaxPriorityFeePerGas: 20},
+		"multiplier below one": {GasLimitMultiplier: 0.5},
+	}
+	for name, gas := range invalid {
+		config.Blockchain.Gas = gas
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected validation error for %s", name)
+		}
+	}
+}
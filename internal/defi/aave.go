@@ -1,8 +1,10 @@
 package defi
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -12,12 +14,26 @@ import (
 // AaveManager handles Aave protocol interactions
 type AaveManager struct {
 	ContractManager *ContractManager
+
+	// Pool names the registered pool contract; "aave_v3_pool" by default,
+	// or "aave_lending_pool" for Aave V2's deposit, withdraw and borrow
+	Pool string
+
+	// Oracle names the registered Aave price oracle; "aave_oracle" by
+	// default
+	Oracle string
+
+	// Allowances, when set, approves the pool for deposits and repayments
+	// that exceed the current allowance and waits for the approval first
+	Allowances *AllowanceManager
 }
 
-// NewAaveManager creates a new Aave manager
+// NewAaveManager creates a new Aave manager for the Aave V3 Pool
 func NewAaveManager(cm *ContractManager) *AaveManager {
 	return &AaveManager{
 		ContractManager: cm,
+		Pool:            "aave_v3_pool",
+		Oracle:          "aave_oracle",
 	}
 }
 
@@ -45,13 +61,45 @@ type BorrowParams struct {
 	OnBehalfOf       common.Address
 }
 
+// RepayParams contains parameters for Aave repay. An Amount of MaxRepay
+// repays the whole debt, approving only the debt plus a small buffer.
+type RepayParams struct {
+	Asset            common.Address
+	Amount           *big.Int
+	InterestRateMode *big.Int
+	OnBehalfOf       common.Address
+}
+
+// MaxRepay repays the whole debt of a reserve
+var MaxRepay = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// RepayApprovalBufferBps is added to the debt approved for a MaxRepay so
+// interest accrued before the repayment is mined is still covered
+const RepayApprovalBufferBps = 10
+
 // ExecuteDeposit deposits assets to Aave
 func (am *AaveManager) ExecuteDeposit(params DepositParams) (*types.Transaction, error) {
 	log.Printf("Executing Aave deposit: %s %s", params.Amount.String(), params.Asset.Hex())
 
+	pool, err := am.pool()
+	if err != nil {
+		return nil, err
+	}
+	if am.Allowances != nil {
+		if err := am.Allowances.Require(params.Asset, pool.Address, params.Amount); err != nil {
+			return nil, fmt.Errorf("failed to approve deposit: %v", err)
+		}
+	}
+
+	// Aave V3 renamed deposit to supply
+	method := AaveDeposit
+	if _, exists := pool.ABI.Methods[AaveSupply]; exists {
+		method = AaveSupply
+	}
+
 	tx, err := am.ContractManager.TransactContract(
-		"aave_lending_pool",
-		AaveDeposit,
+		am.Pool,
+		method,
 		big.NewInt(0),
 		params.Asset,
 		params.Amount,
@@ -71,7 +119,7 @@ func (am *AaveManager) ExecuteWithdraw(params WithdrawParams) (*types.Transactio
 	log.Printf("Executing Aave withdraw: %s %s", params.Amount.String(), params.Asset.Hex())
 
 	tx, err := am.ContractManager.TransactContract(
-		"aave_lending_pool",
+		am.Pool,
 		AaveWithdraw,
 		big.NewInt(0),
 		params.Asset,
@@ -91,7 +139,7 @@ func (am *AaveManager) ExecuteBorrow(params BorrowParams) (*types.Transaction, e
 	log.Printf("Executing Aave borrow: %s %s", params.Amount.String(), params.Asset.Hex())
 
 	tx, err := am.ContractManager.TransactContract(
-		"aave_lending_pool",
+		am.Pool,
 		AaveBorrow,
 		big.NewInt(0),
 		params.Asset,
@@ -108,6 +156,139 @@ func (am *AaveManager) ExecuteBorrow(params BorrowParams) (*types.Transaction, e
 	return tx, nil
 }
 
+// ExecuteRepay repays borrowed assets to Aave
+func (am *AaveManager) ExecuteRepay(params RepayParams) (*types.Transaction, error) {
+	log.Printf("Executing Aave repay: %s %s", params.Amount.String(), params.Asset.Hex())
+
+	if err := validateRateMode(params.InterestRateMode); err != nil {
+		return nil, err
+	}
+	pool, err := am.pool()
+	if err != nil {
+		return nil, err
+	}
+	if am.Allowances != nil {
+		approval := params.Amount
+		if approval.Cmp(MaxRepay) == 0 {
+			if approval, err = am.repayApproval(params); err != nil {
+				return nil, err
+			}
+		}
+		if err := am.Allowances.Require(params.Asset, pool.Address, approval); err != nil {
+			return nil, fmt.Errorf("failed to approve repay: %v", err)
+		}
+	}
+
+	tx, err := am.ContractManager.TransactContract(
+		am.Pool,
+		AaveRepay,
+		big.NewInt(0),
+		params.Asset,
+		params.Amount,
+		params.InterestRateMode,
+		params.OnBehalfOf,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute repay: %v", err)
+	}
+
+	log.Printf("Aave repay transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// repayApproval returns the allowance a MaxRepay needs: the current debt of
+// the repaid rate mode plus RepayApprovalBufferBps, rather than an unlimited
+// approval
+func (am *AaveManager) repayApproval(params RepayParams) (*big.Int, error) {
+	reserve, err := am.reserveData(params.Asset)
+	if err != nil {
+		return nil, err
+	}
+	debtToken := reserve.VariableDebtTokenAddress
+	if params.InterestRateMode.Cmp(StableRate) == 0 {
+		debtToken = reserve.StableDebtTokenAddress
+	}
+
+	debt, err := am.ContractManager.tokenUint(debtToken, ERC20BalanceOf, params.OnBehalfOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get debt of %s: %v", params.Asset.Hex(), err)
+	}
+	if debt.Sign() == 0 {
+		return nil, fmt.Errorf("no Aave debt of %s to repay", params.Asset.Hex())
+	}
+
+	buffer := new(big.Int).Mul(debt, big.NewInt(RepayApprovalBufferBps))
+	buffer.Div(buffer, big.NewInt(10_000))
+	return buffer.Add(buffer, debt).Add(buffer, big.NewInt(1)), nil
+}
+
+// SetCollateral enables or disables a supplied asset as collateral for the
+// manager's account
+func (am *AaveManager) SetCollateral(asset common.Address, enabled bool) (*types.Transaction, error) {
+	tx, err := am.ContractManager.TransactContract(am.Pool, AaveSetUserUseReserveAsCollateral, big.NewInt(0), asset, enabled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set collateral: %v", err)
+	}
+	return tx, nil
+}
+
+// SetEMode selects the account's efficiency mode category; category 0
+// leaves e-mode
+func (am *AaveManager) SetEMode(category uint8) (*types.Transaction, error) {
+	tx, err := am.ContractManager.TransactContract(am.Pool, AaveSetUserEMode, big.NewInt(0), category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set e-mode: %v", err)
+	}
+	return tx, nil
+}
+
+// GetEMode returns user's efficiency mode category, 0 if none
+func (am *AaveManager) GetEMode(user common.Address) (uint8, error) {
+	result, err := am.ContractManager.CallContract(am.Pool, AaveGetUserEMode, user)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get e-mode: %v", err)
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("no e-mode returned")
+	}
+	category, ok := result[0].(*big.Int)
+	if !ok || !category.IsUint64() || category.Uint64() > math.MaxUint8 {
+		return 0, fmt.Errorf("invalid e-mode format")
+	}
+	return uint8(category.Uint64()), nil
+}
+
+// SwapBorrowRateMode switches the account's debt in asset from the given
+// rate mode to the other one
+func (am *AaveManager) SwapBorrowRateMode(asset common.Address, currentMode *big.Int) (*types.Transaction, error) {
+	if err := validateRateMode(currentMode); err != nil {
+		return nil, err
+	}
+
+	tx, err := am.ContractManager.TransactContract(am.Pool, AaveSwapBorrowRateMode, big.NewInt(0), asset, currentMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to swap borrow rate mode: %v", err)
+	}
+	return tx, nil
+}
+
+// pool returns the manager's registered pool contract
+func (am *AaveManager) pool() (*DeFiContract, error) {
+	pool, exists := am.ContractManager.Contract(am.Pool)
+	if !exists {
+		return nil, fmt.Errorf("contract %s not found", am.Pool)
+	}
+	return pool, nil
+}
+
+// validateRateMode accepts StableRate and VariableRate
+func validateRateMode(mode *big.Int) error {
+	if mode == nil || (mode.Cmp(StableRate) != 0 && mode.Cmp(VariableRate) != 0) {
+		return fmt.Errorf("invalid interest rate mode %v", mode)
+	}
+	return nil
+}
+
 // Common Aave interest rate modes
 var (
 	StableRate   = big.NewInt(1)
@@ -133,7 +314,7 @@ func (am *AaveManager) GetSupportedAssets() map[string]common.Address {
 func (am *AaveManager) MonitorTransaction(txHash common.Hash) (*types.Receipt, error) {
 	log.Printf("Monitoring Aave transaction: %s", txHash.Hex())
 
-	receipt, err := am.ContractManager.WaitForTransaction(txHash)
+	receipt, err := am.ContractManager.WaitForTransaction(context.Background(), txHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to monitor transaction: %v", err)
 	}
@@ -147,14 +328,91 @@ func (am *AaveManager) MonitorTransaction(txHash common.Hash) (*types.Receipt, e
 	return receipt, nil
 }
 
-// GetHealthFactor calculates health factor (simulated)
+// GetHealthFactor returns user's health factor; positions without debt
+// have an infinite health factor
 func (am *AaveManager) GetHealthFactor(user common.Address) (*big.Float, error) {
-	// In a real implementation, this would query Aave's data provider
-	// For now, return a simulated health factor
+	account, err := am.GetUserAccountData(user)
+	if err != nil {
+		return nil, err
+	}
 
-	// Simulate a healthy position
-	healthFactor := big.NewFloat(2.5)
+	healthFactor := account.HealthFactorFloat()
 	log.Printf("Health factor for %s: %.2f", user.Hex(), healthFactor)
-
 	return healthFactor, nil
 }
+
+// Name identifies Aave as a LendingProtocol
+func (am *AaveManager) Name() string {
+	return "aave"
+}
+
+// Supply deposits amount of asset for the manager's account
+func (am *AaveManager) Supply(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return am.ExecuteDeposit(DepositParams{
+		Asset:        asset,
+		Amount:       amount,
+		OnBehalfOf:   am.ContractManager.Transactor.From,
+		ReferralCode: AaveReferralCode,
+	})
+}
+
+// Withdraw withdraws amount of asset to the manager's account
+func (am *AaveManager) Withdraw(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return am.ExecuteWithdraw(WithdrawParams{
+		Asset:  asset,
+		Amount: amount,
+		To:     am.ContractManager.Transactor.From,
+	})
+}
+
+// Borrow borrows amount of asset at the variable rate
+func (am *AaveManager) Borrow(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return am.ExecuteBorrow(BorrowParams{
+		Asset:            asset,
+		Amount:           amount,
+		InterestRateMode: VariableRate,
+		ReferralCode:     AaveReferralCode,
+		OnBehalfOf:       am.ContractManager.Transactor.From,
+	})
+}
+
+// Repay repays variable-rate debt of asset; MaxRepay repays all of it
+func (am *AaveManager) Repay(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return am.ExecuteRepay(RepayParams{
+		Asset:            asset,
+		Amount:           amount,
+		InterestRateMode: VariableRate,
+		OnBehalfOf:       am.ContractManager.Transactor.From,
+	})
+}
+
+// SupplyRate returns asset's supply APY
+func (am *AaveManager) SupplyRate(asset common.Address) (float64, error) {
+	reserve, err := am.reserveData(asset)
+	if err != nil {
+		return 0, err
+	}
+	return RayToAPY(reserve.CurrentLiquidityRate), nil
+}
+
+// BorrowRate returns asset's variable borrow APY
+func (am *AaveManager) BorrowRate(asset common.Address) (float64, error) {
+	reserve, err := am.reserveData(asset)
+	if err != nil {
+		return 0, err
+	}
+	return RayToAPY(reserve.CurrentVariableBorrowRate), nil
+}
+
+// SupplyBalance returns the manager's account's aToken balance of asset
+func (am *AaveManager) SupplyBalance(asset common.Address) (*big.Int, error) {
+	reserve, err := am.reserveData(asset)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := am.ContractManager.tokenUint(reserve.ATokenAddress, ERC20BalanceOf, am.ContractManager.Transactor.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplied balance: %v", err)
+	}
+	return balance, nil
+}
@@ -0,0 +1,395 @@
+package defi
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// secondsPerYear is the year Aave's per-year rates are accrued over
+const secondsPerYear = 365 * 24 * 60 * 60
+
+var (
+	// ray is Aave's 27-decimal fixed point unit for rates
+	ray = new(big.Int).Exp(big.NewInt(10), big.NewInt(27), nil)
+
+	// wad is the 18-decimal fixed point unit of health factors
+	wad = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+)
+
+// AaveAccountData is a user's position totals from getUserAccountData.
+// Amounts are in the pool's base currency (USD with 8 decimals on Aave V3),
+// the liquidation threshold and LTV in basis points and the health factor
+// in wad.
+type AaveAccountData struct {
+	TotalCollateralBase  *big.Int
+	TotalDebtBase        *big.Int
+	AvailableBorrowsBase *big.Int
+	LiquidationThreshold *big.Int
+	LTV                  *big.Int
+	HealthFactor         *big.Int
+}
+
+// HealthFactorFloat returns the health factor as a number, infinite when
+// the user has no debt
+func (d *AaveAccountData) HealthFactorFloat() *big.Float {
+	if d.TotalDebtBase.Sign() == 0 {
+		return new(big.Float).SetInf(false)
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(d.HealthFactor), new(big.Float).SetInt(wad))
+}
+
+// AaveReserveConfiguration is a decoded reserve configuration bitmap. LTV,
+// liquidation threshold and reserve factor are in basis points; the
+// liquidation bonus is 10000 plus the bonus.
+type AaveReserveConfiguration struct {
+	LTV                    uint16
+	LiquidationThreshold   uint16
+	LiquidationBonus       uint16
+	Decimals               uint8
+	Active                 bool
+	Frozen                 bool
+	BorrowingEnabled       bool
+	StableBorrowingEnabled bool
+	Paused                 bool
+	ReserveFactor          uint16
+	EModeCategory          uint8
+}
+
+// ParseReserveConfiguration decodes Aave V3's reserve configuration bitmap
+func ParseReserveConfiguration(data *big.Int) AaveReserveConfiguration {
+	bits := func(from, width uint) uint64 {
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), width), big.NewInt(1))
+		return new(big.Int).And(new(big.Int).Rsh(data, from), mask).Uint64()
+	}
+	return AaveReserveConfiguration{
+		LTV:                    uint16(bits(0, 16)),
+		LiquidationThreshold:   uint16(bits(16, 16)),
+		LiquidationBonus:       uint16(bits(32, 16)),
+		Decimals:               uint8(bits(48, 8)),
+		Active:                 bits(56, 1) == 1,
+		Frozen:                 bits(57, 1) == 1,
+		BorrowingEnabled:       bits(58, 1) == 1,
+		StableBorrowingEnabled: bits(59, 1) == 1,
+		Paused:                 bits(60, 1) == 1,
+		ReserveFactor:          uint16(bits(64, 16)),
+		EModeCategory:          uint8(bits(168, 8)),
+	}
+}
+
+// AaveReserveData is a reserve's configuration, rates and utilization.
+// Rates are per-year rays; the APYs compound them every second.
+type AaveReserveData struct {
+	Asset         common.Address
+	ID            uint16
+	Configuration AaveReserveConfiguration
+
+	AToken            common.Address
+	StableDebtToken   common.Address
+	VariableDebtToken common.Address
+
+	LiquidityRate      *big.Int
+	VariableBorrowRate *big.Int
+	StableBorrowRate   *big.Int
+	SupplyAPY          float64
+	VariableBorrowAPY  float64
+	StableBorrowAPY    float64
+
+	// AvailableLiquidity is the asset held by the aToken, and Utilization
+	// the share of the reserve that is borrowed
+	AvailableLiquidity *big.Int
+	TotalStableDebt    *big.Int
+	TotalVariableDebt  *big.Int
+	Utilization        float64
+}
+
+// AavePosition is a user's Aave position with its per-reserve breakdown
+type AavePosition struct {
+	User          common.Address
+	Account       *AaveAccountData
+	EModeCategory uint8
+
+	// Reserves holds the reserves the user supplies or borrows
+	Reserves []AaveReservePosition
+}
+
+// AaveReservePosition is a user's supply and debt in one reserve, with the
+// reserve's LTV and liquidation threshold in basis points
+type AaveReservePosition struct {
+	Asset                common.Address
+	Supplied             *big.Int
+	StableDebt           *big.Int
+	VariableDebt         *big.Int
+	UsedAsCollateral     bool
+	LTV                  uint16
+	LiquidationThreshold uint16
+}
+
+// reserveDataTuple is the pool's ReserveData struct
+type reserveDataTuple struct {
+	Configuration               struct{ Data *big.Int }
+	LiquidityIndex              *big.Int
+	CurrentLiquidityRate        *big.Int
+	VariableBorrowIndex         *big.Int
+	CurrentVariableBorrowRate   *big.Int
+	CurrentStableBorrowRate     *big.Int
+	LastUpdateTimestamp         *big.Int
+	Id                          uint16
+	ATokenAddress               common.Address
+	StableDebtTokenAddress      common.Address
+	VariableDebtTokenAddress    common.Address
+	InterestRateStrategyAddress common.Address
+	AccruedToTreasury           *big.Int
+	Unbacked                    *big.Int
+	IsolationModeTotalDebt      *big.Int
+}
+
+// GetUserAccountData returns user's position totals across all reserves
+func (am *AaveManager) GetUserAccountData(user common.Address) (*AaveAccountData, error) {
+	result, err := am.ContractManager.CallContract(am.Pool, AaveGetUserAccountData, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user account data: %v", err)
+	}
+	if len(result) != 6 {
+		return nil, fmt.Errorf("invalid account data format")
+	}
+
+	values := make([]*big.Int, len(result))
+	for i := range result {
+		value, ok := result[i].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("invalid account data format")
+		}
+		values[i] = value
+	}
+	return &AaveAccountData{
+		TotalCollateralBase:  values[0],
+		TotalDebtBase:        values[1],
+		AvailableBorrowsBase: values[2],
+		LiquidationThreshold: values[3],
+		LTV:                  values[4],
+		HealthFactor:         values[5],
+	}, nil
+}
+
+// FlashLoanPremiumBps returns the pool's flash loan fee in basis points
+func (am *AaveManager) FlashLoanPremiumBps() (int64, error) {
+	result, err := am.ContractManager.CallContract(am.Pool, AaveFlashLoanPremiumTotal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get flash loan premium: %v", err)
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("no flash loan premium returned")
+	}
+	premium, ok := result[0].(*big.Int)
+	if !ok || !premium.IsInt64() {
+		return 0, fmt.Errorf("invalid flash loan premium format")
+	}
+	return premium.Int64(), nil
+}
+
+// GetReserveData returns a reserve's configuration, interest rates and
+// utilization
+func (am *AaveManager) GetReserveData(asset common.Address) (*AaveReserveData, error) {
+	reserve, err := am.reserveData(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &AaveReserveData{
+		Asset:              asset,
+		ID:                 reserve.Id,
+		Configuration:      ParseReserveConfiguration(reserve.Configuration.Data),
+		AToken:             reserve.ATokenAddress,
+		StableDebtToken:    reserve.StableDebtTokenAddress,
+		VariableDebtToken:  reserve.VariableDebtTokenAddress,
+		LiquidityRate:      reserve.CurrentLiquidityRate,
+		VariableBorrowRate: reserve.CurrentVariableBorrowRate,
+		StableBorrowRate:   reserve.CurrentStableBorrowRate,
+		SupplyAPY:          RayToAPY(reserve.CurrentLiquidityRate),
+		VariableBorrowAPY:  RayToAPY(reserve.CurrentVariableBorrowRate),
+		StableBorrowAPY:    RayToAPY(reserve.CurrentStableBorrowRate),
+	}
+
+	cm := am.ContractManager
+	if data.AvailableLiquidity, err = cm.tokenUint(asset, ERC20BalanceOf, reserve.ATokenAddress); err != nil {
+		return nil, fmt.Errorf("failed to get available liquidity: %v", err)
+	}
+	if data.TotalStableDebt, err = cm.tokenUint(reserve.StableDebtTokenAddress, ERC20TotalSupply); err != nil {
+		return nil, fmt.Errorf("failed to get stable debt: %v", err)
+	}
+	if data.TotalVariableDebt, err = cm.tokenUint(reserve.VariableDebtTokenAddress, ERC20TotalSupply); err != nil {
+		return nil, fmt.Errorf("failed to get variable debt: %v", err)
+	}
+
+	debt := new(big.Int).Add(data.TotalStableDebt, data.TotalVariableDebt)
+	if total := new(big.Int).Add(debt, data.AvailableLiquidity); total.Sign() > 0 {
+		data.Utilization, _ = new(big.Float).Quo(new(big.Float).SetInt(debt), new(big.Float).SetInt(total)).Float64()
+	}
+	return data, nil
+}
+
+// GetPosition returns user's account totals, e-mode category and the
+// reserves they supply or borrow
+func (am *AaveManager) GetPosition(user common.Address) (*AavePosition, error) {
+	account, err := am.GetUserAccountData(user)
+	if err != nil {
+		return nil, err
+	}
+	category, err := am.GetEMode(user)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := am.ContractManager
+	pool, err := am.pool()
+	if err != nil {
+		return nil, err
+	}
+	var configuration struct{ Config struct{ Data *big.Int } }
+	result, err := cm.CallContract(am.Pool, AaveGetUserConfiguration, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user configuration: %v", err)
+	}
+	if err := pool.ABI.Methods[AaveGetUserConfiguration].Outputs.Copy(&configuration, result); err != nil {
+		return nil, fmt.Errorf("failed to unpack user configuration: %v", err)
+	}
+
+	result, err = cm.CallContract(am.Pool, AaveGetReservesList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserves: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no reserves returned")
+	}
+	assets, ok := result[0].([]common.Address)
+	if !ok {
+		return nil, fmt.Errorf("invalid reserves format")
+	}
+
+	position := &AavePosition{User: user, Account: account, EModeCategory: category}
+	for _, asset := range assets {
+		reserve, err := am.reserveData(asset)
+		if err != nil {
+			return nil, err
+		}
+
+		config := ParseReserveConfiguration(reserve.Configuration.Data)
+		entry := AaveReservePosition{
+			Asset:                asset,
+			UsedAsCollateral:     configuration.Config.Data.Bit(2*int(reserve.Id)+1) == 1,
+			LTV:                  config.LTV,
+			LiquidationThreshold: config.LiquidationThreshold,
+		}
+		if entry.Supplied, err = cm.tokenUint(reserve.ATokenAddress, ERC20BalanceOf, user); err != nil {
+			return nil, fmt.Errorf("failed to get supply of %s: %v", asset.Hex(), err)
+		}
+		if entry.StableDebt, err = cm.tokenUint(reserve.StableDebtTokenAddress, ERC20BalanceOf, user); err != nil {
+			return nil, fmt.Errorf("failed to get stable debt of %s: %v", asset.Hex(), err)
+		}
+		if entry.VariableDebt, err = cm.tokenUint(reserve.VariableDebtTokenAddress, ERC20BalanceOf, user); err != nil {
+			return nil, fmt.Errorf("failed to get variable debt of %s: %v", asset.Hex(), err)
+		}
+
+		if entry.Supplied.Sign() > 0 || entry.StableDebt.Sign() > 0 || entry.VariableDebt.Sign() > 0 {
+			position.Reserves = append(position.Reserves, entry)
+		}
+	}
+	return position, nil
+}
+
+// SupplyAPY returns the current Aave supply APY of an asset by symbol, so
+// the manager can serve as a StrategyEngine YieldSource
+func (am *AaveManager) SupplyAPY(protocol, asset string) (float64, error) {
+	if !strings.EqualFold(protocol, "aave") {
+		return 0, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+
+	symbol := strings.ToUpper(asset)
+	address, exists := am.GetSupportedAssets()[symbol]
+	if token, registered := am.ContractManager.Contract("erc20_" + symbol); registered {
+		address, exists = token.Address, true
+	}
+	if !exists {
+		return 0, fmt.Errorf("unknown asset %s", asset)
+	}
+
+	reserve, err := am.reserveData(address)
+	if err != nil {
+		return 0, err
+	}
+	return RayToAPY(reserve.CurrentLiquidityRate), nil
+}
+
+// GetAssetPrice returns the oracle price of one whole token of asset in the
+// pool's base currency
+func (am *AaveManager) GetAssetPrice(asset common.Address) (*big.Int, error) {
+	result, err := am.ContractManager.CallContract(am.Oracle, AaveGetAssetPrice, asset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset price: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no price returned")
+	}
+	price, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid price format")
+	}
+	if price.Sign() <= 0 {
+		return nil, fmt.Errorf("no price for %s", asset.Hex())
+	}
+	return price, nil
+}
+
+// BaseToToken converts an amount in the pool's base currency, such as the
+// totals of GetUserAccountData, to units of asset at the oracle price
+func (am *AaveManager) BaseToToken(asset common.Address, amount *big.Int) (*big.Int, error) {
+	reserve, err := am.reserveData(asset)
+	if err != nil {
+		return nil, err
+	}
+	price, err := am.GetAssetPrice(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals := ParseReserveConfiguration(reserve.Configuration.Data).Decimals
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	tokens := new(big.Int).Mul(amount, unit)
+	return tokens.Div(tokens, price), nil
+}
+
+// reserveData reads an asset's ReserveData from the pool
+func (am *AaveManager) reserveData(asset common.Address) (*reserveDataTuple, error) {
+	pool, err := am.pool()
+	if err != nil {
+		return nil, err
+	}
+	result, err := am.ContractManager.CallContract(am.Pool, AaveGetReserveData, asset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserve data: %v", err)
+	}
+
+	// Copy fills the first field of a struct from a single tuple output
+	var reserve struct{ Data reserveDataTuple }
+	if err := pool.ABI.Methods[AaveGetReserveData].Outputs.Copy(&reserve, result); err != nil {
+		return nil, fmt.Errorf("failed to unpack reserve data: %v", err)
+	}
+	if reserve.Data.ATokenAddress == (common.Address{}) {
+		return nil, fmt.Errorf("reserve %s not listed", asset.Hex())
+	}
+	return &reserve.Data, nil
+}
+
+// RayToAPY converts an Aave per-year rate in ray to an APY compounded every
+// second, as the Aave interface reports it
+func RayToAPY(rate *big.Int) float64 {
+	if rate == nil {
+		return 0
+	}
+	apr, _ := new(big.Float).Quo(new(big.Float).SetInt(rate), new(big.Float).SetInt(ray)).Float64()
+	return math.Pow(1+apr/secondsPerYear, secondsPerYear) - 1
+}
@@ -0,0 +1,228 @@
+package defi
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+// reserveConfiguration packs the fields of Aave V3's reserve configuration
+// bitmap that the tests use
+func reserveConfiguration(ltv, threshold, bonus uint64, decimals uint8, eModeCategory uint8) *big.Int {
+	data := new(big.Int).SetUint64(ltv | threshold<<16 | bonus<<32 | uint64(decimals)<<48 | 1<<56 | 1<<58)
+	return data.Or(data, new(big.Int).Lsh(big.NewInt(int64(eModeCategory)), 168))
+}
+
+// rayRate returns an annual rate as a ray
+func rayRate(rate float64) *big.Int {
+	scaled, _ := new(big.Float).Mul(big.NewFloat(rate), new(big.Float).SetInt(ray)).Int(nil)
+	return scaled
+}
+
+func TestParseReserveConfiguration(t *testing.T) {
+	config := ParseReserveConfiguration(reserveConfiguration(8250, 8600, 10500, 6, 1))
+	assert.Equal(t, uint16(8250), config.LTV)
+	assert.Equal(t, uint16(8600), config.LiquidationThreshold)
+	assert.Equal(t, uint16(10500), config.LiquidationBonus)
+	assert.Equal(t, uint8(6), config.Decimals)
+	assert.True(t, config.Active)
+	assert.False(t, config.Frozen)
+	assert.True(t, config.BorrowingEnabled)
+	assert.False(t, config.StableBorrowingEnabled)
+	assert.Equal(t, uint8(1), config.EModeCategory)
+}
+
+func TestRayToAPY(t *testing.T) {
+	assert.Zero(t, RayToAPY(big.NewInt(0)))
+	assert.Zero(t, RayToAPY(nil))
+	// Compounding every second approaches continuous compounding
+	assert.InDelta(t, math.Exp(0.05)-1, RayToAPY(rayRate(0.05)), 1e-6)
+}
+
+func TestAaveV3LifecycleOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	aave := NewAaveManager(cm)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+	chain.Approve(chain.Key, chain.USDC, chain.Pool, defitest.Ether(400))
+	chain.Approve(chain.Key, chain.WETH, chain.Pool, MaxRepay)
+
+	healthFactor, err := aave.GetHealthFactor(chain.Account)
+	require.NoError(t, err)
+	assert.True(t, healthFactor.IsInf())
+
+	// Aave V3 deposits go through supply
+	tx, err := aave.ExecuteDeposit(DepositParams{Asset: chain.USDC, Amount: defitest.Ether(400), OnBehalfOf: chain.Account})
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+
+	tx, err = aave.ExecuteBorrow(BorrowParams{Asset: chain.WETH, Amount: defitest.Ether(200), InterestRateMode: VariableRate, OnBehalfOf: chain.Account})
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+
+	account, err := aave.GetUserAccountData(chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(400).String(), account.TotalCollateralBase.String())
+	assert.Equal(t, defitest.Ether(200).String(), account.TotalDebtBase.String())
+	assert.Equal(t, int64(defitest.PoolLTV), account.LTV.Int64())
+	assert.Equal(t, int64(defitest.PoolLiquidationThreshold), account.LiquidationThreshold.Int64())
+	healthFactor, err = aave.GetHealthFactor(chain.Account)
+	require.NoError(t, err)
+	value, _ := healthFactor.Float64()
+	assert.Equal(t, 1.6, value)
+
+	tx, err = aave.SwapBorrowRateMode(chain.WETH, VariableRate)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	_, err = aave.SwapBorrowRateMode(chain.WETH, big.NewInt(3))
+	assert.Error(t, err)
+
+	tx, err = aave.SetEMode(1)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	category, err := aave.GetEMode(chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), category)
+	_, err = aave.SetEMode(9)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "inconsistent eMode category")
+
+	// Partial repay, then the rest with MaxRepay
+	tx, err = aave.ExecuteRepay(RepayParams{Asset: chain.WETH, Amount: defitest.Ether(50), InterestRateMode: VariableRate, OnBehalfOf: chain.Account})
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	assert.Equal(t, defitest.Ether(150).String(), chain.PoolAccount(chain.Account).Debt.String())
+
+	_, simulation, err := cm.SimulateAndTransact("aave_v3_pool", AaveRepay, big.NewInt(0), chain.WETH, MaxRepay, VariableRate, chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Neg(defitest.Ether(150)).String(), simulation.BalanceChange(chain.WETH, chain.Account).String())
+	chain.Commit()
+	assert.Zero(t, chain.PoolAccount(chain.Account).Debt.Sign())
+
+	// Without debt there is nothing to switch
+	_, err = aave.SwapBorrowRateMode(chain.WETH, StableRate)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no debt of selected type")
+}
+
+func TestAaveMaxRepayApprovesTheDebt(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	aave := NewAaveManager(cm)
+	aave.Allowances = NewAllowanceManager(cm)
+
+	weth := chain.ListReserve(chain.WETH, defitest.ReserveParams{
+		ID:                 1,
+		Configuration:      reserveConfiguration(8000, 8250, 10500, 18, 1),
+		LiquidityRate:      rayRate(0.01),
+		VariableBorrowRate: rayRate(0.02),
+		StableBorrowRate:   rayRate(0.04),
+	})
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(400))
+	pause := autoCommit(t, chain, cm)
+
+	_, err := aave.Repay(chain.WETH, MaxRepay)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Aave debt")
+
+	tx, err := aave.Supply(chain.USDC, defitest.Ether(400))
+	require.NoError(t, err)
+	_, err = aave.MonitorTransaction(tx.Hash())
+	require.NoError(t, err)
+	tx, err = aave.Borrow(chain.WETH, defitest.Ether(200))
+	require.NoError(t, err)
+	_, err = aave.MonitorTransaction(tx.Hash())
+	require.NoError(t, err)
+	pause(func() { chain.Mint(weth.VariableDebtToken, chain.Account, defitest.Ether(200)) })
+
+	// The debt and a 0.1% buffer are approved, not an unlimited amount
+	tx, err = aave.Repay(chain.WETH, MaxRepay)
+	require.NoError(t, err)
+	receipt, err := aave.MonitorTransaction(tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Zero(t, chain.PoolAccount(chain.Account).Debt.Sign())
+
+	allowance, err := aave.Allowances.Allowance(chain.WETH, chain.Account, chain.Pool)
+	require.NoError(t, err)
+	assert.Equal(t, "200000000000000001", allowance.String())
+}
+
+func TestAavePositionAndReserveData(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	aave := NewAaveManager(cm)
+
+	usdc := chain.ListReserve(chain.USDC, defitest.ReserveParams{
+		ID:                 0,
+		Configuration:      reserveConfiguration(7500, 8000, 10500, 18, 0),
+		LiquidityRate:      rayRate(0.03),
+		VariableBorrowRate: rayRate(0.05),
+		StableBorrowRate:   rayRate(0.07),
+	})
+	weth := chain.ListReserve(chain.WETH, defitest.ReserveParams{
+		ID:                 1,
+		Configuration:      reserveConfiguration(8000, 8250, 10500, 18, 1),
+		LiquidityRate:      rayRate(0.01),
+		VariableBorrowRate: rayRate(0.02),
+		StableBorrowRate:   rayRate(0.04),
+	})
+
+	// 600 USDC is lent out of 800 supplied
+	chain.Mint(chain.USDC, usdc.AToken, defitest.Ether(200))
+	chain.Mint(usdc.AToken, chain.Account, defitest.Ether(800))
+	chain.Mint(usdc.VariableDebtToken, chain.Executor, defitest.Ether(450))
+	chain.Mint(usdc.StableDebtToken, chain.Executor, defitest.Ether(150))
+	chain.Mint(weth.VariableDebtToken, chain.Account, defitest.Ether(2))
+
+	reserve, err := aave.GetReserveData(chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, usdc.AToken, reserve.AToken)
+	assert.Equal(t, uint16(7500), reserve.Configuration.LTV)
+	assert.InDelta(t, math.Exp(0.03)-1, reserve.SupplyAPY, 1e-6)
+	assert.InDelta(t, math.Exp(0.05)-1, reserve.VariableBorrowAPY, 1e-6)
+	assert.InDelta(t, 0.75, reserve.Utilization, 1e-9)
+
+	tx, err := aave.SetCollateral(chain.USDC, true)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+
+	position, err := aave.GetPosition(chain.Account)
+	require.NoError(t, err)
+	require.Len(t, position.Reserves, 2)
+	supplied := position.Reserves[0]
+	assert.Equal(t, chain.USDC, supplied.Asset)
+	assert.Equal(t, defitest.Ether(800).String(), supplied.Supplied.String())
+	assert.Zero(t, supplied.StableDebt.Sign())
+	assert.Zero(t, supplied.VariableDebt.Sign())
+	assert.True(t, supplied.UsedAsCollateral)
+	assert.Equal(t, uint16(7500), supplied.LTV)
+	assert.Equal(t, uint16(8000), supplied.LiquidationThreshold)
+	assert.Equal(t, chain.WETH, position.Reserves[1].Asset)
+	assert.Equal(t, defitest.Ether(2).String(), position.Reserves[1].VariableDebt.String())
+	assert.False(t, position.Reserves[1].UsedAsCollateral)
+
+	tx, err = aave.SetCollateral(chain.USDC, false)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	position, err = aave.GetPosition(chain.Account)
+	require.NoError(t, err)
+	assert.False(t, position.Reserves[0].UsedAsCollateral)
+
+	_, err = aave.SetCollateral(chain.Router, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reserve not listed")
+
+	// The strategy engine reads live yields through the manager
+	engine := NewStrategyEngine()
+	engine.Yields = aave
+	assert.InDelta(t, math.Exp(0.03)-1, engine.getCurrentYieldRate(map[string]interface{}{"protocol": "aave", "asset": "usdc"}), 1e-6)
+	assert.Equal(t, 0.067, engine.getCurrentYieldRate(map[string]interface{}{"protocol": "compound", "asset": "USDC"}))
+}
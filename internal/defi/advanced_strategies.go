@@ -4,7 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/indicators"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
 )
 
 // AdvancedStrategyEngine manages complex trading strategies with risk management
@@ -15,6 +21,17 @@ type AdvancedStrategyEngine struct {
 	Portfolio       *PortfolioManager
 	IsRunning       bool
 	PerformanceData *StrategyPerformance
+	Clock           Clock
+	History         *market.PriceHistory
+	Executor        TradeExecutor
+	Hedging         *HedgingEngine
+}
+
+// TradeExecutor opens and closes positions on behalf of advanced strategies
+type TradeExecutor interface {
+	OpenPosition(strategy *AdvancedTradingStrategy, size float64) error
+	ClosePosition(strategy *AdvancedTradingStrategy) error
+	HasOpenPosition(strategyID string) bool
 }
 
 // AdvancedTradingStrategy represents a sophisticated trading strategy
@@ -132,6 +149,19 @@ type AdvancedRiskManager struct {
 	LiquidityRisk    *LiquidityRisk
 	CounterpartyRisk *CounterpartyRisk
 	RiskLimits       *RiskLimits
+
+	// Method, Confidence and Window configure how value at risk and
+	// expected shortfall are measured, over Window returns annualized with
+	// PeriodsPerYear; Monte Carlo runs MonteCarloPaths paths seeded by Seed
+	Method          VaRMethod
+	Confidence      float64
+	Window          int
+	PeriodsPerYear  float64
+	MonteCarloPaths int
+	Seed            int64
+
+	// Scenarios are the stress tests the portfolio is put through
+	Scenarios []StressScenario
 }
 
 // PortfolioRisk metrics
@@ -220,6 +250,7 @@ type StrategyPerformance struct {
 	AnnualizedReturn float64
 	Volatility       float64
 	SharpeRatio      float64
+	SortinoRatio     float64
 	MaxDrawdown      float64
 	WinRate          float64
 	ProfitFactor     float64
@@ -236,6 +267,10 @@ type StrategyPerformanceStats struct {
 	MaxLoss       float64
 	AvgProfit     float64
 	AvgLoss       float64
+	WinRate       float64
+	SharpeRatio   float64
+	SortinoRatio  float64
+	MaxDrawdown   float64
 }
 
 // Advanced strategy implementations
@@ -249,7 +284,16 @@ func NewAdvancedStrategyEngine() *AdvancedStrategyEngine {
 		Portfolio:       NewPortfolioManager(),
 		IsRunning:       false,
 		PerformanceData: &StrategyPerformance{},
+		Clock:           SystemClock,
+	}
+}
+
+// now returns the current time from the engine clock
+func (ase *AdvancedStrategyEngine) now() time.Time {
+	if ase.Clock == nil {
+		return time.Now()
 	}
+	return ase.Clock.Now()
 }
 
 // NewAdvancedRiskManager creates a new advanced risk manager
@@ -265,6 +309,12 @@ func NewAdvancedRiskManager() *AdvancedRiskManager {
 			MinLiquidity:     10000, // $10k minimum liquidity
 			MaxConcentration: 0.20,  // 20% max concentration
 		},
+		Method:          VaRHistorical,
+		Confidence:      DefaultVaRConfidence,
+		Window:          DefaultRiskWindow,
+		PeriodsPerYear:  DefaultRiskPeriodsPerYear,
+		MonteCarloPaths: DefaultMonteCarloPaths,
+		Scenarios:       DefaultStressScenarios(),
 	}
 }
 
@@ -426,6 +476,7 @@ func TrendFollowingStrategy() *AdvancedTradingStrategy {
 				Aggregation: "latest",
 				Weight:      0.7,
 				Metadata: map[string]interface{}{
+					"asset":       "BTC",
 					"fast_period": 9,
 					"slow_period": 21,
 				},
@@ -439,6 +490,7 @@ func TrendFollowingStrategy() *AdvancedTradingStrategy {
 				Aggregation: "average",
 				Weight:      0.3,
 				Metadata: map[string]interface{}{
+					"asset":  "BTC",
 					"period": 20,
 				},
 			},
@@ -453,6 +505,7 @@ func TrendFollowingStrategy() *AdvancedTradingStrategy {
 				Aggregation: "latest",
 				Weight:      1.0,
 				Metadata: map[string]interface{}{
+					"asset":       "BTC",
 					"fast_period": 9,
 					"slow_period": 21,
 				},
@@ -633,26 +686,51 @@ func (ase *AdvancedStrategyEngine) calculateFixedPosition(strategy *AdvancedTrad
 	return portfolioValue * fixed.Fraction
 }
 
-// AssessRisk evaluates overall strategy risk
+// AssessRisk evaluates overall strategy risk. Zero strategy limits are
+// unset and not checked.
 func (ase *AdvancedStrategyEngine) AssessRisk(strategy *AdvancedTradingStrategy) bool {
+	params := strategy.RiskParameters
+
 	// Check market risk
-	if ase.RiskManager.MarketRisk.Volatility > strategy.RiskParameters.VolatilityLimit {
+	if params.VolatilityLimit > 0 && ase.RiskManager.MarketRisk.Volatility > params.VolatilityLimit {
 		log.Printf("Risk assessment failed: Volatility too high")
 		return false
 	}
 
 	// Check portfolio risk
-	if ase.RiskManager.PortfolioRisk.MaxDrawdown > strategy.RiskParameters.MaxDrawdown {
+	if params.MaxDrawdown > 0 && ase.RiskManager.PortfolioRisk.MaxDrawdown > params.MaxDrawdown {
 		log.Printf("Risk assessment failed: Max drawdown exceeded")
 		return false
 	}
 
 	// Check value at risk
-	if ase.RiskManager.PortfolioRisk.ValueAtRisk > strategy.RiskParameters.ValueAtRisk {
+	if params.ValueAtRisk > 0 && ase.RiskManager.PortfolioRisk.ValueAtRisk > params.ValueAtRisk {
 		log.Printf("Risk assessment failed: VaR exceeded")
 		return false
 	}
 
+	// Check the portfolio-wide limits, including the concentration the trade
+	// would leave in the strategy's primary asset
+	limits := ase.RiskManager.RiskLimits
+	if limits == nil {
+		return true
+	}
+	if limits.MaxVaR > 0 && ase.RiskManager.PortfolioRisk.ValueAtRisk > limits.MaxVaR {
+		log.Printf("Risk assessment failed: VaR limit exceeded")
+		return false
+	}
+	if limits.MaxDrawdown > 0 && ase.RiskManager.PortfolioRisk.MaxDrawdown > limits.MaxDrawdown {
+		log.Printf("Risk assessment failed: Drawdown limit exceeded")
+		return false
+	}
+	if limits.MaxConcentration > 0 && len(strategy.Parameters.TargetAssets) > 0 {
+		size := ase.CalculatePositionSize(strategy, ase.Portfolio.GetTotalValue())
+		if Concentration(ase.Portfolio.Assets, strategy.Parameters.TargetAssets[0], size) > limits.MaxConcentration {
+			log.Printf("Risk assessment failed: Concentration limit exceeded")
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -693,31 +771,64 @@ func (ase *AdvancedStrategyEngine) evaluateAdvancedStrategies(ctx context.Contex
 
 			for _, strategy := range ase.Strategies {
 				if strategy.IsActive {
-					go ase.evaluateAdvancedStrategy(strategy)
+					go ase.evaluateAdvancedStrategy(ctx, strategy)
 				}
 			}
 		}
 	}
 }
 
+// EvaluateStrategies evaluates every active advanced strategy once, sequentially and in ID order
+func (ase *AdvancedStrategyEngine) EvaluateStrategies() {
+	ids := make([]string, 0, len(ase.Strategies))
+	for id := range ase.Strategies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if strategy := ase.Strategies[id]; strategy.IsActive {
+			ase.evaluateAdvancedStrategy(context.Background(), strategy)
+		}
+	}
+}
+
 // evaluateAdvancedStrategy evaluates a single advanced strategy
-func (ase *AdvancedStrategyEngine) evaluateAdvancedStrategy(strategy *AdvancedTradingStrategy) {
+func (ase *AdvancedStrategyEngine) evaluateAdvancedStrategy(ctx context.Context, strategy *AdvancedTradingStrategy) {
+	// Refresh indicators for the primary asset
+	if len(strategy.Parameters.TargetAssets) > 0 {
+		ase.UpdateIndicators(strategy.Parameters.TargetAssets[0])
+	}
+
 	// Check entry conditions
 	entryScore := ase.calculateConditionScore(strategy.EntryConditions)
 
 	// Check exit conditions
 	exitScore := ase.calculateConditionScore(strategy.ExitConditions)
 
-	// Risk assessment
-	if !ase.AssessRisk(strategy) {
-		return
+	// Hedges are kept on target whether or not the strategy may trade
+	if ase.Hedging != nil && strategy.HedgingStrategy != nil {
+		if _, err := ase.Hedging.Rebalance(ctx, strategy.HedgingStrategy); err != nil {
+			log.Printf("Failed to rebalance hedges for %s: %v", strategy.Name, err)
+		}
+	}
+
+	// Risk measurements stay at their last values until there is enough
+	// price history
+	if ase.History != nil {
+		_ = ase.UpdateRisk()
 	}
 
-	// Execute based on scores
-	if entryScore >= 0.8 && exitScore < 0.5 {
+	// Execute based on scores; with an executor attached, only enter when flat and exit when holding.
+	// Risk assessment gates entries only, so positions can always be closed.
+	hasPosition := ase.Executor != nil && ase.Executor.HasOpenPosition(strategy.ID)
+	if entryScore >= 0.8 && exitScore < 0.5 && !hasPosition {
+		if !ase.AssessRisk(strategy) {
+			return
+		}
 		log.Printf("Advanced strategy %s conditions met, executing trade", strategy.Name)
 		ase.executeAdvancedTrade(strategy)
-	} else if exitScore >= 0.8 {
+	} else if exitScore >= 0.8 && (ase.Executor == nil || hasPosition) {
 		log.Printf("Advanced strategy %s exit conditions met, closing position", strategy.Name)
 		ase.closeAdvancedPosition(strategy)
 	}
@@ -768,30 +879,185 @@ func (ase *AdvancedStrategyEngine) getAdvancedMetricValue(metric string, metadat
 	}
 }
 
-// Placeholder implementations for technical indicators
+// Default indicator periods used when condition metadata does not specify one
+const (
+	defaultIndicatorPeriod = 20
+	defaultRSIPeriod       = 14
+	defaultFastPeriod      = 9
+	defaultSlowPeriod      = 21
+	defaultZScoreLookback  = 60
+)
+
+// calculateBollingerPosition returns how many standard deviations the latest close sits from the middle band
 func (ase *AdvancedStrategyEngine) calculateBollingerPosition(metadata map[string]interface{}) float64 {
-	// Implementation would calculate position within Bollinger Bands
-	return 0.0 // Placeholder
+	asset, _ := metadata["asset"].(string)
+	period := metadataInt(metadata, "period", defaultIndicatorPeriod)
+
+	closes := ase.closes(asset, period)
+	bands, err := indicators.Bollinger(closes, period, 2)
+	if err != nil {
+		return 0.0
+	}
+	return bands.Position(closes[len(closes)-1])
 }
 
+// calculateRSI returns the relative strength index of an asset, or a neutral 50 without enough history
 func (ase *AdvancedStrategyEngine) calculateRSI(metadata map[string]interface{}) float64 {
-	// Implementation would calculate RSI
-	return 50.0 // Placeholder
+	asset, _ := metadata["asset"].(string)
+	period := metadataInt(metadata, "period", defaultRSIPeriod)
+
+	// Wilder smoothing converges with more history, so read a few periods back
+	rsi, err := indicators.RSI(ase.closes(asset, period*4), period)
+	if err != nil {
+		return 50.0
+	}
+	return rsi
 }
 
+// calculateMACrossover returns the fast SMA's distance above the slow SMA as a fraction of the slow SMA
 func (ase *AdvancedStrategyEngine) calculateMACrossover(metadata map[string]interface{}) float64 {
-	// Implementation would calculate moving average crossover
-	return 0.0 // Placeholder
+	asset, _ := metadata["asset"].(string)
+	fast := metadataInt(metadata, "fast_period", defaultFastPeriod)
+	slow := metadataInt(metadata, "slow_period", defaultSlowPeriod)
+
+	closes := ase.closes(asset, slow)
+	fastMA, err := indicators.SMA(closes, fast)
+	if err != nil {
+		return 0.0
+	}
+	slowMA, err := indicators.SMA(closes, slow)
+	if err != nil || slowMA == 0 {
+		return 0.0
+	}
+	return (fastMA - slowMA) / slowMA
 }
 
+// calculateVolumeRatio returns the latest volume relative to the average of the preceding period
 func (ase *AdvancedStrategyEngine) calculateVolumeRatio(metadata map[string]interface{}) float64 {
-	// Implementation would calculate volume ratio
-	return 1.0 // Placeholder
+	asset, _ := metadata["asset"].(string)
+	period := metadataInt(metadata, "period", defaultIndicatorPeriod)
+
+	if ase.History == nil || asset == "" {
+		return 1.0
+	}
+
+	candles := ase.History.Candles(asset, period+1)
+	volumes := make([]float64, len(candles))
+	for i, c := range candles {
+		volumes[i] = c.Volume
+	}
+
+	ratio, err := indicators.VolumeRatio(volumes, period)
+	if err != nil {
+		return 1.0
+	}
+	return ratio
 }
 
+// calculateZScore returns the z-score of the price ratio of a "BASE/QUOTE" pair over the lookback window
 func (ase *AdvancedStrategyEngine) calculateZScore(metadata map[string]interface{}) float64 {
-	// Implementation would calculate z-score for statistical arbitrage
-	return 0.0 // Placeholder
+	pair, _ := metadata["pair"].(string)
+	lookback := metadataInt(metadata, "lookback", defaultZScoreLookback)
+
+	base, quote, found := strings.Cut(pair, "/")
+	if !found {
+		return 0.0
+	}
+
+	baseCloses := ase.closes(base, lookback)
+	quoteCloses := ase.closes(quote, lookback)
+	n := min(len(baseCloses), len(quoteCloses))
+	baseCloses = baseCloses[len(baseCloses)-n:]
+	quoteCloses = quoteCloses[len(quoteCloses)-n:]
+
+	ratios := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		if quoteCloses[i] > 0 {
+			ratios = append(ratios, baseCloses[i]/quoteCloses[i])
+		}
+	}
+
+	z, err := indicators.ZScore(ratios, len(ratios))
+	if err != nil {
+		return 0.0
+	}
+	return z
+}
+
+// closes returns up to n recent closing prices for an asset from the engine's price history
+func (ase *AdvancedStrategyEngine) closes(asset string, n int) []float64 {
+	if ase.History == nil || asset == "" {
+		return nil
+	}
+	return ase.History.Closes(asset, n)
+}
+
+// UpdateIndicators recomputes the market analyzer's technical indicators for an asset.
+// Indicators without enough price history keep their previous values.
+func (ase *AdvancedStrategyEngine) UpdateIndicators(asset string) {
+	if ase.History == nil {
+		return
+	}
+
+	candles := ase.History.Candles(asset, 0)
+	if len(candles) == 0 {
+		return
+	}
+
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	ti := ase.MarketAnalyzer.TechnicalIndicators
+
+	if rsi, err := indicators.RSI(closes, defaultRSIPeriod); err == nil {
+		ti.RSI = rsi
+	}
+	if macd, err := indicators.MACDLine(closes, 12, 26); err == nil {
+		ti.MACD = macd
+	}
+
+	if bands, err := indicators.Bollinger(closes, defaultIndicatorPeriod, 2); err == nil {
+		ti.BollingerBands.Upper = bands.Upper
+		ti.BollingerBands.Middle = bands.Middle
+		ti.BollingerBands.Lower = bands.Lower
+		ti.BollingerBands.Width = bands.Width()
+	}
+
+	ma := ti.MovingAverages
+	for _, target := range []struct {
+		value  *float64
+		period int
+	}{{&ma.SMA20, 20}, {&ma.SMA50, 50}, {&ma.SMA200, 200}} {
+		if v, err := indicators.SMA(closes, target.period); err == nil {
+			*target.value = v
+		}
+	}
+	if v, err := indicators.EMA(closes, 12); err == nil {
+		ma.EMA12 = v
+	}
+	if v, err := indicators.EMA(closes, 26); err == nil {
+		ma.EMA26 = v
+	}
+
+	sr := ti.SupportResistance
+	sr.Support1, sr.Resistance1 = priceRange(candles, 20)
+	sr.Support2, sr.Resistance2 = priceRange(candles, 50)
+}
+
+// priceRange returns the lowest low and highest high of the last n candles
+func priceRange(candles []market.Candle, n int) (low, high float64) {
+	if n < len(candles) {
+		candles = candles[len(candles)-n:]
+	}
+
+	low, high = candles[0].Low, candles[0].High
+	for _, c := range candles[1:] {
+		low = math.Min(low, c.Low)
+		high = math.Max(high, c.High)
+	}
+	return low, high
 }
 
 // executeAdvancedTrade executes a trade for an advanced strategy
@@ -802,15 +1068,30 @@ func (ase *AdvancedStrategyEngine) executeAdvancedTrade(strategy *AdvancedTradin
 
 	log.Printf("Executing advanced trade for %s: position size $%.2f", strategy.Name, positionSize)
 
+	if ase.Executor != nil {
+		if err := ase.Executor.OpenPosition(strategy, positionSize); err != nil {
+			log.Printf("Failed to open position for %s: %v", strategy.Name, err)
+			return
+		}
+	}
+
 	// Update performance stats
 	strategy.PerformanceStats.TotalTrades++
-	strategy.UpdatedAt = time.Now()
+	strategy.UpdatedAt = ase.now()
 }
 
 // closeAdvancedPosition closes a position for an advanced strategy
 func (ase *AdvancedStrategyEngine) closeAdvancedPosition(strategy *AdvancedTradingStrategy) {
 	log.Printf("Closing position for advanced strategy: %s", strategy.Name)
-	strategy.UpdatedAt = time.Now()
+
+	if ase.Executor != nil {
+		if err := ase.Executor.ClosePosition(strategy); err != nil {
+			log.Printf("Failed to close position for %s: %v", strategy.Name, err)
+			return
+		}
+	}
+
+	strategy.UpdatedAt = ase.now()
 }
 
 // Helper types for portfolio management
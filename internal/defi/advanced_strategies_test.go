@@ -2,7 +2,11 @@ package defi
 
 import (
 	"context"
+	"math"
 	"testing"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
 )
 
 func TestAdvancedStrategyEngine(t *testing.T) {
@@ -248,3 +252,71 @@ func TestAdvancedRiskManager(t *testing.T) {
 		t.Errorf("Expected MaxDrawdown 0.10, got %.2f", riskManager.RiskLimits.MaxDrawdown)
 	}
 }
+
+func TestAdvancedMetricsFromHistory(t *testing.T) {
+	engine := NewAdvancedStrategyEngine()
+
+	// Without history every metric falls back to a neutral value
+	if rsi := engine.getAdvancedMetricValue("rsi", map[string]interface{}{"asset": "ETH"}); rsi != 50.0 {
+		t.Errorf("Expected neutral RSI 50 without history, got %.2f", rsi)
+	}
+	if ratio := engine.getAdvancedMetricValue("volume_ratio", map[string]interface{}{"asset": "ETH"}); ratio != 1.0 {
+		t.Errorf("Expected neutral volume ratio 1 without history, got %.2f", ratio)
+	}
+
+	engine.History = market.NewPriceHistory(0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		volume := 100.0
+		if i == 29 {
+			volume = 300.0
+		}
+		engine.History.AddPrice("ETH", float64(100+i), volume, at)
+		engine.History.AddPrice("BTC", 1000, 10, at)
+	}
+
+	// A steadily rising series has no losses
+	if rsi := engine.getAdvancedMetricValue("rsi", map[string]interface{}{"asset": "ETH", "period": 14}); rsi != 100.0 {
+		t.Errorf("Expected RSI 100 for a rising series, got %.2f", rsi)
+	}
+
+	// SMA(9) of 121..129 is 125, SMA(21) of 109..129 is 119
+	crossover := engine.getAdvancedMetricValue("ma_crossover", map[string]interface{}{"asset": "ETH", "fast_period": 9, "slow_period": 21})
+	if math.Abs(crossover-(125.0-119.0)/119.0) > 1e-9 {
+		t.Errorf("Unexpected MA crossover %.6f", crossover)
+	}
+
+	if ratio := engine.getAdvancedMetricValue("volume_ratio", map[string]interface{}{"asset": "ETH", "period": 20}); ratio != 3.0 {
+		t.Errorf("Expected volume ratio 3, got %.2f", ratio)
+	}
+
+	// The last close of a linear series sits sqrt(3)*(n-1)/sqrt(n^2-1) deviations above the mean
+	position := engine.getAdvancedMetricValue("bollinger_position", map[string]interface{}{"asset": "ETH", "period": 20})
+	expected := math.Sqrt(3) * 19 / math.Sqrt(399)
+	if math.Abs(position-expected) > 1e-9 {
+		t.Errorf("Expected Bollinger position %.6f, got %.6f", expected, position)
+	}
+
+	if z := engine.getAdvancedMetricValue("z_score", map[string]interface{}{"pair": "ETH/BTC", "lookback": 20}); math.Abs(z-expected) > 1e-9 {
+		t.Errorf("Expected ETH/BTC z-score %.6f, got %.6f", expected, z)
+	}
+
+	engine.UpdateIndicators("ETH")
+	ti := engine.MarketAnalyzer.TechnicalIndicators
+	if ti.RSI != 100.0 {
+		t.Errorf("Expected RSI indicator 100, got %.2f", ti.RSI)
+	}
+	if ti.MACD <= 0 {
+		t.Errorf("Expected positive MACD for a rising series, got %.4f", ti.MACD)
+	}
+	if ti.BollingerBands.Middle != 119.5 {
+		t.Errorf("Expected middle band 119.5, got %.2f", ti.BollingerBands.Middle)
+	}
+	if ti.MovingAverages.SMA20 != 119.5 || ti.MovingAverages.SMA50 != 0 {
+		t.Errorf("Unexpected moving averages: %+v", ti.MovingAverages)
+	}
+	if ti.SupportResistance.Support1 != 110 || ti.SupportResistance.Resistance1 != 129 {
+		t.Errorf("Unexpected support/resistance: %+v", ti.SupportResistance)
+	}
+}
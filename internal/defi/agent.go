@@ -6,6 +6,7 @@ import (
 	"log"
 	"math/big"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/pkg/mcpclient"
@@ -22,6 +23,10 @@ type DeFiAgent struct {
 	MarketData   *MarketData
 	RiskManager  *RiskManager
 	Blockchain   *Blockchain
+	Arbitrage    *ArbitrageScanner
+	Liquidity    *UniswapV3LPManager
+	MarketMaker  *MarketMaker
+	Yield        *YieldOptimizer
 	IsActive     bool
 	LastActivity time.Time
 }
@@ -85,14 +90,19 @@ type Blockchain struct {
 
 // NewDeFiAgent creates a new DeFi agent
 func NewDeFiAgent(id, name string, strategy Strategy, wallet *Wallet) *DeFiAgent {
+	riskManager := NewRiskManager()
+	arbitrage := NewArbitrageScanner()
+	arbitrage.Slippage = riskManager.MaxSlippage
+
 	return &DeFiAgent{
 		ID:           id,
 		Name:         name,
 		Strategy:     strategy,
 		Wallet:       wallet,
 		MarketData:   NewMarketData(),
-		RiskManager:  NewRiskManager(),
+		RiskManager:  riskManager,
 		Blockchain:   NewBlockchain(),
+		Arbitrage:    arbitrage,
 		IsActive:     false,
 		LastActivity: time.Now(),
 	}
@@ -256,8 +266,7 @@ func (agent *DeFiAgent) evaluateConditions() bool {
 func (agent *DeFiAgent) getMetricValue(metric string) float64 {
 	switch metric {
 	case "price_difference":
-		// Example: ETH/USD price difference between exchanges
-		return agent.calculatePriceDifference("ETH/USD")
+		return agent.calculatePriceDifference(agent.tokenPair())
 	case "yield_rate":
 		// Example: Current yield rate for a protocol
 		return agent.getCurrentYieldRate()
@@ -323,52 +332,128 @@ func (agent *DeFiAgent) calculateMarketVolatility() float64 {
 	return 0.1 // Placeholder
 }
 
-// calculatePriceDifference calculates price difference for arbitrage
+// tokenPair returns the strategy's token_pair parameter, WETH/USDC by default
+func (agent *DeFiAgent) tokenPair() string {
+	if pair, ok := agent.Strategy.Parameters["token_pair"].(string); ok && pair != "" {
+		return pair
+	}
+	return "WETH/USDC"
+}
+
+// calculatePriceDifference returns the largest spot price spread for a pair
+// across the venues the arbitrage scanner knows, or 0 without two venues
 func (agent *DeFiAgent) calculatePriceDifference(symbol string) float64 {
-	// Placeholder - would compare prices across multiple DEXs
-	return 0.02 // 2% difference
+	if agent.Arbitrage == nil {
+		return 0
+	}
+	spread, _ := agent.Arbitrage.PriceSpread(symbol)
+	return spread
 }
 
-// getCurrentYieldRate gets current yield rate
+// getCurrentYieldRate returns the best supply APY the yield optimizer's
+// venues pay, or 0 without an optimizer
 func (agent *DeFiAgent) getCurrentYieldRate() float64 {
-	// Placeholder - would fetch from DeFi protocols
-	return 0.05 // 5% APY
+	if agent.Yield == nil {
+		return 0
+	}
+	rates, err := agent.Yield.Rates()
+	if err != nil {
+		log.Printf("Failed to get supply rates: %v", err)
+		return 0
+	}
+	return rates[0].Rate
 }
 
 // executeArbitrage executes arbitrage strategy
 func (agent *DeFiAgent) executeArbitrage() {
 	log.Printf("Executing arbitrage strategy")
-	// Implementation would involve:
-	// 1. Identify price differences
-	// 2. Calculate profitable trades
-	// 3. Execute trades on different DEXs
+	if agent.Arbitrage == nil {
+		return
+	}
+
+	opportunities := agent.Arbitrage.Scan()
+	if len(opportunities) == 0 {
+		log.Printf("No profitable arbitrage cycles found")
+		return
+	}
+	best := opportunities[0]
+	log.Printf("Found %d arbitrage opportunities; best %s nets $%.2f (%.3f%%) on an input of %s",
+		len(opportunities), best.String(), best.ProfitUSD, best.ProfitPct*100, best.AmountIn)
 }
 
-// executeYieldFarming executes yield farming strategy
+// executeYieldFarming lets the yield optimizer migrate the supply and
+// compound its rewards
 func (agent *DeFiAgent) executeYieldFarming() {
 	log.Printf("Executing yield farming strategy")
-	// Implementation would involve:
-	// 1. Identify high-yield opportunities
-	// 2. Deposit liquidity
-	// 3. Monitor and compound rewards
+	if agent.Yield == nil {
+		return
+	}
+
+	plan, err := agent.Yield.Step()
+	if err != nil {
+		log.Printf("Failed to optimize yield: %v", err)
+		return
+	}
+	if plan.Worthwhile() {
+		log.Printf("Migrated supply: %s", plan.String())
+		return
+	}
+	log.Printf("Supplying on %s at %.3f%%", plan.From.Name(), plan.FromRate*100)
 }
 
-// executeLiquidityProvision executes liquidity provision
+// executeLiquidityProvision manages the agent's Uniswap V3 positions,
+// rebalancing them as the range policy says, and opens one on the
+// strategy's token_pair with its base_amount and quote_amount when none is
+// open
 func (agent *DeFiAgent) executeLiquidityProvision() {
 	log.Printf("Executing liquidity provision strategy")
-	// Implementation would involve:
-	// 1. Analyze liquidity pools
-	// 2. Provide liquidity
-	// 3. Manage impermanent loss
+	if agent.Liquidity == nil {
+		return
+	}
+
+	if len(agent.Liquidity.Positions()) > 0 {
+		if err := agent.Liquidity.Manage(); err != nil {
+			log.Printf("Failed to manage liquidity positions: %v", err)
+		}
+		return
+	}
+
+	pair := agent.tokenPair()
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok {
+		log.Printf("Invalid token pair %s", pair)
+		return
+	}
+	action := StrategyAction{
+		ID:   agent.ID + "_provide_liquidity",
+		Type: ActionProvideLiquidity,
+		Parameters: map[string]interface{}{
+			"token_a":  base,
+			"token_b":  quote,
+			"amount_a": agent.Strategy.Parameters["base_amount"],
+			"amount_b": agent.Strategy.Parameters["quote_amount"],
+			"fee":      metadataInt(agent.Strategy.Parameters, "fee", int(FeeTierMedium)),
+		},
+	}
+	if err := agent.Liquidity.ExecuteAction(nil, action); err != nil {
+		log.Printf("Failed to provide liquidity: %v", err)
+	}
 }
 
-// executeMarketMaking executes market making strategy
+// executeMarketMaking refreshes the market maker's quotes and range orders
 func (agent *DeFiAgent) executeMarketMaking() {
 	log.Printf("Executing market making strategy")
-	// Implementation would involve:
-	// 1. Set bid-ask spreads
-	// 2. Manage inventory
-	// 3. Adjust prices based on market conditions
+	if agent.MarketMaker == nil {
+		return
+	}
+
+	quote, err := agent.MarketMaker.Step()
+	if err != nil {
+		log.Printf("Failed to refresh market making orders: %v", err)
+		return
+	}
+	log.Printf("Quoting %.6f / %.6f around %.6f (spread %.3f%%, inventory %.6f)",
+		quote.Bid, quote.Ask, quote.Mid, quote.Spread*100, quote.Inventory)
 }
 
 // GetStatus returns agent status
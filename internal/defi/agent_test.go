@@ -91,7 +91,7 @@ func TestDeFiAgent_EvaluateConditions(t *testing.T) {
 			{
 				Metric:    "price_difference",
 				Operator:  ">",
-				Threshold: 0.03, // Higher than hardcoded 0.02
+				Threshold: 0.03,
 			},
 		},
 		IsEnabled: true,
@@ -101,7 +101,7 @@ func TestDeFiAgent_EvaluateConditions(t *testing.T) {
 
 	// Test condition evaluation
 	result := agent.evaluateConditions()
-	assert.False(t, result) // Should be false since price difference is hardcoded to 0.02
+	assert.False(t, result) // No venues are known, so there is no price difference
 }
 
 func TestDeFiAgent_GetStatus(t *testing.T) {
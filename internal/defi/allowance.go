@@ -0,0 +1,474 @@
+package defi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ApprovalMode selects how much AllowanceManager approves when an allowance
+// is too low
+type ApprovalMode int
+
+const (
+	// ApproveExact approves just the amount about to be spent
+	ApproveExact ApprovalMode = iota
+
+	// ApproveCapped approves the token's cap so later spends need no new
+	// approval; tokens without a cap are approved exactly
+	ApproveCapped
+)
+
+// EIP-712 type hashes for EIP-2612 permits and Permit2's PermitSingle
+var (
+	permitTypehash        = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	permitDetailsTypehash = crypto.Keccak256Hash([]byte("PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)"))
+	permitSingleTypehash  = crypto.Keccak256Hash([]byte("PermitSingle(PermitDetails details,address spender,uint256 sigDeadline)PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)"))
+)
+
+// AllowanceManager issues ERC20 approvals for the manager's account only
+// when the current allowance is too low, and signs EIP-2612 and Permit2
+// permits that avoid a separate approval transaction
+type AllowanceManager struct {
+	ContractManager *ContractManager
+	Mode            ApprovalMode
+
+	// Caps bounds the approval per token in ApproveCapped mode; spending
+	// more than a token's cap is refused
+	Caps map[common.Address]*big.Int
+
+	mu       sync.Mutex
+	spenders map[common.Address]bool
+}
+
+// NewAllowanceManager creates an allowance manager approving exact amounts
+func NewAllowanceManager(cm *ContractManager) *AllowanceManager {
+	return &AllowanceManager{
+		ContractManager: cm,
+		Mode:            ApproveExact,
+		Caps:            make(map[common.Address]*big.Int),
+		spenders:        make(map[common.Address]bool),
+	}
+}
+
+// Approval is an outstanding ERC20 allowance
+type Approval struct {
+	Token   common.Address
+	Spender common.Address
+	Amount  *big.Int
+}
+
+// Allowance returns how much of owner's token spender may move
+func (m *AllowanceManager) Allowance(token, owner, spender common.Address) (*big.Int, error) {
+	name, err := m.ContractManager.tokenContractName(token)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.ContractManager.CallContract(name, ERC20Allowance, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no allowance returned")
+	}
+
+	allowance, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid allowance format")
+	}
+	return allowance, nil
+}
+
+// EnsureAllowance approves spender for at least amount of token from the
+// manager's account. It returns a nil transaction when the current
+// allowance already covers amount.
+func (m *AllowanceManager) EnsureAllowance(token, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	owner := m.ContractManager.Transactor.From
+	current, err := m.Allowance(token, owner, spender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowance: %v", err)
+	}
+	m.track(spender)
+	if current.Cmp(amount) >= 0 {
+		return nil, nil
+	}
+
+	approval := amount
+	if m.Mode == ApproveCapped {
+		if limit, exists := m.Caps[token]; exists {
+			if amount.Cmp(limit) > 0 {
+				return nil, fmt.Errorf("amount %s exceeds the approval cap %s for token %s", amount, limit, token.Hex())
+			}
+			approval = limit
+		}
+	}
+
+	tx, err := m.approve(token, spender, approval)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Approved %s of %s for %s: %s", approval, token.Hex(), spender.Hex(), tx.Hash().Hex())
+	return tx, nil
+}
+
+// Require is EnsureAllowance that waits for the approval, if one was needed,
+// to be mined successfully
+func (m *AllowanceManager) Require(token, spender common.Address, amount *big.Int) error {
+	tx, err := m.EnsureAllowance(token, spender, amount)
+	if err != nil || tx == nil {
+		return err
+	}
+
+	receipt, err := m.ContractManager.WaitForTransaction(context.Background(), tx.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to wait for approval: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("approval transaction %s failed", tx.Hash().Hex())
+	}
+	return nil
+}
+
+// Revoke sets spender's allowance for token from the manager's account to
+// zero
+func (m *AllowanceManager) Revoke(token, spender common.Address) (*types.Transaction, error) {
+	return m.approve(token, spender, big.NewInt(0))
+}
+
+// RevokeAll revokes every approval Approvals reports for the manager's
+// account
+func (m *AllowanceManager) RevokeAll() ([]*types.Transaction, error) {
+	approvals, err := m.Approvals(m.ContractManager.Transactor.From)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []*types.Transaction
+	for _, approval := range approvals {
+		tx, err := m.Revoke(approval.Token, approval.Spender)
+		if err != nil {
+			return txs, fmt.Errorf("failed to revoke %s for %s: %v", approval.Token.Hex(), approval.Spender.Hex(), err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// Approvals lists owner's nonzero allowances of the registered tokens
+// deployed on the connected chain. Only the registered protocol contracts
+// and spenders this manager has approved are checked, as allowances cannot
+// be enumerated on-chain.
+func (m *AllowanceManager) Approvals(owner common.Address) ([]Approval, error) {
+	var tokens []common.Address
+	spenders := make(map[common.Address]bool)
+	for name, contract := range m.ContractManager.registeredContracts() {
+		if strings.HasPrefix(name, "erc20_") {
+			code, err := m.ContractManager.Client.CodeAt(context.Background(), contract.Address, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get code of %s: %v", name, err)
+			}
+			if len(code) > 0 {
+				tokens = append(tokens, contract.Address)
+			}
+		} else {
+			spenders[contract.Address] = true
+		}
+	}
+	m.mu.Lock()
+	for spender := range m.spenders {
+		spenders[spender] = true
+	}
+	m.mu.Unlock()
+
+	sorted := make([]common.Address, 0, len(spenders))
+	for spender := range spenders {
+		sorted = append(sorted, spender)
+	}
+	byAddress := func(list []common.Address) {
+		sort.Slice(list, func(i, j int) bool { return bytes.Compare(list[i][:], list[j][:]) < 0 })
+	}
+	byAddress(tokens)
+	byAddress(sorted)
+
+	var approvals []Approval
+	for _, token := range tokens {
+		for _, spender := range sorted {
+			amount, err := m.Allowance(token, owner, spender)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get allowance of %s for %s: %v", token.Hex(), spender.Hex(), err)
+			}
+			if amount.Sign() > 0 {
+				approvals = append(approvals, Approval{Token: token, Spender: spender, Amount: amount})
+			}
+		}
+	}
+	return approvals, nil
+}
+
+// approve sends an ERC20 approval from the manager's account
+func (m *AllowanceManager) approve(token, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	name, err := m.ContractManager.tokenContractName(token)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.ContractManager.TransactContract(name, ERC20Approve, big.NewInt(0), spender, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve %s: %v", spender.Hex(), err)
+	}
+	return tx, nil
+}
+
+// track remembers a spender so Approvals checks it
+func (m *AllowanceManager) track(spender common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spenders[spender] = true
+}
+
+// Permit is a signed EIP-2612 approval of Spender for Value of Token
+type Permit struct {
+	Token    common.Address
+	Owner    common.Address
+	Spender  common.Address
+	Value    *big.Int
+	Nonce    *big.Int
+	Deadline *big.Int
+
+	V    uint8
+	R, S [32]byte
+}
+
+// SignPermit signs an EIP-2612 permit from the manager's account. The
+// token must implement permit; its nonce and domain separator are read from
+// the token.
+func (m *AllowanceManager) SignPermit(token, spender common.Address, value, deadline *big.Int) (*Permit, error) {
+	cm := m.ContractManager
+	name, err := cm.tokenContractName(token)
+	if err != nil {
+		return nil, err
+	}
+	owner := cm.Transactor.From
+
+	result, err := cm.CallContract(name, ERC20Nonces, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permit nonce: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no permit nonce returned")
+	}
+	nonce, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid permit nonce format")
+	}
+	domain, err := m.domainSeparator(name)
+	if err != nil {
+		return nil, err
+	}
+
+	structHash := crypto.Keccak256Hash(
+		permitTypehash.Bytes(),
+		common.LeftPadBytes(owner.Bytes(), 32),
+		common.LeftPadBytes(spender.Bytes(), 32),
+		word(value),
+		word(nonce),
+		word(deadline),
+	)
+	signature, err := cm.signTypedData(domain, structHash)
+	if err != nil {
+		return nil, err
+	}
+
+	permit := &Permit{
+		Token:    token,
+		Owner:    owner,
+		Spender:  spender,
+		Value:    value,
+		Nonce:    nonce,
+		Deadline: deadline,
+		V:        signature[64],
+	}
+	copy(permit.R[:], signature[:32])
+	copy(permit.S[:], signature[32:64])
+	m.track(spender)
+	return permit, nil
+}
+
+// SubmitPermit sends a permit as its own transaction. Permits are usually
+// added to a bundle with Bundle.Permit instead, so the approval and the
+// spend share one transaction.
+func (m *AllowanceManager) SubmitPermit(permit *Permit) (*types.Transaction, error) {
+	name, err := m.ContractManager.tokenContractName(permit.Token)
+	if err != nil {
+		return nil, err
+	}
+	return m.ContractManager.TransactContract(name, ERC20Permit, big.NewInt(0),
+		permit.Owner, permit.Spender, permit.Value, permit.Deadline, permit.V, permit.R, permit.S)
+}
+
+// Permit2Approval is a signed Permit2 PermitSingle letting Spender move up
+// to Amount of Token until Expiration
+type Permit2Approval struct {
+	Owner       common.Address
+	Token       common.Address
+	Amount      *big.Int
+	Expiration  *big.Int
+	Nonce       *big.Int
+	Spender     common.Address
+	SigDeadline *big.Int
+	Signature   []byte
+}
+
+// permitSingle is Permit2's PermitSingle tuple; the ABI encoder expects
+// *big.Int for uint160 and uint48
+type permitSingle struct {
+	Details     permitDetails
+	Spender     common.Address
+	SigDeadline *big.Int
+}
+
+// permitDetails is Permit2's PermitDetails tuple
+type permitDetails struct {
+	Token      common.Address
+	Amount     *big.Int
+	Expiration *big.Int
+	Nonce      *big.Int
+}
+
+// EnsurePermit2Approval approves Permit2 to move the maximum amount of
+// token from the manager's account, once per token. Spenders are then
+// authorized by Permit2 signatures instead of approval transactions.
+func (m *AllowanceManager) EnsurePermit2Approval(token common.Address) (*types.Transaction, error) {
+	permit2, exists := m.ContractManager.Contract("permit2")
+	if !exists {
+		return nil, fmt.Errorf("contract permit2 not found")
+	}
+
+	maxAmount := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	owner := m.ContractManager.Transactor.From
+	current, err := m.Allowance(token, owner, permit2.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allowance: %v", err)
+	}
+	// Permit2 leaves a maximum allowance untouched, so half of it is as
+	// good as unlimited
+	if current.Cmp(new(big.Int).Rsh(maxAmount, 1)) > 0 {
+		return nil, nil
+	}
+	return m.approve(token, permit2.Address, maxAmount)
+}
+
+// Permit2Allowance returns spender's Permit2 allowance of owner's token, its
+// expiry as a unix time and the owner's next permit nonce
+func (m *AllowanceManager) Permit2Allowance(owner, token, spender common.Address) (amount, expiration, nonce *big.Int, err error) {
+	result, err := m.ContractManager.CallContract("permit2", Permit2Allowance, owner, token, spender)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(result) != 3 {
+		return nil, nil, nil, fmt.Errorf("invalid Permit2 allowance format")
+	}
+
+	values := make([]*big.Int, 3)
+	for i := range values {
+		value, ok := result[i].(*big.Int)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("invalid Permit2 allowance format")
+		}
+		values[i] = value
+	}
+	return values[0], values[1], values[2], nil
+}
+
+// SignPermit2 signs a Permit2 PermitSingle from the manager's account. The
+// token must already be approved for Permit2 with EnsurePermit2Approval.
+func (m *AllowanceManager) SignPermit2(token, spender common.Address, amount, expiration, sigDeadline *big.Int) (*Permit2Approval, error) {
+	cm := m.ContractManager
+	owner := cm.Transactor.From
+	_, _, nonce, err := m.Permit2Allowance(owner, token, spender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Permit2 nonce: %v", err)
+	}
+	domain, err := m.domainSeparator("permit2")
+	if err != nil {
+		return nil, err
+	}
+
+	detailsHash := crypto.Keccak256Hash(
+		permitDetailsTypehash.Bytes(),
+		common.LeftPadBytes(token.Bytes(), 32),
+		word(amount),
+		word(expiration),
+		word(nonce),
+	)
+	structHash := crypto.Keccak256Hash(
+		permitSingleTypehash.Bytes(),
+		detailsHash.Bytes(),
+		common.LeftPadBytes(spender.Bytes(), 32),
+		word(sigDeadline),
+	)
+	signature, err := cm.signTypedData(domain, structHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Permit2Approval{
+		Owner:       owner,
+		Token:       token,
+		Amount:      amount,
+		Expiration:  expiration,
+		Nonce:       nonce,
+		Spender:     spender,
+		SigDeadline: sigDeadline,
+		Signature:   signature,
+	}, nil
+}
+
+// SubmitPermit2 sends a Permit2 permit as its own transaction
+func (m *AllowanceManager) SubmitPermit2(permit *Permit2Approval) (*types.Transaction, error) {
+	return m.ContractManager.TransactContract("permit2", Permit2Permit, big.NewInt(0), permit.Owner, permit.single(), permit.Signature)
+}
+
+func (p *Permit2Approval) single() permitSingle {
+	return permitSingle{
+		Details: permitDetails{
+			Token:      p.Token,
+			Amount:     p.Amount,
+			Expiration: p.Expiration,
+			Nonce:      p.Nonce,
+		},
+		Spender:     p.Spender,
+		SigDeadline: p.SigDeadline,
+	}
+}
+
+// domainSeparator reads a registered contract's EIP-712 domain separator
+func (m *AllowanceManager) domainSeparator(contractName string) (common.Hash, error) {
+	result, err := m.ContractManager.CallContract(contractName, ERC20DomainSeparator)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get domain separator: %v", err)
+	}
+	if len(result) == 0 {
+		return common.Hash{}, fmt.Errorf("no domain separator returned")
+	}
+	domain, ok := result[0].([32]byte)
+	if !ok {
+		return common.Hash{}, fmt.Errorf("invalid domain separator format")
+	}
+	return domain, nil
+}
+
+// word encodes a uint256 as an ABI word
+func word(n *big.Int) []byte {
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
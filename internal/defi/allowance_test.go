@@ -0,0 +1,248 @@
+package defi
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+// autoCommit mines a block every few milliseconds until the test ends, for
+// code that waits on its own transactions. Fixture transactions commit their
+// own blocks and must not race it, so tests make fixture changes before
+// starting it or through the returned pause, which runs them between blocks.
+func autoCommit(t *testing.T, chain *defitest.Chain, cm *ContractManager) (pause func(fixture func())) {
+	t.Helper()
+
+	cm.Monitor.PollInterval = 5 * time.Millisecond
+	var mu sync.Mutex
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				chain.Commit()
+				mu.Unlock()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+
+	return func(fixture func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		fixture()
+	}
+}
+
+func TestAllowanceManagerApprovesOnlyWhenNeeded(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	allowances := NewAllowanceManager(cm)
+
+	tx, err := allowances.EnsureAllowance(chain.USDC, chain.Router, defitest.Ether(100))
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+	mine(t, chain, cm, tx)
+	assert.Equal(t, defitest.Ether(100).String(), chain.Allowance(chain.USDC, chain.Account, chain.Router).String())
+
+	tx, err = allowances.EnsureAllowance(chain.USDC, chain.Router, defitest.Ether(50))
+	require.NoError(t, err)
+	assert.Nil(t, tx)
+
+	// Capped mode approves up to the cap and refuses to go past it
+	allowances.Mode = ApproveCapped
+	allowances.Caps[chain.USDC] = defitest.Ether(1_000)
+	tx, err = allowances.EnsureAllowance(chain.USDC, chain.Router, defitest.Ether(200))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	assert.Equal(t, defitest.Ether(1_000).String(), chain.Allowance(chain.USDC, chain.Account, chain.Router).String())
+
+	_, err = allowances.EnsureAllowance(chain.USDC, chain.Router, defitest.Ether(2_000))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the approval cap")
+
+	// Tokens without a cap are approved exactly
+	tx, err = allowances.EnsureAllowance(chain.WETH, chain.Pool, defitest.Ether(3))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+
+	approvals, err := allowances.Approvals(chain.Account)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Approval{
+		{Token: chain.USDC, Spender: chain.Router, Amount: defitest.Ether(1_000)},
+		{Token: chain.WETH, Spender: chain.Pool, Amount: defitest.Ether(3)},
+	}, approvals)
+
+	txs, err := allowances.RevokeAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	for _, tx := range txs {
+		assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	}
+
+	approvals, err = allowances.Approvals(chain.Account)
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+}
+
+func TestManagersApproveBeforeSpending(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+	autoCommit(t, chain, cm)
+	allowances := NewAllowanceManager(cm)
+
+	aave := NewAaveManager(cm)
+	aave.Allowances = allowances
+	tx, err := aave.ExecuteDeposit(DepositParams{
+		Asset:        chain.USDC,
+		Amount:       defitest.Ether(400),
+		OnBehalfOf:   chain.Account,
+		ReferralCode: AaveReferralCode,
+	})
+	require.NoError(t, err)
+	receipt, err := aave.MonitorTransaction(tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, defitest.Ether(400).String(), chain.PoolAccount(chain.Account).Collateral.String())
+
+	uv3 := NewUniswapV3Manager(cm)
+	uv3.Allowances = allowances
+	tx, err = uv3.ExecuteSwap(SwapParams{
+		TokenIn:          chain.USDC,
+		TokenOut:         chain.WETH,
+		Fee:              FeeTierMedium,
+		Recipient:        chain.Account,
+		Deadline:         CreateDeadline(10),
+		AmountIn:         defitest.Ether(100),
+		AmountOutMinimum: big.NewInt(49_850_000_000_000_000),
+	})
+	require.NoError(t, err)
+	receipt, err = uv3.MonitorSwap(tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, "49850000000000000", chain.BalanceOf(chain.WETH, chain.Account).String())
+
+	// Exact approvals are used up by the spend
+	approvals, err := allowances.Approvals(chain.Account)
+	require.NoError(t, err)
+	assert.Empty(t, approvals)
+}
+
+func TestPermitAvoidsApprovalTransaction(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	allowances := NewAllowanceManager(cm)
+	ctx := context.Background()
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(100))
+
+	// The executor pulls the tokens in the same transaction as the permit
+	permit, err := allowances.SignPermit(chain.USDC, chain.Executor, defitest.Ether(60), CreateDeadline(10))
+	require.NoError(t, err)
+	assert.Zero(t, permit.Nonce.Sign())
+
+	pull := func(permit *Permit) *Bundle {
+		bundle := cm.NewBundle()
+		require.NoError(t, bundle.Permit(permit))
+		require.NoError(t, bundle.Add("erc20_USDC", ERC20TransferFrom, chain.Account, chain.Executor, permit.Value))
+		return bundle
+	}
+	tx, err := pull(permit).Execute(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	assert.Equal(t, defitest.Ether(60).String(), chain.BalanceOf(chain.USDC, chain.Executor).String())
+
+	nonce, err := chain.Client.PendingNonceAt(ctx, chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+
+	// A permit cannot be replayed once its nonce is used
+	_, err = pull(permit).Execute(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ERC20: invalid signature")
+
+	permit, err = allowances.SignPermit(chain.USDC, chain.Router, defitest.Ether(25), CreateDeadline(10))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), permit.Nonce.Int64())
+	tx, err = allowances.SubmitPermit(permit)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	assert.Equal(t, defitest.Ether(25).String(), chain.Allowance(chain.USDC, chain.Account, chain.Router).String())
+
+	approvals, err := allowances.Approvals(chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, []Approval{{Token: chain.USDC, Spender: chain.Router, Amount: defitest.Ether(25)}}, approvals)
+}
+
+func TestPermit2SignatureTransfers(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	allowances := NewAllowanceManager(cm)
+	ctx := context.Background()
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(100))
+
+	// Permit2 is approved once per token
+	tx, err := allowances.EnsurePermit2Approval(chain.USDC)
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+	mine(t, chain, cm, tx)
+	tx, err = allowances.EnsurePermit2Approval(chain.USDC)
+	require.NoError(t, err)
+	assert.Nil(t, tx)
+
+	expiration := big.NewInt(time.Now().Add(time.Hour).Unix())
+	permit, err := allowances.SignPermit2(chain.USDC, chain.Executor, defitest.Ether(60), expiration, CreateDeadline(10))
+	require.NoError(t, err)
+
+	transfer := func(permit *Permit2Approval, amount *big.Int) *Bundle {
+		bundle := cm.NewBundle()
+		if permit != nil {
+			require.NoError(t, bundle.Permit2(permit))
+		}
+		require.NoError(t, bundle.Add("permit2", Permit2TransferFrom, chain.Account, chain.Executor, amount, chain.USDC))
+		return bundle
+	}
+	tx, err = transfer(permit, defitest.Ether(40)).Execute(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	assert.Equal(t, defitest.Ether(40).String(), chain.BalanceOf(chain.USDC, chain.Executor).String())
+
+	amount, expires, nonce, err := allowances.Permit2Allowance(chain.Account, chain.USDC, chain.Executor)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(20).String(), amount.String())
+	assert.Equal(t, expiration.String(), expires.String())
+	assert.Equal(t, int64(1), nonce.Int64())
+
+	// The remaining allowance is spent without a new signature, but not
+	// exceeded
+	_, err = transfer(nil, defitest.Ether(21)).Simulate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InsufficientAllowance")
+
+	// Replaying the used signature fails on its nonce
+	_, err = transfer(permit, defitest.Ether(20)).Simulate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InvalidNonce")
+}
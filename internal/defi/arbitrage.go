@@ -0,0 +1,539 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+)
+
+// Typical gas used by a swap through one pool of each venue, on top of the
+// 21000 paid once per transaction
+const (
+	GasPerV2Swap    = 110_000
+	GasPerV3Swap    = 130_000
+	GasPerCurveSwap = 150_000
+	gasPerTx        = 21_000
+)
+
+// VenuePool is one venue's pool for a token pair. Token0 and Token1 follow
+// the pool's own ordering, so zero-for-one swaps sell Token0.
+type VenuePool struct {
+	Venue    string // e.g. "uniswap_v2", "sushiswap", "uniswap_v3", "curve"
+	Token0   string
+	Token1   string
+	Pool     poolmath.Pool
+	GasUnits uint64
+}
+
+// Pair returns the pool's pair as "TOKEN0/TOKEN1"
+func (vp *VenuePool) Pair() string {
+	return vp.Token0 + "/" + vp.Token1
+}
+
+// ID identifies the pool by venue and pair
+func (vp *VenuePool) ID() string {
+	return vp.Venue + ":" + vp.Pair()
+}
+
+// other returns the token the pool pays out for tokenIn
+func (vp *VenuePool) other(tokenIn string) (string, bool) {
+	switch tokenIn {
+	case vp.Token0:
+		return vp.Token1, true
+	case vp.Token1:
+		return vp.Token0, true
+	default:
+		return "", false
+	}
+}
+
+// ArbitrageToken describes a token cycles may start and end in
+type ArbitrageToken struct {
+	Decimals int
+	PriceUSD float64
+}
+
+// ArbitrageOpportunity is a profitable cycle of swaps sized for maximum
+// profit. Amounts are in base units of the cycle's first token.
+type ArbitrageOpportunity struct {
+	ID     string
+	Path   []string // tokens visited, starting and ending with the same token
+	Venues []string // pool IDs, one per leg
+
+	AmountIn     *big.Int
+	AmountOut    *big.Int
+	MinAmountOut *big.Int // AmountOut less the slippage tolerance
+	GasUnits     uint64
+	GasCost      *big.Int
+	Profit       *big.Int // AmountOut - AmountIn - GasCost
+
+	ProfitUSD  float64
+	ProfitPct  float64 // Profit / AmountIn
+	DetectedAt time.Time
+}
+
+// Legs returns the number of swaps in the cycle
+func (o *ArbitrageOpportunity) Legs() int {
+	return len(o.Venues)
+}
+
+// String describes the route, e.g. "WETH -(uniswap_v2:WETH/USDC)-> USDC -(sushiswap:WETH/USDC)-> WETH"
+func (o *ArbitrageOpportunity) String() string {
+	var b strings.Builder
+	b.WriteString(o.Path[0])
+	for i, venue := range o.Venues {
+		fmt.Fprintf(&b, " -(%s)-> %s", venue, o.Path[i+1])
+	}
+	return b.String()
+}
+
+// ArbitrageSink receives each scan's opportunities, best first
+type ArbitrageSink interface {
+	HandleArbitrage(opportunities []ArbitrageOpportunity)
+}
+
+// ArbitrageSinkFunc adapts a function to ArbitrageSink
+type ArbitrageSinkFunc func(opportunities []ArbitrageOpportunity)
+
+// HandleArbitrage calls f
+func (f ArbitrageSinkFunc) HandleArbitrage(opportunities []ArbitrageOpportunity) {
+	f(opportunities)
+}
+
+// WebSocketArbitrageFeed publishes each scan on a market data WebSocket
+// service as an arbitrage_update message
+func WebSocketArbitrageFeed(ws *market.WebSocketService) ArbitrageSink {
+	return ArbitrageSinkFunc(func(opportunities []ArbitrageOpportunity) {
+		updates := make([]market.ArbitrageUpdate, len(opportunities))
+		for i, o := range opportunities {
+			updates[i] = market.ArbitrageUpdate{
+				ID:        o.ID,
+				Path:      o.Path,
+				Venues:    o.Venues,
+				AmountIn:  o.AmountIn.String(),
+				AmountOut: o.AmountOut.String(),
+				GasCost:   o.GasCost.String(),
+				Profit:    o.Profit.String(),
+				ProfitUSD: o.ProfitUSD,
+				ProfitPct: o.ProfitPct,
+				Timestamp: o.DetectedAt.Unix(),
+			}
+		}
+		ws.BroadcastArbitrage(updates)
+	})
+}
+
+// ArbitrageScanner finds two-leg and triangular arbitrage cycles across the
+// pool states it is given. Cycles start from the tokens registered with
+// SetToken, whose USD prices value the profit and convert gas into the
+// starting token.
+type ArbitrageScanner struct {
+	mu     sync.RWMutex
+	pools  map[string]*VenuePool
+	tokens map[string]ArbitrageToken
+
+	NativeToken  string   // token gas is paid in, usually "WETH"
+	GasPrice     *big.Int // wei per gas
+	Slippage     float64  // tolerance applied to MinAmountOut
+	MinProfitUSD float64
+	Clock        Clock
+	Sinks        []ArbitrageSink
+
+	// Sources supply the pool states Refresh loads
+	Sources []PoolSource
+}
+
+// NewArbitrageScanner creates a scanner pricing gas at 20 gwei in WETH
+func NewArbitrageScanner() *ArbitrageScanner {
+	return &ArbitrageScanner{
+		pools:       make(map[string]*VenuePool),
+		tokens:      make(map[string]ArbitrageToken),
+		NativeToken: "WETH",
+		GasPrice:    big.NewInt(20_000_000_000),
+		Slippage:    0.005,
+		Clock:       SystemClock,
+	}
+}
+
+// DefaultGasUnits returns the typical swap gas for a venue
+func DefaultGasUnits(venue string) uint64 {
+	switch {
+	case strings.Contains(venue, "v3"):
+		return GasPerV3Swap
+	case strings.Contains(venue, "curve"):
+		return GasPerCurveSwap
+	default:
+		return GasPerV2Swap
+	}
+}
+
+// SetPool adds or replaces a venue's pool state. GasUnits defaults by venue.
+func (s *ArbitrageScanner) SetPool(vp VenuePool) error {
+	if vp.Pool == nil || vp.Venue == "" || vp.Token0 == "" || vp.Token1 == "" || vp.Token0 == vp.Token1 {
+		return fmt.Errorf("invalid pool %s", vp.ID())
+	}
+	if vp.GasUnits == 0 {
+		vp.GasUnits = DefaultGasUnits(vp.Venue)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pools[vp.ID()] = &vp
+	return nil
+}
+
+// Refresh reloads the pools of every source. Pools a source fails to read,
+// or has not read by the time ctx is done, keep their previous state; the
+// failures are returned together.
+func (s *ArbitrageScanner) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, source := range s.Sources {
+		pools, err := source.LoadPools(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, vp := range pools {
+			if err := s.SetPool(vp); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RemovePool drops a venue's pool for a pair
+func (s *ArbitrageScanner) RemovePool(venue, pair string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pools, venue+":"+pair)
+}
+
+// SetToken registers a token cycles may start from
+func (s *ArbitrageScanner) SetToken(symbol string, decimals int, priceUSD float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[symbol] = ArbitrageToken{Decimals: decimals, PriceUSD: priceUSD}
+}
+
+// SetTokenPrice updates a registered token's USD price
+func (s *ArbitrageScanner) SetTokenPrice(symbol string, priceUSD float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[symbol]; ok {
+		token.PriceUSD = priceUSD
+		s.tokens[symbol] = token
+	}
+}
+
+// PriceSpread returns the largest relative difference between venues' spot
+// prices for a pair, and false if fewer than two venues quote it
+func (s *ArbitrageScanner) PriceSpread(pair string) (float64, bool) {
+	base, quote, ok := strings.Cut(pair, "/")
+	if !ok {
+		return 0, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	low, high := math.Inf(1), 0.0
+	venues := 0
+	for _, vp := range s.pools {
+		price := vp.Pool.SpotPrice()
+		switch {
+		case vp.Token0 == base && vp.Token1 == quote:
+		case vp.Token0 == quote && vp.Token1 == base && price > 0:
+			price = 1 / price
+		default:
+			continue
+		}
+		if price <= 0 {
+			continue
+		}
+		low, high = math.Min(low, price), math.Max(high, price)
+		venues++
+	}
+	if venues < 2 {
+		return 0, false
+	}
+	return (high - low) / low, true
+}
+
+// Scan searches every cycle for its most profitable size and returns the
+// profitable ones ranked by USD profit. The results are also sent to Sinks.
+func (s *ArbitrageScanner) Scan() []ArbitrageOpportunity {
+	s.mu.RLock()
+	cycles := s.cycles()
+	tokens := make(map[string]ArbitrageToken, len(s.tokens))
+	for symbol, token := range s.tokens {
+		tokens[symbol] = token
+	}
+	s.mu.RUnlock()
+
+	now := time.Now()
+	if s.Clock != nil {
+		now = s.Clock.Now()
+	}
+
+	// Rotations of a cycle are the same trade; keep the best starting token
+	best := make(map[string]ArbitrageOpportunity)
+	for _, c := range cycles {
+		opportunity, ok := s.evaluate(c, tokens)
+		if !ok || opportunity.ProfitUSD < s.MinProfitUSD {
+			continue
+		}
+		opportunity.DetectedAt = now
+		key := c.key()
+		if current, exists := best[key]; !exists || opportunity.ProfitUSD > current.ProfitUSD {
+			best[key] = opportunity
+		}
+	}
+
+	opportunities := make([]ArbitrageOpportunity, 0, len(best))
+	for _, opportunity := range best {
+		opportunities = append(opportunities, opportunity)
+	}
+	sort.Slice(opportunities, func(i, j int) bool {
+		if opportunities[i].ProfitUSD != opportunities[j].ProfitUSD {
+			return opportunities[i].ProfitUSD > opportunities[j].ProfitUSD
+		}
+		return opportunities[i].ID < opportunities[j].ID
+	})
+
+	for _, sink := range s.Sinks {
+		sink.HandleArbitrage(opportunities)
+	}
+	return opportunities
+}
+
+// cycle is a closed sequence of swaps; tokens[i] is sold into pools[i]
+type cycle struct {
+	tokens []string
+	pools  []*VenuePool
+}
+
+// key identifies a cycle regardless of the token it starts from
+func (c cycle) key() string {
+	legs := make([]string, len(c.pools))
+	for i, vp := range c.pools {
+		legs[i] = c.tokens[i] + "@" + vp.ID()
+	}
+	start := 0
+	for i := range legs {
+		if legs[i] < legs[start] {
+			start = i
+		}
+	}
+	return strings.Join(append(legs[start:], legs[:start]...), ",")
+}
+
+// cycles lists the two- and three-leg cycles starting from known tokens.
+// Callers hold the read lock.
+func (s *ArbitrageScanner) cycles() []cycle {
+	ids := make([]string, 0, len(s.pools))
+	for id := range s.pools {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	pools := make([]*VenuePool, len(ids))
+	for i, id := range ids {
+		pools[i] = s.pools[id]
+	}
+
+	var cycles []cycle
+	for start := range s.tokens {
+		for _, first := range pools {
+			b, ok := first.other(start)
+			if !ok {
+				continue
+			}
+			for _, second := range pools {
+				if second == first {
+					continue
+				}
+				c, ok := second.other(b)
+				if !ok {
+					continue
+				}
+				if c == start {
+					cycles = append(cycles, cycle{
+						tokens: []string{start, b},
+						pools:  []*VenuePool{first, second},
+					})
+					continue
+				}
+				for _, third := range pools {
+					if third == first || third == second {
+						continue
+					}
+					if out, ok := third.other(c); ok && out == start {
+						cycles = append(cycles, cycle{
+							tokens: []string{start, b, c},
+							pools:  []*VenuePool{first, second, third},
+						})
+					}
+				}
+			}
+		}
+	}
+	return cycles
+}
+
+// run simulates the cycle with amountIn of its first token and returns the
+// amount of it that comes back. Legs that cannot be filled in full fail.
+func (c cycle) run(amountIn *big.Int) (*big.Int, error) {
+	amount := amountIn
+	for i, vp := range c.pools {
+		result, err := poolmath.Quote(vp.Pool, amount, c.tokens[i] == vp.Token0)
+		if err != nil {
+			return nil, err
+		}
+		if result.AmountIn.Cmp(amount) != 0 {
+			return nil, fmt.Errorf("%s cannot fill %s", vp.ID(), amount)
+		}
+		amount = result.AmountOut
+	}
+	return amount, nil
+}
+
+// grossProfit returns the cycle's output less its input, or nil if it fails
+func (c cycle) grossProfit(amountIn *big.Int) *big.Int {
+	out, err := c.run(amountIn)
+	if err != nil {
+		return nil
+	}
+	return out.Sub(out, amountIn)
+}
+
+// optimalInput finds the input that maximises the cycle's gross profit.
+// Profit is concave in the input, so the input is doubled until profit
+// stops growing and the bracket is then narrowed by ternary search. Dust
+// amounts lose to rounding, so the doubling only stops once it has found
+// a profit.
+func (c cycle) optimalInput() (*big.Int, *big.Int) {
+	var bestIn, bestProfit *big.Int
+	amount := big.NewInt(1)
+	for amount.BitLen() <= 128 {
+		profit := c.grossProfit(amount)
+		if profit != nil && (bestProfit == nil || profit.Cmp(bestProfit) > 0) {
+			bestIn, bestProfit = new(big.Int).Set(amount), profit
+		} else if bestProfit != nil && bestProfit.Sign() > 0 {
+			break
+		}
+		amount.Lsh(amount, 1)
+	}
+	if bestProfit == nil || bestProfit.Sign() <= 0 {
+		return nil, nil
+	}
+
+	// The maximum lies between half and double the best doubling step
+	low, high := new(big.Int).Rsh(bestIn, 1), amount
+	tolerance := new(big.Int).Rsh(high, 20)
+	if tolerance.Cmp(big.NewInt(2)) < 0 {
+		tolerance.SetInt64(2)
+	}
+	three := big.NewInt(3)
+	for new(big.Int).Sub(high, low).Cmp(tolerance) > 0 {
+		third := new(big.Int).Sub(high, low)
+		third.Quo(third, three)
+		m1 := new(big.Int).Add(low, third)
+		m2 := new(big.Int).Sub(high, third)
+
+		p1, p2 := c.grossProfit(m1), c.grossProfit(m2)
+		if p1 != nil && p1.Cmp(bestProfit) > 0 {
+			bestIn, bestProfit = m1, p1
+		}
+		if p2 != nil && p2.Cmp(bestProfit) > 0 {
+			bestIn, bestProfit = m2, p2
+		}
+		if p2 == nil || (p1 != nil && p1.Cmp(p2) >= 0) {
+			high = m2
+		} else {
+			low = m1
+		}
+	}
+	return bestIn, bestProfit
+}
+
+// evaluate sizes a cycle and nets out gas, returning false if it is not
+// profitable
+func (s *ArbitrageScanner) evaluate(c cycle, tokens map[string]ArbitrageToken) (ArbitrageOpportunity, bool) {
+	base := tokens[c.tokens[0]]
+	amountIn, gross := c.optimalInput()
+	if amountIn == nil {
+		return ArbitrageOpportunity{}, false
+	}
+
+	gasUnits := uint64(gasPerTx)
+	for _, vp := range c.pools {
+		gasUnits += vp.GasUnits
+	}
+	gasCost, ok := s.gasCost(gasUnits, c.tokens[0], tokens)
+	if !ok {
+		return ArbitrageOpportunity{}, false
+	}
+	profit := new(big.Int).Sub(gross, gasCost)
+	if profit.Sign() <= 0 {
+		return ArbitrageOpportunity{}, false
+	}
+
+	amountOut := new(big.Int).Add(amountIn, gross)
+	path := append(append([]string(nil), c.tokens...), c.tokens[0])
+	venues := make([]string, len(c.pools))
+	for i, vp := range c.pools {
+		venues[i] = vp.ID()
+	}
+
+	profitTokens, _ := new(big.Float).Quo(new(big.Float).SetInt(profit), big.NewFloat(math.Pow10(base.Decimals))).Float64()
+	profitPct, _ := new(big.Float).Quo(new(big.Float).SetInt(profit), new(big.Float).SetInt(amountIn)).Float64()
+	return ArbitrageOpportunity{
+		ID:           strings.Join(path, ">") + "|" + strings.Join(venues, ">"),
+		Path:         path,
+		Venues:       venues,
+		AmountIn:     amountIn,
+		AmountOut:    amountOut,
+		MinAmountOut: ApplySlippage(amountOut, s.Slippage),
+		GasUnits:     gasUnits,
+		GasCost:      gasCost,
+		Profit:       profit,
+		ProfitUSD:    profitTokens * base.PriceUSD,
+		ProfitPct:    profitPct,
+	}, true
+}
+
+// gasCost converts gasUnits at GasPrice into base units of token, through
+// the USD prices of the native token and of token
+func (s *ArbitrageScanner) gasCost(gasUnits uint64, token string, tokens map[string]ArbitrageToken) (*big.Int, bool) {
+	wei := new(big.Int).SetUint64(gasUnits)
+	if s.GasPrice != nil {
+		wei.Mul(wei, s.GasPrice)
+	} else {
+		wei.SetInt64(0)
+	}
+	if wei.Sign() == 0 || token == s.NativeToken {
+		return wei, true
+	}
+
+	native, nativeOK := tokens[s.NativeToken]
+	target := tokens[token]
+	if !nativeOK || native.PriceUSD <= 0 || target.PriceUSD <= 0 {
+		return nil, false
+	}
+
+	// wei -> native tokens -> USD -> target tokens -> target base units
+	cost := new(big.Float).SetInt(wei)
+	cost.Quo(cost, big.NewFloat(math.Pow10(native.Decimals)))
+	cost.Mul(cost, big.NewFloat(native.PriceUSD/target.PriceUSD))
+	cost.Mul(cost, big.NewFloat(math.Pow10(target.Decimals)))
+	units, _ := cost.Int(nil)
+	return units, true
+}
@@ -0,0 +1,116 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+)
+
+// ErrNoPool reports that a venue has no pool for a pair
+var ErrNoPool = errors.New("pool not deployed")
+
+// PoolSource supplies the pool states an ArbitrageScanner refreshes from
+type PoolSource interface {
+	LoadPools(ctx context.Context) ([]VenuePool, error)
+}
+
+// DEXPoolSource reads the current state of token pairs' pools through the
+// DEX managers. Pools are named by the pair's registered token symbols, with
+// the lower token address as Token0 on every venue.
+type DEXPoolSource struct {
+	ContractManager *ContractManager
+
+	// Pairs are the pairs to read, as "WETH/USDC"; each symbol must be
+	// registered as an "erc20_<symbol>" contract
+	Pairs []string
+
+	// V2 are the Uniswap V2 style venues read for each pair
+	V2 []*UniswapV2Manager
+
+	// V3 reads Uniswap V3 pools at each of FeeTiers, as venues named
+	// "uniswap_v3_<fee>"
+	V3       *UniswapV3LPManager
+	FeeTiers []uint24
+
+	Curve *CurveManager
+}
+
+// NewDEXPoolSource reads pairs from Uniswap V2, SushiSwap, Uniswap V3 at
+// every fee tier, and Curve
+func NewDEXPoolSource(cm *ContractManager, pairs ...string) *DEXPoolSource {
+	return &DEXPoolSource{
+		ContractManager: cm,
+		Pairs:           pairs,
+		V2:              []*UniswapV2Manager{NewUniswapV2Manager(cm), NewSushiSwapManager(cm)},
+		V3:              NewUniswapV3LPManager(cm),
+		FeeTiers:        []uint24{FeeTierLow, FeeTierMedium, FeeTierHigh},
+		Curve:           NewCurveManager(cm),
+	}
+}
+
+// LoadPools reads every venue's pool for each pair. Venues without a pool
+// for a pair are skipped; other failures are returned together with the
+// pools that were read. Reading stops between pools once ctx is done.
+func (ps *DEXPoolSource) LoadPools(ctx context.Context) ([]VenuePool, error) {
+	var pools []VenuePool
+	var errs []error
+	for _, pair := range ps.Pairs {
+		if ctx.Err() != nil {
+			break
+		}
+		symbolA, symbolB, ok := strings.Cut(pair, "/")
+		if !ok {
+			errs = append(errs, fmt.Errorf("invalid pair %q", pair))
+			continue
+		}
+		tokenA, okA := ps.ContractManager.Contract("erc20_" + symbolA)
+		tokenB, okB := ps.ContractManager.Contract("erc20_" + symbolB)
+		if !okA || !okB {
+			errs = append(errs, fmt.Errorf("pair %s has unregistered tokens", pair))
+			continue
+		}
+		symbol0, symbol1 := symbolA, symbolB
+		token0, token1 := sortTokens(tokenA.Address, tokenB.Address)
+		if token0 != tokenA.Address {
+			symbol0, symbol1 = symbolB, symbolA
+		}
+
+		add := func(venue string, pool poolmath.Pool, err error) {
+			switch {
+			case errors.Is(err, ErrNoPool):
+			case err != nil:
+				errs = append(errs, fmt.Errorf("failed to load %s pool for %s/%s: %v", venue, symbol0, symbol1, err))
+			default:
+				pools = append(pools, VenuePool{Venue: venue, Token0: symbol0, Token1: symbol1, Pool: pool})
+			}
+		}
+		for _, v2 := range ps.V2 {
+			if ctx.Err() != nil {
+				break
+			}
+			pool, err := v2.PoolState(token0, token1)
+			add(v2.Name(), pool, err)
+		}
+		if ps.V3 != nil {
+			for _, fee := range ps.FeeTiers {
+				if ctx.Err() != nil {
+					break
+				}
+				pool, err := ps.V3.PoolState(token0, token1, fee)
+				add(fmt.Sprintf("uniswap_v3_%d", fee), pool, err)
+			}
+		}
+		if ps.Curve != nil && ctx.Err() == nil {
+			pool, err := ps.Curve.PoolState(token0, token1)
+			add(ps.Curve.Name(), pool, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return pools, errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package defi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+)
+
+func TestDEXPoolSourceOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	require.NoError(t, cm.SetContractAddress("uniswap_v2_factory", chain.V2Factory))
+	// SushiSwap's factory has no pairs, so the venue is skipped
+	require.NoError(t, cm.SetContractAddress("sushiswap_factory", chain.Deploy(defitest.V2FactoryCode())))
+
+	pair := chain.AddV2Pair(chain.WETH, chain.USDC)
+	chain.Mint(chain.WETH, pair, defitest.Ether(1_020))
+	chain.Mint(chain.USDC, pair, defitest.Ether(1_000))
+	chain.SetV3Liquidity(chain.V3Pool, defitest.Ether(1_000))
+
+	source := NewDEXPoolSource(cm, "WETH/USDC")
+	pools, err := source.LoadPools(context.Background())
+	require.NoError(t, err)
+
+	token0, _ := sortTokens(chain.WETH, chain.USDC)
+	symbol0, symbol1 := "WETH", "USDC"
+	if token0 != chain.WETH {
+		symbol0, symbol1 = "USDC", "WETH"
+	}
+	byVenue := make(map[string]VenuePool)
+	for _, vp := range pools {
+		assert.Equal(t, symbol0, vp.Token0)
+		assert.Equal(t, symbol1, vp.Token1)
+		byVenue[vp.Venue] = vp
+	}
+	require.Len(t, byVenue, 3)
+
+	v2, ok := byVenue["uniswap_v2"].Pool.(*poolmath.V2Pool)
+	require.True(t, ok)
+	reserve0, reserve1 := defitest.Ether(1_020), defitest.Ether(1_000)
+	if symbol0 != "WETH" {
+		reserve0, reserve1 = reserve1, reserve0
+	}
+	assert.Equal(t, reserve0.String(), v2.Reserve0.String())
+	assert.Equal(t, reserve1.String(), v2.Reserve1.String())
+
+	v3, ok := byVenue["uniswap_v3_3000"].Pool.(*poolmath.V3Pool)
+	require.True(t, ok)
+	assert.Equal(t, defitest.Ether(1_000).String(), v3.Liquidity.String())
+	assert.InDelta(t, 1, v3.SpotPrice(), 1e-9)
+
+	curve, ok := byVenue["curve"].Pool.(*poolmath.CurvePair)
+	require.True(t, ok)
+	require.Len(t, curve.Pool.Balances, 2)
+	assert.Equal(t, defitest.Liquidity.String(), curve.Pool.Balances[0].String())
+	assert.Equal(t, int64(defitest.CurveFee), curve.Pool.Fee.Int64())
+	assert.InDelta(t, 1, curve.SpotPrice(), 1e-6)
+
+	// WETH is cheaper on Uniswap V2 than on the other venues
+	scanner := NewArbitrageScanner()
+	scanner.SetToken("WETH", 18, 1)
+	scanner.SetToken("USDC", 18, 1)
+	scanner.Sources = []PoolSource{source}
+	require.NoError(t, scanner.Refresh(context.Background()))
+	spread, ok := scanner.PriceSpread("WETH/USDC")
+	require.True(t, ok)
+	assert.InDelta(t, 0.02, spread, 0.005)
+	opportunities := scanner.Scan()
+	require.NotEmpty(t, opportunities)
+	assert.Contains(t, opportunities[0].Venues, "uniswap_v2:"+symbol0+"/"+symbol1)
+
+	// A cancelled refresh reads no pools
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pools, err = source.LoadPools(ctx)
+	assert.Empty(t, pools)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Failures other than a missing pool are reported
+	source.Pairs = []string{"WETH/NOPE"}
+	_, err = source.LoadPools(context.Background())
+	assert.ErrorContains(t, err, "unregistered tokens")
+}
@@ -0,0 +1,154 @@
+package defi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+)
+
+// usdc converts whole USDC to 6-decimal base units
+func usdc(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1_000_000))
+}
+
+// newTwoVenueScanner prices WETH at 2000 USDC on Uniswap and 2040 on Sushi
+func newTwoVenueScanner(t *testing.T) *ArbitrageScanner {
+	scanner := NewArbitrageScanner()
+	scanner.SetToken("WETH", 18, 2000)
+	scanner.SetToken("USDC", 6, 1)
+	require.NoError(t, scanner.SetPool(VenuePool{
+		Venue: "uniswap_v2", Token0: "WETH", Token1: "USDC",
+		Pool: poolmath.NewV2Pool(defitest.Ether(1_000), usdc(2_000_000)),
+	}))
+	require.NoError(t, scanner.SetPool(VenuePool{
+		Venue: "sushiswap", Token0: "WETH", Token1: "USDC",
+		Pool: poolmath.NewV2Pool(defitest.Ether(1_000), usdc(2_040_000)),
+	}))
+	return scanner
+}
+
+func TestArbitrageTwoLegCycle(t *testing.T) {
+	scanner := newTwoVenueScanner(t)
+	spread, ok := scanner.PriceSpread("WETH/USDC")
+	require.True(t, ok)
+	assert.InDelta(t, 0.02, spread, 1e-9)
+	_, ok = scanner.PriceSpread("WETH/DAI")
+	assert.False(t, ok)
+
+	opportunities := scanner.Scan()
+	require.Len(t, opportunities, 1, "rotations of the cycle are reported once")
+	best := opportunities[0]
+	assert.Equal(t, 2, best.Legs())
+	assert.ElementsMatch(t, []string{"uniswap_v2:WETH/USDC", "sushiswap:WETH/USDC"}, best.Venues)
+	assert.Contains(t, best.String(), "-(sushiswap:WETH/USDC)->")
+	assert.Equal(t, best.Path[0], best.Path[len(best.Path)-1])
+
+	// Replaying the legs reproduces the reported output
+	amount := best.AmountIn
+	pools := map[string]*poolmath.V2Pool{
+		"uniswap_v2:WETH/USDC": poolmath.NewV2Pool(defitest.Ether(1_000), usdc(2_000_000)),
+		"sushiswap:WETH/USDC":  poolmath.NewV2Pool(defitest.Ether(1_000), usdc(2_040_000)),
+	}
+	for i, venue := range best.Venues {
+		result, err := pools[venue].Swap(amount, best.Path[i] == "WETH")
+		require.NoError(t, err)
+		amount = result.AmountOut
+	}
+	assert.Equal(t, best.AmountOut.String(), amount.String())
+
+	gross := new(big.Int).Sub(best.AmountOut, best.AmountIn)
+	assert.Equal(t, new(big.Int).Sub(gross, best.GasCost).String(), best.Profit.String())
+	assert.Equal(t, uint64(gasPerTx+2*GasPerV2Swap), best.GasUnits)
+	assert.Equal(t, ApplySlippage(best.AmountOut, 0.005).String(), best.MinAmountOut.String())
+	assert.Positive(t, best.ProfitUSD)
+
+	// Trading 10% more or less than the optimum earns less
+	c := cycle{tokens: best.Path[:2], pools: []*VenuePool{scanner.pools[best.Venues[0]], scanner.pools[best.Venues[1]]}}
+	for _, scale := range []int64{90, 110} {
+		amountIn := new(big.Int).Mul(best.AmountIn, big.NewInt(scale))
+		amountIn.Quo(amountIn, big.NewInt(100))
+		assert.Less(t, c.grossProfit(amountIn).Cmp(gross), 0, "%d%% of the optimal input", scale)
+	}
+}
+
+func TestArbitrageTriangularCycle(t *testing.T) {
+	scanner := NewArbitrageScanner()
+	scanner.SetToken("WETH", 18, 2000)
+	scanner.SetToken("USDC", 6, 1)
+	scanner.SetToken("DAI", 18, 1)
+
+	curve, err := poolmath.NewCurvePool([]*big.Int{usdc(10_000_000), defitest.Ether(10_000_000)}, []int{6, 18}, 200, 4_000_000, 5_000_000_000)
+	require.NoError(t, err)
+	for _, vp := range []VenuePool{
+		{Venue: "uniswap_v2", Token0: "WETH", Token1: "USDC", Pool: poolmath.NewV2Pool(defitest.Ether(1_000), usdc(2_000_000))},
+		{Venue: "sushiswap", Token0: "WETH", Token1: "DAI", Pool: poolmath.NewV2Pool(defitest.Ether(1_000), defitest.Ether(2_050_000))},
+		{Venue: "curve", Token0: "USDC", Token1: "DAI", Pool: curve},
+	} {
+		require.NoError(t, scanner.SetPool(vp))
+	}
+
+	var received []ArbitrageOpportunity
+	engine := NewStrategyEngine()
+	scanner.Sinks = []ArbitrageSink{engine, ArbitrageSinkFunc(func(opportunities []ArbitrageOpportunity) {
+		received = opportunities
+	})}
+
+	opportunities := scanner.Scan()
+	require.Len(t, opportunities, 1)
+	best := opportunities[0]
+	assert.Equal(t, 3, best.Legs())
+	assert.ElementsMatch(t, []string{"uniswap_v2:WETH/USDC", "sushiswap:WETH/DAI", "curve:USDC/DAI"}, best.Venues)
+	assert.Equal(t, uint64(gasPerTx+2*GasPerV2Swap+GasPerCurveSwap), best.GasUnits)
+
+	// WETH is sold where it is dearest, for DAI
+	for i, venue := range best.Venues {
+		if best.Path[i] == "WETH" {
+			assert.Equal(t, "sushiswap:WETH/DAI", venue)
+		}
+	}
+
+	assert.Equal(t, opportunities, received)
+	assert.Equal(t, best.ProfitUSD, engine.getCurrentMetricValue("arbitrage_profit", nil))
+	assert.Zero(t, engine.getCurrentMetricValue("arbitrage_profit", map[string]interface{}{"token": "BTC"}))
+}
+
+func TestArbitrageGasCost(t *testing.T) {
+	scanner := newTwoVenueScanner(t)
+	scanner.GasPrice = big.NewInt(0)
+	free := scanner.Scan()
+	require.Len(t, free, 1)
+	assert.Zero(t, free[0].GasCost.Sign())
+
+	// 10 gwei on 241000 gas is 0.00241 WETH, or 4.82 USDC
+	scanner.GasPrice = big.NewInt(10_000_000_000)
+	gas, ok := scanner.gasCost(241_000, "WETH", scanner.tokens)
+	require.True(t, ok)
+	assert.Equal(t, "2410000000000000", gas.String())
+	gas, ok = scanner.gasCost(241_000, "USDC", scanner.tokens)
+	require.True(t, ok)
+	assert.Equal(t, "4820000", gas.String())
+
+	priced := scanner.Scan()
+	require.Len(t, priced, 1)
+	assert.InDelta(t, free[0].ProfitUSD-4.82, priced[0].ProfitUSD, 0.01)
+
+	// Gas above the gross profit leaves nothing worth trading
+	scanner.GasPrice = new(big.Int).Mul(big.NewInt(1_000_000), big.NewInt(1_000_000_000_000))
+	assert.Empty(t, scanner.Scan())
+
+	scanner.GasPrice = big.NewInt(10_000_000_000)
+	scanner.MinProfitUSD = free[0].ProfitUSD
+	assert.Empty(t, scanner.Scan())
+}
+
+func TestArbitrageScannerRejectsInvalidPools(t *testing.T) {
+	scanner := NewArbitrageScanner()
+	assert.Error(t, scanner.SetPool(VenuePool{Venue: "uniswap_v2", Token0: "WETH", Token1: "USDC"}))
+	assert.Error(t, scanner.SetPool(VenuePool{Venue: "uniswap_v2", Token0: "WETH", Token1: "WETH", Pool: poolmath.NewV2Pool(big.NewInt(1), big.NewInt(1))}))
+	assert.Empty(t, scanner.Scan())
+}
@@ -0,0 +1,22 @@
+package defi
+
+import (
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Backend is the chain access used by the contract and blockchain managers.
+// It is satisfied by *ethclient.Client for live networks and by
+// go-ethereum's simulated.Client for offline tests.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ethereum.ChainIDReader
+	ethereum.BlockNumberReader
+	ethereum.ChainStateReader
+	ethereum.TransactionReader
+	ethereum.FeeHistoryReader
+}
+
+var _ Backend = (*ethclient.Client)(nil)
@@ -0,0 +1,274 @@
+package defi
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+var _ Backend = simulated.Client(nil)
+
+// newSimulatedContractManager returns a contract manager for the chain's
+// account with the router, pools, executor, Permit2 and tokens pointed at
+// the mock deployments
+func newSimulatedContractManager(t *testing.T, chain *defitest.Chain) *ContractManager {
+	t.Helper()
+
+	useSimulatedRegistry(t, chain)
+	cm, err := NewContractManager(chain.Client, chain.PrivateKeyHex())
+	require.NoError(t, err)
+	t.Cleanup(func() { ReleaseNonceManagers(chain.Client) })
+
+	require.NoError(t, cm.SetContractAddress("uniswap_v3_router", chain.Router))
+	require.NoError(t, cm.SetContractAddress("uniswap_v3_quoter", chain.Quoter))
+	require.NoError(t, cm.SetContractAddress("aave_lending_pool", chain.Pool))
+	require.NoError(t, cm.SetContractAddress("aave_v3_pool", chain.Pool))
+	require.NoError(t, cm.SetBundleExecutor(chain.Executor))
+	require.NoError(t, cm.SetContractAddress("permit2", chain.Permit2))
+	require.NoError(t, cm.SetContractAddress("aave_oracle", chain.Oracle))
+	require.NoError(t, cm.SetContractAddress("compound_v3_usdc", chain.Comet))
+	require.NoError(t, cm.SetContractAddress("curve_registry", chain.CurveRegistry))
+	require.NoError(t, cm.SetContractAddress("uniswap_v3_factory", chain.V3Factory))
+	require.NoError(t, cm.SetContractAddress("uniswap_v3_position_manager", chain.PositionManager))
+	require.NoError(t, cm.SetContractAddress("erc20_WETH", chain.WETH))
+	require.NoError(t, cm.SetContractAddress("erc20_USDC", chain.USDC))
+	return cm
+}
+
+// useSimulatedRegistry points CONTRACT_REGISTRY_DIR at a copy of the
+// embedded registry that also lists the simulated chain, under the mainnet
+// contract names, so managers on the chain start with them registered
+func useSimulatedRegistry(t *testing.T, chain *defitest.Chain) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, registryFiles))
+
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+	mainnet, err := registry.Deployment(MainnetChainID)
+	require.NoError(t, err)
+	simulated := *mainnet
+	simulated.ChainID = chain.ChainID.Uint64()
+	simulated.Name = "Simulated"
+
+	data, err := yaml.Marshal(simulated)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "chains", "simulated.yaml"), data, 0o644))
+	t.Setenv("CONTRACT_REGISTRY_DIR", dir)
+}
+
+// mine commits the pending block and returns the transaction's receipt
+func mine(t *testing.T, chain *defitest.Chain, cm *ContractManager, tx *types.Transaction) *types.Receipt {
+	t.Helper()
+
+	chain.Commit()
+	receipt, err := cm.WaitForTransaction(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	return receipt
+}
+
+func TestUniswapV3SwapOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	uv3 := NewUniswapV3Manager(cm)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14)) // 1 USDC = 0.0005 WETH
+
+	amountIn := defitest.Ether(100)
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+
+	// 0.05 WETH less the 0.3% pool fee
+	expectedOut := big.NewInt(49_850_000_000_000_000)
+	params := SwapParams{
+		TokenIn:          chain.USDC,
+		TokenOut:         chain.WETH,
+		Fee:              FeeTierMedium,
+		Recipient:        chain.Account,
+		Deadline:         CreateDeadline(10),
+		AmountIn:         amountIn,
+		AmountOutMinimum: new(big.Int).Add(expectedOut, big.NewInt(1)),
+	}
+
+	// Slippage protection rejects the swap before it is sent
+	_, err = uv3.ExecuteSwap(params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Too little received")
+
+	params.AmountOutMinimum = expectedOut
+	tx, err = uv3.ExecuteSwap(params)
+	require.NoError(t, err)
+
+	chain.Commit()
+	receipt, err := uv3.MonitorSwap(tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	wethBalance, err := cm.GetTokenBalance(chain.WETH, chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, expectedOut.String(), wethBalance.String())
+
+	usdcBalance, err := cm.GetTokenBalance(chain.USDC, chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(900).String(), usdcBalance.String())
+
+	// The allowance was used up
+	_, err = uv3.ExecuteSwap(params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STF")
+}
+
+func TestAaveLendingOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	aave := NewAaveManager(cm)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Pool, defitest.Ether(400))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+
+	tx, err = aave.ExecuteDeposit(DepositParams{
+		Asset:        chain.USDC,
+		Amount:       defitest.Ether(400),
+		OnBehalfOf:   chain.Account,
+		ReferralCode: AaveReferralCode,
+	})
+	require.NoError(t, err)
+	chain.Commit()
+	receipt, err := aave.MonitorTransaction(tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	account := chain.PoolAccount(chain.Account)
+	assert.Equal(t, defitest.Ether(400).String(), account.Collateral.String())
+	assert.Equal(t, defitest.Ether(300).String(), account.AvailableBorrows.String())
+
+	// Borrowing past the 75% LTV is rejected during gas estimation
+	_, err = aave.ExecuteBorrow(BorrowParams{
+		Asset:            chain.WETH,
+		Amount:           defitest.Ether(301),
+		InterestRateMode: VariableRate,
+		OnBehalfOf:       chain.Account,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "collateral cannot cover borrow")
+
+	tx, err = aave.ExecuteBorrow(BorrowParams{
+		Asset:            chain.WETH,
+		Amount:           defitest.Ether(200),
+		InterestRateMode: VariableRate,
+		OnBehalfOf:       chain.Account,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+
+	account = chain.PoolAccount(chain.Account)
+	assert.Equal(t, defitest.Ether(200).String(), account.Debt.String())
+	assert.Equal(t, "1600000000000000000", account.HealthFactor.String())
+	assert.Equal(t, defitest.Ether(200).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+
+	tx, err = aave.ExecuteWithdraw(WithdrawParams{
+		Asset:  chain.USDC,
+		Amount: defitest.Ether(100),
+		To:     chain.Account,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	assert.Equal(t, defitest.Ether(700).String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+
+	// Withdrawing the rest would leave the debt uncovered
+	_, err = aave.ExecuteWithdraw(WithdrawParams{
+		Asset:  chain.USDC,
+		Amount: defitest.Ether(300),
+		To:     chain.Account,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "health factor too low")
+}
+
+func TestContractManagerRefusesUnlistedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	t.Setenv("CONTRACT_REGISTRY_DIR", "")
+
+	_, err := NewContractManager(chain.Client, chain.PrivateKeyHex())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no deployment for chain "+chain.ChainID.String())
+}
+
+func TestRealBlockchainManagerOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(50))
+
+	useSimulatedRegistry(t, chain)
+	bm, err := NewRealBlockchainManagerWithBackend(chain.Client, chain.PrivateKeyHex())
+	require.NoError(t, err)
+	assert.Equal(t, chain.Account, bm.Address)
+	assert.Equal(t, chain.ChainID.String(), bm.ChainID.String())
+
+	balance, err := bm.GetBalance()
+	require.NoError(t, err)
+	assert.Positive(t, balance.Sign())
+
+	tokenBalance, err := bm.GetTokenBalance(chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(50).String(), tokenBalance.String())
+
+	erc20, err := abi.JSON(strings.NewReader(defitest.ERC20ABI))
+	require.NoError(t, err)
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	data, err := erc20.Pack("transfer", recipient, defitest.Ether(20))
+	require.NoError(t, err)
+
+	tx, err := bm.SendTransaction(chain.USDC, big.NewInt(0), data)
+	require.NoError(t, err)
+	chain.Commit()
+	receipt, err := bm.WaitForTransaction(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash(), receipt.TxHash)
+	assert.Equal(t, defitest.Ether(20).String(), chain.BalanceOf(chain.USDC, recipient).String())
+
+	// Plain ether transfer
+	tx, err = bm.SendTransaction(recipient, defitest.Ether(1), nil)
+	require.NoError(t, err)
+	chain.Commit()
+	_, err = bm.WaitForTransaction(context.Background(), tx.Hash())
+	require.NoError(t, err)
+
+	recipientBalance, err := chain.Client.BalanceAt(context.Background(), recipient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(1).String(), recipientBalance.String())
+
+	// Transfers over the remaining balance fail the pre-flight simulation,
+	// with the decoded reason, and are never sent
+	nonce, err := chain.Client.PendingNonceAt(context.Background(), chain.Account)
+	require.NoError(t, err)
+	data, err = erc20.Pack("transfer", recipient, defitest.Ether(31))
+	require.NoError(t, err)
+	_, err = bm.SendTransaction(chain.USDC, big.NewInt(0), data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ERC20: insufficient balance")
+	var defiErr *DeFiError
+	require.ErrorAs(t, err, &defiErr)
+	assert.Equal(t, ErrSimulation, defiErr.Type)
+	assert.Equal(t, "ERC20: insufficient balance", defiErr.Details["reason"])
+	after, err := chain.Client.PendingNonceAt(context.Background(), chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, nonce, after)
+}
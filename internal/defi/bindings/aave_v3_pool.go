@@ -0,0 +1,598 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package bindings
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// DataTypesReserveConfigurationMap is an auto generated low-level Go binding around an user-defined struct.
+type DataTypesReserveConfigurationMap struct {
+	Data *big.Int
+}
+
+// DataTypesReserveData is an auto generated low-level Go binding around an user-defined struct.
+type DataTypesReserveData struct {
+	Configuration               DataTypesReserveConfigurationMap
+	LiquidityIndex              *big.Int
+	CurrentLiquidityRate        *big.Int
+	VariableBorrowIndex         *big.Int
+	CurrentVariableBorrowRate   *big.Int
+	CurrentStableBorrowRate     *big.Int
+	LastUpdateTimestamp         *big.Int
+	Id                          uint16
+	ATokenAddress               common.Address
+	StableDebtTokenAddress      common.Address
+	VariableDebtTokenAddress    common.Address
+	InterestRateStrategyAddress common.Address
+	AccruedToTreasury           *big.Int
+	Unbacked                    *big.Int
+	IsolationModeTotalDebt      *big.Int
+}
+
+// DataTypesUserConfigurationMap is an auto generated low-level Go binding around an user-defined struct.
+type DataTypesUserConfigurationMap struct {
+	Data *big.Int
+}
+
+// AaveV3PoolMetaData contains all meta data concerning the AaveV3Pool contract.
+var AaveV3PoolMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"onBehalfOf\",\"type\":\"address\"},{\"internalType\":\"uint16\",\"name\":\"referralCode\",\"type\":\"uint16\"}],\"name\":\"supply\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"}],\"name\":\"withdraw\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"interestRateMode\",\"type\":\"uint256\"},{\"internalType\":\"uint16\",\"name\":\"referralCode\",\"type\":\"uint16\"},{\"internalType\":\"address\",\"name\":\"onBehalfOf\",\"type\":\"address\"}],\"name\":\"borrow\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"interestRateMode\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"onBehalfOf\",\"type\":\"address\"}],\"name\":\"repay\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"useAsCollateral\",\"type\":\"bool\"}],\"name\":\"setUserUseReserveAsCollateral\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint8\",\"name\":\"categoryId\",\"type\":\"uint8\"}],\"name\":\"setUserEMode\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"}],\"name\":\"getUserEMode\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"interestRateMode\",\"type\":\"uint256\"}],\"name\":\"swapBorrowRateMode\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"}],\"name\":\"getUserAccountData\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"totalCollateralBase\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"totalDebtBase\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"availableBorrowsBase\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"currentLiquidationThreshold\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"ltv\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"healthFactor\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"user\",\"type\":\"address\"}],\"name\":\"getUserConfiguration\",\"outputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"data\",\"type\":\"uint256\"}],\"internalType\":\"structDataTypes.UserConfigurationMap\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"}],\"name\":\"getReserveData\",\"outputs\":[{\"components\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"data\",\"type\":\"uint256\"}],\"internalType\":\"structDataTypes.ReserveConfigurationMap\",\"name\":\"configuration\",\"type\":\"tuple\"},{\"internalType\":\"uint128\",\"name\":\"liquidityIndex\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"currentLiquidityRate\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"variableBorrowIndex\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"currentVariableBorrowRate\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"currentStableBorrowRate\",\"type\":\"uint128\"},{\"internalType\":\"uint40\",\"name\":\"lastUpdateTimestamp\",\"type\":\"uint40\"},{\"internalType\":\"uint16\",\"name\":\"id\",\"type\":\"uint16\"},{\"internalType\":\"address\",\"name\":\"aTokenAddress\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"stableDebtTokenAddress\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"variableDebtTokenAddress\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"interestRateStrategyAddress\",\"type\":\"address\"},{\"internalType\":\"uint128\",\"name\":\"accruedToTreasury\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"unbacked\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"isolationModeTotalDebt\",\"type\":\"uint128\"}],\"internalType\":\"structDataTypes.ReserveData\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getReservesList\",\"outputs\":[{\"internalType\":\"address[]\",\"name\":\"\",\"type\":\"address[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"receiverAddress\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"bytes\",\"name\":\"params\",\"type\":\"bytes\"},{\"internalType\":\"uint16\",\"name\":\"referralCode\",\"type\":\"uint16\"}],\"name\":\"flashLoanSimple\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"FLASHLOAN_PREMIUM_TOTAL\",\"outputs\":[{\"internalType\":\"uint128\",\"name\":\"\",\"type\":\"uint128\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// AaveV3PoolABI is the input ABI used to generate the binding from.
+// Deprecated: Use AaveV3PoolMetaData.ABI instead.
+var AaveV3PoolABI = AaveV3PoolMetaData.ABI
+
+// AaveV3Pool is an auto generated Go binding around an Ethereum contract.
+type AaveV3Pool struct {
+	AaveV3PoolCaller     // Read-only binding to the contract
+	AaveV3PoolTransactor // Write-only binding to the contract
+	AaveV3PoolFilterer   // Log filterer for contract events
+}
+
+// AaveV3PoolCaller is an auto generated read-only Go binding around an Ethereum contract.
+type AaveV3PoolCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// AaveV3PoolTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type AaveV3PoolTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// AaveV3PoolFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type AaveV3PoolFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// AaveV3PoolSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type AaveV3PoolSession struct {
+	Contract     *AaveV3Pool       // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// AaveV3PoolCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type AaveV3PoolCallerSession struct {
+	Contract *AaveV3PoolCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts     // Call options to use throughout this session
+}
+
+// AaveV3PoolTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type AaveV3PoolTransactorSession struct {
+	Contract     *AaveV3PoolTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts     // Transaction auth options to use throughout this session
+}
+
+// AaveV3PoolRaw is an auto generated low-level Go binding around an Ethereum contract.
+type AaveV3PoolRaw struct {
+	Contract *AaveV3Pool // Generic contract binding to access the raw methods on
+}
+
+// AaveV3PoolCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type AaveV3PoolCallerRaw struct {
+	Contract *AaveV3PoolCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// AaveV3PoolTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type AaveV3PoolTransactorRaw struct {
+	Contract *AaveV3PoolTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewAaveV3Pool creates a new instance of AaveV3Pool, bound to a specific deployed contract.
+func NewAaveV3Pool(address common.Address, backend bind.ContractBackend) (*AaveV3Pool, error) {
+	contract, err := bindAaveV3Pool(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &AaveV3Pool{AaveV3PoolCaller: AaveV3PoolCaller{contract: contract}, AaveV3PoolTransactor: AaveV3PoolTransactor{contract: contract}, AaveV3PoolFilterer: AaveV3PoolFilterer{contract: contract}}, nil
+}
+
+// NewAaveV3PoolCaller creates a new read-only instance of AaveV3Pool, bound to a specific deployed contract.
+func NewAaveV3PoolCaller(address common.Address, caller bind.ContractCaller) (*AaveV3PoolCaller, error) {
+	contract, err := bindAaveV3Pool(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &AaveV3PoolCaller{contract: contract}, nil
+}
+
+// NewAaveV3PoolTransactor creates a new write-only instance of AaveV3Pool, bound to a specific deployed contract.
+func NewAaveV3PoolTransactor(address common.Address, transactor bind.ContractTransactor) (*AaveV3PoolTransactor, error) {
+	contract, err := bindAaveV3Pool(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &AaveV3PoolTransactor{contract: contract}, nil
+}
+
+// NewAaveV3PoolFilterer creates a new log filterer instance of AaveV3Pool, bound to a specific deployed contract.
+func NewAaveV3PoolFilterer(address common.Address, filterer bind.ContractFilterer) (*AaveV3PoolFilterer, error) {
+	contract, err := bindAaveV3Pool(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &AaveV3PoolFilterer{contract: contract}, nil
+}
+
+// bindAaveV3Pool binds a generic wrapper to an already deployed contract.
+func bindAaveV3Pool(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := AaveV3PoolMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_AaveV3Pool *AaveV3PoolRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _AaveV3Pool.Contract.AaveV3PoolCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_AaveV3Pool *AaveV3PoolRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.AaveV3PoolTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_AaveV3Pool *AaveV3PoolRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.AaveV3PoolTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_AaveV3Pool *AaveV3PoolCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _AaveV3Pool.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_AaveV3Pool *AaveV3PoolTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_AaveV3Pool *AaveV3PoolTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.contract.Transact(opts, method, params...)
+}
+
+// FLASHLOANPREMIUMTOTAL is a free data retrieval call binding the contract method 0x074b2e43.
+//
+// Solidity: function FLASHLOAN_PREMIUM_TOTAL() view returns(uint128)
+func (_AaveV3Pool *AaveV3PoolCaller) FLASHLOANPREMIUMTOTAL(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _AaveV3Pool.contract.Call(opts, &out, "FLASHLOAN_PREMIUM_TOTAL")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// FLASHLOANPREMIUMTOTAL is a free data retrieval call binding the contract method 0x074b2e43.
+//
+// Solidity: function FLASHLOAN_PREMIUM_TOTAL() view returns(uint128)
+func (_AaveV3Pool *AaveV3PoolSession) FLASHLOANPREMIUMTOTAL() (*big.Int, error) {
+	return _AaveV3Pool.Contract.FLASHLOANPREMIUMTOTAL(&_AaveV3Pool.CallOpts)
+}
+
+// FLASHLOANPREMIUMTOTAL is a free data retrieval call binding the contract method 0x074b2e43.
+//
+// Solidity: function FLASHLOAN_PREMIUM_TOTAL() view returns(uint128)
+func (_AaveV3Pool *AaveV3PoolCallerSession) FLASHLOANPREMIUMTOTAL() (*big.Int, error) {
+	return _AaveV3Pool.Contract.FLASHLOANPREMIUMTOTAL(&_AaveV3Pool.CallOpts)
+}
+
+// GetReserveData is a free data retrieval call binding the contract method 0x35ea6a75.
+//
+// Solidity: function getReserveData(address asset) view returns(((uint256),uint128,uint128,uint128,uint128,uint128,uint40,uint16,address,address,address,address,uint128,uint128,uint128))
+func (_AaveV3Pool *AaveV3PoolCaller) GetReserveData(opts *bind.CallOpts, asset common.Address) (DataTypesReserveData, error) {
+	var out []interface{}
+	err := _AaveV3Pool.contract.Call(opts, &out, "getReserveData", asset)
+
+	if err != nil {
+		return *new(DataTypesReserveData), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(DataTypesReserveData)).(*DataTypesReserveData)
+
+	return out0, err
+
+}
+
+// GetReserveData is a free data retrieval call binding the contract method 0x35ea6a75.
+//
+// Solidity: function getReserveData(address asset) view returns(((uint256),uint128,uint128,uint128,uint128,uint128,uint40,uint16,address,address,address,address,uint128,uint128,uint128))
+func (_AaveV3Pool *AaveV3PoolSession) GetReserveData(asset common.Address) (DataTypesReserveData, error) {
+	return _AaveV3Pool.Contract.GetReserveData(&_AaveV3Pool.CallOpts, asset)
+}
+
+// GetReserveData is a free data retrieval call binding the contract method 0x35ea6a75.
+//
+// Solidity: function getReserveData(address asset) view returns(((uint256),uint128,uint128,uint128,uint128,uint128,uint40,uint16,address,address,address,address,uint128,uint128,uint128))
+func (_AaveV3Pool *AaveV3PoolCallerSession) GetReserveData(asset common.Address) (DataTypesReserveData, error) {
+	return _AaveV3Pool.Contract.GetReserveData(&_AaveV3Pool.CallOpts, asset)
+}
+
+// GetReservesList is a free data retrieval call binding the contract method 0xd1946dbc.
+//
+// Solidity: function getReservesList() view returns(address[])
+func (_AaveV3Pool *AaveV3PoolCaller) GetReservesList(opts *bind.CallOpts) ([]common.Address, error) {
+	var out []interface{}
+	err := _AaveV3Pool.contract.Call(opts, &out, "getReservesList")
+
+	if err != nil {
+		return *new([]common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([]common.Address)).(*[]common.Address)
+
+	return out0, err
+
+}
+
+// GetReservesList is a free data retrieval call binding the contract method 0xd1946dbc.
+//
+// Solidity: function getReservesList() view returns(address[])
+func (_AaveV3Pool *AaveV3PoolSession) GetReservesList() ([]common.Address, error) {
+	return _AaveV3Pool.Contract.GetReservesList(&_AaveV3Pool.CallOpts)
+}
+
+// GetReservesList is a free data retrieval call binding the contract method 0xd1946dbc.
+//
+// Solidity: function getReservesList() view returns(address[])
+func (_AaveV3Pool *AaveV3PoolCallerSession) GetReservesList() ([]common.Address, error) {
+	return _AaveV3Pool.Contract.GetReservesList(&_AaveV3Pool.CallOpts)
+}
+
+// GetUserAccountData is a free data retrieval call binding the contract method 0xbf92857c.
+//
+// Solidity: function getUserAccountData(address user) view returns(uint256 totalCollateralBase, uint256 totalDebtBase, uint256 availableBorrowsBase, uint256 currentLiquidationThreshold, uint256 ltv, uint256 healthFactor)
+func (_AaveV3Pool *AaveV3PoolCaller) GetUserAccountData(opts *bind.CallOpts, user common.Address) (struct {
+	TotalCollateralBase         *big.Int
+	TotalDebtBase               *big.Int
+	AvailableBorrowsBase        *big.Int
+	CurrentLiquidationThreshold *big.Int
+	Ltv                         *big.Int
+	HealthFactor                *big.Int
+}, error) {
+	var out []interface{}
+	err := _AaveV3Pool.contract.Call(opts, &out, "getUserAccountData", user)
+
+	outstruct := new(struct {
+		TotalCollateralBase         *big.Int
+		TotalDebtBase               *big.Int
+		AvailableBorrowsBase        *big.Int
+		CurrentLiquidationThreshold *big.Int
+		Ltv                         *big.Int
+		HealthFactor                *big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.TotalCollateralBase = *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+	outstruct.TotalDebtBase = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	outstruct.AvailableBorrowsBase = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	outstruct.CurrentLiquidationThreshold = *abi.ConvertType(out[3], new(*big.Int)).(**big.Int)
+	outstruct.Ltv = *abi.ConvertType(out[4], new(*big.Int)).(**big.Int)
+	outstruct.HealthFactor = *abi.ConvertType(out[5], new(*big.Int)).(**big.Int)
+
+	return *outstruct, err
+
+}
+
+// GetUserAccountData is a free data retrieval call binding the contract method 0xbf92857c.
+//
+// Solidity: function getUserAccountData(address user) view returns(uint256 totalCollateralBase, uint256 totalDebtBase, uint256 availableBorrowsBase, uint256 currentLiquidationThreshold, uint256 ltv, uint256 healthFactor)
+func (_AaveV3Pool *AaveV3PoolSession) GetUserAccountData(user common.Address) (struct {
+	TotalCollateralBase         *big.Int
+	TotalDebtBase               *big.Int
+	AvailableBorrowsBase        *big.Int
+	CurrentLiquidationThreshold *big.Int
+	Ltv                         *big.Int
+	HealthFactor                *big.Int
+}, error) {
+	return _AaveV3Pool.Contract.GetUserAccountData(&_AaveV3Pool.CallOpts, user)
+}
+
+// GetUserAccountData is a free data retrieval call binding the contract method 0xbf92857c.
+//
+// Solidity: function getUserAccountData(address user) view returns(uint256 totalCollateralBase, uint256 totalDebtBase, uint256 availableBorrowsBase, uint256 currentLiquidationThreshold, uint256 ltv, uint256 healthFactor)
+func (_AaveV3Pool *AaveV3PoolCallerSession) GetUserAccountData(user common.Address) (struct {
+	TotalCollateralBase         *big.Int
+	TotalDebtBase               *big.Int
+	AvailableBorrowsBase        *big.Int
+	CurrentLiquidationThreshold *big.Int
+	Ltv                         *big.Int
+	HealthFactor                *big.Int
+}, error) {
+	return _AaveV3Pool.Contract.GetUserAccountData(&_AaveV3Pool.CallOpts, user)
+}
+
+// GetUserConfiguration is a free data retrieval call binding the contract method 0x4417a583.
+//
+// Solidity: function getUserConfiguration(address user) view returns((uint256))
+func (_AaveV3Pool *AaveV3PoolCaller) GetUserConfiguration(opts *bind.CallOpts, user common.Address) (DataTypesUserConfigurationMap, error) {
+	var out []interface{}
+	err := _AaveV3Pool.contract.Call(opts, &out, "getUserConfiguration", user)
+
+	if err != nil {
+		return *new(DataTypesUserConfigurationMap), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(DataTypesUserConfigurationMap)).(*DataTypesUserConfigurationMap)
+
+	return out0, err
+
+}
+
+// GetUserConfiguration is a free data retrieval call binding the contract method 0x4417a583.
+//
+// Solidity: function getUserConfiguration(address user) view returns((uint256))
+func (_AaveV3Pool *AaveV3PoolSession) GetUserConfiguration(user common.Address) (DataTypesUserConfigurationMap, error) {
+	return _AaveV3Pool.Contract.GetUserConfiguration(&_AaveV3Pool.CallOpts, user)
+}
+
+// GetUserConfiguration is a free data retrieval call binding the contract method 0x4417a583.
+//
+// Solidity: function getUserConfiguration(address user) view returns((uint256))
+func (_AaveV3Pool *AaveV3PoolCallerSession) GetUserConfiguration(user common.Address) (DataTypesUserConfigurationMap, error) {
+	return _AaveV3Pool.Contract.GetUserConfiguration(&_AaveV3Pool.CallOpts, user)
+}
+
+// GetUserEMode is a free data retrieval call binding the contract method 0xeddf1b79.
+//
+// Solidity: function getUserEMode(address user) view returns(uint256)
+func (_AaveV3Pool *AaveV3PoolCaller) GetUserEMode(opts *bind.CallOpts, user common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _AaveV3Pool.contract.Call(opts, &out, "getUserEMode", user)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetUserEMode is a free data retrieval call binding the contract method 0xeddf1b79.
+//
+// Solidity: function getUserEMode(address user) view returns(uint256)
+func (_AaveV3Pool *AaveV3PoolSession) GetUserEMode(user common.Address) (*big.Int, error) {
+	return _AaveV3Pool.Contract.GetUserEMode(&_AaveV3Pool.CallOpts, user)
+}
+
+// GetUserEMode is a free data retrieval call binding the contract method 0xeddf1b79.
+//
+// Solidity: function getUserEMode(address user) view returns(uint256)
+func (_AaveV3Pool *AaveV3PoolCallerSession) GetUserEMode(user common.Address) (*big.Int, error) {
+	return _AaveV3Pool.Contract.GetUserEMode(&_AaveV3Pool.CallOpts, user)
+}
+
+// Borrow is a paid mutator transaction binding the contract method 0xa415bcad.
+//
+// Solidity: function borrow(address asset, uint256 amount, uint256 interestRateMode, uint16 referralCode, address onBehalfOf) returns()
+func (_AaveV3Pool *AaveV3PoolTransactor) Borrow(opts *bind.TransactOpts, asset common.Address, amount *big.Int, interestRateMode *big.Int, referralCode uint16, onBehalfOf common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "borrow", asset, amount, interestRateMode, referralCode, onBehalfOf)
+}
+
+// Borrow is a paid mutator transaction binding the contract method 0xa415bcad.
+//
+// Solidity: function borrow(address asset, uint256 amount, uint256 interestRateMode, uint16 referralCode, address onBehalfOf) returns()
+func (_AaveV3Pool *AaveV3PoolSession) Borrow(asset common.Address, amount *big.Int, interestRateMode *big.Int, referralCode uint16, onBehalfOf common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Borrow(&_AaveV3Pool.TransactOpts, asset, amount, interestRateMode, referralCode, onBehalfOf)
+}
+
+// Borrow is a paid mutator transaction binding the contract method 0xa415bcad.
+//
+// Solidity: function borrow(address asset, uint256 amount, uint256 interestRateMode, uint16 referralCode, address onBehalfOf) returns()
+func (_AaveV3Pool *AaveV3PoolTransactorSession) Borrow(asset common.Address, amount *big.Int, interestRateMode *big.Int, referralCode uint16, onBehalfOf common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Borrow(&_AaveV3Pool.TransactOpts, asset, amount, interestRateMode, referralCode, onBehalfOf)
+}
+
+// FlashLoanSimple is a paid mutator transaction binding the contract method 0x42b0b77c.
+//
+// Solidity: function flashLoanSimple(address receiverAddress, address asset, uint256 amount, bytes params, uint16 referralCode) returns()
+func (_AaveV3Pool *AaveV3PoolTransactor) FlashLoanSimple(opts *bind.TransactOpts, receiverAddress common.Address, asset common.Address, amount *big.Int, params []byte, referralCode uint16) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "flashLoanSimple", receiverAddress, asset, amount, params, referralCode)
+}
+
+// FlashLoanSimple is a paid mutator transaction binding the contract method 0x42b0b77c.
+//
+// Solidity: function flashLoanSimple(address receiverAddress, address asset, uint256 amount, bytes params, uint16 referralCode) returns()
+func (_AaveV3Pool *AaveV3PoolSession) FlashLoanSimple(receiverAddress common.Address, asset common.Address, amount *big.Int, params []byte, referralCode uint16) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.FlashLoanSimple(&_AaveV3Pool.TransactOpts, receiverAddress, asset, amount, params, referralCode)
+}
+
+// FlashLoanSimple is a paid mutator transaction binding the contract method 0x42b0b77c.
+//
+// Solidity: function flashLoanSimple(address receiverAddress, address asset, uint256 amount, bytes params, uint16 referralCode) returns()
+func (_AaveV3Pool *AaveV3PoolTransactorSession) FlashLoanSimple(receiverAddress common.Address, asset common.Address, amount *big.Int, params []byte, referralCode uint16) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.FlashLoanSimple(&_AaveV3Pool.TransactOpts, receiverAddress, asset, amount, params, referralCode)
+}
+
+// Repay is a paid mutator transaction binding the contract method 0x573ade81.
+//
+// Solidity: function repay(address asset, uint256 amount, uint256 interestRateMode, address onBehalfOf) returns(uint256)
+func (_AaveV3Pool *AaveV3PoolTransactor) Repay(opts *bind.TransactOpts, asset common.Address, amount *big.Int, interestRateMode *big.Int, onBehalfOf common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "repay", asset, amount, interestRateMode, onBehalfOf)
+}
+
+// Repay is a paid mutator transaction binding the contract method 0x573ade81.
+//
+// Solidity: function repay(address asset, uint256 amount, uint256 interestRateMode, address onBehalfOf) returns(uint256)
+func (_AaveV3Pool *AaveV3PoolSession) Repay(asset common.Address, amount *big.Int, interestRateMode *big.Int, onBehalfOf common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Repay(&_AaveV3Pool.TransactOpts, asset, amount, interestRateMode, onBehalfOf)
+}
+
+// Repay is a paid mutator transaction binding the contract method 0x573ade81.
+//
+// Solidity: function repay(address asset, uint256 amount, uint256 interestRateMode, address onBehalfOf) returns(uint256)
+func (_AaveV3Pool *AaveV3PoolTransactorSession) Repay(asset common.Address, amount *big.Int, interestRateMode *big.Int, onBehalfOf common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Repay(&_AaveV3Pool.TransactOpts, asset, amount, interestRateMode, onBehalfOf)
+}
+
+// SetUserEMode is a paid mutator transaction binding the contract method 0x28530a47.
+//
+// Solidity: function setUserEMode(uint8 categoryId) returns()
+func (_AaveV3Pool *AaveV3PoolTransactor) SetUserEMode(opts *bind.TransactOpts, categoryId uint8) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "setUserEMode", categoryId)
+}
+
+// SetUserEMode is a paid mutator transaction binding the contract method 0x28530a47.
+//
+// Solidity: function setUserEMode(uint8 categoryId) returns()
+func (_AaveV3Pool *AaveV3PoolSession) SetUserEMode(categoryId uint8) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.SetUserEMode(&_AaveV3Pool.TransactOpts, categoryId)
+}
+
+// SetUserEMode is a paid mutator transaction binding the contract method 0x28530a47.
+//
+// Solidity: function setUserEMode(uint8 categoryId) returns()
+func (_AaveV3Pool *AaveV3PoolTransactorSession) SetUserEMode(categoryId uint8) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.SetUserEMode(&_AaveV3Pool.TransactOpts, categoryId)
+}
+
+// SetUserUseReserveAsCollateral is a paid mutator transaction binding the contract method 0x5a3b74b9.
+//
+// Solidity: function setUserUseReserveAsCollateral(address asset, bool useAsCollateral) returns()
+func (_AaveV3Pool *AaveV3PoolTransactor) SetUserUseReserveAsCollateral(opts *bind.TransactOpts, asset common.Address, useAsCollateral bool) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "setUserUseReserveAsCollateral", asset, useAsCollateral)
+}
+
+// SetUserUseReserveAsCollateral is a paid mutator transaction binding the contract method 0x5a3b74b9.
+//
+// Solidity: function setUserUseReserveAsCollateral(address asset, bool useAsCollateral) returns()
+func (_AaveV3Pool *AaveV3PoolSession) SetUserUseReserveAsCollateral(asset common.Address, useAsCollateral bool) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.SetUserUseReserveAsCollateral(&_AaveV3Pool.TransactOpts, asset, useAsCollateral)
+}
+
+// SetUserUseReserveAsCollateral is a paid mutator transaction binding the contract method 0x5a3b74b9.
+//
+// Solidity: function setUserUseReserveAsCollateral(address asset, bool useAsCollateral) returns()
+func (_AaveV3Pool *AaveV3PoolTransactorSession) SetUserUseReserveAsCollateral(asset common.Address, useAsCollateral bool) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.SetUserUseReserveAsCollateral(&_AaveV3Pool.TransactOpts, asset, useAsCollateral)
+}
+
+// Supply is a paid mutator transaction binding the contract method 0x617ba037.
+//
+// Solidity: function supply(address asset, uint256 amount, address onBehalfOf, uint16 referralCode) returns()
+func (_AaveV3Pool *AaveV3PoolTransactor) Supply(opts *bind.TransactOpts, asset common.Address, amount *big.Int, onBehalfOf common.Address, referralCode uint16) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "supply", asset, amount, onBehalfOf, referralCode)
+}
+
+// Supply is a paid mutator transaction binding the contract method 0x617ba037.
+//
+// Solidity: function supply(address asset, uint256 amount, address onBehalfOf, uint16 referralCode) returns()
+func (_AaveV3Pool *AaveV3PoolSession) Supply(asset common.Address, amount *big.Int, onBehalfOf common.Address, referralCode uint16) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Supply(&_AaveV3Pool.TransactOpts, asset, amount, onBehalfOf, referralCode)
+}
+
+// Supply is a paid mutator transaction binding the contract method 0x617ba037.
+//
+// Solidity: function supply(address asset, uint256 amount, address onBehalfOf, uint16 referralCode) returns()
+func (_AaveV3Pool *AaveV3PoolTransactorSession) Supply(asset common.Address, amount *big.Int, onBehalfOf common.Address, referralCode uint16) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Supply(&_AaveV3Pool.TransactOpts, asset, amount, onBehalfOf, referralCode)
+}
+
+// SwapBorrowRateMode is a paid mutator transaction binding the contract method 0x94ba89a2.
+//
+// Solidity: function swapBorrowRateMode(address asset, uint256 interestRateMode) returns()
+func (_AaveV3Pool *AaveV3PoolTransactor) SwapBorrowRateMode(opts *bind.TransactOpts, asset common.Address, interestRateMode *big.Int) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "swapBorrowRateMode", asset, interestRateMode)
+}
+
+// SwapBorrowRateMode is a paid mutator transaction binding the contract method 0x94ba89a2.
+//
+// Solidity: function swapBorrowRateMode(address asset, uint256 interestRateMode) returns()
+func (_AaveV3Pool *AaveV3PoolSession) SwapBorrowRateMode(asset common.Address, interestRateMode *big.Int) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.SwapBorrowRateMode(&_AaveV3Pool.TransactOpts, asset, interestRateMode)
+}
+
+// SwapBorrowRateMode is a paid mutator transaction binding the contract method 0x94ba89a2.
+//
+// Solidity: function swapBorrowRateMode(address asset, uint256 interestRateMode) returns()
+func (_AaveV3Pool *AaveV3PoolTransactorSession) SwapBorrowRateMode(asset common.Address, interestRateMode *big.Int) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.SwapBorrowRateMode(&_AaveV3Pool.TransactOpts, asset, interestRateMode)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x69328dec.
+//
+// Solidity: function withdraw(address asset, uint256 amount, address to) returns(uint256)
+func (_AaveV3Pool *AaveV3PoolTransactor) Withdraw(opts *bind.TransactOpts, asset common.Address, amount *big.Int, to common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.contract.Transact(opts, "withdraw", asset, amount, to)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x69328dec.
+//
+// Solidity: function withdraw(address asset, uint256 amount, address to) returns(uint256)
+func (_AaveV3Pool *AaveV3PoolSession) Withdraw(asset common.Address, amount *big.Int, to common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Withdraw(&_AaveV3Pool.TransactOpts, asset, amount, to)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x69328dec.
+//
+// Solidity: function withdraw(address asset, uint256 amount, address to) returns(uint256)
+func (_AaveV3Pool *AaveV3PoolTransactorSession) Withdraw(asset common.Address, amount *big.Int, to common.Address) (*types.Transaction, error) {
+	return _AaveV3Pool.Contract.Withdraw(&_AaveV3Pool.TransactOpts, asset, amount, to)
+}
@@ -0,0 +1,12 @@
+// Package bindings holds typed Go bindings for the core protocol contracts,
+// generated by abigen from the registry ABIs in ../abis. Run go generate in
+// this directory after changing one of those ABIs.
+package bindings
+
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/erc20.json --pkg bindings --type ERC20 --out erc20.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/uniswap_v3_router.json --pkg bindings --type UniswapV3Router --out uniswap_v3_router.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/uniswap_v3_quoter.json --pkg bindings --type UniswapV3Quoter --out uniswap_v3_quoter.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/uniswap_v3_position_manager.json --pkg bindings --type UniswapV3PositionManager --out uniswap_v3_position_manager.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/aave_v3_pool.json --pkg bindings --type AaveV3Pool --out aave_v3_pool.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/comet.json --pkg bindings --type Comet --out comet.go
+//go:generate go run github.com/ethereum/go-ethereum/cmd/abigen@v1.16.5 --abi ../abis/curve_pool.json --pkg bindings --type CurvePool --out curve_pool.go
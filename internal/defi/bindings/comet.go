@@ -0,0 +1,440 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package bindings
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// CometMetaData contains all meta data concerning the Comet contract.
+var CometMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[],\"name\":\"baseToken\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"supply\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"withdraw\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"borrowBalanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"asset\",\"type\":\"address\"}],\"name\":\"collateralBalanceOf\",\"outputs\":[{\"internalType\":\"uint128\",\"name\":\"\",\"type\":\"uint128\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getUtilization\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"utilization\",\"type\":\"uint256\"}],\"name\":\"getSupplyRate\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"utilization\",\"type\":\"uint256\"}],\"name\":\"getBorrowRate\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// CometABI is the input ABI used to generate the binding from.
+// Deprecated: Use CometMetaData.ABI instead.
+var CometABI = CometMetaData.ABI
+
+// Comet is an auto generated Go binding around an Ethereum contract.
+type Comet struct {
+	CometCaller     // Read-only binding to the contract
+	CometTransactor // Write-only binding to the contract
+	CometFilterer   // Log filterer for contract events
+}
+
+// CometCaller is an auto generated read-only Go binding around an Ethereum contract.
+type CometCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CometTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type CometTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CometFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type CometFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CometSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type CometSession struct {
+	Contract     *Comet            // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// CometCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type CometCallerSession struct {
+	Contract *CometCaller  // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts // Call options to use throughout this session
+}
+
+// CometTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type CometTransactorSession struct {
+	Contract     *CometTransactor  // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// CometRaw is an auto generated low-level Go binding around an Ethereum contract.
+type CometRaw struct {
+	Contract *Comet // Generic contract binding to access the raw methods on
+}
+
+// CometCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type CometCallerRaw struct {
+	Contract *CometCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// CometTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type CometTransactorRaw struct {
+	Contract *CometTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewComet creates a new instance of Comet, bound to a specific deployed contract.
+func NewComet(address common.Address, backend bind.ContractBackend) (*Comet, error) {
+	contract, err := bindComet(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Comet{CometCaller: CometCaller{contract: contract}, CometTransactor: CometTransactor{contract: contract}, CometFilterer: CometFilterer{contract: contract}}, nil
+}
+
+// NewCometCaller creates a new read-only instance of Comet, bound to a specific deployed contract.
+func NewCometCaller(address common.Address, caller bind.ContractCaller) (*CometCaller, error) {
+	contract, err := bindComet(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CometCaller{contract: contract}, nil
+}
+
+// NewCometTransactor creates a new write-only instance of Comet, bound to a specific deployed contract.
+func NewCometTransactor(address common.Address, transactor bind.ContractTransactor) (*CometTransactor, error) {
+	contract, err := bindComet(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CometTransactor{contract: contract}, nil
+}
+
+// NewCometFilterer creates a new log filterer instance of Comet, bound to a specific deployed contract.
+func NewCometFilterer(address common.Address, filterer bind.ContractFilterer) (*CometFilterer, error) {
+	contract, err := bindComet(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &CometFilterer{contract: contract}, nil
+}
+
+// bindComet binds a generic wrapper to an already deployed contract.
+func bindComet(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := CometMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Comet *CometRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Comet.Contract.CometCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Comet *CometRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Comet.Contract.CometTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Comet *CometRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Comet.Contract.CometTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Comet *CometCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Comet.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Comet *CometTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Comet.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Comet *CometTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Comet.Contract.contract.Transact(opts, method, params...)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_Comet *CometCaller) BalanceOf(opts *bind.CallOpts, account common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "balanceOf", account)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_Comet *CometSession) BalanceOf(account common.Address) (*big.Int, error) {
+	return _Comet.Contract.BalanceOf(&_Comet.CallOpts, account)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address account) view returns(uint256)
+func (_Comet *CometCallerSession) BalanceOf(account common.Address) (*big.Int, error) {
+	return _Comet.Contract.BalanceOf(&_Comet.CallOpts, account)
+}
+
+// BaseToken is a free data retrieval call binding the contract method 0xc55dae63.
+//
+// Solidity: function baseToken() view returns(address)
+func (_Comet *CometCaller) BaseToken(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "baseToken")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// BaseToken is a free data retrieval call binding the contract method 0xc55dae63.
+//
+// Solidity: function baseToken() view returns(address)
+func (_Comet *CometSession) BaseToken() (common.Address, error) {
+	return _Comet.Contract.BaseToken(&_Comet.CallOpts)
+}
+
+// BaseToken is a free data retrieval call binding the contract method 0xc55dae63.
+//
+// Solidity: function baseToken() view returns(address)
+func (_Comet *CometCallerSession) BaseToken() (common.Address, error) {
+	return _Comet.Contract.BaseToken(&_Comet.CallOpts)
+}
+
+// BorrowBalanceOf is a free data retrieval call binding the contract method 0x374c49b4.
+//
+// Solidity: function borrowBalanceOf(address account) view returns(uint256)
+func (_Comet *CometCaller) BorrowBalanceOf(opts *bind.CallOpts, account common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "borrowBalanceOf", account)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// BorrowBalanceOf is a free data retrieval call binding the contract method 0x374c49b4.
+//
+// Solidity: function borrowBalanceOf(address account) view returns(uint256)
+func (_Comet *CometSession) BorrowBalanceOf(account common.Address) (*big.Int, error) {
+	return _Comet.Contract.BorrowBalanceOf(&_Comet.CallOpts, account)
+}
+
+// BorrowBalanceOf is a free data retrieval call binding the contract method 0x374c49b4.
+//
+// Solidity: function borrowBalanceOf(address account) view returns(uint256)
+func (_Comet *CometCallerSession) BorrowBalanceOf(account common.Address) (*big.Int, error) {
+	return _Comet.Contract.BorrowBalanceOf(&_Comet.CallOpts, account)
+}
+
+// CollateralBalanceOf is a free data retrieval call binding the contract method 0x5c2549ee.
+//
+// Solidity: function collateralBalanceOf(address account, address asset) view returns(uint128)
+func (_Comet *CometCaller) CollateralBalanceOf(opts *bind.CallOpts, account common.Address, asset common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "collateralBalanceOf", account, asset)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// CollateralBalanceOf is a free data retrieval call binding the contract method 0x5c2549ee.
+//
+// Solidity: function collateralBalanceOf(address account, address asset) view returns(uint128)
+func (_Comet *CometSession) CollateralBalanceOf(account common.Address, asset common.Address) (*big.Int, error) {
+	return _Comet.Contract.CollateralBalanceOf(&_Comet.CallOpts, account, asset)
+}
+
+// CollateralBalanceOf is a free data retrieval call binding the contract method 0x5c2549ee.
+//
+// Solidity: function collateralBalanceOf(address account, address asset) view returns(uint128)
+func (_Comet *CometCallerSession) CollateralBalanceOf(account common.Address, asset common.Address) (*big.Int, error) {
+	return _Comet.Contract.CollateralBalanceOf(&_Comet.CallOpts, account, asset)
+}
+
+// GetBorrowRate is a free data retrieval call binding the contract method 0x9fa83b5a.
+//
+// Solidity: function getBorrowRate(uint256 utilization) view returns(uint64)
+func (_Comet *CometCaller) GetBorrowRate(opts *bind.CallOpts, utilization *big.Int) (uint64, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "getBorrowRate", utilization)
+
+	if err != nil {
+		return *new(uint64), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint64)).(*uint64)
+
+	return out0, err
+
+}
+
+// GetBorrowRate is a free data retrieval call binding the contract method 0x9fa83b5a.
+//
+// Solidity: function getBorrowRate(uint256 utilization) view returns(uint64)
+func (_Comet *CometSession) GetBorrowRate(utilization *big.Int) (uint64, error) {
+	return _Comet.Contract.GetBorrowRate(&_Comet.CallOpts, utilization)
+}
+
+// GetBorrowRate is a free data retrieval call binding the contract method 0x9fa83b5a.
+//
+// Solidity: function getBorrowRate(uint256 utilization) view returns(uint64)
+func (_Comet *CometCallerSession) GetBorrowRate(utilization *big.Int) (uint64, error) {
+	return _Comet.Contract.GetBorrowRate(&_Comet.CallOpts, utilization)
+}
+
+// GetSupplyRate is a free data retrieval call binding the contract method 0xd955759d.
+//
+// Solidity: function getSupplyRate(uint256 utilization) view returns(uint64)
+func (_Comet *CometCaller) GetSupplyRate(opts *bind.CallOpts, utilization *big.Int) (uint64, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "getSupplyRate", utilization)
+
+	if err != nil {
+		return *new(uint64), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint64)).(*uint64)
+
+	return out0, err
+
+}
+
+// GetSupplyRate is a free data retrieval call binding the contract method 0xd955759d.
+//
+// Solidity: function getSupplyRate(uint256 utilization) view returns(uint64)
+func (_Comet *CometSession) GetSupplyRate(utilization *big.Int) (uint64, error) {
+	return _Comet.Contract.GetSupplyRate(&_Comet.CallOpts, utilization)
+}
+
+// GetSupplyRate is a free data retrieval call binding the contract method 0xd955759d.
+//
+// Solidity: function getSupplyRate(uint256 utilization) view returns(uint64)
+func (_Comet *CometCallerSession) GetSupplyRate(utilization *big.Int) (uint64, error) {
+	return _Comet.Contract.GetSupplyRate(&_Comet.CallOpts, utilization)
+}
+
+// GetUtilization is a free data retrieval call binding the contract method 0x7eb71131.
+//
+// Solidity: function getUtilization() view returns(uint256)
+func (_Comet *CometCaller) GetUtilization(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _Comet.contract.Call(opts, &out, "getUtilization")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetUtilization is a free data retrieval call binding the contract method 0x7eb71131.
+//
+// Solidity: function getUtilization() view returns(uint256)
+func (_Comet *CometSession) GetUtilization() (*big.Int, error) {
+	return _Comet.Contract.GetUtilization(&_Comet.CallOpts)
+}
+
+// GetUtilization is a free data retrieval call binding the contract method 0x7eb71131.
+//
+// Solidity: function getUtilization() view returns(uint256)
+func (_Comet *CometCallerSession) GetUtilization() (*big.Int, error) {
+	return _Comet.Contract.GetUtilization(&_Comet.CallOpts)
+}
+
+// Supply is a paid mutator transaction binding the contract method 0xf2b9fdb8.
+//
+// Solidity: function supply(address asset, uint256 amount) returns()
+func (_Comet *CometTransactor) Supply(opts *bind.TransactOpts, asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _Comet.contract.Transact(opts, "supply", asset, amount)
+}
+
+// Supply is a paid mutator transaction binding the contract method 0xf2b9fdb8.
+//
+// Solidity: function supply(address asset, uint256 amount) returns()
+func (_Comet *CometSession) Supply(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _Comet.Contract.Supply(&_Comet.TransactOpts, asset, amount)
+}
+
+// Supply is a paid mutator transaction binding the contract method 0xf2b9fdb8.
+//
+// Solidity: function supply(address asset, uint256 amount) returns()
+func (_Comet *CometTransactorSession) Supply(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _Comet.Contract.Supply(&_Comet.TransactOpts, asset, amount)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0xf3fef3a3.
+//
+// Solidity: function withdraw(address asset, uint256 amount) returns()
+func (_Comet *CometTransactor) Withdraw(opts *bind.TransactOpts, asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _Comet.contract.Transact(opts, "withdraw", asset, amount)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0xf3fef3a3.
+//
+// Solidity: function withdraw(address asset, uint256 amount) returns()
+func (_Comet *CometSession) Withdraw(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _Comet.Contract.Withdraw(&_Comet.TransactOpts, asset, amount)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0xf3fef3a3.
+//
+// Solidity: function withdraw(address asset, uint256 amount) returns()
+func (_Comet *CometTransactorSession) Withdraw(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	return _Comet.Contract.Withdraw(&_Comet.TransactOpts, asset, amount)
+}
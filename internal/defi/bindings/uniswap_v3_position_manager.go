@@ -0,0 +1,957 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package bindings
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// INonfungiblePositionManagerCollectParams is an auto generated low-level Go binding around an user-defined struct.
+type INonfungiblePositionManagerCollectParams struct {
+	TokenId    *big.Int
+	Recipient  common.Address
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+// INonfungiblePositionManagerDecreaseLiquidityParams is an auto generated low-level Go binding around an user-defined struct.
+type INonfungiblePositionManagerDecreaseLiquidityParams struct {
+	TokenId    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+	Deadline   *big.Int
+}
+
+// INonfungiblePositionManagerIncreaseLiquidityParams is an auto generated low-level Go binding around an user-defined struct.
+type INonfungiblePositionManagerIncreaseLiquidityParams struct {
+	TokenId        *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Deadline       *big.Int
+}
+
+// INonfungiblePositionManagerMintParams is an auto generated low-level Go binding around an user-defined struct.
+type INonfungiblePositionManagerMintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            *big.Int
+	TickLower      *big.Int
+	TickUpper      *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+// UniswapV3PositionManagerMetaData contains all meta data concerning the UniswapV3PositionManager contract.
+var UniswapV3PositionManagerMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"structINonfungiblePositionManager.MintParams\",\"name\":\"params\",\"type\":\"tuple\",\"components\":[{\"internalType\":\"address\",\"name\":\"token0\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"token1\",\"type\":\"address\"},{\"internalType\":\"uint24\",\"name\":\"fee\",\"type\":\"uint24\"},{\"internalType\":\"int24\",\"name\":\"tickLower\",\"type\":\"int24\"},{\"internalType\":\"int24\",\"name\":\"tickUpper\",\"type\":\"int24\"},{\"internalType\":\"uint256\",\"name\":\"amount0Desired\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1Desired\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount0Min\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1Min\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"deadline\",\"type\":\"uint256\"}]}],\"name\":\"mint\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"uint128\",\"name\":\"liquidity\",\"type\":\"uint128\"},{\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"structINonfungiblePositionManager.IncreaseLiquidityParams\",\"name\":\"params\",\"type\":\"tuple\",\"components\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount0Desired\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1Desired\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount0Min\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1Min\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"deadline\",\"type\":\"uint256\"}]}],\"name\":\"increaseLiquidity\",\"outputs\":[{\"internalType\":\"uint128\",\"name\":\"liquidity\",\"type\":\"uint128\"},{\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"structINonfungiblePositionManager.DecreaseLiquidityParams\",\"name\":\"params\",\"type\":\"tuple\",\"components\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"uint128\",\"name\":\"liquidity\",\"type\":\"uint128\"},{\"internalType\":\"uint256\",\"name\":\"amount0Min\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1Min\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"deadline\",\"type\":\"uint256\"}]}],\"name\":\"decreaseLiquidity\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"structINonfungiblePositionManager.CollectParams\",\"name\":\"params\",\"type\":\"tuple\",\"components\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"internalType\":\"uint128\",\"name\":\"amount0Max\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"amount1Max\",\"type\":\"uint128\"}]}],\"name\":\"collect\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"burn\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"positions\",\"outputs\":[{\"internalType\":\"uint96\",\"name\":\"nonce\",\"type\":\"uint96\"},{\"internalType\":\"address\",\"name\":\"operator\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"token0\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"token1\",\"type\":\"address\"},{\"internalType\":\"uint24\",\"name\":\"fee\",\"type\":\"uint24\"},{\"internalType\":\"int24\",\"name\":\"tickLower\",\"type\":\"int24\"},{\"internalType\":\"int24\",\"name\":\"tickUpper\",\"type\":\"int24\"},{\"internalType\":\"uint128\",\"name\":\"liquidity\",\"type\":\"uint128\"},{\"internalType\":\"uint256\",\"name\":\"feeGrowthInside0LastX128\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"feeGrowthInside1LastX128\",\"type\":\"uint256\"},{\"internalType\":\"uint128\",\"name\":\"tokensOwed0\",\"type\":\"uint128\"},{\"internalType\":\"uint128\",\"name\":\"tokensOwed1\",\"type\":\"uint128\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"}],\"name\":\"ownerOf\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"index\",\"type\":\"uint256\"}],\"name\":\"tokenOfOwnerByIndex\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint128\",\"name\":\"liquidity\",\"type\":\"uint128\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"name\":\"IncreaseLiquidity\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint128\",\"name\":\"liquidity\",\"type\":\"uint128\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"name\":\"DecreaseLiquidity\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint256\",\"name\":\"tokenId\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"recipient\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount0\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount1\",\"type\":\"uint256\"}],\"name\":\"Collect\",\"type\":\"event\"}]",
+}
+
+// UniswapV3PositionManagerABI is the input ABI used to generate the binding from.
+// Deprecated: Use UniswapV3PositionManagerMetaData.ABI instead.
+var UniswapV3PositionManagerABI = UniswapV3PositionManagerMetaData.ABI
+
+// UniswapV3PositionManager is an auto generated Go binding around an Ethereum contract.
+type UniswapV3PositionManager struct {
+	UniswapV3PositionManagerCaller     // Read-only binding to the contract
+	UniswapV3PositionManagerTransactor // Write-only binding to the contract
+	UniswapV3PositionManagerFilterer   // Log filterer for contract events
+}
+
+// UniswapV3PositionManagerCaller is an auto generated read-only Go binding around an Ethereum contract.
+type UniswapV3PositionManagerCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// UniswapV3PositionManagerTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type UniswapV3PositionManagerTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// UniswapV3PositionManagerFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type UniswapV3PositionManagerFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// UniswapV3PositionManagerSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type UniswapV3PositionManagerSession struct {
+	Contract     *UniswapV3PositionManager // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts             // Call options to use throughout this session
+	TransactOpts bind.TransactOpts         // Transaction auth options to use throughout this session
+}
+
+// UniswapV3PositionManagerCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type UniswapV3PositionManagerCallerSession struct {
+	Contract *UniswapV3PositionManagerCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts                   // Call options to use throughout this session
+}
+
+// UniswapV3PositionManagerTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type UniswapV3PositionManagerTransactorSession struct {
+	Contract     *UniswapV3PositionManagerTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts                   // Transaction auth options to use throughout this session
+}
+
+// UniswapV3PositionManagerRaw is an auto generated low-level Go binding around an Ethereum contract.
+type UniswapV3PositionManagerRaw struct {
+	Contract *UniswapV3PositionManager // Generic contract binding to access the raw methods on
+}
+
+// UniswapV3PositionManagerCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type UniswapV3PositionManagerCallerRaw struct {
+	Contract *UniswapV3PositionManagerCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// UniswapV3PositionManagerTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type UniswapV3PositionManagerTransactorRaw struct {
+	Contract *UniswapV3PositionManagerTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewUniswapV3PositionManager creates a new instance of UniswapV3PositionManager, bound to a specific deployed contract.
+func NewUniswapV3PositionManager(address common.Address, backend bind.ContractBackend) (*UniswapV3PositionManager, error) {
+	contract, err := bindUniswapV3PositionManager(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManager{UniswapV3PositionManagerCaller: UniswapV3PositionManagerCaller{contract: contract}, UniswapV3PositionManagerTransactor: UniswapV3PositionManagerTransactor{contract: contract}, UniswapV3PositionManagerFilterer: UniswapV3PositionManagerFilterer{contract: contract}}, nil
+}
+
+// NewUniswapV3PositionManagerCaller creates a new read-only instance of UniswapV3PositionManager, bound to a specific deployed contract.
+func NewUniswapV3PositionManagerCaller(address common.Address, caller bind.ContractCaller) (*UniswapV3PositionManagerCaller, error) {
+	contract, err := bindUniswapV3PositionManager(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManagerCaller{contract: contract}, nil
+}
+
+// NewUniswapV3PositionManagerTransactor creates a new write-only instance of UniswapV3PositionManager, bound to a specific deployed contract.
+func NewUniswapV3PositionManagerTransactor(address common.Address, transactor bind.ContractTransactor) (*UniswapV3PositionManagerTransactor, error) {
+	contract, err := bindUniswapV3PositionManager(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManagerTransactor{contract: contract}, nil
+}
+
+// NewUniswapV3PositionManagerFilterer creates a new log filterer instance of UniswapV3PositionManager, bound to a specific deployed contract.
+func NewUniswapV3PositionManagerFilterer(address common.Address, filterer bind.ContractFilterer) (*UniswapV3PositionManagerFilterer, error) {
+	contract, err := bindUniswapV3PositionManager(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManagerFilterer{contract: contract}, nil
+}
+
+// bindUniswapV3PositionManager binds a generic wrapper to an already deployed contract.
+func bindUniswapV3PositionManager(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := UniswapV3PositionManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_UniswapV3PositionManager *UniswapV3PositionManagerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _UniswapV3PositionManager.Contract.UniswapV3PositionManagerCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_UniswapV3PositionManager *UniswapV3PositionManagerRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.UniswapV3PositionManagerTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_UniswapV3PositionManager *UniswapV3PositionManagerRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.UniswapV3PositionManagerTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _UniswapV3PositionManager.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.contract.Transact(opts, method, params...)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address owner) view returns(uint256)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCaller) BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {
+	var out []interface{}
+	err := _UniswapV3PositionManager.contract.Call(opts, &out, "balanceOf", owner)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address owner) view returns(uint256)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) BalanceOf(owner common.Address) (*big.Int, error) {
+	return _UniswapV3PositionManager.Contract.BalanceOf(&_UniswapV3PositionManager.CallOpts, owner)
+}
+
+// BalanceOf is a free data retrieval call binding the contract method 0x70a08231.
+//
+// Solidity: function balanceOf(address owner) view returns(uint256)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCallerSession) BalanceOf(owner common.Address) (*big.Int, error) {
+	return _UniswapV3PositionManager.Contract.BalanceOf(&_UniswapV3PositionManager.CallOpts, owner)
+}
+
+// OwnerOf is a free data retrieval call binding the contract method 0x6352211e.
+//
+// Solidity: function ownerOf(uint256 tokenId) view returns(address)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCaller) OwnerOf(opts *bind.CallOpts, tokenId *big.Int) (common.Address, error) {
+	var out []interface{}
+	err := _UniswapV3PositionManager.contract.Call(opts, &out, "ownerOf", tokenId)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// OwnerOf is a free data retrieval call binding the contract method 0x6352211e.
+//
+// Solidity: function ownerOf(uint256 tokenId) view returns(address)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) OwnerOf(tokenId *big.Int) (common.Address, error) {
+	return _UniswapV3PositionManager.Contract.OwnerOf(&_UniswapV3PositionManager.CallOpts, tokenId)
+}
+
+// OwnerOf is a free data retrieval call binding the contract method 0x6352211e.
+//
+// Solidity: function ownerOf(uint256 tokenId) view returns(address)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCallerSession) OwnerOf(tokenId *big.Int) (common.Address, error) {
+	return _UniswapV3PositionManager.Contract.OwnerOf(&_UniswapV3PositionManager.CallOpts, tokenId)
+}
+
+// Positions is a free data retrieval call binding the contract method 0x99fbab88.
+//
+// Solidity: function positions(uint256 tokenId) view returns(uint96 nonce, address operator, address token0, address token1, uint24 fee, int24 tickLower, int24 tickUpper, uint128 liquidity, uint256 feeGrowthInside0LastX128, uint256 feeGrowthInside1LastX128, uint128 tokensOwed0, uint128 tokensOwed1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCaller) Positions(opts *bind.CallOpts, tokenId *big.Int) (struct {
+	Nonce                    *big.Int
+	Operator                 common.Address
+	Token0                   common.Address
+	Token1                   common.Address
+	Fee                      *big.Int
+	TickLower                *big.Int
+	TickUpper                *big.Int
+	Liquidity                *big.Int
+	FeeGrowthInside0LastX128 *big.Int
+	FeeGrowthInside1LastX128 *big.Int
+	TokensOwed0              *big.Int
+	TokensOwed1              *big.Int
+}, error) {
+	var out []interface{}
+	err := _UniswapV3PositionManager.contract.Call(opts, &out, "positions", tokenId)
+
+	outstruct := new(struct {
+		Nonce                    *big.Int
+		Operator                 common.Address
+		Token0                   common.Address
+		Token1                   common.Address
+		Fee                      *big.Int
+		TickLower                *big.Int
+		TickUpper                *big.Int
+		Liquidity                *big.Int
+		FeeGrowthInside0LastX128 *big.Int
+		FeeGrowthInside1LastX128 *big.Int
+		TokensOwed0              *big.Int
+		TokensOwed1              *big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Nonce = *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+	outstruct.Operator = *abi.ConvertType(out[1], new(common.Address)).(*common.Address)
+	outstruct.Token0 = *abi.ConvertType(out[2], new(common.Address)).(*common.Address)
+	outstruct.Token1 = *abi.ConvertType(out[3], new(common.Address)).(*common.Address)
+	outstruct.Fee = *abi.ConvertType(out[4], new(*big.Int)).(**big.Int)
+	outstruct.TickLower = *abi.ConvertType(out[5], new(*big.Int)).(**big.Int)
+	outstruct.TickUpper = *abi.ConvertType(out[6], new(*big.Int)).(**big.Int)
+	outstruct.Liquidity = *abi.ConvertType(out[7], new(*big.Int)).(**big.Int)
+	outstruct.FeeGrowthInside0LastX128 = *abi.ConvertType(out[8], new(*big.Int)).(**big.Int)
+	outstruct.FeeGrowthInside1LastX128 = *abi.ConvertType(out[9], new(*big.Int)).(**big.Int)
+	outstruct.TokensOwed0 = *abi.ConvertType(out[10], new(*big.Int)).(**big.Int)
+	outstruct.TokensOwed1 = *abi.ConvertType(out[11], new(*big.Int)).(**big.Int)
+
+	return *outstruct, err
+
+}
+
+// Positions is a free data retrieval call binding the contract method 0x99fbab88.
+//
+// Solidity: function positions(uint256 tokenId) view returns(uint96 nonce, address operator, address token0, address token1, uint24 fee, int24 tickLower, int24 tickUpper, uint128 liquidity, uint256 feeGrowthInside0LastX128, uint256 feeGrowthInside1LastX128, uint128 tokensOwed0, uint128 tokensOwed1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) Positions(tokenId *big.Int) (struct {
+	Nonce                    *big.Int
+	Operator                 common.Address
+	Token0                   common.Address
+	Token1                   common.Address
+	Fee                      *big.Int
+	TickLower                *big.Int
+	TickUpper                *big.Int
+	Liquidity                *big.Int
+	FeeGrowthInside0LastX128 *big.Int
+	FeeGrowthInside1LastX128 *big.Int
+	TokensOwed0              *big.Int
+	TokensOwed1              *big.Int
+}, error) {
+	return _UniswapV3PositionManager.Contract.Positions(&_UniswapV3PositionManager.CallOpts, tokenId)
+}
+
+// Positions is a free data retrieval call binding the contract method 0x99fbab88.
+//
+// Solidity: function positions(uint256 tokenId) view returns(uint96 nonce, address operator, address token0, address token1, uint24 fee, int24 tickLower, int24 tickUpper, uint128 liquidity, uint256 feeGrowthInside0LastX128, uint256 feeGrowthInside1LastX128, uint128 tokensOwed0, uint128 tokensOwed1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCallerSession) Positions(tokenId *big.Int) (struct {
+	Nonce                    *big.Int
+	Operator                 common.Address
+	Token0                   common.Address
+	Token1                   common.Address
+	Fee                      *big.Int
+	TickLower                *big.Int
+	TickUpper                *big.Int
+	Liquidity                *big.Int
+	FeeGrowthInside0LastX128 *big.Int
+	FeeGrowthInside1LastX128 *big.Int
+	TokensOwed0              *big.Int
+	TokensOwed1              *big.Int
+}, error) {
+	return _UniswapV3PositionManager.Contract.Positions(&_UniswapV3PositionManager.CallOpts, tokenId)
+}
+
+// TokenOfOwnerByIndex is a free data retrieval call binding the contract method 0x2f745c59.
+//
+// Solidity: function tokenOfOwnerByIndex(address owner, uint256 index) view returns(uint256)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCaller) TokenOfOwnerByIndex(opts *bind.CallOpts, owner common.Address, index *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _UniswapV3PositionManager.contract.Call(opts, &out, "tokenOfOwnerByIndex", owner, index)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// TokenOfOwnerByIndex is a free data retrieval call binding the contract method 0x2f745c59.
+//
+// Solidity: function tokenOfOwnerByIndex(address owner, uint256 index) view returns(uint256)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) TokenOfOwnerByIndex(owner common.Address, index *big.Int) (*big.Int, error) {
+	return _UniswapV3PositionManager.Contract.TokenOfOwnerByIndex(&_UniswapV3PositionManager.CallOpts, owner, index)
+}
+
+// TokenOfOwnerByIndex is a free data retrieval call binding the contract method 0x2f745c59.
+//
+// Solidity: function tokenOfOwnerByIndex(address owner, uint256 index) view returns(uint256)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerCallerSession) TokenOfOwnerByIndex(owner common.Address, index *big.Int) (*big.Int, error) {
+	return _UniswapV3PositionManager.Contract.TokenOfOwnerByIndex(&_UniswapV3PositionManager.CallOpts, owner, index)
+}
+
+// Burn is a paid mutator transaction binding the contract method 0x42966c68.
+//
+// Solidity: function burn(uint256 tokenId) payable returns()
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactor) Burn(opts *bind.TransactOpts, tokenId *big.Int) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.contract.Transact(opts, "burn", tokenId)
+}
+
+// Burn is a paid mutator transaction binding the contract method 0x42966c68.
+//
+// Solidity: function burn(uint256 tokenId) payable returns()
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) Burn(tokenId *big.Int) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.Burn(&_UniswapV3PositionManager.TransactOpts, tokenId)
+}
+
+// Burn is a paid mutator transaction binding the contract method 0x42966c68.
+//
+// Solidity: function burn(uint256 tokenId) payable returns()
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorSession) Burn(tokenId *big.Int) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.Burn(&_UniswapV3PositionManager.TransactOpts, tokenId)
+}
+
+// Collect is a paid mutator transaction binding the contract method 0xfc6f7865.
+//
+// Solidity: function collect((uint256,address,uint128,uint128) params) payable returns(uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactor) Collect(opts *bind.TransactOpts, params INonfungiblePositionManagerCollectParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.contract.Transact(opts, "collect", params)
+}
+
+// Collect is a paid mutator transaction binding the contract method 0xfc6f7865.
+//
+// Solidity: function collect((uint256,address,uint128,uint128) params) payable returns(uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) Collect(params INonfungiblePositionManagerCollectParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.Collect(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// Collect is a paid mutator transaction binding the contract method 0xfc6f7865.
+//
+// Solidity: function collect((uint256,address,uint128,uint128) params) payable returns(uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorSession) Collect(params INonfungiblePositionManagerCollectParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.Collect(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// DecreaseLiquidity is a paid mutator transaction binding the contract method 0x0c49ccbe.
+//
+// Solidity: function decreaseLiquidity((uint256,uint128,uint256,uint256,uint256) params) payable returns(uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactor) DecreaseLiquidity(opts *bind.TransactOpts, params INonfungiblePositionManagerDecreaseLiquidityParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.contract.Transact(opts, "decreaseLiquidity", params)
+}
+
+// DecreaseLiquidity is a paid mutator transaction binding the contract method 0x0c49ccbe.
+//
+// Solidity: function decreaseLiquidity((uint256,uint128,uint256,uint256,uint256) params) payable returns(uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) DecreaseLiquidity(params INonfungiblePositionManagerDecreaseLiquidityParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.DecreaseLiquidity(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// DecreaseLiquidity is a paid mutator transaction binding the contract method 0x0c49ccbe.
+//
+// Solidity: function decreaseLiquidity((uint256,uint128,uint256,uint256,uint256) params) payable returns(uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorSession) DecreaseLiquidity(params INonfungiblePositionManagerDecreaseLiquidityParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.DecreaseLiquidity(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// IncreaseLiquidity is a paid mutator transaction binding the contract method 0x219f5d17.
+//
+// Solidity: function increaseLiquidity((uint256,uint256,uint256,uint256,uint256,uint256) params) payable returns(uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactor) IncreaseLiquidity(opts *bind.TransactOpts, params INonfungiblePositionManagerIncreaseLiquidityParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.contract.Transact(opts, "increaseLiquidity", params)
+}
+
+// IncreaseLiquidity is a paid mutator transaction binding the contract method 0x219f5d17.
+//
+// Solidity: function increaseLiquidity((uint256,uint256,uint256,uint256,uint256,uint256) params) payable returns(uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) IncreaseLiquidity(params INonfungiblePositionManagerIncreaseLiquidityParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.IncreaseLiquidity(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// IncreaseLiquidity is a paid mutator transaction binding the contract method 0x219f5d17.
+//
+// Solidity: function increaseLiquidity((uint256,uint256,uint256,uint256,uint256,uint256) params) payable returns(uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorSession) IncreaseLiquidity(params INonfungiblePositionManagerIncreaseLiquidityParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.IncreaseLiquidity(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// Mint is a paid mutator transaction binding the contract method 0x88316456.
+//
+// Solidity: function mint((address,address,uint24,int24,int24,uint256,uint256,uint256,uint256,address,uint256) params) payable returns(uint256 tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactor) Mint(opts *bind.TransactOpts, params INonfungiblePositionManagerMintParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.contract.Transact(opts, "mint", params)
+}
+
+// Mint is a paid mutator transaction binding the contract method 0x88316456.
+//
+// Solidity: function mint((address,address,uint24,int24,int24,uint256,uint256,uint256,uint256,address,uint256) params) payable returns(uint256 tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerSession) Mint(params INonfungiblePositionManagerMintParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.Mint(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// Mint is a paid mutator transaction binding the contract method 0x88316456.
+//
+// Solidity: function mint((address,address,uint24,int24,int24,uint256,uint256,uint256,uint256,address,uint256) params) payable returns(uint256 tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerTransactorSession) Mint(params INonfungiblePositionManagerMintParams) (*types.Transaction, error) {
+	return _UniswapV3PositionManager.Contract.Mint(&_UniswapV3PositionManager.TransactOpts, params)
+}
+
+// UniswapV3PositionManagerCollectIterator is returned from FilterCollect and is used to iterate over the raw logs and unpacked data for Collect events raised by the UniswapV3PositionManager contract.
+type UniswapV3PositionManagerCollectIterator struct {
+	Event *UniswapV3PositionManagerCollect // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *UniswapV3PositionManagerCollectIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(UniswapV3PositionManagerCollect)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(UniswapV3PositionManagerCollect)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *UniswapV3PositionManagerCollectIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *UniswapV3PositionManagerCollectIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// UniswapV3PositionManagerCollect represents a Collect event raised by the UniswapV3PositionManager contract.
+type UniswapV3PositionManagerCollect struct {
+	TokenId   *big.Int
+	Recipient common.Address
+	Amount0   *big.Int
+	Amount1   *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterCollect is a free log retrieval operation binding the contract event 0x40d0efd1a53d60ecbf40971b9daf7dc90178c3aadc7aab1765632738fa8b8f01.
+//
+// Solidity: event Collect(uint256 indexed tokenId, address recipient, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) FilterCollect(opts *bind.FilterOpts, tokenId []*big.Int) (*UniswapV3PositionManagerCollectIterator, error) {
+
+	var tokenIdRule []interface{}
+	for _, tokenIdItem := range tokenId {
+		tokenIdRule = append(tokenIdRule, tokenIdItem)
+	}
+
+	logs, sub, err := _UniswapV3PositionManager.contract.FilterLogs(opts, "Collect", tokenIdRule)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManagerCollectIterator{contract: _UniswapV3PositionManager.contract, event: "Collect", logs: logs, sub: sub}, nil
+}
+
+// WatchCollect is a free log subscription operation binding the contract event 0x40d0efd1a53d60ecbf40971b9daf7dc90178c3aadc7aab1765632738fa8b8f01.
+//
+// Solidity: event Collect(uint256 indexed tokenId, address recipient, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) WatchCollect(opts *bind.WatchOpts, sink chan<- *UniswapV3PositionManagerCollect, tokenId []*big.Int) (event.Subscription, error) {
+
+	var tokenIdRule []interface{}
+	for _, tokenIdItem := range tokenId {
+		tokenIdRule = append(tokenIdRule, tokenIdItem)
+	}
+
+	logs, sub, err := _UniswapV3PositionManager.contract.WatchLogs(opts, "Collect", tokenIdRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(UniswapV3PositionManagerCollect)
+				if err := _UniswapV3PositionManager.contract.UnpackLog(event, "Collect", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseCollect is a log parse operation binding the contract event 0x40d0efd1a53d60ecbf40971b9daf7dc90178c3aadc7aab1765632738fa8b8f01.
+//
+// Solidity: event Collect(uint256 indexed tokenId, address recipient, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) ParseCollect(log types.Log) (*UniswapV3PositionManagerCollect, error) {
+	event := new(UniswapV3PositionManagerCollect)
+	if err := _UniswapV3PositionManager.contract.UnpackLog(event, "Collect", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// UniswapV3PositionManagerDecreaseLiquidityIterator is returned from FilterDecreaseLiquidity and is used to iterate over the raw logs and unpacked data for DecreaseLiquidity events raised by the UniswapV3PositionManager contract.
+type UniswapV3PositionManagerDecreaseLiquidityIterator struct {
+	Event *UniswapV3PositionManagerDecreaseLiquidity // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *UniswapV3PositionManagerDecreaseLiquidityIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(UniswapV3PositionManagerDecreaseLiquidity)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(UniswapV3PositionManagerDecreaseLiquidity)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *UniswapV3PositionManagerDecreaseLiquidityIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *UniswapV3PositionManagerDecreaseLiquidityIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// UniswapV3PositionManagerDecreaseLiquidity represents a DecreaseLiquidity event raised by the UniswapV3PositionManager contract.
+type UniswapV3PositionManagerDecreaseLiquidity struct {
+	TokenId   *big.Int
+	Liquidity *big.Int
+	Amount0   *big.Int
+	Amount1   *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterDecreaseLiquidity is a free log retrieval operation binding the contract event 0x26f6a048ee9138f2c0ce266f322cb99228e8d619ae2bff30c67f8dcf9d2377b4.
+//
+// Solidity: event DecreaseLiquidity(uint256 indexed tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) FilterDecreaseLiquidity(opts *bind.FilterOpts, tokenId []*big.Int) (*UniswapV3PositionManagerDecreaseLiquidityIterator, error) {
+
+	var tokenIdRule []interface{}
+	for _, tokenIdItem := range tokenId {
+		tokenIdRule = append(tokenIdRule, tokenIdItem)
+	}
+
+	logs, sub, err := _UniswapV3PositionManager.contract.FilterLogs(opts, "DecreaseLiquidity", tokenIdRule)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManagerDecreaseLiquidityIterator{contract: _UniswapV3PositionManager.contract, event: "DecreaseLiquidity", logs: logs, sub: sub}, nil
+}
+
+// WatchDecreaseLiquidity is a free log subscription operation binding the contract event 0x26f6a048ee9138f2c0ce266f322cb99228e8d619ae2bff30c67f8dcf9d2377b4.
+//
+// Solidity: event DecreaseLiquidity(uint256 indexed tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) WatchDecreaseLiquidity(opts *bind.WatchOpts, sink chan<- *UniswapV3PositionManagerDecreaseLiquidity, tokenId []*big.Int) (event.Subscription, error) {
+
+	var tokenIdRule []interface{}
+	for _, tokenIdItem := range tokenId {
+		tokenIdRule = append(tokenIdRule, tokenIdItem)
+	}
+
+	logs, sub, err := _UniswapV3PositionManager.contract.WatchLogs(opts, "DecreaseLiquidity", tokenIdRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(UniswapV3PositionManagerDecreaseLiquidity)
+				if err := _UniswapV3PositionManager.contract.UnpackLog(event, "DecreaseLiquidity", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseDecreaseLiquidity is a log parse operation binding the contract event 0x26f6a048ee9138f2c0ce266f322cb99228e8d619ae2bff30c67f8dcf9d2377b4.
+//
+// Solidity: event DecreaseLiquidity(uint256 indexed tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) ParseDecreaseLiquidity(log types.Log) (*UniswapV3PositionManagerDecreaseLiquidity, error) {
+	event := new(UniswapV3PositionManagerDecreaseLiquidity)
+	if err := _UniswapV3PositionManager.contract.UnpackLog(event, "DecreaseLiquidity", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// UniswapV3PositionManagerIncreaseLiquidityIterator is returned from FilterIncreaseLiquidity and is used to iterate over the raw logs and unpacked data for IncreaseLiquidity events raised by the UniswapV3PositionManager contract.
+type UniswapV3PositionManagerIncreaseLiquidityIterator struct {
+	Event *UniswapV3PositionManagerIncreaseLiquidity // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *UniswapV3PositionManagerIncreaseLiquidityIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(UniswapV3PositionManagerIncreaseLiquidity)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(UniswapV3PositionManagerIncreaseLiquidity)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *UniswapV3PositionManagerIncreaseLiquidityIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *UniswapV3PositionManagerIncreaseLiquidityIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// UniswapV3PositionManagerIncreaseLiquidity represents a IncreaseLiquidity event raised by the UniswapV3PositionManager contract.
+type UniswapV3PositionManagerIncreaseLiquidity struct {
+	TokenId   *big.Int
+	Liquidity *big.Int
+	Amount0   *big.Int
+	Amount1   *big.Int
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterIncreaseLiquidity is a free log retrieval operation binding the contract event 0x3067048beee31b25b2f1681f88dac838c8bba36af25bfb2b7cf7473a5847e35f.
+//
+// Solidity: event IncreaseLiquidity(uint256 indexed tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) FilterIncreaseLiquidity(opts *bind.FilterOpts, tokenId []*big.Int) (*UniswapV3PositionManagerIncreaseLiquidityIterator, error) {
+
+	var tokenIdRule []interface{}
+	for _, tokenIdItem := range tokenId {
+		tokenIdRule = append(tokenIdRule, tokenIdItem)
+	}
+
+	logs, sub, err := _UniswapV3PositionManager.contract.FilterLogs(opts, "IncreaseLiquidity", tokenIdRule)
+	if err != nil {
+		return nil, err
+	}
+	return &UniswapV3PositionManagerIncreaseLiquidityIterator{contract: _UniswapV3PositionManager.contract, event: "IncreaseLiquidity", logs: logs, sub: sub}, nil
+}
+
+// WatchIncreaseLiquidity is a free log subscription operation binding the contract event 0x3067048beee31b25b2f1681f88dac838c8bba36af25bfb2b7cf7473a5847e35f.
+//
+// Solidity: event IncreaseLiquidity(uint256 indexed tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) WatchIncreaseLiquidity(opts *bind.WatchOpts, sink chan<- *UniswapV3PositionManagerIncreaseLiquidity, tokenId []*big.Int) (event.Subscription, error) {
+
+	var tokenIdRule []interface{}
+	for _, tokenIdItem := range tokenId {
+		tokenIdRule = append(tokenIdRule, tokenIdItem)
+	}
+
+	logs, sub, err := _UniswapV3PositionManager.contract.WatchLogs(opts, "IncreaseLiquidity", tokenIdRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(UniswapV3PositionManagerIncreaseLiquidity)
+				if err := _UniswapV3PositionManager.contract.UnpackLog(event, "IncreaseLiquidity", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseIncreaseLiquidity is a log parse operation binding the contract event 0x3067048beee31b25b2f1681f88dac838c8bba36af25bfb2b7cf7473a5847e35f.
+//
+// Solidity: event IncreaseLiquidity(uint256 indexed tokenId, uint128 liquidity, uint256 amount0, uint256 amount1)
+func (_UniswapV3PositionManager *UniswapV3PositionManagerFilterer) ParseIncreaseLiquidity(log types.Log) (*UniswapV3PositionManagerIncreaseLiquidity, error) {
+	event := new(UniswapV3PositionManagerIncreaseLiquidity)
+	if err := _UniswapV3PositionManager.contract.UnpackLog(event, "IncreaseLiquidity", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -19,10 +20,16 @@ import (
 
 // RealBlockchainManager handles real blockchain interactions
 type RealBlockchainManager struct {
-	Client     *ethclient.Client
+	Client     Backend
 	PrivateKey string
 	ChainID    *big.Int
 	Address    common.Address
+	Gas        *GasPolicy
+	Nonces     *NonceManager
+
+	// Monitor tracks sent transactions until they have the configured
+	// number of confirmations
+	Monitor *TransactionMonitor
 }
 
 // NewRealBlockchainManager creates a new blockchain manager with real connection
@@ -33,6 +40,25 @@ func NewRealBlockchainManager(privateKey string) (*RealBlockchainManager, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to blockchain: %v", err)
 	}
+	log.Printf("Connected to blockchain at %s", rpcURL)
+
+	return NewRealBlockchainManagerWithBackend(client, privateKey)
+}
+
+// NewRealBlockchainManagerWithBackend creates a blockchain manager on an
+// existing backend such as a simulated chain
+func NewRealBlockchainManagerWithBackend(client Backend, privateKey string) (*RealBlockchainManager, error) {
+	return NewRealBlockchainManagerFromConfig(client, config.BlockchainConfig{PrivateKey: privateKey}, nil)
+}
+
+// NewRealBlockchainManagerFromConfig creates a blockchain manager using the
+// key, gas settings and confirmation depth in cfg. Transaction monitor RPC
+// calls are reported to recorder, which may be nil.
+func NewRealBlockchainManagerFromConfig(client Backend, cfg config.BlockchainConfig, recorder CallRecorder) (*RealBlockchainManager, error) {
+	gas, err := NewGasPolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas configuration: %v", err)
+	}
 
 	chainID, err := client.ChainID(context.Background())
 	if err != nil {
@@ -40,20 +66,22 @@ func NewRealBlockchainManager(privateKey string) (*RealBlockchainManager, error)
 	}
 
 	// Get address from private key
-	address, err := getAddressFromPrivateKey(privateKey)
+	address, err := getAddressFromPrivateKey(cfg.PrivateKey)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("Connected to blockchain at %s", rpcURL)
 	log.Printf("Chain ID: %s", chainID.String())
 	log.Printf("Wallet address: %s", address.Hex())
 
 	return &RealBlockchainManager{
 		Client:     client,
-		PrivateKey: privateKey,
+		PrivateKey: cfg.PrivateKey,
 		ChainID:    chainID,
 		Address:    address,
+		Gas:        gas,
+		Nonces:     SharedNonceManager(client, chainID, address),
+		Monitor:    NewTransactionMonitorFromConfig(client, cfg, recorder),
 	}, nil
 }
 
@@ -120,67 +148,105 @@ func (bm *RealBlockchainManager) GetTokenBalance(tokenAddress common.Address) (*
 	return balance, nil
 }
 
-// SendTransaction sends a transaction
+// SendTransaction simulates a transaction and sends it only if the
+// simulation succeeds. A revert is returned as an ErrSimulation DeFiError
+// with the decoded reason.
 func (bm *RealBlockchainManager) SendTransaction(to common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
-	// Get current nonce
-	nonce, err := bm.Client.PendingNonceAt(context.Background(), bm.Address)
+	sign, err := bm.signer()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %v", err)
+		return nil, err
 	}
 
-	// Get gas price
-	gasPrice, err := bm.Client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %v", err)
+	ctx := context.Background()
+	msg := ethereum.CallMsg{From: bm.Address, To: &to, Value: value, Data: data}
+	if _, err := preflight(ctx, bm.Client, msg, nil, map[string]interface{}{"to": to.Hex()}); err != nil {
+		return nil, err
 	}
 
-	// Estimate gas
-	gasLimit, err := bm.Client.EstimateGas(context.Background(), ethereum.CallMsg{
-		From:  bm.Address,
-		To:    &to,
-		Value: value,
-		Data:  data,
+	// Build, sign and send under the next nonce
+	signedTx, err := bm.Nonces.Send(ctx, bm.Address, func(nonce uint64) (*types.Transaction, error) {
+		tx, err := bm.Gas.BuildTransaction(ctx, bm.Client, bm.ChainID, bm.Address, nonce, &to, value, data)
+		if err != nil {
+			return nil, err
+		}
+		return sign(bm.Address, tx)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+		return nil, err
 	}
 
-	// Create transaction
-	tx := types.NewTransaction(nonce, to, value, gasLimit, gasPrice, data)
+	log.Printf("Transaction sent: %s", signedTx.Hash().Hex())
+	return signedTx, nil
+}
 
-	// Sign transaction
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(bm.PrivateKey, "0x"))
+// SpeedUpTransaction re-broadcasts a stuck transaction with higher fees
+func (bm *RealBlockchainManager) SpeedUpTransaction(txHash common.Hash) (*types.Transaction, error) {
+	sign, err := bm.signer()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key: %v", err)
+		return nil, err
 	}
-
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(bm.ChainID), privateKey)
+	tx, err := bm.Nonces.SpeedUp(context.Background(), txHash, bm.Gas, sign)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		return nil, err
 	}
+	bm.Monitor.Replace(txHash, tx)
+	return tx, nil
+}
 
-	// Send transaction
-	err = bm.Client.SendTransaction(context.Background(), signedTx)
+// CancelTransaction replaces a stuck transaction with an empty transfer
+func (bm *RealBlockchainManager) CancelTransaction(txHash common.Hash) (*types.Transaction, error) {
+	sign, err := bm.signer()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := bm.Nonces.Cancel(context.Background(), txHash, bm.Gas, sign)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %v", err)
+		return nil, err
 	}
+	bm.Monitor.Replace(txHash, tx)
+	return tx, nil
+}
 
-	log.Printf("Transaction sent: %s", signedTx.Hash().Hex())
-	return signedTx, nil
+// Start checks the manager's pending transactions on every new head until
+// ctx is cancelled. Waits poll the chain themselves until it is called.
+func (bm *RealBlockchainManager) Start(ctx context.Context) {
+	bm.Monitor.Start(ctx)
 }
 
-// WaitForTransaction waits for transaction confirmation
-func (bm *RealBlockchainManager) WaitForTransaction(txHash common.Hash) (*types.Receipt, error) {
-	ctx := context.Background()
+// signer returns a signing function for the manager's key
+func (bm *RealBlockchainManager) signer() (bind.SignerFn, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(bm.PrivateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+
+	signer := types.LatestSignerForChainID(bm.ChainID)
+	return func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signedTx, err := types.SignTx(tx, signer, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+		return signedTx, nil
+	}, nil
+}
 
-	// Wait for transaction to be mined
-	receipt, err := bind.WaitMined(ctx, bm.Client, &types.Transaction{})
+// WaitForTransaction waits until a transaction, or the replacement that
+// took its nonce, has the monitor's confirmations
+func (bm *RealBlockchainManager) WaitForTransaction(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if _, err := bm.Monitor.MonitorTransaction(txHash, bm.Address); err != nil {
+		return nil, err
+	}
+	info, err := bm.Monitor.WaitSettled(ctx, txHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to wait for transaction: %v", err)
 	}
 
+	receipt, err := bm.Client.TransactionReceipt(ctx, info.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %v", err)
+	}
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return nil, fmt.Errorf("transaction failed: %s", txHash.Hex())
+		return nil, fmt.Errorf("transaction failed: %s", info.Hash.Hex())
 	}
 
 	log.Printf("Transaction confirmed in block %d", receipt.BlockNumber.Uint64())
@@ -189,10 +255,10 @@ func (bm *RealBlockchainManager) WaitForTransaction(txHash common.Hash) (*types.
 
 // packBalanceOfCall packs balanceOf function call
 func packBalanceOfCall(address common.Address) ([]byte, error) {
-	// balanceOf function selector + padded address
+	// balanceOf function selector + left-padded address
 	data := make([]byte, 36)
 	copy(data[0:4], crypto.Keccak256([]byte("balanceOf(address)"))[0:4])
-	copy(data[4:36], address.Bytes())
+	copy(data[16:36], address.Bytes())
 	return data, nil
 }
 
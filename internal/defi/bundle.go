@@ -0,0 +1,294 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BundleCall is one step of a bundle, matching Multicall3's Call3 struct
+type BundleCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// BundleResult is the outcome of one bundle step, matching Multicall3's
+// Result struct
+type BundleResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// BundleSimulation is the outcome of a bundle's pre-flight eth_call
+type BundleSimulation struct {
+	// Results holds one entry per executed call. Flash-loan bundles run
+	// their steps inside the loan, so they report the single loan call.
+	Results []BundleResult
+
+	// Profit is the change in the profit account's token balance, or nil
+	// when the bundle has no profit requirement
+	Profit *big.Int
+}
+
+// Bundle packs several contract calls into one aggregate3 call on the
+// account's bundle executor so they succeed or revert together. Steps run
+// with the executor as msg.sender, so it must hold or borrow the tokens
+// they spend and approvals are granted from its balance.
+type Bundle struct {
+	Calls []BundleCall
+
+	cm        *ContractManager
+	flashLoan *bundleFlashLoan
+	profit    *bundleProfit
+}
+
+type bundleFlashLoan struct {
+	asset  common.Address
+	amount *big.Int
+}
+
+type bundleProfit struct {
+	token   common.Address
+	account common.Address
+	minimum *big.Int
+}
+
+// SetBundleExecutor registers the account's bundle executor. Bundles leave
+// tokens with the executor and grant approvals from it, so it must be an
+// aggregate3 contract only the account can call; shared executors such as
+// Multicall3 let anyone take them and are refused.
+func (cm *ContractManager) SetBundleExecutor(address common.Address) error {
+	if address == (common.Address{}) || address == Multicall3 {
+		return fmt.Errorf("bundle executor %s is not restricted to the account", address.Hex())
+	}
+	return cm.AddRegisteredContract("bundle_executor", address, "bundle_executor")
+}
+
+// bundleExecutor returns the registered executor once it has checked that
+// the manager's account owns it
+func (cm *ContractManager) bundleExecutor() (*DeFiContract, error) {
+	executor, exists := cm.Contract("bundle_executor")
+	if !exists {
+		return nil, fmt.Errorf("no bundle executor configured")
+	}
+	if executor.Address == Multicall3 {
+		return nil, fmt.Errorf("bundle executor %s is the public Multicall3", executor.Address.Hex())
+	}
+
+	result, err := cm.CallContract("bundle_executor", BundleOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle executor owner: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no bundle executor owner returned")
+	}
+	owner, ok := result[0].(common.Address)
+	if !ok {
+		return nil, fmt.Errorf("invalid bundle executor owner format")
+	}
+	if owner != cm.Transactor.From {
+		return nil, fmt.Errorf("bundle executor %s is owned by %s, not %s", executor.Address.Hex(), owner.Hex(), cm.Transactor.From.Hex())
+	}
+	return executor, nil
+}
+
+// NewBundle starts an empty bundle sent through the manager's executor
+func (cm *ContractManager) NewBundle() *Bundle {
+	return &Bundle{cm: cm}
+}
+
+// Add appends a call to a contract registered with the manager. The call
+// reverts the whole bundle if it fails.
+func (b *Bundle) Add(contractName, method string, args ...interface{}) error {
+	contract, exists := b.cm.Contract(contractName)
+	if !exists {
+		return fmt.Errorf("contract %s not found", contractName)
+	}
+
+	data, err := contract.ABI.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("failed to pack method %s: %v", method, err)
+	}
+
+	b.AddCall(contract.Address, data, false)
+	return nil
+}
+
+// AddCall appends a call with pre-encoded calldata. If allowFailure is set
+// the bundle carries on when the call reverts.
+func (b *Bundle) AddCall(target common.Address, data []byte, allowFailure bool) {
+	b.Calls = append(b.Calls, BundleCall{Target: target, AllowFailure: allowFailure, CallData: data})
+}
+
+// Approve appends an approval of spender for amount of a registered token
+func (b *Bundle) Approve(token, spender common.Address, amount *big.Int) error {
+	name, err := b.cm.tokenContractName(token)
+	if err != nil {
+		return err
+	}
+	return b.Add(name, ERC20Approve, spender, amount)
+}
+
+// Permit adds a signed EIP-2612 permit to the bundle so a following step
+// can spend the owner's tokens without a prior approval transaction
+func (b *Bundle) Permit(permit *Permit) error {
+	name, err := b.cm.tokenContractName(permit.Token)
+	if err != nil {
+		return err
+	}
+	return b.Add(name, ERC20Permit, permit.Owner, permit.Spender, permit.Value, permit.Deadline, permit.V, permit.R, permit.S)
+}
+
+// Permit2 adds a signed Permit2 permit to the bundle
+func (b *Bundle) Permit2(permit *Permit2Approval) error {
+	return b.Add("permit2", Permit2Permit, permit.Owner, permit.single(), permit.Signature)
+}
+
+// Swap appends a single-pool exact-input swap on Uniswap V3
+func (b *Bundle) Swap(params SwapParams) error {
+	if params.AmountIn == nil || params.AmountOutMinimum == nil || params.Deadline == nil {
+		return fmt.Errorf("swap amount, minimum output and deadline are required")
+	}
+	sqrtPriceLimit := params.SqrtPriceLimitX96
+	if sqrtPriceLimit == nil {
+		sqrtPriceLimit = big.NewInt(0)
+	}
+
+	return b.Add("uniswap_v3_router", UniswapV3ExactInputSingle, exactInputSingleParams{
+		TokenIn:           params.TokenIn,
+		TokenOut:          params.TokenOut,
+		Fee:               big.NewInt(int64(params.Fee)),
+		Recipient:         params.Recipient,
+		Deadline:          params.Deadline,
+		AmountIn:          params.AmountIn,
+		AmountOutMinimum:  params.AmountOutMinimum,
+		SqrtPriceLimitX96: sqrtPriceLimit,
+	})
+}
+
+// Deposit appends an Aave V3 supply
+func (b *Bundle) Deposit(params DepositParams) error {
+	return b.Add("aave_v3_pool", AaveSupply, params.Asset, params.Amount, params.OnBehalfOf, params.ReferralCode)
+}
+
+// WithFlashLoan funds the bundle with an Aave V3 flash loan of amount of
+// asset. The executor runs the steps inside the loan callback and must end
+// with the amount plus premium to repay, or the whole bundle reverts.
+func (b *Bundle) WithFlashLoan(asset common.Address, amount *big.Int) *Bundle {
+	b.flashLoan = &bundleFlashLoan{asset: asset, amount: amount}
+	return b
+}
+
+// RequireProfit aborts the bundle unless the pre-flight simulation grows
+// account's balance of a registered token by at least minimum
+func (b *Bundle) RequireProfit(token, account common.Address, minimum *big.Int) *Bundle {
+	b.profit = &bundleProfit{token: token, account: account, minimum: minimum}
+	return b
+}
+
+// Simulate runs the bundle with eth_call at the pending block. It fails if
+// any step reverts, with an ErrSimulation DeFiError, or if the profit
+// requirement is not met.
+func (b *Bundle) Simulate(ctx context.Context) (*BundleSimulation, error) {
+	executor, calls, err := b.executorCalls()
+	if err != nil {
+		return nil, err
+	}
+
+	// The profit is read by balanceOf steps around the bundle's calls, so
+	// both balances come from the same simulated block
+	if b.profit != nil {
+		name, err := b.cm.tokenContractName(b.profit.token)
+		if err != nil {
+			return nil, err
+		}
+		token, _ := b.cm.Contract(name)
+		data, err := token.ABI.Pack(ERC20BalanceOf, b.profit.account)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack method %s: %v", ERC20BalanceOf, err)
+		}
+		balance := BundleCall{Target: b.profit.token, CallData: data}
+		calls = append(append([]BundleCall{balance}, calls...), balance)
+	}
+
+	data, err := executor.ABI.Pack(BundleAggregate3, calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack bundle: %v", err)
+	}
+	output, err := b.cm.simulate(ctx, "bundle_executor", BundleAggregate3, nil, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BundleResult
+	method := executor.ABI.Methods[BundleAggregate3]
+	if err := method.Outputs.Copy(&results, output.Outputs); err != nil {
+		return nil, fmt.Errorf("failed to unpack bundle results: %v", err)
+	}
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("bundle returned %d results for %d calls", len(results), len(calls))
+	}
+
+	simulation := &BundleSimulation{Results: results}
+	if b.profit != nil {
+		before := new(big.Int).SetBytes(results[0].ReturnData)
+		after := new(big.Int).SetBytes(results[len(results)-1].ReturnData)
+		simulation.Results = results[1 : len(results)-1]
+		simulation.Profit = after.Sub(after, before)
+		if simulation.Profit.Cmp(b.profit.minimum) < 0 {
+			return simulation, fmt.Errorf("bundle profit %s is below the minimum %s", simulation.Profit, b.profit.minimum)
+		}
+	}
+	return simulation, nil
+}
+
+// Execute simulates the bundle and sends it as one transaction only if the
+// simulation succeeds
+func (b *Bundle) Execute(ctx context.Context) (*types.Transaction, error) {
+	if _, err := b.Simulate(ctx); err != nil {
+		return nil, err
+	}
+
+	_, calls, err := b.executorCalls()
+	if err != nil {
+		return nil, err
+	}
+	return b.cm.TransactContract("bundle_executor", BundleAggregate3, big.NewInt(0), calls)
+}
+
+// executorCalls returns the executor and the calls to pass to aggregate3,
+// wrapping the bundle's steps in a flash loan when one is set
+func (b *Bundle) executorCalls() (*DeFiContract, []BundleCall, error) {
+	if len(b.Calls) == 0 {
+		return nil, nil, fmt.Errorf("bundle has no calls")
+	}
+	executor, err := b.cm.bundleExecutor()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	calls := append([]BundleCall(nil), b.Calls...)
+	if b.flashLoan == nil {
+		return executor, calls, nil
+	}
+
+	pool, exists := b.cm.Contract("aave_v3_pool")
+	if !exists {
+		return nil, nil, fmt.Errorf("contract aave_v3_pool not found")
+	}
+
+	// The executor decodes its flash loan params as abi.encode(Call3[])
+	params, err := executor.ABI.Methods[BundleAggregate3].Inputs.Pack(calls)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pack flash loan calls: %v", err)
+	}
+	data, err := pool.ABI.Pack(AaveFlashLoanSimple, executor.Address, b.flashLoan.asset, b.flashLoan.amount, params, uint16(0))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to pack method %s: %v", AaveFlashLoanSimple, err)
+	}
+	return executor, []BundleCall{{Target: pool.Address, CallData: data}}, nil
+}
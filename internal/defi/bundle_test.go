@@ -0,0 +1,201 @@
+package defi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestBundleExecutesStepsAtomically(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	ctx := context.Background()
+
+	chain.Mint(chain.USDC, chain.Executor, defitest.Ether(100))
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+
+	bundle := cm.NewBundle()
+	require.NoError(t, bundle.Approve(chain.USDC, chain.Router, defitest.Ether(100)))
+	require.NoError(t, bundle.Swap(SwapParams{
+		TokenIn:          chain.USDC,
+		TokenOut:         chain.WETH,
+		Fee:              FeeTierMedium,
+		Recipient:        chain.Account,
+		Deadline:         CreateDeadline(10),
+		AmountIn:         defitest.Ether(100),
+		AmountOutMinimum: big.NewInt(49_850_000_000_000_000),
+	}))
+	bundle.AddCall(chain.USDC, []byte{0xde, 0xad, 0xbe, 0xef}, true)
+
+	simulation, err := bundle.Simulate(ctx)
+	require.NoError(t, err)
+	require.Len(t, simulation.Results, 3)
+	assert.True(t, simulation.Results[1].Success)
+	assert.False(t, simulation.Results[2].Success)
+	assert.Nil(t, simulation.Profit)
+
+	tx, err := bundle.Execute(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	assert.Equal(t, "49850000000000000", chain.BalanceOf(chain.WETH, chain.Account).String())
+	assert.Zero(t, chain.BalanceOf(chain.USDC, chain.Executor).Sign())
+}
+
+func TestBundleDepositSuppliesToAaveV3(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	ctx := context.Background()
+
+	chain.Mint(chain.USDC, chain.Executor, defitest.Ether(100))
+
+	bundle := cm.NewBundle()
+	require.NoError(t, bundle.Approve(chain.USDC, chain.Pool, defitest.Ether(100)))
+	require.NoError(t, bundle.Deposit(DepositParams{Asset: chain.USDC, Amount: defitest.Ether(100), OnBehalfOf: chain.Executor}))
+	pool, _ := cm.Contract("aave_v3_pool")
+	assert.Equal(t, pool.ABI.Methods[AaveSupply].ID, bundle.Calls[1].CallData[:4])
+
+	tx, err := bundle.Execute(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	assert.Equal(t, defitest.Ether(100).String(), chain.PoolAccount(chain.Executor).Collateral.String())
+}
+
+func TestBundleAbortsWhenAStepReverts(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	ctx := context.Background()
+
+	chain.Mint(chain.USDC, chain.Executor, defitest.Ether(100))
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+
+	bundle := cm.NewBundle()
+	require.NoError(t, bundle.Approve(chain.USDC, chain.Router, defitest.Ether(100)))
+	require.NoError(t, bundle.Swap(SwapParams{
+		TokenIn:          chain.USDC,
+		TokenOut:         chain.WETH,
+		Fee:              FeeTierMedium,
+		Recipient:        chain.Account,
+		Deadline:         CreateDeadline(10),
+		AmountIn:         defitest.Ether(100),
+		AmountOutMinimum: big.NewInt(49_850_000_000_000_001),
+	}))
+
+	_, err := bundle.Execute(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Too little received")
+
+	// Nothing was sent
+	nonce, err := chain.Client.PendingNonceAt(ctx, chain.Account)
+	require.NoError(t, err)
+	assert.Zero(t, nonce)
+	assert.Equal(t, defitest.Ether(100).String(), chain.BalanceOf(chain.USDC, chain.Executor).String())
+}
+
+func TestBundleFlashLoanArbitrage(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	ctx := context.Background()
+
+	// WETH is cheap in the 0.05% pool and dear in the 0.3% pool
+	chain.SetPoolRate(chain.USDC, chain.WETH, uint32(FeeTierLow), big.NewInt(5e14))
+	chain.SetPoolRate(chain.WETH, chain.USDC, uint32(FeeTierMedium), defitest.Ether(2_100))
+
+	loan := defitest.Ether(1_000)
+	wethOut := big.NewInt(499_750_000_000_000_000) // 0.5 WETH less the 0.05% fee
+	maxApproval := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	arbitrage := func() *Bundle {
+		bundle := cm.NewBundle().WithFlashLoan(chain.USDC, loan)
+		require.NoError(t, bundle.Approve(chain.USDC, chain.Router, maxApproval))
+		require.NoError(t, bundle.Approve(chain.WETH, chain.Router, maxApproval))
+		require.NoError(t, bundle.Swap(SwapParams{
+			TokenIn: chain.USDC, TokenOut: chain.WETH, Fee: FeeTierLow,
+			Recipient: chain.Executor, Deadline: CreateDeadline(10), AmountIn: loan, AmountOutMinimum: wethOut,
+		}))
+		require.NoError(t, bundle.Swap(SwapParams{
+			TokenIn: chain.WETH, TokenOut: chain.USDC, Fee: FeeTierMedium,
+			Recipient: chain.Executor, Deadline: CreateDeadline(10), AmountIn: wethOut, AmountOutMinimum: big.NewInt(0),
+		}))
+		return bundle
+	}
+
+	// A pending transfer to the executor is in both balances, not profit
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1))
+	_, err := cm.TransactContract("erc20_USDC", ERC20Transfer, big.NewInt(0), chain.Executor, defitest.Ether(1))
+	require.NoError(t, err)
+
+	// 1046.326575 USDC back, less the 1000 USDC loan and its 0.05% premium
+	bundle := arbitrage().RequireProfit(chain.USDC, chain.Executor, defitest.Ether(50))
+	simulation, err := bundle.Simulate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "below the minimum")
+	require.NotNil(t, simulation)
+	assert.Equal(t, "45826575000000000000", simulation.Profit.String())
+	assert.Len(t, simulation.Results, 1)
+
+	bundle.RequireProfit(chain.USDC, chain.Executor, defitest.Ether(45))
+	tx, err := bundle.Execute(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+	assert.Equal(t, "46826575000000000000", chain.BalanceOf(chain.USDC, chain.Executor).String())
+	premium := new(big.Int).Div(new(big.Int).Mul(loan, big.NewInt(defitest.FlashLoanPremiumBps)), big.NewInt(10_000))
+	assert.Equal(t, new(big.Int).Add(defitest.Liquidity, premium).String(), chain.BalanceOf(chain.USDC, chain.Pool).String())
+
+	// Once the price gap reverses the loan cannot be repaid, even with the
+	// profit already held by the executor
+	chain.SetPoolRate(chain.WETH, chain.USDC, uint32(FeeTierMedium), defitest.Ether(1_900))
+	_, err = arbitrage().Simulate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "flash loan not repaid")
+}
+
+func TestBundleRequiresOwnedExecutor(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	ctx := context.Background()
+
+	transfer := func() *Bundle {
+		bundle := cm.NewBundle()
+		require.NoError(t, bundle.Add("erc20_USDC", ERC20Transfer, chain.Account, big.NewInt(1)))
+		return bundle
+	}
+
+	// The executor only runs calls from its owner
+	data, err := cm.Contracts["bundle_executor"].ABI.Pack(BundleAggregate3, []BundleCall{})
+	require.NoError(t, err)
+	_, err = chain.Client.CallContract(ctx, ethereum.CallMsg{From: chain.Pool, To: &chain.Executor, Data: data}, nil)
+	assert.ErrorContains(t, err, "caller is not the owner")
+
+	assert.Error(t, cm.SetBundleExecutor(Multicall3))
+	assert.Error(t, cm.SetBundleExecutor(common.Address{}))
+
+	delete(cm.Contracts, "bundle_executor")
+	_, err = transfer().Simulate(ctx)
+	assert.ErrorContains(t, err, "no bundle executor configured")
+
+	require.NoError(t, cm.AddRegisteredContract("bundle_executor", Multicall3, "bundle_executor"))
+	_, err = transfer().Execute(ctx)
+	assert.ErrorContains(t, err, "public Multicall3")
+
+	require.NoError(t, cm.SetBundleExecutor(chain.Deploy(defitest.ExecutorCode(chain.Pool))))
+	_, err = transfer().WithFlashLoan(chain.USDC, defitest.Ether(1)).Execute(ctx)
+	assert.ErrorContains(t, err, "is owned by")
+}
+
+func TestBundleRequiresCalls(t *testing.T) {
+	cm := &ContractManager{Contracts: make(map[string]*DeFiContract)}
+	bundle := cm.NewBundle()
+
+	_, err := bundle.Simulate(context.Background())
+	assert.Error(t, err)
+	assert.Error(t, bundle.Add("missing", "method"))
+	assert.Error(t, bundle.Approve(common.Address{}, common.Address{}, big.NewInt(1)))
+	assert.Error(t, bundle.Swap(SwapParams{AmountIn: big.NewInt(1)}))
+}
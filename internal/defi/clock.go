@@ -0,0 +1,53 @@
+package defi
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstracts the time source used by strategy engines
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock reads the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the default wall-clock time source
+var SystemClock Clock = systemClock{}
+
+// SimulatedClock is a manually driven clock for replaying historical data
+type SimulatedClock struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewSimulatedClock creates a simulated clock starting at the given time
+func NewSimulatedClock(start time.Time) *SimulatedClock {
+	return &SimulatedClock{now: start}
+}
+
+// Now returns the current simulated time
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now
+}
+
+// Set moves the clock to the given time
+func (c *SimulatedClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// Advance moves the clock forward by d
+func (c *SimulatedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,235 @@
+package defi
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// CompoundV3Manager handles Compound V3 (Comet) interactions. A Comet
+// market lends a single base token against collateral in other assets.
+type CompoundV3Manager struct {
+	ContractManager *ContractManager
+
+	// Market names the registered Comet contract; "compound_v3_usdc" by
+	// default
+	Market string
+
+	// Allowances, when set, approves the market for supplies and repayments
+	// that exceed the current allowance and waits for the approval first
+	Allowances *AllowanceManager
+}
+
+// NewCompoundV3Manager creates a new Compound V3 manager for the USDC market
+func NewCompoundV3Manager(cm *ContractManager) *CompoundV3Manager {
+	return &CompoundV3Manager{
+		ContractManager: cm,
+		Market:          "compound_v3_usdc",
+	}
+}
+
+// Name identifies Compound as a LendingProtocol
+func (c *CompoundV3Manager) Name() string {
+	return "compound"
+}
+
+// BaseToken returns the token the market lends
+func (c *CompoundV3Manager) BaseToken() (common.Address, error) {
+	result, err := c.ContractManager.CallContract(c.Market, CometBaseToken)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get base token: %v", err)
+	}
+	if len(result) == 0 {
+		return common.Address{}, fmt.Errorf("no base token returned")
+	}
+	base, ok := result[0].(common.Address)
+	if !ok {
+		return common.Address{}, fmt.Errorf("invalid base token format")
+	}
+	return base, nil
+}
+
+// Supply supplies amount of asset for the manager's account: the base token
+// earns interest or repays debt, any other asset is collateral
+func (c *CompoundV3Manager) Supply(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	log.Printf("Executing Compound V3 supply: %s %s", amount.String(), asset.Hex())
+
+	if err := c.approveMarket(asset, amount); err != nil {
+		return nil, err
+	}
+
+	tx, err := c.ContractManager.TransactContract(c.Market, CometSupply, big.NewInt(0), asset, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute supply: %v", err)
+	}
+
+	log.Printf("Compound V3 supply transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// Withdraw withdraws amount of asset to the manager's account. Withdrawing
+// more base token than is supplied borrows the rest.
+func (c *CompoundV3Manager) Withdraw(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	log.Printf("Executing Compound V3 withdraw: %s %s", amount.String(), asset.Hex())
+
+	tx, err := c.ContractManager.TransactContract(c.Market, CometWithdraw, big.NewInt(0), asset, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute withdraw: %v", err)
+	}
+
+	log.Printf("Compound V3 withdraw transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// Borrow borrows amount of the base token by withdrawing it against the
+// account's collateral. A base token supply is withdrawn before borrowing.
+func (c *CompoundV3Manager) Borrow(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	if err := c.requireBase(asset); err != nil {
+		return nil, err
+	}
+	return c.Withdraw(asset, amount)
+}
+
+// Repay repays amount of base token debt by supplying it; MaxRepay repays
+// the borrow balance read before sending, so interest accrued in between is
+// left outstanding
+func (c *CompoundV3Manager) Repay(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	if err := c.requireBase(asset); err != nil {
+		return nil, err
+	}
+	if amount.Cmp(MaxRepay) == 0 {
+		_, borrowed, err := c.GetBalances(c.ContractManager.Transactor.From)
+		if err != nil {
+			return nil, err
+		}
+		if borrowed.Sign() == 0 {
+			return nil, fmt.Errorf("no Compound V3 debt to repay")
+		}
+		amount = borrowed
+	}
+	return c.Supply(asset, amount)
+}
+
+// SupplyRate returns the base token's supply APY at the current utilization
+func (c *CompoundV3Manager) SupplyRate(asset common.Address) (float64, error) {
+	return c.rate(asset, CometGetSupplyRate)
+}
+
+// BorrowRate returns the base token's borrow APY at the current utilization
+func (c *CompoundV3Manager) BorrowRate(asset common.Address) (float64, error) {
+	return c.rate(asset, CometGetBorrowRate)
+}
+
+// SupplyBalance returns the manager's account's supplied base token, or its
+// collateral balance of any other asset
+func (c *CompoundV3Manager) SupplyBalance(asset common.Address) (*big.Int, error) {
+	base, err := c.BaseToken()
+	if err != nil {
+		return nil, err
+	}
+	account := c.ContractManager.Transactor.From
+	if asset != base {
+		return c.CollateralBalance(account, asset)
+	}
+	supplied, err := c.callUint(CometBalanceOf, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplied balance: %v", err)
+	}
+	return supplied, nil
+}
+
+// GetBalances returns account's supplied and borrowed base token, at most
+// one of which is non-zero
+func (c *CompoundV3Manager) GetBalances(account common.Address) (supplied, borrowed *big.Int, err error) {
+	if supplied, err = c.callUint(CometBalanceOf, account); err != nil {
+		return nil, nil, fmt.Errorf("failed to get supplied balance: %v", err)
+	}
+	if borrowed, err = c.callUint(CometBorrowBalanceOf, account); err != nil {
+		return nil, nil, fmt.Errorf("failed to get borrow balance: %v", err)
+	}
+	return supplied, borrowed, nil
+}
+
+// CollateralBalance returns how much of asset account has supplied as
+// collateral
+func (c *CompoundV3Manager) CollateralBalance(account, asset common.Address) (*big.Int, error) {
+	balance, err := c.callUint(CometCollateralBalanceOf, account, asset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collateral balance: %v", err)
+	}
+	return balance, nil
+}
+
+// rate reads the market's per-second supply or borrow rate at the current
+// utilization and compounds it every second into an APY
+func (c *CompoundV3Manager) rate(asset common.Address, method string) (float64, error) {
+	if err := c.requireBase(asset); err != nil {
+		return 0, err
+	}
+	utilization, err := c.callUint(CometGetUtilization)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get utilization: %v", err)
+	}
+
+	result, err := c.ContractManager.CallContract(c.Market, method, utilization)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rate: %v", err)
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("no rate returned")
+	}
+	perSecond, ok := result[0].(uint64)
+	if !ok {
+		return 0, fmt.Errorf("invalid rate format")
+	}
+	return math.Pow(1+float64(perSecond)/1e18, secondsPerYear) - 1, nil
+}
+
+// requireBase rejects assets the market does not lend
+func (c *CompoundV3Manager) requireBase(asset common.Address) error {
+	base, err := c.BaseToken()
+	if err != nil {
+		return err
+	}
+	if asset != base {
+		return fmt.Errorf("%s only lends %s", c.Market, base.Hex())
+	}
+	return nil
+}
+
+// callUint calls a market view method returning a uint
+func (c *CompoundV3Manager) callUint(method string, args ...interface{}) (*big.Int, error) {
+	result, err := c.ContractManager.CallContract(c.Market, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no %s returned", method)
+	}
+	value, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s format", method)
+	}
+	return value, nil
+}
+
+// approveMarket makes sure the market may spend amount of token when the
+// manager has an allowance manager
+func (c *CompoundV3Manager) approveMarket(token common.Address, amount *big.Int) error {
+	if c.Allowances == nil {
+		return nil
+	}
+
+	market, exists := c.ContractManager.Contract(c.Market)
+	if !exists {
+		return fmt.Errorf("contract %s not found", c.Market)
+	}
+	if err := c.Allowances.Require(token, market.Address, amount); err != nil {
+		return fmt.Errorf("failed to approve supply: %v", err)
+	}
+	return nil
+}
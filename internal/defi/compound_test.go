@@ -0,0 +1,83 @@
+package defi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestCompoundV3LifecycleOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	compound := NewCompoundV3Manager(cm)
+
+	base, err := compound.BaseToken()
+	require.NoError(t, err)
+	assert.Equal(t, chain.USDC, base)
+
+	chain.Mint(chain.WETH, chain.Account, defitest.Ether(1_000))
+	chain.Approve(chain.Key, chain.WETH, chain.Comet, defitest.Ether(1_000))
+	chain.Approve(chain.Key, chain.USDC, chain.Comet, MaxRepay)
+
+	// Collateral is supplied through the same call as the base token
+	tx, err := compound.Supply(chain.WETH, defitest.Ether(1_000))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	collateral, err := compound.CollateralBalance(chain.Account, chain.WETH)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(1_000).String(), collateral.String())
+
+	_, err = compound.Borrow(chain.WETH, defitest.Ether(1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only lends")
+	_, err = compound.Borrow(chain.USDC, defitest.Ether(800))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NotCollateralized")
+
+	tx, err = compound.Borrow(chain.USDC, defitest.Ether(600))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	supplied, borrowed, err := compound.GetBalances(chain.Account)
+	require.NoError(t, err)
+	assert.Zero(t, supplied.Sign())
+	assert.Equal(t, defitest.Ether(600).String(), borrowed.String())
+	assert.Equal(t, defitest.Ether(600).String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+
+	// Repaying everything, then supplying the base token earns interest
+	tx, err = compound.Repay(chain.USDC, MaxRepay)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	_, err = compound.Repay(chain.USDC, MaxRepay)
+	assert.Error(t, err)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(250))
+	tx, err = compound.Supply(chain.USDC, defitest.Ether(250))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	supplied, borrowed, err = compound.GetBalances(chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(250).String(), supplied.String())
+	assert.Zero(t, borrowed.Sign())
+}
+
+func TestCompoundV3Rates(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	compound := NewCompoundV3Manager(cm)
+
+	chain.SetCometRates(defitest.Ether(1), 1_000_000_000, 2_000_000_000)
+
+	supplyRate, err := compound.SupplyRate(chain.USDC)
+	require.NoError(t, err)
+	assert.InDelta(t, math.Exp(1e-9*secondsPerYear)-1, supplyRate, 1e-6)
+	borrowRate, err := compound.BorrowRate(chain.USDC)
+	require.NoError(t, err)
+	assert.InDelta(t, math.Exp(2e-9*secondsPerYear)-1, borrowRate, 1e-6)
+
+	_, err = compound.SupplyRate(chain.WETH)
+	assert.Error(t, err)
+}
@@ -2,13 +2,16 @@ package defi
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"log"
 	"math/big"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -23,9 +26,27 @@ type uint24 uint32
 
 // ContractManager handles interactions with DeFi smart contracts
 type ContractManager struct {
-	Client     *ethclient.Client
+	Client     Backend
 	Transactor *bind.TransactOpts
-	Contracts  map[string]*DeFiContract
+	ChainID    *big.Int
+	Gas        *GasPolicy
+	Nonces     *NonceManager
+
+	// Contracts are the registered contracts by name. Pools and tokens
+	// found at runtime are added while other goroutines read the map, so
+	// once the manager is shared use Contract and RegisterContract.
+	Contracts   map[string]*DeFiContract
+	contractsMu sync.RWMutex
+
+	// Registry supplies the ABIs and per-chain addresses of the contracts
+	// the manager starts with
+	Registry *ContractRegistry
+
+	// Monitor tracks sent transactions until they have the configured
+	// number of confirmations
+	Monitor *TransactionMonitor
+
+	key *ecdsa.PrivateKey
 }
 
 // DeFiContract represents a DeFi protocol contract
@@ -36,19 +57,32 @@ type DeFiContract struct {
 	Instance interface{}
 }
 
-// NewContractManager creates a new contract manager with real blockchain connection
-func NewContractManager(client *ethclient.Client, privateKey string) (*ContractManager, error) {
+// NewContractManager creates a new contract manager on the given backend,
+// dialing the RPC endpoint from the environment when client is nil
+func NewContractManager(client Backend, privateKey string) (*ContractManager, error) {
+	return NewContractManagerFromConfig(client, config.BlockchainConfig{PrivateKey: privateKey}, nil)
+}
+
+// NewContractManagerFromConfig creates a contract manager using the key, gas
+// settings and confirmation depth in cfg. Transaction monitor RPC calls are
+// reported to recorder, which may be nil.
+func NewContractManagerFromConfig(client Backend, cfg config.BlockchainConfig, recorder CallRecorder) (*ContractManager, error) {
+	gas, err := NewGasPolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas configuration: %v", err)
+	}
+
 	if client == nil {
 		// Create a real blockchain connection
 		rpcURL := getRPCURL()
-		var err error
-		client, err = ethclient.Dial(rpcURL)
+		ethClient, err := ethclient.Dial(rpcURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to blockchain: %v", err)
 		}
+		client = ethClient
 	}
 
-	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(cfg.PrivateKey, "0x"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %v", err)
 	}
@@ -63,207 +97,68 @@ func NewContractManager(client *ethclient.Client, privateKey string) (*ContractM
 		return nil, fmt.Errorf("failed to create transactor: %v", err)
 	}
 
-	// Get current nonce and gas price
-	address := crypto.PubkeyToAddress(key.PublicKey)
-	nonce, err := client.PendingNonceAt(context.Background(), address)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %v", err)
-	}
-
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	registry, err := loadRegistry()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %v", err)
+		return nil, fmt.Errorf("failed to load contract registry: %v", err)
 	}
 
-	transactor.Nonce = big.NewInt(int64(nonce))
-	transactor.GasPrice = gasPrice
-	transactor.GasLimit = 300000
-
+	// Nonces, fees and gas limits are set per transaction by Nonces and Gas
 	manager := &ContractManager{
 		Client:     client,
 		Transactor: transactor,
+		ChainID:    chainID,
+		Gas:        gas,
+		Nonces:     SharedNonceManager(client, chainID, transactor.From),
 		Contracts:  make(map[string]*DeFiContract),
+		Registry:   registry,
+		Monitor:    NewTransactionMonitorFromConfig(client, cfg, recorder),
+		key:        key,
 	}
 
 	// Initialize common DeFi contracts
 	if err := manager.initializeCommonContracts(); err != nil {
-		log.Printf("Warning: Failed to initialize some contracts: %v", err)
+		return nil, fmt.Errorf("failed to initialize contracts: %v", err)
+	}
+	if cfg.BundleExecutor != "" {
+		if !common.IsHexAddress(cfg.BundleExecutor) {
+			return nil, fmt.Errorf("invalid bundle executor address %q", cfg.BundleExecutor)
+		}
+		if err := manager.SetBundleExecutor(common.HexToAddress(cfg.BundleExecutor)); err != nil {
+			return nil, err
+		}
 	}
 
 	return manager, nil
 }
 
-// initializeCommonContracts initializes common DeFi contracts
+// initializeCommonContracts registers the registry's contracts for the
+// manager's chain. A chain the registry does not list is an error, since
+// another chain's addresses would send transactions to the wrong contracts.
 func (cm *ContractManager) initializeCommonContracts() error {
-	// Initialize Uniswap V2 Router
-	uniswapV2RouterABI := `[
-		{
-			"inputs": [
-				{"internalType": "uint256", "name": "amountIn", "type": "uint256"},
-				{"internalType": "address[]", "name": "path", "type": "address[]"}
-			],
-			"name": "getAmountsOut",
-			"outputs": [
-				{"internalType": "uint256[]", "name": "amounts", "type": "uint256[]"}
-			],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{"internalType": "uint256", "name": "amountOutMin", "type": "uint256"},
-				{"internalType": "address[]", "name": "path", "type": "address[]"},
-				{"internalType": "address", "name": "to", "type": "address"},
-				{"internalType": "uint256", "name": "deadline", "type": "uint256"}
-			],
-			"name": "swapExactETHForTokens",
-			"outputs": [
-				{"internalType": "uint256[]", "name": "amounts", "type": "uint256[]"}
-			],
-			"stateMutability": "payable",
-			"type": "function"
-		}
-	]`
-
-	// Initialize Uniswap V3 Router
-	uniswapV3RouterABI := `[
-		{
-			"inputs": [
-				{
-					"components": [
-						{"internalType": "address", "name": "tokenIn", "type": "address"},
-						{"internalType": "address", "name": "tokenOut", "type": "address"},
-						{"internalType": "uint24", "name": "fee", "type": "uint24"},
-						{"internalType": "address", "name": "recipient", "type": "address"},
-						{"internalType": "uint256", "name": "deadline", "type": "uint256"},
-						{"internalType": "uint256", "name": "amountIn", "type": "uint256"},
-						{"internalType": "uint256", "name": "amountOutMinimum", "type": "uint256"},
-						{"internalType": "uint160", "name": "sqrtPriceLimitX96", "type": "uint160"}
-					],
-					"internalType": "struct ISwapRouter.ExactInputSingleParams",
-					"name": "params",
-					"type": "tuple"
-				}
-			],
-			"name": "exactInputSingle",
-			"outputs": [{"internalType": "uint256", "name": "amountOut", "type": "uint256"}],
-			"stateMutability": "payable",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{
-					"components": [
-						{"internalType": "bytes", "name": "path", "type": "bytes"},
-						{"internalType": "address", "name": "recipient", "type": "address"},
-						{"internalType": "uint256", "name": "deadline", "type": "uint256"},
-						{"internalType": "uint256", "name": "amountIn", "type": "uint256"},
-						{"internalType": "uint256", "name": "amountOutMinimum", "type": "uint256"}
-					],
-					"internalType": "struct ISwapRouter.ExactInputParams",
-					"name": "params",
-					"type": "tuple"
-				}
-			],
-			"name": "exactInput",
-			"outputs": [{"internalType": "uint256", "name": "amountOut", "type": "uint256"}],
-			"stateMutability": "payable",
-			"type": "function"
-		}
-	]`
-
-	if err := cm.AddContract("uniswap_v2_router", UniswapV2Router, uniswapV2RouterABI); err != nil {
-		return fmt.Errorf("failed to add Uniswap V2 Router: %v", err)
-	}
-
-	// Initialize Uniswap V3 Router
-	if err := cm.AddContract("uniswap_v3_router", UniswapV3Router, uniswapV3RouterABI); err != nil {
-		return fmt.Errorf("failed to add Uniswap V3 Router: %v", err)
-	}
-
-	// Initialize Aave Lending Pool
-	aaveLendingPoolABI := `[
-		{
-			"inputs": [
-				{"internalType": "address", "name": "asset", "type": "address"},
-				{"internalType": "uint256", "name": "amount", "type": "uint256"},
-				{"internalType": "address", "name": "onBehalfOf", "type": "address"},
-				{"internalType": "uint16", "name": "referralCode", "type": "uint16"}
-			],
-			"name": "deposit",
-			"outputs": [],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{"internalType": "address", "name": "asset", "type": "address"},
-				{"internalType": "uint256", "name": "amount", "type": "uint256"},
-				{"internalType": "address", "name": "to", "type": "address"}
-			],
-			"name": "withdraw",
-			"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{"internalType": "address", "name": "asset", "type": "address"},
-				{"internalType": "uint256", "name": "amount", "type": "uint256"},
-				{"internalType": "uint256", "name": "interestRateMode", "type": "uint256"},
-				{"internalType": "uint16", "name": "referralCode", "type": "uint16"},
-				{"internalType": "address", "name": "onBehalfOf", "type": "address"}
-			],
-			"name": "borrow",
-			"outputs": [],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		}
-	]`
-
-	if err := cm.AddContract("aave_lending_pool", AaveLendingPool, aaveLendingPoolABI); err != nil {
-		return fmt.Errorf("failed to add Aave Lending Pool: %v", err)
-	}
-
-	// Initialize ERC20 token interface (for common tokens)
-	erc20ABI := `[
-		{
-			"inputs": [{"internalType": "address", "name": "account", "type": "address"}],
-			"name": "balanceOf",
-			"outputs": [{"internalType": "uint256", "name": "", "type": "uint256"}],
-			"stateMutability": "view",
-			"type": "function"
-		},
-		{
-			"inputs": [
-				{"internalType": "address", "name": "spender", "type": "address"},
-				{"internalType": "uint256", "name": "amount", "type": "uint256"}
-			],
-			"name": "approve",
-			"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
-			"stateMutability": "nonpayable",
-			"type": "function"
-		}
-	]`
-
-	// Add common ERC20 tokens
-	commonTokens := map[string]common.Address{
-		"USDC": common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
-		"USDT": common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"),
-		"DAI":  common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"),
-		"WETH": common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+	deployment, err := cm.Registry.Deployment(cm.ChainID.Uint64())
+	if err != nil {
+		return err
 	}
 
-	for name, address := range commonTokens {
-		if err := cm.AddContract("erc20_"+name, address, erc20ABI); err != nil {
-			log.Printf("Warning: Failed to add %s token: %v", name, err)
+	for name, contract := range deployment.Contracts {
+		if err := cm.AddRegisteredContract(name, common.HexToAddress(contract.Address), contract.ABI); err != nil {
+			return fmt.Errorf("failed to add %s: %v", name, err)
 		}
 	}
 
-	log.Printf("Initialized %d common contracts", len(cm.Contracts))
+	log.Printf("Initialized %d common contracts for %s", len(cm.Contracts), deployment.Name)
 	return nil
 }
 
+// loadRegistry loads the registry from CONTRACT_REGISTRY_DIR when it is set,
+// or the registry embedded in the package
+func loadRegistry() (*ContractRegistry, error) {
+	if dir := os.Getenv("CONTRACT_REGISTRY_DIR"); dir != "" {
+		return LoadRegistryDir(dir)
+	}
+	return DefaultRegistry()
+}
+
 // getRPCURL returns the appropriate RPC URL based on environment
 func getRPCURL() string {
 	// Check environment variables first
@@ -281,39 +176,110 @@ func getRPCURL() string {
 	return "https://eth-mainnet.g.alchemy.com/v2/demo"
 }
 
-// AddContract adds a new contract to the manager
+// AddRegisteredContract adds a contract whose ABI is in the manager's
+// registry
+func (cm *ContractManager) AddRegisteredContract(name string, address common.Address, abiName string) error {
+	contractABI, err := cm.Registry.ABI(abiName)
+	if err != nil {
+		return err
+	}
+
+	cm.contractsMu.Lock()
+	cm.Contracts[name] = &DeFiContract{
+		Name:    name,
+		Address: address,
+		ABI:     *contractABI,
+	}
+	cm.contractsMu.Unlock()
+
+	log.Printf("Added contract: %s at %s", name, address.Hex())
+	return nil
+}
+
+// AddContract adds a new contract to the manager with a raw JSON ABI
 func (cm *ContractManager) AddContract(name string, address common.Address, abiJSON string) error {
 	contractABI, err := abi.JSON(strings.NewReader(abiJSON))
 	if err != nil {
 		return fmt.Errorf("failed to parse ABI for %s: %v", name, err)
 	}
 
+	cm.contractsMu.Lock()
 	cm.Contracts[name] = &DeFiContract{
 		Name:    name,
 		Address: address,
 		ABI:     contractABI,
 	}
+	cm.contractsMu.Unlock()
 
 	log.Printf("Added contract: %s at %s", name, address.Hex())
 	return nil
 }
 
+// RegisterContract adds a contract found at runtime, such as a pool read
+// from a factory, with one of the registry's ABIs unless name is already
+// registered
+func (cm *ContractManager) RegisterContract(name string, address common.Address, abiName string) error {
+	if _, exists := cm.Contract(name); exists {
+		return nil
+	}
+	return cm.AddRegisteredContract(name, address, abiName)
+}
+
+// Contract returns the contract registered as name
+func (cm *ContractManager) Contract(name string) (*DeFiContract, bool) {
+	cm.contractsMu.RLock()
+	defer cm.contractsMu.RUnlock()
+	contract, exists := cm.Contracts[name]
+	return contract, exists
+}
+
+// registeredContracts returns a copy of the registered contracts, for
+// callers that query each one
+func (cm *ContractManager) registeredContracts() map[string]*DeFiContract {
+	cm.contractsMu.RLock()
+	defer cm.contractsMu.RUnlock()
+	contracts := make(map[string]*DeFiContract, len(cm.Contracts))
+	for name, contract := range cm.Contracts {
+		contracts[name] = contract
+	}
+	return contracts
+}
+
+// SetContractAddress points a registered contract at a different address,
+// e.g. a testnet or local deployment with the same ABI
+func (cm *ContractManager) SetContractAddress(name string, address common.Address) error {
+	contract, exists := cm.Contract(name)
+	if !exists {
+		return fmt.Errorf("contract %s not found", name)
+	}
+
+	contract.Address = address
+	log.Printf("Contract %s moved to %s", name, address.Hex())
+	return nil
+}
+
 // CallContract calls a contract method (read-only)
 func (cm *ContractManager) CallContract(contractName, method string, args ...interface{}) ([]interface{}, error) {
-	contract, exists := cm.Contracts[contractName]
+	contract, exists := cm.Contract(contractName)
 	if !exists {
 		return nil, fmt.Errorf("contract %s not found", contractName)
 	}
 
+	return cm.CallContractAt(contract.Address, &contract.ABI, method, args...)
+}
+
+// CallContractAt calls a read-only method on an unregistered contract, such
+// as a protocol's per-market token, with the given ABI
+func (cm *ContractManager) CallContractAt(address common.Address, contractABI *abi.ABI, method string, args ...interface{}) ([]interface{}, error) {
 	// Pack the method call
-	data, err := contract.ABI.Pack(method, args...)
+	data, err := contractABI.Pack(method, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack method %s: %v", method, err)
 	}
 
 	// Call the contract
 	result, err := cm.Client.CallContract(context.Background(), ethereum.CallMsg{
-		To:   &contract.Address,
+		To:   &address,
 		Data: data,
 	}, nil)
 	if err != nil {
@@ -323,7 +289,7 @@ func (cm *ContractManager) CallContract(contractName, method string, args ...int
 	// Unpack the result
 	var unpacked []interface{}
 	if len(result) > 0 {
-		unpacked, err = contract.ABI.Unpack(method, result)
+		unpacked, err = contractABI.Unpack(method, result)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unpack result: %v", err)
 		}
@@ -332,87 +298,111 @@ func (cm *ContractManager) CallContract(contractName, method string, args ...int
 	return unpacked, nil
 }
 
-// TransactContract sends a transaction to a contract
+// TransactContract sends a transaction to a contract after simulating it.
+// A call that would revert is not sent; the error is an ErrSimulation
+// DeFiError with the decoded revert reason.
 func (cm *ContractManager) TransactContract(contractName, method string, value *big.Int, args ...interface{}) (*types.Transaction, error) {
-	contract, exists := cm.Contracts[contractName]
+	tx, _, err := cm.SimulateAndTransact(contractName, method, value, args...)
+	return tx, err
+}
+
+// SimulateAndTransact is TransactContract that also returns the pre-trade
+// simulation, so the caller can inspect its outputs and balance changes
+func (cm *ContractManager) SimulateAndTransact(contractName, method string, value *big.Int, args ...interface{}) (*types.Transaction, *Simulation, error) {
+	contract, exists := cm.Contract(contractName)
 	if !exists {
-		return nil, fmt.Errorf("contract %s not found", contractName)
+		return nil, nil, fmt.Errorf("contract %s not found", contractName)
 	}
 
 	// Pack the method call
 	data, err := contract.ABI.Pack(method, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to pack method %s: %v", method, err)
+		return nil, nil, fmt.Errorf("failed to pack method %s: %v", method, err)
 	}
 
-	// Estimate gas
-	gasLimit, err := cm.Client.EstimateGas(context.Background(), ethereum.CallMsg{
-		From:  cm.Transactor.From,
-		To:    &contract.Address,
-		Value: value,
-		Data:  data,
-	})
+	ctx := context.Background()
+	simulation, err := cm.simulate(ctx, contractName, method, value, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+		return nil, nil, err
 	}
 
-	// Get current gas price
-	gasPrice, err := cm.Client.SuggestGasPrice(context.Background())
+	// Build, sign and send under the next nonce
+	signedTx, err := cm.Nonces.Send(ctx, cm.Transactor.From, func(nonce uint64) (*types.Transaction, error) {
+		tx, err := cm.Gas.BuildTransaction(ctx, cm.Client, cm.ChainID, cm.Transactor.From, nonce, &contract.Address, value, data)
+		if err != nil {
+			return nil, err
+		}
+
+		signedTx, err := cm.Transactor.Signer(cm.Transactor.From, tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		}
+		return signedTx, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %v", err)
+		return nil, simulation, err
 	}
 
-	// Create and send transaction
-	tx := types.NewTransaction(
-		cm.Transactor.Nonce.Uint64(),
-		contract.Address,
-		value,
-		gasLimit,
-		gasPrice,
-		data,
-	)
+	log.Printf("Transaction sent: %s", signedTx.Hash().Hex())
+	return signedTx, simulation, nil
+}
 
-	// Sign the transaction
-	signedTx, err := cm.Transactor.Signer(cm.Transactor.From, tx)
+// Start checks the manager's pending transactions on every new head until
+// ctx is cancelled. Waits poll the chain themselves until it is called.
+func (cm *ContractManager) Start(ctx context.Context) {
+	cm.Monitor.Start(ctx)
+}
+
+// SpeedUpTransaction re-broadcasts a stuck transaction with higher fees
+func (cm *ContractManager) SpeedUpTransaction(txHash common.Hash) (*types.Transaction, error) {
+	tx, err := cm.Nonces.SpeedUp(context.Background(), txHash, cm.Gas, cm.Transactor.Signer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		return nil, err
 	}
+	cm.Monitor.Replace(txHash, tx)
+	return tx, nil
+}
 
-	// Send the transaction
-	err = cm.Client.SendTransaction(context.Background(), signedTx)
+// CancelTransaction replaces a stuck transaction with an empty transfer
+func (cm *ContractManager) CancelTransaction(txHash common.Hash) (*types.Transaction, error) {
+	tx, err := cm.Nonces.Cancel(context.Background(), txHash, cm.Gas, cm.Transactor.Signer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %v", err)
+		return nil, err
 	}
-
-	// Increment nonce
-	cm.Transactor.Nonce.Add(cm.Transactor.Nonce, big.NewInt(1))
-
-	log.Printf("Transaction sent: %s", signedTx.Hash().Hex())
-	return signedTx, nil
+	cm.Monitor.Replace(txHash, tx)
+	return tx, nil
 }
 
 // GetTransactionReceipt gets receipt for a transaction
 func (cm *ContractManager) GetTransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
 	receipt, err := cm.Client.TransactionReceipt(context.Background(), txHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get receipt: %v", err)
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
 	}
 	return receipt, nil
 }
 
-// WaitForTransaction waits for transaction confirmation
-func (cm *ContractManager) WaitForTransaction(txHash common.Hash) (*types.Receipt, error) {
-	for {
-		receipt, err := cm.GetTransactionReceipt(txHash)
-		if err == nil {
-			return receipt, nil
-		}
-		if err != ethereum.NotFound {
-			return nil, err
-		}
-		// Wait before retrying
-		time.Sleep(5 * time.Second)
+// WaitForTransaction waits until a transaction, or the replacement that
+// took its nonce, has the monitor's confirmations and returns its receipt.
+// Reverted transactions return their receipt for the caller to check.
+func (cm *ContractManager) WaitForTransaction(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if _, err := cm.Monitor.MonitorTransaction(txHash, cm.Transactor.From); err != nil {
+		return nil, err
+	}
+	info, err := cm.Monitor.WaitSettled(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	return cm.GetTransactionReceipt(info.Hash)
+}
+
+// waitContext bounds a transaction wait by timeout, or by
+// DefaultMonitorTimeout when timeout is not positive
+func waitContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultMonitorTimeout
 	}
+	return context.WithTimeout(context.Background(), timeout)
 }
 
 // Common DeFi Contract Addresses (Ethereum Mainnet)
@@ -421,15 +411,36 @@ var (
 	UniswapV2Router  = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
 	UniswapV2Factory = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
 
+	// SushiSwap, a Uniswap V2 fork
+	SushiSwapFactory = common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac")
+
 	// Uniswap V3
 	UniswapV3Router = common.HexToAddress("0xE592427A0AEce92De3Edee1F18E0157C05861564")
+	UniswapV3Quoter = common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e")
+
+	// Uniswap V3 liquidity positions
+	UniswapV3Factory                    = common.HexToAddress("0x1F98431c8aD98523631AE2e1d3e9A2A5d04b56e4")
+	UniswapV3NonfungiblePositionManager = common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88")
 
 	// Aave V2
 	AaveLendingPool = common.HexToAddress("0x7d2768dE32b0b80b7a3454c06BdAc94A69DDc7A9")
 
+	// Aave V3
+	AaveV3Pool   = common.HexToAddress("0x87870Bca3F3fD6335C3F4ce8392D69350B4fA4E2")
+	AaveV3Oracle = common.HexToAddress("0x54586bE62E3c3580375aE3723C145253060Ca0C2")
+
+	// Multicall3, deployed at the same address on most EVM chains
+	Multicall3 = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+	// Uniswap Permit2, deployed at the same address on most EVM chains
+	Permit2 = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
 	// Compound
 	CompoundComptroller = common.HexToAddress("0x3d9819210A31b4961b30EF54bE2aeD79B9c9Cd3B")
 
+	// Compound V3 USDC market
+	CompoundV3USDC = common.HexToAddress("0xc3d688B66703497DAA19211EEdff47f25384cdc3")
+
 	// Curve
 	CurveRegistry = common.HexToAddress("0x90E00ACe148ca3b23Ac1bC8C240C2a7Dd9c2d7f5")
 )
@@ -441,19 +452,94 @@ const (
 	UniswapV2SwapExactTokensForETH = "swapExactTokensForETH"
 	UniswapV2GetAmountsOut         = "getAmountsOut"
 
+	// Uniswap V2 factory and pair ABI methods, shared by V2 forks
+	UniswapV2GetPair     = "getPair"
+	UniswapV2GetReserves = "getReserves"
+
 	// Uniswap V3 Router ABI methods
 	UniswapV3ExactInputSingle = "exactInputSingle"
 	UniswapV3ExactInput       = "exactInput"
 
+	// Uniswap V3 QuoterV2 ABI methods
+	UniswapV3QuoteExactInputSingle = "quoteExactInputSingle"
+	UniswapV3QuoteExactInput       = "quoteExactInput"
+
+	// Uniswap V3 NonfungiblePositionManager, factory and pool ABI methods
+	UniswapV3Mint              = "mint"
+	UniswapV3IncreaseLiquidity = "increaseLiquidity"
+	UniswapV3DecreaseLiquidity = "decreaseLiquidity"
+	UniswapV3Collect           = "collect"
+	UniswapV3Burn              = "burn"
+	UniswapV3Positions         = "positions"
+	UniswapV3GetPool           = "getPool"
+	UniswapV3Slot0             = "slot0"
+	UniswapV3Liquidity         = "liquidity"
+
 	// Aave ABI methods
 	AaveDeposit  = "deposit"
 	AaveWithdraw = "withdraw"
 	AaveBorrow   = "borrow"
 
+	// Aave V3 Pool ABI methods, besides withdraw and borrow
+	AaveSupply                        = "supply"
+	AaveRepay                         = "repay"
+	AaveSetUserUseReserveAsCollateral = "setUserUseReserveAsCollateral"
+	AaveSetUserEMode                  = "setUserEMode"
+	AaveGetUserEMode                  = "getUserEMode"
+	AaveSwapBorrowRateMode            = "swapBorrowRateMode"
+	AaveGetUserAccountData            = "getUserAccountData"
+	AaveGetUserConfiguration          = "getUserConfiguration"
+	AaveGetReserveData                = "getReserveData"
+	AaveGetReservesList               = "getReservesList"
+	AaveFlashLoanSimple               = "flashLoanSimple"
+	AaveFlashLoanPremiumTotal         = "FLASHLOAN_PREMIUM_TOTAL"
+
+	// Aave V3 oracle ABI methods
+	AaveGetAssetPrice = "getAssetPrice"
+
+	// Compound V3 (Comet) ABI methods
+	CometSupply              = "supply"
+	CometWithdraw            = "withdraw"
+	CometBaseToken           = "baseToken"
+	CometBalanceOf           = "balanceOf"
+	CometBorrowBalanceOf     = "borrowBalanceOf"
+	CometCollateralBalanceOf = "collateralBalanceOf"
+	CometGetUtilization      = "getUtilization"
+	CometGetSupplyRate       = "getSupplyRate"
+	CometGetBorrowRate       = "getBorrowRate"
+
+	// Curve registry and pool ABI methods
+	CurveFindPoolForCoins = "find_pool_for_coins"
+	CurveGetCoinIndices   = "get_coin_indices"
+	CurveGetNCoins        = "get_n_coins"
+	CurveGetDy            = "get_dy"
+	CurveExchange         = "exchange"
+	CurveCoins            = "coins"
+	CurveBalances         = "balances"
+	CurveA                = "A"
+	CurveFee              = "fee"
+	CurveAdminFee         = "admin_fee"
+
+	// Bundle executor ABI methods
+	BundleAggregate3 = "aggregate3"
+	BundleOwner      = "owner"
+
+	// Permit2 ABI methods
+	Permit2Allowance    = "allowance"
+	Permit2Permit       = "permit"
+	Permit2TransferFrom = "transferFrom"
+
 	// ERC20 ABI methods
-	ERC20BalanceOf = "balanceOf"
-	ERC20Transfer  = "transfer"
-	ERC20Approve   = "approve"
+	ERC20BalanceOf       = "balanceOf"
+	ERC20Decimals        = "decimals"
+	ERC20TotalSupply     = "totalSupply"
+	ERC20Transfer        = "transfer"
+	ERC20TransferFrom    = "transferFrom"
+	ERC20Approve         = "approve"
+	ERC20Allowance       = "allowance"
+	ERC20Nonces          = "nonces"
+	ERC20DomainSeparator = "DOMAIN_SEPARATOR"
+	ERC20Permit          = "permit"
 )
 
 // Example: Swap ETH for tokens on Uniswap V2
@@ -516,20 +602,10 @@ func (cm *ContractManager) UniswapV3ExactInputSingle(
 	sqrtPriceLimitX96 *big.Int,
 ) (*types.Transaction, error) {
 
-	// Create the params struct
-	params := struct {
-		TokenIn           common.Address
-		TokenOut          common.Address
-		Fee               uint24
-		Recipient         common.Address
-		Deadline          *big.Int
-		AmountIn          *big.Int
-		AmountOutMinimum  *big.Int
-		SqrtPriceLimitX96 *big.Int
-	}{
+	params := exactInputSingleParams{
 		TokenIn:           tokenIn,
 		TokenOut:          tokenOut,
-		Fee:               fee,
+		Fee:               big.NewInt(int64(fee)),
 		Recipient:         recipient,
 		Deadline:          deadline,
 		AmountIn:          amountIn,
@@ -545,6 +621,54 @@ func (cm *ContractManager) UniswapV3ExactInputSingle(
 	)
 }
 
+// exactInputSingleParams is the router's ExactInputSingleParams tuple; the
+// ABI encoder expects *big.Int for uint24
+type exactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	Deadline          *big.Int
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+// UniswapV3ExactInput executes a swap along an encoded multi-hop path on
+// Uniswap V3
+func (cm *ContractManager) UniswapV3ExactInput(
+	path []byte,
+	recipient common.Address,
+	deadline *big.Int,
+	amountIn *big.Int,
+	amountOutMinimum *big.Int,
+) (*types.Transaction, error) {
+
+	params := exactInputParams{
+		Path:             path,
+		Recipient:        recipient,
+		Deadline:         deadline,
+		AmountIn:         amountIn,
+		AmountOutMinimum: amountOutMinimum,
+	}
+
+	return cm.TransactContract(
+		"uniswap_v3_router",
+		UniswapV3ExactInput,
+		big.NewInt(0),
+		params,
+	)
+}
+
+// exactInputParams is the router's ExactInputParams tuple
+type exactInputParams struct {
+	Path             []byte
+	Recipient        common.Address
+	Deadline         *big.Int
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
 // Example: Deposit to Aave
 func (cm *ContractManager) AaveDeposit(
 	asset common.Address,
@@ -564,10 +688,15 @@ func (cm *ContractManager) AaveDeposit(
 	)
 }
 
-// GetTokenBalance gets balance of an ERC20 token
+// GetTokenBalance gets balance of an ERC20 token registered with the manager
 func (cm *ContractManager) GetTokenBalance(token, account common.Address) (*big.Int, error) {
+	name, err := cm.tokenContractName(token)
+	if err != nil {
+		return nil, err
+	}
+
 	result, err := cm.CallContract(
-		"erc20_"+token.Hex(),
+		name,
 		ERC20BalanceOf,
 		account,
 	)
@@ -586,3 +715,74 @@ func (cm *ContractManager) GetTokenBalance(token, account common.Address) (*big.
 
 	return balance, nil
 }
+
+// signTypedData signs the EIP-712 digest of a domain separator and struct
+// hash with the manager's key, returning r, s and v with v as 27 or 28
+func (cm *ContractManager) signTypedData(domain, structHash common.Hash) ([]byte, error) {
+	if cm.key == nil {
+		return nil, fmt.Errorf("contract manager has no signing key")
+	}
+
+	digest := crypto.Keccak256([]byte{0x19, 0x01}, domain.Bytes(), structHash.Bytes())
+	signature, err := crypto.Sign(digest, cm.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign typed data: %v", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// tokenUint calls an ERC20 view method returning a uint256 on any token,
+// registered or not
+func (cm *ContractManager) tokenUint(token common.Address, method string, args ...interface{}) (*big.Int, error) {
+	tokenABI, err := cm.Registry.ABI("erc20")
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cm.CallContractAt(token, tokenABI, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no %s returned by %s", method, token.Hex())
+	}
+	value, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s format", method)
+	}
+	return value, nil
+}
+
+// tokenDecimals reads the decimals of any token, registered or not
+func (cm *ContractManager) tokenDecimals(token common.Address) (int, error) {
+	tokenABI, err := cm.Registry.ABI("erc20")
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := cm.CallContractAt(token, tokenABI, ERC20Decimals)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get decimals of %s: %v", token.Hex(), err)
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("no decimals returned by %s", token.Hex())
+	}
+	decimals, ok := result[0].(uint8)
+	if !ok {
+		return 0, fmt.Errorf("invalid decimals format")
+	}
+	return int(decimals), nil
+}
+
+// tokenContractName finds the registered ERC20 contract at address
+func (cm *ContractManager) tokenContractName(token common.Address) (string, error) {
+	cm.contractsMu.RLock()
+	defer cm.contractsMu.RUnlock()
+	for name, contract := range cm.Contracts {
+		if strings.HasPrefix(name, "erc20_") && contract.Address == token {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("token %s not registered", token.Hex())
+}
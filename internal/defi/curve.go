@@ -0,0 +1,247 @@
+package defi
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// CurveManager handles Curve swaps through the pools its registry finds
+type CurveManager struct {
+	ContractManager *ContractManager
+
+	// Registry names the registered Curve registry; "curve_registry" by
+	// default
+	Registry string
+
+	// Allowances, when set, approves a pool for swaps that exceed the
+	// current allowance and waits for the approval before swapping
+	Allowances *AllowanceManager
+}
+
+// NewCurveManager creates a new Curve manager
+func NewCurveManager(cm *ContractManager) *CurveManager {
+	return &CurveManager{
+		ContractManager: cm,
+		Registry:        "curve_registry",
+	}
+}
+
+// CurvePool is a Curve pool trading a pair of coins, with the coins'
+// indices in the pool
+type CurvePool struct {
+	Address common.Address
+	I       *big.Int
+	J       *big.Int
+}
+
+// Name identifies Curve as a DEXProtocol
+func (c *CurveManager) Name() string {
+	return "curve"
+}
+
+// FindPool returns the registry's pool for swapping tokenIn to tokenOut
+func (c *CurveManager) FindPool(tokenIn, tokenOut common.Address) (*CurvePool, error) {
+	result, err := c.ContractManager.CallContract(c.Registry, CurveFindPoolForCoins, tokenIn, tokenOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Curve pool: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no pool returned")
+	}
+	address, ok := result[0].(common.Address)
+	if !ok {
+		return nil, fmt.Errorf("invalid pool format")
+	}
+	if address == (common.Address{}) {
+		return nil, fmt.Errorf("no Curve pool for %s -> %s: %w", tokenIn.Hex(), tokenOut.Hex(), ErrNoPool)
+	}
+
+	result, err = c.ContractManager.CallContract(c.Registry, CurveGetCoinIndices, address, tokenIn, tokenOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin indices: %v", err)
+	}
+	if len(result) < 3 {
+		return nil, fmt.Errorf("no coin indices returned")
+	}
+	i, iOK := result[0].(*big.Int)
+	j, jOK := result[1].(*big.Int)
+	underlying, _ := result[2].(bool)
+	if !iOK || !jOK {
+		return nil, fmt.Errorf("invalid coin indices format")
+	}
+	if underlying {
+		return nil, fmt.Errorf("pool %s only trades %s -> %s as underlying coins", address.Hex(), tokenIn.Hex(), tokenOut.Hex())
+	}
+
+	return &CurvePool{Address: address, I: i, J: j}, nil
+}
+
+// Quote returns what amountIn of tokenIn buys of tokenOut, after the pool
+// fee
+func (c *CurveManager) Quote(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	pool, err := c.FindPool(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	name, err := c.poolContract(pool.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.ContractManager.CallContract(name, CurveGetDy, pool.I, pool.J, amountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Curve quote: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no quote returned")
+	}
+	amountOut, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid quote format")
+	}
+	return amountOut, nil
+}
+
+// Swap exchanges amountIn of tokenIn for at least minAmountOut of tokenOut,
+// paid to the manager's account
+func (c *CurveManager) Swap(tokenIn, tokenOut common.Address, amountIn, minAmountOut *big.Int) (*types.Transaction, error) {
+	log.Printf("Executing Curve swap: %s -> %s (amount: %s)", tokenIn.Hex(), tokenOut.Hex(), amountIn.String())
+
+	pool, err := c.FindPool(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	name, err := c.poolContract(pool.Address)
+	if err != nil {
+		return nil, err
+	}
+	if c.Allowances != nil {
+		if err := c.Allowances.Require(tokenIn, pool.Address, amountIn); err != nil {
+			return nil, fmt.Errorf("failed to approve swap: %v", err)
+		}
+	}
+
+	tx, err := c.ContractManager.TransactContract(name, CurveExchange, big.NewInt(0), pool.I, pool.J, amountIn, minAmountOut)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute swap: %v", err)
+	}
+
+	log.Printf("Curve swap transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// curveETH is the coin address Curve pools list for native ETH
+var curveETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+// PoolState reads the registry's pool for tokenA/tokenB as swap state for
+// quoting, trading tokenA as token0. Every coin of the pool is read, so
+// quotes between two coins of a larger pool stay exact.
+func (c *CurveManager) PoolState(tokenA, tokenB common.Address) (*poolmath.CurvePair, error) {
+	pool, err := c.FindPool(tokenA, tokenB)
+	if err != nil {
+		return nil, err
+	}
+	name, err := c.poolContract(pool.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.ContractManager.CallContract(c.Registry, CurveGetNCoins, pool.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coin count: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no coin count returned")
+	}
+	// get_n_coins returns the pool's coins and underlying coins
+	counts, ok := result[0].([2]*big.Int)
+	if !ok || !counts[0].IsInt64() || counts[0].Int64() < 2 {
+		return nil, fmt.Errorf("invalid coin count format")
+	}
+
+	n := int(counts[0].Int64())
+	balances := make([]*big.Int, n)
+	decimals := make([]int, n)
+	for k := 0; k < n; k++ {
+		index := big.NewInt(int64(k))
+		coin, err := c.callAddress(name, CurveCoins, index)
+		if err != nil {
+			return nil, err
+		}
+		if balances[k], err = c.callUint(name, CurveBalances, index); err != nil {
+			return nil, err
+		}
+		decimals[k] = 18
+		if coin != curveETH {
+			if decimals[k], err = c.ContractManager.tokenDecimals(coin); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	params := make([]int64, 3)
+	for k, method := range []string{CurveA, CurveFee, CurveAdminFee} {
+		value, err := c.callUint(name, method)
+		if err != nil {
+			return nil, err
+		}
+		if !value.IsInt64() {
+			return nil, fmt.Errorf("invalid %s format", method)
+		}
+		params[k] = value.Int64()
+	}
+
+	state, err := poolmath.NewCurvePool(balances, decimals, params[0], params[1], params[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool state: %v", err)
+	}
+	return &poolmath.CurvePair{Pool: state, I: int(pool.I.Int64()), J: int(pool.J.Int64())}, nil
+}
+
+// callUint calls a pool method returning a single uint256
+func (c *CurveManager) callUint(name, method string, args ...interface{}) (*big.Int, error) {
+	result, err := c.ContractManager.CallContract(name, method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool %s: %v", method, err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no pool %s returned", method)
+	}
+	value, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid pool %s format", method)
+	}
+	return value, nil
+}
+
+// callAddress calls a pool method returning a single address
+func (c *CurveManager) callAddress(name, method string, args ...interface{}) (common.Address, error) {
+	result, err := c.ContractManager.CallContract(name, method, args...)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to get pool %s: %v", method, err)
+	}
+	if len(result) == 0 {
+		return common.Address{}, fmt.Errorf("no pool %s returned", method)
+	}
+	value, ok := result[0].(common.Address)
+	if !ok {
+		return common.Address{}, fmt.Errorf("invalid pool %s format", method)
+	}
+	return value, nil
+}
+
+// poolContract registers a pool found through the registry on first use
+// and returns its contract name
+func (c *CurveManager) poolContract(pool common.Address) (string, error) {
+	name := "curve_pool_" + strings.ToLower(pool.Hex())
+	if err := c.ContractManager.RegisterContract(name, pool, "curve_pool"); err != nil {
+		return "", err
+	}
+	return name, nil
+}
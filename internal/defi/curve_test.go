@@ -0,0 +1,52 @@
+package defi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestCurveSwapOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	curve := NewCurveManager(cm)
+
+	// USDC is coin 0 of the pool and WETH coin 1
+	pool, err := curve.FindPool(chain.WETH, chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, chain.CurvePool, pool.Address)
+	assert.Equal(t, int64(1), pool.I.Int64())
+	assert.Zero(t, pool.J.Sign())
+
+	_, err = curve.FindPool(chain.WETH, chain.Router)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Curve pool")
+
+	chain.SetCurveRate(chain.CurvePool, 1, 0, defitest.Ether(2))
+	quote, err := curve.Quote(chain.WETH, chain.USDC, defitest.Ether(10))
+	require.NoError(t, err)
+	expected := new(big.Int).Div(new(big.Int).Mul(defitest.Ether(20), big.NewInt(10_000_000_000-defitest.CurveFee)), big.NewInt(10_000_000_000))
+	assert.Equal(t, expected.String(), quote.String())
+
+	chain.Mint(chain.WETH, chain.Account, defitest.Ether(10))
+	autoCommit(t, chain, cm)
+	curve.Allowances = NewAllowanceManager(cm)
+	_, err = curve.Swap(chain.WETH, chain.USDC, defitest.Ether(10), new(big.Int).Add(quote, big.NewInt(1)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fewer coins")
+
+	// The allowance manager approved the pool before the first attempt
+	tx, err := curve.Swap(chain.WETH, chain.USDC, defitest.Ether(10), quote)
+	require.NoError(t, err)
+	receipt, err := cm.WaitForTransaction(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, quote.String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+	assert.Zero(t, chain.BalanceOf(chain.WETH, chain.Account).Sign())
+}
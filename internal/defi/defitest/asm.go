@@ -0,0 +1,236 @@
+package defitest
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// assembler builds EVM bytecode with named jump labels. The mock contracts are
+// written with it because no Solidity compiler is available to the tests.
+// Operands follow EVM stack order: the first argument of an opcode is pushed last.
+type assembler struct {
+	code   []byte
+	labels map[string]int
+	fixups map[int]string // offset of a PUSH2 operand -> label it refers to
+	fresh  int
+}
+
+func newAssembler() *assembler {
+	return &assembler{labels: make(map[string]int), fixups: make(map[int]string)}
+}
+
+func (a *assembler) op(ops ...vm.OpCode) *assembler {
+	for _, op := range ops {
+		a.code = append(a.code, byte(op))
+	}
+	return a
+}
+
+// push pushes a constant using the shortest PUSH opcode
+func (a *assembler) push(value interface{}) *assembler {
+	var b []byte
+	switch v := value.(type) {
+	case int:
+		b = big.NewInt(int64(v)).Bytes()
+	case uint64:
+		b = new(big.Int).SetUint64(v).Bytes()
+	case *big.Int:
+		b = v.Bytes()
+	case []byte:
+		b = new(big.Int).SetBytes(v).Bytes()
+	case common.Address:
+		b = new(big.Int).SetBytes(v.Bytes()).Bytes()
+	case common.Hash:
+		b = new(big.Int).SetBytes(v.Bytes()).Bytes()
+	default:
+		panic(fmt.Sprintf("defitest: cannot push %T", value))
+	}
+	if len(b) == 0 {
+		return a.op(vm.PUSH0)
+	}
+	if len(b) > 32 {
+		panic("defitest: push operand longer than 32 bytes")
+	}
+	a.code = append(a.code, byte(vm.PUSH1)+byte(len(b)-1))
+	a.code = append(a.code, b...)
+	return a
+}
+
+// newLabel returns a unique label name for macros
+func (a *assembler) newLabel(prefix string) string {
+	a.fresh++
+	return fmt.Sprintf("%s_%d", prefix, a.fresh)
+}
+
+// label marks a jump destination
+func (a *assembler) label(name string) *assembler {
+	if _, exists := a.labels[name]; exists {
+		panic("defitest: duplicate label " + name)
+	}
+	a.labels[name] = len(a.code)
+	return a.op(vm.JUMPDEST)
+}
+
+func (a *assembler) pushLabel(name string) *assembler {
+	a.code = append(a.code, byte(vm.PUSH2))
+	a.fixups[len(a.code)] = name
+	a.code = append(a.code, 0, 0)
+	return a
+}
+
+func (a *assembler) jump(name string) *assembler {
+	return a.pushLabel(name).op(vm.JUMP)
+}
+
+// jumpi jumps to name if the value on top of the stack is non-zero
+func (a *assembler) jumpi(name string) *assembler {
+	return a.pushLabel(name).op(vm.JUMPI)
+}
+
+// bytes resolves labels and returns the finished code
+func (a *assembler) bytes() []byte {
+	code := append([]byte(nil), a.code...)
+	for offset, name := range a.fixups {
+		target, ok := a.labels[name]
+		if !ok {
+			panic("defitest: undefined label " + name)
+		}
+		code[offset] = byte(target >> 8)
+		code[offset+1] = byte(target)
+	}
+	return code
+}
+
+// Calldata and memory helpers
+
+// arg pushes the i-th static ABI argument
+func (a *assembler) arg(i int) *assembler {
+	return a.push(4 + 32*i).op(vm.CALLDATALOAD)
+}
+
+// store pops the top of the stack into memory at offset
+func (a *assembler) store(offset int) *assembler {
+	return a.push(offset).op(vm.MSTORE)
+}
+
+// load pushes the word at memory offset
+func (a *assembler) load(offset int) *assembler {
+	return a.push(offset).op(vm.MLOAD)
+}
+
+// hash2 pops two words (top first) and pushes keccak256 of their concatenation
+func (a *assembler) hash2() *assembler {
+	return a.store(0x00).store(0x20).push(0x40).push(0x00).op(vm.KECCAK256)
+}
+
+// returnTop returns the top of the stack as a single ABI word
+func (a *assembler) returnTop() *assembler {
+	return a.store(0x00).push(0x20).push(0x00).op(vm.RETURN)
+}
+
+// returnWords returns count consecutive memory words starting at offset
+func (a *assembler) returnWords(offset, count int) *assembler {
+	return a.push(32 * count).push(offset).op(vm.RETURN)
+}
+
+// stop ends execution successfully without return data
+func (a *assembler) stop() *assembler {
+	return a.op(vm.STOP)
+}
+
+// require pops a condition and reverts with Error(reason) if it is zero
+func (a *assembler) require(reason string) *assembler {
+	ok := a.newLabel("ok")
+	return a.jumpi(ok).revert(reason).label(ok)
+}
+
+// revert aborts with the standard Error(string) payload. Reasons are limited
+// to 32 bytes so they fit a single word.
+func (a *assembler) revert(reason string) *assembler {
+	if len(reason) > 32 {
+		panic("defitest: revert reason longer than 32 bytes")
+	}
+	word := make([]byte, 32)
+	copy(word, reason)
+
+	errorSelector := make([]byte, 32)
+	copy(errorSelector, selector("Error(string)"))
+
+	return a.push(errorSelector).store(0x00).
+		push(0x20).store(0x04).
+		push(len(reason)).store(0x24).
+		push(word).store(0x44).
+		push(0x64).push(0x00).op(vm.REVERT)
+}
+
+// dispatch jumps to the label registered for the call's function selector and
+// reverts for unknown selectors
+func (a *assembler) dispatch(functions map[string]string) *assembler {
+	signatures := make([]string, 0, len(functions))
+	for signature := range functions {
+		signatures = append(signatures, signature)
+	}
+	sort.Strings(signatures)
+
+	a.push(0).op(vm.CALLDATALOAD).push(0xe0).op(vm.SHR)
+	for _, signature := range signatures {
+		a.op(vm.DUP1).push(selector(signature)).op(vm.EQ).jumpi(functions[signature])
+	}
+	return a.revert("unknown function")
+}
+
+// callMem is where outgoing call data is assembled
+const callMem = 0x100
+
+// call invokes a function on the address pushed by target, with arguments
+// pushed by the args callbacks, and pushes 1 if the call succeeded and
+// returned a non-zero first word (the ERC20 success convention)
+func (a *assembler) call(target func(), signature string, args ...func()) *assembler {
+	a.callData(signature, args)
+
+	// Clear the output word so a call without return data reads as failure
+	a.push(0).store(0x00)
+	a.push(0x20).push(0x00).push(4 + 32*len(args)).push(callMem).push(0)
+	target()
+	a.op(vm.GAS, vm.CALL)
+	return a.load(0x00).op(vm.ISZERO, vm.ISZERO, vm.AND)
+}
+
+// staticCall invokes a view function like call but pushes only whether the
+// call succeeded; the first returned word is left at memory 0x00
+func (a *assembler) staticCall(target func(), signature string, args ...func()) *assembler {
+	a.callData(signature, args)
+	a.push(0x20).push(0x00).push(4 + 32*len(args)).push(callMem)
+	target()
+	return a.op(vm.GAS, vm.STATICCALL)
+}
+
+// callData assembles the selector and static arguments at callMem
+func (a *assembler) callData(signature string, args []func()) {
+	word := make([]byte, 32)
+	copy(word, selector(signature))
+	a.push(word).store(callMem)
+	for i, arg := range args {
+		arg()
+		a.store(callMem + 4 + 32*i)
+	}
+}
+
+// deployCode wraps runtime code in init code that returns it
+func deployCode(runtime []byte) []byte {
+	a := newAssembler()
+	a.push(len(runtime)).op(vm.DUP1).pushLabel("runtime").push(0).op(vm.CODECOPY).
+		push(0).op(vm.RETURN)
+	a.labels["runtime"] = len(a.code)
+	return append(a.bytes(), runtime...)
+}
+
+// selector returns the 4-byte function selector for a signature
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
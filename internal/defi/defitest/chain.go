@@ -0,0 +1,411 @@
+// Package defitest provides an in-memory Ethereum chain with mock DeFi
+// contracts for exercising the defi package end-to-end without a node.
+package defitest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+)
+
+var (
+	erc20ABI       = mustParseABI(ERC20ABI)
+	swapRouterABI  = mustParseABI(SwapRouterABI)
+	lendingPoolABI = mustParseABI(LendingPoolABI)
+	cometABI       = mustParseABI(CometABI)
+	curveABI       = mustParseABI(CurveRegistryABI)
+	curvePoolABI   = mustParseABI(CurvePoolABI)
+	v2FactoryABI   = mustParseABI(V2FactoryABI)
+	v3FactoryABI   = mustParseABI(V3FactoryABI)
+	v3PoolABI      = mustParseABI(V3PoolABI)
+	positionsABI   = mustParseABI(PositionManagerABI)
+)
+
+// Liquidity is the amount of each token minted to the router, the lending
+// pool, the Comet market, the Curve pool and the position manager
+var Liquidity = Ether(1_000_000)
+
+// Ether converts a whole number of 18-decimal units to base units
+func Ether(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), wad)
+}
+
+// Chain is a simulated chain with two mock tokens, a swap router, a quoter,
+// a lending pool, a price oracle, a bundle executor owned by Account,
+// Permit2, a Comet market with USDC as its base, a Curve registry with a
+// USDC/WETH pool, a Uniswap V2 factory and a Uniswap V3 factory and position
+// manager with a 0.3% WETH/USDC pool priced 1:1 deployed; the venues are
+// funded with Liquidity of each token
+type Chain struct {
+	Backend *simulated.Backend
+	Client  simulated.Client
+	ChainID *big.Int
+
+	// Key is a funded account for the code under test. Fixture setup is sent
+	// from a separate deployer account so Key's nonces are left untouched.
+	Key     *ecdsa.PrivateKey
+	Account common.Address
+
+	WETH     common.Address
+	USDC     common.Address
+	Router   common.Address
+	Quoter   common.Address
+	Pool     common.Address
+	Executor common.Address
+	Permit2  common.Address
+	Oracle   common.Address
+
+	Comet         common.Address
+	CurveRegistry common.Address
+	CurvePool     common.Address
+
+	V2Factory common.Address
+
+	V3Factory       common.Address
+	V3Pool          common.Address
+	PositionManager common.Address
+
+	t        testing.TB
+	deployer *ecdsa.PrivateKey
+}
+
+// NewChain starts a simulated backend and deploys the mock contracts. The
+// backend is closed when the test finishes.
+func NewChain(t testing.TB) *Chain {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	deployer, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		account: types.Account{Balance: Ether(1_000)},
+		crypto.PubkeyToAddress(deployer.PublicKey): types.Account{Balance: Ether(1_000)},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	client := backend.Client()
+	chainID, err := client.ChainID(context.Background())
+	require.NoError(t, err)
+
+	c := &Chain{
+		Backend:  backend,
+		Client:   client,
+		ChainID:  chainID,
+		Key:      key,
+		Account:  account,
+		t:        t,
+		deployer: deployer,
+	}
+
+	c.WETH = c.Deploy(ERC20Code())
+	c.USDC = c.Deploy(ERC20Code())
+	c.Router = c.Deploy(SwapRouterCode())
+	c.Quoter = c.Deploy(QuoterCode(c.Router))
+	c.Pool = c.Deploy(LendingPoolCode())
+	c.Executor = c.Deploy(ExecutorCode(account))
+	c.Permit2 = c.Deploy(Permit2Code())
+	c.Oracle = c.Deploy(OracleCode())
+	c.Comet = c.Deploy(CometCode(c.USDC))
+	c.CurveRegistry = c.Deploy(CurveRegistryCode())
+	c.CurvePool = c.Deploy(CurvePoolCode(c.USDC, c.WETH))
+	c.AddCurvePool(c.CurvePool, c.USDC, c.WETH)
+	c.V2Factory = c.Deploy(V2FactoryCode())
+	c.V3Factory = c.Deploy(V3FactoryCode())
+	c.PositionManager = c.Deploy(PositionManagerCode())
+	c.V3Pool = c.AddV3Pool(c.WETH, c.USDC, 3000)
+	for _, token := range []common.Address{c.WETH, c.USDC} {
+		for _, venue := range []common.Address{c.Router, c.Pool, c.Comet, c.CurvePool, c.PositionManager} {
+			c.Mint(token, venue, Liquidity)
+		}
+	}
+
+	return c
+}
+
+// PrivateKeyHex returns Key in the hex form accepted by the defi constructors
+func (c *Chain) PrivateKeyHex() string {
+	return hex.EncodeToString(crypto.FromECDSA(c.Key))
+}
+
+// Commit mines the pending transactions into a block
+func (c *Chain) Commit() {
+	c.Backend.Commit()
+}
+
+// Reorg rewinds the chain by depth blocks. Transactions from the dropped
+// blocks return to the pending pool and are mined again by the next Commit.
+func (c *Chain) Reorg(depth uint64) {
+	c.t.Helper()
+	ctx := context.Background()
+
+	head, err := c.Client.BlockNumber(ctx)
+	require.NoError(c.t, err)
+	require.LessOrEqual(c.t, depth, head, "cannot reorg past genesis")
+	parent, err := c.Client.HeaderByNumber(ctx, new(big.Int).SetUint64(head-depth))
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.Backend.Fork(parent.Hash()))
+}
+
+// Deploy creates a contract from the deployer account and returns its address
+func (c *Chain) Deploy(code []byte) common.Address {
+	c.t.Helper()
+	receipt := c.send(c.deployer, nil, code)
+	return receipt.ContractAddress
+}
+
+// Transact sends a transaction signed by key, mines it and fails the test if
+// it reverts
+func (c *Chain) Transact(key *ecdsa.PrivateKey, to common.Address, data []byte) *types.Receipt {
+	c.t.Helper()
+	return c.send(key, &to, data)
+}
+
+// Call executes a read-only call against the latest block
+func (c *Chain) Call(to common.Address, data []byte) []byte {
+	c.t.Helper()
+	out, err := c.Client.CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: data}, nil)
+	require.NoError(c.t, err)
+	return out
+}
+
+// Mint creates amount of token for to
+func (c *Chain) Mint(token, to common.Address, amount *big.Int) {
+	c.t.Helper()
+	c.Transact(c.deployer, token, pack(c.t, erc20ABI, "mint", to, amount))
+}
+
+// Approve lets spender move amount of token on behalf of key's account
+func (c *Chain) Approve(key *ecdsa.PrivateKey, token, spender common.Address, amount *big.Int) {
+	c.t.Helper()
+	c.Transact(key, token, pack(c.t, erc20ABI, "approve", spender, amount))
+}
+
+// BalanceOf returns owner's token balance
+func (c *Chain) BalanceOf(token, owner common.Address) *big.Int {
+	c.t.Helper()
+	return c.unpackUint(erc20ABI, "balanceOf", c.Call(token, pack(c.t, erc20ABI, "balanceOf", owner)))
+}
+
+// Allowance returns how much of owner's token spender may move
+func (c *Chain) Allowance(token, owner, spender common.Address) *big.Int {
+	c.t.Helper()
+	return c.unpackUint(erc20ABI, "allowance", c.Call(token, pack(c.t, erc20ABI, "allowance", owner, spender)))
+}
+
+// SetRate sets the router's output per input token as an 18-decimal number
+func (c *Chain) SetRate(tokenIn, tokenOut common.Address, rate *big.Int) {
+	c.t.Helper()
+	c.Transact(c.deployer, c.Router, pack(c.t, swapRouterABI, "setRate", tokenIn, tokenOut, rate))
+}
+
+// SetPoolRate sets the rate of the router's tokenIn/tokenOut pool with the
+// given fee, overriding the pair rate for that pool
+func (c *Chain) SetPoolRate(tokenIn, tokenOut common.Address, fee uint32, rate *big.Int) {
+	c.t.Helper()
+	c.Transact(c.deployer, c.Router, pack(c.t, swapRouterABI, "setPoolRate", tokenIn, tokenOut, new(big.Int).SetUint64(uint64(fee)), rate))
+}
+
+// AccountData is a user's position in the mock lending pool
+type AccountData struct {
+	Collateral       *big.Int
+	Debt             *big.Int
+	AvailableBorrows *big.Int
+	HealthFactor     *big.Int
+}
+
+// PoolAccount returns user's position in the lending pool
+func (c *Chain) PoolAccount(user common.Address) AccountData {
+	c.t.Helper()
+	out, err := lendingPoolABI.Unpack("getUserAccountData",
+		c.Call(c.Pool, pack(c.t, lendingPoolABI, "getUserAccountData", user)))
+	require.NoError(c.t, err)
+	return AccountData{
+		Collateral:       out[0].(*big.Int),
+		Debt:             out[1].(*big.Int),
+		AvailableBorrows: out[2].(*big.Int),
+		HealthFactor:     out[5].(*big.Int),
+	}
+}
+
+// Reserve is a reserve listed in the mock lending pool. The pool never
+// mints its aToken or debt tokens; tests mint them to model positions.
+type Reserve struct {
+	Asset             common.Address
+	AToken            common.Address
+	StableDebtToken   common.Address
+	VariableDebtToken common.Address
+}
+
+// ReserveParams configures a listed reserve. Configuration is Aave V3's
+// reserve configuration bitmap and the rates are per-year rays. AToken, if
+// set, is used instead of a newly deployed token; pass Pool to back the
+// aToken with pool collateral.
+type ReserveParams struct {
+	ID                 uint16
+	Configuration      *big.Int
+	LiquidityRate      *big.Int
+	VariableBorrowRate *big.Int
+	StableBorrowRate   *big.Int
+	AToken             common.Address
+}
+
+// ListReserve lists asset in the lending pool with newly deployed debt
+// tokens and, unless params names one, aToken
+func (c *Chain) ListReserve(asset common.Address, params ReserveParams) Reserve {
+	c.t.Helper()
+	aToken := params.AToken
+	if aToken == (common.Address{}) {
+		aToken = c.Deploy(ERC20Code())
+	}
+	reserve := Reserve{
+		Asset:             asset,
+		AToken:            aToken,
+		StableDebtToken:   c.Deploy(ERC20Code()),
+		VariableDebtToken: c.Deploy(ERC20Code()),
+	}
+	c.Transact(c.deployer, c.Pool, pack(c.t, lendingPoolABI, "setReserve", asset, params.Configuration,
+		params.LiquidityRate, params.VariableBorrowRate, params.StableBorrowRate, big.NewInt(int64(params.ID)),
+		reserve.AToken, reserve.StableDebtToken, reserve.VariableDebtToken))
+	return reserve
+}
+
+// SetCometRates sets the Comet market's utilization and its supply and
+// borrow rates, all 18-decimal numbers with the rates per second
+func (c *Chain) SetCometRates(utilization *big.Int, supplyRate, borrowRate uint64) {
+	c.t.Helper()
+	c.Transact(c.deployer, c.Comet, pack(c.t, cometABI, "setRates", utilization, supplyRate, borrowRate))
+}
+
+// AddCurvePool registers a two-coin pool in the Curve registry
+func (c *Chain) AddCurvePool(pool, coin0, coin1 common.Address) {
+	c.t.Helper()
+	c.Transact(c.deployer, c.CurveRegistry, pack(c.t, curveABI, "addPool", pool, coin0, coin1))
+}
+
+// SetCurveRate sets the Curve pool's output of coin j per input of coin i
+// as an 18-decimal number, before the fee
+func (c *Chain) SetCurveRate(pool common.Address, i, j int64, rate *big.Int) {
+	c.t.Helper()
+	c.Transact(c.deployer, pool, pack(c.t, curvePoolABI, "setRate", big.NewInt(i), big.NewInt(j), rate))
+}
+
+// AddV2Pair deploys a Uniswap V2 pair for the token pair and registers it
+// with the factory. Its reserves are its token balances, so minting to the
+// pair funds it.
+func (c *Chain) AddV2Pair(tokenA, tokenB common.Address) common.Address {
+	c.t.Helper()
+	token0, token1 := tokenA, tokenB
+	if bytes.Compare(token0.Bytes(), token1.Bytes()) > 0 {
+		token0, token1 = token1, token0
+	}
+	pair := c.Deploy(V2PairCode(token0, token1))
+	c.Transact(c.deployer, c.V2Factory, pack(c.t, v2FactoryABI, "setPair", token0, token1, pair))
+	return pair
+}
+
+// AddV3Pool deploys a Uniswap V3 pool for the token pair and fee, registers
+// it with the factory and prices it 1:1
+func (c *Chain) AddV3Pool(tokenA, tokenB common.Address, fee int) common.Address {
+	c.t.Helper()
+	token0, token1 := tokenA, tokenB
+	if bytes.Compare(token0.Bytes(), token1.Bytes()) > 0 {
+		token0, token1 = token1, token0
+	}
+	pool := c.Deploy(V3PoolCode(token0, token1, fee))
+	c.Transact(c.deployer, c.V3Factory, pack(c.t, v3FactoryABI, "setPool", token0, token1, big.NewInt(int64(fee)), pool))
+	c.SetV3Price(pool, poolmath.Q96)
+	return pool
+}
+
+// SetV3Price sets a Uniswap V3 pool's sqrt price, as a Q64.96 number of
+// token1 per token0, and the tick it falls in
+func (c *Chain) SetV3Price(pool common.Address, sqrtPriceX96 *big.Int) {
+	c.t.Helper()
+	tick, err := poolmath.GetTickAtSqrtRatio(sqrtPriceX96)
+	require.NoError(c.t, err)
+	c.Transact(c.deployer, pool, pack(c.t, v3PoolABI, "setSlot0", sqrtPriceX96, big.NewInt(int64(tick))))
+}
+
+// SetV3Liquidity sets a Uniswap V3 pool's in-range liquidity
+func (c *Chain) SetV3Liquidity(pool common.Address, liquidity *big.Int) {
+	c.t.Helper()
+	c.Transact(c.deployer, pool, pack(c.t, v3PoolABI, "setLiquidity", liquidity))
+}
+
+// AccrueLPFees credits a position manager position with uncollected fees
+func (c *Chain) AccrueLPFees(tokenID, amount0, amount1 *big.Int) {
+	c.t.Helper()
+	c.Transact(c.deployer, c.PositionManager, pack(c.t, positionsABI, "accrueFees", tokenID, amount0, amount1))
+}
+
+func (c *Chain) send(key *ecdsa.PrivateKey, to *common.Address, data []byte) *types.Receipt {
+	c.t.Helper()
+	ctx := context.Background()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	nonce, err := c.Client.PendingNonceAt(ctx, from)
+	require.NoError(c.t, err)
+	gas, err := c.Client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: to, Data: data})
+	require.NoError(c.t, err)
+	tip, err := c.Client.SuggestGasTipCap(ctx)
+	require.NoError(c.t, err)
+	head, err := c.Client.HeaderByNumber(ctx, nil)
+	require.NoError(c.t, err)
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(c.ChainID), &types.DynamicFeeTx{
+		ChainID:   c.ChainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip),
+		Gas:       gas,
+		To:        to,
+		Data:      data,
+	})
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.Client.SendTransaction(ctx, tx))
+	c.Commit()
+
+	receipt, err := c.Client.TransactionReceipt(ctx, tx.Hash())
+	require.NoError(c.t, err)
+	require.Equal(c.t, types.ReceiptStatusSuccessful, receipt.Status, "fixture transaction reverted")
+	return receipt
+}
+
+func (c *Chain) unpackUint(contract abi.ABI, method string, data []byte) *big.Int {
+	c.t.Helper()
+	out, err := contract.Unpack(method, data)
+	require.NoError(c.t, err)
+	return out[0].(*big.Int)
+}
+
+func pack(t testing.TB, contract abi.ABI, method string, args ...interface{}) []byte {
+	t.Helper()
+	data, err := contract.Pack(method, args...)
+	require.NoError(t, err)
+	return data
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic("defitest: invalid ABI: " + err.Error())
+	}
+	return parsed
+}
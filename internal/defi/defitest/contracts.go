@@ -0,0 +1,1577 @@
+package defitest
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+)
+
+// ABIs of the mock contracts. They use the same signatures as the mainnet
+// contracts registered by defi.ContractManager so the production code paths
+// can be pointed at them unchanged.
+const (
+	ERC20ABI = `[
+		{"name":"balanceOf","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"allowance","type":"function","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+		{"name":"totalSupply","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"transfer","type":"function","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"name":"transferFrom","type":"function","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"name":"approve","type":"function","stateMutability":"nonpayable","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"name":"mint","type":"function","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"name":"nonces","type":"function","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"DOMAIN_SEPARATOR","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+		{"name":"permit","type":"function","stateMutability":"nonpayable","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"outputs":[]},
+		{"name":"Transfer","type":"event","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+		{"name":"Approval","type":"event","anonymous":false,"inputs":[{"name":"owner","type":"address","indexed":true},{"name":"spender","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}
+	]`
+
+	SwapRouterABI = `[
+		{"name":"exactInputSingle","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],"outputs":[{"name":"amountOut","type":"uint256"}]},
+		{"name":"exactInput","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"path","type":"bytes"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"}]}],"outputs":[{"name":"amountOut","type":"uint256"}]},
+		{"name":"getAmountOut","type":"function","stateMutability":"view","inputs":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"amountIn","type":"uint256"}],"outputs":[{"name":"amountOut","type":"uint256"}]},
+		{"name":"setRate","type":"function","stateMutability":"nonpayable","inputs":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"rate","type":"uint256"}],"outputs":[]},
+		{"name":"setPoolRate","type":"function","stateMutability":"nonpayable","inputs":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"fee","type":"uint24"},{"name":"rate","type":"uint256"}],"outputs":[]}
+	]`
+
+	QuoterABI = `[
+		{"name":"quoteExactInputSingle","type":"function","stateMutability":"nonpayable","inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"amountIn","type":"uint256"},{"name":"fee","type":"uint24"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],"outputs":[{"name":"amountOut","type":"uint256"},{"name":"sqrtPriceX96After","type":"uint160"},{"name":"initializedTicksCrossed","type":"uint32"},{"name":"gasEstimate","type":"uint256"}]},
+		{"name":"quoteExactInput","type":"function","stateMutability":"nonpayable","inputs":[{"name":"path","type":"bytes"},{"name":"amountIn","type":"uint256"}],"outputs":[{"name":"amountOut","type":"uint256"},{"name":"sqrtPriceX96AfterList","type":"uint160[]"},{"name":"initializedTicksCrossedList","type":"uint32[]"},{"name":"gasEstimate","type":"uint256"}]}
+	]`
+
+	LendingPoolABI = `[
+		{"name":"deposit","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"onBehalfOf","type":"address"},{"name":"referralCode","type":"uint16"}],"outputs":[]},
+		{"name":"withdraw","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"to","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"borrow","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"interestRateMode","type":"uint256"},{"name":"referralCode","type":"uint16"},{"name":"onBehalfOf","type":"address"}],"outputs":[]},
+		{"name":"repay","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"rateMode","type":"uint256"},{"name":"onBehalfOf","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"getUserAccountData","type":"function","stateMutability":"view","inputs":[{"name":"user","type":"address"}],"outputs":[{"name":"totalCollateralETH","type":"uint256"},{"name":"totalDebtETH","type":"uint256"},{"name":"availableBorrowsETH","type":"uint256"},{"name":"currentLiquidationThreshold","type":"uint256"},{"name":"ltv","type":"uint256"},{"name":"healthFactor","type":"uint256"}]},
+		{"name":"flashLoanSimple","type":"function","stateMutability":"nonpayable","inputs":[{"name":"receiverAddress","type":"address"},{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"params","type":"bytes"},{"name":"referralCode","type":"uint16"}],"outputs":[]},
+		{"name":"supply","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"onBehalfOf","type":"address"},{"name":"referralCode","type":"uint16"}],"outputs":[]},
+		{"name":"setUserUseReserveAsCollateral","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"useAsCollateral","type":"bool"}],"outputs":[]},
+		{"name":"setUserEMode","type":"function","stateMutability":"nonpayable","inputs":[{"name":"categoryId","type":"uint8"}],"outputs":[]},
+		{"name":"getUserEMode","type":"function","stateMutability":"view","inputs":[{"name":"user","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"swapBorrowRateMode","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"interestRateMode","type":"uint256"}],"outputs":[]},
+		{"name":"getUserConfiguration","type":"function","stateMutability":"view","inputs":[{"name":"user","type":"address"}],"outputs":[{"name":"","type":"tuple","components":[{"name":"data","type":"uint256"}]}]},
+		{"name":"getReserveData","type":"function","stateMutability":"view","inputs":[{"name":"asset","type":"address"}],"outputs":[{"name":"","type":"tuple","components":[{"name":"configuration","type":"tuple","components":[{"name":"data","type":"uint256"}]},{"name":"liquidityIndex","type":"uint128"},{"name":"currentLiquidityRate","type":"uint128"},{"name":"variableBorrowIndex","type":"uint128"},{"name":"currentVariableBorrowRate","type":"uint128"},{"name":"currentStableBorrowRate","type":"uint128"},{"name":"lastUpdateTimestamp","type":"uint40"},{"name":"id","type":"uint16"},{"name":"aTokenAddress","type":"address"},{"name":"stableDebtTokenAddress","type":"address"},{"name":"variableDebtTokenAddress","type":"address"},{"name":"interestRateStrategyAddress","type":"address"},{"name":"accruedToTreasury","type":"uint128"},{"name":"unbacked","type":"uint128"},{"name":"isolationModeTotalDebt","type":"uint128"}]}]},
+		{"name":"getReservesList","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]},
+		{"name":"FLASHLOAN_PREMIUM_TOTAL","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint128"}]},
+		{"name":"setReserve","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"configuration","type":"uint256"},{"name":"liquidityRate","type":"uint256"},{"name":"variableBorrowRate","type":"uint256"},{"name":"stableBorrowRate","type":"uint256"},{"name":"id","type":"uint256"},{"name":"aToken","type":"address"},{"name":"stableDebtToken","type":"address"},{"name":"variableDebtToken","type":"address"}],"outputs":[]}
+	]`
+
+	Permit2ABI = `[
+		{"name":"DOMAIN_SEPARATOR","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+		{"name":"allowance","type":"function","stateMutability":"view","inputs":[{"name":"user","type":"address"},{"name":"token","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"amount","type":"uint160"},{"name":"expiration","type":"uint48"},{"name":"nonce","type":"uint48"}]},
+		{"name":"permit","type":"function","stateMutability":"nonpayable","inputs":[{"name":"owner","type":"address"},{"name":"permitSingle","type":"tuple","components":[{"name":"details","type":"tuple","components":[{"name":"token","type":"address"},{"name":"amount","type":"uint160"},{"name":"expiration","type":"uint48"},{"name":"nonce","type":"uint48"}]},{"name":"spender","type":"address"},{"name":"sigDeadline","type":"uint256"}]},{"name":"signature","type":"bytes"}],"outputs":[]},
+		{"name":"transferFrom","type":"function","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint160"},{"name":"token","type":"address"}],"outputs":[]}
+	]`
+
+	CometABI = `[
+		{"name":"baseToken","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+		{"name":"supply","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"name":"withdraw","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"name":"balanceOf","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"borrowBalanceOf","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"collateralBalanceOf","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"asset","type":"address"}],"outputs":[{"name":"","type":"uint128"}]},
+		{"name":"getUtilization","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"getSupplyRate","type":"function","stateMutability":"view","inputs":[{"name":"utilization","type":"uint256"}],"outputs":[{"name":"","type":"uint64"}]},
+		{"name":"getBorrowRate","type":"function","stateMutability":"view","inputs":[{"name":"utilization","type":"uint256"}],"outputs":[{"name":"","type":"uint64"}]},
+		{"name":"setRates","type":"function","stateMutability":"nonpayable","inputs":[{"name":"utilization","type":"uint256"},{"name":"supplyRate","type":"uint64"},{"name":"borrowRate","type":"uint64"}],"outputs":[]}
+	]`
+
+	CurveRegistryABI = `[
+		{"name":"find_pool_for_coins","type":"function","stateMutability":"view","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"}],"outputs":[{"name":"","type":"address"}]},
+		{"name":"get_coin_indices","type":"function","stateMutability":"view","inputs":[{"name":"_pool","type":"address"},{"name":"_from","type":"address"},{"name":"_to","type":"address"}],"outputs":[{"name":"","type":"int128"},{"name":"","type":"int128"},{"name":"","type":"bool"}]},
+		{"name":"get_n_coins","type":"function","stateMutability":"view","inputs":[{"name":"_pool","type":"address"}],"outputs":[{"name":"","type":"uint256[2]"}]},
+		{"name":"addPool","type":"function","stateMutability":"nonpayable","inputs":[{"name":"pool","type":"address"},{"name":"coin0","type":"address"},{"name":"coin1","type":"address"}],"outputs":[]}
+	]`
+
+	CurvePoolABI = `[
+		{"name":"coins","type":"function","stateMutability":"view","inputs":[{"name":"i","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+		{"name":"balances","type":"function","stateMutability":"view","inputs":[{"name":"i","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"A","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"fee","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"admin_fee","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"get_dy","type":"function","stateMutability":"view","inputs":[{"name":"i","type":"int128"},{"name":"j","type":"int128"},{"name":"dx","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"exchange","type":"function","stateMutability":"nonpayable","inputs":[{"name":"i","type":"int128"},{"name":"j","type":"int128"},{"name":"dx","type":"uint256"},{"name":"min_dy","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"setRate","type":"function","stateMutability":"nonpayable","inputs":[{"name":"i","type":"int128"},{"name":"j","type":"int128"},{"name":"rate","type":"uint256"}],"outputs":[]}
+	]`
+
+	PositionManagerABI = `[
+		{"name":"mint","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"token0","type":"address"},{"name":"token1","type":"address"},{"name":"fee","type":"uint24"},{"name":"tickLower","type":"int24"},{"name":"tickUpper","type":"int24"},{"name":"amount0Desired","type":"uint256"},{"name":"amount1Desired","type":"uint256"},{"name":"amount0Min","type":"uint256"},{"name":"amount1Min","type":"uint256"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"}]}],"outputs":[{"name":"tokenId","type":"uint256"},{"name":"liquidity","type":"uint128"},{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+		{"name":"increaseLiquidity","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenId","type":"uint256"},{"name":"amount0Desired","type":"uint256"},{"name":"amount1Desired","type":"uint256"},{"name":"amount0Min","type":"uint256"},{"name":"amount1Min","type":"uint256"},{"name":"deadline","type":"uint256"}]}],"outputs":[{"name":"liquidity","type":"uint128"},{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+		{"name":"decreaseLiquidity","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenId","type":"uint256"},{"name":"liquidity","type":"uint128"},{"name":"amount0Min","type":"uint256"},{"name":"amount1Min","type":"uint256"},{"name":"deadline","type":"uint256"}]}],"outputs":[{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+		{"name":"collect","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenId","type":"uint256"},{"name":"recipient","type":"address"},{"name":"amount0Max","type":"uint128"},{"name":"amount1Max","type":"uint128"}]}],"outputs":[{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+		{"name":"burn","type":"function","stateMutability":"payable","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[]},
+		{"name":"positions","type":"function","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"nonce","type":"uint96"},{"name":"operator","type":"address"},{"name":"token0","type":"address"},{"name":"token1","type":"address"},{"name":"fee","type":"uint24"},{"name":"tickLower","type":"int24"},{"name":"tickUpper","type":"int24"},{"name":"liquidity","type":"uint128"},{"name":"feeGrowthInside0LastX128","type":"uint256"},{"name":"feeGrowthInside1LastX128","type":"uint256"},{"name":"tokensOwed0","type":"uint128"},{"name":"tokensOwed1","type":"uint128"}]},
+		{"name":"accrueFees","type":"function","stateMutability":"nonpayable","inputs":[{"name":"tokenId","type":"uint256"},{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}],"outputs":[]},
+		{"name":"IncreaseLiquidity","type":"event","anonymous":false,"inputs":[{"name":"tokenId","type":"uint256","indexed":true},{"name":"liquidity","type":"uint128","indexed":false},{"name":"amount0","type":"uint256","indexed":false},{"name":"amount1","type":"uint256","indexed":false}]}
+	]`
+
+	V2FactoryABI = `[
+		{"name":"getPair","type":"function","stateMutability":"view","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"}],"outputs":[{"name":"pair","type":"address"}]},
+		{"name":"setPair","type":"function","stateMutability":"nonpayable","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"},{"name":"pair","type":"address"}],"outputs":[]}
+	]`
+
+	V2PairABI = `[
+		{"name":"getReserves","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}]},
+		{"name":"token0","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+		{"name":"token1","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+	]`
+
+	V3FactoryABI = `[
+		{"name":"getPool","type":"function","stateMutability":"view","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"},{"name":"fee","type":"uint24"}],"outputs":[{"name":"pool","type":"address"}]},
+		{"name":"setPool","type":"function","stateMutability":"nonpayable","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"},{"name":"fee","type":"uint24"},{"name":"pool","type":"address"}],"outputs":[]}
+	]`
+
+	V3PoolABI = `[
+		{"name":"slot0","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"},{"name":"observationIndex","type":"uint16"},{"name":"observationCardinality","type":"uint16"},{"name":"observationCardinalityNext","type":"uint16"},{"name":"feeProtocol","type":"uint8"},{"name":"unlocked","type":"bool"}]},
+		{"name":"token0","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+		{"name":"token1","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+		{"name":"fee","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint24"}]},
+		{"name":"tickSpacing","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"int24"}]},
+		{"name":"liquidity","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint128"}]},
+		{"name":"setSlot0","type":"function","stateMutability":"nonpayable","inputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"}],"outputs":[]},
+		{"name":"setLiquidity","type":"function","stateMutability":"nonpayable","inputs":[{"name":"liquidity","type":"uint128"}],"outputs":[]}
+	]`
+
+	ExecutorABI = `[
+		{"name":"aggregate3","type":"function","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]},
+		{"name":"executeOperation","type":"function","stateMutability":"nonpayable","inputs":[{"name":"asset","type":"address"},{"name":"amount","type":"uint256"},{"name":"premium","type":"uint256"},{"name":"initiator","type":"address"},{"name":"params","type":"bytes"}],"outputs":[{"name":"","type":"bool"}]},
+		{"name":"owner","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]}
+	]`
+)
+
+// QuoteGasPerHop is the gas the mock quoter estimates for each pool a swap
+// crosses
+const QuoteGasPerHop = 100_000
+
+// Lending pool risk parameters and flash loan fee, in basis points
+const (
+	PoolLTV                  = 7500
+	PoolLiquidationThreshold = 8000
+	FlashLoanPremiumBps      = 5
+)
+
+// Mock Curve pool parameters: the swap fee and the admin's share of it with
+// 1e10 precision, 0.04% and 50%, and the amplification coefficient
+const (
+	CurveFee      = 4_000_000
+	CurveAdminFee = 5_000_000_000
+	CurveA        = 200
+)
+
+// Memory slots for function locals; 0x00-0x3f is hashing scratch space and
+// callMem holds outgoing call data.
+func local(i int) int {
+	return 0x200 + 32*i
+}
+
+var (
+	maxUint256    = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	maxUint160    = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+	wad           = big.NewInt(1e18)
+	thresholdWad  = new(big.Int).Div(new(big.Int).Mul(wad, big.NewInt(PoolLiquidationThreshold)), big.NewInt(10_000))
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	approvalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+	nonceTag      = crypto.Keccak256Hash([]byte("nonces"))
+	reservesTag   = crypto.Keccak256Hash([]byte("reserves"))
+	supplyTag     = crypto.Keccak256Hash([]byte("totalSupply"))
+	ray           = new(big.Int).Exp(big.NewInt(10), big.NewInt(27), nil)
+	positionTag   = crypto.Keccak256Hash([]byte("positions"))
+	increaseTopic = crypto.Keccak256Hash([]byte("IncreaseLiquidity(uint256,uint128,uint256,uint256)"))
+
+	// EIP-712 type hashes for EIP-2612 permits and Permit2
+	domainTypehash        = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	permitTypehash        = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	permit2DomainTypehash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,uint256 chainId,address verifyingContract)"))
+	permitDetailsTypehash = crypto.Keccak256Hash([]byte("PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)"))
+	permitSingleTypehash  = crypto.Keccak256Hash([]byte("PermitSingle(PermitDetails details,address spender,uint256 sigDeadline)PermitDetails(address token,uint160 amount,uint48 expiration,uint48 nonce)"))
+)
+
+// ERC20Code returns deployment code for a mintable ERC20 token with 18
+// decimals and EIP-2612 permits under the domain name "Mock Token", version
+// "1". Anyone may mint; an allowance of 2^256-1 is never decremented.
+func ERC20Code() []byte {
+	var (
+		from, to, amount = local(0), local(1), local(2)
+		allowanceSlot    = local(3)
+		allowance        = local(4)
+		balance          = local(5)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"balanceOf(address)":                    "balanceOf",
+		"allowance(address,address)":            "allowance",
+		"decimals()":                            "decimals",
+		"totalSupply()":                         "totalSupply",
+		"transfer(address,uint256)":             "transfer",
+		"transferFrom(address,address,uint256)": "transferFrom",
+		"approve(address,uint256)":              "approve",
+		"mint(address,uint256)":                 "mint",
+		"nonces(address)":                       "nonces",
+		"DOMAIN_SEPARATOR()":                    "DOMAIN_SEPARATOR",
+		"permit(address,address,uint256,uint256,uint8,bytes32,bytes32)": "permit",
+	})
+
+	// Balances live at slot = address, allowances at keccak(owner, spender)
+	// and the total supply at supplyTag
+	a.label("balanceOf").arg(0).op(vm.SLOAD).returnTop()
+
+	a.label("allowance").arg(1).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("decimals").push(18).returnTop()
+
+	a.label("totalSupply").push(supplyTag).op(vm.SLOAD).returnTop()
+
+	a.label("approve").
+		arg(1).arg(0).op(vm.CALLER).hash2().op(vm.SSTORE).
+		arg(1).store(0x00).
+		arg(0).op(vm.CALLER).push(approvalTopic).push(0x20).push(0x00).op(vm.LOG3).
+		push(1).returnTop()
+
+	a.label("transfer").
+		op(vm.CALLER).store(from).arg(0).store(to).arg(1).store(amount).
+		jump("move")
+
+	a.label("transferFrom").
+		arg(0).store(from).arg(1).store(to).arg(2).store(amount).
+		op(vm.CALLER).load(from).hash2().store(allowanceSlot).
+		load(allowanceSlot).op(vm.SLOAD).store(allowance).
+		load(allowance).push(maxUint256).op(vm.EQ).jumpi("move").
+		load(amount).load(allowance).op(vm.LT, vm.ISZERO).require("ERC20: insufficient allowance").
+		load(amount).load(allowance).op(vm.SUB).load(allowanceSlot).op(vm.SSTORE).
+		jump("move")
+
+	// move transfers amount from -> to, emits Transfer and returns true
+	a.label("move").
+		load(from).op(vm.SLOAD).store(balance).
+		load(amount).load(balance).op(vm.LT, vm.ISZERO).require("ERC20: insufficient balance").
+		load(amount).load(balance).op(vm.SUB).load(from).op(vm.SSTORE).
+		load(amount).load(to).op(vm.SLOAD).op(vm.ADD).load(to).op(vm.SSTORE).
+		load(amount).store(0x00).
+		load(to).load(from).push(transferTopic).push(0x20).push(0x00).op(vm.LOG3).
+		push(1).returnTop()
+
+	a.label("mint").
+		arg(1).arg(0).op(vm.SLOAD).op(vm.ADD).arg(0).op(vm.SSTORE).
+		arg(1).push(supplyTag).op(vm.SLOAD).op(vm.ADD).push(supplyTag).op(vm.SSTORE).
+		arg(1).store(0x00).
+		arg(0).push(0).push(transferTopic).push(0x20).push(0x00).op(vm.LOG3).
+		stop()
+
+	a.permitMethods("Mock Token", func() { a.arg(1).arg(0).hash2() })
+
+	return deployCode(a.bytes())
+}
+
+// Permit2Code returns deployment code for the allowance half of Uniswap's
+// Permit2: signed PermitSingle approvals and transferFrom by the approved
+// spender. Errors are reverted as Error(string) with Permit2's error names.
+func Permit2Code() []byte {
+	var (
+		base                = local(0)
+		amount, expiration  = local(1), local(2)
+		nonce               = local(3)
+		detailsHash, domain = local(4), local(5)
+		structHash          = local(6)
+		signature           = local(7)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"DOMAIN_SEPARATOR()":                 "DOMAIN_SEPARATOR",
+		"allowance(address,address,address)": "allowance",
+		"permit(address,((address,uint160,uint48,uint48),address,uint256),bytes)": "permit",
+		"transferFrom(address,address,uint160,address)":                           "transferFrom",
+	})
+
+	domainSeparator := func() {
+		a.hashWords(
+			func() { a.push(permit2DomainTypehash) },
+			func() { a.push(crypto.Keccak256Hash([]byte("Permit2"))) },
+			func() { a.op(vm.CHAINID) },
+			func() { a.op(vm.ADDRESS) },
+		)
+	}
+
+	a.label("DOMAIN_SEPARATOR")
+	domainSeparator()
+	a.returnTop()
+
+	// Allowances live at keccak(keccak(owner, token), spender) as amount,
+	// expiration and nonce in consecutive slots
+	a.label("allowance").
+		arg(2).arg(1).arg(0).hash2().hash2().store(base).
+		load(base).op(vm.SLOAD).store(amount).
+		load(base).push(1).op(vm.ADD).op(vm.SLOAD).store(expiration).
+		load(base).push(2).op(vm.ADD).op(vm.SLOAD).store(nonce).
+		returnWords(amount, 3)
+
+	// permit's tuple is static, so its fields are arguments 1-6 and the
+	// signature offset is argument 7
+	a.label("permit").
+		op(vm.TIMESTAMP).arg(6).op(vm.LT, vm.ISZERO).require("SignatureExpired").
+		arg(5).arg(1).arg(0).hash2().hash2().store(base).
+		load(base).push(2).op(vm.ADD).op(vm.SLOAD).arg(4).op(vm.EQ).require("InvalidNonce")
+	a.hashWords(
+		func() { a.push(permitDetailsTypehash) },
+		func() { a.arg(1) },
+		func() { a.arg(2) },
+		func() { a.arg(3) },
+		func() { a.arg(4) },
+	).store(detailsHash)
+	a.hashWords(
+		func() { a.push(permitSingleTypehash) },
+		func() { a.load(detailsHash) },
+		func() { a.arg(5) },
+		func() { a.arg(6) },
+	).store(structHash)
+	domainSeparator()
+	a.store(domain)
+
+	// The signature is r, s and a one-byte v
+	a.arg(7).push(4).op(vm.ADD).store(signature).
+		load(signature).op(vm.CALLDATALOAD).push(65).op(vm.EQ).require("InvalidSignatureLength")
+	a.recoverSigner(domain, structHash,
+		func() { a.load(signature).push(0x60).op(vm.ADD).op(vm.CALLDATALOAD).push(0).op(vm.BYTE) },
+		func() { a.load(signature).push(0x20).op(vm.ADD).op(vm.CALLDATALOAD) },
+		func() { a.load(signature).push(0x40).op(vm.ADD).op(vm.CALLDATALOAD) },
+	).op(vm.DUP1).arg(0).op(vm.EQ).op(vm.SWAP1, vm.ISZERO, vm.ISZERO, vm.AND).require("InvalidSigner").
+		arg(2).load(base).op(vm.SSTORE).
+		arg(3).load(base).push(1).op(vm.ADD).op(vm.SSTORE).
+		arg(4).push(1).op(vm.ADD).load(base).push(2).op(vm.ADD).op(vm.SSTORE).
+		stop()
+
+	// transferFrom spends the caller's allowance from the owner; a maximum
+	// amount is never decremented
+	a.label("transferFrom").
+		op(vm.CALLER).arg(3).arg(0).hash2().hash2().store(base).
+		op(vm.TIMESTAMP).load(base).push(1).op(vm.ADD).op(vm.SLOAD).op(vm.LT, vm.ISZERO).require("AllowanceExpired").
+		load(base).op(vm.SLOAD).store(amount).
+		load(amount).push(maxUint160).op(vm.EQ).jumpi("transfer").
+		arg(2).load(amount).op(vm.LT, vm.ISZERO).require("InsufficientAllowance").
+		arg(2).load(amount).op(vm.SUB).load(base).op(vm.SSTORE).
+		label("transfer")
+	a.call(func() { a.arg(3) }, "transferFrom(address,address,uint256)",
+		func() { a.arg(0) },
+		func() { a.arg(1) },
+		func() { a.arg(2) },
+	).require("TRANSFER_FROM_FAILED").stop()
+
+	return deployCode(a.bytes())
+}
+
+// eip712Mem is scratch memory for EIP-712 hashing and signature recovery
+const eip712Mem = 0x400
+
+// hashWords pushes the keccak256 of the words pushed by the callbacks
+func (a *assembler) hashWords(words ...func()) *assembler {
+	for i, word := range words {
+		word()
+		a.store(eip712Mem + 32*i)
+	}
+	return a.push(32 * len(words)).push(eip712Mem).op(vm.KECCAK256)
+}
+
+// recoverSigner pushes the address that signed the EIP-712 digest of the
+// domain separator and struct hash held at the given memory offsets, or 0
+// if the signature is invalid
+func (a *assembler) recoverSigner(domain, structHash int, v, r, s func()) *assembler {
+	prefix := make([]byte, 32)
+	prefix[0], prefix[1] = 0x19, 0x01
+	a.push(prefix).store(eip712Mem).
+		load(domain).store(eip712Mem + 2).
+		load(structHash).store(eip712Mem + 34).
+		push(66).push(eip712Mem).op(vm.KECCAK256).store(eip712Mem)
+	v()
+	a.store(eip712Mem + 0x20)
+	r()
+	a.store(eip712Mem + 0x40)
+	s()
+	a.store(eip712Mem + 0x60)
+	return a.push(0).store(0x00).
+		push(0x20).push(0x00).push(0x80).push(eip712Mem).push(1).op(vm.GAS, vm.STATICCALL, vm.POP).
+		load(0x00)
+}
+
+// permitMethods adds EIP-2612's nonces, DOMAIN_SEPARATOR and permit under
+// the domain name and version "1". allowanceSlot pushes the storage slot of
+// the allowance of owner argument 0 for spender argument 1. Permit nonces
+// live at keccak(owner, nonceTag).
+func (a *assembler) permitMethods(name string, allowanceSlot func()) {
+	nonceSlot, domain, structHash := local(0), local(1), local(2)
+	domainSeparator := func() {
+		a.hashWords(
+			func() { a.push(domainTypehash) },
+			func() { a.push(crypto.Keccak256Hash([]byte(name))) },
+			func() { a.push(crypto.Keccak256Hash([]byte("1"))) },
+			func() { a.op(vm.CHAINID) },
+			func() { a.op(vm.ADDRESS) },
+		)
+	}
+
+	a.label("nonces").push(nonceTag).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("DOMAIN_SEPARATOR")
+	domainSeparator()
+	a.returnTop()
+
+	a.label("permit").
+		op(vm.TIMESTAMP).arg(3).op(vm.LT, vm.ISZERO).require("ERC20: expired deadline").
+		push(nonceTag).arg(0).hash2().store(nonceSlot)
+	domainSeparator()
+	a.store(domain)
+	a.hashWords(
+		func() { a.push(permitTypehash) },
+		func() { a.arg(0) },
+		func() { a.arg(1) },
+		func() { a.arg(2) },
+		func() { a.load(nonceSlot).op(vm.SLOAD) },
+		func() { a.arg(3) },
+	).store(structHash)
+	a.recoverSigner(domain, structHash,
+		func() { a.arg(4) },
+		func() { a.arg(5) },
+		func() { a.arg(6) },
+	).op(vm.DUP1).arg(0).op(vm.EQ).op(vm.SWAP1, vm.ISZERO, vm.ISZERO, vm.AND).require("ERC20: invalid signature").
+		load(nonceSlot).op(vm.SLOAD).push(1).op(vm.ADD).load(nonceSlot).op(vm.SSTORE).
+		arg(2)
+	allowanceSlot()
+	a.op(vm.SSTORE).
+		arg(2).store(0x00).
+		arg(1).arg(0).push(approvalTopic).push(0x20).push(0x00).op(vm.LOG3).
+		stop()
+}
+
+// SwapRouterCode returns deployment code for a Uniswap V3 style router that
+// swaps at a fixed rate per pool. Rates are 18-decimal fixed point (1e18 is
+// 1:1, the default); a pool's own rate set with setPoolRate overrides the
+// pair rate set with setRate. The pool fee in hundredths of a bip is deducted
+// from the output, and the router pays out of its own token balances.
+func SwapRouterCode() []byte {
+	var (
+		tokenIn, tokenOut, fee = local(0), local(1), local(2)
+		recipient              = local(3)
+		amountIn, minOut       = local(4), local(5)
+		rate, amountOut        = local(6), local(7)
+		firstToken             = local(8)
+		cursor, remaining      = local(9), local(10)
+		params                 = local(11)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))": "exactInputSingle",
+		"exactInput((bytes,address,uint256,uint256,uint256))":                                "exactInput",
+		"getAmountOut(address,address,uint24,uint256)":                                       "getAmountOut",
+		"setRate(address,address,uint256)":                                                   "setRate",
+		"setPoolRate(address,address,uint24,uint256)":                                        "setPoolRate",
+	})
+
+	// Pair rates live at keccak(tokenIn, tokenOut) and pool rates at
+	// keccak(pairSlot, fee)
+	a.label("setRate").arg(2).arg(1).arg(0).hash2().op(vm.SSTORE).stop()
+
+	a.label("setPoolRate").arg(3).arg(2).arg(1).arg(0).hash2().hash2().op(vm.SSTORE).stop()
+
+	a.label("getAmountOut").
+		arg(0).store(tokenIn).arg(1).store(tokenOut).arg(2).store(fee).arg(3).store(amountIn)
+	swapOutput(a, tokenIn, tokenOut, fee, amountIn, rate, amountOut)
+	a.load(amountOut).returnTop()
+
+	a.label("exactInputSingle").
+		arg(0).store(tokenIn).arg(1).store(tokenOut).arg(2).store(fee).arg(3).store(recipient).
+		arg(5).store(amountIn).arg(6).store(minOut).
+		op(vm.TIMESTAMP).arg(4).op(vm.LT, vm.ISZERO).require("Transaction too old")
+	swapOutput(a, tokenIn, tokenOut, fee, amountIn, rate, amountOut)
+	a.jump("settle")
+
+	// exactInput takes a single tuple whose path is encoded as
+	// tokenIn (20 bytes) | fee (3 bytes) | tokenOut (20 bytes) | ...
+	a.label("exactInput").
+		arg(0).push(4).op(vm.ADD).store(params).
+		load(params).push(0x20).op(vm.ADD).op(vm.CALLDATALOAD).store(recipient).
+		load(params).push(0x60).op(vm.ADD).op(vm.CALLDATALOAD).store(amountIn).
+		load(params).push(0x80).op(vm.ADD).op(vm.CALLDATALOAD).store(minOut).
+		op(vm.TIMESTAMP).load(params).push(0x40).op(vm.ADD).op(vm.CALLDATALOAD).op(vm.LT, vm.ISZERO).
+		require("Transaction too old").
+		load(params).op(vm.CALLDATALOAD).load(params).op(vm.ADD).store(cursor).
+		load(cursor).op(vm.CALLDATALOAD).store(remaining).
+		load(cursor).push(0x20).op(vm.ADD).store(cursor).
+		load(cursor).op(vm.CALLDATALOAD).push(96).op(vm.SHR).store(firstToken).
+		load(amountIn).store(amountOut)
+	walkPath(a, cursor, remaining, tokenIn, tokenOut, fee, func() {
+		swapOutput(a, tokenIn, tokenOut, fee, amountOut, rate, amountOut)
+	})
+	a.load(firstToken).store(tokenIn)
+
+	// settle checks slippage, pulls amountIn of tokenIn from the caller and
+	// pays amountOut of tokenOut to the recipient
+	a.label("settle").
+		load(minOut).load(amountOut).op(vm.LT, vm.ISZERO).require("Too little received")
+	a.call(func() { a.load(tokenIn) }, "transferFrom(address,address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.op(vm.ADDRESS) },
+		func() { a.load(amountIn) },
+	).require("STF")
+	a.call(func() { a.load(tokenOut) }, "transfer(address,uint256)",
+		func() { a.load(recipient) },
+		func() { a.load(amountOut) },
+	).require("ST")
+
+	a.load(amountOut).returnTop()
+
+	return deployCode(a.bytes())
+}
+
+// swapOutput stores in amountOut what amountIn of tokenIn buys from the
+// router's tokenIn/tokenOut pool with the given fee:
+// amountIn * rate / 1e18 * (1e6 - fee) / 1e6
+func swapOutput(a *assembler, tokenIn, tokenOut, fee, amountIn, rate, amountOut int) {
+	rateSet := a.newLabel("rate_set")
+	a.load(fee).load(tokenOut).load(tokenIn).hash2().hash2().op(vm.SLOAD).store(rate).
+		load(rate).jumpi(rateSet).
+		load(tokenOut).load(tokenIn).hash2().op(vm.SLOAD).store(rate).
+		load(rate).jumpi(rateSet).
+		push(wad).store(rate).
+		label(rateSet)
+
+	a.load(rate).load(amountIn).op(vm.MUL).push(wad).op(vm.SWAP1, vm.DIV).
+		load(fee).push(1_000_000).op(vm.SUB, vm.MUL).
+		push(1_000_000).op(vm.SWAP1, vm.DIV).store(amountOut)
+}
+
+// walkPath runs hop for each pool of the encoded path starting at calldata
+// offset cursor with remaining bytes, with tokenIn, tokenOut and fee set to
+// the pool's tokens and fee. It reverts for a path without a pool.
+func walkPath(a *assembler, cursor, remaining, tokenIn, tokenOut, fee int, hop func()) {
+	const hopSize = 20 + 3 // token and fee; the next token starts the next hop
+	loop, done := a.newLabel("path"), a.newLabel("path_done")
+
+	a.push(20+hopSize).load(remaining).op(vm.LT, vm.ISZERO).require("Invalid path").
+		label(loop).
+		push(20 + hopSize).load(remaining).op(vm.LT).jumpi(done).
+		load(cursor).op(vm.CALLDATALOAD).push(96).op(vm.SHR).store(tokenIn).
+		load(cursor).push(20).op(vm.ADD).op(vm.CALLDATALOAD).push(232).op(vm.SHR).store(fee).
+		load(cursor).push(hopSize).op(vm.ADD).op(vm.CALLDATALOAD).push(96).op(vm.SHR).store(tokenOut)
+	hop()
+	a.load(cursor).push(hopSize).op(vm.ADD).store(cursor).
+		push(hopSize).load(remaining).op(vm.SUB).store(remaining).
+		jump(loop).
+		label(done)
+}
+
+// QuoterCode returns deployment code for a Uniswap V3 QuoterV2 style quoter
+// that prices swaps with the router's getAmountOut. Each pool crossed adds
+// QuoteGasPerHop to the gas estimate; price and tick movements are not
+// modelled, so those results are zero or empty.
+func QuoterCode(router common.Address) []byte {
+	var (
+		tokenIn, tokenOut, fee = local(0), local(1), local(2)
+		amount, gas            = local(3), local(4)
+		cursor, remaining      = local(5), local(6)
+		result                 = local(8) // six words: amountOut, two array offsets, gas, two array lengths
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"quoteExactInputSingle((address,address,uint256,uint24,uint160))": "quoteExactInputSingle",
+		"quoteExactInput(bytes,uint256)":                                  "quoteExactInput",
+	})
+
+	quote := func() {
+		a.staticCall(func() { a.push(router) }, "getAmountOut(address,address,uint24,uint256)",
+			func() { a.load(tokenIn) },
+			func() { a.load(tokenOut) },
+			func() { a.load(fee) },
+			func() { a.load(amount) },
+		).require("quote failed")
+		a.load(0x00).store(amount).
+			load(gas).push(QuoteGasPerHop).op(vm.ADD).store(gas)
+	}
+
+	a.label("quoteExactInputSingle").
+		arg(0).store(tokenIn).arg(1).store(tokenOut).arg(2).store(amount).arg(3).store(fee)
+	quote()
+	a.load(amount).store(result).
+		push(0).store(result+0x20).
+		push(0).store(result+0x40).
+		load(gas).store(result+0x60).
+		returnWords(result, 4)
+
+	a.label("quoteExactInput").
+		arg(0).push(4).op(vm.ADD).store(cursor).
+		load(cursor).op(vm.CALLDATALOAD).store(remaining).
+		load(cursor).push(0x20).op(vm.ADD).store(cursor).
+		arg(1).store(amount)
+	walkPath(a, cursor, remaining, tokenIn, tokenOut, fee, quote)
+	a.load(amount).store(result).
+		push(0x80).store(result+0x20).
+		push(0xa0).store(result+0x40).
+		load(gas).store(result+0x60).
+		push(0).store(result+0x80).
+		push(0).store(result+0xa0).
+		returnWords(result, 6)
+
+	return deployCode(a.bytes())
+}
+
+// LendingPoolCode returns deployment code for an Aave V2 style lending pool
+// that also offers Aave V3's flashLoanSimple. Every asset is valued 1:1, so
+// each user has one collateral and one debt balance; borrows are limited by
+// PoolLTV and the health factor uses PoolLiquidationThreshold. Borrowed
+// assets and flash loans are paid from the pool's own token balances.
+//
+// Aave V3's supply, collateral, e-mode and rate mode calls and its reserve
+// and user configuration reads are recorded but do not affect accounting.
+// The pool's own ERC20 interface over collateral balances can stand in for
+// a reserve's aToken.
+// Reserves are listed with the mock-only setReserve; the pool never mints
+// their aTokens or debt tokens.
+func LendingPoolCode() []byte {
+	var (
+		slot, value, next = local(0), local(1), local(2)
+		debt              = local(3)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"deposit(address,uint256,address,uint16)":               "deposit",
+		"withdraw(address,uint256,address)":                     "withdraw",
+		"borrow(address,uint256,uint256,uint16,address)":        "borrow",
+		"repay(address,uint256,uint256,address)":                "repay",
+		"getUserAccountData(address)":                           "getUserAccountData",
+		"flashLoanSimple(address,address,uint256,bytes,uint16)": "flashLoanSimple",
+		"supply(address,uint256,address,uint16)":                "deposit",
+		"setUserUseReserveAsCollateral(address,bool)":           "setUserUseReserveAsCollateral",
+		"setUserEMode(uint8)":                                   "setUserEMode",
+		"getUserEMode(address)":                                 "getUserEMode",
+		"swapBorrowRateMode(address,uint256)":                   "swapBorrowRateMode",
+		"getUserConfiguration(address)":                         "getUserConfiguration",
+		"getReserveData(address)":                               "getReserveData",
+		"getReservesList()":                                     "getReservesList",
+		"FLASHLOAN_PREMIUM_TOTAL()":                             "FLASHLOAN_PREMIUM_TOTAL",
+		"setReserve(address,uint256,uint256,uint256,uint256,uint256,address,address,address)": "setReserve",
+		"balanceOf(address)":                    "balanceOf",
+		"allowance(address,address)":            "allowance",
+		"approve(address,uint256)":              "approve",
+		"transferFrom(address,address,uint256)": "transferFrom",
+		"nonces(address)":                       "nonces",
+		"DOMAIN_SEPARATOR()":                    "DOMAIN_SEPARATOR",
+		"permit(address,address,uint256,uint256,uint8,bytes32,bytes32)": "permit",
+	})
+
+	// Collateral lives at keccak(user, 1) and debt at keccak(user, 2)
+	a.label("deposit").
+		push(1).arg(2).hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.ADD).load(slot).op(vm.SSTORE)
+	a.call(func() { a.arg(0) }, "transferFrom(address,address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.op(vm.ADDRESS) },
+		func() { a.arg(1) },
+	).require("transfer failed").stop()
+
+	a.label("withdraw").
+		push(1).op(vm.CALLER).hash2().store(slot).
+		load(slot).op(vm.SLOAD).store(value).
+		arg(1).load(value).op(vm.LT, vm.ISZERO).require("insufficient collateral").
+		arg(1).load(value).op(vm.SUB).store(next).
+		push(2).op(vm.CALLER).hash2().op(vm.SLOAD).store(debt).
+		push(10_000).load(debt).op(vm.MUL).
+		push(PoolLTV).load(next).op(vm.MUL).
+		op(vm.LT, vm.ISZERO).require("health factor too low").
+		load(next).load(slot).op(vm.SSTORE)
+	a.call(func() { a.arg(0) }, "transfer(address,uint256)",
+		func() { a.arg(2) },
+		func() { a.arg(1) },
+	).require("transfer failed")
+	a.arg(1).returnTop()
+
+	a.label("borrow").
+		arg(4).op(vm.CALLER).op(vm.EQ).require("credit delegation unsupported").
+		push(2).op(vm.CALLER).hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.ADD).store(next).
+		push(1).op(vm.CALLER).hash2().op(vm.SLOAD).store(value).
+		push(10_000).load(next).op(vm.MUL).
+		push(PoolLTV).load(value).op(vm.MUL).
+		op(vm.LT, vm.ISZERO).require("collateral cannot cover borrow").
+		load(next).load(slot).op(vm.SSTORE)
+	a.call(func() { a.arg(0) }, "transfer(address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.arg(1) },
+	).require("insufficient pool liquidity").stop()
+
+	// repay caps the amount at the outstanding debt and returns what was paid
+	a.label("repay").
+		push(2).arg(3).hash2().store(slot).
+		load(slot).op(vm.SLOAD).store(debt).
+		arg(1).store(value).
+		arg(1).load(debt).op(vm.LT, vm.ISZERO).jumpi("repay_amount").
+		load(debt).store(value).
+		label("repay_amount").
+		load(value).load(debt).op(vm.SUB).load(slot).op(vm.SSTORE)
+	a.call(func() { a.arg(0) }, "transferFrom(address,address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.op(vm.ADDRESS) },
+		func() { a.load(value) },
+	).require("transfer failed")
+	a.load(value).returnTop()
+
+	// The pool doubles as an aToken over the collateral balances, so tests
+	// can list it as a reserve's aToken and move collateral between
+	// accounts. Allowances live at keccak(keccak(owner, 7), spender), are
+	// also granted by EIP-2612 permits under the domain name "Mock aToken",
+	// and a transfer must leave the sender's debt within PoolLTV.
+	a.label("balanceOf").
+		push(1).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("allowance").
+		arg(1).push(7).arg(0).hash2().hash2().op(vm.SLOAD).returnTop()
+
+	a.label("approve").
+		arg(1).arg(0).push(7).op(vm.CALLER).hash2().hash2().op(vm.SSTORE).
+		push(1).returnTop()
+
+	a.label("transferFrom").
+		op(vm.CALLER).push(7).arg(0).hash2().hash2().store(slot).
+		load(slot).op(vm.SLOAD).store(value).
+		arg(2).load(value).op(vm.LT, vm.ISZERO).require("insufficient allowance").
+		arg(2).load(value).op(vm.SUB).load(slot).op(vm.SSTORE).
+		push(1).arg(0).hash2().store(slot).
+		load(slot).op(vm.SLOAD).store(value).
+		arg(2).load(value).op(vm.LT, vm.ISZERO).require("insufficient collateral").
+		arg(2).load(value).op(vm.SUB).store(next).
+		push(2).arg(0).hash2().op(vm.SLOAD).store(debt).
+		push(10_000).load(debt).op(vm.MUL).
+		push(PoolLTV).load(next).op(vm.MUL).
+		op(vm.LT, vm.ISZERO).require("health factor too low").
+		load(next).load(slot).op(vm.SSTORE).
+		push(1).arg(1).hash2().store(slot).
+		arg(2).load(slot).op(vm.SLOAD).op(vm.ADD).load(slot).op(vm.SSTORE).
+		push(1).returnTop()
+
+	a.permitMethods("Mock aToken", func() { a.arg(1).push(7).arg(0).hash2().hash2() })
+
+	// getUserAccountData returns (collateral, debt, availableBorrows,
+	// liquidationThreshold, ltv, healthFactor) from consecutive memory words;
+	// users without debt have a health factor of 2^256-1
+	var (
+		collateral, owed, available = local(8), local(9), local(10)
+		threshold, ltv, health      = local(11), local(12), local(13)
+		capacity                    = local(14)
+	)
+	a.label("getUserAccountData").
+		push(1).arg(0).hash2().op(vm.SLOAD).store(collateral).
+		push(2).arg(0).hash2().op(vm.SLOAD).store(owed).
+		push(PoolLiquidationThreshold).store(threshold).
+		push(PoolLTV).store(ltv)
+
+	a.push(10_000).push(PoolLTV).load(collateral).op(vm.MUL, vm.DIV).store(capacity).
+		push(0).store(available).
+		load(owed).load(capacity).op(vm.LT).jumpi("available_set").
+		load(owed).load(capacity).op(vm.SUB).store(available).
+		label("available_set")
+
+	// healthFactor = collateral * threshold / debt as an 18-decimal number
+	a.push(maxUint256).store(health).
+		load(owed).op(vm.ISZERO).jumpi("health_set").
+		load(owed).push(thresholdWad).load(collateral).op(vm.MUL, vm.DIV).store(health).
+		label("health_set").
+		returnWords(collateral, 6)
+
+	// Reserve data lives at keccak(asset, 3) in getReserveData's word order,
+	// the user configuration bitmap at keccak(user, 5), the e-mode category
+	// at keccak(user, 6) and the reserve list after its length at
+	// reservesTag
+	var (
+		reserve, bit = local(17), local(18)
+		count, index = local(19), local(20)
+		words        = local(21)
+	)
+	const reserveWords, listMem = 15, 0x1000
+	a.label("setReserve").
+		push(3).arg(0).hash2().store(reserve)
+	for i, word := range []int{0, 2, 4, 5, 7, 8, 9, 10} {
+		a.arg(i + 1).load(reserve).push(word).op(vm.ADD).op(vm.SSTORE)
+	}
+	a.push(ray).load(reserve).push(1).op(vm.ADD).op(vm.SSTORE).
+		push(ray).load(reserve).push(3).op(vm.ADD).op(vm.SSTORE).
+		op(vm.TIMESTAMP).load(reserve).push(6).op(vm.ADD).op(vm.SSTORE).
+		push(reservesTag).op(vm.SLOAD).push(1).op(vm.ADD).store(index).
+		arg(0).load(index).push(reservesTag).op(vm.ADD).op(vm.SSTORE).
+		load(index).push(reservesTag).op(vm.SSTORE).
+		stop()
+
+	a.label("getReserveData").
+		push(3).arg(0).hash2().store(reserve)
+	for i := 0; i < reserveWords; i++ {
+		a.load(reserve).push(i).op(vm.ADD).op(vm.SLOAD).store(words + 32*i)
+	}
+	a.returnWords(words, reserveWords)
+
+	a.label("getReservesList").
+		push(reservesTag).op(vm.SLOAD).store(count).
+		push(0x20).store(listMem).
+		load(count).store(listMem+0x20).
+		push(0).store(index).
+		label("reserves").
+		load(count).load(index).op(vm.LT, vm.ISZERO).jumpi("reserves_done").
+		load(index).push(1).op(vm.ADD).push(reservesTag).op(vm.ADD).op(vm.SLOAD).
+		load(index).push(5).op(vm.SHL).push(listMem + 0x40).op(vm.ADD).op(vm.MSTORE).
+		load(index).push(1).op(vm.ADD).store(index).
+		jump("reserves").
+		label("reserves_done").
+		load(count).push(5).op(vm.SHL).push(0x40).op(vm.ADD).push(listMem).op(vm.RETURN)
+
+	// Using a reserve as collateral is bit 2*id+1 of the user's
+	// configuration; the pool's accounting ignores it
+	a.label("setUserUseReserveAsCollateral").
+		push(3).arg(0).hash2().store(reserve).
+		load(reserve).push(8).op(vm.ADD).op(vm.SLOAD).require("reserve not listed").
+		push(1).load(reserve).push(7).op(vm.ADD).op(vm.SLOAD).push(1).op(vm.SHL).push(1).op(vm.ADD).op(vm.SHL).store(bit).
+		push(5).op(vm.CALLER).hash2().store(slot).
+		load(slot).op(vm.SLOAD).store(value).
+		arg(1).jumpi("collateral_on").
+		load(bit).op(vm.NOT).load(value).op(vm.AND).load(slot).op(vm.SSTORE).
+		stop().
+		label("collateral_on").
+		load(bit).load(value).op(vm.OR).load(slot).op(vm.SSTORE).
+		stop()
+
+	a.label("getUserConfiguration").
+		push(5).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	// E-mode categories 0-4 exist
+	a.label("setUserEMode").
+		arg(0).push(5).op(vm.GT).require("inconsistent eMode category").
+		arg(0).push(6).op(vm.CALLER).hash2().op(vm.SSTORE).
+		stop()
+
+	a.label("getUserEMode").
+		push(6).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("FLASHLOAN_PREMIUM_TOTAL").
+		push(FlashLoanPremiumBps).returnTop()
+
+	// swapBorrowRateMode only checks its arguments; the pool keeps a single
+	// debt balance whatever the rate mode
+	a.label("swapBorrowRateMode").
+		arg(1).push(1).op(vm.EQ).arg(1).push(2).op(vm.EQ).op(vm.OR).require("invalid interest rate mode").
+		push(2).op(vm.CALLER).hash2().op(vm.SLOAD).require("no debt of selected type").
+		stop()
+
+	// flashLoanSimple lends amount to the receiver, calls its
+	// executeOperation(asset, amount, premium, initiator, params) and pulls
+	// back amount plus FlashLoanPremiumBps
+	const callback = 0x1000
+	var premium, size = local(15), local(16)
+	word := make([]byte, 32)
+	copy(word, selector("executeOperation(address,uint256,uint256,address,bytes)"))
+	a.label("flashLoanSimple").
+		push(10_000).push(FlashLoanPremiumBps).arg(2).op(vm.MUL, vm.DIV).store(premium)
+	a.call(func() { a.arg(1) }, "transfer(address,uint256)",
+		func() { a.arg(0) },
+		func() { a.arg(2) },
+	).require("insufficient pool liquidity")
+
+	// The callback's params are copied with their length word, padded to
+	// a whole number of words
+	a.push(word).store(callback).
+		arg(1).store(callback+0x04).
+		arg(2).store(callback+0x24).
+		load(premium).store(callback+0x44).
+		op(vm.CALLER).store(callback+0x64).
+		push(0xa0).store(callback+0x84).
+		arg(3).push(4).op(vm.ADD).op(vm.CALLDATALOAD).push(63).op(vm.ADD).push(5).op(vm.SHR).push(5).op(vm.SHL).store(size).
+		load(size).arg(3).push(4).op(vm.ADD).push(callback+0xa4).op(vm.CALLDATACOPY).
+		push(0).store(0x00).
+		push(0x20).push(0x00).load(size).push(0xa4).op(vm.ADD).push(callback).push(0).arg(0).op(vm.GAS, vm.CALL).
+		load(0x00).op(vm.AND).require("flash loan callback failed")
+	a.call(func() { a.arg(1) }, "transferFrom(address,address,uint256)",
+		func() { a.arg(0) },
+		func() { a.op(vm.ADDRESS) },
+		func() { a.load(premium).arg(2).op(vm.ADD) },
+	).require("flash loan not repaid").stop()
+
+	return deployCode(a.bytes())
+}
+
+// OracleCode returns deployment code for an Aave V3 price oracle that
+// prices every asset at 1e18 base currency units, matching the lending
+// pool's 1:1 valuation of 18-decimal tokens
+func OracleCode() []byte {
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"getAssetPrice(address)": "getAssetPrice",
+	})
+
+	a.label("getAssetPrice").push(wad).returnTop()
+
+	return deployCode(a.bytes())
+}
+
+// ExecutorCode returns deployment code for a bundle executor with
+// Multicall3's aggregate3 interface that only owner may call. Unlike
+// Multicall3 it bubbles up the revert data of a failing call that does not
+// allow failure, and it can receive Aave V3 flash loans: executeOperation
+// runs the Call3 array encoded in params, if the executor itself took the
+// loan, and approves the lender to pull back the loan plus premium.
+func ExecutorCode(owner common.Address) []byte {
+	var array = local(0)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"aggregate3((address,bool,bytes)[])":                      "aggregate3",
+		"executeOperation(address,uint256,uint256,address,bytes)": "executeOperation",
+		"owner()": "owner",
+	})
+
+	a.label("owner").push(owner).returnTop()
+
+	a.label("aggregate3").
+		op(vm.CALLER).push(owner).op(vm.EQ).require("caller is not the owner").
+		arg(0).push(4).op(vm.ADD).store(array)
+	end := runCalls(a, array)
+	a.push(callResults).load(end).op(vm.SUB).push(callResults).op(vm.RETURN)
+
+	// params is abi.encode(Call3[]), so its first word is the array's offset
+	a.label("executeOperation").
+		op(vm.ADDRESS).arg(3).op(vm.EQ).require("untrusted flash loan initiator").
+		arg(4).push(4 + 32).op(vm.ADD).op(vm.DUP1).op(vm.CALLDATALOAD).op(vm.ADD).store(array)
+	runCalls(a, array)
+	a.call(func() { a.arg(0) }, "approve(address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.arg(2).arg(1).op(vm.ADD) },
+	).require("approve failed")
+	a.push(1).returnTop()
+
+	return deployCode(a.bytes())
+}
+
+// callResults is where runCalls encodes its Result[] return value
+const callResults = 0x1000
+
+// runCalls makes each call of the Call3 array whose length word is at the
+// calldata offset stored in array, and ABI-encodes the (success, returnData)
+// results at callResults. It returns the local holding the memory offset
+// where the encoding ends.
+func runCalls(a *assembler, array int) int {
+	var (
+		count, index = local(1), local(2)
+		tuple, data  = local(3), local(4)
+		end          = local(5)
+		heads        = callResults + 0x40
+	)
+	loop, done, ok := a.newLabel("calls"), a.newLabel("calls_done"), a.newLabel("call_ok")
+
+	a.load(array).op(vm.CALLDATALOAD).store(count).
+		push(0x20).store(callResults).
+		load(count).store(callResults + 0x20).
+		load(count).push(5).op(vm.SHL).push(heads).op(vm.ADD).store(end).
+		push(0).store(index)
+
+	// Each call's data is copied past the results so far, then overwritten
+	// by its own result
+	a.label(loop).
+		load(count).load(index).op(vm.LT, vm.ISZERO).jumpi(done).
+		load(array).push(0x20).op(vm.ADD).op(vm.DUP1).
+		load(index).push(5).op(vm.SHL).op(vm.ADD).op(vm.CALLDATALOAD).op(vm.ADD).store(tuple).
+		load(tuple).push(0x40).op(vm.ADD).op(vm.CALLDATALOAD).load(tuple).op(vm.ADD).store(data).
+		load(data).op(vm.CALLDATALOAD).load(data).push(0x20).op(vm.ADD).load(end).push(0x60).op(vm.ADD).op(vm.CALLDATACOPY).
+		push(0).push(0).load(data).op(vm.CALLDATALOAD).load(end).push(0x60).op(vm.ADD).push(0).
+		load(tuple).op(vm.CALLDATALOAD).op(vm.GAS, vm.CALL).
+		op(vm.DUP1).load(end).op(vm.MSTORE).
+		jumpi(ok).
+		load(tuple).push(0x20).op(vm.ADD).op(vm.CALLDATALOAD).jumpi(ok).
+		op(vm.RETURNDATASIZE).push(0).push(0).op(vm.RETURNDATACOPY).
+		op(vm.RETURNDATASIZE).push(0).op(vm.REVERT)
+
+	// Result i is (success, 0x40, length, data) with its offset in the heads
+	a.label(ok).
+		push(heads).load(end).op(vm.SUB).load(index).push(5).op(vm.SHL).push(heads).op(vm.ADD).op(vm.MSTORE).
+		push(0x40).load(end).push(0x20).op(vm.ADD).op(vm.MSTORE).
+		op(vm.RETURNDATASIZE).load(end).push(0x40).op(vm.ADD).op(vm.MSTORE).
+		op(vm.RETURNDATASIZE).push(0).load(end).push(0x60).op(vm.ADD).op(vm.RETURNDATACOPY).
+		op(vm.RETURNDATASIZE).push(31).op(vm.ADD).push(5).op(vm.SHR).push(5).op(vm.SHL).
+		push(0x60).op(vm.ADD).load(end).op(vm.ADD).store(end).
+		load(index).push(1).op(vm.ADD).store(index).
+		jump(loop).
+		label(done)
+	return end
+}
+
+// CometCode returns deployment code for a Compound V3 (Comet) market with
+// the given base token. Each account has a signed base principal, positive
+// when supplying and negative when borrowing, and per-asset collateral
+// balances valued 1:1 against the base; withdrawing base past zero borrows
+// it, up to PoolLTV of the collateral. Interest does not accrue: the
+// utilization and per-second rates are set with the mock-only setRates.
+// Withdrawals are paid from the market's own token balances.
+func CometCode(base common.Address) []byte {
+	var (
+		slot, value, debt = local(0), local(1), local(2)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"baseToken()":                          "baseToken",
+		"supply(address,uint256)":              "supply",
+		"withdraw(address,uint256)":            "withdraw",
+		"balanceOf(address)":                   "balanceOf",
+		"borrowBalanceOf(address)":             "borrowBalanceOf",
+		"collateralBalanceOf(address,address)": "collateralBalanceOf",
+		"getUtilization()":                     "getUtilization",
+		"getSupplyRate(uint256)":               "getSupplyRate",
+		"getBorrowRate(uint256)":               "getBorrowRate",
+		"setRates(uint256,uint64,uint64)":      "setRates",
+	})
+
+	a.label("baseToken").push(base).returnTop()
+
+	// Rates live in slots 0-2
+	a.label("setRates").
+		arg(0).push(0).op(vm.SSTORE).
+		arg(1).push(1).op(vm.SSTORE).
+		arg(2).push(2).op(vm.SSTORE).stop()
+	a.label("getUtilization").push(0).op(vm.SLOAD).returnTop()
+	a.label("getSupplyRate").push(1).op(vm.SLOAD).returnTop()
+	a.label("getBorrowRate").push(2).op(vm.SLOAD).returnTop()
+
+	// The principal lives at keccak(user, 1), collateral at
+	// keccak(keccak(user, 2), asset) and its total at keccak(user, 3)
+	a.label("balanceOf").
+		push(1).arg(0).hash2().op(vm.SLOAD).store(value).
+		push(0).load(value).op(vm.SLT).jumpi("zero").
+		load(value).returnTop()
+
+	a.label("borrowBalanceOf").
+		push(1).arg(0).hash2().op(vm.SLOAD).store(value).
+		push(0).load(value).op(vm.SLT, vm.ISZERO).jumpi("zero").
+		load(value).push(0).op(vm.SUB).returnTop()
+
+	a.label("zero").push(0).returnTop()
+
+	a.label("collateralBalanceOf").
+		arg(1).push(2).arg(0).hash2().hash2().op(vm.SLOAD).returnTop()
+
+	a.label("supply")
+	a.call(func() { a.arg(0) }, "transferFrom(address,address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.op(vm.ADDRESS) },
+		func() { a.arg(1) },
+	).require("transfer failed")
+	a.push(base).arg(0).op(vm.EQ, vm.ISZERO).jumpi("supply_collateral").
+		push(1).op(vm.CALLER).hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.ADD).load(slot).op(vm.SSTORE).stop()
+	a.label("supply_collateral").
+		arg(0).push(2).op(vm.CALLER).hash2().hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.ADD).load(slot).op(vm.SSTORE).
+		push(3).op(vm.CALLER).hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.ADD).load(slot).op(vm.SSTORE).stop()
+
+	a.label("withdraw").
+		push(base).arg(0).op(vm.EQ, vm.ISZERO).jumpi("withdraw_collateral").
+		push(1).op(vm.CALLER).hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.SUB).load(slot).op(vm.SSTORE).
+		jump("withdraw_check")
+	a.label("withdraw_collateral").
+		arg(0).push(2).op(vm.CALLER).hash2().hash2().store(slot).
+		load(slot).op(vm.SLOAD).store(value).
+		arg(1).load(value).op(vm.LT, vm.ISZERO).require("insufficient collateral").
+		arg(1).load(value).op(vm.SUB).load(slot).op(vm.SSTORE).
+		push(3).op(vm.CALLER).hash2().store(slot).
+		arg(1).load(slot).op(vm.SLOAD).op(vm.SUB).load(slot).op(vm.SSTORE)
+
+	// A negative principal must stay within PoolLTV of the collateral
+	a.label("withdraw_check").
+		push(1).op(vm.CALLER).hash2().op(vm.SLOAD).store(value).
+		push(0).load(value).op(vm.SLT, vm.ISZERO).jumpi("withdraw_pay").
+		load(value).push(0).op(vm.SUB).store(debt).
+		push(10_000).load(debt).op(vm.MUL).
+		push(PoolLTV).push(3).op(vm.CALLER).hash2().op(vm.SLOAD).op(vm.MUL).
+		op(vm.LT, vm.ISZERO).require("NotCollateralized")
+	a.label("withdraw_pay")
+	a.call(func() { a.arg(0) }, "transfer(address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.arg(1) },
+	).require("insufficient liquidity").stop()
+
+	return deployCode(a.bytes())
+}
+
+// CurveRegistryCode returns deployment code for a Curve registry that finds
+// two-coin pools added with the mock-only addPool
+func CurveRegistryCode() []byte {
+	var (
+		from, to = local(0), local(1)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"find_pool_for_coins(address,address)":      "find_pool_for_coins",
+		"get_coin_indices(address,address,address)": "get_coin_indices",
+		"get_n_coins(address)":                      "get_n_coins",
+		"addPool(address,address,address)":          "addPool",
+	})
+
+	// Pools live at keccak(from, to) and each coin's index plus one at
+	// keccak(pool, coin)
+	a.label("addPool").
+		arg(0).arg(2).arg(1).hash2().op(vm.SSTORE).
+		arg(0).arg(1).arg(2).hash2().op(vm.SSTORE).
+		push(1).arg(1).arg(0).hash2().op(vm.SSTORE).
+		push(2).arg(2).arg(0).hash2().op(vm.SSTORE).stop()
+
+	a.label("find_pool_for_coins").
+		arg(1).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("get_coin_indices").
+		arg(1).arg(0).hash2().op(vm.SLOAD).store(from).
+		arg(2).arg(0).hash2().op(vm.SLOAD).store(to).
+		load(from).load(to).op(vm.MUL).require("coins not in pool").
+		push(1).load(from).op(vm.SUB).store(from).
+		push(1).load(to).op(vm.SUB).store(to).
+		push(0).store(local(2)).
+		returnWords(from, 3)
+
+	// Every pool has two coins and no underlying coins of its own
+	a.label("get_n_coins").
+		push(2).store(from).
+		push(2).store(to).
+		returnWords(from, 2)
+
+	return deployCode(a.bytes())
+}
+
+// CurvePoolCode returns deployment code for a two-coin Curve pool that
+// swaps at a fixed rate per direction, set with the mock-only setRate as an
+// 18-decimal number (1:1 by default), less CurveFee. The pool pays out of
+// its own token balances, which balances reports.
+func CurvePoolCode(coin0, coin1 common.Address) []byte {
+	var (
+		rate, dy = local(0), local(1)
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"coins(uint256)":                "coins",
+		"balances(uint256)":             "balances",
+		"A()":                           "A",
+		"fee()":                         "fee",
+		"admin_fee()":                   "admin_fee",
+		"get_dy(int128,int128,uint256)": "get_dy",
+		"exchange(int128,int128,uint256,uint256)": "exchange",
+		"setRate(int128,int128,uint256)":          "setRate",
+	})
+
+	// coin pushes coin0 or coin1 for the index in argument i
+	coin := func(i int) {
+		chosen := a.newLabel("coin")
+		a.push(coin1).arg(i).jumpi(chosen).
+			op(vm.POP).push(coin0).
+			label(chosen)
+	}
+	indices := func() {
+		a.push(2).arg(0).op(vm.LT).
+			push(2).arg(1).op(vm.LT).op(vm.AND).
+			arg(0).arg(1).op(vm.EQ, vm.ISZERO, vm.AND).
+			require("invalid coin index")
+	}
+
+	// dy is dx * rate / 1e18 * (1e10 - fee) / 1e10, with the rate for i to j
+	// at keccak(i, j)
+	output := func() {
+		rateSet := a.newLabel("rate_set")
+		indices()
+		a.arg(1).arg(0).hash2().op(vm.SLOAD).store(rate).
+			load(rate).jumpi(rateSet).
+			push(wad).store(rate).
+			label(rateSet).
+			load(rate).arg(2).op(vm.MUL).push(wad).op(vm.SWAP1, vm.DIV).
+			push(10_000_000_000-CurveFee).op(vm.MUL).
+			push(10_000_000_000).op(vm.SWAP1, vm.DIV).store(dy)
+	}
+
+	a.label("coins").
+		push(2).arg(0).op(vm.LT).require("invalid coin index")
+	coin(0)
+	a.returnTop()
+
+	a.label("balances").
+		push(2).arg(0).op(vm.LT).require("invalid coin index")
+	a.staticCall(func() { coin(0) }, "balanceOf(address)",
+		func() { a.op(vm.ADDRESS) },
+	).require("balanceOf failed")
+	a.load(0x00).returnTop()
+
+	a.label("A").push(CurveA).returnTop()
+	a.label("fee").push(CurveFee).returnTop()
+	a.label("admin_fee").push(CurveAdminFee).returnTop()
+
+	a.label("setRate").
+		arg(2).arg(1).arg(0).hash2().op(vm.SSTORE).stop()
+
+	a.label("get_dy")
+	output()
+	a.load(dy).returnTop()
+
+	a.label("exchange")
+	output()
+	a.arg(3).load(dy).op(vm.LT, vm.ISZERO).require("Exchange resulted in fewer coins")
+	a.call(func() { coin(0) }, "transferFrom(address,address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.op(vm.ADDRESS) },
+		func() { a.arg(2) },
+	).require("transfer failed")
+	a.call(func() { coin(1) }, "transfer(address,uint256)",
+		func() { a.op(vm.CALLER) },
+		func() { a.load(dy) },
+	).require("insufficient liquidity")
+	a.load(dy).returnTop()
+
+	return deployCode(a.bytes())
+}
+
+// V2FactoryCode returns deployment code for a Uniswap V2 factory that finds
+// pairs registered with the mock-only setPair under either token order
+func V2FactoryCode() []byte {
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"getPair(address,address)":         "getPair",
+		"setPair(address,address,address)": "setPair",
+	})
+
+	// Pairs live at keccak(tokenA, tokenB)
+	a.label("getPair").
+		arg(1).arg(0).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("setPair").
+		arg(2).arg(1).arg(0).hash2().op(vm.SSTORE).
+		arg(2).arg(0).arg(1).hash2().op(vm.SSTORE).stop()
+
+	return deployCode(a.bytes())
+}
+
+// V2PairCode returns deployment code for the state half of a Uniswap V2
+// pair: its tokens and reserves, which are its balances of them. It cannot
+// swap.
+func V2PairCode(token0, token1 common.Address) []byte {
+	reserves := local(0)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"getReserves()": "getReserves",
+		"token0()":      "token0",
+		"token1()":      "token1",
+	})
+
+	a.label("token0").push(token0).returnTop()
+	a.label("token1").push(token1).returnTop()
+
+	a.label("getReserves")
+	for i, token := range []common.Address{token0, token1} {
+		a.staticCall(func() { a.push(token) }, "balanceOf(address)",
+			func() { a.op(vm.ADDRESS) },
+		).require("balanceOf failed")
+		a.load(0x00).store(reserves + 0x20*i)
+	}
+	a.push(0).store(reserves+0x40).
+		returnWords(reserves, 3)
+
+	return deployCode(a.bytes())
+}
+
+// V3FactoryCode returns deployment code for a Uniswap V3 factory that finds
+// pools registered with the mock-only setPool under either token order
+func V3FactoryCode() []byte {
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"getPool(address,address,uint24)":         "getPool",
+		"setPool(address,address,uint24,address)": "setPool",
+	})
+
+	// Pools live at keccak(fee, keccak(tokenA, tokenB))
+	a.label("getPool").
+		arg(1).arg(0).hash2().arg(2).hash2().op(vm.SLOAD).returnTop()
+
+	a.label("setPool").
+		arg(3).arg(1).arg(0).hash2().arg(2).hash2().op(vm.SSTORE).
+		arg(3).arg(0).arg(1).hash2().arg(2).hash2().op(vm.SSTORE).stop()
+
+	return deployCode(a.bytes())
+}
+
+// V3PoolCode returns deployment code for the state half of a Uniswap V3
+// pool: its tokens, fee, slot0 price and in-range liquidity, which are set
+// with the mock-only setSlot0 and setLiquidity. It cannot swap.
+func V3PoolCode(token0, token1 common.Address, fee int) []byte {
+	slot0 := local(0)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"slot0()":                 "slot0",
+		"token0()":                "token0",
+		"token1()":                "token1",
+		"fee()":                   "fee",
+		"tickSpacing()":           "tickSpacing",
+		"liquidity()":             "liquidity",
+		"setSlot0(uint160,int24)": "setSlot0",
+		"setLiquidity(uint128)":   "setLiquidity",
+	})
+
+	a.label("token0").push(token0).returnTop()
+	a.label("token1").push(token1).returnTop()
+	a.label("fee").push(fee).returnTop()
+	a.label("tickSpacing").push(poolmath.TickSpacingForFee(int64(fee))).returnTop()
+
+	// Liquidity lives in slot 2
+	a.label("liquidity").push(2).op(vm.SLOAD).returnTop()
+	a.label("setLiquidity").arg(0).push(2).op(vm.SSTORE).stop()
+
+	// The sqrt price lives in slot 0 and the tick in slot 1; the oracle and
+	// protocol fee fields read as zero and the pool as unlocked
+	a.label("setSlot0").
+		arg(0).push(0).op(vm.SSTORE).
+		arg(1).push(1).op(vm.SSTORE).stop()
+
+	a.label("slot0").
+		push(0).op(vm.SLOAD).store(slot0).
+		push(1).op(vm.SLOAD).store(slot0 + 0x20)
+	for i := 2; i < 6; i++ {
+		a.push(0).store(slot0 + 0x20*i)
+	}
+	a.push(1).store(slot0+0xa0).
+		returnWords(slot0, 7)
+
+	return deployCode(a.bytes())
+}
+
+// PositionManagerCode returns deployment code for a Uniswap V3
+// NonfungiblePositionManager that keeps positions without pricing their
+// ticks. A deposit of amount0 and amount1 adds sqrt(amount0 * amount1)
+// liquidity, which is what a full-range position at the pool price would
+// get, and takes both amounts in full. Removing liquidity returns the same
+// share of the deposits whatever the price, so a position never changes
+// composition. Fees are credited to tokensOwed with the mock-only
+// accrueFees and paid, like withdrawn liquidity, out of the manager's own
+// token balances.
+func PositionManagerCode() []byte {
+	var (
+		tokenID, liquidity, amount0, amount1 = local(0), local(1), local(2), local(3)
+		position                             = local(4)
+		root, guess                          = local(5), local(6)
+		product                              = local(7)
+		fields                               = local(8)
+	)
+
+	// Position fields are stored from keccak(tokenId, positionTag): owner,
+	// token0, token1, fee, tickLower, tickUpper, liquidity, tokensOwed0,
+	// tokensOwed1, and the deposits backing the liquidity
+	const (
+		fieldOwner = iota
+		fieldToken0
+		fieldToken1
+		fieldFee
+		fieldTickLower
+		fieldTickUpper
+		fieldLiquidity
+		fieldOwed0
+		fieldOwed1
+		fieldDeposit0
+		fieldDeposit1
+	)
+
+	a := newAssembler()
+	a.dispatch(map[string]string{
+		"mint((address,address,uint24,int24,int24,uint256,uint256,uint256,uint256,address,uint256))": "mint",
+		"increaseLiquidity((uint256,uint256,uint256,uint256,uint256,uint256))":                       "increaseLiquidity",
+		"decreaseLiquidity((uint256,uint128,uint256,uint256,uint256))":                               "decreaseLiquidity",
+		"collect((uint256,address,uint128,uint128))":                                                 "collect",
+		"burn(uint256)":                       "burn",
+		"positions(uint256)":                  "positions",
+		"accrueFees(uint256,uint256,uint256)": "accrueFees",
+	})
+
+	field := func(i int) *assembler {
+		return a.load(position).push(i).op(vm.ADD)
+	}
+	get := func(i int) *assembler {
+		return field(i).op(vm.SLOAD)
+	}
+	// set pops the top of the stack into field i
+	set := func(i int) *assembler {
+		return field(i).op(vm.SSTORE)
+	}
+	// add adds the word at memory offset to field i
+	add := func(i, offset int) {
+		a.load(offset)
+		get(i).op(vm.ADD)
+		set(i)
+	}
+	// sub subtracts the word at memory offset from field i
+	sub := func(i, offset int) {
+		a.load(offset)
+		get(i).op(vm.SUB)
+		set(i)
+	}
+	locate := func(id func()) {
+		a.push(positionTag)
+		id()
+		a.hash2().store(position)
+	}
+	exists := func() {
+		get(fieldOwner).require("Invalid token ID")
+	}
+	onlyOwner := func() {
+		get(fieldOwner).op(vm.CALLER, vm.EQ).require("Not approved")
+	}
+	deadline := func(i int) {
+		a.arg(i).op(vm.TIMESTAMP, vm.GT, vm.ISZERO).require("Transaction too old")
+	}
+	// pull takes an amount of a position token from the caller
+	pull := func(token, amount int) {
+		a.call(func() { get(token) }, "transferFrom(address,address,uint256)",
+			func() { a.op(vm.CALLER) },
+			func() { a.op(vm.ADDRESS) },
+			func() { a.load(amount) },
+		).require("STF")
+	}
+	// sqrt stores the integer square root of product in root by Newton's
+	// method
+	sqrt := func() {
+		loop, done := a.newLabel("sqrt"), a.newLabel("sqrt_done")
+		a.load(product).store(root).
+			push(2).load(product).push(1).op(vm.ADD, vm.DIV).store(guess).
+			label(loop).
+			load(root).load(guess).op(vm.LT, vm.ISZERO).jumpi(done).
+			load(guess).store(root).
+			push(2).load(guess).load(guess).load(product).op(vm.DIV, vm.ADD, vm.DIV).store(guess).
+			jump(loop).
+			label(done)
+	}
+	// deposit adds sqrt(amount0 * amount1) liquidity backed by both
+	// amounts, or the amount itself for a one-sided deposit, and emits
+	// IncreaseLiquidity
+	deposit := func() {
+		twoSided := a.newLabel("two_sided")
+		a.load(amount1).load(amount0).op(vm.MUL).store(product)
+		sqrt()
+		a.load(product).jumpi(twoSided).
+			load(amount1).load(amount0).op(vm.ADD).store(root).
+			label(twoSided)
+		a.load(root).store(liquidity)
+		add(fieldLiquidity, liquidity)
+		add(fieldDeposit0, amount0)
+		add(fieldDeposit1, amount1)
+		a.load(tokenID).push(increaseTopic).push(0x60).push(liquidity).op(vm.LOG2)
+	}
+	// slippage checks the amounts against the minimums in arguments i and i+1
+	slippage := func(i int) {
+		a.arg(i).load(amount0).op(vm.LT, vm.ISZERO).
+			arg(i+1).load(amount1).op(vm.LT, vm.ISZERO).op(vm.AND).
+			require("Price slippage check")
+	}
+
+	a.label("mint")
+	deadline(10)
+	a.arg(5).store(amount0).arg(6).store(amount1)
+	slippage(7)
+	a.push(0).op(vm.SLOAD).push(1).op(vm.ADD).op(vm.DUP1).store(tokenID).push(0).op(vm.SSTORE)
+	locate(func() { a.load(tokenID) })
+	a.arg(9)
+	set(fieldOwner)
+	for i, f := range []int{fieldToken0, fieldToken1, fieldFee, fieldTickLower, fieldTickUpper} {
+		a.arg(i)
+		set(f)
+	}
+	pull(fieldToken0, amount0)
+	pull(fieldToken1, amount1)
+	deposit()
+	a.returnWords(tokenID, 4)
+
+	a.label("increaseLiquidity")
+	deadline(5)
+	a.arg(0).store(tokenID).arg(1).store(amount0).arg(2).store(amount1)
+	slippage(3)
+	locate(func() { a.load(tokenID) })
+	exists()
+	pull(fieldToken0, amount0)
+	pull(fieldToken1, amount1)
+	deposit()
+	a.returnWords(liquidity, 3)
+
+	// Withdrawn deposits are owed to the owner until collected
+	a.label("decreaseLiquidity")
+	deadline(4)
+	locate(func() { a.arg(0) })
+	onlyOwner()
+	a.arg(1)
+	get(fieldLiquidity).op(vm.LT, vm.ISZERO).require("insufficient liquidity")
+	for _, withdraw := range []struct{ deposit, amount int }{{fieldDeposit0, amount0}, {fieldDeposit1, amount1}} {
+		get(fieldLiquidity)
+		a.arg(1)
+		get(withdraw.deposit).op(vm.MUL, vm.DIV).store(withdraw.amount)
+	}
+	slippage(2)
+	a.arg(1).store(liquidity)
+	sub(fieldLiquidity, liquidity)
+	sub(fieldDeposit0, amount0)
+	sub(fieldDeposit1, amount1)
+	add(fieldOwed0, amount0)
+	add(fieldOwed1, amount1)
+	a.returnWords(amount0, 2)
+
+	a.label("collect")
+	locate(func() { a.arg(0) })
+	onlyOwner()
+	for i, owed := range []struct{ field, amount, token int }{{fieldOwed0, amount0, fieldToken0}, {fieldOwed1, amount1, fieldToken1}} {
+		capped := a.newLabel("capped")
+		get(owed.field).store(owed.amount)
+		a.load(owed.amount).arg(2+i).op(vm.LT, vm.ISZERO).jumpi(capped).
+			arg(2 + i).store(owed.amount).
+			label(capped)
+		sub(owed.field, owed.amount)
+		a.call(func() { get(owed.token) }, "transfer(address,uint256)",
+			func() { a.arg(1) },
+			func() { a.load(owed.amount) },
+		).require("insufficient liquidity")
+	}
+	a.returnWords(amount0, 2)
+
+	a.label("burn")
+	locate(func() { a.arg(0) })
+	onlyOwner()
+	get(fieldLiquidity)
+	get(fieldOwed0).op(vm.OR)
+	get(fieldOwed1).op(vm.OR, vm.ISZERO).require("Not cleared")
+	a.push(0)
+	set(fieldOwner)
+	a.stop()
+
+	a.label("positions")
+	locate(func() { a.arg(0) })
+	exists()
+	a.push(0).store(fields).push(0).store(fields + 0x20)
+	for i, f := range []int{fieldToken0, fieldToken1, fieldFee, fieldTickLower, fieldTickUpper, fieldLiquidity} {
+		get(f).store(fields + 0x40 + 0x20*i)
+	}
+	a.push(0).store(fields + 0x100).push(0).store(fields + 0x120)
+	get(fieldOwed0).store(fields + 0x140)
+	get(fieldOwed1).store(fields + 0x160)
+	a.returnWords(fields, 12)
+
+	a.label("accrueFees")
+	locate(func() { a.arg(0) })
+	exists()
+	a.arg(1).store(amount0).arg(2).store(amount1)
+	add(fieldOwed0, amount0)
+	add(fieldOwed1, amount1)
+	a.stop()
+
+	return deployCode(a.bytes())
+}
@@ -21,6 +21,7 @@ const (
 	ErrNetwork        ErrorType = "NETWORK_ERROR"
 	ErrConfiguration  ErrorType = "CONFIGURATION_ERROR"
 	ErrExecution      ErrorType = "EXECUTION_ERROR"
+	ErrSimulation     ErrorType = "SIMULATION_ERROR"
 )
 
 // DeFiError represents a structured error in DeFi operations
@@ -202,3 +203,11 @@ func IsBlockchainError(err error) bool {
 	}
 	return false
 }
+
+func IsSimulationError(err error) bool {
+	var defiErr *DeFiError
+	if errors.As(err, &defiErr) {
+		return defiErr.Type == ErrSimulation
+	}
+	return false
+}
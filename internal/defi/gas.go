@@ -0,0 +1,295 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+)
+
+// GasStrategy prices a transaction for inclusion in the next block. baseFee
+// is nil on chains without EIP-1559, in which case feeCap is used as the
+// legacy gas price.
+type GasStrategy interface {
+	SuggestFees(ctx context.Context, client Backend, baseFee *big.Int) (tipCap, feeCap *big.Int, err error)
+}
+
+// PercentileStrategy tips a percentile of the priority fees paid in recent
+// blocks and leaves headroom for the base fee to rise before inclusion
+type PercentileStrategy struct {
+	Percentile        float64 // of recent priority fees, 0-100
+	Blocks            uint64  // recent blocks sampled
+	BaseFeeMultiplier float64 // fee cap = base fee * multiplier + tip
+	LegacyMultiplier  float64 // applied to the node's gas price on legacy chains
+}
+
+// Gas strategy presets
+var (
+	FastGas     = PercentileStrategy{Percentile: 90, Blocks: 20, BaseFeeMultiplier: 2, LegacyMultiplier: 1.25}
+	StandardGas = PercentileStrategy{Percentile: 50, Blocks: 20, BaseFeeMultiplier: 2, LegacyMultiplier: 1}
+	EconomyGas  = PercentileStrategy{Percentile: 20, Blocks: 20, BaseFeeMultiplier: 1.25, LegacyMultiplier: 0.9}
+)
+
+// SuggestFees implements GasStrategy
+func (s PercentileStrategy) SuggestFees(ctx context.Context, client Backend, baseFee *big.Int) (*big.Int, *big.Int, error) {
+	if baseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get gas price: %v", err)
+		}
+		gasPrice = mulFloat(gasPrice, s.LegacyMultiplier)
+		return gasPrice, gasPrice, nil
+	}
+
+	tip, err := s.priorityFee(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	feeCap := new(big.Int).Add(mulFloat(baseFee, s.BaseFeeMultiplier), tip)
+	return tip, feeCap, nil
+}
+
+// priorityFee returns the median across recent blocks of each block's
+// Percentile priority fee. Nodes without eth_feeHistory, and ranges of empty
+// blocks, fall back to the node's own tip suggestion.
+func (s PercentileStrategy) priorityFee(ctx context.Context, client Backend) (*big.Int, error) {
+	blocks := s.Blocks
+	if blocks == 0 {
+		blocks = StandardGas.Blocks
+	}
+
+	history, err := client.FeeHistory(ctx, blocks, nil, []float64{s.Percentile})
+	if err == nil {
+		var samples []*big.Int
+		for i, rewards := range history.Reward {
+			// Empty blocks report zero rewards that say nothing about the market
+			if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+				continue
+			}
+			if len(rewards) > 0 && rewards[0] != nil {
+				samples = append(samples, rewards[0])
+			}
+		}
+		if len(samples) > 0 {
+			sort.Slice(samples, func(i, j int) bool { return samples[i].Cmp(samples[j]) < 0 })
+			return new(big.Int).Set(samples[len(samples)/2]), nil
+		}
+	}
+
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get priority fee: %v", err)
+	}
+	return tip, nil
+}
+
+// FixedStrategy always pays the same price per gas, as both tip and fee cap
+type FixedStrategy struct {
+	GasPrice *big.Int
+}
+
+// SuggestFees implements GasStrategy
+func (s FixedStrategy) SuggestFees(ctx context.Context, client Backend, baseFee *big.Int) (*big.Int, *big.Int, error) {
+	return new(big.Int).Set(s.GasPrice), new(big.Int).Set(s.GasPrice), nil
+}
+
+// TxFees is the fee pricing for one transaction. GasPrice is set for legacy
+// transactions, GasTipCap and GasFeeCap for EIP-1559 ones.
+type TxFees struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// IsLegacy reports whether the fees are for a pre-London transaction
+func (f TxFees) IsLegacy() bool {
+	return f.GasPrice != nil
+}
+
+// GasPolicy turns a GasStrategy into concrete transaction fees and gas limits,
+// enforcing hard fee caps and a safety margin over gas estimates
+type GasPolicy struct {
+	Strategy             GasStrategy
+	MaxFeePerGas         *big.Int // nil for no cap
+	MaxPriorityFeePerGas *big.Int // nil for no cap
+	GasLimitMultiplier   float64
+	MinGasLimit          uint64
+	FallbackGasPrice     *big.Int // used when the strategy cannot price fees
+}
+
+// DefaultGasPolicy returns the standard strategy with a 20% gas margin
+func DefaultGasPolicy() *GasPolicy {
+	return &GasPolicy{
+		Strategy:           StandardGas,
+		GasLimitMultiplier: 1.2,
+		MinGasLimit:        params.TxGas,
+	}
+}
+
+// NewGasPolicy builds a gas policy from the blockchain configuration
+func NewGasPolicy(cfg config.BlockchainConfig) (*GasPolicy, error) {
+	if err := cfg.Gas.Validate(); err != nil {
+		return nil, err
+	}
+
+	policy := DefaultGasPolicy()
+	if cfg.GasPrice > 0 {
+		policy.FallbackGasPrice = gweiToWei(float64(cfg.GasPrice))
+	}
+	if cfg.GasLimit > 0 {
+		policy.MinGasLimit = cfg.GasLimit
+	}
+	if cfg.Gas.GasLimitMultiplier > 0 {
+		policy.GasLimitMultiplier = cfg.Gas.GasLimitMultiplier
+	}
+	if cfg.Gas.MaxFeePerGas > 0 {
+		policy.MaxFeePerGas = gweiToWei(cfg.Gas.MaxFeePerGas)
+	}
+	if cfg.Gas.MaxPriorityFeePerGas > 0 {
+		policy.MaxPriorityFeePerGas = gweiToWei(cfg.Gas.MaxPriorityFeePerGas)
+	}
+
+	var strategy PercentileStrategy
+	switch cfg.Gas.Strategy {
+	case "fast":
+		strategy = FastGas
+	case "", "standard":
+		strategy = StandardGas
+	case "economy":
+		strategy = EconomyGas
+	case "percentile":
+		strategy = StandardGas
+		strategy.Percentile = cfg.Gas.Percentile
+	case "fixed":
+		if policy.FallbackGasPrice == nil {
+			return nil, fmt.Errorf("fixed gas strategy requires a gas price")
+		}
+		policy.Strategy = FixedStrategy{GasPrice: policy.FallbackGasPrice}
+		return policy, nil
+	}
+	if cfg.Gas.HistoryBlocks > 0 {
+		strategy.Blocks = cfg.Gas.HistoryBlocks
+	}
+	policy.Strategy = strategy
+
+	return policy, nil
+}
+
+// Fees prices a transaction for the next block. Chains whose latest header
+// has no base fee get legacy pricing.
+func (p *GasPolicy) Fees(ctx context.Context, client Backend) (TxFees, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return TxFees{}, fmt.Errorf("failed to get latest header: %v", err)
+	}
+	baseFee := head.BaseFee
+
+	tip, feeCap, err := p.Strategy.SuggestFees(ctx, client, baseFee)
+	if err != nil {
+		if p.FallbackGasPrice == nil {
+			return TxFees{}, err
+		}
+		tip, feeCap = new(big.Int).Set(p.FallbackGasPrice), new(big.Int).Set(p.FallbackGasPrice)
+	}
+
+	if p.MaxFeePerGas != nil && feeCap.Cmp(p.MaxFeePerGas) > 0 {
+		feeCap = new(big.Int).Set(p.MaxFeePerGas)
+	}
+
+	if baseFee == nil {
+		return TxFees{GasPrice: feeCap}, nil
+	}
+
+	if p.MaxPriorityFeePerGas != nil && tip.Cmp(p.MaxPriorityFeePerGas) > 0 {
+		tip = new(big.Int).Set(p.MaxPriorityFeePerGas)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	if feeCap.Cmp(baseFee) < 0 {
+		return TxFees{}, fmt.Errorf("max fee per gas %s is below the current base fee %s", feeCap, baseFee)
+	}
+
+	return TxFees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// GasLimit applies the safety multiplier and minimum to a gas estimate
+func (p *GasPolicy) GasLimit(estimate uint64) uint64 {
+	limit := estimate
+	if p.GasLimitMultiplier > 1 {
+		limit = uint64(math.Ceil(float64(estimate) * p.GasLimitMultiplier))
+	}
+	if limit < p.MinGasLimit {
+		limit = p.MinGasLimit
+	}
+	return limit
+}
+
+// BuildTransaction estimates gas and prices an unsigned transaction from
+// from; a nil to creates a contract
+func (p *GasPolicy) BuildTransaction(ctx context.Context, client Backend, chainID *big.Int, from common.Address, nonce uint64, to *common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	estimate, err := client.EstimateGas(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    to,
+		Value: value,
+		Data:  data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+
+	fees, err := p.Fees(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit := p.GasLimit(estimate)
+	if fees.IsLegacy() {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.GasPrice,
+			Gas:      gasLimit,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}), nil
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}), nil
+}
+
+// mulFloat scales a wei amount by a float factor
+func mulFloat(amount *big.Int, factor float64) *big.Int {
+	if factor == 0 || factor == 1 {
+		return new(big.Int).Set(amount)
+	}
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(amount), big.NewFloat(factor)).Int(nil)
+	return scaled
+}
+
+// gweiToWei converts a gwei amount to wei
+func gweiToWei(gwei float64) *big.Int {
+	return mulFloat(big.NewInt(params.GWei), gwei)
+}
@@ -0,0 +1,250 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func gwei(amount float64) *big.Int {
+	return gweiToWei(amount)
+}
+
+// feeMarket is a Backend that only answers fee queries. Each block's reward
+// at percentile p is p/10 gwei.
+type feeMarket struct {
+	Backend
+	baseFee    *big.Int
+	gasPrice   *big.Int
+	tip        *big.Int
+	historyErr error
+}
+
+func (m *feeMarket) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), BaseFee: m.baseFee}, nil
+}
+
+func (m *feeMarket) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return m.gasPrice, nil
+}
+
+func (m *feeMarket) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return m.tip, nil
+}
+
+func (m *feeMarket) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	if m.historyErr != nil {
+		return nil, m.historyErr
+	}
+	history := &ethereum.FeeHistory{OldestBlock: big.NewInt(int64(101 - blockCount))}
+	for i := uint64(0); i < blockCount; i++ {
+		reward := gwei(rewardPercentiles[0] / 10)
+		ratio := 0.5
+		if i == 0 {
+			// An empty block is ignored
+			reward, ratio = big.NewInt(0), 0
+		}
+		history.Reward = append(history.Reward, []*big.Int{reward})
+		history.GasUsedRatio = append(history.GasUsedRatio, ratio)
+	}
+	return history, nil
+}
+
+// legacyBackend hides the base fee of a London chain so the gas policy
+// prices transactions as if London had not activated
+type legacyBackend struct {
+	Backend
+}
+
+func (b legacyBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	head, err := b.Backend.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	head = types.CopyHeader(head)
+	head.BaseFee = nil
+	return head, nil
+}
+
+func TestGasStrategies(t *testing.T) {
+	market := &feeMarket{baseFee: gwei(10), gasPrice: gwei(20), tip: gwei(1)}
+
+	tests := []struct {
+		name     string
+		strategy GasStrategy
+		tip      *big.Int
+		feeCap   *big.Int
+	}{
+		{"fast", FastGas, gwei(9), gwei(29)},
+		{"standard", StandardGas, gwei(5), gwei(25)},
+		{"economy", EconomyGas, gwei(2), gwei(14.5)},
+		{"fixed", FixedStrategy{GasPrice: gwei(30)}, gwei(30), gwei(30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultGasPolicy()
+			policy.Strategy = tt.strategy
+
+			fees, err := policy.Fees(context.Background(), market)
+			require.NoError(t, err)
+			assert.False(t, fees.IsLegacy())
+			assert.Equal(t, tt.tip.String(), fees.GasTipCap.String())
+			assert.Equal(t, tt.feeCap.String(), fees.GasFeeCap.String())
+		})
+	}
+}
+
+func TestGasPolicyFeeHistoryUnavailable(t *testing.T) {
+	market := &feeMarket{baseFee: gwei(10), tip: gwei(1.5), historyErr: errors.New("method not found")}
+
+	fees, err := DefaultGasPolicy().Fees(context.Background(), market)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(1.5).String(), fees.GasTipCap.String())
+	assert.Equal(t, gwei(21.5).String(), fees.GasFeeCap.String())
+}
+
+func TestGasPolicyCaps(t *testing.T) {
+	market := &feeMarket{baseFee: gwei(10), gasPrice: gwei(20), tip: gwei(1)}
+	policy := DefaultGasPolicy()
+	policy.Strategy = FastGas
+
+	policy.MaxFeePerGas = gwei(20)
+	policy.MaxPriorityFeePerGas = gwei(3)
+	fees, err := policy.Fees(context.Background(), market)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(3).String(), fees.GasTipCap.String())
+	assert.Equal(t, gwei(20).String(), fees.GasFeeCap.String())
+
+	// The tip never exceeds the fee cap
+	policy.Strategy = FixedStrategy{GasPrice: gwei(30)}
+	policy.MaxFeePerGas = gwei(12)
+	policy.MaxPriorityFeePerGas = nil
+	fees, err = policy.Fees(context.Background(), market)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(12).String(), fees.GasTipCap.String())
+	assert.Equal(t, gwei(12).String(), fees.GasFeeCap.String())
+
+	// A cap under the base fee could never be included
+	policy.MaxFeePerGas = gwei(5)
+	_, err = policy.Fees(context.Background(), market)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "below the current base fee")
+}
+
+func TestGasPolicyLegacyChain(t *testing.T) {
+	market := &feeMarket{gasPrice: gwei(20)}
+	policy := DefaultGasPolicy()
+
+	fees, err := policy.Fees(context.Background(), market)
+	require.NoError(t, err)
+	assert.True(t, fees.IsLegacy())
+	assert.Equal(t, gwei(20).String(), fees.GasPrice.String())
+
+	policy.Strategy = FastGas
+	fees, err = policy.Fees(context.Background(), market)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(25).String(), fees.GasPrice.String())
+
+	policy.MaxFeePerGas = gwei(22)
+	fees, err = policy.Fees(context.Background(), market)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(22).String(), fees.GasPrice.String())
+}
+
+func TestGasPolicyGasLimit(t *testing.T) {
+	policy := DefaultGasPolicy()
+	assert.Equal(t, uint64(120_000), policy.GasLimit(100_000))
+	assert.Equal(t, uint64(60_002), policy.GasLimit(50_001))
+	assert.Equal(t, params.TxGas, policy.GasLimit(10_000))
+
+	policy.MinGasLimit = 200_000
+	assert.Equal(t, uint64(200_000), policy.GasLimit(100_000))
+}
+
+func TestNewGasPolicy(t *testing.T) {
+	cfg := config.BlockchainConfig{
+		GasPrice: 40,
+		GasLimit: 50_000,
+		Gas: config.GasConfig{
+			Strategy:             "percentile",
+			Percentile:           75,
+			HistoryBlocks:        10,
+			MaxFeePerGas:         150,
+			MaxPriorityFeePerGas: 2.5,
+			GasLimitMultiplier:   1.5,
+		},
+	}
+
+	policy, err := NewGasPolicy(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, PercentileStrategy{Percentile: 75, Blocks: 10, BaseFeeMultiplier: 2, LegacyMultiplier: 1}, policy.Strategy)
+	assert.Equal(t, gwei(150).String(), policy.MaxFeePerGas.String())
+	assert.Equal(t, "2500000000", policy.MaxPriorityFeePerGas.String())
+	assert.Equal(t, gwei(40).String(), policy.FallbackGasPrice.String())
+	assert.Equal(t, 1.5, policy.GasLimitMultiplier)
+	assert.Equal(t, uint64(50_000), policy.MinGasLimit)
+
+	cfg.Gas = config.GasConfig{Strategy: "fixed"}
+	policy, err = NewGasPolicy(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, FixedStrategy{GasPrice: gwei(40)}, policy.Strategy)
+
+	cfg.GasPrice = 0
+	_, err = NewGasPolicy(cfg)
+	assert.Error(t, err)
+
+	cfg.Gas = config.GasConfig{Strategy: "instant"}
+	_, err = NewGasPolicy(cfg)
+	assert.Error(t, err)
+}
+
+func TestDynamicFeeTransactionsOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+
+	data, err := cm.Contracts["erc20_USDC"].ABI.Pack(ERC20Approve, chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+	estimate, err := chain.Client.EstimateGas(context.Background(), ethereum.CallMsg{
+		From: chain.Account,
+		To:   &chain.USDC,
+		Data: data,
+	})
+	require.NoError(t, err)
+
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+	assert.Equal(t, cm.Gas.GasLimit(estimate), tx.Gas())
+	assert.Greater(t, tx.Gas(), estimate)
+	assert.Equal(t, chain.ChainID.String(), tx.ChainId().String())
+
+	receipt := mine(t, chain, cm, tx)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.LessOrEqual(t, receipt.GasUsed, tx.Gas())
+
+	// A chain without a base fee gets legacy transactions
+	bm, err := NewRealBlockchainManagerWithBackend(legacyBackend{chain.Client}, chain.PrivateKeyHex())
+	require.NoError(t, err)
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	tx, err = bm.SendTransaction(recipient, defitest.Ether(1), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(types.LegacyTxType), tx.Type())
+	assert.Greater(t, tx.Gas(), params.TxGas)
+
+	chain.Commit()
+	receipt, err = bm.WaitForTransaction(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
@@ -0,0 +1,682 @@
+package defi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Health guardian defaults
+const (
+	DefaultGuardianInterval = 30 * time.Second
+	DefaultGuardianWarning  = 1.5
+	DefaultGuardianCritical = 1.2
+	DefaultGuardianTarget   = 1.6
+
+	// DefaultGuardianSlippageBps is the extra collateral a flash-loan plan
+	// swaps to cover price moves between the oracle and the swap pool
+	DefaultGuardianSlippageBps = 50
+
+	// DefaultGuardianPendingTimeout is how long a deleverage transaction
+	// may stay unmined before it is resent with higher fees
+	DefaultGuardianPendingTimeout = 5 * time.Minute
+)
+
+// Alerter receives the alerts raised by the health guardian.
+// monitoring.AlertManager implements it.
+type Alerter interface {
+	SendAlert(title, message, severity string)
+}
+
+// HealthLevel classifies a health factor against the guardian thresholds
+type HealthLevel string
+
+const (
+	HealthOK       HealthLevel = "ok"
+	HealthWarning  HealthLevel = "warning"
+	HealthCritical HealthLevel = "critical"
+)
+
+// GuardianThresholds are the health factors below which the guardian warns
+// and deleverages, and the health factor a deleverage restores
+type GuardianThresholds struct {
+	Warning  float64
+	Critical float64
+	Target   float64
+}
+
+// Validate checks that the thresholds are ordered
+// 1 < Critical <= Warning < Target
+func (t GuardianThresholds) Validate() error {
+	if t.Critical <= 1 {
+		return fmt.Errorf("critical health factor %.2f must be above 1", t.Critical)
+	}
+	if t.Warning < t.Critical {
+		return fmt.Errorf("warning health factor %.2f is below the critical %.2f", t.Warning, t.Critical)
+	}
+	if t.Target <= t.Warning {
+		return fmt.Errorf("target health factor %.2f must be above the warning %.2f", t.Target, t.Warning)
+	}
+	return nil
+}
+
+// level classifies healthFactor
+func (t GuardianThresholds) level(healthFactor float64) HealthLevel {
+	switch {
+	case healthFactor < t.Critical:
+		return HealthCritical
+	case healthFactor < t.Warning:
+		return HealthWarning
+	default:
+		return HealthOK
+	}
+}
+
+// GuardedWallet is an Aave account watched by the guardian. Deleveraging
+// repays DebtAsset; when the manager's account cannot fund the repayment, a
+// flash-loan plan withdraws CollateralAsset instead and swaps it to
+// DebtAsset, which requires the wallet to be the manager's own account and
+// its collateral aToken to support EIP-2612 permits.
+type GuardedWallet struct {
+	Address         common.Address
+	DebtAsset       common.Address
+	CollateralAsset common.Address
+
+	// InterestRateMode of the repaid debt; VariableRate if nil
+	InterestRateMode *big.Int
+
+	// SwapFee is the Uniswap V3 pool fee for the collateral swap;
+	// FeeTierMedium if zero
+	SwapFee uint24
+
+	// Thresholds overrides the guardian's thresholds when Target is set
+	Thresholds GuardianThresholds
+}
+
+// DeleveragePlan is a repayment sized to restore a wallet's target health
+// factor. Amounts are in token units; a flash-loan plan withdraws
+// WithdrawAmount of collateral to pay back the loan of RepayAmount.
+type DeleveragePlan struct {
+	Wallet               common.Address
+	HealthFactor         float64
+	TargetHealthFactor   float64
+	ExpectedHealthFactor float64
+
+	DebtAsset        common.Address
+	RepayAmount      *big.Int
+	InterestRateMode *big.Int
+
+	FlashLoan       bool
+	CollateralAsset common.Address
+	WithdrawAmount  *big.Int
+	SwapFee         uint24
+}
+
+// String describes the plan for logs and alerts
+func (p *DeleveragePlan) String() string {
+	if p.FlashLoan {
+		return fmt.Sprintf("flash-loan repay %s of %s, withdrawing %s of %s, health factor %.3f -> %.3f",
+			p.RepayAmount, p.DebtAsset.Hex(), p.WithdrawAmount, p.CollateralAsset.Hex(), p.HealthFactor, p.ExpectedHealthFactor)
+	}
+	return fmt.Sprintf("repay %s of %s, health factor %.3f -> %.3f",
+		p.RepayAmount, p.DebtAsset.Hex(), p.HealthFactor, p.ExpectedHealthFactor)
+}
+
+// GuardianStatus is the guardian's latest view of a wallet
+type GuardianStatus struct {
+	Wallet       common.Address
+	HealthFactor float64
+	Level        HealthLevel
+	CheckedAt    time.Time
+
+	// LastPlan is the latest deleverage plan, executed or dry-run, and
+	// PendingTx its transaction until it is mined, sent at PendingSince
+	LastPlan     *DeleveragePlan
+	PendingTx    common.Hash
+	PendingSince time.Time
+	Error        string
+}
+
+// HealthGuardian watches the health factor of Aave accounts, alerts when
+// it crosses the warning or critical threshold, and deleverages critical
+// accounts back to the target health factor. In DryRun mode plans are only
+// logged and alerted.
+type HealthGuardian struct {
+	Aave       *AaveManager
+	Alerts     Alerter
+	Thresholds GuardianThresholds
+	Interval   time.Duration
+	DryRun     bool
+
+	// FlashLoans allows plans that fund the repayment with a flash loan
+	// when the manager's account holds too little of the debt asset
+	FlashLoans  bool
+	SlippageBps int64
+
+	// PendingTimeout is how long a deleverage transaction may stay
+	// unmined before it is resent with higher fees, or dropped and
+	// replanned when it cannot be resent
+	PendingTimeout time.Duration
+
+	Clock Clock
+
+	mu      sync.Mutex
+	wallets map[common.Address]GuardedWallet
+	status  map[common.Address]*GuardianStatus
+}
+
+// NewHealthGuardian creates a guardian with the default thresholds. alerts
+// may be nil.
+func NewHealthGuardian(aave *AaveManager, alerts Alerter) *HealthGuardian {
+	return &HealthGuardian{
+		Aave:   aave,
+		Alerts: alerts,
+		Thresholds: GuardianThresholds{
+			Warning:  DefaultGuardianWarning,
+			Critical: DefaultGuardianCritical,
+			Target:   DefaultGuardianTarget,
+		},
+		Interval:       DefaultGuardianInterval,
+		SlippageBps:    DefaultGuardianSlippageBps,
+		PendingTimeout: DefaultGuardianPendingTimeout,
+		Clock:          SystemClock,
+		wallets:        make(map[common.Address]GuardedWallet),
+		status:         make(map[common.Address]*GuardianStatus),
+	}
+}
+
+// Watch starts guarding a wallet, replacing an earlier entry for the same
+// address
+func (g *HealthGuardian) Watch(wallet GuardedWallet) error {
+	if wallet.Thresholds.Target != 0 {
+		if err := wallet.Thresholds.Validate(); err != nil {
+			return err
+		}
+	}
+	if wallet.InterestRateMode == nil {
+		wallet.InterestRateMode = VariableRate
+	}
+	if err := validateRateMode(wallet.InterestRateMode); err != nil {
+		return err
+	}
+	if wallet.SwapFee == 0 {
+		wallet.SwapFee = FeeTierMedium
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.wallets[wallet.Address] = wallet
+	if _, exists := g.status[wallet.Address]; !exists {
+		g.status[wallet.Address] = &GuardianStatus{Wallet: wallet.Address, Level: HealthOK}
+	}
+	return nil
+}
+
+// Unwatch stops guarding a wallet
+func (g *HealthGuardian) Unwatch(address common.Address) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.wallets, address)
+	delete(g.status, address)
+}
+
+// Status returns the latest status of every guarded wallet, sorted by
+// address
+func (g *HealthGuardian) Status() []GuardianStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	statuses := make([]GuardianStatus, 0, len(g.status))
+	for _, status := range g.status {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return bytes.Compare(statuses[i].Wallet[:], statuses[j].Wallet[:]) < 0
+	})
+	return statuses
+}
+
+// Start checks every guarded wallet each Interval until ctx is done
+func (g *HealthGuardian) Start(ctx context.Context) error {
+	if err := g.Thresholds.Validate(); err != nil {
+		return err
+	}
+
+	interval := g.Interval
+	if interval <= 0 {
+		interval = DefaultGuardianInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				g.Check(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+// Check reads the health factor of every guarded wallet, raises alerts on
+// threshold crossings and deleverages critical wallets, returning their
+// updated status. Failures are alerted once until the error changes.
+func (g *HealthGuardian) Check(ctx context.Context) []GuardianStatus {
+	g.mu.Lock()
+	wallets := make([]GuardedWallet, 0, len(g.wallets))
+	for _, wallet := range g.wallets {
+		wallets = append(wallets, wallet)
+	}
+	g.mu.Unlock()
+
+	for _, wallet := range wallets {
+		if ctx.Err() != nil {
+			break
+		}
+		g.checkWallet(ctx, wallet)
+	}
+	return g.Status()
+}
+
+// checkWallet updates one wallet's status and acts on its health factor
+func (g *HealthGuardian) checkWallet(ctx context.Context, wallet GuardedWallet) {
+	g.mu.Lock()
+	status, exists := g.status[wallet.Address]
+	if !exists {
+		g.mu.Unlock()
+		return
+	}
+	previous := *status
+	g.mu.Unlock()
+
+	next := previous
+	next.CheckedAt = g.now()
+	next.Error = ""
+
+	thresholds := g.thresholds(wallet)
+	account, err := g.Aave.GetUserAccountData(wallet.Address)
+	if err != nil {
+		next.Error = err.Error()
+		log.Printf("Guardian failed to read %s: %v", wallet.Address.Hex(), err)
+		g.update(next)
+		return
+	}
+	healthFactor, _ := account.HealthFactorFloat().Float64()
+	next.HealthFactor = healthFactor
+	next.Level = thresholds.level(healthFactor)
+
+	if next.Level != previous.Level {
+		g.alertLevel(wallet.Address, previous.Level, next.Level, healthFactor)
+	}
+
+	// A deleverage already in flight is given time to be mined
+	if next.PendingTx != (common.Hash{}) {
+		if receipt, err := g.Aave.ContractManager.GetTransactionReceipt(next.PendingTx); err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				g.alert("Deleverage Failed", fmt.Sprintf("Deleverage transaction %s for %s reverted", next.PendingTx.Hex(), wallet.Address.Hex()), "critical")
+			}
+			next.PendingTx = common.Hash{}
+		} else if g.PendingTimeout > 0 && next.CheckedAt.Sub(next.PendingSince) >= g.PendingTimeout {
+			g.resendPending(wallet, &next)
+		}
+	}
+
+	if next.Level != HealthCritical || next.PendingTx != (common.Hash{}) {
+		g.update(next)
+		return
+	}
+
+	plan, err := g.Plan(wallet, account, thresholds.Target)
+	if err != nil {
+		next.Error = err.Error()
+		log.Printf("Guardian cannot deleverage %s: %v", wallet.Address.Hex(), err)
+		if next.Error != previous.Error {
+			g.alert("Deleverage Impossible", fmt.Sprintf("Cannot restore health factor of %s: %v", wallet.Address.Hex(), err), "critical")
+		}
+		g.update(next)
+		return
+	}
+	next.LastPlan = plan
+
+	if g.DryRun {
+		log.Printf("Guardian dry run for %s: %s", wallet.Address.Hex(), plan)
+		if previous.Level != HealthCritical {
+			g.alert("Deleverage Planned", fmt.Sprintf("Dry run for %s: %s", wallet.Address.Hex(), plan), "warning")
+		}
+		g.update(next)
+		return
+	}
+
+	tx, err := g.Execute(ctx, plan)
+	if err != nil {
+		next.Error = err.Error()
+		log.Printf("Guardian failed to deleverage %s: %v", wallet.Address.Hex(), err)
+		if next.Error != previous.Error {
+			g.alert("Deleverage Failed", fmt.Sprintf("Failed to deleverage %s: %v", wallet.Address.Hex(), err), "critical")
+		}
+		g.update(next)
+		return
+	}
+	next.PendingTx = tx.Hash()
+	next.PendingSince = next.CheckedAt
+	log.Printf("Guardian deleveraging %s: %s (%s)", wallet.Address.Hex(), plan, tx.Hash().Hex())
+	g.alert("Deleverage Sent", fmt.Sprintf("Deleveraging %s: %s in %s", wallet.Address.Hex(), plan, tx.Hash().Hex()), "warning")
+	g.update(next)
+}
+
+// resendPending replaces a deleverage transaction that has been pending
+// past PendingTimeout with one paying higher fees. When it cannot be
+// replaced, e.g. because the fees would exceed the gas policy's cap, it is
+// dropped so the wallet is replanned.
+func (g *HealthGuardian) resendPending(wallet GuardedWallet, next *GuardianStatus) {
+	stuck := next.PendingTx
+	tx, err := g.Aave.ContractManager.SpeedUpTransaction(stuck)
+	if err != nil {
+		log.Printf("Guardian failed to resend %s for %s: %v", stuck.Hex(), wallet.Address.Hex(), err)
+		g.alert("Deleverage Stuck", fmt.Sprintf("Deleverage transaction %s for %s was not mined within %s and could not be resent: %v",
+			stuck.Hex(), wallet.Address.Hex(), g.PendingTimeout, err), "critical")
+		next.PendingTx = common.Hash{}
+		return
+	}
+	log.Printf("Guardian resent %s for %s as %s", stuck.Hex(), wallet.Address.Hex(), tx.Hash().Hex())
+	g.alert("Deleverage Resent", fmt.Sprintf("Deleverage transaction %s for %s was not mined within %s, resent as %s",
+		stuck.Hex(), wallet.Address.Hex(), g.PendingTimeout, tx.Hash().Hex()), "warning")
+	next.PendingTx = tx.Hash()
+	next.PendingSince = next.CheckedAt
+}
+
+// Plan sizes the repayment that brings a wallet's health factor back to
+// target. The manager's account repays directly when it holds enough of the
+// debt asset; otherwise, if FlashLoans is set, a flash loan repays and is
+// paid back by withdrawing and swapping collateral.
+func (g *HealthGuardian) Plan(wallet GuardedWallet, account *AaveAccountData, target float64) (*DeleveragePlan, error) {
+	if account.TotalDebtBase == nil || account.TotalDebtBase.Sign() == 0 {
+		return nil, fmt.Errorf("wallet has no debt")
+	}
+	healthFactor, _ := account.HealthFactorFloat().Float64()
+	if healthFactor >= target {
+		return nil, fmt.Errorf("health factor %.3f already meets the target %.3f", healthFactor, target)
+	}
+	rateMode := wallet.InterestRateMode
+	if rateMode == nil {
+		rateMode = VariableRate
+	}
+	swapFee := wallet.SwapFee
+	if swapFee == 0 {
+		swapFee = FeeTierMedium
+	}
+
+	plan := &DeleveragePlan{
+		Wallet:             wallet.Address,
+		HealthFactor:       healthFactor,
+		TargetHealthFactor: target,
+		DebtAsset:          wallet.DebtAsset,
+		InterestRateMode:   rateMode,
+	}
+
+	repayBase, _, err := deleverageAmounts(account, target, 0)
+	if err != nil {
+		return nil, err
+	}
+	repay, err := g.Aave.BaseToToken(wallet.DebtAsset, repayBase)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := g.Aave.ContractManager.tokenUint(wallet.DebtAsset, ERC20BalanceOf, g.Aave.ContractManager.Transactor.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get debt asset balance: %v", err)
+	}
+	if balance.Cmp(repay) >= 0 {
+		plan.RepayAmount = repay
+		plan.ExpectedHealthFactor = healthAfter(account, repayBase, new(big.Int))
+		return plan, nil
+	}
+	if !g.FlashLoans {
+		return nil, fmt.Errorf("repaying needs %s of %s but only %s is available and flash loans are disabled",
+			repay, wallet.DebtAsset.Hex(), balance)
+	}
+
+	// Each unit repaid costs the flash loan premium, the swap fee and the
+	// slippage allowance in collateral
+	premiumBps, err := g.Aave.FlashLoanPremiumBps()
+	if err != nil {
+		return nil, err
+	}
+	cost := (1 + float64(premiumBps)/10_000) / (1 - float64(swapFee)/1e6) * (1 + float64(g.SlippageBps)/10_000)
+	repayBase, withdrawBase, err := deleverageAmounts(account, target, cost)
+	if err != nil {
+		return nil, err
+	}
+	if plan.RepayAmount, err = g.Aave.BaseToToken(wallet.DebtAsset, repayBase); err != nil {
+		return nil, err
+	}
+	if plan.WithdrawAmount, err = g.Aave.BaseToToken(wallet.CollateralAsset, withdrawBase); err != nil {
+		return nil, err
+	}
+	plan.FlashLoan = true
+	plan.CollateralAsset = wallet.CollateralAsset
+	plan.SwapFee = swapFee
+	plan.ExpectedHealthFactor = healthAfter(account, repayBase, withdrawBase)
+	return plan, nil
+}
+
+// Execute sends a deleverage plan: a repay from the manager's account, or
+// a bundle that flash-borrows the debt asset, repays, pulls the wallet's
+// collateral aTokens, withdraws and swaps them to pay back the loan. The
+// aTokens are pulled with an EIP-2612 permit for exactly the withdrawal,
+// signed for this bundle, so flash loans only deleverage the manager's own
+// account and the wallet never approves the executor. Any swap surplus
+// stays with the bundle executor.
+func (g *HealthGuardian) Execute(ctx context.Context, plan *DeleveragePlan) (*types.Transaction, error) {
+	if !plan.FlashLoan {
+		return g.Aave.ExecuteRepay(RepayParams{
+			Asset:            plan.DebtAsset,
+			Amount:           plan.RepayAmount,
+			InterestRateMode: plan.InterestRateMode,
+			OnBehalfOf:       plan.Wallet,
+		})
+	}
+
+	cm := g.Aave.ContractManager
+	if plan.Wallet != cm.Transactor.From {
+		return nil, fmt.Errorf("flash loan deleveraging needs wallet %s to be the manager's account %s",
+			plan.Wallet.Hex(), cm.Transactor.From.Hex())
+	}
+	pool, err := g.Aave.pool()
+	if err != nil {
+		return nil, err
+	}
+	executor, err := cm.bundleExecutor()
+	if err != nil {
+		return nil, err
+	}
+	router, exists := cm.Contract("uniswap_v3_router")
+	if !exists {
+		return nil, fmt.Errorf("contract uniswap_v3_router not found")
+	}
+	reserve, err := g.Aave.reserveData(plan.CollateralAsset)
+	if err != nil {
+		return nil, err
+	}
+	aToken := reserve.ATokenAddress
+	if err := g.registerAToken(aToken); err != nil {
+		return nil, err
+	}
+	permit, err := NewAllowanceManager(cm).SignPermit(aToken, executor.Address, plan.WithdrawAmount, CreateDeadline(10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign aToken permit: %v", err)
+	}
+
+	tokenABI, err := cm.Registry.ABI("erc20")
+	if err != nil {
+		return nil, err
+	}
+	pack := func(method string, args ...interface{}) ([]byte, error) {
+		data, err := tokenABI.Pack(method, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack method %s: %v", method, err)
+		}
+		return data, nil
+	}
+
+	premiumBps, err := g.Aave.FlashLoanPremiumBps()
+	if err != nil {
+		return nil, err
+	}
+	premium := new(big.Int).Div(new(big.Int).Mul(plan.RepayAmount, big.NewInt(premiumBps)), big.NewInt(10_000))
+	owed := new(big.Int).Add(plan.RepayAmount, premium)
+
+	bundle := cm.NewBundle().WithFlashLoan(plan.DebtAsset, plan.RepayAmount)
+	approveRepay, err := pack(ERC20Approve, pool.Address, plan.RepayAmount)
+	if err != nil {
+		return nil, err
+	}
+	bundle.AddCall(plan.DebtAsset, approveRepay, false)
+	if err := bundle.Add(g.Aave.Pool, AaveRepay, plan.DebtAsset, plan.RepayAmount, plan.InterestRateMode, plan.Wallet); err != nil {
+		return nil, err
+	}
+	if err := bundle.Permit(permit); err != nil {
+		return nil, err
+	}
+	pull, err := pack(ERC20TransferFrom, plan.Wallet, executor.Address, plan.WithdrawAmount)
+	if err != nil {
+		return nil, err
+	}
+	bundle.AddCall(aToken, pull, false)
+	if err := bundle.Add(g.Aave.Pool, AaveWithdraw, plan.CollateralAsset, plan.WithdrawAmount, executor.Address); err != nil {
+		return nil, err
+	}
+	approveSwap, err := pack(ERC20Approve, router.Address, plan.WithdrawAmount)
+	if err != nil {
+		return nil, err
+	}
+	bundle.AddCall(plan.CollateralAsset, approveSwap, false)
+	if err := bundle.Swap(SwapParams{
+		TokenIn:          plan.CollateralAsset,
+		TokenOut:         plan.DebtAsset,
+		Fee:              plan.SwapFee,
+		Recipient:        executor.Address,
+		Deadline:         CreateDeadline(10),
+		AmountIn:         plan.WithdrawAmount,
+		AmountOutMinimum: owed,
+	}); err != nil {
+		return nil, err
+	}
+
+	return bundle.Execute(ctx)
+}
+
+// registerAToken registers an aToken as an ERC20 contract on first use so
+// permits can be signed for it
+func (g *HealthGuardian) registerAToken(aToken common.Address) error {
+	cm := g.Aave.ContractManager
+	if _, err := cm.tokenContractName(aToken); err == nil {
+		return nil
+	}
+	return cm.RegisterContract("erc20_atoken_"+strings.ToLower(aToken.Hex()), aToken, "erc20")
+}
+
+// deleverageAmounts returns the debt to repay and the collateral to
+// withdraw, in base currency, that bring account's health factor to
+// target when each unit repaid withdraws cost units of collateral
+func deleverageAmounts(account *AaveAccountData, target, cost float64) (repay, withdraw *big.Int, err error) {
+	collateral := new(big.Float).SetInt(account.TotalCollateralBase)
+	debt := new(big.Float).SetInt(account.TotalDebtBase)
+	threshold := new(big.Float).Quo(new(big.Float).SetInt(account.LiquidationThreshold), big.NewFloat(10_000))
+
+	// target = (collateral - cost*x) * threshold / (debt - x)
+	denominator := new(big.Float).Sub(big.NewFloat(target), new(big.Float).Mul(big.NewFloat(cost), threshold))
+	if denominator.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("target health factor %.3f is unreachable", target)
+	}
+	numerator := new(big.Float).Sub(new(big.Float).Mul(big.NewFloat(target), debt), new(big.Float).Mul(collateral, threshold))
+	x := new(big.Float).Quo(numerator, denominator)
+	if x.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("health factor already meets the target %.3f", target)
+	}
+	if x.Cmp(debt) > 0 {
+		x = debt
+	}
+	y := new(big.Float).Mul(x, big.NewFloat(cost))
+	if y.Cmp(collateral) > 0 {
+		return nil, nil, fmt.Errorf("deleveraging needs more collateral than the wallet holds")
+	}
+
+	repay, _ = x.Int(nil)
+	withdraw, _ = y.Int(nil)
+	return repay, withdraw, nil
+}
+
+// healthAfter returns account's health factor after repaying repay and
+// withdrawing withdraw, both in base currency
+func healthAfter(account *AaveAccountData, repay, withdraw *big.Int) float64 {
+	debt := new(big.Int).Sub(account.TotalDebtBase, repay)
+	if debt.Sign() <= 0 {
+		return math.Inf(1)
+	}
+	collateral := new(big.Int).Sub(account.TotalCollateralBase, withdraw)
+	weighted := new(big.Float).Mul(new(big.Float).SetInt(collateral), new(big.Float).SetInt(account.LiquidationThreshold))
+	healthFactor, _ := weighted.Quo(weighted, new(big.Float).SetInt(debt)).Float64()
+	return healthFactor / 10_000
+}
+
+// thresholds returns the wallet's own thresholds, or the guardian's
+func (g *HealthGuardian) thresholds(wallet GuardedWallet) GuardianThresholds {
+	if wallet.Thresholds.Target != 0 {
+		return wallet.Thresholds
+	}
+	return g.Thresholds
+}
+
+// alertLevel raises an alert for a health level change
+func (g *HealthGuardian) alertLevel(wallet common.Address, from, to HealthLevel, healthFactor float64) {
+	switch to {
+	case HealthCritical:
+		g.alert("Health Factor Critical", fmt.Sprintf("Health factor of %s fell to %.3f", wallet.Hex(), healthFactor), "critical")
+	case HealthWarning:
+		if from == HealthOK {
+			g.alert("Health Factor Warning", fmt.Sprintf("Health factor of %s fell to %.3f", wallet.Hex(), healthFactor), "warning")
+		} else {
+			g.alert("Health Factor Recovering", fmt.Sprintf("Health factor of %s rose to %.3f", wallet.Hex(), healthFactor), "info")
+		}
+	case HealthOK:
+		g.alert("Health Factor Restored", fmt.Sprintf("Health factor of %s is back at %.3f", wallet.Hex(), healthFactor), "info")
+	}
+}
+
+// alert sends an alert when an Alerter is set
+func (g *HealthGuardian) alert(title, message, severity string) {
+	if g.Alerts != nil {
+		g.Alerts.SendAlert(title, message, severity)
+	}
+}
+
+// update stores a wallet's new status unless it was unwatched meanwhile
+func (g *HealthGuardian) update(status GuardianStatus) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.status[status.Wallet]; exists {
+		g.status[status.Wallet] = &status
+	}
+}
+
+// now reads the guardian's clock
+func (g *HealthGuardian) now() time.Time {
+	if g.Clock == nil {
+		return time.Now()
+	}
+	return g.Clock.Now()
+}
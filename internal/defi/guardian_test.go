@@ -0,0 +1,233 @@
+package defi
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+)
+
+var _ Alerter = (*monitoring.AlertManager)(nil)
+
+// alertRecorder collects alert titles
+type alertRecorder struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (r *alertRecorder) SendAlert(title, message, severity string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.titles = append(r.titles, title)
+}
+
+// take returns the alerts recorded since the last call
+func (r *alertRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	titles := r.titles
+	r.titles = nil
+	return titles
+}
+
+func etherFloat(amount *big.Int) float64 {
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), big.NewFloat(1e18)).Float64()
+	return value
+}
+
+func TestGuardianThresholds(t *testing.T) {
+	thresholds := GuardianThresholds{Warning: 1.5, Critical: 1.2, Target: 1.6}
+	require.NoError(t, thresholds.Validate())
+	assert.Equal(t, HealthCritical, thresholds.level(1.1))
+	assert.Equal(t, HealthWarning, thresholds.level(1.3))
+	assert.Equal(t, HealthOK, thresholds.level(1.5))
+
+	assert.Error(t, GuardianThresholds{Warning: 1.5, Critical: 1, Target: 1.6}.Validate())
+	assert.Error(t, GuardianThresholds{Warning: 1.1, Critical: 1.2, Target: 1.6}.Validate())
+	assert.Error(t, GuardianThresholds{Warning: 1.5, Critical: 1.2, Target: 1.5}.Validate())
+}
+
+func TestDeleverageAmounts(t *testing.T) {
+	account := &AaveAccountData{
+		TotalCollateralBase:  defitest.Ether(1_000),
+		TotalDebtBase:        defitest.Ether(700),
+		LiquidationThreshold: big.NewInt(8000),
+	}
+
+	// Repaying from the wallet: 800 / (700 - 200) = 1.6
+	repay, withdraw, err := deleverageAmounts(account, 1.6, 0)
+	require.NoError(t, err)
+	assert.InDelta(t, 200, etherFloat(repay), 1e-9)
+	assert.Zero(t, withdraw.Sign())
+
+	// Withdrawing one unit of collateral per unit repaid:
+	// (1000 - 400) * 0.8 / (700 - 400) = 1.6
+	repay, withdraw, err = deleverageAmounts(account, 1.6, 1)
+	require.NoError(t, err)
+	assert.InDelta(t, 400, etherFloat(repay), 1e-9)
+	assert.InDelta(t, 400, etherFloat(withdraw), 1e-9)
+	assert.InDelta(t, 1.6, healthAfter(account, repay, withdraw), 1e-9)
+
+	_, _, err = deleverageAmounts(account, 0.7, 1)
+	assert.Error(t, err)
+}
+
+// leveragedAccount lists USDC and WETH, with WETH's aToken backed by pool
+// collateral, and opens a position of 1000 WETH collateral against 700 USDC
+// debt, a health factor of 800/700
+func leveragedAccount(t *testing.T, chain *defitest.Chain, aave *AaveManager) {
+	t.Helper()
+
+	reserve := func(id uint16, aToken common.Address) defitest.ReserveParams {
+		zero := big.NewInt(0)
+		return defitest.ReserveParams{
+			ID:                 id,
+			Configuration:      reserveConfiguration(7500, 8000, 10500, 18, 0),
+			LiquidityRate:      zero,
+			VariableBorrowRate: zero,
+			StableBorrowRate:   zero,
+			AToken:             aToken,
+		}
+	}
+	chain.ListReserve(chain.USDC, reserve(0, common.Address{}))
+	chain.ListReserve(chain.WETH, reserve(1, chain.Pool))
+	chain.Mint(chain.WETH, chain.Account, defitest.Ether(1_000))
+	chain.Approve(chain.Key, chain.WETH, chain.Pool, defitest.Ether(1_000))
+	chain.Approve(chain.Key, chain.USDC, chain.Pool, MaxRepay)
+
+	cm := aave.ContractManager
+	tx, err := aave.ExecuteDeposit(DepositParams{Asset: chain.WETH, Amount: defitest.Ether(1_000), OnBehalfOf: chain.Account})
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	tx, err = aave.ExecuteBorrow(BorrowParams{Asset: chain.USDC, Amount: defitest.Ether(700), InterestRateMode: VariableRate, OnBehalfOf: chain.Account})
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+}
+
+func TestHealthGuardianRepaysCriticalWallet(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	aave := NewAaveManager(cm)
+	ctx := context.Background()
+	leveragedAccount(t, chain, aave)
+
+	alerts := &alertRecorder{}
+	clock := NewSimulatedClock(time.Unix(1_700_000_000, 0))
+	guardian := NewHealthGuardian(aave, alerts)
+	guardian.Clock = clock
+	guardian.DryRun = true
+	require.NoError(t, guardian.Watch(GuardedWallet{Address: chain.Account, DebtAsset: chain.USDC, CollateralAsset: chain.WETH}))
+
+	// A dry run only reports the plan
+	statuses := guardian.Check(ctx)
+	require.Len(t, statuses, 1)
+	status := statuses[0]
+	assert.Equal(t, HealthCritical, status.Level)
+	assert.InDelta(t, 800.0/700, status.HealthFactor, 1e-9)
+	require.NotNil(t, status.LastPlan)
+	assert.False(t, status.LastPlan.FlashLoan)
+	assert.InDelta(t, 200, etherFloat(status.LastPlan.RepayAmount), 1e-6)
+	assert.InDelta(t, 1.6, status.LastPlan.ExpectedHealthFactor, 1e-6)
+	assert.Equal(t, []string{"Health Factor Critical", "Deleverage Planned"}, alerts.take())
+	assert.Equal(t, defitest.Ether(700).String(), chain.PoolAccount(chain.Account).Debt.String())
+
+	guardian.Check(ctx)
+	assert.Empty(t, alerts.take())
+
+	// Live, the guardian repays once and waits for the transaction
+	guardian.DryRun = false
+	status = guardian.Check(ctx)[0]
+	require.NotZero(t, status.PendingTx)
+	assert.Equal(t, []string{"Deleverage Sent"}, alerts.take())
+	sent := status.PendingTx
+	status = guardian.Check(ctx)[0]
+	assert.Equal(t, sent, status.PendingTx)
+	assert.Empty(t, alerts.take())
+
+	// Until it has been pending too long, when it is resent with higher fees
+	clock.Advance(guardian.PendingTimeout)
+	status = guardian.Check(ctx)[0]
+	require.NotZero(t, status.PendingTx)
+	assert.NotEqual(t, sent, status.PendingTx)
+	assert.Equal(t, []string{"Deleverage Resent"}, alerts.take())
+
+	chain.Commit()
+	status = guardian.Check(ctx)[0]
+	assert.Equal(t, HealthOK, status.Level)
+	assert.Zero(t, status.PendingTx)
+	assert.InDelta(t, 1.6, status.HealthFactor, 1e-6)
+	assert.Equal(t, []string{"Health Factor Restored"}, alerts.take())
+	assert.InDelta(t, 500, etherFloat(chain.PoolAccount(chain.Account).Debt), 1e-6)
+}
+
+func TestHealthGuardianDeleveragesWithFlashLoan(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	aave := NewAaveManager(cm)
+	ctx := context.Background()
+	leveragedAccount(t, chain, aave)
+
+	// The borrowed USDC is spent, so the wallet cannot repay
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Transfer, big.NewInt(0), chain.Router, defitest.Ether(700))
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	chain.SetRate(chain.WETH, chain.USDC, defitest.Ether(1))
+
+	// Plans pay the pool's own flash loan premium
+	premium, err := aave.FlashLoanPremiumBps()
+	require.NoError(t, err)
+	assert.Equal(t, int64(defitest.FlashLoanPremiumBps), premium)
+
+	alerts := &alertRecorder{}
+	guardian := NewHealthGuardian(aave, alerts)
+	require.NoError(t, guardian.Watch(GuardedWallet{Address: chain.Account, DebtAsset: chain.USDC, CollateralAsset: chain.WETH}))
+
+	status := guardian.Check(ctx)[0]
+	assert.Contains(t, status.Error, "flash loans are disabled")
+	assert.Equal(t, []string{"Health Factor Critical", "Deleverage Impossible"}, alerts.take())
+
+	// The bundle pulls the wallet's aTokens with a permit for exactly the
+	// withdrawal, so the executor needs no standing approval
+	guardian.FlashLoans = true
+	status = guardian.Check(ctx)[0]
+	require.Empty(t, status.Error)
+	require.NotNil(t, status.LastPlan)
+	plan := status.LastPlan
+	assert.True(t, plan.FlashLoan)
+	assert.Greater(t, plan.WithdrawAmount.Cmp(plan.RepayAmount), 0)
+	assert.InDelta(t, 1.6, plan.ExpectedHealthFactor, 1e-6)
+	assert.Equal(t, []string{"Deleverage Sent"}, alerts.take())
+	chain.Commit()
+	receipt, err := cm.WaitForTransaction(context.Background(), status.PendingTx)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	status = guardian.Check(ctx)[0]
+	assert.Equal(t, HealthOK, status.Level)
+	assert.InDelta(t, 1.6, status.HealthFactor, 1e-6)
+	assert.Equal(t, []string{"Health Factor Restored"}, alerts.take())
+
+	account := chain.PoolAccount(chain.Account)
+	assert.Equal(t, new(big.Int).Sub(defitest.Ether(700), plan.RepayAmount).String(), account.Debt.String())
+	assert.Equal(t, new(big.Int).Sub(defitest.Ether(1_000), plan.WithdrawAmount).String(), account.Collateral.String())
+
+	// The swap surplus is left with the executor, and the permit is used up
+	assert.Positive(t, chain.BalanceOf(chain.USDC, chain.Executor).Sign())
+	allowance, err := cm.tokenUint(chain.Pool, ERC20Allowance, chain.Account, chain.Executor)
+	require.NoError(t, err)
+	assert.Zero(t, allowance.Sign())
+
+	// Other wallets cannot be deleveraged with a flash loan
+	plan.Wallet = common.HexToAddress("0xbeef")
+	_, err = guardian.Execute(ctx, plan)
+	assert.ErrorContains(t, err, "manager's account")
+}
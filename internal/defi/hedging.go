@@ -0,0 +1,599 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Hedging engine defaults
+const (
+	// DefaultHedgeWindow is the number of returns correlations and betas are
+	// measured over
+	DefaultHedgeWindow = 30
+	// DefaultHedgeTolerance is how far a hedge may drift from its target, as
+	// a share of the larger of the two, before it is adjusted
+	DefaultHedgeTolerance = 0.1
+)
+
+// HedgeExecutor moves the short hedge in an asset. HedgingEngine adjusts
+// hedges through it; AaveShortHedger and StableRotationHedger implement it.
+type HedgeExecutor interface {
+	// AdjustHedge shortens the account's exposure to asset by amount whole
+	// tokens, or restores it for negative amounts. An adjustment that fails
+	// after moving the hedge part of the way returns a *PartialHedgeError.
+	AdjustHedge(ctx context.Context, asset string, amount float64) error
+}
+
+// PartialHedgeError reports a hedge adjustment that failed after moving the
+// hedge by Amount whole tokens
+type PartialHedgeError struct {
+	Amount float64
+	Err    error
+}
+
+func (e *PartialHedgeError) Error() string {
+	return fmt.Sprintf("hedge moved by %.6f before failing: %v", e.Amount, e.Err)
+}
+
+func (e *PartialHedgeError) Unwrap() error {
+	return e.Err
+}
+
+// HedgeExposure is a portfolio asset's directional exposure and how it moves
+// with the hedge asset it is assigned to
+type HedgeExposure struct {
+	Asset string
+	// ValueUSD is the asset's spot holdings and open positions, with shorts
+	// counting negative
+	ValueUSD float64
+	// HedgeAsset is the hedge asset the exposure is offset in, empty when no
+	// hedge asset correlates with it above the strategy's threshold
+	HedgeAsset  string
+	Correlation float64
+	Beta        float64
+}
+
+// HedgeAdjustment moves the short hedge in an asset from Current to Target
+// whole tokens
+type HedgeAdjustment struct {
+	Asset   string
+	Price   float64
+	Current float64
+	Target  float64
+}
+
+// Amount is how many tokens more to short, negative to cover
+func (a HedgeAdjustment) Amount() float64 {
+	return a.Target - a.Current
+}
+
+// HedgingEngine offsets a portfolio's exposure in the HedgeAssets of a
+// HedgingStrategy. Exposures come from the portfolio's spot assets and open
+// long and short positions; liquidity positions are left out. Each exposure
+// is matched to the hedge asset its returns correlate with most over the
+// last Window returns, if at or above the strategy's CorrelationThreshold,
+// and the engine keeps a short in every hedge asset sized to HedgeRatio of
+// what it offsets:
+//
+//   - HedgingDelta shorts the matched exposures dollar for dollar
+//   - HedgingBeta weights each matched exposure by its beta to the hedge asset
+//   - HedgingPairs offsets the first hedge asset with a beta-weighted short
+//     in the second, unwinding it when their correlation breaks down
+//
+// Hedges are recorded in the portfolio as short positions, closed and
+// reopened at the current price on every adjustment.
+type HedgingEngine struct {
+	Portfolio *portfolio.Portfolio
+	History   *market.PriceHistory
+
+	// Executor, when set, carries out adjustments; without one they are
+	// only recorded
+	Executor HedgeExecutor
+
+	Window    int
+	Tolerance float64
+
+	mu     sync.Mutex
+	hedges map[string]*portfolio.Position
+	opened int
+}
+
+// NewHedgingEngine creates a hedging engine over a portfolio, reading prices
+// and returns from history
+func NewHedgingEngine(p *portfolio.Portfolio, history *market.PriceHistory, executor HedgeExecutor) *HedgingEngine {
+	return &HedgingEngine{
+		Portfolio: p,
+		History:   history,
+		Executor:  executor,
+		Window:    DefaultHedgeWindow,
+		Tolerance: DefaultHedgeTolerance,
+		hedges:    make(map[string]*portfolio.Position),
+	}
+}
+
+// Exposures returns the portfolio's exposures by asset, matched to the
+// strategy's hedge assets
+func (he *HedgingEngine) Exposures(strategy *HedgingStrategy) []HedgeExposure {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+	return he.exposures(strategy)
+}
+
+func (he *HedgingEngine) exposures(strategy *HedgingStrategy) []HedgeExposure {
+	values := make(map[string]float64)
+	for _, asset := range he.Portfolio.GetAssets() {
+		if asset.ValueUSD != nil {
+			value, _ := asset.ValueUSD.Float64()
+			values[asset.Symbol] += value
+		}
+	}
+	for _, position := range he.Portfolio.GetOpenPositions() {
+		if position.Type == portfolio.PositionLiquidity || he.isHedge(position.ID) {
+			continue
+		}
+		size, _ := position.Size.Float64()
+		value := size * he.positionPrice(position)
+		if position.Type == portfolio.PositionShort {
+			value = -value
+		}
+		values[position.Asset] += value
+	}
+
+	exposures := make([]HedgeExposure, 0, len(values))
+	for asset, value := range values {
+		if value == 0 {
+			continue
+		}
+		exposure := HedgeExposure{Asset: asset, ValueUSD: value}
+		he.match(&exposure, strategy)
+		exposures = append(exposures, exposure)
+	}
+	sort.Slice(exposures, func(i, j int) bool { return exposures[i].Asset < exposures[j].Asset })
+	return exposures
+}
+
+// match assigns an exposure to the hedge asset offsetting it
+func (he *HedgingEngine) match(exposure *HedgeExposure, strategy *HedgingStrategy) {
+	candidates := strategy.HedgeAssets
+	if strategy.Type == HedgingPairs {
+		// Only the pair's first leg is hedged, and only with the second
+		if len(candidates) < 2 || exposure.Asset != candidates[0] {
+			return
+		}
+		candidates = candidates[1:2]
+	} else {
+		for _, hedge := range candidates {
+			if hedge == exposure.Asset {
+				exposure.HedgeAsset, exposure.Correlation, exposure.Beta = hedge, 1, 1
+				return
+			}
+		}
+	}
+
+	for _, hedge := range candidates {
+		correlation, beta, ok := returnCorrelation(he.closes(exposure.Asset), he.closes(hedge))
+		if !ok || correlation < strategy.CorrelationThreshold || correlation <= exposure.Correlation {
+			continue
+		}
+		exposure.HedgeAsset, exposure.Correlation, exposure.Beta = hedge, correlation, beta
+	}
+}
+
+// Plan returns the adjustments that bring hedges back to the strategy's
+// targets, leaving those within Tolerance
+func (he *HedgingEngine) Plan(strategy *HedgingStrategy) ([]HedgeAdjustment, error) {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+	return he.plan(strategy)
+}
+
+func (he *HedgingEngine) plan(strategy *HedgingStrategy) ([]HedgeAdjustment, error) {
+	if strategy == nil || strategy.Type == HedgingNone || strategy.Type == "" {
+		return nil, nil
+	}
+	switch strategy.Type {
+	case HedgingDelta, HedgingBeta, HedgingPairs:
+	default:
+		return nil, fmt.Errorf("unsupported hedging type %s", strategy.Type)
+	}
+
+	targets := make(map[string]float64)
+	for _, exposure := range he.exposures(strategy) {
+		if exposure.HedgeAsset == "" {
+			continue
+		}
+		value := exposure.ValueUSD
+		if strategy.Type != HedgingDelta {
+			value *= exposure.Beta
+		}
+		targets[exposure.HedgeAsset] += strategy.HedgeRatio * value
+	}
+
+	var adjustments []HedgeAdjustment
+	for _, asset := range strategy.HedgeAssets {
+		current := 0.0
+		if hedge, exists := he.hedges[asset]; exists {
+			current, _ = hedge.Size.Float64()
+		}
+		// A net short exposure is left alone rather than hedged long
+		targetUSD := math.Max(targets[asset], 0)
+		if targetUSD == 0 && current == 0 {
+			continue
+		}
+
+		price, err := he.price(asset)
+		if err != nil {
+			return nil, err
+		}
+		target := targetUSD / price
+		if math.Abs(target-current) <= he.Tolerance*math.Max(target, current) {
+			continue
+		}
+		adjustments = append(adjustments, HedgeAdjustment{Asset: asset, Price: price, Current: current, Target: target})
+	}
+	return adjustments, nil
+}
+
+// Rebalance carries out the strategy's planned adjustments and returns those
+// made. An adjustment the executor only made part of is recorded and
+// returned with the error, so the next pass plans from where it stopped.
+func (he *HedgingEngine) Rebalance(ctx context.Context, strategy *HedgingStrategy) ([]HedgeAdjustment, error) {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+
+	adjustments, err := he.plan(strategy)
+	if err != nil {
+		return nil, err
+	}
+	for i, adjustment := range adjustments {
+		log.Printf("Adjusting %s hedge from %.6f to %.6f", adjustment.Asset, adjustment.Current, adjustment.Target)
+		if he.Executor != nil {
+			if err := he.Executor.AdjustHedge(ctx, adjustment.Asset, adjustment.Amount()); err != nil {
+				var partial *PartialHedgeError
+				if !errors.As(err, &partial) || partial.Amount == 0 {
+					return adjustments[:i], fmt.Errorf("failed to adjust %s hedge: %v", adjustment.Asset, err)
+				}
+				adjustment.Target = adjustment.Current + partial.Amount
+				if recordErr := he.record(adjustment); recordErr != nil {
+					return adjustments[:i], fmt.Errorf("failed to adjust %s hedge: %v", adjustment.Asset, errors.Join(err, recordErr))
+				}
+				return append(adjustments[:i], adjustment), fmt.Errorf("failed to adjust %s hedge: %w", adjustment.Asset, err)
+			}
+		}
+		if err := he.record(adjustment); err != nil {
+			return adjustments[:i+1], err
+		}
+	}
+	return adjustments, nil
+}
+
+// Hedges returns the open hedge positions by asset
+func (he *HedgingEngine) Hedges() map[string]*portfolio.Position {
+	he.mu.Lock()
+	defer he.mu.Unlock()
+
+	hedges := make(map[string]*portfolio.Position, len(he.hedges))
+	for asset, hedge := range he.hedges {
+		hedges[asset] = hedge
+	}
+	return hedges
+}
+
+// record replaces an asset's hedge position in the portfolio with one of the
+// adjusted size
+func (he *HedgingEngine) record(adjustment HedgeAdjustment) error {
+	price := big.NewFloat(adjustment.Price)
+	if hedge, exists := he.hedges[adjustment.Asset]; exists {
+		if _, err := he.Portfolio.ClosePosition(hedge.ID, price); err != nil {
+			return fmt.Errorf("failed to close %s hedge: %v", adjustment.Asset, err)
+		}
+		delete(he.hedges, adjustment.Asset)
+	}
+	if adjustment.Target <= 0 {
+		return nil
+	}
+
+	he.opened++
+	hedge := &portfolio.Position{
+		ID:           fmt.Sprintf("hedge_%s_%d", adjustment.Asset, he.opened),
+		Asset:        adjustment.Asset,
+		Type:         portfolio.PositionShort,
+		Size:         big.NewFloat(adjustment.Target),
+		EntryPrice:   price,
+		CurrentPrice: price,
+		Leverage:     1,
+	}
+	if err := he.Portfolio.OpenPosition(hedge); err != nil {
+		return fmt.Errorf("failed to open %s hedge: %v", adjustment.Asset, err)
+	}
+	he.hedges[adjustment.Asset] = hedge
+	return nil
+}
+
+func (he *HedgingEngine) isHedge(id string) bool {
+	for _, hedge := range he.hedges {
+		if hedge.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (he *HedgingEngine) closes(asset string) []float64 {
+	if he.History == nil {
+		return nil
+	}
+	return he.History.Closes(asset, he.Window+1)
+}
+
+// price returns an asset's latest close
+func (he *HedgingEngine) price(asset string) (float64, error) {
+	if he.History != nil {
+		if candle, ok := he.History.Latest(asset); ok && candle.Close > 0 {
+			return candle.Close, nil
+		}
+	}
+	return 0, fmt.Errorf("no price for %s", asset)
+}
+
+// positionPrice marks a position at its asset's latest close, or its own
+// current or entry price without one
+func (he *HedgingEngine) positionPrice(position *portfolio.Position) float64 {
+	if price, err := he.price(position.Asset); err == nil {
+		return price
+	}
+	for _, price := range []*big.Float{position.CurrentPrice, position.EntryPrice} {
+		if price != nil {
+			value, _ := price.Float64()
+			return value
+		}
+	}
+	return 0
+}
+
+// returnCorrelation measures how an asset's log returns move with a hedge
+// asset's over the prices both series share at their end, returning the
+// correlation and the asset's beta to the hedge asset. It needs at least
+// three returns and a hedge asset that moves.
+func returnCorrelation(asset, hedge []float64) (correlation, beta float64, ok bool) {
+	n := min(len(asset), len(hedge))
+	x := logReturns(asset[len(asset)-n:])
+	y := logReturns(hedge[len(hedge)-n:])
+	if len(x) < 3 || len(x) != len(y) {
+		return 0, 0, false
+	}
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= float64(len(x))
+	meanY /= float64(len(y))
+
+	var covariance, varianceX, varianceY float64
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+		varianceX += (x[i] - meanX) * (x[i] - meanX)
+		varianceY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return 0, 0, false
+	}
+	return covariance / math.Sqrt(varianceX*varianceY), covariance / varianceY, true
+}
+
+// AaveShortHedger shorts hedge assets by borrowing them on Aave against the
+// account's collateral and selling them for Stable, and covers by buying
+// them back and repaying
+type AaveShortHedger struct {
+	Aave     *AaveManager
+	Venues   *Venues
+	Stable   string
+	Slippage float64
+
+	// Tokens maps hedge assets to the registered token symbols traded for
+	// them, such as ETH to WETH; unmapped assets trade as themselves
+	Tokens map[string]string
+}
+
+// NewAaveShortHedger creates a hedger shorting through Aave and the venues'
+// DEXes
+func NewAaveShortHedger(aave *AaveManager, venues *Venues, stable string) *AaveShortHedger {
+	return &AaveShortHedger{
+		Aave:     aave,
+		Venues:   venues,
+		Stable:   stable,
+		Slippage: DefaultLPSlippage,
+	}
+}
+
+// AdjustHedge borrows and sells amount of asset, or for negative amounts
+// buys it back and repays. A borrow whose sale fails is repaid; when that
+// fails too, or the sale's outcome is unknown, the borrow is reported as a
+// *PartialHedgeError, as is a buy-back whose repay fails.
+func (h *AaveShortHedger) AdjustHedge(ctx context.Context, asset string, amount float64) error {
+	token, stable, raw, err := hedgeAmounts(h.Venues, h.Tokens, asset, h.Stable, amount)
+	if err != nil || raw.Sign() == 0 {
+		return err
+	}
+	cm := h.Venues.ContractManager
+
+	if amount > 0 {
+		tx, err := h.Aave.Borrow(token, raw)
+		if err != nil {
+			return fmt.Errorf("failed to borrow %s: %v", asset, err)
+		}
+		if err := waitHedge(ctx, cm, tx, "borrow"); err != nil {
+			if ctx.Err() != nil {
+				return &PartialHedgeError{Amount: amount, Err: err}
+			}
+			return err
+		}
+		tx, _, err = h.Venues.SwapBest(token, stable, raw, h.Slippage)
+		if err == nil {
+			err = waitHedge(ctx, cm, tx, "sale")
+			if err != nil && ctx.Err() != nil {
+				// The sale may still be mined, so the borrow stands
+				return &PartialHedgeError{Amount: amount, Err: err}
+			}
+		}
+		if err != nil {
+			if repayErr := h.repay(ctx, asset, token, raw); repayErr != nil {
+				return &PartialHedgeError{Amount: amount, Err: errors.Join(err, repayErr)}
+			}
+			return err
+		}
+		return nil
+	}
+
+	bought, err := buyBack(ctx, h.Venues, stable, token, raw, h.Slippage)
+	if err != nil {
+		return err
+	}
+	if bought.Cmp(raw) < 0 {
+		raw = bought
+	}
+	if err := h.repay(ctx, asset, token, raw); err != nil {
+		// The bought tokens cover the short until the debt is repaid
+		decimals, decimalsErr := cm.tokenDecimals(token)
+		if decimalsErr != nil {
+			return errors.Join(err, decimalsErr)
+		}
+		return &PartialHedgeError{Amount: -wholeAmount(raw, decimals), Err: err}
+	}
+	return nil
+}
+
+// repay repays amount of the token borrowed for asset
+func (h *AaveShortHedger) repay(ctx context.Context, asset string, token common.Address, amount *big.Int) error {
+	tx, err := h.Aave.Repay(token, amount)
+	if err != nil {
+		return fmt.Errorf("failed to repay %s: %v", asset, err)
+	}
+	return waitHedge(ctx, h.Venues.ContractManager, tx, "repay")
+}
+
+// StableRotationHedger hedges by rotating held hedge assets into Stable and
+// back
+type StableRotationHedger struct {
+	Venues   *Venues
+	Stable   string
+	Slippage float64
+
+	// Tokens maps hedge assets to the registered token symbols traded for
+	// them, such as ETH to WETH; unmapped assets trade as themselves
+	Tokens map[string]string
+}
+
+// NewStableRotationHedger creates a hedger rotating through the venues'
+// DEXes
+func NewStableRotationHedger(venues *Venues, stable string) *StableRotationHedger {
+	return &StableRotationHedger{
+		Venues:   venues,
+		Stable:   stable,
+		Slippage: DefaultLPSlippage,
+	}
+}
+
+// AdjustHedge sells amount of asset for the stablecoin, or for negative
+// amounts buys it back
+func (h *StableRotationHedger) AdjustHedge(ctx context.Context, asset string, amount float64) error {
+	token, stable, raw, err := hedgeAmounts(h.Venues, h.Tokens, asset, h.Stable, amount)
+	if err != nil || raw.Sign() == 0 {
+		return err
+	}
+
+	if amount > 0 {
+		tx, _, err := h.Venues.SwapBest(token, stable, raw, h.Slippage)
+		if err != nil {
+			return err
+		}
+		return waitHedge(ctx, h.Venues.ContractManager, tx, "sale")
+	}
+	_, err = buyBack(ctx, h.Venues, stable, token, raw, h.Slippage)
+	return err
+}
+
+// hedgeAmounts resolves a hedge asset and the stablecoin and converts a
+// whole-token amount of the asset to base units
+func hedgeAmounts(venues *Venues, tokens map[string]string, asset, stableSymbol string, amount float64) (token, stable common.Address, raw *big.Int, err error) {
+	symbol := asset
+	if mapped, exists := tokens[asset]; exists {
+		symbol = mapped
+	}
+	if token, err = venues.token(symbol); err != nil {
+		return
+	}
+	if stable, err = venues.token(stableSymbol); err != nil {
+		return
+	}
+	decimals, err := venues.ContractManager.tokenDecimals(token)
+	if err != nil {
+		return token, stable, nil, fmt.Errorf("failed to get %s decimals: %v", symbol, err)
+	}
+	return token, stable, rawAmount(math.Abs(amount), decimals), nil
+}
+
+// buyBack buys at least amount of token with stable, spending what the best
+// reverse quote says it costs plus slippage, and returns what was bought
+func buyBack(ctx context.Context, venues *Venues, stable, token common.Address, amount *big.Int, slippage float64) (*big.Int, error) {
+	// The output for amount prices the stable needed through the reverse
+	// route
+	sale, err := venues.BestQuote(token, stable, amount)
+	if err != nil {
+		return nil, err
+	}
+	probe, err := venues.BestQuote(stable, token, sale.AmountOut)
+	if err != nil {
+		return nil, err
+	}
+	if probe.AmountOut.Sign() == 0 {
+		return nil, fmt.Errorf("no liquidity to buy back %s", token.Hex())
+	}
+	cost := new(big.Int).Mul(sale.AmountOut, amount)
+	cost.Div(cost, probe.AmountOut)
+	cost = mulFloat(cost, 1+slippage)
+
+	cm := venues.ContractManager
+	account := cm.Transactor.From
+	before, err := cm.tokenUint(token, ERC20BalanceOf, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+	tx, _, err := venues.SwapBest(stable, token, cost, slippage)
+	if err != nil {
+		return nil, err
+	}
+	if err := waitHedge(ctx, cm, tx, "buy back"); err != nil {
+		return nil, err
+	}
+	after, err := cm.tokenUint(token, ERC20BalanceOf, account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+	return after.Sub(after, before), nil
+}
+
+// waitHedge waits for a hedge transaction to succeed
+func waitHedge(ctx context.Context, cm *ContractManager, tx *types.Transaction, what string) error {
+	receipt, err := cm.WaitForTransaction(ctx, tx.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to wait for %s: %v", what, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%s transaction %s failed", what, tx.Hash().Hex())
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+)
+
+// recordingHedger records the hedge adjustments it is asked for, making only
+// the partial fraction of them and failing when that is set
+type recordingHedger struct {
+	amounts map[string]float64
+	partial float64
+}
+
+func (h *recordingHedger) AdjustHedge(ctx context.Context, asset string, amount float64) error {
+	if h.partial != 0 {
+		h.amounts[asset] += h.partial * amount
+		return &PartialHedgeError{Amount: h.partial * amount, Err: errors.New("sale failed")}
+	}
+	h.amounts[asset] += amount
+	return nil
+}
+
+// correlatedHistory records hourly prices for a seeded ETH random walk, ARB
+// moving arbBeta times ETH's log returns, or on its own for a zero beta, and
+// an independent DOGE
+func correlatedHistory(n int, arbBeta float64, seed int64) *market.PriceHistory {
+	rng := rand.New(rand.NewSource(seed))
+	history := market.NewPriceHistory(0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	eth, arb, doge := 2_000.0, 1.0, 0.1
+	for i := 0; i <= n; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		history.AddPrice("ETH", eth, 0, at)
+		history.AddPrice("ARB", arb, 0, at)
+		history.AddPrice("DOGE", doge, 0, at)
+
+		r := rng.NormFloat64() * 0.02
+		eth *= math.Exp(r)
+		if arbBeta != 0 {
+			arb *= math.Exp(arbBeta * r)
+		} else {
+			arb *= math.Exp(rng.NormFloat64() * 0.02)
+		}
+		doge *= math.Exp(rng.NormFloat64() * 0.03)
+	}
+	return history
+}
+
+// newHedgedPortfolio holds 10 ETH and 5,000 USDC with longs of 20,000 ARB
+// and 10,000 DOGE
+func newHedgedPortfolio(t *testing.T, history *market.PriceHistory) *portfolio.Portfolio {
+	t.Helper()
+
+	logger, err := logging.NewLogger(&config.LoggingConfig{Level: "error", Format: "console", Output: "stdout"})
+	require.NoError(t, err)
+	p := portfolio.NewPortfolio("hedged", "Hedged", portfolio.RiskProfile{}, logger, nil)
+
+	eth, _ := history.Latest("ETH")
+	require.NoError(t, p.AddAsset(&portfolio.Asset{Symbol: "ETH", Amount: big.NewFloat(10), ValueUSD: big.NewFloat(10 * eth.Close)}))
+	require.NoError(t, p.AddAsset(&portfolio.Asset{Symbol: "USDC", Amount: big.NewFloat(5_000), ValueUSD: big.NewFloat(5_000)}))
+	for _, position := range []struct {
+		asset string
+		size  float64
+	}{{"ARB", 20_000}, {"DOGE", 10_000}} {
+		require.NoError(t, p.OpenPosition(&portfolio.Position{
+			ID:         "long_" + position.asset,
+			Asset:      position.asset,
+			Type:       portfolio.PositionLong,
+			Size:       big.NewFloat(position.size),
+			EntryPrice: big.NewFloat(1),
+		}))
+	}
+	return p
+}
+
+func TestReturnCorrelation(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	hedge, asset, independent := []float64{100}, []float64{50}, []float64{10}
+	for i := 0; i < 500; i++ {
+		r := rng.NormFloat64() * 0.02
+		hedge = append(hedge, hedge[i]*math.Exp(r))
+		asset = append(asset, asset[i]*math.Exp(1.5*r+rng.NormFloat64()*0.005))
+		independent = append(independent, independent[i]*math.Exp(rng.NormFloat64()*0.02))
+	}
+
+	correlation, beta, ok := returnCorrelation(asset, hedge)
+	require.True(t, ok)
+	assert.Greater(t, correlation, 0.95)
+	assert.InDelta(t, 1.5, beta, 0.05)
+
+	correlation, _, ok = returnCorrelation(independent, hedge)
+	require.True(t, ok)
+	assert.Less(t, math.Abs(correlation), 0.15)
+
+	// Exact multiples of the returns, and their inverse
+	doubled, inverse := []float64{1}, []float64{1}
+	for i := 1; i < len(hedge); i++ {
+		r := math.Log(hedge[i] / hedge[i-1])
+		doubled = append(doubled, doubled[i-1]*math.Exp(2*r))
+		inverse = append(inverse, inverse[i-1]*math.Exp(-r))
+	}
+	correlation, beta, ok = returnCorrelation(doubled, hedge)
+	require.True(t, ok)
+	assert.InDelta(t, 1, correlation, 1e-9)
+	assert.InDelta(t, 2, beta, 1e-9)
+	correlation, beta, _ = returnCorrelation(inverse, hedge)
+	assert.InDelta(t, -1, correlation, 1e-9)
+	assert.InDelta(t, -1, beta, 1e-9)
+
+	// Series are aligned at their end
+	_, beta, ok = returnCorrelation(doubled[400:], hedge)
+	require.True(t, ok)
+	assert.InDelta(t, 2, beta, 1e-9)
+
+	_, _, ok = returnCorrelation([]float64{1, 2, 3}, []float64{1, 2, 3})
+	assert.False(t, ok)
+	_, _, ok = returnCorrelation(asset[:10], []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5})
+	assert.False(t, ok)
+}
+
+func TestHedgingEngineDeltaAndBeta(t *testing.T) {
+	history := correlatedHistory(60, 1.5, 1)
+	p := newHedgedPortfolio(t, history)
+	executor := &recordingHedger{amounts: make(map[string]float64)}
+	engine := NewHedgingEngine(p, history, executor)
+
+	eth, _ := history.Latest("ETH")
+	arb, _ := history.Latest("ARB")
+	ethValue, arbValue := 10*eth.Close, 20_000*arb.Close
+
+	strategy := &HedgingStrategy{Type: HedgingDelta, HedgeRatio: 0.5, CorrelationThreshold: 0.7, HedgeAssets: []string{"ETH"}}
+	exposures := engine.Exposures(strategy)
+	require.Len(t, exposures, 4)
+	byAsset := make(map[string]HedgeExposure)
+	for _, exposure := range exposures {
+		byAsset[exposure.Asset] = exposure
+	}
+	assert.Equal(t, "ETH", byAsset["ETH"].HedgeAsset)
+	assert.Equal(t, "ETH", byAsset["ARB"].HedgeAsset)
+	assert.InDelta(t, 1, byAsset["ARB"].Correlation, 1e-9)
+	assert.InDelta(t, 1.5, byAsset["ARB"].Beta, 1e-9)
+	assert.InDelta(t, arbValue, byAsset["ARB"].ValueUSD, 1e-9)
+	assert.Empty(t, byAsset["DOGE"].HedgeAsset)
+	assert.Empty(t, byAsset["USDC"].HedgeAsset)
+
+	// Delta hedges ETH and the correlated ARB dollar for dollar
+	adjustments, err := engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	target := 0.5 * (ethValue + arbValue) / eth.Close
+	assert.InDelta(t, target, adjustments[0].Target, 1e-9)
+	assert.InDelta(t, target, executor.amounts["ETH"], 1e-9)
+	hedge := engine.Hedges()["ETH"]
+	require.NotNil(t, hedge)
+	assert.Equal(t, portfolio.PositionShort, hedge.Type)
+	size, _ := hedge.Size.Float64()
+	assert.InDelta(t, target, size, 1e-9)
+
+	// The hedge does not count as exposure, so the next pass is within
+	// tolerance
+	assert.InDelta(t, ethValue, engine.Exposures(strategy)[2].ValueUSD, 1e-9)
+	adjustments, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	assert.Empty(t, adjustments)
+
+	// Beta weights ARB by 1.5, a drift past the tolerance
+	strategy.Type = HedgingBeta
+	adjustments, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	betaTarget := 0.5 * (ethValue + 1.5*arbValue) / eth.Close
+	assert.InDelta(t, betaTarget-target, adjustments[0].Amount(), 1e-9)
+	assert.InDelta(t, betaTarget, executor.amounts["ETH"], 1e-9)
+	closed, exists := p.Positions[hedge.ID]
+	require.True(t, exists)
+	assert.Equal(t, portfolio.PositionClosed, closed.Status)
+	assert.NotEqual(t, hedge.ID, engine.Hedges()["ETH"].ID)
+
+	// A higher hedge ratio grows the short, HedgingNone leaves it alone and a
+	// zero ratio covers it
+	strategy.HedgeRatio = 0.8
+	adjustments, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.InDelta(t, betaTarget*0.8/0.5, executor.amounts["ETH"], 1e-9)
+
+	strategy.Type = HedgingNone
+	adjustments, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	assert.Empty(t, adjustments)
+
+	strategy.Type = HedgingBeta
+	strategy.HedgeRatio = 0
+	_, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	assert.InDelta(t, 0, executor.amounts["ETH"], 1e-9)
+	assert.Empty(t, engine.Hedges())
+
+	_, err = engine.Plan(&HedgingStrategy{Type: "options", HedgeAssets: []string{"ETH"}})
+	assert.Error(t, err)
+}
+
+func TestHedgingEnginePairs(t *testing.T) {
+	history := correlatedHistory(60, 1.5, 2)
+	p := newHedgedPortfolio(t, history)
+	executor := &recordingHedger{amounts: make(map[string]float64)}
+	engine := NewHedgingEngine(p, history, executor)
+
+	eth, _ := history.Latest("ETH")
+	arb, _ := history.Latest("ARB")
+
+	// ARB is offset with a beta-neutral ETH short; ETH's own exposure is
+	// not part of the pair
+	strategy := &HedgingStrategy{Type: HedgingPairs, HedgeRatio: 1, CorrelationThreshold: 0.8, HedgeAssets: []string{"ARB", "ETH"}}
+	adjustments, err := engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, "ETH", adjustments[0].Asset)
+	assert.InDelta(t, 1.5*20_000*arb.Close/eth.Close, executor.amounts["ETH"], 1e-9)
+	assert.Zero(t, executor.amounts["ARB"])
+
+	// Once ARB stops following ETH the pair is unwound
+	engine.History = correlatedHistory(60, 0, 2)
+	exposures := engine.Exposures(strategy)
+	require.Len(t, exposures, 4)
+	assert.Empty(t, exposures[0].HedgeAsset)
+	adjustments, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.Zero(t, adjustments[0].Target)
+	assert.InDelta(t, 0, executor.amounts["ETH"], 1e-9)
+}
+
+func TestHedgingEnginePartialAdjustment(t *testing.T) {
+	history := correlatedHistory(60, 1.5, 1)
+	p := newHedgedPortfolio(t, history)
+	executor := &recordingHedger{amounts: make(map[string]float64), partial: 0.5}
+	engine := NewHedgingEngine(p, history, executor)
+	strategy := &HedgingStrategy{Type: HedgingDelta, HedgeRatio: 0.5, CorrelationThreshold: 0.7, HedgeAssets: []string{"ETH"}}
+
+	// The part made is recorded with the error
+	planned, err := engine.Plan(strategy)
+	require.NoError(t, err)
+	require.Len(t, planned, 1)
+	target := planned[0].Target
+	adjustments, err := engine.Rebalance(context.Background(), strategy)
+	var partial *PartialHedgeError
+	require.ErrorAs(t, err, &partial)
+	require.Len(t, adjustments, 1)
+	assert.InDelta(t, target/2, adjustments[0].Target, 1e-9)
+	size, _ := engine.Hedges()["ETH"].Size.Float64()
+	assert.InDelta(t, target/2, size, 1e-9)
+
+	// The next pass only makes up the rest
+	executor.partial = 0
+	adjustments, err = engine.Rebalance(context.Background(), strategy)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.InDelta(t, target/2, adjustments[0].Amount(), 1e-9)
+	assert.InDelta(t, target, executor.amounts["ETH"], 1e-9)
+}
+
+func TestStableRotationHedger(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+
+	chain.Mint(chain.WETH, chain.Account, defitest.Ether(10))
+	for _, spender := range []common.Address{chain.CurvePool, chain.Router} {
+		chain.Approve(chain.Key, chain.USDC, spender, MaxRepay)
+		chain.Approve(chain.Key, chain.WETH, spender, MaxRepay)
+	}
+	autoCommit(t, chain, cm)
+
+	ctx := context.Background()
+	hedger := NewStableRotationHedger(venues, "USDC")
+	hedger.Tokens = map[string]string{"ETH": "WETH"}
+	require.NoError(t, hedger.AdjustHedge(ctx, "ETH", 4))
+	assert.Equal(t, defitest.Ether(6).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+	sold := wholeAmount(chain.BalanceOf(chain.USDC, chain.Account), 18)
+	assert.InDelta(t, 4*0.9996, sold, 1e-9)
+
+	// Covering buys back at least the amount
+	require.NoError(t, hedger.AdjustHedge(ctx, "ETH", -2))
+	bought := wholeAmount(chain.BalanceOf(chain.WETH, chain.Account), 18) - 6
+	assert.GreaterOrEqual(t, bought, 2.0)
+	assert.Less(t, bought, 2.02)
+
+	assert.Error(t, hedger.AdjustHedge(ctx, "BTC", 1))
+}
+
+func TestAaveShortHedger(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+	aave := NewAaveManager(cm)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_010))
+	chain.Mint(chain.WETH, chain.Pool, defitest.Ether(100))
+	for _, spender := range []common.Address{chain.Pool, chain.CurvePool, chain.Router} {
+		chain.Approve(chain.Key, chain.USDC, spender, MaxRepay)
+		chain.Approve(chain.Key, chain.WETH, spender, MaxRepay)
+	}
+	autoCommit(t, chain, cm)
+	tx, err := aave.Supply(chain.USDC, defitest.Ether(1_000))
+	require.NoError(t, err)
+	require.NoError(t, waitHedge(context.Background(), cm, tx, "supply"))
+
+	// Shorting borrows WETH and sells it
+	ctx := context.Background()
+	hedger := NewAaveShortHedger(aave, venues, "USDC")
+	hedger.Tokens = map[string]string{"ETH": "WETH"}
+	require.NoError(t, hedger.AdjustHedge(ctx, "ETH", 2))
+	assert.Equal(t, defitest.Ether(2).String(), chain.PoolAccount(chain.Account).Debt.String())
+	assert.Zero(t, chain.BalanceOf(chain.WETH, chain.Account).Sign())
+	assert.InDelta(t, 10+2*0.9996, wholeAmount(chain.BalanceOf(chain.USDC, chain.Account), 18), 1e-9)
+
+	// Covering buys the WETH back and repays
+	require.NoError(t, hedger.AdjustHedge(ctx, "ETH", -1))
+	assert.Equal(t, defitest.Ether(1).String(), chain.PoolAccount(chain.Account).Debt.String())
+	require.NoError(t, hedger.AdjustHedge(ctx, "ETH", -1))
+	assert.Zero(t, chain.PoolAccount(chain.Account).Debt.Sign())
+}
+
+func TestAaveShortHedgerFailedSale(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+	aave := NewAaveManager(cm)
+
+	// The DEXes may not take WETH, so every sale fails, and the pool may
+	// take back only one borrow of 2 WETH
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_010))
+	chain.Mint(chain.WETH, chain.Pool, defitest.Ether(100))
+	for _, spender := range []common.Address{chain.Pool, chain.CurvePool, chain.Router} {
+		chain.Approve(chain.Key, chain.USDC, spender, MaxRepay)
+	}
+	chain.Approve(chain.Key, chain.WETH, chain.Pool, defitest.Ether(2))
+	autoCommit(t, chain, cm)
+	ctx := context.Background()
+	tx, err := aave.Supply(chain.USDC, defitest.Ether(1_000))
+	require.NoError(t, err)
+	require.NoError(t, waitHedge(ctx, cm, tx, "supply"))
+
+	// The borrow is repaid
+	hedger := NewAaveShortHedger(aave, venues, "USDC")
+	hedger.Tokens = map[string]string{"ETH": "WETH"}
+	err = hedger.AdjustHedge(ctx, "ETH", 2)
+	require.Error(t, err)
+	var partial *PartialHedgeError
+	assert.False(t, errors.As(err, &partial))
+	assert.Zero(t, chain.PoolAccount(chain.Account).Debt.Sign())
+	assert.Zero(t, chain.BalanceOf(chain.WETH, chain.Account).Sign())
+
+	// Without a repay the borrow is reported
+	err = hedger.AdjustHedge(ctx, "ETH", 2)
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, 2.0, partial.Amount)
+	assert.Equal(t, defitest.Ether(2).String(), chain.PoolAccount(chain.Account).Debt.String())
+
+	// So is a buy-back whose repay fails, by what it bought
+	err = hedger.AdjustHedge(ctx, "ETH", -1)
+	require.ErrorAs(t, err, &partial)
+	assert.InDelta(t, -1, partial.Amount, 1e-9)
+	assert.Equal(t, defitest.Ether(2).String(), chain.PoolAccount(chain.Account).Debt.String())
+	assert.GreaterOrEqual(t, chain.BalanceOf(chain.WETH, chain.Account).Cmp(defitest.Ether(3)), 0)
+
+	// And a borrow whose outcome was not waited for
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = hedger.AdjustHedge(cancelled, "ETH", 1)
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, 1.0, partial.Amount)
+}
@@ -0,0 +1,670 @@
+package defi
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Market making defaults
+const (
+	DefaultMarketMakingRiskAversion     = 0.1
+	DefaultMarketMakingOrderIntensity   = 400.0
+	DefaultMarketMakingHorizon          = 1.0
+	DefaultMarketMakingRequoteThreshold = 0.002
+	DefaultMarketMakingVolatilityWindow = 100
+)
+
+// QuoteSide is the side of a market maker's quote
+type QuoteSide string
+
+const (
+	QuoteBid QuoteSide = "bid"
+	QuoteAsk QuoteSide = "ask"
+)
+
+// MarketMakingConfig sets the pair a MarketMaker quotes and the parameters
+// of its Avellaneda-Stoikov quotes. Prices are quote tokens per base token
+// and amounts are whole tokens.
+type MarketMakingConfig struct {
+	// Base and Quote are the symbols of the pair's tokens
+	Base  string
+	Quote string
+
+	// BaseFeed and QuoteFeed are the market.Data symbols pricing the tokens
+	// in USD; Base and Quote when empty
+	BaseFeed  string
+	QuoteFeed string
+
+	// Fee is the fee tier of the Uniswap V3 pool the orders rest in;
+	// FeeTierMedium if zero
+	Fee uint24
+
+	// OrderSize is the base amount quoted on each side
+	OrderSize float64
+
+	// TargetInventory is the base holding the quotes skew back towards
+	TargetInventory float64
+
+	// RiskAversion is the model's gamma: how far quotes skew away from the
+	// inventory and how wide they are
+	RiskAversion float64
+
+	// Volatility is the standard deviation of the price's log returns per
+	// period. When zero it is estimated from the last VolatilityWindow
+	// prices.
+	Volatility       float64
+	VolatilityWindow int
+
+	// OrderIntensity is the model's kappa: how fast the chance of a fill
+	// falls as a quote moves away from the mid, per unit of relative spread
+	OrderIntensity float64
+
+	// Horizon is the number of periods the inventory risk is priced over,
+	// the model's T - t
+	Horizon float64
+
+	// MinSpread is the narrowest spread quoted, relative to the mid
+	MinSpread float64
+
+	// RequoteThreshold is how far, relative to the mid, a quote may move
+	// from its resting order before the order is replaced
+	RequoteThreshold float64
+}
+
+// Validate checks that the config names a pair and an order size
+func (c MarketMakingConfig) Validate() error {
+	if c.Base == "" || c.Quote == "" {
+		return fmt.Errorf("market making needs a base and a quote token")
+	}
+	if c.OrderSize <= 0 {
+		return fmt.Errorf("invalid order size %v", c.OrderSize)
+	}
+	if c.RiskAversion < 0 || c.OrderIntensity < 0 || c.Volatility < 0 {
+		return fmt.Errorf("risk aversion, order intensity and volatility must not be negative")
+	}
+	return nil
+}
+
+// withDefaults fills in the zero parameters
+func (c MarketMakingConfig) withDefaults() MarketMakingConfig {
+	if c.BaseFeed == "" {
+		c.BaseFeed = c.Base
+	}
+	if c.QuoteFeed == "" {
+		c.QuoteFeed = c.Quote
+	}
+	if c.Fee == 0 {
+		c.Fee = FeeTierMedium
+	}
+	if c.RiskAversion == 0 {
+		c.RiskAversion = DefaultMarketMakingRiskAversion
+	}
+	if c.OrderIntensity == 0 {
+		c.OrderIntensity = DefaultMarketMakingOrderIntensity
+	}
+	if c.Horizon <= 0 {
+		c.Horizon = DefaultMarketMakingHorizon
+	}
+	if c.VolatilityWindow <= 0 {
+		c.VolatilityWindow = DefaultMarketMakingVolatilityWindow
+	}
+	if c.RequoteThreshold <= 0 {
+		c.RequoteThreshold = DefaultMarketMakingRequoteThreshold
+	}
+	return c
+}
+
+// MarketQuote is a market maker's two-sided quote
+type MarketQuote struct {
+	Mid float64
+
+	// Reservation is the price the market maker is indifferent at given its
+	// inventory; the quotes sit half the spread either side of it
+	Reservation float64
+	Bid         float64
+	Ask         float64
+
+	// Spread is Ask - Bid relative to Mid
+	Spread float64
+
+	// BidSize and AskSize are the base amounts quoted, zero when the
+	// inventory cap or the holdings rule a side out
+	BidSize float64
+	AskSize float64
+
+	// Inventory is the base holding quoted against and MaxInventory its
+	// cap, zero when uncapped
+	Inventory    float64
+	MaxInventory float64
+}
+
+// RangeOrder is a quote resting on the pool as a position over one tick
+// spacing. An ask holds base tokens and a bid quote tokens; the pool
+// converts the order as its price crosses the range.
+type RangeOrder struct {
+	Side      QuoteSide
+	TokenID   *big.Int
+	TickLower int
+	TickUpper int
+
+	// Price is what the order converts at, the price at the middle of its
+	// range, and Size its base amount
+	Price float64
+	Size  float64
+
+	PlacedAt time.Time
+
+	// holds0 is set when the order holds the pool's token0 and so sits
+	// above the pool price
+	holds0 bool
+}
+
+// crossed reports whether the pool price at tick has crossed the whole
+// order, converting it
+func (o *RangeOrder) crossed(tick int) bool {
+	if o.holds0 {
+		return tick >= o.TickUpper
+	}
+	return tick < o.TickLower
+}
+
+// MarketFill is a quote taken by the market
+type MarketFill struct {
+	Time  time.Time
+	Side  QuoteSide
+	Price float64
+	Size  float64
+}
+
+// MarketMaker quotes a pair around the reference price from market data,
+// skewing the quotes by its inventory as in the Avellaneda-Stoikov model,
+// and rests them on a Uniswap V3 pool as range orders. Simulate replays
+// recorded prices through the same quotes without a chain.
+type MarketMaker struct {
+	Config MarketMakingConfig
+
+	// Risk caps the base inventory at MaxPositionSize of the equity, the
+	// value of everything the market maker holds
+	Risk portfolio.RiskProfile
+
+	Market    *market.Data
+	Liquidity *UniswapV3LPManager
+
+	Clock Clock
+
+	mu     sync.Mutex
+	orders map[QuoteSide]*RangeOrder
+	fills  []MarketFill
+}
+
+// NewMarketMaker creates a market maker placing orders through lm
+func NewMarketMaker(config MarketMakingConfig, risk portfolio.RiskProfile, data *market.Data, lm *UniswapV3LPManager) *MarketMaker {
+	return &MarketMaker{
+		Config:    config,
+		Risk:      risk,
+		Market:    data,
+		Liquidity: lm,
+		Clock:     SystemClock,
+		orders:    make(map[QuoteSide]*RangeOrder),
+	}
+}
+
+// Quote computes the quote around mid for a base inventory and a quote
+// token balance. The reservation price moves against the inventory's
+// distance from the target, counted in order sizes, by
+// gamma * sigma^2 * horizon each, and the spread is the model's optimal
+// gamma * sigma^2 * horizon + 2/gamma * ln(1 + gamma/kappa), no narrower
+// than MinSpread. Neither quote crosses the mid.
+func (mm *MarketMaker) Quote(mid, volatility, inventory, quoteBalance float64) MarketQuote {
+	cfg := mm.Config.withDefaults()
+	gamma, kappa := cfg.RiskAversion, cfg.OrderIntensity
+
+	risk := gamma * volatility * volatility * cfg.Horizon
+	lots := (inventory - cfg.TargetInventory) / cfg.OrderSize
+	reservation := mid * math.Exp(-lots*risk)
+
+	spread := risk + 2/gamma*math.Log1p(gamma/kappa)
+	if spread < cfg.MinSpread {
+		spread = cfg.MinSpread
+	}
+	quote := MarketQuote{
+		Mid:         mid,
+		Reservation: reservation,
+		Bid:         math.Min(reservation*math.Exp(-spread/2), mid),
+		Ask:         math.Max(reservation*math.Exp(spread/2), mid),
+		Inventory:   inventory,
+	}
+	quote.Spread = (quote.Ask - quote.Bid) / mid
+
+	bidSize := math.Min(cfg.OrderSize, quoteBalance/quote.Bid)
+	if mm.Risk.MaxPositionSize > 0 {
+		equity := inventory*mid + quoteBalance
+		quote.MaxInventory = mm.Risk.MaxPositionSize * equity / mid
+		bidSize = math.Min(bidSize, quote.MaxInventory-inventory)
+	}
+	quote.BidSize = math.Max(bidSize, 0)
+	quote.AskSize = math.Max(math.Min(cfg.OrderSize, inventory), 0)
+	return quote
+}
+
+// ReferencePrice is the base token's price in quote tokens from the market
+// data
+func (mm *MarketMaker) ReferencePrice() (float64, error) {
+	cfg := mm.Config.withDefaults()
+	if mm.Market == nil {
+		return 0, fmt.Errorf("no market data")
+	}
+	base, exists := mm.Market.GetPrice(cfg.BaseFeed)
+	if !exists || base.Price <= 0 {
+		return 0, fmt.Errorf("no %s price", cfg.BaseFeed)
+	}
+	quote, exists := mm.Market.GetPrice(cfg.QuoteFeed)
+	if !exists || quote.Price <= 0 {
+		return 0, fmt.Errorf("no %s price", cfg.QuoteFeed)
+	}
+	return base.Price / quote.Price, nil
+}
+
+// Volatility is the configured volatility, or the one estimated from the
+// base feed's price history
+func (mm *MarketMaker) Volatility() float64 {
+	cfg := mm.Config.withDefaults()
+	if cfg.Volatility > 0 || mm.Market == nil || mm.Market.History == nil {
+		return cfg.Volatility
+	}
+	return logReturnVolatility(mm.Market.History.Closes(cfg.BaseFeed, cfg.VolatilityWindow+1))
+}
+
+// Step refreshes the market maker's orders. It withdraws the orders the
+// pool price has crossed, recording them as fills, quotes around the
+// reference price, replaces the orders the quote has moved away from by
+// more than RequoteThreshold and places the missing sides. Each order sits
+// on the tick spacing nearest its quote on the far side of the pool price.
+func (mm *MarketMaker) Step() (*MarketQuote, error) {
+	if err := mm.Config.Validate(); err != nil {
+		return nil, err
+	}
+	if mm.Liquidity == nil {
+		return nil, fmt.Errorf("no liquidity manager to place orders with")
+	}
+	cfg := mm.Config.withDefaults()
+
+	mid, err := mm.ReferencePrice()
+	if err != nil {
+		return nil, err
+	}
+	pair, err := mm.pair()
+	if err != nil {
+		return nil, err
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	for _, side := range []QuoteSide{QuoteBid, QuoteAsk} {
+		order, exists := mm.orders[side]
+		if !exists || !order.crossed(pair.pool.Tick) {
+			continue
+		}
+		if err := mm.cancel(side); err != nil {
+			return nil, err
+		}
+		fill := MarketFill{Time: mm.now(), Side: side, Price: order.Price, Size: order.Size}
+		mm.fills = append(mm.fills, fill)
+		log.Printf("Market maker %s filled: %.6f %s at %.6f", side, fill.Size, cfg.Base, fill.Price)
+	}
+
+	inventory, quoteBalance, err := mm.holdings(pair)
+	if err != nil {
+		return nil, err
+	}
+	quote := mm.Quote(mid, mm.Volatility(), inventory, quoteBalance)
+	log.Printf("Market maker quote for %s/%s: %.6f x %.6f bid, %.6f x %.6f ask (mid %.6f, inventory %.6f)",
+		cfg.Base, cfg.Quote, quote.BidSize, quote.Bid, quote.AskSize, quote.Ask, mid, inventory)
+
+	for _, side := range []QuoteSide{QuoteBid, QuoteAsk} {
+		price, size := quote.Bid, quote.BidSize
+		if side == QuoteAsk {
+			price, size = quote.Ask, quote.AskSize
+		}
+
+		if order, exists := mm.orders[side]; exists {
+			_, _, target := pair.orderRange(side, price)
+			if size > 0 && math.Abs(order.Price-target)/mid <= cfg.RequoteThreshold {
+				continue
+			}
+			if err := mm.cancel(side); err != nil {
+				return nil, err
+			}
+		}
+		if size <= 0 {
+			continue
+		}
+		order, err := mm.place(pair, side, price, size)
+		if err != nil {
+			return nil, err
+		}
+		mm.orders[side] = order
+	}
+	return &quote, nil
+}
+
+// Orders returns the resting orders, bid first
+func (mm *MarketMaker) Orders() []RangeOrder {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	var orders []RangeOrder
+	for _, side := range []QuoteSide{QuoteBid, QuoteAsk} {
+		if order, exists := mm.orders[side]; exists {
+			orders = append(orders, *order)
+		}
+	}
+	return orders
+}
+
+// Fills returns the orders filled so far, oldest first
+func (mm *MarketMaker) Fills() []MarketFill {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return append([]MarketFill(nil), mm.fills...)
+}
+
+// CancelAll withdraws every resting order
+func (mm *MarketMaker) CancelAll() error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	for _, side := range []QuoteSide{QuoteBid, QuoteAsk} {
+		if _, exists := mm.orders[side]; !exists {
+			continue
+		}
+		if err := mm.cancel(side); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marketMakingPair is the pool the orders rest in and the pair's tokens
+type marketMakingPair struct {
+	pool          *LPPoolState
+	base, quote   common.Address
+	baseDecimals  int
+	quoteDecimals int
+	baseIs0       bool
+}
+
+// pair resolves the configured tokens and reads their pool
+func (mm *MarketMaker) pair() (*marketMakingPair, error) {
+	cfg := mm.Config.withDefaults()
+	cm := mm.Liquidity.ContractManager
+
+	base, err := mm.Liquidity.token(cfg.Base)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := mm.Liquidity.token(cfg.Quote)
+	if err != nil {
+		return nil, err
+	}
+	baseDecimals, err := cm.tokenDecimals(base)
+	if err != nil {
+		return nil, err
+	}
+	quoteDecimals, err := cm.tokenDecimals(quote)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := mm.Liquidity.Pool(base, quote, cfg.Fee)
+	if err != nil {
+		return nil, err
+	}
+	return &marketMakingPair{
+		pool:          pool,
+		base:          base,
+		quote:         quote,
+		baseDecimals:  baseDecimals,
+		quoteDecimals: quoteDecimals,
+		baseIs0:       pool.Token0 == base,
+	}, nil
+}
+
+// holdings returns the market maker's base and quote tokens, in its
+// account and resting in orders
+func (mm *MarketMaker) holdings(pair *marketMakingPair) (float64, float64, error) {
+	cm := mm.Liquidity.ContractManager
+	account := cm.Transactor.From
+
+	baseBalance, err := cm.tokenUint(pair.base, ERC20BalanceOf, account)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get %s balance: %v", mm.Config.Base, err)
+	}
+	quoteBalance, err := cm.tokenUint(pair.quote, ERC20BalanceOf, account)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get %s balance: %v", mm.Config.Quote, err)
+	}
+
+	inventory := wholeAmount(baseBalance, pair.baseDecimals)
+	quoteHeld := wholeAmount(quoteBalance, pair.quoteDecimals)
+	if ask, exists := mm.orders[QuoteAsk]; exists {
+		inventory += ask.Size
+	}
+	if bid, exists := mm.orders[QuoteBid]; exists {
+		quoteHeld += bid.Size * bid.Price
+	}
+	return inventory, quoteHeld, nil
+}
+
+// orderRange returns the ticks of an order on side quoted at price and the
+// price it converts at. The order covers the tick spacing holding price,
+// moved to the nearest one on the far side of the pool price when the quote
+// is not.
+func (p *marketMakingPair) orderRange(side QuoteSide, price float64) (int, int, float64) {
+	spacing := p.pool.TickSpacing
+
+	// Prices on the pool are token1 per token0 in base units
+	decimals0, decimals1 := p.baseDecimals, p.quoteDecimals
+	poolPrice := price
+	if !p.baseIs0 {
+		decimals0, decimals1 = decimals1, decimals0
+		poolPrice = 1 / price
+	}
+	tick := int(math.Floor(math.Log(poolPrice*math.Pow10(decimals1-decimals0)) / math.Log(1.0001)))
+
+	lower := floorTick(tick, spacing)
+	if p.holds0(side) && lower <= p.pool.Tick {
+		lower = floorTick(p.pool.Tick, spacing) + spacing
+	}
+	if !p.holds0(side) && lower+spacing > p.pool.Tick {
+		lower = floorTick(p.pool.Tick, spacing) - spacing
+	}
+	upper := lower + spacing
+
+	orderPrice := math.Pow(1.0001, float64(lower+upper)/2) * math.Pow10(decimals0-decimals1)
+	if !p.baseIs0 {
+		orderPrice = 1 / orderPrice
+	}
+	return lower, upper, orderPrice
+}
+
+// holds0 reports whether an order on side holds the pool's token0. An ask
+// holds base tokens and a bid quote tokens; token0 rests above the pool
+// price and token1 below it.
+func (p *marketMakingPair) holds0(side QuoteSide) bool {
+	return (side == QuoteAsk) == p.baseIs0
+}
+
+// place rests an order of size base tokens quoted at price on the pool
+func (mm *MarketMaker) place(pair *marketMakingPair, side QuoteSide, price, size float64) (*RangeOrder, error) {
+	lower, upper, orderPrice := pair.orderRange(side, price)
+	holds0 := pair.holds0(side)
+
+	// A bid spends what the quote budgeted for it and buys however much
+	// base that is at the order's price
+	amount := rawAmount(size, pair.baseDecimals)
+	if side == QuoteBid {
+		amount = rawAmount(size*price, pair.quoteDecimals)
+		size = size * price / orderPrice
+	}
+	amount0, amount1 := big.NewInt(0), big.NewInt(0)
+	if holds0 {
+		amount0 = amount
+	} else {
+		amount1 = amount
+	}
+
+	position, _, err := mm.Liquidity.mintRange(pair.pool, lower, upper, amount0, amount1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place %s: %v", side, err)
+	}
+	log.Printf("Market maker placed %s %s: %.6f at %.6f over ticks %d to %d",
+		side, position.TokenID, size, orderPrice, lower, upper)
+	return &RangeOrder{
+		Side:      side,
+		TokenID:   position.TokenID,
+		TickLower: lower,
+		TickUpper: upper,
+		Price:     orderPrice,
+		Size:      size,
+		PlacedAt:  mm.now(),
+		holds0:    holds0,
+	}, nil
+}
+
+// cancel withdraws the order on side
+func (mm *MarketMaker) cancel(side QuoteSide) error {
+	order := mm.orders[side]
+	if _, _, err := mm.Liquidity.withdraw(order.TokenID); err != nil {
+		return fmt.Errorf("failed to cancel %s %s: %v", side, order.TokenID, err)
+	}
+	delete(mm.orders, side)
+	return nil
+}
+
+func (mm *MarketMaker) now() time.Time {
+	if mm.Clock == nil {
+		return time.Now()
+	}
+	return mm.Clock.Now()
+}
+
+// MarketMakingResult is the outcome of replaying a price path through a
+// market maker's quotes
+type MarketMakingResult struct {
+	Fills []MarketFill
+
+	// Volume is the quote tokens traded
+	Volume float64
+
+	// StartEquity and EndEquity value the holdings in quote tokens at the
+	// first open and the last close
+	StartEquity float64
+	EndEquity   float64
+	PnL         float64
+
+	// Inventory and QuoteBalance are the holdings at the end of the path
+	// and PeakInventory the largest base inventory held
+	Inventory     float64
+	QuoteBalance  float64
+	PeakInventory float64
+}
+
+// Simulate replays a recorded price path through the market maker's quotes,
+// starting from inventory base and quoteBalance quote tokens. Each candle
+// is quoted at its open, with the volatility estimated from the closes
+// before it when the config sets none. A bid fills when the candle's low
+// reaches it and an ask when its high does, each at most once per candle.
+// The market maker's orders are not touched.
+func (mm *MarketMaker) Simulate(path []market.Candle, inventory, quoteBalance float64) (*MarketMakingResult, error) {
+	if err := mm.Config.Validate(); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty price path")
+	}
+	cfg := mm.Config.withDefaults()
+
+	result := &MarketMakingResult{
+		StartEquity:   inventory*path[0].Open + quoteBalance,
+		PeakInventory: inventory,
+	}
+	closes := make([]float64, 0, len(path))
+	for _, candle := range path {
+		volatility := cfg.Volatility
+		if volatility == 0 {
+			window := closes
+			if len(window) > cfg.VolatilityWindow+1 {
+				window = window[len(window)-cfg.VolatilityWindow-1:]
+			}
+			volatility = logReturnVolatility(window)
+		}
+
+		quote := mm.Quote(candle.Open, volatility, inventory, quoteBalance)
+		if quote.BidSize > 0 && candle.Low <= quote.Bid {
+			inventory += quote.BidSize
+			quoteBalance -= quote.BidSize * quote.Bid
+			result.Volume += quote.BidSize * quote.Bid
+			result.Fills = append(result.Fills, MarketFill{Time: candle.Time, Side: QuoteBid, Price: quote.Bid, Size: quote.BidSize})
+		}
+		if quote.AskSize > 0 && candle.High >= quote.Ask {
+			inventory -= quote.AskSize
+			quoteBalance += quote.AskSize * quote.Ask
+			result.Volume += quote.AskSize * quote.Ask
+			result.Fills = append(result.Fills, MarketFill{Time: candle.Time, Side: QuoteAsk, Price: quote.Ask, Size: quote.AskSize})
+		}
+		result.PeakInventory = math.Max(result.PeakInventory, inventory)
+		closes = append(closes, candle.Close)
+	}
+
+	result.Inventory = inventory
+	result.QuoteBalance = quoteBalance
+	result.EndEquity = inventory*path[len(path)-1].Close + quoteBalance
+	result.PnL = result.EndEquity - result.StartEquity
+	return result, nil
+}
+
+// logReturnVolatility is the sample standard deviation of the log returns
+// of prices, zero with fewer than two returns
+func logReturnVolatility(prices []float64) float64 {
+	var returns []float64
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 && prices[i] > 0 {
+			returns = append(returns, math.Log(prices[i]/prices[i-1]))
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// wholeAmount converts base units to whole tokens
+func wholeAmount(amount *big.Int, decimals int) float64 {
+	whole, _ := new(big.Float).Quo(new(big.Float).SetInt(amount), big.NewFloat(math.Pow10(decimals))).Float64()
+	return whole
+}
+
+// rawAmount converts whole tokens to base units
+func rawAmount(amount float64, decimals int) *big.Int {
+	raw, _ := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(math.Pow10(decimals))).Int(nil)
+	return raw
+}
@@ -0,0 +1,206 @@
+package defi
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+)
+
+func testMarketMakingConfig() MarketMakingConfig {
+	return MarketMakingConfig{
+		Base:            "WETH",
+		Quote:           "USDC",
+		OrderSize:       1,
+		TargetInventory: 5,
+		Volatility:      0.05,
+	}
+}
+
+func TestMarketMakerQuote(t *testing.T) {
+	mm := NewMarketMaker(testMarketMakingConfig(), portfolio.RiskProfile{MaxPositionSize: 0.5}, nil, nil)
+
+	// At the target inventory the quotes are symmetric around the mid
+	risk := 0.1 * 0.05 * 0.05
+	spread := risk + 2/0.1*math.Log1p(0.1/400)
+	quote := mm.Quote(1_000, 0.05, 5, 15_000)
+	assert.InDelta(t, 1_000, quote.Reservation, 1e-9)
+	assert.InDelta(t, 1_000*math.Exp(-spread/2), quote.Bid, 1e-9)
+	assert.InDelta(t, 1_000*math.Exp(spread/2), quote.Ask, 1e-9)
+	assert.InDelta(t, 0.00525, quote.Spread, 1e-5)
+	assert.Equal(t, 1.0, quote.BidSize)
+	assert.Equal(t, 1.0, quote.AskSize)
+	assert.InDelta(t, 10, quote.MaxInventory, 1e-9)
+
+	// Three lots long skews both quotes down, and the cap stops buying
+	long := mm.Quote(1_000, 0.05, 8, 2_000)
+	assert.InDelta(t, 1_000*math.Exp(-3*risk), long.Reservation, 1e-9)
+	assert.Less(t, long.Ask, quote.Ask)
+	assert.Less(t, long.Bid, quote.Bid)
+	assert.Zero(t, long.BidSize)
+	assert.Equal(t, 1.0, long.AskSize)
+
+	// Short of the target the quotes skew up; nothing is left to sell and
+	// the cap on a small equity limits the bid
+	short := mm.Quote(1_000, 0.05, 0, 500)
+	assert.Greater(t, short.Reservation, 1_000.0)
+	assert.Greater(t, short.Ask, quote.Ask)
+	assert.Zero(t, short.AskSize)
+	assert.InDelta(t, 0.25, short.BidSize, 1e-12)
+
+	// Wider minimum spreads win over the model's
+	mm.Config.MinSpread = 0.02
+	assert.InDelta(t, 0.02, mm.Quote(1_000, 0.05, 5, 5_000).Spread, 1e-6)
+
+	mm.Config.OrderSize = 0
+	require.Error(t, mm.Config.Validate())
+}
+
+func TestMarketMakerSimulate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candle := func(i int, open, low, high, close float64) market.Candle {
+		return market.Candle{Symbol: "WETH", Time: start.Add(time.Duration(i) * time.Hour), Open: open, High: high, Low: low, Close: close}
+	}
+	mm := NewMarketMaker(testMarketMakingConfig(), portfolio.RiskProfile{MaxPositionSize: 0.6}, nil, nil)
+
+	// A range-bound market takes both quotes every period, earning the spread
+	var ranging []market.Candle
+	for i := 0; i < 10; i++ {
+		ranging = append(ranging, candle(i, 1_000, 990, 1_010, 1_000))
+	}
+	result, err := mm.Simulate(ranging, 5, 5_000)
+	require.NoError(t, err)
+	assert.Len(t, result.Fills, 20)
+	assert.InDelta(t, 5, result.Inventory, 1e-9)
+	assert.InDelta(t, 10*1_000*mm.Quote(1_000, 0.05, 5, 5_000).Spread, result.PnL, 1e-6)
+	assert.InDelta(t, 10_000, result.StartEquity, 1e-9)
+
+	// A falling market fills only bids, buying less as the inventory nears
+	// its cap
+	var falling []market.Candle
+	price := 1_000.0
+	for i := 0; i < 10; i++ {
+		falling = append(falling, candle(i, price, price*0.98, price, price*0.98))
+		price *= 0.98
+	}
+	result, err = mm.Simulate(falling, 5, 5_000)
+	require.NoError(t, err)
+	for _, fill := range result.Fills {
+		assert.Equal(t, QuoteBid, fill.Side)
+	}
+	assert.Len(t, result.Fills, 10)
+	assert.Less(t, result.Fills[9].Size, result.Fills[0].Size)
+	assert.Less(t, result.Inventory, 7.0)
+	assert.Equal(t, result.Inventory, result.PeakInventory)
+	last := falling[len(falling)-1]
+	assert.LessOrEqual(t, result.Inventory, 0.6*(result.Inventory*last.Open+result.QuoteBalance)/last.Open+1e-9)
+	assert.Less(t, result.PnL, 0.0)
+
+	// Without a configured volatility it is estimated from the path so far
+	mm.Config.Volatility = 0
+	estimated, err := mm.Simulate(falling, 5, 5_000)
+	require.NoError(t, err)
+	assert.NotEqual(t, result.Fills, estimated.Fills)
+
+	_, err = mm.Simulate(nil, 5, 5_000)
+	require.Error(t, err)
+}
+
+func TestLogReturnVolatility(t *testing.T) {
+	assert.Zero(t, logReturnVolatility([]float64{100, 110}))
+	up, down := math.Log(1.1), math.Log(1/1.1)
+	mean := (up + down + up) / 3
+	variance := (2*(up-mean)*(up-mean) + (down-mean)*(down-mean)) / 2
+	assert.InDelta(t, math.Sqrt(variance), logReturnVolatility([]float64{100, 110, 100, 110}), 1e-12)
+}
+
+func TestMarketMakerRestsRangeOrders(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	lm, _, _ := newLPManager(t, chain, cm)
+
+	data := &market.Data{Prices: map[string]market.PriceData{
+		"ETH":  {Symbol: "ETH", Price: 1},
+		"USDC": {Symbol: "USDC", Price: 1},
+	}}
+	config := MarketMakingConfig{
+		Base:            "WETH",
+		Quote:           "USDC",
+		BaseFeed:        "ETH",
+		OrderSize:       10,
+		TargetInventory: 1_000,
+		Volatility:      0.02,
+	}
+	mm := NewMarketMaker(config, portfolio.RiskProfile{MaxPositionSize: 0.6}, data, lm)
+
+	mid, err := mm.ReferencePrice()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, mid)
+
+	quote, err := mm.Step()
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, quote.BidSize)
+	assert.Equal(t, 10.0, quote.AskSize)
+	assert.InDelta(t, 1_200, quote.MaxInventory, 1e-9)
+
+	orders := mm.Orders()
+	require.Len(t, orders, 2)
+	bid, ask := orders[0], orders[1]
+	assert.Equal(t, QuoteBid, bid.Side)
+	assert.Equal(t, QuoteAsk, ask.Side)
+	assert.Less(t, bid.Price, 1.0)
+	assert.Greater(t, ask.Price, 1.0)
+	for _, order := range orders {
+		assert.Equal(t, 60, order.TickUpper-order.TickLower)
+		position, err := lm.Position(order.TokenID)
+		require.NoError(t, err)
+		assert.Positive(t, position.Liquidity.Sign())
+		assert.False(t, position.InRange(0))
+		assert.False(t, order.crossed(0))
+	}
+	assert.Equal(t, defitest.Ether(990).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+	assert.Less(t, wholeAmount(chain.BalanceOf(chain.USDC, chain.Account), 18), 991.0)
+
+	// Orders the quote still matches stay on the pool
+	_, err = mm.Step()
+	require.NoError(t, err)
+	assert.Equal(t, orders, mm.Orders())
+	assert.Empty(t, lm.Positions())
+
+	// A higher reference price moves the ask up; the bid is already as close
+	// to the pool price as it can rest
+	data.Prices["ETH"] = market.PriceData{Symbol: "ETH", Price: 1.01}
+	_, err = mm.Step()
+	require.NoError(t, err)
+	moved := mm.Orders()
+	require.Len(t, moved, 2)
+	assert.Equal(t, bid.TokenID, moved[0].TokenID)
+	assert.NotEqual(t, ask.TokenID, moved[1].TokenID)
+	assert.Greater(t, moved[1].Price, ask.Price)
+	_, err = lm.Position(ask.TokenID)
+	require.Error(t, err)
+
+	require.NoError(t, mm.CancelAll())
+	assert.Empty(t, mm.Orders())
+	assert.Empty(t, mm.Fills())
+	assert.Equal(t, defitest.Ether(1_000).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+	assert.Equal(t, defitest.Ether(1_000).String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+}
+
+func TestRangeOrderCrossed(t *testing.T) {
+	ask := &RangeOrder{TickLower: 60, TickUpper: 120, holds0: true}
+	assert.False(t, ask.crossed(0))
+	assert.False(t, ask.crossed(119))
+	assert.True(t, ask.crossed(120))
+
+	bid := &RangeOrder{TickLower: -60, TickUpper: 0}
+	assert.False(t, bid.crossed(0))
+	assert.False(t, bid.crossed(-60))
+	assert.True(t, bid.crossed(-61))
+}
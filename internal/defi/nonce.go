@@ -0,0 +1,368 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxNonceRetries is how many times a send is retried after resyncing a
+// nonce that was taken by a transaction sent outside the manager
+const maxNonceRetries = 3
+
+// NonceManager allocates transaction nonces per sending address. Managers
+// sending from the same account should share one so that concurrent
+// strategies never reuse or skip a nonce.
+type NonceManager struct {
+	Client Backend
+
+	// Monitor, when set, follows transactions replaced by SpeedUp and Cancel
+	Monitor *TransactionMonitor
+
+	mu       sync.Mutex
+	accounts map[common.Address]*accountNonces
+	sent     map[common.Hash]sentTransaction
+}
+
+// accountNonces is the nonce state of one sending address. Its lock is held
+// from nonce allocation until the node accepts the transaction.
+type accountNonces struct {
+	mu      sync.Mutex
+	synced  bool
+	next    uint64
+	pending map[uint64]*types.Transaction
+}
+
+type sentTransaction struct {
+	from  common.Address
+	nonce uint64
+}
+
+// NewNonceManager creates a nonce manager on the given backend
+func NewNonceManager(client Backend) *NonceManager {
+	return &NonceManager{
+		Client:   client,
+		accounts: make(map[common.Address]*accountNonces),
+		sent:     make(map[common.Hash]sentTransaction),
+	}
+}
+
+// sharedNonceKey identifies the nonce manager of a sending address on a
+// chain, reached through one client
+type sharedNonceKey struct {
+	client  Backend
+	chainID string
+	from    common.Address
+}
+
+// sharedNonces holds the nonce managers handed out by SharedNonceManager
+// until their client is released
+var sharedNonces = struct {
+	sync.Mutex
+	managers map[sharedNonceKey]*NonceManager
+}{managers: make(map[sharedNonceKey]*NonceManager)}
+
+// SharedNonceManager returns the nonce manager for sending from an address on
+// a chain through client, creating it the first time. ContractManager and
+// RealBlockchainManager use it, so managers created separately on the same
+// client never hand out the same nonce. Call ReleaseNonceManagers when the
+// client is closed.
+func SharedNonceManager(client Backend, chainID *big.Int, from common.Address) *NonceManager {
+	key := sharedNonceKey{client: client, chainID: chainID.String(), from: from}
+
+	sharedNonces.Lock()
+	defer sharedNonces.Unlock()
+
+	nm, exists := sharedNonces.managers[key]
+	if !exists {
+		nm = NewNonceManager(client)
+		sharedNonces.managers[key] = nm
+	}
+	return nm
+}
+
+// ReleaseNonceManagers forgets the shared nonce managers of client, so a
+// closed client is not kept alive and managers created later start from
+// the chain's nonces
+func ReleaseNonceManagers(client Backend) {
+	sharedNonces.Lock()
+	defer sharedNonces.Unlock()
+
+	for key := range sharedNonces.managers {
+		if key.client == client {
+			delete(sharedNonces.managers, key)
+		}
+	}
+}
+
+// account returns the nonce state for an address, creating it unsynced
+func (nm *NonceManager) account(from common.Address) *accountNonces {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	acct, exists := nm.accounts[from]
+	if !exists {
+		acct = &accountNonces{pending: make(map[uint64]*types.Transaction)}
+		nm.accounts[from] = acct
+	}
+	return acct
+}
+
+// Send allocates the next nonce for from and sends the signed transaction
+// build returns for it. The nonce is only consumed once the node accepts the
+// transaction, so a failed build or send leaves no gap.
+func (nm *NonceManager) Send(ctx context.Context, from common.Address, build func(nonce uint64) (*types.Transaction, error)) (*types.Transaction, error) {
+	acct := nm.account(from)
+	acct.mu.Lock()
+	defer acct.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if !acct.synced {
+			if err := nm.sync(ctx, from, acct); err != nil {
+				return nil, err
+			}
+		}
+
+		tx, err := build(acct.next)
+		if err != nil {
+			return nil, err
+		}
+
+		// A node that already holds this exact transaction accepted an
+		// earlier broadcast of it, so it counts as sent
+		err = nm.Client.SendTransaction(ctx, tx)
+		if err == nil || isAlreadyKnown(err) {
+			acct.pending[tx.Nonce()] = tx
+			acct.next++
+			nm.track(tx, from)
+			return tx, nil
+		}
+
+		if !isNonceConflict(err) || attempt >= maxNonceRetries {
+			return nil, fmt.Errorf("failed to send transaction: %v", err)
+		}
+		log.Printf("Nonce %d for %s is taken, resyncing: %v", acct.next, from.Hex(), err)
+		acct.synced = false
+	}
+}
+
+// Resync discards the local nonce for from and reloads it from the node
+func (nm *NonceManager) Resync(ctx context.Context, from common.Address) error {
+	acct := nm.account(from)
+	acct.mu.Lock()
+	defer acct.mu.Unlock()
+
+	return nm.sync(ctx, from, acct)
+}
+
+// sync loads the next nonce from the node's pending state and forgets
+// transactions that have since been mined. The account lock must be held.
+func (nm *NonceManager) sync(ctx context.Context, from common.Address, acct *accountNonces) error {
+	next, err := nm.Client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %v", err)
+	}
+	if err := nm.prune(ctx, from, acct); err != nil {
+		return err
+	}
+
+	acct.next = next
+	acct.synced = true
+	return nil
+}
+
+// prune drops pending transactions below the account's mined nonce. The
+// account lock must be held.
+func (nm *NonceManager) prune(ctx context.Context, from common.Address, acct *accountNonces) error {
+	mined, err := nm.Client.NonceAt(ctx, from, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	for nonce := range acct.pending {
+		if nonce < mined {
+			delete(acct.pending, nonce)
+		}
+	}
+
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	for hash, sent := range nm.sent {
+		if sent.from == from && sent.nonce < mined {
+			delete(nm.sent, hash)
+		}
+	}
+	return nil
+}
+
+func (nm *NonceManager) track(tx *types.Transaction, from common.Address) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	nm.sent[tx.Hash()] = sentTransaction{from: from, nonce: tx.Nonce()}
+}
+
+// PendingTransactions returns the transactions sent from an address that
+// were not yet mined when the manager last checked, in nonce order
+func (nm *NonceManager) PendingTransactions(from common.Address) []*types.Transaction {
+	acct := nm.account(from)
+	acct.mu.Lock()
+	defer acct.mu.Unlock()
+
+	pending := make([]*types.Transaction, 0, len(acct.pending))
+	for _, tx := range acct.pending {
+		pending = append(pending, tx)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Nonce() < pending[j].Nonce() })
+	return pending
+}
+
+// SpeedUp re-broadcasts a stuck transaction with the same nonce and fees
+// raised enough for nodes to accept it as a replacement, or to the policy's
+// current quote when that is higher
+func (nm *NonceManager) SpeedUp(ctx context.Context, txHash common.Hash, policy *GasPolicy, sign bind.SignerFn) (*types.Transaction, error) {
+	return nm.replace(ctx, txHash, policy, sign, false)
+}
+
+// Cancel replaces a stuck transaction with an empty transfer to the sender,
+// freeing its nonce without executing the original call
+func (nm *NonceManager) Cancel(ctx context.Context, txHash common.Hash, policy *GasPolicy, sign bind.SignerFn) (*types.Transaction, error) {
+	return nm.replace(ctx, txHash, policy, sign, true)
+}
+
+func (nm *NonceManager) replace(ctx context.Context, txHash common.Hash, policy *GasPolicy, sign bind.SignerFn, cancel bool) (*types.Transaction, error) {
+	nm.mu.Lock()
+	sent, exists := nm.sent[txHash]
+	nm.mu.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("transaction %s is not pending in the nonce manager", txHash.Hex())
+	}
+
+	acct := nm.account(sent.from)
+	acct.mu.Lock()
+	defer acct.mu.Unlock()
+
+	if err := nm.prune(ctx, sent.from, acct); err != nil {
+		return nil, err
+	}
+	old, pending := acct.pending[sent.nonce]
+	if !pending {
+		return nil, fmt.Errorf("transaction %s is already mined", txHash.Hex())
+	}
+	if old.Hash() != txHash {
+		return nil, fmt.Errorf("transaction %s has been replaced by %s", txHash.Hex(), old.Hash().Hex())
+	}
+
+	var fees TxFees
+	if policy != nil {
+		var err error
+		fees, err = policy.Fees(ctx, nm.Client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	to, value, data, gas := old.To(), old.Value(), old.Data(), old.Gas()
+	if cancel {
+		to, value, data, gas = &sent.from, big.NewInt(0), nil, params.TxGas
+	}
+
+	var tx *types.Transaction
+	if old.Type() == types.LegacyTxType {
+		gasPrice := maxBig(bumpFee(old.GasPrice()), fees.GasPrice, fees.GasFeeCap)
+		if err := checkFeeCap(policy, gasPrice); err != nil {
+			return nil, err
+		}
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    old.Nonce(),
+			GasPrice: gasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		})
+	} else {
+		tip := maxBig(bumpFee(old.GasTipCap()), fees.GasTipCap)
+		feeCap := maxBig(bumpFee(old.GasFeeCap()), fees.GasFeeCap, tip)
+		if err := checkFeeCap(policy, feeCap); err != nil {
+			return nil, err
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   old.ChainId(),
+			Nonce:     old.Nonce(),
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+
+	signedTx, err := sign(sent.from, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := nm.Client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("failed to send replacement transaction: %v", err)
+	}
+
+	acct.pending[sent.nonce] = signedTx
+	nm.track(signedTx, sent.from)
+	if nm.Monitor != nil {
+		nm.Monitor.Replace(txHash, signedTx)
+	}
+
+	log.Printf("Transaction %s replaced by %s", txHash.Hex(), signedTx.Hash().Hex())
+	return signedTx, nil
+}
+
+// isNonceConflict reports whether a node rejected a transaction because its
+// nonce is already used by a different mined or pending transaction
+func isNonceConflict(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") ||
+		strings.Contains(msg, "replacement transaction underpriced")
+}
+
+// isAlreadyKnown reports whether a node rejected a transaction because the
+// identical transaction is already in its pool
+func isAlreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// bumpFee raises a fee by just over the 10% nodes require to replace a
+// pending transaction
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Div(fee, big.NewInt(10))
+	return bumped.Add(bumped, fee).Add(bumped, big.NewInt(1))
+}
+
+// checkFeeCap rejects a replacement fee over the policy's hard cap
+func checkFeeCap(policy *GasPolicy, fee *big.Int) error {
+	if policy != nil && policy.MaxFeePerGas != nil && fee.Cmp(policy.MaxFeePerGas) > 0 {
+		return fmt.Errorf("replacement fee %s exceeds the max fee per gas %s", fee, policy.MaxFeePerGas)
+	}
+	return nil
+}
+
+// maxBig returns the largest of the non-nil values
+func maxBig(values ...*big.Int) *big.Int {
+	var max *big.Int
+	for _, v := range values {
+		if v != nil && (max == nil || v.Cmp(max) > 0) {
+			max = v
+		}
+	}
+	return new(big.Int).Set(max)
+}
@@ -0,0 +1,217 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestNonceManagerConcurrentSends(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	bm, err := NewRealBlockchainManagerWithBackend(chain.Client, chain.PrivateKeyHex())
+	require.NoError(t, err)
+
+	// Both managers send from the same account and share its nonces
+	require.Same(t, cm.Nonces, bm.Nonces)
+
+	// Another chain's client gets its own manager, and a released client's
+	// managers are not handed out again
+	other := defitest.NewChain(t)
+	assert.NotSame(t, cm.Nonces, SharedNonceManager(other.Client, cm.ChainID, chain.Account))
+	ReleaseNonceManagers(other.Client)
+	released := SharedNonceManager(other.Client, cm.ChainID, chain.Account)
+	ReleaseNonceManagers(other.Client)
+	assert.NotSame(t, released, SharedNonceManager(other.Client, cm.ChainID, chain.Account))
+	ReleaseNonceManagers(other.Client)
+
+	const senders = 8
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+	txs := make(chan *types.Transaction, 2*senders)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+			assert.NoError(t, err)
+			txs <- tx
+
+			tx, err = bm.SendTransaction(recipient, big.NewInt(1), nil)
+			assert.NoError(t, err)
+			txs <- tx
+		}()
+	}
+	wg.Wait()
+	close(txs)
+
+	nonces := make(map[uint64]bool)
+	var sent []*types.Transaction
+	for tx := range txs {
+		require.NotNil(t, tx)
+		assert.False(t, nonces[tx.Nonce()], "nonce %d reused", tx.Nonce())
+		nonces[tx.Nonce()] = true
+		sent = append(sent, tx)
+	}
+	for nonce := uint64(0); nonce < 2*senders; nonce++ {
+		assert.True(t, nonces[nonce], "nonce %d skipped", nonce)
+	}
+	assert.Len(t, cm.Nonces.PendingTransactions(chain.Account), 2*senders)
+
+	chain.Commit()
+	for _, tx := range sent {
+		receipt, err := chain.Client.TransactionReceipt(context.Background(), tx.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	}
+}
+
+func TestNonceManagerResyncsAfterExternalSend(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), tx.Nonce())
+
+	// Another wallet using the same key takes nonce 1 behind the manager's back
+	chain.Approve(chain.Key, chain.USDC, chain.Pool, defitest.Ether(2))
+
+	tx, err = cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(3))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), tx.Nonce())
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+
+	// The mined transactions are no longer pending
+	require.NoError(t, cm.Nonces.Resync(context.Background(), chain.Account))
+	assert.Empty(t, cm.Nonces.PendingTransactions(chain.Account))
+}
+
+func TestNonceManagerSpeedUpAndCancel(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	monitor := NewTransactionMonitor(chain.Client)
+	cm.Nonces.Monitor = monitor
+
+	stuck, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(5))
+	require.NoError(t, err)
+	_, err = monitor.MonitorTransaction(stuck.Hash(), chain.Account)
+	require.NoError(t, err)
+	assert.Len(t, monitor.StuckTransactions(0), 1)
+
+	faster, err := cm.SpeedUpTransaction(stuck.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, stuck.Nonce(), faster.Nonce())
+	assert.Equal(t, stuck.Data(), faster.Data())
+	assert.Greater(t, faster.GasTipCap().Cmp(stuck.GasTipCap()), 0)
+	assert.Greater(t, faster.GasFeeCap().Cmp(stuck.GasFeeCap()), 0)
+
+	info, _ := monitor.GetTransactionInfo(stuck.Hash())
+	assert.Equal(t, TransactionReplaced, info.Status)
+	assert.Equal(t, faster.Hash(), info.ReplacedBy)
+	info, exists := monitor.GetTransactionInfo(faster.Hash())
+	require.True(t, exists)
+	assert.Equal(t, []common.Hash{stuck.Hash()}, info.Replaces)
+
+	_, err = cm.SpeedUpTransaction(stuck.Hash())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has been replaced")
+
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, faster).Status)
+	assert.Equal(t, defitest.Ether(5).String(), chain.Allowance(chain.USDC, chain.Account, chain.Router).String())
+
+	_, err = cm.SpeedUpTransaction(faster.Hash())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already mined")
+
+	// A cancelled approval never takes effect
+	stuck, err = cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(9))
+	require.NoError(t, err)
+	cancelled, err := cm.CancelTransaction(stuck.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, stuck.Nonce(), cancelled.Nonce())
+	assert.Equal(t, chain.Account, *cancelled.To())
+	assert.Empty(t, cancelled.Data())
+
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, cancelled).Status)
+	assert.Equal(t, defitest.Ether(5).String(), chain.Allowance(chain.USDC, chain.Account, chain.Router).String())
+
+	// The next transaction follows the cancelled nonce
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+	assert.Equal(t, cancelled.Nonce()+1, tx.Nonce())
+}
+
+func TestNonceManagerReplacementRespectsFeeCap(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+
+	stuck, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+
+	cm.Gas.MaxFeePerGas = stuck.GasFeeCap()
+	_, err = cm.SpeedUpTransaction(stuck.Hash())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the max fee per gas")
+}
+
+func TestIsNonceConflict(t *testing.T) {
+	assert.True(t, isNonceConflict(errors.New("nonce too low: address 0xabc, tx: 1 state: 2")))
+	assert.True(t, isNonceConflict(errors.New("replacement transaction underpriced")))
+	assert.False(t, isNonceConflict(errors.New("already known")))
+	assert.False(t, isNonceConflict(errors.New("insufficient funds for gas * price + value")))
+	assert.True(t, isAlreadyKnown(errors.New("already known")))
+}
+
+// rebroadcastBackend delivers every transaction but reports it as already
+// known, as a node does when an earlier broadcast reached it
+type rebroadcastBackend struct {
+	Backend
+}
+
+func (b rebroadcastBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.Backend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	return errors.New("already known")
+}
+
+func TestNonceManagerAlreadyKnownIsSent(t *testing.T) {
+	chain := defitest.NewChain(t)
+	nm := NewNonceManager(rebroadcastBackend{chain.Client})
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000000b0")
+
+	builds := 0
+	send := func() *types.Transaction {
+		tx, err := nm.Send(context.Background(), chain.Account, func(nonce uint64) (*types.Transaction, error) {
+			builds++
+			return types.SignNewTx(chain.Key, types.LatestSignerForChainID(chain.ChainID), &types.DynamicFeeTx{
+				ChainID:   chain.ChainID,
+				Nonce:     nonce,
+				GasTipCap: big.NewInt(1e9),
+				GasFeeCap: big.NewInt(1e11),
+				Gas:       21_000,
+				To:        &recipient,
+				Value:     big.NewInt(1),
+			})
+		})
+		require.NoError(t, err)
+		return tx
+	}
+
+	// Each action is signed and broadcast once, and the next one moves on
+	assert.Equal(t, uint64(0), send().Nonce())
+	assert.Equal(t, uint64(1), send().Nonce())
+	assert.Equal(t, 2, builds)
+	assert.Len(t, nm.PendingTransactions(chain.Account), 2)
+}
@@ -0,0 +1,318 @@
+package poolmath
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Curve fees are fractions of curveFeeDenominator
+var (
+	curveFeeDenominator = big.NewInt(10_000_000_000)
+	curvePrecision      = big.NewInt(1e18)
+)
+
+// curveIterations is how many Newton steps the contracts allow
+const curveIterations = 255
+
+var errCurveConvergence = errors.New("stableswap invariant did not converge")
+
+// CurvePool is a Curve StableSwap pool as in StableSwap3Pool.vy. Balances
+// are scaled by Rates to 18 decimals before the invariant is applied. As a
+// Pool it trades coin 0 for coin 1.
+type CurvePool struct {
+	Balances []*big.Int
+	Rates    []*big.Int // 10^(36-decimals) for each coin, the contract's RATES
+
+	// A is the amplification coefficient as stored by the contract, which
+	// for later pools includes a factor of APrecision
+	A          *big.Int
+	APrecision *big.Int
+
+	Fee      *big.Int // swap fee in 1e-10 units, 4000000 for 0.04%
+	AdminFee *big.Int // share of the swap fee withdrawn from the pool, in 1e-10 units
+}
+
+// NewCurvePool creates a pool from coin balances and decimals with the
+// 3pool-era amplification precision of 1
+func NewCurvePool(balances []*big.Int, decimals []int, a, fee, adminFee int64) (*CurvePool, error) {
+	if len(balances) < 2 || len(balances) != len(decimals) {
+		return nil, fmt.Errorf("need matching balances and decimals for at least two coins")
+	}
+
+	pool := &CurvePool{
+		Balances:   make([]*big.Int, len(balances)),
+		Rates:      make([]*big.Int, len(balances)),
+		A:          big.NewInt(a),
+		APrecision: big.NewInt(1),
+		Fee:        big.NewInt(fee),
+		AdminFee:   big.NewInt(adminFee),
+	}
+	for i, balance := range balances {
+		if decimals[i] < 0 || decimals[i] > 36 {
+			return nil, fmt.Errorf("invalid decimals %d for coin %d", decimals[i], i)
+		}
+		pool.Balances[i] = new(big.Int).Set(balance)
+		pool.Rates[i] = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(36-decimals[i])), nil)
+	}
+	return pool, nil
+}
+
+// xp returns the balances scaled to 18 decimals
+func (p *CurvePool) xp() []*big.Int {
+	xp := make([]*big.Int, len(p.Balances))
+	for i, balance := range p.Balances {
+		xp[i] = mulDiv(p.Rates[i], balance, curvePrecision)
+	}
+	return xp
+}
+
+// getD solves the StableSwap invariant for D by Newton's method
+func (p *CurvePool) getD(xp []*big.Int) (*big.Int, error) {
+	n := big.NewInt(int64(len(xp)))
+	sum := new(big.Int)
+	for _, x := range xp {
+		sum.Add(sum, x)
+	}
+	if sum.Sign() == 0 {
+		return sum, nil
+	}
+
+	d := new(big.Int).Set(sum)
+	ann := new(big.Int).Mul(p.A, n)
+	for i := 0; i < curveIterations; i++ {
+		dP := new(big.Int).Set(d)
+		for _, x := range xp {
+			// D_P = D_P * D / (x * N)
+			dP.Mul(dP, d).Quo(dP, new(big.Int).Mul(x, n))
+		}
+		prev := d
+
+		// D = (Ann*S/A_PRECISION + D_P*N) * D / ((Ann - A_PRECISION)*D/A_PRECISION + (N+1)*D_P)
+		numerator := new(big.Int).Mul(ann, sum)
+		numerator.Quo(numerator, p.APrecision).Add(numerator, new(big.Int).Mul(dP, n)).Mul(numerator, d)
+		denominator := new(big.Int).Sub(ann, p.APrecision)
+		denominator.Mul(denominator, d).Quo(denominator, p.APrecision)
+		denominator.Add(denominator, new(big.Int).Mul(new(big.Int).Add(n, big.NewInt(1)), dP))
+		d = numerator.Quo(numerator, denominator)
+
+		if withinOne(d, prev) {
+			return d, nil
+		}
+	}
+	return nil, errCurveConvergence
+}
+
+// getY returns the new scaled balance of coin j that keeps the invariant
+// when coin i's scaled balance becomes x
+func (p *CurvePool) getY(i, j int, x *big.Int, xp []*big.Int) (*big.Int, error) {
+	n := big.NewInt(int64(len(xp)))
+	d, err := p.getD(xp)
+	if err != nil {
+		return nil, err
+	}
+
+	ann := new(big.Int).Mul(p.A, n)
+	c := new(big.Int).Set(d)
+	sum := new(big.Int)
+	for k := range xp {
+		var xk *big.Int
+		switch k {
+		case i:
+			xk = x
+		case j:
+			continue
+		default:
+			xk = xp[k]
+		}
+		sum.Add(sum, xk)
+		c.Mul(c, d).Quo(c, new(big.Int).Mul(xk, n))
+	}
+	// c = c * D * A_PRECISION / (Ann * N); b = S + D * A_PRECISION / Ann
+	c.Mul(c, d).Mul(c, p.APrecision).Quo(c, new(big.Int).Mul(ann, n))
+	b := new(big.Int).Mul(d, p.APrecision)
+	b.Quo(b, ann).Add(b, sum)
+
+	y := new(big.Int).Set(d)
+	for k := 0; k < curveIterations; k++ {
+		prev := y
+		// y = (y*y + c) / (2*y + b - D)
+		numerator := new(big.Int).Mul(y, y)
+		numerator.Add(numerator, c)
+		denominator := new(big.Int).Lsh(y, 1)
+		denominator.Add(denominator, b).Sub(denominator, d)
+		y = numerator.Quo(numerator, denominator)
+
+		if withinOne(y, prev) {
+			return y, nil
+		}
+	}
+	return nil, errCurveConvergence
+}
+
+func withinOne(a, b *big.Int) bool {
+	diff := new(big.Int).Sub(a, b)
+	return diff.CmpAbs(big.NewInt(1)) <= 0
+}
+
+func (p *CurvePool) checkCoins(i, j int) error {
+	if i == j || i < 0 || j < 0 || i >= len(p.Balances) || j >= len(p.Balances) {
+		return fmt.Errorf("invalid coin indices %d and %d", i, j)
+	}
+	return nil
+}
+
+// swapOutput returns the scaled output of coin j before fees for dx of coin i
+func (p *CurvePool) swapOutput(i, j int, dx *big.Int) (*big.Int, error) {
+	if err := p.checkCoins(i, j); err != nil {
+		return nil, err
+	}
+	if err := positive(dx); err != nil {
+		return nil, err
+	}
+
+	xp := p.xp()
+	x := mulDiv(dx, p.Rates[i], curvePrecision)
+	x.Add(x, xp[i])
+	y, err := p.getY(i, j, x, xp)
+	if err != nil {
+		return nil, err
+	}
+
+	dy := new(big.Int).Sub(xp[j], y)
+	dy.Sub(dy, big.NewInt(1))
+	if dy.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: output rounds to zero", ErrInsufficientLiquidity)
+	}
+	return dy, nil
+}
+
+// GetDy returns the output of coin j for dx of coin i, as the contract's
+// get_dy
+func (p *CurvePool) GetDy(i, j int, dx *big.Int) (*big.Int, error) {
+	dy, err := p.swapOutput(i, j, dx)
+	if err != nil {
+		return nil, err
+	}
+
+	dy = mulDiv(dy, curvePrecision, p.Rates[j])
+	fee := mulDiv(p.Fee, dy, curveFeeDenominator)
+	return dy.Sub(dy, fee), nil
+}
+
+// Exchange swaps dx of coin i for coin j and updates the balances, as the
+// contract's exchange. The admin share of the fee leaves the pool.
+func (p *CurvePool) Exchange(i, j int, dx *big.Int) (*SwapResult, error) {
+	dyScaled, err := p.swapOutput(i, j, dx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The fee is taken from the output before it is unscaled, so the
+	// result can differ from GetDy by a wei for coins with fewer decimals
+	feeScaled := mulDiv(dyScaled, p.Fee, curveFeeDenominator)
+	dy := new(big.Int).Sub(dyScaled, feeScaled)
+	dy = mulDiv(dy, curvePrecision, p.Rates[j])
+	adminFee := mulDiv(feeScaled, p.AdminFee, curveFeeDenominator)
+	adminFee = mulDiv(adminFee, curvePrecision, p.Rates[j])
+
+	result := &SwapResult{
+		AmountIn:    new(big.Int).Set(dx),
+		AmountOut:   dy,
+		Fee:         mulDiv(feeScaled, curvePrecision, p.Rates[j]),
+		PriceBefore: p.SpotPriceBetween(i, j),
+	}
+	p.Balances[i].Add(p.Balances[i], dx)
+	p.Balances[j].Sub(p.Balances[j], dy).Sub(p.Balances[j], adminFee)
+	result.PriceAfter = p.SpotPriceBetween(i, j)
+	return result, nil
+}
+
+// Swap exchanges coin 0 for coin 1 (zeroForOne) or coin 1 for coin 0
+func (p *CurvePool) Swap(amountIn *big.Int, zeroForOne bool) (*SwapResult, error) {
+	if zeroForOne {
+		return p.Exchange(0, 1, amountIn)
+	}
+	return p.Exchange(1, 0, amountIn)
+}
+
+// SpotPriceBetween returns the marginal price of coin i in coin j, in raw
+// units and before fees. It is the ratio of the invariant's partial
+// derivatives, (Ann + D_P/x_i) / (Ann + D_P/x_j), with D_P = D^(n+1) / (n^n * prod(x)).
+func (p *CurvePool) SpotPriceBetween(i, j int) float64 {
+	if p.checkCoins(i, j) != nil {
+		return 0
+	}
+	xp := p.xp()
+	d, err := p.getD(xp)
+	if err != nil || d.Sign() == 0 {
+		return 0
+	}
+
+	n := big.NewInt(int64(len(xp)))
+	dP := new(big.Int).Set(d)
+	for _, x := range xp {
+		if x.Sign() == 0 {
+			return 0
+		}
+		dP.Mul(dP, d).Quo(dP, new(big.Int).Mul(x, n))
+	}
+
+	annF, _ := new(big.Float).Quo(new(big.Float).SetInt(new(big.Int).Mul(p.A, n)), new(big.Float).SetInt(p.APrecision)).Float64()
+	dPF, _ := new(big.Float).SetInt(dP).Float64()
+	xi, _ := new(big.Float).SetInt(xp[i]).Float64()
+	xj, _ := new(big.Float).SetInt(xp[j]).Float64()
+	price := (annF + dPF/xi) / (annF + dPF/xj)
+
+	// Convert scaled units to raw: rate_i / rate_j
+	return price * ratio(p.Rates[i], p.Rates[j])
+}
+
+// SpotPrice returns the marginal price of coin 0 in coin 1
+func (p *CurvePool) SpotPrice() float64 {
+	return p.SpotPriceBetween(0, 1)
+}
+
+// Clone returns a copy of the pool
+func (p *CurvePool) Clone() Pool {
+	clone := &CurvePool{
+		Balances:   make([]*big.Int, len(p.Balances)),
+		Rates:      make([]*big.Int, len(p.Rates)),
+		A:          new(big.Int).Set(p.A),
+		APrecision: new(big.Int).Set(p.APrecision),
+		Fee:        new(big.Int).Set(p.Fee),
+		AdminFee:   new(big.Int).Set(p.AdminFee),
+	}
+	for i := range p.Balances {
+		clone.Balances[i] = new(big.Int).Set(p.Balances[i])
+		clone.Rates[i] = new(big.Int).Set(p.Rates[i])
+	}
+	return clone
+}
+
+// CurvePair trades two coins of a Curve pool as a Pool, with coin I as
+// token0 and coin J as token1. It lets pools of three or more coins, or
+// pairs not at indices 0 and 1, stand in for a two-token pool.
+type CurvePair struct {
+	Pool *CurvePool
+	I    int
+	J    int
+}
+
+// Swap exchanges coin I for coin J (zeroForOne) or coin J for coin I
+func (p *CurvePair) Swap(amountIn *big.Int, zeroForOne bool) (*SwapResult, error) {
+	if zeroForOne {
+		return p.Pool.Exchange(p.I, p.J, amountIn)
+	}
+	return p.Pool.Exchange(p.J, p.I, amountIn)
+}
+
+// SpotPrice returns the marginal price of coin I in coin J
+func (p *CurvePair) SpotPrice() float64 {
+	return p.Pool.SpotPriceBetween(p.I, p.J)
+}
+
+// Clone returns a copy of the pair and its pool
+func (p *CurvePair) Clone() Pool {
+	return &CurvePair{Pool: p.Pool.Clone().(*CurvePool), I: p.I, J: p.J}
+}
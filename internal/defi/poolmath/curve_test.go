@@ -0,0 +1,112 @@
+package poolmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newThreePool builds a DAI/USDC/USDT pool with 3pool's parameters
+func newThreePool(t *testing.T) *CurvePool {
+	t.Helper()
+	pool, err := NewCurvePool([]*big.Int{
+		bigInt(t, "100000000000000000000000000"), // 100M DAI
+		big.NewInt(120_000_000_000_000),          // 120M USDC
+		big.NewInt(80_000_000_000_000),           // 80M USDT
+	}, []int{18, 6, 6}, 2000, 1_000_000, 5_000_000_000)
+	require.NoError(t, err)
+	return pool
+}
+
+func TestCurveGetD(t *testing.T) {
+	pool := newThreePool(t)
+	d, err := pool.getD(pool.xp())
+	require.NoError(t, err)
+	assert.Equal(t, "299997917765608652100285788", d.String())
+}
+
+func TestCurveGetDy(t *testing.T) {
+	tests := []struct {
+		name string
+		i, j int
+		dx   string
+		want string
+	}{
+		{"DAI to USDC", 0, 1, "1000000000000000000000", "999986753"},
+		{"USDC to USDT", 1, 2, "1000000000000", "999676783018"},
+		{"USDT to DAI", 2, 0, "50000000000000", "49982997688267243169385785"},
+		{"one unit of USDC", 1, 0, "1", "999813250715"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newThreePool(t).GetDy(tt.i, tt.j, bigInt(t, tt.dx))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestCurveExchange(t *testing.T) {
+	pool := newThreePool(t)
+
+	result, err := pool.Exchange(1, 2, big.NewInt(1_000_000_000_000))
+	require.NoError(t, err)
+	assert.Equal(t, "999676783018", result.AmountOut.String())
+	assert.Equal(t, "100000000000000000000000000", pool.Balances[0].String())
+	assert.Equal(t, "121000000000000", pool.Balances[1].String())
+	assert.Equal(t, "79000273228144", pool.Balances[2].String(), "admin fee leaves the pool")
+
+	result, err = pool.Exchange(0, 1, bigInt(t, "3000000000000000000000000"))
+	require.NoError(t, err)
+	assert.Equal(t, "2999932264993", result.AmountOut.String())
+	assert.Equal(t, "103000000000000000000000000", pool.Balances[0].String())
+	assert.Equal(t, "117999917723393", pool.Balances[1].String())
+
+	// Selling DAI makes it cheaper in USDC
+	assert.Less(t, result.PriceAfter, result.PriceBefore)
+	assert.InDelta(t, 1e-12, result.PriceBefore, 1e-14)
+
+	_, err = pool.Exchange(1, 1, big.NewInt(1))
+	assert.Error(t, err)
+	_, err = pool.Exchange(0, 3, big.NewInt(1))
+	assert.Error(t, err)
+}
+
+func TestCurveAmplificationPrecision(t *testing.T) {
+	pool, err := NewCurvePool([]*big.Int{
+		bigInt(t, "5000000000000000000000"),
+		bigInt(t, "4000000000000000000000"),
+	}, []int{18, 18}, 20000, 4_000_000, 5_000_000_000)
+	require.NoError(t, err)
+	pool.APrecision = big.NewInt(100)
+
+	dy, err := pool.GetDy(0, 1, bigInt(t, "100000000000000000000"))
+	require.NoError(t, err)
+	assert.Equal(t, "99834807568030471840", dy.String())
+
+	dy, err = pool.GetDy(1, 0, bigInt(t, "3999000000000000000000"))
+	require.NoError(t, err)
+	assert.Equal(t, "3965075048330923215072", dy.String())
+}
+
+func TestCurvePair(t *testing.T) {
+	pool := newThreePool(t)
+	pair := &CurvePair{Pool: pool, I: 2, J: 1}
+
+	// The pair sells USDT for USDC as zero-for-one and leaves the pool alone
+	// when cloned
+	clone := pair.Clone().(*CurvePair)
+	result, err := clone.Swap(big.NewInt(1_000_000_000), true)
+	require.NoError(t, err)
+	expected, err := pool.Clone().(*CurvePool).Exchange(2, 1, big.NewInt(1_000_000_000))
+	require.NoError(t, err)
+	assert.Equal(t, expected.AmountOut.String(), result.AmountOut.String())
+	assert.Equal(t, "80000000000000", pool.Balances[2].String())
+
+	result, err = clone.Swap(big.NewInt(1_000_000_000), false)
+	require.NoError(t, err)
+	assert.Positive(t, result.AmountOut.Sign())
+	assert.Equal(t, pool.SpotPriceBetween(2, 1), pair.SpotPrice())
+}
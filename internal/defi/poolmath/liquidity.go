@@ -0,0 +1,57 @@
+package poolmath
+
+import "math/big"
+
+// LiquidityAmounts
+
+// GetLiquidityForAmounts returns the most liquidity amount0 and amount1 can
+// fund over the sqrt price range [sqrtA, sqrtB) at sqrtPriceX96, as
+// LiquidityAmounts.getLiquidityForAmounts
+func GetLiquidityForAmounts(sqrtPriceX96, sqrtA, sqrtB, amount0, amount1 *big.Int) *big.Int {
+	if sqrtA.Cmp(sqrtB) > 0 {
+		sqrtA, sqrtB = sqrtB, sqrtA
+	}
+
+	switch {
+	case sqrtPriceX96.Cmp(sqrtA) <= 0:
+		return liquidityForAmount0(sqrtA, sqrtB, amount0)
+	case sqrtPriceX96.Cmp(sqrtB) < 0:
+		liquidity0 := liquidityForAmount0(sqrtPriceX96, sqrtB, amount0)
+		liquidity1 := liquidityForAmount1(sqrtA, sqrtPriceX96, amount1)
+		if liquidity0.Cmp(liquidity1) < 0 {
+			return liquidity0
+		}
+		return liquidity1
+	default:
+		return liquidityForAmount1(sqrtA, sqrtB, amount1)
+	}
+}
+
+// GetAmountsForLiquidity returns the token amounts a liquidity over the sqrt
+// price range [sqrtA, sqrtB) is worth at sqrtPriceX96, rounded down as
+// LiquidityAmounts.getAmountsForLiquidity
+func GetAmountsForLiquidity(sqrtPriceX96, sqrtA, sqrtB, liquidity *big.Int) (amount0, amount1 *big.Int) {
+	if sqrtA.Cmp(sqrtB) > 0 {
+		sqrtA, sqrtB = sqrtB, sqrtA
+	}
+
+	switch {
+	case sqrtPriceX96.Cmp(sqrtA) <= 0:
+		return GetAmount0Delta(sqrtA, sqrtB, liquidity, false), big.NewInt(0)
+	case sqrtPriceX96.Cmp(sqrtB) < 0:
+		return GetAmount0Delta(sqrtPriceX96, sqrtB, liquidity, false), GetAmount1Delta(sqrtA, sqrtPriceX96, liquidity, false)
+	default:
+		return big.NewInt(0), GetAmount1Delta(sqrtA, sqrtB, liquidity, false)
+	}
+}
+
+// liquidityForAmount0 is LiquidityAmounts.getLiquidityForAmount0
+func liquidityForAmount0(sqrtA, sqrtB, amount0 *big.Int) *big.Int {
+	intermediate := mulDiv(sqrtA, sqrtB, Q96)
+	return mulDiv(amount0, intermediate, new(big.Int).Sub(sqrtB, sqrtA))
+}
+
+// liquidityForAmount1 is LiquidityAmounts.getLiquidityForAmount1
+func liquidityForAmount1(sqrtA, sqrtB, amount1 *big.Int) *big.Int {
+	return mulDiv(amount1, Q96, new(big.Int).Sub(sqrtB, sqrtA))
+}
@@ -0,0 +1,47 @@
+package poolmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiquidityAmountsRoundTrip(t *testing.T) {
+	sqrtPrice, err := GetSqrtRatioAtTick(195000)
+	require.NoError(t, err)
+	sqrtPrice.Add(sqrtPrice, big.NewInt(123456789))
+	sqrtLower, _ := GetSqrtRatioAtTick(194400)
+	sqrtUpper, _ := GetSqrtRatioAtTick(195600)
+	liquidity := new(big.Int).Mul(big.NewInt(20), big.NewInt(e18))
+
+	// The amounts the scenario pool's mint took for this position, rounded up
+	amount0, _ := new(big.Int).SetString("34472456735983", 10)
+	amount1, _ := new(big.Int).SetString("10134240895117484400392", 10)
+
+	funded := GetLiquidityForAmounts(sqrtPrice, sqrtLower, sqrtUpper, amount0, amount1)
+	assert.LessOrEqual(t, new(big.Int).Sub(funded, liquidity).CmpAbs(big.NewInt(1e9)), 0,
+		"funded %s, want about %s", funded, liquidity)
+
+	// Valued back down, the position is worth at most what was paid
+	value0, value1 := GetAmountsForLiquidity(sqrtPrice, sqrtLower, sqrtUpper, liquidity)
+	assert.Equal(t, new(big.Int).Sub(amount0, big.NewInt(1)).String(), value0.String())
+	assert.Equal(t, new(big.Int).Sub(amount1, big.NewInt(1)).String(), value1.String())
+
+	// Token1 is the scarcer side here, so extra token0 buys no more liquidity
+	extra := new(big.Int).Mul(amount0, big.NewInt(2))
+	assert.Equal(t, funded.String(), GetLiquidityForAmounts(sqrtPrice, sqrtLower, sqrtUpper, extra, amount1).String())
+
+	// Out of range, a position holds only one token
+	below, _ := GetSqrtRatioAtTick(194000)
+	value0, value1 = GetAmountsForLiquidity(below, sqrtLower, sqrtUpper, liquidity)
+	assert.Positive(t, value0.Sign())
+	assert.Zero(t, value1.Sign())
+	assert.LessOrEqual(t, GetLiquidityForAmounts(below, sqrtLower, sqrtUpper, value0, big.NewInt(0)).Cmp(liquidity), 0)
+
+	above, _ := GetSqrtRatioAtTick(196000)
+	value0, value1 = GetAmountsForLiquidity(above, sqrtUpper, sqrtLower, liquidity)
+	assert.Zero(t, value0.Sign())
+	assert.Equal(t, GetAmount1Delta(sqrtLower, sqrtUpper, liquidity, false).String(), value1.String())
+}
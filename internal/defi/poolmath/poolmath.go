@@ -0,0 +1,142 @@
+// Package poolmath simulates AMM pools off-chain. Uniswap V2, Uniswap V3 and
+// Curve StableSwap pools are ported from their contracts with the same
+// integer rounding, so simulated swaps match on-chain results to the wei.
+package poolmath
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+var (
+	// ErrInvalidAmount is returned for zero or negative swap amounts
+	ErrInvalidAmount = errors.New("swap amount must be positive")
+
+	// ErrInsufficientLiquidity is returned when a pool cannot fill a swap
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+)
+
+// Pool is a two-token pool whose swaps can be simulated. Prices are in raw
+// token units: how much token1 one base unit of token0 is worth.
+type Pool interface {
+	// Swap sells amountIn of token0 (zeroForOne) or token1 and applies the
+	// trade to the pool's state
+	Swap(amountIn *big.Int, zeroForOne bool) (*SwapResult, error)
+
+	// SpotPrice returns the marginal price of token0 in token1, before fees
+	SpotPrice() float64
+
+	// Clone returns an independent copy of the pool's state
+	Clone() Pool
+}
+
+// SwapResult describes a simulated swap
+type SwapResult struct {
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	Fee       *big.Int // fees paid, in the input token except for Curve's output fee
+
+	PriceBefore float64
+	PriceAfter  float64
+}
+
+// ExecutionPrice returns the average price of the swap in output per input
+func (r *SwapResult) ExecutionPrice() float64 {
+	return ratio(r.AmountOut, r.AmountIn)
+}
+
+// PriceImpact returns how far the swap moved the pool's spot price, as a
+// fraction of the price before
+func (r *SwapResult) PriceImpact() float64 {
+	if r.PriceBefore == 0 {
+		return 0
+	}
+	return math.Abs(r.PriceAfter-r.PriceBefore) / r.PriceBefore
+}
+
+// Quote simulates a swap without changing the pool
+func Quote(pool Pool, amountIn *big.Int, zeroForOne bool) (*SwapResult, error) {
+	return pool.Clone().Swap(amountIn, zeroForOne)
+}
+
+// Depth returns the largest input of token0 (zeroForOne) or token1 that moves
+// the pool's spot price by at most maxImpact, to within one part in 2^20 of
+// the input
+func Depth(pool Pool, maxImpact float64, zeroForOne bool) *big.Int {
+	if maxImpact <= 0 {
+		return big.NewInt(0)
+	}
+
+	// Double the input until it exceeds the impact, then bisect. Amounts too
+	// small to produce any output are skipped rather than ending the search.
+	low, high := big.NewInt(0), big.NewInt(1)
+	for {
+		result, err := Quote(pool, high, zeroForOne)
+		if (err == nil && result.PriceImpact() > maxImpact) || (err != nil && low.Sign() > 0) {
+			break
+		}
+		if err == nil {
+			low.Set(high)
+		}
+		if high.BitLen() > 255 {
+			return low
+		}
+		high.Lsh(high, 1)
+	}
+
+	tolerance := new(big.Int).Rsh(high, 20)
+	if tolerance.Sign() == 0 {
+		tolerance.SetInt64(1)
+	}
+	for new(big.Int).Sub(high, low).Cmp(tolerance) > 0 {
+		mid := new(big.Int).Add(low, high)
+		mid.Rsh(mid, 1)
+		result, err := Quote(pool, mid, zeroForOne)
+		if err != nil || result.PriceImpact() > maxImpact {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return low
+}
+
+// Integer helpers with Solidity rounding. Inputs are non-negative.
+
+// mulDiv returns floor(a*b/d)
+func mulDiv(a, b, d *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	return product.Quo(product, d)
+}
+
+// mulDivRoundingUp returns ceil(a*b/d)
+func mulDivRoundingUp(a, b, d *big.Int) *big.Int {
+	return divRoundingUp(new(big.Int).Mul(a, b), d)
+}
+
+// divRoundingUp returns ceil(a/d)
+func divRoundingUp(a, d *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(a, d, new(big.Int))
+	if remainder.Sign() != 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// ratio returns a/b as a float64
+func ratio(a, b *big.Int) float64 {
+	if b.Sign() == 0 {
+		return 0
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(a), new(big.Float).SetInt(b)).Float64()
+	return f
+}
+
+// positive rejects nil, zero and negative amounts
+func positive(amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
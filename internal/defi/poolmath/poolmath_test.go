@@ -0,0 +1,67 @@
+package poolmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteLeavesPoolUnchanged(t *testing.T) {
+	pools := map[string]Pool{
+		"v2":    newWETHUSDCPool(t),
+		"v3":    newScenarioPool(t),
+		"curve": newThreePool(t),
+	}
+	amounts := map[string]*big.Int{
+		"v2":    big.NewInt(1e18),
+		"v3":    big.NewInt(1e12),
+		"curve": big.NewInt(1e18),
+	}
+	for name, pool := range pools {
+		t.Run(name, func(t *testing.T) {
+			before := pool.SpotPrice()
+			quote, err := Quote(pool, amounts[name], true)
+			require.NoError(t, err)
+			assert.Equal(t, before, pool.SpotPrice())
+
+			swapped, err := pool.Swap(amounts[name], true)
+			require.NoError(t, err)
+			assert.Equal(t, quote.AmountOut.String(), swapped.AmountOut.String())
+			assert.NotEqual(t, before, pool.SpotPrice())
+		})
+	}
+}
+
+func TestDepth(t *testing.T) {
+	pools := map[string]Pool{
+		"v2":    newWETHUSDCPool(t),
+		"v3":    newScenarioPool(t),
+		"curve": newThreePool(t),
+	}
+	for name, pool := range pools {
+		t.Run(name, func(t *testing.T) {
+			shallow := Depth(pool, 0.001, false)
+			deep := Depth(pool, 0.01, false)
+			require.Positive(t, shallow.Sign())
+			assert.Greater(t, deep.Cmp(shallow), 0)
+
+			result, err := Quote(pool, deep, false)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, result.PriceImpact(), 0.01)
+
+			// Just past the depth the impact exceeds the limit
+			past := new(big.Int).Add(deep, new(big.Int).Rsh(deep, 10))
+			result, err = Quote(pool, past, false)
+			require.NoError(t, err)
+			assert.Greater(t, result.PriceImpact(), 0.01)
+		})
+	}
+
+	// A 1% move in a V2 pool takes about 0.5% of the reserve
+	v2 := newWETHUSDCPool(t)
+	depth := Depth(v2, 0.01, true)
+	assert.InDelta(t, 0.005, ratio(depth, v2.Reserve0), 0.0002)
+	assert.Zero(t, Depth(v2, 0, true).Sign())
+}
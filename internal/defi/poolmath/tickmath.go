@@ -0,0 +1,242 @@
+package poolmath
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Tick range and the matching sqrt price bounds from Uniswap V3 TickMath
+const (
+	MinTick = -887272
+	MaxTick = 887272
+)
+
+var (
+	MinSqrtRatio, _ = new(big.Int).SetString("4295128739", 10)
+	MaxSqrtRatio, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+
+	// Q96 is 2^96, the fixed point scale of sqrtPriceX96
+	Q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+	q128       = new(big.Int).Lsh(big.NewInt(1), 128)
+	maxUint160 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	feeUnits   = big.NewInt(1_000_000)
+)
+
+// tickRatios are 2^128 / sqrt(1.0001)^(2^i), the factors TickMath multiplies
+// for each set bit of the absolute tick
+var tickRatios = func() []*big.Int {
+	hexes := []string{
+		"fffcb933bd6fad37aa2d162d1a594001",
+		"fff97272373d413259a46990580e213a",
+		"fff2e50f5f656932ef12357cf3c7fdcc",
+		"ffe5caca7e10e4e61c3624eaa0941cd0",
+		"ffcb9843d60f6159c9db58835c926644",
+		"ff973b41fa98c081472e6896dfb254c0",
+		"ff2ea16466c96a3843ec78b326b52861",
+		"fe5dee046a99a2a811c461f1969c3053",
+		"fcbe86c7900a88aedcffc83b479aa3a4",
+		"f987a7253ac413176f2b074cf7815e54",
+		"f3392b0822b70005940c7a398e4b70f3",
+		"e7159475a2c29b7443b29c7fa6e889d9",
+		"d097f3bdfd2022b8845ad8f792aa5825",
+		"a9f746462d870fdf8a65dc1f90e061e5",
+		"70d869a156d2a1b890bb3df62baf32f7",
+		"31be135f97d08fd981231505542fcfa6",
+		"9aa508b5b7a84e1c677de54f3e99bc9",
+		"5d6af8dedb81196699c329225ee604",
+		"2216e584f5fa1ea926041bedfe98",
+		"48a170391f7dc42444e8fa2",
+	}
+	ratios := make([]*big.Int, len(hexes))
+	for i, h := range hexes {
+		ratios[i], _ = new(big.Int).SetString(h, 16)
+	}
+	return ratios
+}()
+
+// GetSqrtRatioAtTick returns sqrt(1.0001^tick) * 2^96, as TickMath.getSqrtRatioAtTick
+func GetSqrtRatioAtTick(tick int) (*big.Int, error) {
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+	if absTick > MaxTick {
+		return nil, fmt.Errorf("tick %d out of range", tick)
+	}
+
+	ratio := new(big.Int).Set(q128)
+	if absTick&1 != 0 {
+		ratio.Set(tickRatios[0])
+	}
+	for i := 1; i < len(tickRatios); i++ {
+		if absTick&(1<<i) != 0 {
+			ratio.Mul(ratio, tickRatios[i]).Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Quo(maxUint256, ratio)
+	}
+
+	// Round up when dividing by 2^32 so the result is never below the true price
+	sqrtPrice := new(big.Int).Rsh(ratio, 32)
+	if new(big.Int).And(ratio, big.NewInt(0xffffffff)).Sign() != 0 {
+		sqrtPrice.Add(sqrtPrice, big.NewInt(1))
+	}
+	return sqrtPrice, nil
+}
+
+// GetTickAtSqrtRatio returns the greatest tick whose sqrt ratio is at most
+// sqrtPriceX96, as TickMath.getTickAtSqrtRatio
+func GetTickAtSqrtRatio(sqrtPriceX96 *big.Int) (int, error) {
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return 0, fmt.Errorf("sqrt price %s out of range", sqrtPriceX96)
+	}
+
+	low, high := MinTick, MaxTick
+	for low < high {
+		mid := low + (high-low+1)/2
+		ratio, _ := GetSqrtRatioAtTick(mid)
+		if ratio.Cmp(sqrtPriceX96) <= 0 {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
+
+// SqrtPriceMath
+
+// GetAmount0Delta returns the token0 between two sqrt prices for a
+// liquidity, as SqrtPriceMath.getAmount0Delta
+func GetAmount0Delta(sqrtA, sqrtB, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtA.Cmp(sqrtB) > 0 {
+		sqrtA, sqrtB = sqrtB, sqrtA
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtB, sqrtA)
+
+	if roundUp {
+		return divRoundingUp(mulDivRoundingUp(numerator1, numerator2, sqrtB), sqrtA)
+	}
+	amount := mulDiv(numerator1, numerator2, sqrtB)
+	return amount.Quo(amount, sqrtA)
+}
+
+// GetAmount1Delta returns the token1 between two sqrt prices for a
+// liquidity, as SqrtPriceMath.getAmount1Delta
+func GetAmount1Delta(sqrtA, sqrtB, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtA.Cmp(sqrtB) > 0 {
+		sqrtA, sqrtB = sqrtB, sqrtA
+	}
+	diff := new(big.Int).Sub(sqrtB, sqrtA)
+
+	if roundUp {
+		return mulDivRoundingUp(liquidity, diff, Q96)
+	}
+	return mulDiv(liquidity, diff, Q96)
+}
+
+// GetNextSqrtPriceFromInput returns the sqrt price after adding amountIn of
+// token0 (zeroForOne) or token1, as SqrtPriceMath.getNextSqrtPriceFromInput
+func GetNextSqrtPriceFromInput(sqrtPriceX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPriceX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+	if zeroForOne {
+		return nextSqrtPriceFromAmount0RoundingUp(sqrtPriceX96, liquidity, amountIn), nil
+	}
+	return nextSqrtPriceFromAmount1RoundingDown(sqrtPriceX96, liquidity, amountIn)
+}
+
+// nextSqrtPriceFromAmount0RoundingUp adds token0. The contract falls back
+// to a less precise formula when the exact one would overflow 256 bits, and
+// so does this port.
+func nextSqrtPriceFromAmount0RoundingUp(sqrtPriceX96, liquidity, amount *big.Int) *big.Int {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPriceX96)
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+
+	product := new(big.Int).Mul(amount, sqrtPriceX96)
+	if product.Cmp(maxUint256) <= 0 {
+		denominator := new(big.Int).Add(numerator1, product)
+		if denominator.Cmp(maxUint256) <= 0 {
+			return mulDivRoundingUp(numerator1, sqrtPriceX96, denominator)
+		}
+	}
+
+	denominator := new(big.Int).Quo(numerator1, sqrtPriceX96)
+	return divRoundingUp(numerator1, denominator.Add(denominator, amount))
+}
+
+// nextSqrtPriceFromAmount1RoundingDown adds token1
+func nextSqrtPriceFromAmount1RoundingDown(sqrtPriceX96, liquidity, amount *big.Int) (*big.Int, error) {
+	quotient := new(big.Int).Lsh(amount, 96)
+	quotient.Quo(quotient, liquidity)
+
+	next := quotient.Add(quotient, sqrtPriceX96)
+	if next.Cmp(maxUint160) > 0 {
+		return nil, fmt.Errorf("%w: sqrt price overflows", ErrInsufficientLiquidity)
+	}
+	return next, nil
+}
+
+// SwapMath
+
+// swapStep is the outcome of swapping within one tick range
+type swapStep struct {
+	sqrtPriceNext *big.Int
+	amountIn      *big.Int
+	amountOut     *big.Int
+	feeAmount     *big.Int
+}
+
+// computeSwapStep swaps up to amountRemaining (input including fees) from
+// sqrtPriceCurrent towards sqrtPriceTarget, as SwapMath.computeSwapStep for
+// exact input
+func computeSwapStep(sqrtPriceCurrent, sqrtPriceTarget, liquidity, amountRemaining *big.Int, fee int64) (swapStep, error) {
+	zeroForOne := sqrtPriceCurrent.Cmp(sqrtPriceTarget) >= 0
+	feeComplement := big.NewInt(1_000_000 - fee)
+
+	amountRemainingLessFee := mulDiv(amountRemaining, feeComplement, feeUnits)
+	var amountIn *big.Int
+	if zeroForOne {
+		amountIn = GetAmount0Delta(sqrtPriceTarget, sqrtPriceCurrent, liquidity, true)
+	} else {
+		amountIn = GetAmount1Delta(sqrtPriceCurrent, sqrtPriceTarget, liquidity, true)
+	}
+
+	step := swapStep{sqrtPriceNext: sqrtPriceTarget}
+	if amountRemainingLessFee.Cmp(amountIn) < 0 {
+		next, err := GetNextSqrtPriceFromInput(sqrtPriceCurrent, liquidity, amountRemainingLessFee, zeroForOne)
+		if err != nil {
+			return swapStep{}, err
+		}
+		step.sqrtPriceNext = next
+	}
+	reachedTarget := step.sqrtPriceNext.Cmp(sqrtPriceTarget) == 0
+
+	if zeroForOne {
+		if !reachedTarget {
+			amountIn = GetAmount0Delta(step.sqrtPriceNext, sqrtPriceCurrent, liquidity, true)
+		}
+		step.amountOut = GetAmount1Delta(step.sqrtPriceNext, sqrtPriceCurrent, liquidity, false)
+	} else {
+		if !reachedTarget {
+			amountIn = GetAmount1Delta(sqrtPriceCurrent, step.sqrtPriceNext, liquidity, true)
+		}
+		step.amountOut = GetAmount0Delta(sqrtPriceCurrent, step.sqrtPriceNext, liquidity, false)
+	}
+	step.amountIn = amountIn
+
+	// Whatever input is left when the price stops short of the target is fee
+	if reachedTarget {
+		step.feeAmount = mulDivRoundingUp(amountIn, big.NewInt(fee), feeComplement)
+	} else {
+		step.feeAmount = new(big.Int).Sub(amountRemaining, amountIn)
+	}
+	return step, nil
+}
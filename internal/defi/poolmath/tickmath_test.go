@@ -0,0 +1,108 @@
+package poolmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bigInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(s, 10)
+	require.True(t, ok, "invalid integer %q", s)
+	return n
+}
+
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	tests := []struct {
+		tick int
+		want string
+	}{
+		{MinTick, "4295128739"},
+		{-200000, "3598751819609688046946419"},
+		{-1000, "75364347830767020784054125655"},
+		{-50, "79030349367926598376800521322"},
+		{-1, "79224201403219477170569942574"},
+		{0, "79228162514264337593543950336"},
+		{1, "79232123823359799118286999568"},
+		{50, "79426470787362580746886972461"},
+		{100, "79625275426524748796330556128"},
+		{1000, "83290069058676223003182343270"},
+		{195000, "1358435673239453248152483143175383"},
+		{887271, "1461373636630004318706518188784493106690254656249"},
+		{MaxTick, "1461446703485210103287273052203988822378723970342"},
+	}
+	for _, tt := range tests {
+		got, err := GetSqrtRatioAtTick(tt.tick)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got.String(), "tick %d", tt.tick)
+	}
+
+	_, err := GetSqrtRatioAtTick(MaxTick + 1)
+	assert.Error(t, err)
+	_, err = GetSqrtRatioAtTick(MinTick - 1)
+	assert.Error(t, err)
+}
+
+func TestGetTickAtSqrtRatio(t *testing.T) {
+	tests := []struct {
+		sqrtPrice string
+		want      int
+	}{
+		{"4295128739", MinTick},
+		{"79228162514264337593543950335", -1},
+		{"79228162514264337593543950336", 0},
+		{"1358435673239453248152483266632172", 195000},
+		{"1461446703485210103287273052203988822378723970341", MaxTick - 1},
+	}
+	for _, tt := range tests {
+		got, err := GetTickAtSqrtRatio(bigInt(t, tt.sqrtPrice))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "sqrt price %s", tt.sqrtPrice)
+	}
+
+	_, err := GetTickAtSqrtRatio(MaxSqrtRatio)
+	assert.Error(t, err)
+	_, err = GetTickAtSqrtRatio(new(big.Int).Sub(MinSqrtRatio, big.NewInt(1)))
+	assert.Error(t, err)
+}
+
+// Expected values are from the Uniswap V3 core SqrtPriceMath and SwapMath
+// test suites
+func TestSqrtPriceMath(t *testing.T) {
+	liquidity := big.NewInt(1e18)
+	amount := big.NewInt(1e17)
+
+	next, err := GetNextSqrtPriceFromInput(Q96, liquidity, amount, false)
+	require.NoError(t, err)
+	assert.Equal(t, "87150978765690771352898345369", next.String())
+
+	next, err = GetNextSqrtPriceFromInput(Q96, liquidity, amount, true)
+	require.NoError(t, err)
+	assert.Equal(t, "72025602285694852357767227579", next.String())
+
+	// Inputs too large for the exact formula take the overflow-safe path
+	huge := new(big.Int).Lsh(big.NewInt(1), 200)
+	next, err = GetNextSqrtPriceFromInput(Q96, liquidity, huge, true)
+	require.NoError(t, err)
+	assert.Equal(t, "1", next.String())
+
+	upper := bigInt(t, "87150978765690771352898345369")
+	assert.Equal(t, "90909090909090910", GetAmount0Delta(Q96, upper, liquidity, true).String())
+	assert.Equal(t, "90909090909090909", GetAmount0Delta(upper, Q96, liquidity, false).String())
+	assert.Equal(t, "100000000000000000", GetAmount1Delta(Q96, upper, liquidity, true).String())
+	assert.Equal(t, "99999999999999999", GetAmount1Delta(Q96, upper, liquidity, false).String())
+}
+
+func TestComputeSwapStep(t *testing.T) {
+	// Exact input capped at the price target, one for zero
+	target := bigInt(t, "79623317895830914510639640423") // sqrt(1.01)
+	step, err := computeSwapStep(Q96, target, big.NewInt(2e18), big.NewInt(1e18), 600)
+	require.NoError(t, err)
+	assert.Equal(t, target.String(), step.sqrtPriceNext.String())
+	assert.Equal(t, "9975124224178055", step.amountIn.String())
+	assert.Equal(t, "9925619580021728", step.amountOut.String())
+	assert.Equal(t, "5988667735148", step.feeAmount.String())
+}
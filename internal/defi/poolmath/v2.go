@@ -0,0 +1,111 @@
+package poolmath
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Uniswap V2 style fees in basis points
+const (
+	UniswapV2FeeBps = 30
+	SushiSwapFeeBps = 30
+)
+
+var bpsDenominator = big.NewInt(10_000)
+
+// V2Pool is a constant-product (x*y=k) pool as in UniswapV2Pair
+type V2Pool struct {
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+	FeeBps   int64 // swap fee in basis points, 30 for Uniswap V2
+}
+
+// NewV2Pool creates a constant-product pool with the Uniswap V2 fee
+func NewV2Pool(reserve0, reserve1 *big.Int) *V2Pool {
+	return &V2Pool{
+		Reserve0: new(big.Int).Set(reserve0),
+		Reserve1: new(big.Int).Set(reserve1),
+		FeeBps:   UniswapV2FeeBps,
+	}
+}
+
+// reserves returns the input and output reserves for a swap direction
+func (p *V2Pool) reserves(zeroForOne bool) (reserveIn, reserveOut *big.Int) {
+	if zeroForOne {
+		return p.Reserve0, p.Reserve1
+	}
+	return p.Reserve1, p.Reserve0
+}
+
+// GetAmountOut returns the output for amountIn, as UniswapV2Library.getAmountOut
+func (p *V2Pool) GetAmountOut(amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if err := positive(amountIn); err != nil {
+		return nil, err
+	}
+	reserveIn, reserveOut := p.reserves(zeroForOne)
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(10_000-p.FeeBps))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, bpsDenominator)
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Quo(numerator, denominator), nil
+}
+
+// GetAmountIn returns the input needed for amountOut, as
+// UniswapV2Library.getAmountIn
+func (p *V2Pool) GetAmountIn(amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if err := positive(amountOut); err != nil {
+		return nil, err
+	}
+	reserveIn, reserveOut := p.reserves(zeroForOne)
+	if reserveIn.Sign() <= 0 || amountOut.Cmp(reserveOut) >= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	numerator := new(big.Int).Mul(reserveIn, amountOut)
+	numerator.Mul(numerator, bpsDenominator)
+	denominator := new(big.Int).Sub(reserveOut, amountOut)
+	denominator.Mul(denominator, big.NewInt(10_000-p.FeeBps))
+	amountIn := numerator.Quo(numerator, denominator)
+	return amountIn.Add(amountIn, big.NewInt(1)), nil
+}
+
+// Swap sells amountIn and updates the reserves
+func (p *V2Pool) Swap(amountIn *big.Int, zeroForOne bool) (*SwapResult, error) {
+	amountOut, err := p.GetAmountOut(amountIn, zeroForOne)
+	if err != nil {
+		return nil, err
+	}
+	reserveIn, reserveOut := p.reserves(zeroForOne)
+	if amountOut.Sign() == 0 {
+		return nil, fmt.Errorf("%w: output rounds to zero", ErrInsufficientLiquidity)
+	}
+
+	result := &SwapResult{
+		AmountIn:    new(big.Int).Set(amountIn),
+		AmountOut:   amountOut,
+		Fee:         mulDiv(amountIn, big.NewInt(p.FeeBps), bpsDenominator),
+		PriceBefore: p.SpotPrice(),
+	}
+	reserveIn.Add(reserveIn, amountIn)
+	reserveOut.Sub(reserveOut, amountOut)
+	result.PriceAfter = p.SpotPrice()
+	return result, nil
+}
+
+// SpotPrice returns reserve1 / reserve0
+func (p *V2Pool) SpotPrice() float64 {
+	return ratio(p.Reserve1, p.Reserve0)
+}
+
+// Clone returns a copy of the pool
+func (p *V2Pool) Clone() Pool {
+	return &V2Pool{
+		Reserve0: new(big.Int).Set(p.Reserve0),
+		Reserve1: new(big.Int).Set(p.Reserve1),
+		FeeBps:   p.FeeBps,
+	}
+}
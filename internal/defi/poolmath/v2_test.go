@@ -0,0 +1,75 @@
+package poolmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 12,345 WETH against 24.68M USDC
+func newWETHUSDCPool(t *testing.T) *V2Pool {
+	return NewV2Pool(bigInt(t, "12345000000000000000000"), bigInt(t, "24680000000000"))
+}
+
+func TestV2GetAmountOut(t *testing.T) {
+	tests := []struct {
+		name       string
+		amountIn   string
+		zeroForOne bool
+		want       string
+	}{
+		{"one WETH", "1000000000000000000", true, "1993031425"},
+		{"thousand WETH", "1000000000000000000000", true, "1844248238644"},
+		{"ten million USDC", "10000000000000", false, "3552082251082251082251"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newWETHUSDCPool(t).GetAmountOut(bigInt(t, tt.amountIn), tt.zeroForOne)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestV2GetAmountIn(t *testing.T) {
+	pool := newWETHUSDCPool(t)
+
+	amountIn, err := pool.GetAmountIn(big.NewInt(1_000_000_000), true)
+	require.NoError(t, err)
+	assert.Equal(t, "501728045679239591", amountIn.String())
+
+	amountIn, err = pool.GetAmountIn(big.NewInt(1e18), false)
+	require.NoError(t, err)
+	assert.Equal(t, "2005368016", amountIn.String())
+
+	// Buying at least what GetAmountIn quotes
+	out, err := pool.GetAmountOut(amountIn, false)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, out.Cmp(big.NewInt(1e18)), 0)
+
+	_, err = pool.GetAmountIn(pool.Reserve1, true)
+	assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+}
+
+func TestV2Swap(t *testing.T) {
+	pool := newWETHUSDCPool(t)
+	k := new(big.Int).Mul(pool.Reserve0, pool.Reserve1)
+
+	result, err := pool.Swap(big.NewInt(1e18), true)
+	require.NoError(t, err)
+	assert.Equal(t, "1993031425", result.AmountOut.String())
+	assert.Equal(t, "3000000000000000", result.Fee.String())
+	assert.Equal(t, "12346000000000000000000", pool.Reserve0.String())
+	assert.Equal(t, "24678006968575", pool.Reserve1.String(), "reserves move by the swap amounts")
+
+	// Fees grow k and selling WETH lowers its price
+	assert.Positive(t, new(big.Int).Mul(pool.Reserve0, pool.Reserve1).Cmp(k))
+	assert.Less(t, result.PriceAfter, result.PriceBefore)
+	assert.InDelta(t, 2.0/12346, result.PriceImpact(), 1e-6)
+	assert.InDelta(t, 1993.031425e-12, result.ExecutionPrice(), 1e-18)
+
+	_, err = pool.Swap(big.NewInt(0), true)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
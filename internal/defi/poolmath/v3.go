@@ -0,0 +1,270 @@
+package poolmath
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// TickSpacingForFee returns the tick spacing Uniswap V3 enables for a fee
+// tier, or 0 for an unknown tier
+func TickSpacingForFee(fee int64) int {
+	switch fee {
+	case 100:
+		return 1
+	case 500:
+		return 10
+	case 3000:
+		return 60
+	case 10000:
+		return 200
+	default:
+		return 0
+	}
+}
+
+// V3Pool is the swap state of a Uniswap V3 pool: the current price and
+// in-range liquidity, and the net liquidity change at each initialized tick
+type V3Pool struct {
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Liquidity    *big.Int
+	Fee          int64 // in hundredths of a bip, 3000 for 0.3%
+	TickSpacing  int
+
+	ticks map[int]*big.Int // initialized tick -> liquidityNet
+}
+
+// NewV3Pool creates a pool without liquidity at a sqrt price
+func NewV3Pool(sqrtPriceX96 *big.Int, fee int64, tickSpacing int) (*V3Pool, error) {
+	tick, err := GetTickAtSqrtRatio(sqrtPriceX96)
+	if err != nil {
+		return nil, err
+	}
+	if tickSpacing <= 0 {
+		return nil, fmt.Errorf("invalid tick spacing %d", tickSpacing)
+	}
+	if fee < 0 || fee >= 1_000_000 {
+		return nil, fmt.Errorf("invalid fee %d", fee)
+	}
+
+	return &V3Pool{
+		SqrtPriceX96: new(big.Int).Set(sqrtPriceX96),
+		Tick:         tick,
+		Liquidity:    big.NewInt(0),
+		Fee:          fee,
+		TickSpacing:  tickSpacing,
+		ticks:        make(map[int]*big.Int),
+	}, nil
+}
+
+// SetTick records an initialized tick's liquidityNet, as read from the
+// pool's ticks(tick). Liquidity and the current tick are not changed.
+func (p *V3Pool) SetTick(tick int, liquidityNet *big.Int) {
+	if p.ticks == nil {
+		p.ticks = make(map[int]*big.Int)
+	}
+	p.ticks[tick] = new(big.Int).Set(liquidityNet)
+}
+
+// LiquidityNet returns the net liquidity at a tick and whether it is
+// initialized
+func (p *V3Pool) LiquidityNet(tick int) (*big.Int, bool) {
+	net, initialized := p.ticks[tick]
+	if !initialized {
+		return big.NewInt(0), false
+	}
+	return new(big.Int).Set(net), true
+}
+
+// AddLiquidity adds a position over [tickLower, tickUpper) and returns the
+// token amounts it takes, rounded up as the pool's mint does
+func (p *V3Pool) AddLiquidity(tickLower, tickUpper int, liquidity *big.Int) (amount0, amount1 *big.Int, err error) {
+	if err := positive(liquidity); err != nil {
+		return nil, nil, err
+	}
+	if tickLower >= tickUpper || tickLower < MinTick || tickUpper > MaxTick {
+		return nil, nil, fmt.Errorf("invalid tick range [%d, %d)", tickLower, tickUpper)
+	}
+	if tickLower%p.TickSpacing != 0 || tickUpper%p.TickSpacing != 0 {
+		return nil, nil, fmt.Errorf("ticks %d and %d are not multiples of the tick spacing %d", tickLower, tickUpper, p.TickSpacing)
+	}
+
+	sqrtLower, _ := GetSqrtRatioAtTick(tickLower)
+	sqrtUpper, _ := GetSqrtRatioAtTick(tickUpper)
+	amount0, amount1 = big.NewInt(0), big.NewInt(0)
+	switch {
+	case p.Tick < tickLower:
+		amount0 = GetAmount0Delta(sqrtLower, sqrtUpper, liquidity, true)
+	case p.Tick < tickUpper:
+		amount0 = GetAmount0Delta(p.SqrtPriceX96, sqrtUpper, liquidity, true)
+		amount1 = GetAmount1Delta(sqrtLower, p.SqrtPriceX96, liquidity, true)
+		p.Liquidity.Add(p.Liquidity, liquidity)
+	default:
+		amount1 = GetAmount1Delta(sqrtLower, sqrtUpper, liquidity, true)
+	}
+
+	p.addLiquidityNet(tickLower, liquidity)
+	p.addLiquidityNet(tickUpper, new(big.Int).Neg(liquidity))
+	return amount0, amount1, nil
+}
+
+func (p *V3Pool) addLiquidityNet(tick int, delta *big.Int) {
+	if net, exists := p.ticks[tick]; exists {
+		net.Add(net, delta)
+		return
+	}
+	p.SetTick(tick, delta)
+}
+
+// Swap sells amountIn with no price limit. If the pool runs out of liquidity
+// the swap stops early and the result's AmountIn is what was used.
+func (p *V3Pool) Swap(amountIn *big.Int, zeroForOne bool) (*SwapResult, error) {
+	limit := new(big.Int).Add(MinSqrtRatio, big.NewInt(1))
+	if !zeroForOne {
+		limit.Sub(MaxSqrtRatio, big.NewInt(1))
+	}
+	return p.SwapToLimit(amountIn, zeroForOne, limit)
+}
+
+// SwapToLimit sells amountIn until it is used up or the price reaches
+// sqrtPriceLimitX96, as UniswapV3Pool.swap for exact input
+func (p *V3Pool) SwapToLimit(amountIn *big.Int, zeroForOne bool, sqrtPriceLimitX96 *big.Int) (*SwapResult, error) {
+	if err := positive(amountIn); err != nil {
+		return nil, err
+	}
+	if zeroForOne {
+		if sqrtPriceLimitX96.Cmp(p.SqrtPriceX96) >= 0 || sqrtPriceLimitX96.Cmp(MinSqrtRatio) <= 0 {
+			return nil, fmt.Errorf("invalid sqrt price limit %s", sqrtPriceLimitX96)
+		}
+	} else if sqrtPriceLimitX96.Cmp(p.SqrtPriceX96) <= 0 || sqrtPriceLimitX96.Cmp(MaxSqrtRatio) >= 0 {
+		return nil, fmt.Errorf("invalid sqrt price limit %s", sqrtPriceLimitX96)
+	}
+
+	initialized := p.sortedTicks()
+	result := &SwapResult{
+		AmountOut:   big.NewInt(0),
+		Fee:         big.NewInt(0),
+		PriceBefore: p.SpotPrice(),
+	}
+	remaining := new(big.Int).Set(amountIn)
+
+	for remaining.Sign() != 0 && p.SqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStart := p.SqrtPriceX96
+
+		tickNext, tickInitialized := p.nextInitializedTick(initialized, zeroForOne)
+		if tickNext < MinTick {
+			tickNext = MinTick
+		} else if tickNext > MaxTick {
+			tickNext = MaxTick
+		}
+		sqrtPriceNext, _ := GetSqrtRatioAtTick(tickNext)
+
+		target := sqrtPriceNext
+		if (zeroForOne && sqrtPriceNext.Cmp(sqrtPriceLimitX96) < 0) || (!zeroForOne && sqrtPriceNext.Cmp(sqrtPriceLimitX96) > 0) {
+			target = sqrtPriceLimitX96
+		}
+
+		step, err := computeSwapStep(p.SqrtPriceX96, target, p.Liquidity, remaining, p.Fee)
+		if err != nil {
+			return nil, err
+		}
+		p.SqrtPriceX96 = new(big.Int).Set(step.sqrtPriceNext)
+		remaining.Sub(remaining, step.amountIn).Sub(remaining, step.feeAmount)
+		result.AmountOut.Add(result.AmountOut, step.amountOut)
+		result.Fee.Add(result.Fee, step.feeAmount)
+
+		if p.SqrtPriceX96.Cmp(sqrtPriceNext) == 0 {
+			// Crossing an initialized tick moves its liquidity in or out of range
+			if tickInitialized {
+				net := p.ticks[tickNext]
+				if zeroForOne {
+					p.Liquidity.Sub(p.Liquidity, net)
+				} else {
+					p.Liquidity.Add(p.Liquidity, net)
+				}
+			}
+			if zeroForOne {
+				p.Tick = tickNext - 1
+			} else {
+				p.Tick = tickNext
+			}
+		} else if p.SqrtPriceX96.Cmp(sqrtPriceStart) != 0 {
+			p.Tick, err = GetTickAtSqrtRatio(p.SqrtPriceX96)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.AmountIn = new(big.Int).Sub(amountIn, remaining)
+	if result.AmountOut.Sign() == 0 {
+		return nil, fmt.Errorf("%w: output rounds to zero", ErrInsufficientLiquidity)
+	}
+	result.PriceAfter = p.SpotPrice()
+	return result, nil
+}
+
+// sortedTicks returns the initialized ticks in ascending order
+func (p *V3Pool) sortedTicks() []int {
+	ticks := make([]int, 0, len(p.ticks))
+	for tick := range p.ticks {
+		ticks = append(ticks, tick)
+	}
+	sort.Ints(ticks)
+	return ticks
+}
+
+// nextInitializedTick finds the next initialized tick at or below (lte) or
+// above the current tick, looking no further than the current 256-tick word
+// of the pool's tick bitmap, as TickBitmap.nextInitializedTickWithinOneWord.
+// Swaps step at word boundaries, so they must match for amounts to round
+// the same way as on-chain.
+func (p *V3Pool) nextInitializedTick(initialized []int, lte bool) (int, bool) {
+	spacing := p.TickSpacing
+	compressed := p.Tick / spacing
+	if p.Tick < 0 && p.Tick%spacing != 0 {
+		compressed-- // round towards negative infinity
+	}
+
+	if lte {
+		wordStart := (compressed - compressed&0xff) * spacing
+		// Greatest initialized tick <= compressed*spacing
+		i := sort.SearchInts(initialized, compressed*spacing+1) - 1
+		if i >= 0 && initialized[i] >= wordStart {
+			return initialized[i], true
+		}
+		return wordStart, false
+	}
+
+	compressed++
+	wordEnd := (compressed + 255 - compressed&0xff) * spacing
+	// Smallest initialized tick >= compressed*spacing
+	i := sort.SearchInts(initialized, compressed*spacing)
+	if i < len(initialized) && initialized[i] <= wordEnd {
+		return initialized[i], true
+	}
+	return wordEnd, false
+}
+
+// SpotPrice returns (sqrtPriceX96 / 2^96)^2
+func (p *V3Pool) SpotPrice() float64 {
+	sqrtPrice, _ := new(big.Float).Quo(new(big.Float).SetInt(p.SqrtPriceX96), new(big.Float).SetInt(Q96)).Float64()
+	return sqrtPrice * sqrtPrice
+}
+
+// Clone returns a copy of the pool
+func (p *V3Pool) Clone() Pool {
+	clone := &V3Pool{
+		SqrtPriceX96: new(big.Int).Set(p.SqrtPriceX96),
+		Tick:         p.Tick,
+		Liquidity:    new(big.Int).Set(p.Liquidity),
+		Fee:          p.Fee,
+		TickSpacing:  p.TickSpacing,
+		ticks:        make(map[int]*big.Int, len(p.ticks)),
+	}
+	for tick, net := range p.ticks {
+		clone.ticks[tick] = new(big.Int).Set(net)
+	}
+	return clone
+}
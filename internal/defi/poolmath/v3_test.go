@@ -0,0 +1,137 @@
+package poolmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const e18 = 1_000_000_000_000_000_000
+
+// newScenarioPool builds a 0.3% pool near tick 195000 with four positions,
+// two of them overlapping the current price
+func newScenarioPool(t *testing.T) *V3Pool {
+	t.Helper()
+	sqrtPrice, err := GetSqrtRatioAtTick(195000)
+	require.NoError(t, err)
+	sqrtPrice.Add(sqrtPrice, big.NewInt(123456789))
+
+	pool, err := NewV3Pool(sqrtPrice, 3000, TickSpacingForFee(3000))
+	require.NoError(t, err)
+	assert.Equal(t, 195000, pool.Tick)
+
+	positions := []struct {
+		lower, upper int
+		liquidity    int64
+		amount0      string
+		amount1      string
+	}{
+		{194400, 195600, 20, "34472456735983", "10134240895117484400392"},
+		{192000, 201000, 5, "75578167535494", "11940860373758227984537"},
+		{195600, 199980, 8, "89051100852772", "0"},
+		{180000, 194400, 3, "0", "25619154210528173009366"},
+	}
+	for _, position := range positions {
+		liquidity := new(big.Int).Mul(big.NewInt(position.liquidity), big.NewInt(e18))
+		amount0, amount1, err := pool.AddLiquidity(position.lower, position.upper, liquidity)
+		require.NoError(t, err)
+		assert.Equal(t, position.amount0, amount0.String())
+		assert.Equal(t, position.amount1, amount1.String())
+	}
+	assert.Equal(t, "25000000000000000000", pool.Liquidity.String())
+	return pool
+}
+
+func TestV3Swap(t *testing.T) {
+	tests := []struct {
+		name       string
+		amountIn   string
+		zeroForOne bool
+
+		used      string // input consumed, less than amountIn if liquidity runs out
+		amountOut string
+		sqrtPrice string
+		tick      int
+		liquidity int64 // in units of 1e18
+	}{
+		{"within range", "1000000000000", true,
+			"1000000000000", "292898594259563789771", "1357507440542405396490710459596123", 194986, 25},
+		{"crosses one tick", "50000000000000", true,
+			"50000000000000", "14159024233376421903205", "1303521450602222848473071857360317", 194174, 8},
+		{"crosses two ticks", "200000000000000", true,
+			"200000000000000", "41687962548960546982245", "800326155386838406371268972346039", 184418, 3},
+		{"crosses bitmap words", "250000000000000", true,
+			"250000000000000", "46043597909971613947465", "685296493308484662690374572265753", 181314, 3},
+		{"exhausts liquidity", "1000000000000000", true,
+			"273632408553588", "47694255479403885394291", "4295128740", MinTick, 0},
+		{"small buy", "500000000000000000000", false,
+			"500000000000000000000", "1693719157227", "1360015482799987679044098533001871", 195023, 25},
+		{"within range buy", "5000000000000000000000", false,
+			"5000000000000000000000", "16761953676049", "1374233768844797557068635930329170", 195231, 25},
+		{"crosses upper ticks", "50000000000000000000000", false,
+			"50000000000000000000000", "144691050328358", "1624058964942302613235571870011875", 198572, 13},
+		{"crosses word boundary", "70000000000000000000000", false,
+			"70000000000000000000000", "188839266666501", "1750513869818698066793386200660652", 200071, 5},
+		{"buys out the pool", "100000000000000000000000", false,
+			"75263608152576021197736", "199101725124244", "1461446703485210103287273052203988822378723970341", MaxTick - 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newScenarioPool(t)
+			result, err := pool.Swap(bigInt(t, tt.amountIn), tt.zeroForOne)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.used, result.AmountIn.String())
+			assert.Equal(t, tt.amountOut, result.AmountOut.String())
+			assert.Equal(t, tt.sqrtPrice, pool.SqrtPriceX96.String())
+			assert.Equal(t, tt.tick, pool.Tick)
+			assert.Equal(t, new(big.Int).Mul(big.NewInt(tt.liquidity), big.NewInt(e18)).String(), pool.Liquidity.String())
+			assert.Positive(t, result.Fee.Sign())
+			assert.Positive(t, result.PriceImpact())
+		})
+	}
+}
+
+func TestV3SwapToLimit(t *testing.T) {
+	pool := newScenarioPool(t)
+	limit, err := GetSqrtRatioAtTick(194700)
+	require.NoError(t, err)
+
+	result, err := pool.SwapToLimit(big.NewInt(1e15), true, limit)
+	require.NoError(t, err)
+	assert.Equal(t, "22101208330749", result.AmountIn.String())
+	assert.Equal(t, "6381401548038776152653", result.AmountOut.String())
+	assert.Equal(t, limit.String(), pool.SqrtPriceX96.String())
+	assert.Equal(t, 194700, pool.Tick)
+
+	// The price cannot move back through the limit in the same direction
+	_, err = pool.SwapToLimit(big.NewInt(1e12), true, limit)
+	assert.Error(t, err)
+}
+
+func TestV3SwapRejectsDust(t *testing.T) {
+	pool := newScenarioPool(t)
+
+	_, err := pool.Swap(big.NewInt(1), true)
+	assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	_, err = pool.Swap(big.NewInt(1_000_000), false)
+	assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	_, err = pool.Swap(big.NewInt(0), false)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestV3AddLiquidityValidatesTicks(t *testing.T) {
+	pool := newScenarioPool(t)
+	liquidity := big.NewInt(e18)
+
+	_, _, err := pool.AddLiquidity(195000, 194400, liquidity)
+	assert.Error(t, err)
+	_, _, err = pool.AddLiquidity(194401, 195600, liquidity)
+	assert.Error(t, err)
+
+	net, initialized := pool.LiquidityNet(195600)
+	assert.True(t, initialized)
+	assert.Equal(t, "-12000000000000000000", net.String())
+}
@@ -0,0 +1,312 @@
+package defi
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LendingProtocol is a lending market acting for the manager's account.
+// Rates are annual percentage yields as fractions, so venues can be ranked
+// against each other. AaveManager and CompoundV3Manager implement it.
+type LendingProtocol interface {
+	Name() string
+	Supply(asset common.Address, amount *big.Int) (*types.Transaction, error)
+	Withdraw(asset common.Address, amount *big.Int) (*types.Transaction, error)
+	Borrow(asset common.Address, amount *big.Int) (*types.Transaction, error)
+	Repay(asset common.Address, amount *big.Int) (*types.Transaction, error)
+	SupplyRate(asset common.Address) (float64, error)
+	BorrowRate(asset common.Address) (float64, error)
+	// SupplyBalance returns what the account has supplied of asset,
+	// including the interest earned so far
+	SupplyBalance(asset common.Address) (*big.Int, error)
+}
+
+// DEXProtocol is an exchange that swaps for the manager's account.
+// UniswapV3Manager and CurveManager implement it.
+type DEXProtocol interface {
+	Name() string
+	Quote(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error)
+	Swap(tokenIn, tokenOut common.Address, amountIn, minAmountOut *big.Int) (*types.Transaction, error)
+}
+
+// LendingRate is a lending venue's rate for an asset
+type LendingRate struct {
+	Venue LendingProtocol
+	Rate  float64
+}
+
+// VenueQuote is a DEX's output for a swap
+type VenueQuote struct {
+	Venue     DEXProtocol
+	AmountOut *big.Int
+}
+
+// Venues are the protocols a strategy may route through. Lending and DEX
+// venues are picked by rate and output, so strategies need no
+// protocol-specific code paths.
+type Venues struct {
+	ContractManager *ContractManager
+	Lending         []LendingProtocol
+	DEXes           []DEXProtocol
+
+	// Liquidity, when set, carries out provide_liquidity and
+	// remove_liquidity actions
+	Liquidity *UniswapV3LPManager
+}
+
+// NewVenues creates venues over the given lending and DEX protocols, with
+// asset symbols resolved through the manager's registered tokens
+func NewVenues(cm *ContractManager, lending []LendingProtocol, dexes []DEXProtocol) *Venues {
+	return &Venues{
+		ContractManager: cm,
+		Lending:         lending,
+		DEXes:           dexes,
+	}
+}
+
+// BestSupplyRate returns the lending venue paying the highest supply rate
+// for asset. Venues that cannot quote the asset are skipped.
+func (v *Venues) BestSupplyRate(asset common.Address) (*LendingRate, error) {
+	return v.bestRate(asset, "supply", LendingProtocol.SupplyRate, func(rate, best float64) bool { return rate > best })
+}
+
+// BestBorrowRate returns the lending venue charging the lowest borrow rate
+// for asset. Venues that cannot quote the asset are skipped.
+func (v *Venues) BestBorrowRate(asset common.Address) (*LendingRate, error) {
+	return v.bestRate(asset, "borrow", LendingProtocol.BorrowRate, func(rate, best float64) bool { return rate < best })
+}
+
+func (v *Venues) bestRate(asset common.Address, side string, rate func(LendingProtocol, common.Address) (float64, error), better func(rate, best float64) bool) (*LendingRate, error) {
+	var best *LendingRate
+	var lastErr error
+	for _, venue := range v.Lending {
+		value, err := rate(venue, asset)
+		if err != nil {
+			log.Printf("Skipping %s %s rate for %s: %v", venue.Name(), side, asset.Hex(), err)
+			lastErr = err
+			continue
+		}
+		if best == nil || better(value, best.Rate) {
+			best = &LendingRate{Venue: venue, Rate: value}
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s rate for %s: %v", side, asset.Hex(), lastErr)
+	}
+	return best, nil
+}
+
+// BestQuote returns the DEX with the highest output for amountIn of
+// tokenIn. Venues without a route are skipped.
+func (v *Venues) BestQuote(tokenIn, tokenOut common.Address, amountIn *big.Int) (*VenueQuote, error) {
+	var best *VenueQuote
+	var lastErr error
+	for _, venue := range v.DEXes {
+		amountOut, err := venue.Quote(tokenIn, tokenOut, amountIn)
+		if err != nil {
+			log.Printf("Skipping %s quote for %s -> %s: %v", venue.Name(), tokenIn.Hex(), tokenOut.Hex(), err)
+			lastErr = err
+			continue
+		}
+		if best == nil || amountOut.Cmp(best.AmountOut) > 0 {
+			best = &VenueQuote{Venue: venue, AmountOut: amountOut}
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no quote for %s -> %s: %v", tokenIn.Hex(), tokenOut.Hex(), lastErr)
+	}
+	return best, nil
+}
+
+// SwapBest swaps amountIn of tokenIn on the DEX with the highest output,
+// accepting at most slippage below its quote
+func (v *Venues) SwapBest(tokenIn, tokenOut common.Address, amountIn *big.Int, slippage float64) (*types.Transaction, *VenueQuote, error) {
+	quote, err := v.BestQuote(tokenIn, tokenOut, amountIn)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := quote.Venue.Swap(tokenIn, tokenOut, amountIn, ApplySlippage(quote.AmountOut, slippage))
+	if err != nil {
+		return nil, quote, fmt.Errorf("failed to swap on %s: %v", quote.Venue.Name(), err)
+	}
+	return tx, quote, nil
+}
+
+// SupplyAPY returns the named venue's supply rate for an asset symbol, or
+// the best venue's for "best" or no protocol, so Venues can serve as a
+// StrategyEngine YieldSource
+func (v *Venues) SupplyAPY(protocol, asset string) (float64, error) {
+	address, err := v.token(asset)
+	if err != nil {
+		return 0, err
+	}
+	if protocol == "" || strings.EqualFold(protocol, "best") {
+		best, err := v.BestSupplyRate(address)
+		if err != nil {
+			return 0, err
+		}
+		return best.Rate, nil
+	}
+
+	venue, err := v.lending(protocol)
+	if err != nil {
+		return 0, err
+	}
+	return venue.SupplyRate(address)
+}
+
+// ExecuteAction carries out swap, deposit, withdraw, borrow and repay
+// actions, so Venues can serve as a StrategyEngine ActionExecutor. An action
+// targeting "best" or no protocol goes to the venue with the best output or
+// rate; other targets name the venue. Amounts are whole tokens with the
+// "decimals" parameter, the token's own decimals by default. Liquidity
+// actions go to Liquidity.
+func (v *Venues) ExecuteAction(strategy *TradingStrategy, action StrategyAction) error {
+	if action.Type == ActionProvideLiquidity || action.Type == ActionRemoveLiquidity {
+		if v.Liquidity == nil {
+			return fmt.Errorf("no liquidity manager for %s", action.Type)
+		}
+		return v.Liquidity.ExecuteAction(strategy, action)
+	}
+
+	best := action.Target == "" || strings.EqualFold(action.Target, "best")
+
+	if action.Type == ActionSwap {
+		from, _ := action.Parameters["from_token"].(string)
+		to, _ := action.Parameters["to_token"].(string)
+		tokenIn, err := v.token(from)
+		if err != nil {
+			return err
+		}
+		tokenOut, err := v.token(to)
+		if err != nil {
+			return err
+		}
+		amount, err := v.actionAmount(action.Parameters, tokenIn)
+		if err != nil {
+			return err
+		}
+
+		slippage := strategy.Parameters.MaxSlippage
+		if best {
+			_, _, err = v.SwapBest(tokenIn, tokenOut, amount, slippage)
+			return err
+		}
+		venue, err := v.dex(action.Target)
+		if err != nil {
+			return err
+		}
+		quoted, err := venue.Quote(tokenIn, tokenOut, amount)
+		if err != nil {
+			return err
+		}
+		_, err = venue.Swap(tokenIn, tokenOut, amount, ApplySlippage(quoted, slippage))
+		return err
+	}
+
+	symbol, _ := action.Parameters["asset"].(string)
+	asset, err := v.token(symbol)
+	if err != nil {
+		return err
+	}
+	amount, err := v.actionAmount(action.Parameters, asset)
+	if err != nil {
+		return err
+	}
+
+	var venue LendingProtocol
+	switch {
+	case !best:
+		venue, err = v.lending(action.Target)
+	case action.Type == ActionBorrow:
+		var rate *LendingRate
+		if rate, err = v.BestBorrowRate(asset); err == nil {
+			venue = rate.Venue
+		}
+	default:
+		var rate *LendingRate
+		if rate, err = v.BestSupplyRate(asset); err == nil {
+			venue = rate.Venue
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	switch action.Type {
+	case ActionDeposit:
+		_, err = venue.Supply(asset, amount)
+	case ActionWithdraw:
+		_, err = venue.Withdraw(asset, amount)
+	case ActionBorrow:
+		_, err = venue.Borrow(asset, amount)
+	case ActionRepay:
+		_, err = venue.Repay(asset, amount)
+	default:
+		return fmt.Errorf("unsupported action type %s", action.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s on %s: %v", action.Type, venue.Name(), err)
+	}
+	return nil
+}
+
+// lending finds a lending venue by name
+func (v *Venues) lending(name string) (LendingProtocol, error) {
+	for _, venue := range v.Lending {
+		if strings.EqualFold(venue.Name(), name) {
+			return venue, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported protocol %q", name)
+}
+
+// dex finds a DEX venue by name
+func (v *Venues) dex(name string) (DEXProtocol, error) {
+	for _, venue := range v.DEXes {
+		if strings.EqualFold(venue.Name(), name) {
+			return venue, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported DEX %q", name)
+}
+
+// token resolves a registered token symbol
+func (v *Venues) token(symbol string) (common.Address, error) {
+	if v.ContractManager != nil {
+		if token, exists := v.ContractManager.Contract("erc20_" + strings.ToUpper(symbol)); exists {
+			return token.Address, nil
+		}
+	}
+	return common.Address{}, fmt.Errorf("unknown asset %s", symbol)
+}
+
+// actionAmount converts an action's whole-token "amount" parameter to base
+// units of token, with the "decimals" parameter or else the token's decimals
+func (v *Venues) actionAmount(parameters map[string]interface{}, token common.Address) (*big.Int, error) {
+	if _, set := parameters["decimals"]; set {
+		return actionAmount(parameters, metadataInt(parameters, "decimals", 18))
+	}
+	decimals, err := v.ContractManager.tokenDecimals(token)
+	if err != nil {
+		return nil, err
+	}
+	return actionAmount(parameters, decimals)
+}
+
+// actionAmount converts an action's whole-token "amount" parameter to base
+// units
+func actionAmount(parameters map[string]interface{}, decimals int) (*big.Int, error) {
+	amount := metadataFloat(parameters, "amount", 0)
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid amount %v", parameters["amount"])
+	}
+	scaled, _ := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(math.Pow10(decimals))).Int(nil)
+	return scaled, nil
+}
@@ -0,0 +1,133 @@
+package defi
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+var (
+	_ LendingProtocol = (*AaveManager)(nil)
+	_ LendingProtocol = (*CompoundV3Manager)(nil)
+	_ DEXProtocol     = (*UniswapV3Manager)(nil)
+	_ DEXProtocol     = (*CurveManager)(nil)
+	_ YieldSource     = (*Venues)(nil)
+	_ ActionExecutor  = (*Venues)(nil)
+	_ ActionExecutor  = (*UniswapV3LPManager)(nil)
+	_ RangePolicy     = StaticRange{}
+	_ RangePolicy     = RecenterRange{}
+	_ RangePolicy     = VolatilityRange{}
+	_ HedgeExecutor   = (*AaveShortHedger)(nil)
+	_ HedgeExecutor   = (*StableRotationHedger)(nil)
+)
+
+// newVenues lists USDC on Aave at 3% supply and 5% borrow and sets Comet's
+// rates to about 3.2% and 6.5%, so Compound pays more and Aave charges less
+func newVenues(t *testing.T, chain *defitest.Chain, cm *ContractManager) *Venues {
+	t.Helper()
+
+	chain.ListReserve(chain.USDC, defitest.ReserveParams{
+		Configuration:      reserveConfiguration(7500, 8000, 10500, 18, 0),
+		LiquidityRate:      rayRate(0.03),
+		VariableBorrowRate: rayRate(0.05),
+		StableBorrowRate:   rayRate(0.07),
+	})
+	chain.SetCometRates(defitest.Ether(1), 1_000_000_000, 2_000_000_000)
+
+	return NewVenues(cm,
+		[]LendingProtocol{NewAaveManager(cm), NewCompoundV3Manager(cm)},
+		[]DEXProtocol{NewUniswapV3Manager(cm), NewCurveManager(cm)},
+	)
+}
+
+func TestVenuesPickBestLendingRates(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+
+	supply, err := venues.BestSupplyRate(chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, "compound", supply.Venue.Name())
+	assert.InDelta(t, math.Exp(1e-9*secondsPerYear)-1, supply.Rate, 1e-6)
+
+	borrow, err := venues.BestBorrowRate(chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, "aave", borrow.Venue.Name())
+	assert.InDelta(t, math.Exp(0.05)-1, borrow.Rate, 1e-6)
+
+	// Neither venue lends WETH
+	_, err = venues.BestSupplyRate(chain.WETH)
+	assert.Error(t, err)
+
+	// As a yield source the venues answer by name or for the best rate
+	engine := NewStrategyEngine()
+	engine.Yields = venues
+	assert.InDelta(t, math.Exp(0.03)-1, engine.getCurrentYieldRate(map[string]interface{}{"protocol": "aave", "asset": "usdc"}), 1e-6)
+	assert.InDelta(t, supply.Rate, engine.getCurrentYieldRate(map[string]interface{}{"protocol": "best", "asset": "USDC"}), 1e-9)
+}
+
+func TestVenuesRouteStrategyActions(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(5_000))
+	chain.Mint(chain.WETH, chain.Account, defitest.Ether(20))
+	for _, spender := range []common.Address{chain.Comet, chain.CurvePool, chain.Router} {
+		chain.Approve(chain.Key, chain.USDC, spender, MaxRepay)
+		chain.Approve(chain.Key, chain.WETH, spender, MaxRepay)
+	}
+
+	// Deposits go to the best supply rate
+	strategy := YieldFarmingStrategy()
+	deposit := strategy.Actions[0]
+	deposit.Target = "best"
+	require.NoError(t, venues.ExecuteAction(strategy, deposit))
+	chain.Commit()
+	supplied, _, err := NewCompoundV3Manager(cm).GetBalances(chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(5_000).String(), supplied.String())
+
+	// Curve's 0.04% fee beats Uniswap's cheapest 0.05% tier at equal rates
+	quote, err := venues.BestQuote(chain.WETH, chain.USDC, defitest.Ether(10))
+	require.NoError(t, err)
+	assert.Equal(t, "curve", quote.Venue.Name())
+
+	swap := StrategyAction{
+		ID:         "sell_weth",
+		Type:       ActionSwap,
+		Target:     "best",
+		Parameters: map[string]interface{}{"from_token": "WETH", "to_token": "USDC", "amount": 10.0},
+	}
+	require.NoError(t, venues.ExecuteAction(strategy, swap))
+	chain.Commit()
+	assert.Equal(t, quote.AmountOut.String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+
+	// A better Uniswap rate wins the next swap
+	chain.SetRate(chain.WETH, chain.USDC, defitest.Ether(2))
+	quote, err = venues.BestQuote(chain.WETH, chain.USDC, defitest.Ether(10))
+	require.NoError(t, err)
+	assert.Equal(t, "uniswap_v3", quote.Venue.Name())
+	before := chain.BalanceOf(chain.USDC, chain.Account)
+	require.NoError(t, venues.ExecuteAction(strategy, swap))
+	chain.Commit()
+	received := new(big.Int).Sub(chain.BalanceOf(chain.USDC, chain.Account), before)
+	assert.Equal(t, quote.AmountOut.String(), received.String())
+
+	// Named targets bypass the comparison
+	swap.Target = "sushiswap"
+	assert.Error(t, venues.ExecuteAction(strategy, swap))
+	deposit.Target = "aave"
+	deposit.Parameters = map[string]interface{}{"asset": "USDC", "amount": 1.0}
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1))
+	chain.Approve(chain.Key, chain.USDC, chain.Pool, defitest.Ether(1))
+	require.NoError(t, venues.ExecuteAction(strategy, deposit))
+	chain.Commit()
+	assert.Equal(t, defitest.Ether(1).String(), chain.PoolAccount(chain.Account).Collateral.String())
+}
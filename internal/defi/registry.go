@@ -0,0 +1,223 @@
+package defi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/yaml.v3"
+)
+
+// MainnetChainID is the Ethereum mainnet chain ID
+const MainnetChainID = 1
+
+// registryFiles holds the built-in ABIs and chain deployments
+//
+//go:embed abis/*.json chains/*.yaml
+var registryFiles embed.FS
+
+// RequiredMethods lists, by ABI name, the methods the managers call. A
+// registry whose ABIs lack any of them fails validation.
+var RequiredMethods = map[string][]string{
+	"uniswap_v2_router":  {UniswapV2SwapExactETHForTokens, UniswapV2GetAmountsOut},
+	"uniswap_v2_factory": {UniswapV2GetPair},
+	"uniswap_v2_pair":    {UniswapV2GetReserves},
+	"uniswap_v3_router":  {UniswapV3ExactInputSingle, UniswapV3ExactInput},
+	"uniswap_v3_quoter":  {UniswapV3QuoteExactInputSingle, UniswapV3QuoteExactInput},
+	"uniswap_v3_position_manager": {
+		UniswapV3Mint, UniswapV3IncreaseLiquidity, UniswapV3DecreaseLiquidity, UniswapV3Collect,
+		UniswapV3Burn, UniswapV3Positions,
+	},
+	"uniswap_v3_factory": {UniswapV3GetPool},
+	"uniswap_v3_pool":    {UniswapV3Slot0, UniswapV3Liquidity},
+	"aave_lending_pool":  {AaveDeposit, AaveWithdraw, AaveBorrow},
+	"aave_v3_pool": {
+		AaveSupply, AaveWithdraw, AaveBorrow, AaveRepay,
+		AaveSetUserUseReserveAsCollateral, AaveSetUserEMode, AaveGetUserEMode, AaveSwapBorrowRateMode,
+		AaveGetUserAccountData, AaveGetUserConfiguration, AaveGetReserveData, AaveGetReservesList,
+		AaveFlashLoanSimple, AaveFlashLoanPremiumTotal,
+	},
+	"aave_oracle": {AaveGetAssetPrice},
+	"comet": {
+		CometSupply, CometWithdraw, CometBaseToken, CometBalanceOf, CometBorrowBalanceOf,
+		CometCollateralBalanceOf, CometGetUtilization, CometGetSupplyRate, CometGetBorrowRate,
+	},
+	"curve_registry":  {CurveFindPoolForCoins, CurveGetCoinIndices, CurveGetNCoins},
+	"curve_pool":      {CurveGetDy, CurveExchange, CurveCoins, CurveBalances, CurveA, CurveFee, CurveAdminFee},
+	"bundle_executor": {BundleAggregate3, BundleOwner},
+	"permit2":         {Permit2Allowance, Permit2Permit, Permit2TransferFrom},
+	"erc20": {
+		ERC20BalanceOf, ERC20Decimals, ERC20TotalSupply, ERC20Transfer, ERC20TransferFrom, ERC20Approve,
+		ERC20Allowance, ERC20Nonces, ERC20DomainSeparator, ERC20Permit,
+	},
+}
+
+// ContractRegistry holds contract ABIs by name and the contracts deployed
+// on each chain. It is loaded from a directory with an abis folder of
+// <name>.json ABI files and a chains folder of JSON or YAML deployments.
+type ContractRegistry struct {
+	ABIs   map[string]abi.ABI
+	Chains map[uint64]*ChainDeployment
+}
+
+// ChainDeployment is the set of contracts deployed on one chain, by the
+// name the managers look them up with
+type ChainDeployment struct {
+	ChainID   uint64                        `json:"chain_id" yaml:"chain_id"`
+	Name      string                        `json:"name" yaml:"name"`
+	Contracts map[string]ContractDeployment `json:"contracts" yaml:"contracts"`
+}
+
+// ContractDeployment is a contract's ABI name and address on a chain
+type ContractDeployment struct {
+	ABI     string `json:"abi" yaml:"abi"`
+	Address string `json:"address" yaml:"address"`
+}
+
+var (
+	defaultRegistryOnce sync.Once
+	defaultRegistry     *ContractRegistry
+	defaultRegistryErr  error
+)
+
+// DefaultRegistry returns the registry embedded in the package. It is
+// shared, so callers must not modify it.
+func DefaultRegistry() (*ContractRegistry, error) {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry, defaultRegistryErr = LoadRegistry(registryFiles)
+	})
+	return defaultRegistry, defaultRegistryErr
+}
+
+// LoadRegistryDir loads and validates a registry from a directory
+func LoadRegistryDir(dir string) (*ContractRegistry, error) {
+	return LoadRegistry(os.DirFS(dir))
+}
+
+// LoadRegistry loads and validates a registry from the abis and chains
+// folders of fsys
+func LoadRegistry(fsys fs.FS) (*ContractRegistry, error) {
+	registry := &ContractRegistry{
+		ABIs:   make(map[string]abi.ABI),
+		Chains: make(map[uint64]*ChainDeployment),
+	}
+
+	abiFiles, err := fs.Glob(fsys, "abis/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ABIs: %v", err)
+	}
+	for _, file := range abiFiles {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ABI %s: %v", file, err)
+		}
+		parsed, err := abi.JSON(strings.NewReader(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI %s: %v", file, err)
+		}
+		registry.ABIs[strings.TrimSuffix(path.Base(file), ".json")] = parsed
+	}
+
+	chainFiles, err := fs.ReadDir(fsys, "chains")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list chains: %v", err)
+	}
+	for _, entry := range chainFiles {
+		if entry.IsDir() {
+			continue
+		}
+		file := path.Join("chains", entry.Name())
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read deployment %s: %v", file, err)
+		}
+
+		deployment := &ChainDeployment{}
+		switch strings.ToLower(path.Ext(file)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, deployment)
+		case ".json":
+			err = json.Unmarshal(data, deployment)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse deployment %s: %v", file, err)
+		}
+		if deployment.ChainID == 0 {
+			return nil, fmt.Errorf("deployment %s has no chain_id", file)
+		}
+		if _, exists := registry.Chains[deployment.ChainID]; exists {
+			return nil, fmt.Errorf("chain %d deployed twice, again in %s", deployment.ChainID, file)
+		}
+		if deployment.Name == "" {
+			deployment.Name = fmt.Sprintf("chain %d", deployment.ChainID)
+		}
+		registry.Chains[deployment.ChainID] = deployment
+	}
+
+	if err := registry.Validate(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Validate checks that every ABI has the methods the managers call and
+// that every deployment names a known ABI at a valid address
+func (r *ContractRegistry) Validate() error {
+	var problems []string
+	for name, methods := range RequiredMethods {
+		contractABI, exists := r.ABIs[name]
+		if !exists {
+			continue
+		}
+		for _, method := range methods {
+			if _, exists := contractABI.Methods[method]; !exists {
+				problems = append(problems, fmt.Sprintf("ABI %s lacks method %s", name, method))
+			}
+		}
+	}
+
+	for _, deployment := range r.Chains {
+		for name, contract := range deployment.Contracts {
+			if _, exists := r.ABIs[contract.ABI]; !exists {
+				problems = append(problems, fmt.Sprintf("%s %s uses unknown ABI %q", deployment.Name, name, contract.ABI))
+			}
+			if !common.IsHexAddress(contract.Address) || common.HexToAddress(contract.Address) == (common.Address{}) {
+				problems = append(problems, fmt.Sprintf("%s %s has invalid address %q", deployment.Name, name, contract.Address))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid contract registry: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ABI returns a registered ABI by name
+func (r *ContractRegistry) ABI(name string) (*abi.ABI, error) {
+	contractABI, exists := r.ABIs[name]
+	if !exists {
+		return nil, fmt.Errorf("ABI %s not registered", name)
+	}
+	return &contractABI, nil
+}
+
+// Deployment returns the contracts deployed on a chain
+func (r *ContractRegistry) Deployment(chainID uint64) (*ChainDeployment, error) {
+	deployment, exists := r.Chains[chainID]
+	if !exists {
+		return nil, fmt.Errorf("no deployment for chain %d", chainID)
+	}
+	return deployment, nil
+}
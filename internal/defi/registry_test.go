@@ -0,0 +1,179 @@
+package defi
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/bindings"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestDefaultRegistryMatchesMainnetAddresses(t *testing.T) {
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+	require.NoError(t, registry.Validate())
+
+	mainnet, err := registry.Deployment(MainnetChainID)
+	require.NoError(t, err)
+	expected := map[string]common.Address{
+		"uniswap_v2_router":           UniswapV2Router,
+		"uniswap_v2_factory":          UniswapV2Factory,
+		"sushiswap_factory":           SushiSwapFactory,
+		"uniswap_v3_router":           UniswapV3Router,
+		"uniswap_v3_quoter":           UniswapV3Quoter,
+		"uniswap_v3_factory":          UniswapV3Factory,
+		"uniswap_v3_position_manager": UniswapV3NonfungiblePositionManager,
+		"aave_lending_pool":           AaveLendingPool,
+		"aave_v3_pool":                AaveV3Pool,
+		"aave_oracle":                 AaveV3Oracle,
+		"compound_v3_usdc":            CompoundV3USDC,
+		"curve_registry":              CurveRegistry,
+		"permit2":                     Permit2,
+	}
+	for name, address := range expected {
+		require.Contains(t, mainnet.Contracts, name)
+		assert.Equal(t, address, common.HexToAddress(mainnet.Contracts[name].Address), name)
+	}
+	assert.NotContains(t, mainnet.Contracts, "bundle_executor", "executors are per account")
+
+	_, err = registry.Deployment(5)
+	assert.Error(t, err)
+}
+
+func TestRegisterContractConcurrently(t *testing.T) {
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+	cm := &ContractManager{Contracts: make(map[string]*DeFiContract), Registry: registry}
+
+	// Pools found by a scan register while other goroutines look them up
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pool := common.BigToAddress(big.NewInt(int64(i % 4)))
+			name := "uniswap_v2_pair_" + strings.ToLower(pool.Hex())
+			assert.NoError(t, cm.RegisterContract(name, pool, "uniswap_v2_pair"))
+			contract, exists := cm.Contract(name)
+			if assert.True(t, exists) {
+				assert.Equal(t, pool, contract.Address)
+			}
+			_, err := cm.tokenContractName(pool)
+			assert.Error(t, err)
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, cm.Contracts, 4)
+
+	assert.Error(t, cm.RegisterContract("unknown_abi", common.Address{}, "nope"))
+}
+
+func TestLoadRegistryValidatesABIsAndDeployments(t *testing.T) {
+	erc20, err := registryFiles.ReadFile("abis/erc20.json")
+	require.NoError(t, err)
+	files := fstest.MapFS{
+		"abis/erc20.json": {Data: erc20},
+		"chains/local.json": {Data: []byte(`{"chain_id": 1337, "contracts": {
+			"erc20_USDC": {"abi": "erc20", "address": "0x00000000000000000000000000000000000000aa"}}}`)},
+		"chains/sepolia.yml": {Data: []byte("chain_id: 11155111\nname: sepolia\ncontracts: {}\n")},
+	}
+	registry, err := LoadRegistry(files)
+	require.NoError(t, err)
+	assert.Len(t, registry.Chains, 2)
+	local, err := registry.Deployment(1337)
+	require.NoError(t, err)
+	assert.Equal(t, "chain 1337", local.Name)
+	assert.Equal(t, "erc20", local.Contracts["erc20_USDC"].ABI)
+
+	// An ABI without a method the managers call
+	var methods []map[string]interface{}
+	require.NoError(t, json.Unmarshal(erc20, &methods))
+	var partial []map[string]interface{}
+	for _, method := range methods {
+		if method["name"] != ERC20Permit {
+			partial = append(partial, method)
+		}
+	}
+	data, err := json.Marshal(partial)
+	require.NoError(t, err)
+	files["abis/erc20.json"] = &fstest.MapFile{Data: data}
+	_, err = LoadRegistry(files)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ABI erc20 lacks method permit")
+
+	// Deployments must name known ABIs at valid addresses
+	files["abis/erc20.json"] = &fstest.MapFile{Data: erc20}
+	files["chains/local.json"] = &fstest.MapFile{Data: []byte(`{"chain_id": 1337, "contracts": {
+		"pool": {"abi": "aave_v3_pool", "address": "0x00000000000000000000000000000000000000aa"},
+		"erc20_DAI": {"abi": "erc20", "address": "0x1234"}}}`)}
+	_, err = LoadRegistry(files)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `pool uses unknown ABI "aave_v3_pool"`)
+	assert.Contains(t, err.Error(), `erc20_DAI has invalid address "0x1234"`)
+
+	files["chains/again.yaml"] = &fstest.MapFile{Data: []byte("chain_id: 11155111\n")}
+	delete(files, "chains/local.json")
+	_, err = LoadRegistry(files)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chain 11155111 deployed twice")
+}
+
+// TestBindingsMatchRegistry fails when an ABI changes without go generate
+// being rerun for its binding
+func TestBindingsMatchRegistry(t *testing.T) {
+	registry, err := DefaultRegistry()
+	require.NoError(t, err)
+
+	generated := map[string]*bind.MetaData{
+		"erc20":                       bindings.ERC20MetaData,
+		"uniswap_v3_router":           bindings.UniswapV3RouterMetaData,
+		"uniswap_v3_quoter":           bindings.UniswapV3QuoterMetaData,
+		"uniswap_v3_position_manager": bindings.UniswapV3PositionManagerMetaData,
+		"aave_v3_pool":                bindings.AaveV3PoolMetaData,
+		"comet":                       bindings.CometMetaData,
+		"curve_pool":                  bindings.CurvePoolMetaData,
+	}
+	signatures := func(contractABI *abi.ABI) []string {
+		var sigs []string
+		for _, method := range contractABI.Methods {
+			sigs = append(sigs, method.String())
+		}
+		sort.Strings(sigs)
+		return sigs
+	}
+	for name, metadata := range generated {
+		bound, err := abi.JSON(strings.NewReader(metadata.ABI))
+		require.NoError(t, err)
+		registered, err := registry.ABI(name)
+		require.NoError(t, err)
+		assert.Equal(t, signatures(registered), signatures(&bound), name)
+	}
+}
+
+func TestBindingsOnSimulatedChain(t *testing.T) {
+	chain := defitest.NewChain(t)
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(42))
+
+	token, err := bindings.NewERC20(chain.USDC, chain.Client)
+	require.NoError(t, err)
+	balance, err := token.BalanceOf(&bind.CallOpts{}, chain.Account)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(42).String(), balance.String())
+
+	market, err := bindings.NewComet(chain.Comet, chain.Client)
+	require.NoError(t, err)
+	base, err := market.BaseToken(&bind.CallOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, chain.USDC, base)
+}
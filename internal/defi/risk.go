@@ -0,0 +1,369 @@
+package defi
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// VaRMethod selects how AdvancedRiskManager estimates value at risk and
+// expected shortfall
+type VaRMethod string
+
+const (
+	// VaRHistorical reads the loss quantile off the observed returns
+	VaRHistorical VaRMethod = "historical"
+	// VaRParametric fits a normal distribution to the returns
+	VaRParametric VaRMethod = "parametric"
+	// VaRMonteCarlo simulates correlated normal asset returns
+	VaRMonteCarlo VaRMethod = "monte_carlo"
+)
+
+// Risk measurement defaults
+const (
+	DefaultVaRConfidence   = 0.95
+	DefaultRiskWindow      = 250
+	DefaultMonteCarloPaths = 10_000
+	// DefaultRiskPeriodsPerYear annualizes daily returns
+	DefaultRiskPeriodsPerYear = 365.0
+)
+
+// StressScenario shocks asset prices by fractional moves, such as -0.4 for a
+// 40% fall or -0.05 for a stablecoin depegging to 0.95
+type StressScenario struct {
+	Name   string
+	Shocks map[string]float64
+}
+
+// StressResult is a portfolio's loss under a stress scenario, in value and
+// as a fraction of the portfolio
+type StressResult struct {
+	Scenario string
+	Loss     float64
+	LossPct  float64
+}
+
+// DefaultStressScenarios returns the scenarios a new risk manager stresses
+// portfolios with
+func DefaultStressScenarios() []StressScenario {
+	stables := func(shock float64, shocks map[string]float64) map[string]float64 {
+		for _, symbol := range []string{"USDC", "USDT", "DAI"} {
+			shocks[symbol] = shock
+		}
+		return shocks
+	}
+	return []StressScenario{
+		{Name: "ETH -40%, stablecoin depeg to 0.95", Shocks: stables(-0.05, map[string]float64{"ETH": -0.4, "WETH": -0.4})},
+		{Name: "Crypto crash -50%", Shocks: map[string]float64{"ETH": -0.5, "WETH": -0.5, "BTC": -0.5, "WBTC": -0.5}},
+		{Name: "Stablecoin depeg to 0.90", Shocks: stables(-0.1, map[string]float64{})},
+	}
+}
+
+// HistoricalVaR returns the loss not exceeded with the given confidence
+// among returns, and the average loss beyond it, as positive fractions
+func HistoricalVaR(returns []float64, confidence float64) (valueAtRisk, expectedShortfall float64) {
+	if len(returns) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+
+	tail := int(math.Floor((1 - confidence) * float64(len(sorted))))
+	if tail >= len(sorted) {
+		tail = len(sorted) - 1
+	}
+	sum := 0.0
+	for _, r := range sorted[:tail+1] {
+		sum += r
+	}
+	return -sorted[tail], -sum / float64(tail+1)
+}
+
+// ParametricVaR returns the value at risk and expected shortfall of a normal
+// distribution with the returns' mean and standard deviation
+func ParametricVaR(returns []float64, confidence float64) (valueAtRisk, expectedShortfall float64) {
+	if len(returns) < 2 {
+		return 0, 0
+	}
+	mean := meanOf(returns)
+	sigma := stdDev(returns)
+	z := normalQuantile(1 - confidence)
+	density := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+	return -(mean + z*sigma), -mean + sigma*density/(1-confidence)
+}
+
+// MonteCarloVaR simulates paths of normally distributed asset returns with
+// the observed means and covariances, weights them into portfolio returns
+// and reads the value at risk and expected shortfall off the simulation.
+// assetReturns holds one equally long series per weight.
+func MonteCarloVaR(assetReturns [][]float64, weights []float64, confidence float64, paths int, rng *rand.Rand) (valueAtRisk, expectedShortfall float64) {
+	if len(assetReturns) == 0 || len(assetReturns[0]) < 2 || paths <= 0 {
+		return 0, 0
+	}
+
+	n := len(assetReturns)
+	means := make([]float64, n)
+	for i, series := range assetReturns {
+		means[i] = meanOf(series)
+	}
+	covariance := make([][]float64, n)
+	for i := range covariance {
+		covariance[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			sum := 0.0
+			for t := range assetReturns[i] {
+				sum += (assetReturns[i][t] - means[i]) * (assetReturns[j][t] - means[j])
+			}
+			covariance[i][j] = sum / float64(len(assetReturns[i])-1)
+			covariance[j][i] = covariance[i][j]
+		}
+	}
+	factor := cholesky(covariance)
+
+	simulated := make([]float64, paths)
+	draws := make([]float64, n)
+	for p := range simulated {
+		for i := range draws {
+			draws[i] = rng.NormFloat64()
+		}
+		portfolio := 0.0
+		for i := 0; i < n; i++ {
+			r := means[i]
+			for k := 0; k <= i; k++ {
+				r += factor[i][k] * draws[k]
+			}
+			portfolio += weights[i] * r
+		}
+		simulated[p] = portfolio
+	}
+	return HistoricalVaR(simulated, confidence)
+}
+
+// Measure computes the portfolio risk of holding assets with the given
+// weights from their aligned per-period returns: value at risk and expected
+// shortfall by the manager's Method, the maximum drawdown, and the Sharpe,
+// Sortino and Calmar ratios annualized over PeriodsPerYear
+func (rm *AdvancedRiskManager) Measure(assetReturns [][]float64, weights []float64) error {
+	if len(assetReturns) != len(weights) {
+		return fmt.Errorf("%d return series for %d weights", len(assetReturns), len(weights))
+	}
+	if len(assetReturns) == 0 || len(assetReturns[0]) < 2 {
+		return fmt.Errorf("not enough returns to measure risk")
+	}
+	periods := len(assetReturns[0])
+	for _, series := range assetReturns {
+		if len(series) != periods {
+			return fmt.Errorf("return series are not aligned")
+		}
+	}
+
+	returns := make([]float64, periods)
+	for i, series := range assetReturns {
+		for t, r := range series {
+			returns[t] += weights[i] * r
+		}
+	}
+
+	confidence := rm.Confidence
+	if confidence <= 0 || confidence >= 1 {
+		confidence = DefaultVaRConfidence
+	}
+	risk := rm.PortfolioRisk
+	switch rm.Method {
+	case VaRParametric:
+		risk.ValueAtRisk, risk.ExpectedShortfall = ParametricVaR(returns, confidence)
+	case VaRMonteCarlo:
+		paths := rm.MonteCarloPaths
+		if paths <= 0 {
+			paths = DefaultMonteCarloPaths
+		}
+		rng := rand.New(rand.NewSource(rm.Seed))
+		risk.ValueAtRisk, risk.ExpectedShortfall = MonteCarloVaR(assetReturns, weights, confidence, paths, rng)
+	case VaRHistorical, "":
+		risk.ValueAtRisk, risk.ExpectedShortfall = HistoricalVaR(returns, confidence)
+	default:
+		return fmt.Errorf("unsupported VaR method %s", rm.Method)
+	}
+
+	periodsPerYear := rm.PeriodsPerYear
+	if periodsPerYear <= 0 {
+		periodsPerYear = DefaultRiskPeriodsPerYear
+	}
+	mean := meanOf(returns)
+	growth := 1.0
+	peak, drawdown := 1.0, 0.0
+	downside := 0.0
+	for _, r := range returns {
+		growth *= 1 + r
+		peak = math.Max(peak, growth)
+		drawdown = math.Max(drawdown, (peak-growth)/peak)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	downside = math.Sqrt(downside / float64(periods))
+
+	risk.MaxDrawdown = drawdown
+	risk.SharpeRatio, risk.SortinoRatio, risk.CalmarRatio = 0, 0, 0
+	if sigma := stdDev(returns); sigma > 0 {
+		risk.SharpeRatio = mean / sigma * math.Sqrt(periodsPerYear)
+	}
+	if downside > 0 {
+		risk.SortinoRatio = mean / downside * math.Sqrt(periodsPerYear)
+	}
+	if drawdown > 0 && growth > 0 {
+		annualized := math.Pow(growth, periodsPerYear/float64(periods)) - 1
+		risk.CalmarRatio = annualized / drawdown
+	}
+	return nil
+}
+
+// StressTest revalues assets under each of the manager's Scenarios and
+// records the worst loss, as a fraction of the portfolio, as the market
+// risk's StressTestResult
+func (rm *AdvancedRiskManager) StressTest(assets map[string]*PortfolioAsset) []StressResult {
+	total := 0.0
+	for _, asset := range assets {
+		total += asset.Value
+	}
+
+	results := make([]StressResult, 0, len(rm.Scenarios))
+	worst := 0.0
+	for _, scenario := range rm.Scenarios {
+		result := StressResult{Scenario: scenario.Name}
+		for symbol, asset := range assets {
+			result.Loss -= asset.Value * scenario.Shocks[symbol]
+		}
+		if total > 0 {
+			result.LossPct = result.Loss / total
+		}
+		worst = math.Max(worst, result.LossPct)
+		results = append(results, result)
+	}
+	rm.MarketRisk.StressTestResult = worst
+	return results
+}
+
+// Concentration returns the share of the portfolio's value asset would hold
+// after moving value into it from the other holdings
+func Concentration(assets map[string]*PortfolioAsset, asset string, value float64) float64 {
+	total, held := 0.0, value
+	for symbol, holding := range assets {
+		total += holding.Value
+		if symbol == asset {
+			held += holding.Value
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+	return held / total
+}
+
+// UpdateRisk measures the engine portfolio's risk over the risk manager's
+// Window from its assets' price history, weighting assets by their current
+// value, and stress tests it. Assets without price history count as cash.
+func (ase *AdvancedStrategyEngine) UpdateRisk() error {
+	rm := ase.RiskManager
+	assets := ase.Portfolio.Assets
+	total := ase.Portfolio.GetTotalValue()
+	if total <= 0 {
+		return fmt.Errorf("portfolio has no value")
+	}
+	rm.StressTest(assets)
+
+	window := rm.Window
+	if window <= 0 {
+		window = DefaultRiskWindow
+	}
+	symbols := make([]string, 0, len(assets))
+	for symbol := range assets {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	series := make([][]float64, len(symbols))
+	periods := -1
+	for i, symbol := range symbols {
+		closes := ase.closes(symbol, window+1)
+		if len(closes) < 2 {
+			continue
+		}
+		series[i] = simpleReturns(closes)
+		if periods < 0 || len(series[i]) < periods {
+			periods = len(series[i])
+		}
+	}
+	if periods < 2 {
+		return fmt.Errorf("not enough price history to measure risk")
+	}
+
+	weights := make([]float64, len(symbols))
+	for i, symbol := range symbols {
+		weights[i] = assets[symbol].Value / total
+		if series[i] == nil {
+			series[i] = make([]float64, periods)
+		} else {
+			series[i] = series[i][len(series[i])-periods:]
+		}
+	}
+	return rm.Measure(series, weights)
+}
+
+// simpleReturns converts a price series into per-period simple returns,
+// skipping periods without a positive starting price
+func simpleReturns(prices []float64) []float64 {
+	returns := make([]float64, 0, len(prices))
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 {
+			returns = append(returns, prices[i]/prices[i-1]-1)
+		}
+	}
+	return returns
+}
+
+// cholesky returns the lower triangular factor of a covariance matrix,
+// leaving zero columns for riskless assets
+func cholesky(covariance [][]float64) [][]float64 {
+	n := len(covariance)
+	factor := make([][]float64, n)
+	for i := range factor {
+		factor[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		diagonal := covariance[j][j]
+		for k := 0; k < j; k++ {
+			diagonal -= factor[j][k] * factor[j][k]
+		}
+		if diagonal <= 1e-18 {
+			continue
+		}
+		factor[j][j] = math.Sqrt(diagonal)
+		for i := j + 1; i < n; i++ {
+			sum := covariance[i][j]
+			for k := 0; k < j; k++ {
+				sum -= factor[i][k] * factor[j][k]
+			}
+			factor[i][j] = sum / factor[j][j]
+		}
+	}
+	return factor
+}
+
+// normalQuantile inverts the standard normal distribution function
+func normalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// meanOf returns the arithmetic mean of values
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
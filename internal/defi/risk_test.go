@@ -0,0 +1,216 @@
+package defi
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
+)
+
+func TestHistoricalVaR(t *testing.T) {
+	returns := []float64{-0.05, -0.04}
+	for i := 0; i < 18; i++ {
+		returns = append(returns, 0.01)
+	}
+
+	// The 5% tail of 20 returns holds the two worst
+	valueAtRisk, shortfall := HistoricalVaR(returns, 0.95)
+	if math.Abs(valueAtRisk-0.04) > 1e-12 {
+		t.Errorf("Expected VaR 0.04, got %.6f", valueAtRisk)
+	}
+	if math.Abs(shortfall-0.045) > 1e-12 {
+		t.Errorf("Expected expected shortfall 0.045, got %.6f", shortfall)
+	}
+
+	if valueAtRisk, shortfall := HistoricalVaR(nil, 0.95); valueAtRisk != 0 || shortfall != 0 {
+		t.Errorf("Expected no risk without returns, got %.6f/%.6f", valueAtRisk, shortfall)
+	}
+}
+
+func TestParametricAndMonteCarloVaR(t *testing.T) {
+	returns := make([]float64, 100)
+	for i := range returns {
+		returns[i] = 0.02
+		if i%2 == 1 {
+			returns[i] = -0.02
+		}
+	}
+	sigma := stdDev(returns)
+
+	// A zero-mean normal loses 1.645 deviations at 95% and averages 2.063 beyond
+	valueAtRisk, shortfall := ParametricVaR(returns, 0.95)
+	if math.Abs(valueAtRisk-1.644854*sigma) > 1e-6 {
+		t.Errorf("Expected parametric VaR %.6f, got %.6f", 1.644854*sigma, valueAtRisk)
+	}
+	if math.Abs(shortfall-2.062713*sigma) > 1e-6 {
+		t.Errorf("Expected parametric expected shortfall %.6f, got %.6f", 2.062713*sigma, shortfall)
+	}
+
+	// Simulation converges on the normal model, and half the portfolio in a
+	// riskless asset halves the risk
+	cash := make([]float64, len(returns))
+	rng := rand.New(rand.NewSource(1))
+	simulatedVaR, simulatedShortfall := MonteCarloVaR([][]float64{returns, cash}, []float64{0.5, 0.5}, 0.95, DefaultMonteCarloPaths, rng)
+	if math.Abs(simulatedVaR-valueAtRisk/2) > 0.05*valueAtRisk {
+		t.Errorf("Expected Monte Carlo VaR near %.6f, got %.6f", valueAtRisk/2, simulatedVaR)
+	}
+	if math.Abs(simulatedShortfall-shortfall/2) > 0.05*shortfall {
+		t.Errorf("Expected Monte Carlo expected shortfall near %.6f, got %.6f", shortfall/2, simulatedShortfall)
+	}
+}
+
+func TestRiskManagerMeasure(t *testing.T) {
+	rm := NewAdvancedRiskManager()
+
+	if err := rm.Measure([][]float64{{0.1, 0.2}}, []float64{0.5, 0.5}); err == nil {
+		t.Error("Expected an error for mismatched weights")
+	}
+	if err := rm.Measure([][]float64{{0.1, 0.2}, {0.1}}, []float64{0.5, 0.5}); err == nil {
+		t.Error("Expected an error for unaligned returns")
+	}
+
+	// 1.1, 0.55, 0.66: the fall from 1.1 to 0.55 is the worst drawdown
+	if err := rm.Measure([][]float64{{0.1, -0.5, 0.2}}, []float64{1}); err != nil {
+		t.Fatalf("Failed to measure risk: %v", err)
+	}
+	risk := rm.PortfolioRisk
+	if math.Abs(risk.MaxDrawdown-0.5) > 1e-12 {
+		t.Errorf("Expected max drawdown 0.5, got %.6f", risk.MaxDrawdown)
+	}
+	if risk.ValueAtRisk != 0.5 || risk.ExpectedShortfall != 0.5 {
+		t.Errorf("Expected VaR and expected shortfall 0.5, got %.6f/%.6f", risk.ValueAtRisk, risk.ExpectedShortfall)
+	}
+	if risk.SharpeRatio >= 0 || risk.SortinoRatio >= 0 || risk.CalmarRatio >= 0 {
+		t.Errorf("Expected negative ratios for a losing portfolio, got %.2f/%.2f/%.2f", risk.SharpeRatio, risk.SortinoRatio, risk.CalmarRatio)
+	}
+
+	rm.Method = VaRParametric
+	if err := rm.Measure([][]float64{{0.1, -0.5, 0.2}}, []float64{1}); err != nil {
+		t.Fatalf("Failed to measure parametric risk: %v", err)
+	}
+	if expected, _ := ParametricVaR([]float64{0.1, -0.5, 0.2}, DefaultVaRConfidence); risk.ValueAtRisk != expected {
+		t.Errorf("Expected parametric VaR %.6f, got %.6f", expected, risk.ValueAtRisk)
+	}
+
+	rm.Method = "unknown"
+	if err := rm.Measure([][]float64{{0.1, -0.5, 0.2}}, []float64{1}); err == nil {
+		t.Error("Expected an error for an unsupported VaR method")
+	}
+}
+
+func TestStressTest(t *testing.T) {
+	rm := NewAdvancedRiskManager()
+	assets := map[string]*PortfolioAsset{
+		"ETH":  {Symbol: "ETH", Value: 4000},
+		"USDC": {Symbol: "USDC", Value: 6000},
+	}
+
+	results := rm.StressTest(assets)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 stress results, got %d", len(results))
+	}
+
+	// ETH -40% loses 1600 and the depeg 300
+	if results[0].Scenario != "ETH -40%, stablecoin depeg to 0.95" || math.Abs(results[0].Loss-1900) > 1e-9 {
+		t.Errorf("Unexpected result %+v", results[0])
+	}
+	if math.Abs(results[0].LossPct-0.19) > 1e-12 {
+		t.Errorf("Expected 19%% loss, got %.4f", results[0].LossPct)
+	}
+	if math.Abs(results[2].LossPct-0.06) > 1e-12 {
+		t.Errorf("Expected 6%% loss on the depeg, got %.4f", results[2].LossPct)
+	}
+
+	// The crash halving ETH is the worst case
+	if math.Abs(rm.MarketRisk.StressTestResult-0.2) > 1e-12 {
+		t.Errorf("Expected worst stress loss 0.2, got %.4f", rm.MarketRisk.StressTestResult)
+	}
+}
+
+func TestAssessRiskEnforcesLimits(t *testing.T) {
+	engine := NewAdvancedStrategyEngine()
+	strategy := MeanReversionStrategy()
+	engine.RiskManager.MarketRisk.Volatility = 0.01
+	engine.RiskManager.PortfolioRisk.MaxDrawdown = 0.01
+	engine.RiskManager.PortfolioRisk.ValueAtRisk = 0.01
+	engine.Portfolio.Assets["USDC"] = &PortfolioAsset{Symbol: "USDC", Value: 10000}
+
+	if !engine.AssessRisk(strategy) {
+		t.Error("Risk assessment should pass within the limits")
+	}
+
+	limits := engine.RiskManager.RiskLimits
+	limits.MaxVaR = 0.005
+	if engine.AssessRisk(strategy) {
+		t.Error("Risk assessment should fail above the VaR limit")
+	}
+	limits.MaxVaR = 0.05
+
+	limits.MaxDrawdown = 0.005
+	if engine.AssessRisk(strategy) {
+		t.Error("Risk assessment should fail above the drawdown limit")
+	}
+	limits.MaxDrawdown = 0.10
+
+	// Adding to an ETH holding at 19% would take it over the 20% limit
+	engine.Portfolio.Assets["USDC"].Value = 8100
+	engine.Portfolio.Assets["ETH"] = &PortfolioAsset{Symbol: "ETH", Value: 1900}
+	if size := engine.CalculatePositionSize(strategy, 10000); size <= 100 {
+		t.Fatalf("Expected a position above 1%% of the portfolio, got %.2f", size)
+	}
+	if engine.AssessRisk(strategy) {
+		t.Error("Risk assessment should fail above the concentration limit")
+	}
+
+	engine.RiskManager.RiskLimits = nil
+	if !engine.AssessRisk(strategy) {
+		t.Error("Risk assessment should pass without portfolio limits")
+	}
+
+	// Zero strategy limits are unset rather than forbidding any risk
+	strategy.RiskParameters.VolatilityLimit = 0
+	strategy.RiskParameters.MaxDrawdown = 0
+	strategy.RiskParameters.ValueAtRisk = 0
+	if !engine.AssessRisk(strategy) {
+		t.Error("Risk assessment should pass with unset strategy limits")
+	}
+}
+
+func TestUpdateRiskFromHistory(t *testing.T) {
+	engine := NewAdvancedStrategyEngine()
+	engine.Portfolio.Assets["ETH"] = &PortfolioAsset{Symbol: "ETH", Value: 5000}
+	engine.Portfolio.Assets["USDC"] = &PortfolioAsset{Symbol: "USDC", Value: 5000}
+
+	if err := engine.UpdateRisk(); err == nil {
+		t.Error("Expected an error without price history")
+	}
+
+	engine.History = market.NewPriceHistory(0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := make([]float64, 30)
+	for i := range prices {
+		prices[i] = 100 + 10*math.Sin(float64(i))
+		engine.History.AddPrice("ETH", prices[i], 100, start.Add(time.Duration(i)*time.Hour))
+	}
+	if err := engine.UpdateRisk(); err != nil {
+		t.Fatalf("Failed to update risk: %v", err)
+	}
+
+	// USDC has no history and counts as cash, halving ETH's returns
+	returns := simpleReturns(prices)
+	for i := range returns {
+		returns[i] *= 0.5
+	}
+	expected, _ := HistoricalVaR(returns, DefaultVaRConfidence)
+	if math.Abs(engine.RiskManager.PortfolioRisk.ValueAtRisk-expected) > 1e-12 {
+		t.Errorf("Expected VaR %.6f, got %.6f", expected, engine.RiskManager.PortfolioRisk.ValueAtRisk)
+	}
+	if engine.RiskManager.PortfolioRisk.MaxDrawdown <= 0 {
+		t.Error("Expected a drawdown from the oscillating price")
+	}
+	if math.Abs(engine.RiskManager.MarketRisk.StressTestResult-0.25) > 1e-12 {
+		t.Errorf("Expected worst stress loss 0.25, got %.4f", engine.RiskManager.MarketRisk.StressTestResult)
+	}
+}
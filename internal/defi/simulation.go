@@ -0,0 +1,266 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// NativeCurrency stands for the chain's native currency in balance changes
+var NativeCurrency = common.Address{}
+
+// Simulation is the outcome of running a transaction with eth_call at the
+// pending block before it is sent
+type Simulation struct {
+	Contract string
+	Method   string
+
+	// ReturnData is the raw call output and Outputs its decoded values
+	ReturnData []byte
+	Outputs    []interface{}
+
+	// BalanceChanges previews the token movements implied by the call's
+	// arguments and simulated outputs. Only the manager's ERC20, Uniswap V3
+	// router and Aave pool methods are understood; other calls preview just
+	// the native value sent.
+	BalanceChanges []BalanceChange
+}
+
+// BalanceChange is an expected change in an account's token balance
+type BalanceChange struct {
+	Token   common.Address
+	Account common.Address
+	Delta   *big.Int
+}
+
+// BalanceChange returns the net expected change in account's token balance
+func (s *Simulation) BalanceChange(token, account common.Address) *big.Int {
+	total := big.NewInt(0)
+	for _, change := range s.BalanceChanges {
+		if change.Token == token && change.Account == account {
+			total.Add(total, change.Delta)
+		}
+	}
+	return total
+}
+
+// SimulateTransaction runs a contract call from the manager's account with
+// eth_call at the pending block. A revert is returned as an ErrSimulation
+// DeFiError whose "reason" detail holds the decoded revert reason.
+func (cm *ContractManager) SimulateTransaction(contractName, method string, value *big.Int, args ...interface{}) (*Simulation, error) {
+	contract, exists := cm.Contract(contractName)
+	if !exists {
+		return nil, fmt.Errorf("contract %s not found", contractName)
+	}
+
+	data, err := contract.ABI.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack method %s: %v", method, err)
+	}
+
+	return cm.simulate(context.Background(), contractName, method, value, data)
+}
+
+// simulate runs packed call data against a registered contract
+func (cm *ContractManager) simulate(ctx context.Context, contractName, method string, value *big.Int, data []byte) (*Simulation, error) {
+	contract, exists := cm.Contract(contractName)
+	if !exists {
+		return nil, fmt.Errorf("contract %s not found", contractName)
+	}
+	msg := ethereum.CallMsg{
+		From:  cm.Transactor.From,
+		To:    &contract.Address,
+		Value: value,
+		Data:  data,
+	}
+
+	output, err := preflight(ctx, cm.Client, msg, &contract.ABI, map[string]interface{}{
+		"contract": contractName,
+		"method":   method,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	simulation := &Simulation{Contract: contractName, Method: method, ReturnData: output}
+	if len(contract.ABI.Methods[method].Outputs) > 0 {
+		simulation.Outputs, err = contract.ABI.Unpack(method, output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack simulated result: %v", err)
+		}
+	}
+	simulation.BalanceChanges = previewBalanceChanges(contractName, contract, method, cm.Transactor.From, value, data, simulation.Outputs)
+	return simulation, nil
+}
+
+// preflight runs msg with eth_call at the pending block, returning a revert
+// as an ErrSimulation DeFiError decoded with contractABI, which may be nil
+func preflight(ctx context.Context, client Backend, msg ethereum.CallMsg, contractABI *abi.ABI, details map[string]interface{}) ([]byte, error) {
+	output, err := client.CallContract(ctx, msg, big.NewInt(int64(rpc.PendingBlockNumber)))
+	if err != nil {
+		return nil, simulationError(err, contractABI, details)
+	}
+	return output, nil
+}
+
+// simulationError turns a failed eth_call into an ErrSimulation DeFiError if
+// the call reverted, decoding the reason with contractABI. Other failures,
+// such as an unreachable node, are returned as plain errors.
+func simulationError(err error, contractABI *abi.ABI, details map[string]interface{}) error {
+	data, reverted := revertData(err)
+	if !reverted {
+		return fmt.Errorf("failed to simulate transaction: %v", err)
+	}
+
+	reason, decodeErr := DecodeRevert(data, contractABI)
+	if decodeErr != nil {
+		reason = "execution reverted"
+	}
+	details["reason"] = reason
+	if len(data) > 0 {
+		details["revert_data"] = hexutil.Encode(data)
+	}
+	return WrapDeFiError(ErrSimulation, "transaction reverted: "+reason, err, details)
+}
+
+// revertData extracts the revert payload from an eth_call error. Nodes send
+// it as the JSON-RPC error data; a revert without a payload only shows in
+// the message.
+func revertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if encoded, ok := dataErr.ErrorData().(string); ok {
+			if data, err := hexutil.Decode(encoded); err == nil {
+				return data, true
+			}
+		}
+	}
+	return nil, strings.Contains(err.Error(), "execution reverted")
+}
+
+// DecodeRevert decodes revert data as an Error(string) message, a
+// Panic(uint256) code or one of the custom errors declared in contractABI,
+// which may be nil
+func DecodeRevert(data []byte, contractABI *abi.ABI) (string, error) {
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason, nil
+	}
+	if len(data) < 4 {
+		return "", fmt.Errorf("revert data too short: %d bytes", len(data))
+	}
+
+	if contractABI != nil {
+		if customErr, err := contractABI.ErrorByID([4]byte(data[:4])); err == nil {
+			values, err := customErr.Inputs.Unpack(data[4:])
+			if err != nil {
+				return "", fmt.Errorf("failed to unpack error %s: %v", customErr.Name, err)
+			}
+			args := make([]string, len(values))
+			for i, value := range values {
+				args[i] = fmt.Sprintf("%v", value)
+				if name := customErr.Inputs[i].Name; name != "" {
+					args[i] = name + ": " + args[i]
+				}
+			}
+			return fmt.Sprintf("%s(%s)", customErr.Name, strings.Join(args, ", ")), nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown revert selector %s", hexutil.Encode(data[:4]))
+}
+
+// isAavePool reports whether a registered contract is an Aave pool
+func isAavePool(contractName string) bool {
+	return contractName == "aave_lending_pool" || contractName == "aave_v3_pool"
+}
+
+// previewBalanceChanges derives the token movements of a call from its
+// arguments and simulated outputs
+func previewBalanceChanges(contractName string, contract *DeFiContract, method string, from common.Address, value *big.Int, data []byte, outputs []interface{}) []BalanceChange {
+	var changes []BalanceChange
+	move := func(token, account common.Address, delta *big.Int) {
+		if delta != nil && delta.Sign() != 0 {
+			changes = append(changes, BalanceChange{Token: token, Account: account, Delta: new(big.Int).Set(delta)})
+		}
+	}
+	if value != nil {
+		move(NativeCurrency, from, new(big.Int).Neg(value))
+	}
+
+	abiMethod, exists := contract.ABI.Methods[method]
+	if !exists || len(data) < 4 {
+		return changes
+	}
+	args, err := abiMethod.Inputs.Unpack(data[4:])
+	if err != nil {
+		return changes
+	}
+	address := func(i int) common.Address {
+		if i < len(args) {
+			if a, ok := args[i].(common.Address); ok {
+				return a
+			}
+		}
+		return common.Address{}
+	}
+	amount := func(values []interface{}, i int) *big.Int {
+		if i < len(values) {
+			if n, ok := values[i].(*big.Int); ok {
+				return n
+			}
+		}
+		return nil
+	}
+	spent := func(n *big.Int) *big.Int {
+		if n == nil {
+			return nil
+		}
+		return new(big.Int).Neg(n)
+	}
+
+	switch {
+	case strings.HasPrefix(contractName, "erc20_") && method == ERC20Transfer:
+		move(contract.Address, from, spent(amount(args, 1)))
+		move(contract.Address, address(0), amount(args, 1))
+
+	case contractName == "uniswap_v3_router" && method == UniswapV3ExactInputSingle:
+		// Copy fills the first field of a struct from a single tuple argument
+		var call struct{ Params exactInputSingleParams }
+		if abiMethod.Inputs.Copy(&call, args) == nil {
+			params := call.Params
+			move(params.TokenIn, from, spent(params.AmountIn))
+			move(params.TokenOut, params.Recipient, amount(outputs, 0))
+		}
+
+	case contractName == "uniswap_v3_router" && method == UniswapV3ExactInput:
+		var call struct{ Params exactInputParams }
+		if abiMethod.Inputs.Copy(&call, args) == nil && len(call.Params.Path) >= 2*common.AddressLength {
+			params := call.Params
+			tokenIn := common.BytesToAddress(params.Path[:common.AddressLength])
+			tokenOut := common.BytesToAddress(params.Path[len(params.Path)-common.AddressLength:])
+			move(tokenIn, from, spent(params.AmountIn))
+			move(tokenOut, params.Recipient, amount(outputs, 0))
+		}
+
+	case isAavePool(contractName) && (method == AaveDeposit || method == AaveSupply):
+		move(address(0), from, spent(amount(args, 1)))
+
+	case isAavePool(contractName) && method == AaveWithdraw:
+		move(address(0), address(2), amount(outputs, 0))
+
+	case isAavePool(contractName) && method == AaveBorrow:
+		move(address(0), from, amount(args, 1))
+
+	case isAavePool(contractName) && method == AaveRepay:
+		move(address(0), from, spent(amount(outputs, 0)))
+	}
+	return changes
+}
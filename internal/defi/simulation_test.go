@@ -0,0 +1,112 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[
+		{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+	]`))
+	require.NoError(t, err)
+
+	encode := func(signature string, types []string, values ...interface{}) []byte {
+		var args abi.Arguments
+		for _, name := range types {
+			typ, err := abi.NewType(name, "", nil)
+			require.NoError(t, err)
+			args = append(args, abi.Argument{Type: typ})
+		}
+		packed, err := args.Pack(values...)
+		require.NoError(t, err)
+		return append(crypto.Keccak256([]byte(signature))[:4], packed...)
+	}
+
+	reason, err := DecodeRevert(encode("Error(string)", []string{"string"}, "Too little received"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Too little received", reason)
+
+	reason, err = DecodeRevert(encode("Panic(uint256)", []string{"uint256"}, big.NewInt(0x11)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "arithmetic underflow or overflow", reason)
+
+	custom := encode("InsufficientBalance(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(5), big.NewInt(7))
+	reason, err = DecodeRevert(custom, &contractABI)
+	require.NoError(t, err)
+	assert.Equal(t, "InsufficientBalance(available: 5, required: 7)", reason)
+
+	_, err = DecodeRevert(custom, nil)
+	assert.Error(t, err)
+	_, err = DecodeRevert([]byte{0x01}, &contractABI)
+	assert.Error(t, err)
+}
+
+func TestSimulationPreviewsBalanceChanges(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+	chain.Approve(chain.Key, chain.USDC, chain.Router, defitest.Ether(100))
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+
+	simulation, err := cm.SimulateTransaction("uniswap_v3_router", UniswapV3ExactInputSingle, big.NewInt(0), exactInputSingleParams{
+		TokenIn:           chain.USDC,
+		TokenOut:          chain.WETH,
+		Fee:               big.NewInt(int64(FeeTierMedium)),
+		Recipient:         chain.Account,
+		Deadline:          CreateDeadline(10),
+		AmountIn:          defitest.Ether(100),
+		AmountOutMinimum:  big.NewInt(0),
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "49850000000000000", simulation.Outputs[0].(*big.Int).String())
+	assert.Equal(t, new(big.Int).Neg(defitest.Ether(100)).String(), simulation.BalanceChange(chain.USDC, chain.Account).String())
+	assert.Equal(t, "49850000000000000", simulation.BalanceChange(chain.WETH, chain.Account).String())
+
+	// Simulating changes nothing
+	assert.Equal(t, defitest.Ether(1_000).String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+
+	tx, simulation, err := cm.SimulateAndTransact("erc20_USDC", ERC20Transfer, big.NewInt(0), chain.Executor, defitest.Ether(10))
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).Neg(defitest.Ether(10)).String(), simulation.BalanceChange(chain.USDC, chain.Account).String())
+	assert.Equal(t, defitest.Ether(10).String(), simulation.BalanceChange(chain.USDC, chain.Executor).String())
+	mine(t, chain, cm, tx)
+	assert.Equal(t, defitest.Ether(10).String(), chain.BalanceOf(chain.USDC, chain.Executor).String())
+}
+
+func TestTransactContractRejectsSimulatedReverts(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+
+	// Spending tokens the account does not have reverts in the token
+	_, err := cm.TransactContract("erc20_USDC", ERC20Transfer, big.NewInt(0), chain.Executor, defitest.Ether(1))
+	require.Error(t, err)
+	assert.True(t, IsSimulationError(err))
+
+	var defiErr *DeFiError
+	require.True(t, errors.As(err, &defiErr))
+	assert.Equal(t, "erc20_USDC", defiErr.Details["contract"])
+	assert.Equal(t, ERC20Transfer, defiErr.Details["method"])
+	assert.Equal(t, "ERC20: insufficient balance", defiErr.Details["reason"])
+	assert.Contains(t, defiErr.Details["revert_data"], "0x08c379a0") // Error(string)
+
+	nonce, err := chain.Client.PendingNonceAt(context.Background(), chain.Account)
+	require.NoError(t, err)
+	assert.Zero(t, nonce)
+
+	// Node failures are not reported as reverts
+	assert.False(t, IsSimulationError(simulationError(errors.New("connection refused"), nil, map[string]interface{}{})))
+	assert.True(t, IsSimulationError(simulationError(errors.New("execution reverted"), nil, map[string]interface{}{})))
+}
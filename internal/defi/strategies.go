@@ -5,7 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
 	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/market"
 )
 
 // StrategyEngine manages multiple trading strategies
@@ -13,6 +20,34 @@ type StrategyEngine struct {
 	Strategies map[string]*TradingStrategy
 	Agents     map[string]*DeFiAgent
 	IsRunning  bool
+	Clock      Clock
+	History    *market.PriceHistory
+	Executor   ActionExecutor
+
+	// Pools holds simulated pool state by market.VenueSymbol(dex, pair), for
+	// price and depth metrics computed from the pools themselves
+	Pools map[string]poolmath.Pool
+
+	// Yields reports live supply rates for the yield_rate metric
+	Yields YieldSource
+
+	// Liquidity carries out the built-in provide and remove liquidity
+	// actions
+	Liquidity *UniswapV3LPManager
+
+	arbitrageMu sync.RWMutex
+	arbitrage   []ArbitrageOpportunity
+}
+
+// YieldSource reports a lending protocol's current supply APY for an asset
+// symbol, e.g. AaveManager for "aave"
+type YieldSource interface {
+	SupplyAPY(protocol, asset string) (float64, error)
+}
+
+// ActionExecutor carries out strategy actions in place of the built-in handlers
+type ActionExecutor interface {
+	ExecuteAction(strategy *TradingStrategy, action StrategyAction) error
 }
 
 // TradingStrategy defines a complete trading strategy
@@ -78,7 +113,45 @@ func NewStrategyEngine() *StrategyEngine {
 		Strategies: make(map[string]*TradingStrategy),
 		Agents:     make(map[string]*DeFiAgent),
 		IsRunning:  false,
+		Clock:      SystemClock,
+	}
+}
+
+// SetPool registers the simulated state of a DEX's pool for a token pair
+func (se *StrategyEngine) SetPool(dex, pair string, pool poolmath.Pool) {
+	if se.Pools == nil {
+		se.Pools = make(map[string]poolmath.Pool)
+	}
+	se.Pools[market.VenueSymbol(dex, pair)] = pool
+}
+
+// pool returns the registered pool for a DEX and token pair
+func (se *StrategyEngine) pool(dex, pair string) (poolmath.Pool, bool) {
+	pool, ok := se.Pools[market.VenueSymbol(dex, pair)]
+	return pool, ok
+}
+
+// HandleArbitrage keeps the latest scan's opportunities for the
+// arbitrage_profit metric, so the engine can be an ArbitrageScanner sink
+func (se *StrategyEngine) HandleArbitrage(opportunities []ArbitrageOpportunity) {
+	se.arbitrageMu.Lock()
+	defer se.arbitrageMu.Unlock()
+	se.arbitrage = append([]ArbitrageOpportunity(nil), opportunities...)
+}
+
+// ArbitrageOpportunities returns the latest scan's opportunities, best first
+func (se *StrategyEngine) ArbitrageOpportunities() []ArbitrageOpportunity {
+	se.arbitrageMu.RLock()
+	defer se.arbitrageMu.RUnlock()
+	return append([]ArbitrageOpportunity(nil), se.arbitrage...)
+}
+
+// now returns the current time from the engine clock
+func (se *StrategyEngine) now() time.Time {
+	if se.Clock == nil {
+		return time.Now()
 	}
+	return se.Clock.Now()
 }
 
 // AddStrategy adds a new trading strategy
@@ -87,8 +160,8 @@ func (se *StrategyEngine) AddStrategy(strategy *TradingStrategy) error {
 		return fmt.Errorf("strategy with ID %s already exists", strategy.ID)
 	}
 
-	strategy.CreatedAt = time.Now()
-	strategy.UpdatedAt = time.Now()
+	strategy.CreatedAt = se.now()
+	strategy.UpdatedAt = se.now()
 	se.Strategies[strategy.ID] = strategy
 
 	log.Printf("Added strategy: %s (%s)", strategy.Name, strategy.ID)
@@ -150,6 +223,21 @@ func (se *StrategyEngine) evaluateStrategies(ctx context.Context) {
 	}
 }
 
+// EvaluateStrategies evaluates every active strategy once, sequentially and in ID order
+func (se *StrategyEngine) EvaluateStrategies() {
+	ids := make([]string, 0, len(se.Strategies))
+	for id := range se.Strategies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if strategy := se.Strategies[id]; strategy.IsActive {
+			se.evaluateStrategy(strategy)
+		}
+	}
+}
+
 // evaluateStrategy evaluates a single strategy
 func (se *StrategyEngine) evaluateStrategy(strategy *TradingStrategy) {
 	// Check if all conditions are met
@@ -184,6 +272,8 @@ func (se *StrategyEngine) getCurrentMetricValue(metric string, metadata map[stri
 		return se.getLiquidityDepth(metadata)
 	case "volatility":
 		return se.calculateVolatility(metadata)
+	case "arbitrage_profit":
+		return se.bestArbitrageProfit(metadata)
 	case "gas_price":
 		return se.getCurrentGasPrice()
 	default:
@@ -198,10 +288,16 @@ func (se *StrategyEngine) executeStrategyActions(strategy *TradingStrategy) {
 	actions := se.sortActionsByPriority(strategy.Actions)
 
 	for _, action := range actions {
+		if se.Executor != nil {
+			if err := se.Executor.ExecuteAction(strategy, action); err != nil {
+				log.Printf("Action %s for strategy %s failed: %v", action.ID, strategy.ID, err)
+			}
+			continue
+		}
 		se.executeAction(action)
 	}
 
-	strategy.UpdatedAt = time.Now()
+	strategy.UpdatedAt = se.now()
 }
 
 // sortActionsByPriority sorts actions by priority (higher first)
@@ -342,22 +438,90 @@ func YieldFarmingStrategy() *TradingStrategy {
 // Helper functions for metric calculations
 
 func (se *StrategyEngine) calculatePriceDifference(metadata map[string]interface{}) float64 {
+	pair, _ := metadata["token_pair"].(string)
+	dex1, _ := metadata["dex1"].(string)
+	dex2, _ := metadata["dex2"].(string)
+
+	pool1, ok1 := se.pool(dex1, pair)
+	pool2, ok2 := se.pool(dex2, pair)
+	if ok1 && ok2 {
+		a, b := pool1.SpotPrice(), pool2.SpotPrice()
+		if a > 0 && b > 0 {
+			return math.Abs(a-b) / math.Min(a, b)
+		}
+	}
+
+	if se.History != nil && pair != "" && dex1 != "" && dex2 != "" {
+		a, okA := se.History.Latest(market.VenueSymbol(dex1, pair))
+		b, okB := se.History.Latest(market.VenueSymbol(dex2, pair))
+		if okA && okB && a.Close > 0 && b.Close > 0 {
+			return math.Abs(a.Close-b.Close) / math.Min(a.Close, b.Close)
+		}
+	}
+
 	// Implementation would fetch prices from multiple DEXs
 	// and calculate the difference
 	return 0.015 // 1.5% difference (example)
 }
 
+// bestArbitrageProfit returns the USD profit of the best known opportunity,
+// limited to cycles starting from metadata's token when it is set
+func (se *StrategyEngine) bestArbitrageProfit(metadata map[string]interface{}) float64 {
+	token, _ := metadata["token"].(string)
+	for _, opportunity := range se.ArbitrageOpportunities() {
+		if token == "" || opportunity.Path[0] == token {
+			return opportunity.ProfitUSD
+		}
+	}
+	return 0
+}
+
+// getCurrentYieldRate returns the supply APY of the metadata's protocol and
+// asset from the engine's yield source
 func (se *StrategyEngine) getCurrentYieldRate(metadata map[string]interface{}) float64 {
+	protocol, _ := metadata["protocol"].(string)
+	asset, _ := metadata["asset"].(string)
+
+	if se.Yields != nil && asset != "" {
+		apy, err := se.Yields.SupplyAPY(protocol, asset)
+		if err == nil {
+			return apy
+		}
+		log.Printf("Failed to get %s yield for %s: %v", protocol, asset, err)
+	}
+
 	// Implementation would fetch current yield rates from protocols
 	return 0.067 // 6.7% APY (example)
 }
 
+// getLiquidityDepth returns how much of the pair's quote token (token1) the
+// pool absorbs before its price moves by max_price_impact, in whole tokens
 func (se *StrategyEngine) getLiquidityDepth(metadata map[string]interface{}) float64 {
+	pair, _ := metadata["token_pair"].(string)
+	dex, _ := metadata["dex"].(string)
+
+	if pool, ok := se.pool(dex, pair); ok {
+		maxImpact := metadataFloat(metadata, "max_price_impact", 0.01)
+		decimals := metadataInt(metadata, "quote_decimals", 18)
+		depth, _ := new(big.Float).SetInt(poolmath.Depth(pool, maxImpact, false)).Float64()
+		return depth / math.Pow10(decimals)
+	}
+
 	// Implementation would calculate liquidity depth
 	return 5000000.0 // $5M liquidity (example)
 }
 
 func (se *StrategyEngine) calculateVolatility(metadata map[string]interface{}) float64 {
+	asset, _ := metadata["asset"].(string)
+	period := metadataInt(metadata, "period", 20)
+
+	if se.History != nil && asset != "" {
+		closes := se.History.Closes(asset, period+1)
+		if len(closes) > 2 {
+			return stdDev(logReturns(closes))
+		}
+	}
+
 	// Implementation would calculate price volatility
 	return 0.025 // 2.5% volatility (example)
 }
@@ -396,12 +560,82 @@ func (se *StrategyEngine) executeRepayAction(action StrategyAction) {
 
 func (se *StrategyEngine) executeProvideLiquidityAction(action StrategyAction) {
 	log.Printf("Executing provide liquidity action: %v", action.Parameters)
-	// Implementation would call DEX contracts
+	se.executeLiquidityAction(action)
 }
 
 func (se *StrategyEngine) executeRemoveLiquidityAction(action StrategyAction) {
 	log.Printf("Executing remove liquidity action: %v", action.Parameters)
-	// Implementation would call DEX contracts
+	se.executeLiquidityAction(action)
+}
+
+// executeLiquidityAction hands a liquidity action to the engine's
+// liquidity manager
+func (se *StrategyEngine) executeLiquidityAction(action StrategyAction) {
+	if se.Liquidity == nil {
+		log.Printf("No liquidity manager for action %s", action.ID)
+		return
+	}
+	if err := se.Liquidity.ExecuteAction(nil, action); err != nil {
+		log.Printf("Liquidity action %s failed: %v", action.ID, err)
+	}
+}
+
+// metadataInt reads an integer value from condition metadata
+func metadataInt(metadata map[string]interface{}, key string, fallback int) int {
+	switch v := metadata[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return fallback
+	}
+}
+
+// metadataFloat reads a numeric metadata value, falling back when it is absent
+func metadataFloat(metadata map[string]interface{}, key string, fallback float64) float64 {
+	switch v := metadata[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return fallback
+	}
+}
+
+// logReturns converts a price series into log returns
+func logReturns(prices []float64) []float64 {
+	returns := make([]float64, 0, len(prices))
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 && prices[i] > 0 {
+			returns = append(returns, math.Log(prices[i]/prices[i-1]))
+		}
+	}
+	return returns
+}
+
+// stdDev returns the sample standard deviation of values
+func stdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
 }
 
 // Helper function for value comparison
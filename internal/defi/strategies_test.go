@@ -2,11 +2,15 @@ package defi
 
 import (
 	"context"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
 )
 
 func TestNewStrategyEngine(t *testing.T) {
@@ -202,3 +206,31 @@ func TestStrategyParametersDefaults(t *testing.T) {
 	assert.Equal(t, 60, params.CooldownPeriod)
 	assert.Equal(t, []string{"ETH", "USDC"}, params.TargetAssets)
 }
+
+func TestStrategyMetricsFromPools(t *testing.T) {
+	engine := NewStrategyEngine()
+	metadata := map[string]interface{}{"token_pair": "ETH/USDC", "dex1": "uniswap", "dex2": "sushiswap"}
+	assert.Equal(t, 0.015, engine.calculatePriceDifference(metadata))
+
+	// ETH at 2000 USDC on one DEX and 2020 on the other
+	engine.SetPool("uniswap", "ETH/USDC", poolmath.NewV2Pool(defitest.Ether(1_000), big.NewInt(2_000_000_000_000)))
+	engine.SetPool("sushiswap", "ETH/USDC", poolmath.NewV2Pool(defitest.Ether(1_000), big.NewInt(2_020_000_000_000)))
+	assert.InDelta(t, 0.01, engine.calculatePriceDifference(metadata), 1e-9)
+
+	// Moving the price 1% takes about 0.5% of the 2M USDC reserve
+	depth := engine.getLiquidityDepth(map[string]interface{}{
+		"token_pair":     "ETH/USDC",
+		"dex":            "uniswap",
+		"quote_decimals": 6,
+	})
+	assert.InDelta(t, 10_000, depth, 100)
+
+	deeper := engine.getLiquidityDepth(map[string]interface{}{
+		"token_pair":       "ETH/USDC",
+		"dex":              "uniswap",
+		"quote_decimals":   6,
+		"max_price_impact": 0.05,
+	})
+	assert.Greater(t, deeper, depth)
+	assert.Equal(t, 5000000.0, engine.getLiquidityDepth(map[string]interface{}{"token_pair": "ETH/DAI", "dex": "uniswap"}))
+}
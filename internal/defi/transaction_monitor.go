@@ -2,15 +2,19 @@ package defi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
 )
 
 // TransactionStatus represents the status of a transaction
@@ -18,11 +22,27 @@ type TransactionStatus string
 
 const (
 	TransactionPending   TransactionStatus = "pending"
+	TransactionIncluded  TransactionStatus = "included"
 	TransactionConfirmed TransactionStatus = "confirmed"
 	TransactionFailed    TransactionStatus = "failed"
 	TransactionReverted  TransactionStatus = "reverted"
+	TransactionReplaced  TransactionStatus = "replaced"
 )
 
+// Transaction monitor defaults
+const (
+	DefaultMonitorPollInterval        = 2 * time.Second
+	DefaultMonitorTimeout             = 5 * time.Minute
+	DefaultReorgWindow         uint64 = 64
+)
+
+// CallRecorder receives the outcome and latency of every RPC call made by
+// the transaction monitor, typically to export metrics.
+// monitoring.Monitor implements it.
+type CallRecorder interface {
+	RecordBlockchainCall(success bool, latency time.Duration, gasUsed uint64)
+}
+
 // TransactionInfo contains detailed information about a transaction
 type TransactionInfo struct {
 	Hash        common.Hash
@@ -35,25 +55,69 @@ type TransactionInfo struct {
 	Value       *big.Int
 	Timestamp   time.Time
 	Error       string
+
+	// Confirmations counts the inclusion block and the blocks on top of it.
+	// Reorgs counts how often a reorg dropped the transaction's receipt.
+	Confirmations uint64
+	Reorgs        int
+
+	// ReplacedBy is the hash of the transaction that took this one's nonce.
+	// Replaces lists the earlier versions of this transaction, any of which
+	// may still be the one that gets mined.
+	ReplacedBy common.Hash
+	Replaces   []common.Hash
+
+	pendingSince time.Time
 }
 
-// TransactionMonitor monitors blockchain transactions
+// TransactionMonitor tracks transactions from submission to confirmation.
+// Once started it checks every tracked transaction on each new head, moving
+// it to included when mined and to confirmed or reverted after
+// Confirmations blocks. A reorg that drops a receipt moves the transaction
+// back to pending. Settled transactions are dropped once they are
+// ReorgWindow blocks deep.
 type TransactionMonitor struct {
-	Client       *ethclient.Client
+	Client        Backend
+	Confirmations uint64
+	PollInterval  time.Duration // head polling interval without subscriptions
+	Timeout       time.Duration // pending transactions fail after this long
+	ReorgWindow   uint64        // blocks a settled receipt is rechecked for reorgs
+	Recorder      CallRecorder
+
 	mu           sync.RWMutex
 	transactions map[common.Hash]*TransactionInfo
 	callbacks    map[common.Hash][]func(*TransactionInfo)
+	changed      chan struct{}
+	running      atomic.Bool
 }
 
-// NewTransactionMonitor creates a new transaction monitor
-func NewTransactionMonitor(client *ethclient.Client) *TransactionMonitor {
+// NewTransactionMonitor creates a new transaction monitor that confirms
+// transactions on inclusion
+func NewTransactionMonitor(client Backend) *TransactionMonitor {
 	return &TransactionMonitor{
-		Client:       client,
-		transactions: make(map[common.Hash]*TransactionInfo),
-		callbacks:    make(map[common.Hash][]func(*TransactionInfo)),
+		Client:        client,
+		Confirmations: 1,
+		PollInterval:  DefaultMonitorPollInterval,
+		Timeout:       DefaultMonitorTimeout,
+		ReorgWindow:   DefaultReorgWindow,
+		transactions:  make(map[common.Hash]*TransactionInfo),
+		callbacks:     make(map[common.Hash][]func(*TransactionInfo)),
+		changed:       make(chan struct{}),
 	}
 }
 
+// NewTransactionMonitorFromConfig creates a transaction monitor that waits
+// for the configured number of confirmations and reports its RPC calls to
+// recorder, which may be nil
+func NewTransactionMonitorFromConfig(client Backend, cfg config.BlockchainConfig, recorder CallRecorder) *TransactionMonitor {
+	tm := NewTransactionMonitor(client)
+	if cfg.Confirmations > 0 {
+		tm.Confirmations = uint64(cfg.Confirmations)
+	}
+	tm.Recorder = recorder
+	return tm
+}
+
 // MonitorTransaction starts monitoring a transaction
 func (tm *TransactionMonitor) MonitorTransaction(txHash common.Hash, from common.Address) (*TransactionInfo, error) {
 	tm.mu.Lock()
@@ -61,107 +125,391 @@ func (tm *TransactionMonitor) MonitorTransaction(txHash common.Hash, from common
 
 	// Check if already monitoring
 	if info, exists := tm.transactions[txHash]; exists {
-		return info, nil
+		return info.snapshot(), nil
 	}
 
 	// Create new transaction info
+	now := time.Now()
 	info := &TransactionInfo{
-		Hash:      txHash,
-		Status:    TransactionPending,
-		From:      from,
-		Timestamp: time.Now(),
+		Hash:         txHash,
+		Status:       TransactionPending,
+		From:         from,
+		Timestamp:    now,
+		pendingSince: now,
 	}
 
 	tm.transactions[txHash] = info
 
-	// Start monitoring in background
-	go tm.monitorTransaction(txHash)
-
 	log.Printf("Started monitoring transaction: %s", txHash.Hex())
-	return info, nil
+	return info.snapshot(), nil
+}
+
+// Start checks the monitored transactions on every new head until ctx is
+// done. Heads come from a subscription when the backend supports one, and
+// from polling the block number otherwise.
+func (tm *TransactionMonitor) Start(ctx context.Context) {
+	tm.running.Store(true)
+	go tm.run(ctx)
+}
+
+func (tm *TransactionMonitor) run(ctx context.Context) {
+	defer tm.running.Store(false)
+
+	if subscriber, ok := tm.Client.(headSubscriber); ok {
+		heads := make(chan *types.Header, 16)
+		sub, err := subscriber.SubscribeNewHead(ctx, heads)
+		if err == nil {
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-heads:
+					tm.check(ctx)
+				case err := <-sub.Err():
+					log.Printf("Head subscription ended, polling instead: %v", err)
+					tm.poll(ctx)
+					return
+				}
+			}
+		}
+		log.Printf("Head subscription unavailable, polling instead: %v", err)
+	}
+	tm.poll(ctx)
 }
 
-// monitorTransaction continuously monitors a transaction
-func (tm *TransactionMonitor) monitorTransaction(txHash common.Hash) {
-	ctx := context.Background()
-	maxAttempts := 60 // 5 minutes with 5-second intervals
+// headSubscriber is implemented by backends that push new chain heads
+type headSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
 
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		time.Sleep(5 * time.Second)
+// poll checks the monitored transactions whenever the block number changes
+func (tm *TransactionMonitor) poll(ctx context.Context) {
+	interval := tm.PollInterval
+	if interval <= 0 {
+		interval = DefaultMonitorPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-		receipt, err := tm.Client.TransactionReceipt(ctx, txHash)
-		if err != nil {
-			if err.Error() == "not found" {
-				// Transaction still pending
+	var last uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			head, err := tm.blockNumber(ctx)
+			if err != nil {
+				log.Printf("Failed to get block number: %v", err)
 				continue
 			}
-			tm.updateTransactionStatus(txHash, TransactionFailed, err.Error())
-			return
+			if head != last {
+				last = head
+				tm.check(ctx)
+			} else {
+				tm.expire()
+			}
 		}
+	}
+}
 
-		// Transaction found
-		if receipt.Status == types.ReceiptStatusSuccessful {
-			tm.updateTransactionStatus(txHash, TransactionConfirmed, "")
+func (tm *TransactionMonitor) check(ctx context.Context) {
+	if err := tm.Check(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to check transactions: %v", err)
+	}
+}
 
-			// Get transaction details
-			tx, _, err := tm.Client.TransactionByHash(ctx, txHash)
-			if err == nil {
-				tm.updateTransactionDetails(txHash, receipt, tx)
+// Check updates every tracked transaction against the current head and
+// dispatches callbacks for the status changes. Start calls it on each new
+// head; it can also be driven directly.
+func (tm *TransactionMonitor) Check(ctx context.Context) error {
+	head, err := tm.blockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	for _, txHash := range tm.tracked(head) {
+		if err := tm.checkTransaction(ctx, txHash, head); err != nil {
+			// Transient errors are retried on the next head
+			log.Printf("Failed to check transaction %s: %v", txHash.Hex(), err)
+		}
+	}
+	tm.expire()
+	tm.prune(head)
+	return nil
+}
+
+// tracked returns the transactions that can still change status: pending
+// and included ones, and settled ones within the reorg window
+func (tm *TransactionMonitor) tracked(head uint64) []common.Hash {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	var hashes []common.Hash
+	for hash, info := range tm.transactions {
+		switch info.Status {
+		case TransactionPending, TransactionIncluded:
+			hashes = append(hashes, hash)
+		case TransactionConfirmed, TransactionReverted:
+			if info.BlockNumber != nil && info.BlockNumber.Uint64()+tm.ReorgWindow > head {
+				hashes = append(hashes, hash)
 			}
+		}
+	}
+	return hashes
+}
 
-			log.Printf("Transaction %s confirmed in block %d", txHash.Hex(), receipt.BlockNumber.Uint64())
-			return
-		} else {
-			tm.updateTransactionStatus(txHash, TransactionReverted, "transaction reverted")
-			log.Printf("Transaction %s reverted in block %d", txHash.Hex(), receipt.BlockNumber.Uint64())
-			return
+// checkTransaction looks up the receipt of a transaction, or of any version
+// it replaced, and applies it
+func (tm *TransactionMonitor) checkTransaction(ctx context.Context, txHash common.Hash, head uint64) error {
+	tm.mu.RLock()
+	info, exists := tm.transactions[txHash]
+	if !exists {
+		tm.mu.RUnlock()
+		return nil
+	}
+	hashes := append([]common.Hash{txHash}, info.Replaces...)
+	needDetails := info.To == nil && info.Value == nil
+	tm.mu.RUnlock()
+
+	receipt, err := tm.findReceipt(ctx, hashes)
+	if err != nil {
+		return err
+	}
+
+	var tx *types.Transaction
+	if receipt != nil && needDetails {
+		start := time.Now()
+		tx, _, err = tm.Client.TransactionByHash(ctx, receipt.TxHash)
+		tm.record(start, err, 0)
+		if err != nil {
+			tx = nil
 		}
 	}
 
-	// Timeout
-	tm.updateTransactionStatus(txHash, TransactionFailed, "monitoring timeout")
-	log.Printf("Transaction %s monitoring timeout", txHash.Hex())
+	tm.applyReceipt(txHash, receipt, tx, head)
+	return nil
 }
 
-// updateTransactionStatus updates the status of a transaction
-func (tm *TransactionMonitor) updateTransactionStatus(txHash common.Hash, status TransactionStatus, errorMsg string) {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+// findReceipt returns the receipt of whichever hash was mined, or nil while
+// none has been
+func (tm *TransactionMonitor) findReceipt(ctx context.Context, hashes []common.Hash) (*types.Receipt, error) {
+	for _, hash := range hashes {
+		start := time.Now()
+		receipt, err := tm.Client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			tm.record(start, nil, receipt.GasUsed)
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			tm.record(start, err, 0)
+			return nil, err
+		}
+		tm.record(start, nil, 0)
+	}
+	return nil, nil
+}
 
+// applyReceipt moves a transaction to the status its receipt implies at
+// the given head. A nil receipt for a mined transaction means a reorg.
+func (tm *TransactionMonitor) applyReceipt(txHash common.Hash, receipt *types.Receipt, tx *types.Transaction, head uint64) {
+	tm.mu.Lock()
 	info, exists := tm.transactions[txHash]
 	if !exists {
+		tm.mu.Unlock()
 		return
 	}
+	previous := info.Status
 
-	info.Status = status
-	info.Error = errorMsg
+	if receipt == nil {
+		if previous == TransactionPending {
+			tm.mu.Unlock()
+			return
+		}
 
-	// Execute callbacks
-	if callbacks, exists := tm.callbacks[txHash]; exists {
-		for _, callback := range callbacks {
-			go callback(info)
+		log.Printf("Transaction %s was dropped from block %s by a reorg", txHash.Hex(), info.BlockNumber)
+		info.Status = TransactionPending
+		info.BlockNumber = nil
+		info.Confirmations = 0
+		info.Error = ""
+		info.Reorgs++
+		info.pendingSince = time.Now()
+	} else {
+		info.BlockNumber = receipt.BlockNumber
+		info.GasUsed = receipt.GasUsed
+		info.GasPrice = receipt.EffectiveGasPrice
+		if tx != nil {
+			if info.GasPrice == nil {
+				info.GasPrice = tx.GasPrice()
+			}
+			info.To = tx.To()
+			info.Value = tx.Value()
+		}
+
+		info.Confirmations = 0
+		if block := receipt.BlockNumber.Uint64(); head >= block {
+			info.Confirmations = head - block + 1
+		}
+
+		switch {
+		case info.Confirmations < tm.Confirmations:
+			info.Status = TransactionIncluded
+		case receipt.Status == types.ReceiptStatusSuccessful:
+			info.Status = TransactionConfirmed
+		default:
+			info.Status = TransactionReverted
+			info.Error = "transaction reverted"
 		}
 	}
+
+	if info.Status == previous {
+		tm.mu.Unlock()
+		return
+	}
+	callbacks, snapshot := tm.transition(info)
+	tm.mu.Unlock()
+
+	if snapshot.Status != TransactionIncluded {
+		log.Printf("Transaction %s %s in block %s", txHash.Hex(), snapshot.Status, snapshot.BlockNumber)
+	}
+	dispatch(callbacks, snapshot)
 }
 
-// updateTransactionDetails updates transaction details from receipt
-func (tm *TransactionMonitor) updateTransactionDetails(txHash common.Hash, receipt *types.Receipt, tx *types.Transaction) {
+// prune stops tracking confirmed and reverted transactions once they are
+// past the reorg window, along with the versions they replaced, so a
+// long-running monitor does not keep every transaction it has seen
+func (tm *TransactionMonitor) prune(head uint64) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	info, exists := tm.transactions[txHash]
-	if !exists {
+	for hash, info := range tm.transactions {
+		switch info.Status {
+		case TransactionConfirmed, TransactionReverted:
+			if info.BlockNumber == nil || info.BlockNumber.Uint64()+tm.ReorgWindow > head {
+				continue
+			}
+			for _, replaced := range info.Replaces {
+				delete(tm.transactions, replaced)
+				delete(tm.callbacks, replaced)
+			}
+			delete(tm.transactions, hash)
+			delete(tm.callbacks, hash)
+		}
+	}
+}
+
+// expire fails pending transactions that have waited longer than Timeout
+func (tm *TransactionMonitor) expire() {
+	if tm.Timeout <= 0 {
 		return
 	}
 
-	info.BlockNumber = receipt.BlockNumber
-	info.GasUsed = receipt.GasUsed
-	info.GasPrice = tx.GasPrice()
-	info.To = tx.To()
-	info.Value = tx.Value()
+	tm.mu.Lock()
+	var expired []*TransactionInfo
+	var callbacks [][]func(*TransactionInfo)
+	for hash, info := range tm.transactions {
+		if info.Status == TransactionPending && time.Since(info.pendingSince) > tm.Timeout {
+			info.Status = TransactionFailed
+			info.Error = "monitoring timeout"
+			cbs, snapshot := tm.transition(info)
+			expired = append(expired, snapshot)
+			callbacks = append(callbacks, cbs)
+			log.Printf("Transaction %s monitoring timeout", hash.Hex())
+		}
+	}
+	tm.mu.Unlock()
+
+	for i, snapshot := range expired {
+		dispatch(callbacks[i], snapshot)
+	}
+}
+
+// transition records a status change: it wakes waiters and returns the
+// callbacks to run with a snapshot of the new state. The lock must be held.
+func (tm *TransactionMonitor) transition(info *TransactionInfo) ([]func(*TransactionInfo), *TransactionInfo) {
+	close(tm.changed)
+	tm.changed = make(chan struct{})
+
+	callbacks := append([]func(*TransactionInfo){}, tm.callbacks[info.Hash]...)
+	return callbacks, info.snapshot()
+}
+
+// dispatch runs callbacks in registration order, each with its own copy
+func dispatch(callbacks []func(*TransactionInfo), info *TransactionInfo) {
+	for _, callback := range callbacks {
+		callback(info.snapshot())
+	}
+}
+
+// record reports an RPC call to the recorder. A not-found result is a
+// successful call.
+func (tm *TransactionMonitor) record(start time.Time, err error, gasUsed uint64) {
+	if tm.Recorder == nil {
+		return
+	}
+	success := err == nil || errors.Is(err, ethereum.NotFound)
+	tm.Recorder.RecordBlockchainCall(success, time.Since(start), gasUsed)
+}
+
+func (tm *TransactionMonitor) blockNumber(ctx context.Context) (uint64, error) {
+	start := time.Now()
+	head, err := tm.Client.BlockNumber(ctx)
+	tm.record(start, err, 0)
+	return head, err
+}
+
+// Replace moves monitoring of a transaction to the replacement that reuses
+// its nonce. Callbacks registered for the original see it replaced and then
+// follow the replacement.
+func (tm *TransactionMonitor) Replace(oldHash common.Hash, replacement *types.Transaction) {
+	tm.mu.Lock()
+	old, exists := tm.transactions[oldHash]
+	if !exists || old.Status != TransactionPending {
+		tm.mu.Unlock()
+		return
+	}
+
+	newHash := replacement.Hash()
+	old.Status = TransactionReplaced
+	old.ReplacedBy = newHash
+	callbacks, snapshot := tm.transition(old)
+
+	now := time.Now()
+	tm.transactions[newHash] = &TransactionInfo{
+		Hash:         newHash,
+		Status:       TransactionPending,
+		From:         old.From,
+		To:           replacement.To(),
+		Value:        replacement.Value(),
+		Timestamp:    now,
+		Replaces:     append([]common.Hash{oldHash}, old.Replaces...),
+		pendingSince: now,
+	}
+	tm.callbacks[newHash] = append(tm.callbacks[newHash], tm.callbacks[oldHash]...)
+	delete(tm.callbacks, oldHash)
+	tm.mu.Unlock()
+
+	log.Printf("Monitoring replacement %s for transaction %s", newHash.Hex(), oldHash.Hex())
+	dispatch(callbacks, snapshot)
+}
+
+// StuckTransactions returns transactions pending for longer than age,
+// candidates for speeding up or cancelling
+func (tm *TransactionMonitor) StuckTransactions(age time.Duration) []*TransactionInfo {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	var stuck []*TransactionInfo
+	for _, info := range tm.transactions {
+		if info.Status == TransactionPending && time.Since(info.pendingSince) > age {
+			stuck = append(stuck, info.snapshot())
+		}
+	}
+	return stuck
 }
 
-// AddCallback adds a callback for transaction status changes
+// AddCallback adds a callback for transaction status changes. Callbacks run
+// in order on the monitor's goroutine and receive a copy of the new state.
 func (tm *TransactionMonitor) AddCallback(txHash common.Hash, callback func(*TransactionInfo)) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
@@ -175,7 +523,10 @@ func (tm *TransactionMonitor) GetTransactionInfo(txHash common.Hash) (*Transacti
 	defer tm.mu.RUnlock()
 
 	info, exists := tm.transactions[txHash]
-	return info, exists
+	if !exists {
+		return nil, false
+	}
+	return info.snapshot(), true
 }
 
 // GetAllTransactions returns all monitored transactions
@@ -185,7 +536,7 @@ func (tm *TransactionMonitor) GetAllTransactions() []*TransactionInfo {
 
 	transactions := make([]*TransactionInfo, 0, len(tm.transactions))
 	for _, info := range tm.transactions {
-		transactions = append(transactions, info)
+		transactions = append(transactions, info.snapshot())
 	}
 	return transactions
 }
@@ -198,7 +549,7 @@ func (tm *TransactionMonitor) GetPendingTransactions() []*TransactionInfo {
 	var pending []*TransactionInfo
 	for _, info := range tm.transactions {
 		if info.Status == TransactionPending {
-			pending = append(pending, info)
+			pending = append(pending, info.snapshot())
 		}
 	}
 	return pending
@@ -221,33 +572,77 @@ func (tm *TransactionMonitor) GetTransactionCost(txHash common.Hash) (*big.Int,
 	return totalCost, nil
 }
 
-// WaitForConfirmation waits for transaction confirmation
-func (tm *TransactionMonitor) WaitForConfirmation(txHash common.Hash, timeout time.Duration) (*TransactionInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+// Wait blocks until a transaction, or the replacement that took its nonce,
+// is confirmed. Reverted and failed transactions return an error.
+func (tm *TransactionMonitor) Wait(ctx context.Context, txHash common.Hash) (*TransactionInfo, error) {
+	info, err := tm.WaitSettled(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if info.Status == TransactionReverted {
+		return nil, fmt.Errorf("transaction failed: %s", info.Error)
+	}
+	return info, nil
+}
 
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+// WaitSettled blocks until a transaction, or the replacement that took its
+// nonce, has Confirmations blocks and returns it confirmed or reverted.
+// Transactions that fail to be mined return an error. Without Start, the
+// waiter checks the chain itself every PollInterval.
+func (tm *TransactionMonitor) WaitSettled(ctx context.Context, txHash common.Hash) (*TransactionInfo, error) {
+	interval := tm.PollInterval
+	if interval <= 0 {
+		interval = DefaultMonitorPollInterval
+	}
 
 	for {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("timeout waiting for transaction confirmation")
-		case <-ticker.C:
-			info, exists := tm.GetTransactionInfo(txHash)
-			if !exists {
+		tm.mu.RLock()
+		info, exists := tm.transactions[txHash]
+		var snapshot *TransactionInfo
+		if exists {
+			snapshot = info.snapshot()
+		}
+		changed := tm.changed
+		tm.mu.RUnlock()
+
+		if snapshot != nil {
+			switch snapshot.Status {
+			case TransactionReplaced:
+				// Follow the replacement
+				txHash = snapshot.ReplacedBy
 				continue
+			case TransactionConfirmed, TransactionReverted:
+				return snapshot, nil
+			case TransactionFailed:
+				return nil, fmt.Errorf("transaction failed: %s", snapshot.Error)
 			}
+		}
 
-			if info.Status == TransactionConfirmed {
-				return info, nil
-			} else if info.Status == TransactionFailed || info.Status == TransactionReverted {
-				return nil, fmt.Errorf("transaction failed: %s", info.Error)
+		var poll <-chan time.Time
+		if !tm.running.Load() {
+			if err := tm.Check(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to check transactions: %v", err)
 			}
+			poll = time.After(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for transaction confirmation: %w", ctx.Err())
+		case <-changed:
+		case <-poll:
 		}
 	}
 }
 
+// WaitForConfirmation waits for transaction confirmation
+func (tm *TransactionMonitor) WaitForConfirmation(txHash common.Hash, timeout time.Duration) (*TransactionInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return tm.Wait(ctx, txHash)
+}
+
 // CleanupCompleted removes completed transactions from monitoring
 func (tm *TransactionMonitor) CleanupCompleted() int {
 	tm.mu.Lock()
@@ -255,7 +650,7 @@ func (tm *TransactionMonitor) CleanupCompleted() int {
 
 	count := 0
 	for hash, info := range tm.transactions {
-		if info.Status != TransactionPending {
+		if info.Status != TransactionPending && info.Status != TransactionIncluded {
 			delete(tm.transactions, hash)
 			delete(tm.callbacks, hash)
 			count++
@@ -272,30 +667,45 @@ func (tm *TransactionMonitor) GetTransactionStats() map[string]interface{} {
 	defer tm.mu.RUnlock()
 
 	pending := 0
+	included := 0
 	confirmed := 0
 	failed := 0
 	reverted := 0
+	replaced := 0
 
 	for _, info := range tm.transactions {
 		switch info.Status {
 		case TransactionPending:
 			pending++
+		case TransactionIncluded:
+			included++
 		case TransactionConfirmed:
 			confirmed++
 		case TransactionFailed:
 			failed++
 		case TransactionReverted:
 			reverted++
+		case TransactionReplaced:
+			replaced++
 		}
 	}
 
 	stats := map[string]interface{}{
 		"total":     len(tm.transactions),
 		"pending":   pending,
+		"included":  included,
 		"confirmed": confirmed,
 		"failed":    failed,
 		"reverted":  reverted,
+		"replaced":  replaced,
 	}
 
 	return stats
 }
+
+// snapshot copies the info so callers never share the monitor's state
+func (info *TransactionInfo) snapshot() *TransactionInfo {
+	copied := *info
+	copied.Replaces = append([]common.Hash(nil), info.Replaces...)
+	return &copied
+}
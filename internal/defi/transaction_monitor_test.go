@@ -0,0 +1,275 @@
+package defi
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+)
+
+var _ CallRecorder = (*monitoring.Monitor)(nil)
+
+// callRecorder counts the RPC calls reported by a monitor
+type callRecorder struct {
+	mu       sync.Mutex
+	calls    int
+	failures int
+	gasUsed  uint64
+}
+
+func (r *callRecorder) RecordBlockchainCall(success bool, latency time.Duration, gasUsed uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	if !success {
+		r.failures++
+	}
+	if gasUsed > 0 {
+		r.gasUsed = gasUsed
+	}
+}
+
+// statusLog collects the statuses a transaction's callbacks see
+type statusLog struct {
+	mu       sync.Mutex
+	statuses []TransactionStatus
+}
+
+func (l *statusLog) record(info *TransactionInfo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statuses = append(l.statuses, info.Status)
+}
+
+func (l *statusLog) get() []TransactionStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]TransactionStatus(nil), l.statuses...)
+}
+
+// sendApproval sends an approval and starts monitoring it
+func sendApproval(t *testing.T, chain *defitest.Chain, cm *ContractManager, tm *TransactionMonitor) (*types.Transaction, *statusLog) {
+	t.Helper()
+
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+	_, err = tm.MonitorTransaction(tx.Hash(), chain.Account)
+	require.NoError(t, err)
+
+	statuses := &statusLog{}
+	tm.AddCallback(tx.Hash(), statuses.record)
+	return tx, statuses
+}
+
+func TestTransactionMonitorConfirmations(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	recorder := &callRecorder{}
+	tm := NewTransactionMonitorFromConfig(chain.Client, config.BlockchainConfig{Confirmations: 3}, recorder)
+	assert.Equal(t, uint64(3), tm.Confirmations)
+
+	tx, statuses := sendApproval(t, chain, cm, tm)
+	ctx := context.Background()
+
+	require.NoError(t, tm.Check(ctx))
+	assert.Empty(t, statuses.get())
+
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+	info, _ := tm.GetTransactionInfo(tx.Hash())
+	assert.Equal(t, TransactionIncluded, info.Status)
+	assert.Equal(t, uint64(1), info.Confirmations)
+	assert.Equal(t, chain.USDC, *info.To)
+	assert.Positive(t, info.GasUsed)
+
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+
+	info, _ = tm.GetTransactionInfo(tx.Hash())
+	assert.Equal(t, TransactionConfirmed, info.Status)
+	assert.Equal(t, uint64(3), info.Confirmations)
+	assert.Equal(t, []TransactionStatus{TransactionIncluded, TransactionConfirmed}, statuses.get())
+
+	cost, err := tm.GetTransactionCost(tx.Hash())
+	require.NoError(t, err)
+	assert.Positive(t, cost.Sign())
+
+	assert.Positive(t, recorder.calls)
+	assert.Zero(t, recorder.failures)
+	assert.Equal(t, info.GasUsed, recorder.gasUsed)
+}
+
+func TestContractManagerWaitsForConfirmations(t *testing.T) {
+	chain := defitest.NewChain(t)
+	recorder := &callRecorder{}
+	useSimulatedRegistry(t, chain)
+	cm, err := NewContractManagerFromConfig(chain.Client, config.BlockchainConfig{
+		PrivateKey:    chain.PrivateKeyHex(),
+		Confirmations: 3,
+	}, recorder)
+	require.NoError(t, err)
+	require.NoError(t, cm.SetContractAddress("erc20_USDC", chain.USDC))
+	cm.Monitor.PollInterval = 5 * time.Millisecond
+
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(1))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan *types.Receipt, 1)
+	go func() {
+		receipt, err := cm.WaitForTransaction(ctx, tx.Hash())
+		assert.NoError(t, err)
+		done <- receipt
+	}()
+
+	chain.Commit()
+	require.Eventually(t, func() bool {
+		info, ok := cm.Monitor.GetTransactionInfo(tx.Hash())
+		return ok && info.Status == TransactionIncluded
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, done)
+
+	chain.Commit()
+	chain.Commit()
+	select {
+	case receipt := <-done:
+		require.NotNil(t, receipt)
+		assert.Equal(t, tx.Hash(), receipt.TxHash)
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait did not return after three confirmations")
+	}
+	assert.Positive(t, recorder.calls)
+
+	// Waits end with their context
+	tx, err = cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, defitest.Ether(2))
+	require.NoError(t, err)
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	_, err = cm.WaitForTransaction(short, tx.Hash())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout waiting for transaction confirmation")
+}
+
+func TestTransactionMonitorReorg(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	tm := NewTransactionMonitor(chain.Client)
+	tm.Confirmations = 2
+	ctx := context.Background()
+
+	tx, statuses := sendApproval(t, chain, cm, tm)
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+
+	info, _ := tm.GetTransactionInfo(tx.Hash())
+	require.Equal(t, TransactionConfirmed, info.Status)
+
+	// Dropping both blocks takes the receipt with them
+	chain.Reorg(2)
+	require.NoError(t, tm.Check(ctx))
+	info, _ = tm.GetTransactionInfo(tx.Hash())
+	assert.Equal(t, TransactionPending, info.Status)
+	assert.Equal(t, 1, info.Reorgs)
+	assert.Nil(t, info.BlockNumber)
+
+	// The transaction is mined again on the new branch
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+
+	info, _ = tm.GetTransactionInfo(tx.Hash())
+	assert.Equal(t, TransactionConfirmed, info.Status)
+	assert.Equal(t, []TransactionStatus{
+		TransactionIncluded, TransactionConfirmed,
+		TransactionPending,
+		TransactionIncluded, TransactionConfirmed,
+	}, statuses.get())
+}
+
+func TestTransactionMonitorStartAndWait(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	tm := NewTransactionMonitor(chain.Client)
+	tm.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tm.Start(ctx)
+
+	tx, statuses := sendApproval(t, chain, cm, tm)
+	chain.Commit()
+
+	info, err := tm.Wait(ctx, tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, TransactionConfirmed, info.Status)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]TransactionStatus{TransactionConfirmed}, statuses.get())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTransactionMonitorFollowsReplacement(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	tm := NewTransactionMonitor(chain.Client)
+	tm.ReorgWindow = 2
+	cm.Nonces.Monitor = tm
+	ctx := context.Background()
+
+	stuck, statuses := sendApproval(t, chain, cm, tm)
+	faster, err := cm.SpeedUpTransaction(stuck.Hash())
+	require.NoError(t, err)
+
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+
+	// Waiting on the original hash resolves to the replacement
+	info, err := tm.WaitForConfirmation(stuck.Hash(), time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, faster.Hash(), info.Hash)
+	assert.Equal(t, []TransactionStatus{TransactionReplaced, TransactionConfirmed}, statuses.get())
+
+	stats := tm.GetTransactionStats()
+	assert.Equal(t, 1, stats["replaced"])
+	assert.Equal(t, 1, stats["confirmed"])
+
+	// Both versions are dropped once the replacement is past the reorg window
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+	assert.Len(t, tm.GetAllTransactions(), 2)
+	chain.Commit()
+	require.NoError(t, tm.Check(ctx))
+	assert.Empty(t, tm.GetAllTransactions())
+}
+
+func TestTransactionMonitorTimeout(t *testing.T) {
+	chain := defitest.NewChain(t)
+	tm := NewTransactionMonitor(chain.Client)
+	tm.Timeout = time.Millisecond
+
+	missing := common.HexToHash("0x01")
+	_, err := tm.MonitorTransaction(missing, chain.Account)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	require.NoError(t, tm.Check(context.Background()))
+	_, err = tm.WaitForConfirmation(missing, time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "monitoring timeout")
+}
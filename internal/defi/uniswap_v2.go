@@ -0,0 +1,96 @@
+package defi
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// UniswapV2Manager reads the pairs of a Uniswap V2 style factory, such as
+// Uniswap V2 itself or SushiSwap
+type UniswapV2Manager struct {
+	ContractManager *ContractManager
+
+	// Factory names the registered pair factory; "uniswap_v2_factory" by
+	// default
+	Factory string
+
+	// Venue identifies the DEX in pool IDs; "uniswap_v2" by default
+	Venue string
+}
+
+// NewUniswapV2Manager creates a manager for Uniswap V2's own pairs
+func NewUniswapV2Manager(cm *ContractManager) *UniswapV2Manager {
+	return &UniswapV2Manager{
+		ContractManager: cm,
+		Factory:         "uniswap_v2_factory",
+		Venue:           "uniswap_v2",
+	}
+}
+
+// NewSushiSwapManager creates a manager for SushiSwap's pairs
+func NewSushiSwapManager(cm *ContractManager) *UniswapV2Manager {
+	return &UniswapV2Manager{
+		ContractManager: cm,
+		Factory:         "sushiswap_factory",
+		Venue:           "sushiswap",
+	}
+}
+
+// Name returns the manager's venue
+func (v2 *UniswapV2Manager) Name() string {
+	return v2.Venue
+}
+
+// PoolState reads the tokenA/tokenB pair's reserves as swap state for
+// quoting, trading the lower token address as token0 like the pair itself
+func (v2 *UniswapV2Manager) PoolState(tokenA, tokenB common.Address) (*poolmath.V2Pool, error) {
+	token0, token1 := sortTokens(tokenA, tokenB)
+
+	result, err := v2.ContractManager.CallContract(v2.Factory, UniswapV2GetPair, token0, token1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pair: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no pair returned")
+	}
+	address, ok := result[0].(common.Address)
+	if !ok {
+		return nil, fmt.Errorf("invalid pair format")
+	}
+	if address == (common.Address{}) {
+		return nil, fmt.Errorf("no %s pair for %s/%s: %w", v2.Venue, token0.Hex(), token1.Hex(), ErrNoPool)
+	}
+
+	name, err := v2.pairContract(address)
+	if err != nil {
+		return nil, err
+	}
+	result, err = v2.ContractManager.CallContract(name, UniswapV2GetReserves)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reserves: %v", err)
+	}
+	if len(result) < 2 {
+		return nil, fmt.Errorf("no reserves returned")
+	}
+	reserve0, ok0 := result[0].(*big.Int)
+	reserve1, ok1 := result[1].(*big.Int)
+	if !ok0 || !ok1 {
+		return nil, fmt.Errorf("invalid reserves format")
+	}
+
+	return poolmath.NewV2Pool(reserve0, reserve1), nil
+}
+
+// pairContract registers a pair found through the factory on first use and
+// returns its contract name
+func (v2 *UniswapV2Manager) pairContract(pair common.Address) (string, error) {
+	name := "uniswap_v2_pair_" + strings.ToLower(pair.Hex())
+	if err := v2.ContractManager.RegisterContract(name, pair, "uniswap_v2_pair"); err != nil {
+		return "", err
+	}
+	return name, nil
+}
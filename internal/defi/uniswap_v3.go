@@ -1,25 +1,58 @@
 package defi
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // UniswapV3Manager handles Uniswap V3 specific operations
 type UniswapV3Manager struct {
 	ContractManager *ContractManager
+
+	// RiskManager's MaxSlippage bounds the minimum output of routed swaps
+	RiskManager *RiskManager
+
+	// FeeTiers are the pool fees quoted for each hop of a route
+	FeeTiers []uint24
+
+	// IntermediateTokens are tried as the middle token of two-hop routes
+	IntermediateTokens []common.Address
+
+	// WETH prices route gas costs in the output token
+	WETH common.Address
+
+	// Allowances, when set, approves the router for swaps that exceed the
+	// current allowance and waits for the approval before swapping
+	Allowances *AllowanceManager
 }
 
-// NewUniswapV3Manager creates a new Uniswap V3 manager
+// NewUniswapV3Manager creates a new Uniswap V3 manager. Two-hop routes go
+// through the manager's registered WETH and USDC tokens.
 func NewUniswapV3Manager(cm *ContractManager) *UniswapV3Manager {
-	return &UniswapV3Manager{
+	uv3 := &UniswapV3Manager{
 		ContractManager: cm,
+		RiskManager:     NewRiskManager(),
+		FeeTiers:        []uint24{FeeTierLow, FeeTierMedium, FeeTierHigh},
+	}
+
+	if weth, exists := cm.Contract("erc20_WETH"); exists {
+		uv3.WETH = weth.Address
+		uv3.IntermediateTokens = append(uv3.IntermediateTokens, weth.Address)
+	}
+	if usdc, exists := cm.Contract("erc20_USDC"); exists {
+		uv3.IntermediateTokens = append(uv3.IntermediateTokens, usdc.Address)
 	}
+
+	return uv3
 }
 
 // SwapParams contains parameters for a Uniswap V3 swap
@@ -34,15 +67,91 @@ type SwapParams struct {
 	SqrtPriceLimitX96 *big.Int
 }
 
+// MultiHopSwapParams contains parameters for a Uniswap V3 swap through
+// several pools
+type MultiHopSwapParams struct {
+	Route            SwapRoute
+	Recipient        common.Address
+	Deadline         *big.Int
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// SwapRoute is a path through one or more Uniswap V3 pools, where Fees[i] is
+// the fee of the pool between Tokens[i] and Tokens[i+1]
+type SwapRoute struct {
+	Tokens []common.Address
+	Fees   []uint24
+}
+
+// Hops returns the number of pools the route crosses
+func (r SwapRoute) Hops() int {
+	return len(r.Fees)
+}
+
+// String formats the route as token -(fee)-> token
+func (r SwapRoute) String() string {
+	var b strings.Builder
+	for i, token := range r.Tokens {
+		if i > 0 && i <= len(r.Fees) {
+			fmt.Fprintf(&b, " -(%d)-> ", r.Fees[i-1])
+		}
+		b.WriteString(token.Hex())
+	}
+	return b.String()
+}
+
+// EncodePath packs a route into the tokenIn | fee | token | fee | tokenOut
+// format used by exactInput and the quoter
+func EncodePath(route SwapRoute) ([]byte, error) {
+	if len(route.Fees) == 0 || len(route.Tokens) != len(route.Fees)+1 {
+		return nil, fmt.Errorf("invalid route: %d tokens for %d pools", len(route.Tokens), len(route.Fees))
+	}
+
+	path := make([]byte, 0, common.AddressLength+len(route.Fees)*(3+common.AddressLength))
+	path = append(path, route.Tokens[0].Bytes()...)
+	for i, fee := range route.Fees {
+		path = append(path, byte(fee>>16), byte(fee>>8), byte(fee))
+		path = append(path, route.Tokens[i+1].Bytes()...)
+	}
+	return path, nil
+}
+
+// SwapQuote is a quoter result for swapping AmountIn along Route
+type SwapQuote struct {
+	Route       SwapRoute
+	AmountIn    *big.Int
+	AmountOut   *big.Int
+	GasEstimate uint64
+
+	// GasCost is the swap's gas fee in output token units, or nil when it
+	// could not be priced
+	GasCost *big.Int
+}
+
+// NetAmountOut returns the output less the gas cost
+func (q *SwapQuote) NetAmountOut() *big.Int {
+	if q.GasCost == nil {
+		return new(big.Int).Set(q.AmountOut)
+	}
+	return new(big.Int).Sub(q.AmountOut, q.GasCost)
+}
+
 // ExecuteSwap executes a single token swap on Uniswap V3
 func (uv3 *UniswapV3Manager) ExecuteSwap(params SwapParams) (*types.Transaction, error) {
 	log.Printf("Executing Uniswap V3 swap: %s -> %s (amount: %s)",
 		params.TokenIn.Hex(), params.TokenOut.Hex(), params.AmountIn.String())
 
-	tx, err := uv3.ContractManager.TransactContract(
-		"uniswap_v3_router",
-		UniswapV3ExactInputSingle,
-		big.NewInt(0),
+	if err := uv3.approveRouter(params.TokenIn, params.AmountIn); err != nil {
+		return nil, err
+	}
+
+	sqrtPriceLimit := params.SqrtPriceLimitX96
+	if sqrtPriceLimit == nil {
+		sqrtPriceLimit = big.NewInt(0)
+	}
+
+	tx, err := uv3.ContractManager.UniswapV3ExactInputSingle(
 		params.TokenIn,
 		params.TokenOut,
 		params.Fee,
@@ -50,7 +159,7 @@ func (uv3 *UniswapV3Manager) ExecuteSwap(params SwapParams) (*types.Transaction,
 		params.Deadline,
 		params.AmountIn,
 		params.AmountOutMinimum,
-		params.SqrtPriceLimitX96,
+		sqrtPriceLimit,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute swap: %v", err)
@@ -60,27 +169,191 @@ func (uv3 *UniswapV3Manager) ExecuteSwap(params SwapParams) (*types.Transaction,
 	return tx, nil
 }
 
-// GetSwapQuote gets a quote for a Uniswap V3 swap (simulated)
-func (uv3 *UniswapV3Manager) GetSwapQuote(params SwapParams) (*big.Int, error) {
-	// In a real implementation, this would query the Uniswap V3 Quoter contract
-	// For now, we'll simulate a reasonable output amount
+// ExecuteMultiHopSwap executes a swap along a route through one or more
+// pools on Uniswap V3
+func (uv3 *UniswapV3Manager) ExecuteMultiHopSwap(params MultiHopSwapParams) (*types.Transaction, error) {
+	log.Printf("Executing Uniswap V3 multi-hop swap: %s (amount: %s)",
+		params.Route, params.AmountIn.String())
 
-	// Simple simulation: assume 0.3% fee and some slippage
-	feeMultiplier := big.NewFloat(0.997) // 0.3% fee
-	amountInFloat := new(big.Float).SetInt(params.AmountIn)
+	path, err := EncodePath(params.Route)
+	if err != nil {
+		return nil, err
+	}
+	if err := uv3.approveRouter(params.Route.Tokens[0], params.AmountIn); err != nil {
+		return nil, err
+	}
 
-	// Apply fee
-	amountOutFloat := new(big.Float).Mul(amountInFloat, feeMultiplier)
+	tx, err := uv3.ContractManager.UniswapV3ExactInput(
+		path,
+		params.Recipient,
+		params.Deadline,
+		params.AmountIn,
+		params.AmountOutMinimum,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute swap: %v", err)
+	}
 
-	// Convert back to big.Int
-	amountOut := new(big.Int)
-	amountOutFloat.Int(amountOut)
+	log.Printf("Uniswap V3 multi-hop swap transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
 
-	log.Printf("Swap quote: %s %s -> %s %s (estimated)",
+// approveRouter makes sure the router may spend amount of token when the
+// manager has an allowance manager
+func (uv3 *UniswapV3Manager) approveRouter(token common.Address, amount *big.Int) error {
+	if uv3.Allowances == nil {
+		return nil
+	}
+
+	router, exists := uv3.ContractManager.Contract("uniswap_v3_router")
+	if !exists {
+		return fmt.Errorf("contract uniswap_v3_router not found")
+	}
+	if err := uv3.Allowances.Require(token, router.Address, amount); err != nil {
+		return fmt.Errorf("failed to approve swap: %v", err)
+	}
+	return nil
+}
+
+// ExecuteRoute executes a quoted route, accepting at most the risk manager's
+// maximum slippage from the quoted output
+func (uv3 *UniswapV3Manager) ExecuteRoute(quote *SwapQuote, recipient common.Address, deadline *big.Int) (*types.Transaction, error) {
+	minOut := uv3.MinimumAmountOut(quote.AmountOut)
+
+	if quote.Route.Hops() == 1 {
+		return uv3.ExecuteSwap(SwapParams{
+			TokenIn:          quote.Route.Tokens[0],
+			TokenOut:         quote.Route.Tokens[1],
+			Fee:              quote.Route.Fees[0],
+			Recipient:        recipient,
+			Deadline:         deadline,
+			AmountIn:         quote.AmountIn,
+			AmountOutMinimum: minOut,
+		})
+	}
+
+	return uv3.ExecuteMultiHopSwap(MultiHopSwapParams{
+		Route:            quote.Route,
+		Recipient:        recipient,
+		Deadline:         deadline,
+		AmountIn:         quote.AmountIn,
+		AmountOutMinimum: minOut,
+	})
+}
+
+// MinimumAmountOut returns the least output to accept for a quoted amount
+// given the risk manager's maximum slippage
+func (uv3 *UniswapV3Manager) MinimumAmountOut(quoted *big.Int) *big.Int {
+	slippage := 0.0
+	if uv3.RiskManager != nil {
+		slippage = uv3.RiskManager.MaxSlippage
+	}
+	return ApplySlippage(quoted, slippage)
+}
+
+// ApplySlippage reduces amount by a slippage fraction, rounded to the
+// nearest millionth so the result is exact
+func ApplySlippage(amount *big.Int, slippage float64) *big.Int {
+	if slippage <= 0 {
+		return new(big.Int).Set(amount)
+	}
+	if slippage >= 1 {
+		return big.NewInt(0)
+	}
+
+	const scale = 1_000_000
+	keep := scale - int64(math.Round(slippage*scale))
+	minOut := new(big.Int).Mul(amount, big.NewInt(keep))
+	return minOut.Div(minOut, big.NewInt(scale))
+}
+
+// QuoteExactInputSingle quotes swapping amountIn of tokenIn through the
+// tokenIn/tokenOut pool with the given fee
+func (uv3 *UniswapV3Manager) QuoteExactInputSingle(tokenIn, tokenOut common.Address, fee uint24, amountIn *big.Int) (*SwapQuote, error) {
+	params := struct {
+		TokenIn           common.Address
+		TokenOut          common.Address
+		AmountIn          *big.Int
+		Fee               *big.Int
+		SqrtPriceLimitX96 *big.Int
+	}{
+		TokenIn:           tokenIn,
+		TokenOut:          tokenOut,
+		AmountIn:          amountIn,
+		Fee:               big.NewInt(int64(fee)),
+		SqrtPriceLimitX96: big.NewInt(0),
+	}
+
+	result, err := uv3.ContractManager.CallContract("uniswap_v3_quoter", UniswapV3QuoteExactInputSingle, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote pool %d: %v", fee, err)
+	}
+
+	route := SwapRoute{Tokens: []common.Address{tokenIn, tokenOut}, Fees: []uint24{fee}}
+	return newSwapQuote(route, amountIn, result)
+}
+
+// QuoteExactInput quotes swapping amountIn along a route through one or
+// more pools
+func (uv3 *UniswapV3Manager) QuoteExactInput(route SwapRoute, amountIn *big.Int) (*SwapQuote, error) {
+	if route.Hops() == 1 && len(route.Tokens) == 2 {
+		return uv3.QuoteExactInputSingle(route.Tokens[0], route.Tokens[1], route.Fees[0], amountIn)
+	}
+
+	path, err := EncodePath(route)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := uv3.ContractManager.CallContract("uniswap_v3_quoter", UniswapV3QuoteExactInput, path, amountIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote route %s: %v", route, err)
+	}
+	return newSwapQuote(route, amountIn, result)
+}
+
+// newSwapQuote reads the amountOut and gasEstimate results shared by both
+// quoter methods
+func newSwapQuote(route SwapRoute, amountIn *big.Int, result []interface{}) (*SwapQuote, error) {
+	if len(result) != 4 {
+		return nil, fmt.Errorf("unexpected quoter result for route %s", route)
+	}
+	amountOut, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected quoter amountOut type %T", result[0])
+	}
+	gasEstimate, ok := result[3].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected quoter gasEstimate type %T", result[3])
+	}
+
+	return &SwapQuote{
+		Route:       route,
+		AmountIn:    new(big.Int).Set(amountIn),
+		AmountOut:   amountOut,
+		GasEstimate: gasEstimate.Uint64(),
+	}, nil
+}
+
+// GetSwapQuote quotes params.AmountIn through the params.Fee pool, or
+// through every fee tier when Fee is zero, and returns the best output
+func (uv3 *UniswapV3Manager) GetSwapQuote(params SwapParams) (*big.Int, error) {
+	fees := uv3.FeeTiers
+	if params.Fee != 0 {
+		fees = []uint24{params.Fee}
+	}
+
+	quotes, err := uv3.quoteFeeTiers(params.TokenIn, params.TokenOut, fees, params.AmountIn)
+	if err != nil {
+		return nil, err
+	}
+	best := bestQuote(quotes, false)
+
+	log.Printf("Swap quote: %s %s -> %s %s (pool %d)",
 		params.AmountIn.String(), params.TokenIn.Hex(),
-		amountOut.String(), params.TokenOut.Hex())
+		best.AmountOut.String(), params.TokenOut.Hex(), best.Route.Fees[0])
 
-	return amountOut, nil
+	return best.AmountOut, nil
 }
 
 // Common Uniswap V3 fee tiers
@@ -90,31 +363,124 @@ const (
 	FeeTierHigh   uint24 = 10000 // 1%
 )
 
-// GetOptimalFeeTier determines the optimal fee tier for a token pair
-func (uv3 *UniswapV3Manager) GetOptimalFeeTier(tokenA, tokenB common.Address) uint24 {
-	// Common stablecoin pairs use lower fees
-	stablecoins := map[common.Address]bool{
-		common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"): true, // USDC
-		common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"): true, // USDT
-		common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"): true, // DAI
+// GetOptimalFeeTier returns the fee tier of the tokenIn/tokenOut pool with
+// the best output for amountIn after gas
+func (uv3 *UniswapV3Manager) GetOptimalFeeTier(tokenIn, tokenOut common.Address, amountIn *big.Int) (uint24, error) {
+	quotes, err := uv3.quoteFeeTiers(tokenIn, tokenOut, uv3.FeeTiers, amountIn)
+	if err != nil {
+		return 0, err
+	}
+	uv3.priceGas(tokenOut, quotes)
+	return bestQuote(quotes, true).Route.Fees[0], nil
+}
+
+// FindBestRoute quotes amountIn of tokenIn through every direct pool and
+// every two-hop route via the intermediate tokens, and returns the quote
+// with the best output after gas
+func (uv3 *UniswapV3Manager) FindBestRoute(tokenIn, tokenOut common.Address, amountIn *big.Int) (*SwapQuote, error) {
+	var routes []SwapRoute
+	for _, fee := range uv3.FeeTiers {
+		routes = append(routes, SwapRoute{Tokens: []common.Address{tokenIn, tokenOut}, Fees: []uint24{fee}})
+	}
+	for _, via := range uv3.IntermediateTokens {
+		if via == tokenIn || via == tokenOut {
+			continue
+		}
+		for _, first := range uv3.FeeTiers {
+			for _, second := range uv3.FeeTiers {
+				routes = append(routes, SwapRoute{
+					Tokens: []common.Address{tokenIn, via, tokenOut},
+					Fees:   []uint24{first, second},
+				})
+			}
+		}
+	}
+
+	var quotes []*SwapQuote
+	var lastErr error
+	for _, route := range routes {
+		quote, err := uv3.QuoteExactInput(route, amountIn)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		quotes = append(quotes, quote)
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("no Uniswap V3 route from %s to %s: %v", tokenIn.Hex(), tokenOut.Hex(), lastErr)
 	}
 
-	if stablecoins[tokenA] && stablecoins[tokenB] {
-		return FeeTierLow
+	uv3.priceGas(tokenOut, quotes)
+	best := bestQuote(quotes, true)
+
+	log.Printf("Best route for %s: %s -> %s (gas cost %v)",
+		amountIn.String(), best.Route, best.AmountOut.String(), best.GasCost)
+	return best, nil
+}
+
+// quoteFeeTiers quotes the direct pools with the given fees, skipping pools
+// the quoter cannot price
+func (uv3 *UniswapV3Manager) quoteFeeTiers(tokenIn, tokenOut common.Address, fees []uint24, amountIn *big.Int) ([]*SwapQuote, error) {
+	var quotes []*SwapQuote
+	var lastErr error
+	for _, fee := range fees {
+		quote, err := uv3.QuoteExactInputSingle(tokenIn, tokenOut, fee, amountIn)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		quotes = append(quotes, quote)
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("no Uniswap V3 pool from %s to %s: %v", tokenIn.Hex(), tokenOut.Hex(), lastErr)
 	}
+	return quotes, nil
+}
 
-	// Major tokens with high liquidity
-	majorTokens := map[common.Address]bool{
-		common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"): true, // WETH
-		common.HexToAddress("0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599"): true, // WBTC
+// priceGas sets each quote's GasCost from the current gas price, converted
+// to the output token at the best WETH pool's rate. Quotes are left unpriced
+// when either lookup fails.
+func (uv3 *UniswapV3Manager) priceGas(tokenOut common.Address, quotes []*SwapQuote) {
+	gasPrice, err := uv3.ContractManager.Client.SuggestGasPrice(context.Background())
+	if err != nil {
+		log.Printf("Warning: failed to get gas price for swap routing: %v", err)
+		return
 	}
 
-	if majorTokens[tokenA] || majorTokens[tokenB] {
-		return FeeTierMedium
+	// Output token units per ether of gas
+	oneEther := big.NewInt(params.Ether)
+	tokenPerEther := oneEther
+	if tokenOut != uv3.WETH {
+		rates, err := uv3.quoteFeeTiers(uv3.WETH, tokenOut, uv3.FeeTiers, oneEther)
+		if err != nil {
+			log.Printf("Warning: failed to price swap gas in %s: %v", tokenOut.Hex(), err)
+			return
+		}
+		tokenPerEther = bestQuote(rates, false).AmountOut
 	}
 
-	// Default to higher fee for less liquid pairs
-	return FeeTierHigh
+	for _, quote := range quotes {
+		cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(quote.GasEstimate))
+		cost.Mul(cost, tokenPerEther)
+		quote.GasCost = cost.Div(cost, oneEther)
+	}
+}
+
+// bestQuote returns the quote with the highest output, net of gas costs when
+// net is set
+func bestQuote(quotes []*SwapQuote, net bool) *SwapQuote {
+	var best *SwapQuote
+	var bestOut *big.Int
+	for _, quote := range quotes {
+		out := quote.AmountOut
+		if net {
+			out = quote.NetAmountOut()
+		}
+		if best == nil || out.Cmp(bestOut) > 0 {
+			best, bestOut = quote, out
+		}
+	}
+	return best
 }
 
 // CreateDeadline creates a deadline timestamp for transactions
@@ -127,7 +493,7 @@ func CreateDeadline(minutes int64) *big.Int {
 func (uv3 *UniswapV3Manager) MonitorSwap(txHash common.Hash) (*types.Receipt, error) {
 	log.Printf("Monitoring swap transaction: %s", txHash.Hex())
 
-	receipt, err := uv3.ContractManager.WaitForTransaction(txHash)
+	receipt, err := uv3.ContractManager.WaitForTransaction(context.Background(), txHash)
 	if err != nil {
 		return nil, fmt.Errorf("failed to monitor swap: %v", err)
 	}
@@ -140,3 +506,50 @@ func (uv3 *UniswapV3Manager) MonitorSwap(txHash common.Hash) (*types.Receipt, er
 
 	return receipt, nil
 }
+
+// Name identifies Uniswap V3 as a DEXProtocol
+func (uv3 *UniswapV3Manager) Name() string {
+	return "uniswap_v3"
+}
+
+// Quote returns the output of the best route for amountIn of tokenIn
+func (uv3 *UniswapV3Manager) Quote(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	quote, err := uv3.FindBestRoute(tokenIn, tokenOut, amountIn)
+	if err != nil {
+		return nil, err
+	}
+	return quote.AmountOut, nil
+}
+
+// Swap swaps amountIn of tokenIn along the best route for at least
+// minAmountOut of tokenOut, paid to the manager's account
+func (uv3 *UniswapV3Manager) Swap(tokenIn, tokenOut common.Address, amountIn, minAmountOut *big.Int) (*types.Transaction, error) {
+	quote, err := uv3.FindBestRoute(tokenIn, tokenOut, amountIn)
+	if err != nil {
+		return nil, err
+	}
+	if quote.AmountOut.Cmp(minAmountOut) < 0 {
+		return nil, fmt.Errorf("best route returns %s, below the minimum %s", quote.AmountOut.String(), minAmountOut.String())
+	}
+
+	recipient := uv3.ContractManager.Transactor.From
+	deadline := CreateDeadline(10)
+	if quote.Route.Hops() == 1 {
+		return uv3.ExecuteSwap(SwapParams{
+			TokenIn:          tokenIn,
+			TokenOut:         tokenOut,
+			Fee:              quote.Route.Fees[0],
+			Recipient:        recipient,
+			Deadline:         deadline,
+			AmountIn:         amountIn,
+			AmountOutMinimum: minAmountOut,
+		})
+	}
+	return uv3.ExecuteMultiHopSwap(MultiHopSwapParams{
+		Route:            quote.Route,
+		Recipient:        recipient,
+		Deadline:         deadline,
+		AmountIn:         amountIn,
+		AmountOutMinimum: minAmountOut,
+	})
+}
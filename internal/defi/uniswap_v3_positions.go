@@ -0,0 +1,1380 @@
+package defi
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultLPSlippage is the share of the expected deposit or withdrawal
+// amounts an LP transaction may fall short by
+const DefaultLPSlippage = 0.005
+
+// maxUint128 collects everything a position is owed
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// UniswapV3LPManager provides concentrated liquidity on Uniswap V3 through
+// the NonfungiblePositionManager. Besides the position manager's own
+// operations it opens positions over the range its Policy picks, rebalances
+// them when the policy asks, and tracks each position's impermanent loss and
+// fee APR, mirroring them into Portfolio when one is set.
+type UniswapV3LPManager struct {
+	ContractManager *ContractManager
+
+	// PositionManager and Factory name the registered position manager and
+	// pool factory; "uniswap_v3_position_manager" and "uniswap_v3_factory"
+	// by default
+	PositionManager string
+	Factory         string
+
+	// Slippage bounds the minimum amounts of deposits and withdrawals
+	Slippage float64
+
+	// Policy picks the tick range of new positions and decides when to move
+	// them; StaticRange full range by default
+	Policy RangePolicy
+
+	// Swapper, when set, converts the tokens withdrawn by a rebalance into
+	// the ratio the new range needs
+	Swapper DEXProtocol
+
+	// Allowances, when set, approves the position manager for deposits that
+	// exceed the current allowance and waits for the approval first
+	Allowances *AllowanceManager
+
+	// Portfolio, when set, records every tracked position as a
+	// PositionLiquidity entry valued in the pool's token1
+	Portfolio *portfolio.Portfolio
+
+	// WaitTimeout bounds the wait for each transaction the manager sends;
+	// DefaultMonitorTimeout by default
+	WaitTimeout time.Duration
+
+	Clock Clock
+
+	mu      sync.Mutex
+	tracked map[string]*lpTracking
+}
+
+// lpTracking is what the manager remembers of a position to value it
+// against holding its deposits
+type lpTracking struct {
+	tokenID *big.Int
+	pair    string
+
+	// deposit0 and deposit1 are the deposits backing the current liquidity
+	deposit0, deposit1 *big.Int
+
+	// owed0 and owed1 are withdrawn deposits not yet collected; collected
+	// beyond them are fees
+	owed0, owed1 *big.Int
+	fees0, fees1 *big.Int
+
+	openedAt   time.Time
+	entryPrice float64
+	entryValue float64
+}
+
+// NewUniswapV3LPManager creates a new Uniswap V3 liquidity manager
+func NewUniswapV3LPManager(cm *ContractManager) *UniswapV3LPManager {
+	return &UniswapV3LPManager{
+		ContractManager: cm,
+		PositionManager: "uniswap_v3_position_manager",
+		Factory:         "uniswap_v3_factory",
+		Slippage:        DefaultLPSlippage,
+		Policy:          StaticRange{},
+		WaitTimeout:     DefaultMonitorTimeout,
+		Clock:           SystemClock,
+		tracked:         make(map[string]*lpTracking),
+	}
+}
+
+// LPPoolState is a Uniswap V3 pool's tokens and current price
+type LPPoolState struct {
+	Address      common.Address
+	Token0       common.Address
+	Token1       common.Address
+	Fee          uint24
+	TickSpacing  int
+	SqrtPriceX96 *big.Int
+	Tick         int
+}
+
+// LPPosition is a position manager position
+type LPPosition struct {
+	TokenID     *big.Int
+	Token0      common.Address
+	Token1      common.Address
+	Fee         uint24
+	TickLower   int
+	TickUpper   int
+	Liquidity   *big.Int
+	TokensOwed0 *big.Int
+	TokensOwed1 *big.Int
+}
+
+// InRange reports whether the position earns fees at tick
+func (p *LPPosition) InRange(tick int) bool {
+	return p.TickLower <= tick && tick < p.TickUpper
+}
+
+// MintParams contains parameters for minting a position. Token0 must sort
+// before Token1.
+type MintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            uint24
+	TickLower      int
+	TickUpper      int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+// LiquidityIncrease is an IncreaseLiquidity event of the position manager
+type LiquidityIncrease struct {
+	TokenID   *big.Int
+	Liquidity *big.Int
+	Amount0   *big.Int
+	Amount1   *big.Int
+}
+
+// LPPositionStats values a tracked position in its pool's token1, in whole
+// tokens
+type LPPositionStats struct {
+	TokenID *big.Int
+	Pair    string
+	InRange bool
+
+	// Price is token1 per token0 in whole tokens
+	Price float64
+
+	// Amount0 and Amount1 are the position's tokens at the current price
+	// and Fees0 and Fees1 the fees it has earned, collected or not
+	Amount0 *big.Int
+	Amount1 *big.Int
+	Fees0   *big.Int
+	Fees1   *big.Int
+
+	// Value is the position's tokens, HoldValue the deposits had they been
+	// held instead and FeeValue the fees earned
+	Value     float64
+	HoldValue float64
+	FeeValue  float64
+
+	// ImpermanentLoss is Value against HoldValue, zero or negative, and
+	// FeeAPR the fees earned per unit of Value, annualized over the
+	// position's age
+	ImpermanentLoss float64
+	FeeAPR          float64
+
+	OpenedAt time.Time
+}
+
+// Pool returns the state of the tokenA/tokenB pool with the given fee
+func (lm *UniswapV3LPManager) Pool(tokenA, tokenB common.Address, fee uint24) (*LPPoolState, error) {
+	token0, token1 := sortTokens(tokenA, tokenB)
+
+	result, err := lm.ContractManager.CallContract(lm.Factory, UniswapV3GetPool, token0, token1, big.NewInt(int64(fee)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no pool returned")
+	}
+	address, ok := result[0].(common.Address)
+	if !ok {
+		return nil, fmt.Errorf("invalid pool format")
+	}
+	if address == (common.Address{}) {
+		return nil, fmt.Errorf("no Uniswap V3 pool for %s/%s with fee %d: %w", token0.Hex(), token1.Hex(), fee, ErrNoPool)
+	}
+
+	name, err := lm.poolContract(address)
+	if err != nil {
+		return nil, err
+	}
+	result, err = lm.ContractManager.CallContract(name, UniswapV3Slot0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool price: %v", err)
+	}
+	if len(result) < 2 {
+		return nil, fmt.Errorf("no pool price returned")
+	}
+	sqrtPrice, sqrtOK := result[0].(*big.Int)
+	tick, tickOK := result[1].(*big.Int)
+	if !sqrtOK || !tickOK {
+		return nil, fmt.Errorf("invalid pool price format")
+	}
+
+	spacing := poolmath.TickSpacingForFee(int64(fee))
+	if spacing == 0 {
+		return nil, fmt.Errorf("unknown fee tier %d", fee)
+	}
+
+	return &LPPoolState{
+		Address:      address,
+		Token0:       token0,
+		Token1:       token1,
+		Fee:          fee,
+		TickSpacing:  spacing,
+		SqrtPriceX96: sqrtPrice,
+		Tick:         int(tick.Int64()),
+	}, nil
+}
+
+// PoolState reads the tokenA/tokenB pool with the given fee as swap state
+// for quoting, trading the lower token address as token0. Only the in-range
+// liquidity is read, so it is placed over the current tick spacing interval:
+// quotes that would cross it come out short rather than optimistic.
+func (lm *UniswapV3LPManager) PoolState(tokenA, tokenB common.Address, fee uint24) (*poolmath.V3Pool, error) {
+	state, err := lm.Pool(tokenA, tokenB, fee)
+	if err != nil {
+		return nil, err
+	}
+	name, err := lm.poolContract(state.Address)
+	if err != nil {
+		return nil, err
+	}
+	result, err := lm.ContractManager.CallContract(name, UniswapV3Liquidity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool liquidity: %v", err)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no pool liquidity returned")
+	}
+	liquidity, ok := result[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("invalid pool liquidity format")
+	}
+
+	pool, err := poolmath.NewV3Pool(state.SqrtPriceX96, int64(fee), state.TickSpacing)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool state: %v", err)
+	}
+	if liquidity.Sign() > 0 {
+		lower := floorTick(pool.Tick, state.TickSpacing)
+		if _, _, err := pool.AddLiquidity(lower, lower+state.TickSpacing, liquidity); err != nil {
+			return nil, fmt.Errorf("invalid pool state: %v", err)
+		}
+	}
+	return pool, nil
+}
+
+// Position reads a position from the position manager
+func (lm *UniswapV3LPManager) Position(tokenID *big.Int) (*LPPosition, error) {
+	result, err := lm.ContractManager.CallContract(lm.PositionManager, UniswapV3Positions, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position %s: %v", tokenID, err)
+	}
+	if len(result) < 12 {
+		return nil, fmt.Errorf("no position returned")
+	}
+
+	token0, ok0 := result[2].(common.Address)
+	token1, ok1 := result[3].(common.Address)
+	if !ok0 || !ok1 {
+		return nil, fmt.Errorf("invalid position format")
+	}
+	numbers := make([]*big.Int, 0, 6)
+	for _, i := range []int{4, 5, 6, 7, 10, 11} {
+		value, ok := result[i].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("invalid position format")
+		}
+		numbers = append(numbers, value)
+	}
+
+	return &LPPosition{
+		TokenID:     new(big.Int).Set(tokenID),
+		Token0:      token0,
+		Token1:      token1,
+		Fee:         uint24(numbers[0].Uint64()),
+		TickLower:   int(numbers[1].Int64()),
+		TickUpper:   int(numbers[2].Int64()),
+		Liquidity:   numbers[3],
+		TokensOwed0: numbers[4],
+		TokensOwed1: numbers[5],
+	}, nil
+}
+
+// Mint opens a position over [TickLower, TickUpper). The position manager
+// takes as much of the desired amounts as the range needs at the current
+// price.
+func (lm *UniswapV3LPManager) Mint(params MintParams) (*types.Transaction, error) {
+	log.Printf("Minting Uniswap V3 position: %s/%s (fee %d, ticks %d to %d)",
+		params.Token0.Hex(), params.Token1.Hex(), params.Fee, params.TickLower, params.TickUpper)
+
+	if bytes.Compare(params.Token0.Bytes(), params.Token1.Bytes()) >= 0 {
+		return nil, fmt.Errorf("token0 %s must sort before token1 %s", params.Token0.Hex(), params.Token1.Hex())
+	}
+	if params.TickLower >= params.TickUpper {
+		return nil, fmt.Errorf("invalid tick range %d to %d", params.TickLower, params.TickUpper)
+	}
+	if err := lm.approve(params.Token0, params.Amount0Desired); err != nil {
+		return nil, err
+	}
+	if err := lm.approve(params.Token1, params.Amount1Desired); err != nil {
+		return nil, err
+	}
+
+	tx, err := lm.ContractManager.TransactContract(lm.PositionManager, UniswapV3Mint, big.NewInt(0), mintParams{
+		Token0:         params.Token0,
+		Token1:         params.Token1,
+		Fee:            big.NewInt(int64(params.Fee)),
+		TickLower:      big.NewInt(int64(params.TickLower)),
+		TickUpper:      big.NewInt(int64(params.TickUpper)),
+		Amount0Desired: params.Amount0Desired,
+		Amount1Desired: params.Amount1Desired,
+		Amount0Min:     params.Amount0Min,
+		Amount1Min:     params.Amount1Min,
+		Recipient:      params.Recipient,
+		Deadline:       params.Deadline,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint position: %v", err)
+	}
+
+	log.Printf("Uniswap V3 mint transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// IncreaseLiquidity adds up to amount0 and amount1 to a position
+func (lm *UniswapV3LPManager) IncreaseLiquidity(tokenID, amount0, amount1, amount0Min, amount1Min *big.Int) (*types.Transaction, error) {
+	log.Printf("Increasing Uniswap V3 position %s: %s / %s", tokenID, amount0, amount1)
+
+	position, err := lm.Position(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if err := lm.approve(position.Token0, amount0); err != nil {
+		return nil, err
+	}
+	if err := lm.approve(position.Token1, amount1); err != nil {
+		return nil, err
+	}
+
+	tx, err := lm.ContractManager.TransactContract(lm.PositionManager, UniswapV3IncreaseLiquidity, big.NewInt(0), increaseLiquidityParams{
+		TokenId:        tokenID,
+		Amount0Desired: amount0,
+		Amount1Desired: amount1,
+		Amount0Min:     amount0Min,
+		Amount1Min:     amount1Min,
+		Deadline:       CreateDeadline(20),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to increase liquidity: %v", err)
+	}
+
+	log.Printf("Uniswap V3 increase liquidity transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// DecreaseLiquidity removes liquidity from a position. The withdrawn tokens
+// are owed to the position until collected.
+func (lm *UniswapV3LPManager) DecreaseLiquidity(tokenID, liquidity, amount0Min, amount1Min *big.Int) (*types.Transaction, error) {
+	log.Printf("Decreasing Uniswap V3 position %s by %s liquidity", tokenID, liquidity)
+
+	position, err := lm.Position(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if liquidity.Sign() <= 0 || liquidity.Cmp(position.Liquidity) > 0 {
+		return nil, fmt.Errorf("cannot remove %s of position %s's %s liquidity", liquidity, tokenID, position.Liquidity)
+	}
+
+	tx, simulation, err := lm.ContractManager.SimulateAndTransact(lm.PositionManager, UniswapV3DecreaseLiquidity, big.NewInt(0), decreaseLiquidityParams{
+		TokenId:    tokenID,
+		Liquidity:  liquidity,
+		Amount0Min: amount0Min,
+		Amount1Min: amount1Min,
+		Deadline:   CreateDeadline(20),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrease liquidity: %v", err)
+	}
+
+	// The withdrawn share of the deposits stops backing the position
+	if amount0, amount1, err := amountPair(simulation.Outputs); err == nil {
+		lm.withTracking(tokenID, func(tracking *lpTracking) {
+			remaining := new(big.Int).Sub(position.Liquidity, liquidity)
+			tracking.deposit0 = scaleAmount(tracking.deposit0, remaining, position.Liquidity)
+			tracking.deposit1 = scaleAmount(tracking.deposit1, remaining, position.Liquidity)
+			tracking.owed0 = new(big.Int).Add(tracking.owed0, amount0)
+			tracking.owed1 = new(big.Int).Add(tracking.owed1, amount1)
+		})
+	}
+
+	log.Printf("Uniswap V3 decrease liquidity transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// Collect pays out up to amount0Max and amount1Max of what a position is
+// owed, fees and withdrawn liquidity alike, to recipient
+func (lm *UniswapV3LPManager) Collect(tokenID *big.Int, recipient common.Address, amount0Max, amount1Max *big.Int) (*types.Transaction, error) {
+	log.Printf("Collecting Uniswap V3 position %s", tokenID)
+
+	tx, simulation, err := lm.ContractManager.SimulateAndTransact(lm.PositionManager, UniswapV3Collect, big.NewInt(0),
+		collectParams{TokenId: tokenID, Recipient: recipient, Amount0Max: amount0Max, Amount1Max: amount1Max})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect: %v", err)
+	}
+
+	// What exceeds the withdrawn liquidity is fees
+	if amount0, amount1, err := amountPair(simulation.Outputs); err == nil {
+		lm.withTracking(tokenID, func(tracking *lpTracking) {
+			var fee0, fee1 *big.Int
+			tracking.owed0, fee0 = settleOwed(tracking.owed0, amount0)
+			tracking.owed1, fee1 = settleOwed(tracking.owed1, amount1)
+			tracking.fees0 = new(big.Int).Add(tracking.fees0, fee0)
+			tracking.fees1 = new(big.Int).Add(tracking.fees1, fee1)
+		})
+	}
+
+	log.Printf("Uniswap V3 collect transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// Burn destroys a position whose liquidity and owed tokens are all
+// withdrawn
+func (lm *UniswapV3LPManager) Burn(tokenID *big.Int) (*types.Transaction, error) {
+	log.Printf("Burning Uniswap V3 position %s", tokenID)
+
+	tx, err := lm.ContractManager.TransactContract(lm.PositionManager, UniswapV3Burn, big.NewInt(0), tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to burn position: %v", err)
+	}
+
+	log.Printf("Uniswap V3 burn transaction sent: %s", tx.Hash().Hex())
+	return tx, nil
+}
+
+// UncollectedFees returns the fees a position has earned and not yet
+// collected, by simulating a collect of everything it is owed. Liquidity
+// withdrawn and not collected is excluded when the manager tracks the
+// position.
+func (lm *UniswapV3LPManager) UncollectedFees(tokenID *big.Int) (fee0, fee1 *big.Int, err error) {
+	simulation, err := lm.ContractManager.SimulateTransaction(lm.PositionManager, UniswapV3Collect, big.NewInt(0),
+		collectParams{TokenId: tokenID, Recipient: lm.ContractManager.Transactor.From, Amount0Max: maxUint128, Amount1Max: maxUint128})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read fees of position %s: %v", tokenID, err)
+	}
+	fee0, fee1, err = amountPair(simulation.Outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if tracking, exists := lm.tracked[tokenID.String()]; exists {
+		_, fee0 = settleOwed(tracking.owed0, fee0)
+		_, fee1 = settleOwed(tracking.owed1, fee1)
+	}
+	return fee0, fee1, nil
+}
+
+// IncreaseLiquidityEvent finds the position manager's IncreaseLiquidity
+// event in a mint or increase receipt
+func (lm *UniswapV3LPManager) IncreaseLiquidityEvent(receipt *types.Receipt) (*LiquidityIncrease, error) {
+	contract, exists := lm.ContractManager.Contract(lm.PositionManager)
+	if !exists {
+		return nil, fmt.Errorf("contract %s not found", lm.PositionManager)
+	}
+	event, exists := contract.ABI.Events["IncreaseLiquidity"]
+	if !exists {
+		return nil, fmt.Errorf("ABI of %s has no IncreaseLiquidity event", lm.PositionManager)
+	}
+
+	for _, entry := range receipt.Logs {
+		if entry.Address != contract.Address || len(entry.Topics) < 2 || entry.Topics[0] != event.ID {
+			continue
+		}
+		values, err := contract.ABI.Unpack(event.Name, entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack IncreaseLiquidity: %v", err)
+		}
+		liquidity, ok0 := values[0].(*big.Int)
+		amount0, ok1 := values[1].(*big.Int)
+		amount1, ok2 := values[2].(*big.Int)
+		if !ok0 || !ok1 || !ok2 {
+			return nil, fmt.Errorf("invalid IncreaseLiquidity format")
+		}
+		return &LiquidityIncrease{
+			TokenID:   new(big.Int).SetBytes(entry.Topics[1].Bytes()),
+			Liquidity: liquidity,
+			Amount0:   amount0,
+			Amount1:   amount1,
+		}, nil
+	}
+	return nil, fmt.Errorf("no IncreaseLiquidity event in transaction %s", receipt.TxHash.Hex())
+}
+
+// Open deposits amountA of tokenA and amountB of tokenB into a new position
+// over the range the policy picks at the current price, waits for it to be
+// minted and starts tracking it
+func (lm *UniswapV3LPManager) Open(tokenA, tokenB common.Address, fee uint24, amountA, amountB *big.Int) (*LPPosition, error) {
+	pool, err := lm.Pool(tokenA, tokenB, fee)
+	if err != nil {
+		return nil, err
+	}
+	amount0, amount1 := amountA, amountB
+	if pool.Token0 != tokenA {
+		amount0, amount1 = amountB, amountA
+	}
+	return lm.open(pool, amount0, amount1)
+}
+
+// open mints a position in pool over the policy's range
+func (lm *UniswapV3LPManager) open(pool *LPPoolState, amount0, amount1 *big.Int) (*LPPosition, error) {
+	tickLower, tickUpper := lm.policy().Range(pool)
+	position, minted, err := lm.mintRange(pool, tickLower, tickUpper, amount0, amount1)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lm.track(position, pool, minted.Amount0, minted.Amount1); err != nil {
+		log.Printf("Warning: failed to record Uniswap V3 position %s: %v", position.TokenID, err)
+	}
+	log.Printf("Opened Uniswap V3 position %s: %s liquidity over ticks %d to %d",
+		position.TokenID, position.Liquidity, position.TickLower, position.TickUpper)
+	return position, nil
+}
+
+// mintRange mints a position in pool over [tickLower, tickUpper), waits
+// for it and returns it with its IncreaseLiquidity event
+func (lm *UniswapV3LPManager) mintRange(pool *LPPoolState, tickLower, tickUpper int, amount0, amount1 *big.Int) (*LPPosition, *LiquidityIncrease, error) {
+	// The minimums are what the range takes of the desired amounts at the
+	// current price, less slippage
+	sqrtA, err := poolmath.GetSqrtRatioAtTick(tickLower)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqrtB, err := poolmath.GetSqrtRatioAtTick(tickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+	liquidity := poolmath.GetLiquidityForAmounts(pool.SqrtPriceX96, sqrtA, sqrtB, amount0, amount1)
+	if liquidity.Sign() == 0 {
+		return nil, nil, fmt.Errorf("amounts %s / %s add no liquidity over ticks %d to %d", amount0, amount1, tickLower, tickUpper)
+	}
+	expected0, expected1 := poolmath.GetAmountsForLiquidity(pool.SqrtPriceX96, sqrtA, sqrtB, liquidity)
+
+	tx, err := lm.Mint(MintParams{
+		Token0:         pool.Token0,
+		Token1:         pool.Token1,
+		Fee:            pool.Fee,
+		TickLower:      tickLower,
+		TickUpper:      tickUpper,
+		Amount0Desired: amount0,
+		Amount1Desired: amount1,
+		Amount0Min:     ApplySlippage(expected0, lm.Slippage),
+		Amount1Min:     ApplySlippage(expected1, lm.Slippage),
+		Recipient:      lm.ContractManager.Transactor.From,
+		Deadline:       CreateDeadline(20),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := lm.wait(tx, "mint")
+	if err != nil {
+		return nil, nil, err
+	}
+	minted, err := lm.IncreaseLiquidityEvent(receipt)
+	if err != nil {
+		return nil, nil, err
+	}
+	position, err := lm.Position(minted.TokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return position, minted, nil
+}
+
+// Close withdraws a position's liquidity, collects it with the fees to the
+// manager's account, burns the position and stops tracking it. It returns
+// the amounts collected.
+func (lm *UniswapV3LPManager) Close(tokenID *big.Int) (amount0, amount1 *big.Int, err error) {
+	// Mark the position once more so the portfolio closes it at its final
+	// value
+	stats, err := lm.Track(tokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if amount0, amount1, err = lm.withdraw(tokenID); err != nil {
+		return nil, nil, err
+	}
+
+	lm.mu.Lock()
+	delete(lm.tracked, tokenID.String())
+	lm.mu.Unlock()
+	if lm.Portfolio != nil {
+		if _, err := lm.Portfolio.ClosePosition(lpPositionID(tokenID), big.NewFloat(stats.Price)); err != nil {
+			log.Printf("Warning: failed to close Uniswap V3 position %s in the portfolio: %v", tokenID, err)
+		}
+	}
+
+	log.Printf("Closed Uniswap V3 position %s: collected %s / %s", tokenID, amount0, amount1)
+	return amount0, amount1, nil
+}
+
+// withdraw removes all of a position's liquidity, collects it with the
+// fees to the manager's account and burns the position, waiting for each
+// step. It returns the amounts collected.
+func (lm *UniswapV3LPManager) withdraw(tokenID *big.Int) (amount0, amount1 *big.Int, err error) {
+	position, err := lm.Position(tokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if position.Liquidity.Sign() > 0 {
+		pool, err := lm.Pool(position.Token0, position.Token1, position.Fee)
+		if err != nil {
+			return nil, nil, err
+		}
+		min0, min1, err := withdrawalMinimums(pool, position, lm.Slippage)
+		if err != nil {
+			return nil, nil, err
+		}
+		tx, err := lm.DecreaseLiquidity(tokenID, position.Liquidity, min0, min1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := lm.wait(tx, "decrease liquidity"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	account := lm.ContractManager.Transactor.From
+	simulation, err := lm.ContractManager.SimulateTransaction(lm.PositionManager, UniswapV3Collect, big.NewInt(0),
+		collectParams{TokenId: tokenID, Recipient: account, Amount0Max: maxUint128, Amount1Max: maxUint128})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to simulate collect: %v", err)
+	}
+	if amount0, amount1, err = amountPair(simulation.Outputs); err != nil {
+		return nil, nil, err
+	}
+	tx, err := lm.Collect(tokenID, account, maxUint128, maxUint128)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := lm.wait(tx, "collect"); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err = lm.Burn(tokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := lm.wait(tx, "burn"); err != nil {
+		return nil, nil, err
+	}
+	return amount0, amount1, nil
+}
+
+// Rebalance closes a position and reopens its tokens over the range the
+// policy picks at the current price, first swapping them into the ratio the
+// new range needs when the manager has a Swapper
+func (lm *UniswapV3LPManager) Rebalance(tokenID *big.Int) (*LPPosition, error) {
+	position, err := lm.Position(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	amount0, amount1, err := lm.Close(tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := lm.Pool(position.Token0, position.Token1, position.Fee)
+	if err != nil {
+		return nil, err
+	}
+	if lm.Swapper != nil {
+		if amount0, amount1, err = lm.swapToRatio(pool, amount0, amount1); err != nil {
+			return nil, err
+		}
+	}
+
+	rebalanced, err := lm.open(pool, amount0, amount1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen position %s: %v", tokenID, err)
+	}
+	log.Printf("Rebalanced Uniswap V3 position %s into %s", tokenID, rebalanced.TokenID)
+	return rebalanced, nil
+}
+
+// swapToRatio swaps the surplus side of amount0 and amount1 so the amounts
+// match the ratio the policy's range takes at the current price
+func (lm *UniswapV3LPManager) swapToRatio(pool *LPPoolState, amount0, amount1 *big.Int) (*big.Int, *big.Int, error) {
+	tickLower, tickUpper := lm.policy().Range(pool)
+	sqrtA, err := poolmath.GetSqrtRatioAtTick(tickLower)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqrtB, err := poolmath.GetSqrtRatioAtTick(tickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Amounts one unit of liquidity takes, scaled up to keep precision
+	unit := new(big.Int).Lsh(big.NewInt(1), 64)
+	ratio0, ratio1 := poolmath.GetAmountsForLiquidity(pool.SqrtPriceX96, sqrtA, sqrtB, unit)
+
+	// The value of both amounts in token1, split in the range's ratio
+	price := rawPrice(pool.SqrtPriceX96)
+	value := new(big.Float).Add(new(big.Float).Mul(new(big.Float).SetInt(amount0), price), new(big.Float).SetInt(amount1))
+	unitValue := new(big.Float).Add(new(big.Float).Mul(new(big.Float).SetInt(ratio0), price), new(big.Float).SetInt(ratio1))
+	if unitValue.Sign() == 0 {
+		return amount0, amount1, nil
+	}
+	target0, _ := new(big.Float).Quo(new(big.Float).Mul(value, new(big.Float).SetInt(ratio0)), unitValue).Int(nil)
+	target1, _ := new(big.Float).Quo(new(big.Float).Mul(value, new(big.Float).SetInt(ratio1)), unitValue).Int(nil)
+
+	tokenIn, tokenOut := pool.Token0, pool.Token1
+	amountIn := new(big.Int).Sub(amount0, target0)
+	if amountIn.Sign() <= 0 {
+		tokenIn, tokenOut = pool.Token1, pool.Token0
+		amountIn = new(big.Int).Sub(amount1, target1)
+	}
+	if amountIn.Sign() <= 0 {
+		return amount0, amount1, nil
+	}
+
+	quoted, err := lm.Swapper.Quote(tokenIn, tokenOut, amountIn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to quote rebalancing swap: %v", err)
+	}
+	account := lm.ContractManager.Transactor.From
+	before, err := lm.ContractManager.tokenUint(tokenOut, ERC20BalanceOf, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := lm.Swapper.Swap(tokenIn, tokenOut, amountIn, ApplySlippage(quoted, lm.Slippage))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to swap on %s: %v", lm.Swapper.Name(), err)
+	}
+	if _, err := lm.wait(tx, "swap"); err != nil {
+		return nil, nil, err
+	}
+	after, err := lm.ContractManager.tokenUint(tokenOut, ERC20BalanceOf, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	received := new(big.Int).Sub(after, before)
+
+	log.Printf("Swapped %s of %s for %s of %s to rebalance", amountIn, tokenIn.Hex(), received, tokenOut.Hex())
+	if tokenIn == pool.Token0 {
+		return new(big.Int).Sub(amount0, amountIn), new(big.Int).Add(amount1, received), nil
+	}
+	return new(big.Int).Add(amount0, received), new(big.Int).Sub(amount1, amountIn), nil
+}
+
+// Track values a position against holding its deposits and updates its
+// portfolio entry. A position the manager did not open is tracked from now
+// on, taking its current tokens as the deposits.
+func (lm *UniswapV3LPManager) Track(tokenID *big.Int) (*LPPositionStats, error) {
+	position, err := lm.Position(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := lm.Pool(position.Token0, position.Token1, position.Fee)
+	if err != nil {
+		return nil, err
+	}
+	sqrtA, err := poolmath.GetSqrtRatioAtTick(position.TickLower)
+	if err != nil {
+		return nil, err
+	}
+	sqrtB, err := poolmath.GetSqrtRatioAtTick(position.TickUpper)
+	if err != nil {
+		return nil, err
+	}
+	amount0, amount1 := poolmath.GetAmountsForLiquidity(pool.SqrtPriceX96, sqrtA, sqrtB, position.Liquidity)
+
+	lm.mu.Lock()
+	_, exists := lm.tracked[tokenID.String()]
+	lm.mu.Unlock()
+	if !exists {
+		if err := lm.track(position, pool, amount0, amount1); err != nil {
+			log.Printf("Warning: failed to record Uniswap V3 position %s: %v", tokenID, err)
+		}
+	}
+
+	uncollected0, uncollected1, err := lm.UncollectedFees(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	decimals0, err := lm.ContractManager.tokenDecimals(pool.Token0)
+	if err != nil {
+		return nil, err
+	}
+	decimals1, err := lm.ContractManager.tokenDecimals(pool.Token1)
+	if err != nil {
+		return nil, err
+	}
+
+	lm.mu.Lock()
+	tracking := lm.tracked[tokenID.String()]
+	if tracking == nil {
+		lm.mu.Unlock()
+		return nil, fmt.Errorf("position %s is not tracked", tokenID)
+	}
+	stats := &LPPositionStats{
+		TokenID:  new(big.Int).Set(tokenID),
+		Pair:     tracking.pair,
+		InRange:  position.InRange(pool.Tick),
+		Amount0:  amount0,
+		Amount1:  amount1,
+		Fees0:    new(big.Int).Add(tracking.fees0, uncollected0),
+		Fees1:    new(big.Int).Add(tracking.fees1, uncollected1),
+		OpenedAt: tracking.openedAt,
+	}
+	deposit0, deposit1 := tracking.deposit0, tracking.deposit1
+	entryPrice, entryValue := tracking.entryPrice, tracking.entryValue
+	lm.mu.Unlock()
+
+	price := rawPrice(pool.SqrtPriceX96)
+	stats.Price = wholePrice(price, decimals0, decimals1)
+	stats.Value = valueIn1(price, amount0, amount1, decimals1)
+	stats.HoldValue = valueIn1(price, deposit0, deposit1, decimals1)
+	stats.FeeValue = valueIn1(price, stats.Fees0, stats.Fees1, decimals1)
+	if stats.HoldValue > 0 {
+		stats.ImpermanentLoss = stats.Value/stats.HoldValue - 1
+	}
+	elapsed := lm.now().Sub(stats.OpenedAt).Seconds()
+	if stats.Value > 0 && elapsed > 0 {
+		stats.FeeAPR = stats.FeeValue / stats.Value * secondsPerYear / elapsed
+	}
+
+	if lm.Portfolio != nil {
+		err := lm.Portfolio.UpdatePosition(&portfolio.Position{
+			ID:              lpPositionID(tokenID),
+			CurrentPrice:    big.NewFloat(stats.Price),
+			Pnl:             big.NewFloat(stats.Value + stats.FeeValue - entryValue),
+			ImpermanentLoss: stats.ImpermanentLoss,
+			FeeAPR:          stats.FeeAPR,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to update Uniswap V3 position %s in the portfolio: %v", tokenID, err)
+		}
+	}
+
+	log.Printf("Uniswap V3 position %s (%s): value %.4f, IL %.2f%%, fee APR %.2f%% (entry price %.6f, now %.6f)",
+		tokenID, stats.Pair, stats.Value, stats.ImpermanentLoss*100, stats.FeeAPR*100, entryPrice, stats.Price)
+	return stats, nil
+}
+
+// Positions returns the token IDs of the tracked positions, oldest first
+func (lm *UniswapV3LPManager) Positions() []*big.Int {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	ids := make([]*big.Int, 0, len(lm.tracked))
+	for _, tracking := range lm.tracked {
+		ids = append(ids, new(big.Int).Set(tracking.tokenID))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Cmp(ids[j]) < 0
+	})
+	return ids
+}
+
+// Manage tracks every position the manager tracks and rebalances those the
+// policy says to move. It carries on past failures and returns the first.
+func (lm *UniswapV3LPManager) Manage() error {
+	var firstErr error
+	for _, tokenID := range lm.Positions() {
+		if err := lm.manage(tokenID); err != nil {
+			log.Printf("Failed to manage Uniswap V3 position %s: %v", tokenID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// manage tracks one position and rebalances it when the policy says to
+func (lm *UniswapV3LPManager) manage(tokenID *big.Int) error {
+	if _, err := lm.Track(tokenID); err != nil {
+		return err
+	}
+	position, err := lm.Position(tokenID)
+	if err != nil {
+		return err
+	}
+	pool, err := lm.Pool(position.Token0, position.Token1, position.Fee)
+	if err != nil {
+		return err
+	}
+	if !lm.policy().ShouldRebalance(pool, position) {
+		return nil
+	}
+	_, err = lm.Rebalance(tokenID)
+	return err
+}
+
+// ExecuteAction carries out provide_liquidity and remove_liquidity actions,
+// so the manager can serve as a StrategyEngine ActionExecutor. Providing
+// deposits the whole-token "amount_a" of "token_a" and "amount_b" of
+// "token_b" into the pool with the "fee" tier, 3000 by default. Removing
+// closes the position "token_id", or without one every tracked position on
+// the pair.
+func (lm *UniswapV3LPManager) ExecuteAction(strategy *TradingStrategy, action StrategyAction) error {
+	symbolA, _ := action.Parameters["token_a"].(string)
+	symbolB, _ := action.Parameters["token_b"].(string)
+
+	switch action.Type {
+	case ActionProvideLiquidity:
+		tokenA, err := lm.token(symbolA)
+		if err != nil {
+			return err
+		}
+		tokenB, err := lm.token(symbolB)
+		if err != nil {
+			return err
+		}
+		amountA, err := lm.actionAmount(action.Parameters, "amount_a", tokenA)
+		if err != nil {
+			return err
+		}
+		amountB, err := lm.actionAmount(action.Parameters, "amount_b", tokenB)
+		if err != nil {
+			return err
+		}
+		fee := uint24(metadataInt(action.Parameters, "fee", int(FeeTierMedium)))
+		_, err = lm.Open(tokenA, tokenB, fee, amountA, amountB)
+		return err
+
+	case ActionRemoveLiquidity:
+		if id := metadataInt(action.Parameters, "token_id", 0); id > 0 {
+			_, _, err := lm.Close(big.NewInt(int64(id)))
+			return err
+		}
+		tokenA, err := lm.token(symbolA)
+		if err != nil {
+			return err
+		}
+		tokenB, err := lm.token(symbolB)
+		if err != nil {
+			return err
+		}
+		token0, token1 := sortTokens(tokenA, tokenB)
+		closed := 0
+		for _, tokenID := range lm.Positions() {
+			position, err := lm.Position(tokenID)
+			if err != nil {
+				return err
+			}
+			if position.Token0 != token0 || position.Token1 != token1 {
+				continue
+			}
+			if _, _, err := lm.Close(tokenID); err != nil {
+				return err
+			}
+			closed++
+		}
+		if closed == 0 {
+			return fmt.Errorf("no %s/%s liquidity position to remove", symbolA, symbolB)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported action type %s", action.Type)
+	}
+}
+
+// actionAmount converts a whole-token amount parameter to token's base
+// units
+func (lm *UniswapV3LPManager) actionAmount(parameters map[string]interface{}, key string, token common.Address) (*big.Int, error) {
+	decimals, err := lm.ContractManager.tokenDecimals(token)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := actionAmount(map[string]interface{}{"amount": parameters[key]}, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", key, err)
+	}
+	return amount, nil
+}
+
+// token resolves a registered token symbol
+func (lm *UniswapV3LPManager) token(symbol string) (common.Address, error) {
+	return (&Venues{ContractManager: lm.ContractManager}).token(symbol)
+}
+
+// track starts tracking a position with the given deposits and opens its
+// portfolio entry
+func (lm *UniswapV3LPManager) track(position *LPPosition, pool *LPPoolState, deposit0, deposit1 *big.Int) error {
+	decimals0, err := lm.ContractManager.tokenDecimals(pool.Token0)
+	if err != nil {
+		return err
+	}
+	decimals1, err := lm.ContractManager.tokenDecimals(pool.Token1)
+	if err != nil {
+		return err
+	}
+	price := rawPrice(pool.SqrtPriceX96)
+	tracking := &lpTracking{
+		tokenID:    new(big.Int).Set(position.TokenID),
+		pair:       lm.symbol(pool.Token0) + "/" + lm.symbol(pool.Token1),
+		deposit0:   new(big.Int).Set(deposit0),
+		deposit1:   new(big.Int).Set(deposit1),
+		owed0:      big.NewInt(0),
+		owed1:      big.NewInt(0),
+		fees0:      big.NewInt(0),
+		fees1:      big.NewInt(0),
+		openedAt:   lm.now(),
+		entryPrice: wholePrice(price, decimals0, decimals1),
+		entryValue: valueIn1(price, deposit0, deposit1, decimals1),
+	}
+
+	lm.mu.Lock()
+	if lm.tracked == nil {
+		lm.tracked = make(map[string]*lpTracking)
+	}
+	lm.tracked[position.TokenID.String()] = tracking
+	lm.mu.Unlock()
+
+	if lm.Portfolio == nil {
+		return nil
+	}
+	return lm.Portfolio.OpenPosition(&portfolio.Position{
+		ID:           lpPositionID(position.TokenID),
+		Asset:        tracking.pair,
+		Type:         portfolio.PositionLiquidity,
+		Size:         big.NewFloat(tracking.entryValue),
+		EntryPrice:   big.NewFloat(tracking.entryPrice),
+		CurrentPrice: big.NewFloat(tracking.entryPrice),
+		Pnl:          big.NewFloat(0),
+		Leverage:     1,
+	})
+}
+
+// withTracking updates a tracked position's bookkeeping
+func (lm *UniswapV3LPManager) withTracking(tokenID *big.Int, update func(*lpTracking)) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if tracking, exists := lm.tracked[tokenID.String()]; exists {
+		update(tracking)
+	}
+}
+
+// symbol names a token by its registered symbol, or its address
+func (lm *UniswapV3LPManager) symbol(token common.Address) string {
+	if name, err := lm.ContractManager.tokenContractName(token); err == nil {
+		return strings.TrimPrefix(name, "erc20_")
+	}
+	return token.Hex()
+}
+
+// approve makes sure the position manager may spend amount of token when
+// the manager has an allowance manager
+func (lm *UniswapV3LPManager) approve(token common.Address, amount *big.Int) error {
+	if lm.Allowances == nil || amount.Sign() == 0 {
+		return nil
+	}
+
+	manager, exists := lm.ContractManager.Contract(lm.PositionManager)
+	if !exists {
+		return fmt.Errorf("contract %s not found", lm.PositionManager)
+	}
+	if err := lm.Allowances.Require(token, manager.Address, amount); err != nil {
+		return fmt.Errorf("failed to approve deposit: %v", err)
+	}
+	return nil
+}
+
+// wait waits up to WaitTimeout for a transaction to be mined successfully
+func (lm *UniswapV3LPManager) wait(tx *types.Transaction, what string) (*types.Receipt, error) {
+	ctx, cancel := waitContext(lm.WaitTimeout)
+	defer cancel()
+	receipt, err := lm.ContractManager.WaitForTransaction(ctx, tx.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for %s: %v", what, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%s transaction %s failed", what, tx.Hash().Hex())
+	}
+	return receipt, nil
+}
+
+// poolContract registers a pool found through the factory on first use and
+// returns its contract name
+func (lm *UniswapV3LPManager) poolContract(pool common.Address) (string, error) {
+	name := "uniswap_v3_pool_" + strings.ToLower(pool.Hex())
+	if err := lm.ContractManager.RegisterContract(name, pool, "uniswap_v3_pool"); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (lm *UniswapV3LPManager) policy() RangePolicy {
+	if lm.Policy == nil {
+		return StaticRange{}
+	}
+	return lm.Policy
+}
+
+func (lm *UniswapV3LPManager) now() time.Time {
+	if lm.Clock == nil {
+		return time.Now()
+	}
+	return lm.Clock.Now()
+}
+
+// mintParams is the position manager's MintParams tuple; the ABI encoder
+// expects *big.Int for uint24 and int24
+type mintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            *big.Int
+	TickLower      *big.Int
+	TickUpper      *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+// increaseLiquidityParams is the position manager's IncreaseLiquidityParams
+// tuple
+type increaseLiquidityParams struct {
+	TokenId        *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Deadline       *big.Int
+}
+
+// decreaseLiquidityParams is the position manager's DecreaseLiquidityParams
+// tuple
+type decreaseLiquidityParams struct {
+	TokenId    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+	Deadline   *big.Int
+}
+
+// collectParams is the position manager's CollectParams tuple
+type collectParams struct {
+	TokenId    *big.Int
+	Recipient  common.Address
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+// RangePolicy picks the tick range of a new position and decides when an
+// open one should be moved
+type RangePolicy interface {
+	Range(pool *LPPoolState) (tickLower, tickUpper int)
+	ShouldRebalance(pool *LPPoolState, position *LPPosition) bool
+}
+
+// StaticRange places positions Width, a fraction of the price, either side
+// of the current price and never moves them. A zero Width is the full range.
+type StaticRange struct {
+	Width float64
+}
+
+// Range centres the range on the current tick
+func (s StaticRange) Range(pool *LPPoolState) (int, int) {
+	return tickRange(pool.Tick, pool.TickSpacing, math.Log1p(s.Width))
+}
+
+// ShouldRebalance never moves a static position
+func (s StaticRange) ShouldRebalance(pool *LPPoolState, position *LPPosition) bool {
+	return false
+}
+
+// RecenterRange places positions like StaticRange and recenters them on
+// the current price once it leaves their range
+type RecenterRange struct {
+	Width float64
+}
+
+// Range centres the range on the current tick
+func (r RecenterRange) Range(pool *LPPoolState) (int, int) {
+	return tickRange(pool.Tick, pool.TickSpacing, math.Log1p(r.Width))
+}
+
+// ShouldRebalance moves positions that are out of range
+func (r RecenterRange) ShouldRebalance(pool *LPPoolState, position *LPPosition) bool {
+	return !position.InRange(pool.Tick)
+}
+
+// VolatilityRange sizes ranges to cover Multiplier standard deviations of
+// the price over Periods periods, given the per-period Volatility of log
+// returns, so calm markets get tight ranges that earn more fees and
+// volatile ones wide ranges that stay in range. Update Volatility as it
+// changes. Positions are moved when they leave their range, or when their
+// width is more than Tolerance away from the current target.
+type VolatilityRange struct {
+	Volatility float64
+	Periods    float64
+	Multiplier float64
+	Tolerance  float64
+}
+
+// Range centres the volatility-scaled range on the current tick
+func (v VolatilityRange) Range(pool *LPPoolState) (int, int) {
+	return tickRange(pool.Tick, pool.TickSpacing, v.logWidth())
+}
+
+// ShouldRebalance moves positions out of range or whose width has drifted
+// from the target
+func (v VolatilityRange) ShouldRebalance(pool *LPPoolState, position *LPPosition) bool {
+	if !position.InRange(pool.Tick) {
+		return true
+	}
+	if v.Tolerance <= 0 {
+		return false
+	}
+	lower, upper := v.Range(pool)
+	target := float64(upper - lower)
+	width := float64(position.TickUpper - position.TickLower)
+	return math.Abs(width-target)/target > v.Tolerance
+}
+
+// logWidth is the half width of the range in log price
+func (v VolatilityRange) logWidth() float64 {
+	periods := v.Periods
+	if periods <= 0 {
+		periods = 1
+	}
+	multiplier := v.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	return multiplier * v.Volatility * math.Sqrt(periods)
+}
+
+// tickRange returns the ticks, aligned to spacing, logWidth either side of
+// tick in log price, or the full range when logWidth is not positive
+func tickRange(tick, spacing int, logWidth float64) (int, int) {
+	if spacing <= 0 {
+		spacing = 1
+	}
+	maxTick := poolmath.MaxTick / spacing * spacing
+	minTick := -maxTick
+	if logWidth <= 0 || math.IsNaN(logWidth) || math.IsInf(logWidth, 0) {
+		return minTick, maxTick
+	}
+
+	half := int(math.Ceil(logWidth / math.Log(1.0001)))
+	lower := floorTick(tick-half, spacing)
+	upper := floorTick(tick+half, spacing) + spacing
+	if lower < minTick {
+		lower = minTick
+	}
+	if upper > maxTick {
+		upper = maxTick
+	}
+	return lower, upper
+}
+
+// floorTick rounds tick down to a multiple of spacing
+func floorTick(tick, spacing int) int {
+	aligned := tick / spacing * spacing
+	if tick < 0 && tick%spacing != 0 {
+		aligned -= spacing
+	}
+	return aligned
+}
+
+// withdrawalMinimums returns what withdrawing all of a position's liquidity
+// should return at the current price, less slippage
+func withdrawalMinimums(pool *LPPoolState, position *LPPosition, slippage float64) (*big.Int, *big.Int, error) {
+	sqrtA, err := poolmath.GetSqrtRatioAtTick(position.TickLower)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqrtB, err := poolmath.GetSqrtRatioAtTick(position.TickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+	amount0, amount1 := poolmath.GetAmountsForLiquidity(pool.SqrtPriceX96, sqrtA, sqrtB, position.Liquidity)
+	return ApplySlippage(amount0, slippage), ApplySlippage(amount1, slippage), nil
+}
+
+// sortTokens orders a token pair as Uniswap does
+func sortTokens(tokenA, tokenB common.Address) (common.Address, common.Address) {
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) > 0 {
+		return tokenB, tokenA
+	}
+	return tokenA, tokenB
+}
+
+// amountPair reads the (amount0, amount1) outputs of decreaseLiquidity and
+// collect
+func amountPair(outputs []interface{}) (*big.Int, *big.Int, error) {
+	if len(outputs) < 2 {
+		return nil, nil, fmt.Errorf("no amounts returned")
+	}
+	amount0, ok0 := outputs[0].(*big.Int)
+	amount1, ok1 := outputs[1].(*big.Int)
+	if !ok0 || !ok1 {
+		return nil, nil, fmt.Errorf("invalid amounts format")
+	}
+	return amount0, amount1, nil
+}
+
+// settleOwed splits a collected amount into the withdrawn liquidity still
+// owed and the fees beyond it, returning what remains owed and the fees
+func settleOwed(owed, collected *big.Int) (*big.Int, *big.Int) {
+	if collected.Cmp(owed) <= 0 {
+		return new(big.Int).Sub(owed, collected), big.NewInt(0)
+	}
+	return big.NewInt(0), new(big.Int).Sub(collected, owed)
+}
+
+// scaleAmount returns amount * numerator / denominator
+func scaleAmount(amount, numerator, denominator *big.Int) *big.Int {
+	if denominator.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	scaled := new(big.Int).Mul(amount, numerator)
+	return scaled.Div(scaled, denominator)
+}
+
+// rawPrice is the price of a token0 base unit in token1 base units
+func rawPrice(sqrtPriceX96 *big.Int) *big.Float {
+	sqrtPrice := new(big.Float).Quo(new(big.Float).SetInt(sqrtPriceX96), new(big.Float).SetInt(poolmath.Q96))
+	return sqrtPrice.Mul(sqrtPrice, sqrtPrice)
+}
+
+// wholePrice converts a raw price to token1 per whole token0
+func wholePrice(price *big.Float, decimals0, decimals1 int) float64 {
+	whole, _ := price.Float64()
+	return whole * math.Pow10(decimals0-decimals1)
+}
+
+// valueIn1 values amounts of token0 and token1 in whole token1
+func valueIn1(price *big.Float, amount0, amount1 *big.Int, decimals1 int) float64 {
+	value := new(big.Float).Mul(new(big.Float).SetInt(amount0), price)
+	value.Add(value, new(big.Float).SetInt(amount1))
+	whole, _ := value.Float64()
+	return whole / math.Pow10(decimals1)
+}
+
+// lpPositionID is a position's ID in the portfolio
+func lpPositionID(tokenID *big.Int) string {
+	return "uniswap-v3-" + tokenID.String()
+}
@@ -0,0 +1,247 @@
+package defi
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/poolmath"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/portfolio"
+)
+
+// newLPManager returns a liquidity manager for the chain's account with
+// 1,000 of each token to deposit, a simulated clock and a portfolio, and
+// autoCommit's pause for later fixture changes
+func newLPManager(t *testing.T, chain *defitest.Chain, cm *ContractManager) (*UniswapV3LPManager, *SimulatedClock, func(func())) {
+	t.Helper()
+
+	logger, err := logging.NewLogger(&config.LoggingConfig{Level: "error", Format: "console", Output: "stdout"})
+	require.NoError(t, err)
+
+	chain.Mint(chain.WETH, chain.Account, defitest.Ether(1_000))
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+	pause := autoCommit(t, chain, cm)
+
+	clock := NewSimulatedClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	lm := NewUniswapV3LPManager(cm)
+	lm.Allowances = NewAllowanceManager(cm)
+	lm.Clock = clock
+	lm.Portfolio = portfolio.NewPortfolio("lp", "LP", portfolio.RiskProfile{}, logger, nil)
+	return lm, clock, pause
+}
+
+func TestUniswapV3LPPositionLifecycle(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	lm, clock, pause := newLPManager(t, chain, cm)
+
+	pool, err := lm.Pool(chain.USDC, chain.WETH, FeeTierMedium)
+	require.NoError(t, err)
+	assert.Equal(t, chain.V3Pool, pool.Address)
+	assert.Equal(t, 60, pool.TickSpacing)
+	assert.Zero(t, pool.Tick)
+	_, err = lm.Pool(chain.USDC, chain.WETH, FeeTierLow)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Uniswap V3 pool")
+
+	// The default policy deposits over the full range
+	position, err := lm.Open(chain.WETH, chain.USDC, FeeTierMedium, defitest.Ether(1_000), defitest.Ether(1_000))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), position.TokenID.Int64())
+	assert.Equal(t, -887220, position.TickLower)
+	assert.Equal(t, 887220, position.TickUpper)
+	assert.Equal(t, defitest.Ether(1_000).String(), position.Liquidity.String())
+	assert.Zero(t, chain.BalanceOf(chain.WETH, chain.Account).Sign())
+	assert.Equal(t, []*big.Int{big.NewInt(1)}, lm.Positions())
+
+	stats, err := lm.Track(position.TokenID)
+	require.NoError(t, err)
+	assert.True(t, stats.InRange)
+	assert.InDelta(t, 2_000, stats.Value, 1e-6)
+	assert.InDelta(t, 0, stats.ImpermanentLoss, 1e-9)
+	assert.Zero(t, stats.FeeAPR)
+
+	// After 30 days of fees token0 is worth four times as much: the position
+	// holds 500 token0 and 2,000 token1 against 1,000 of each held
+	pause(func() {
+		chain.AccrueLPFees(position.TokenID, defitest.Ether(10), defitest.Ether(10))
+		chain.SetV3Price(chain.V3Pool, new(big.Int).Lsh(poolmath.Q96, 1))
+	})
+	clock.Advance(30 * 24 * time.Hour)
+
+	stats, err = lm.Track(position.TokenID)
+	require.NoError(t, err)
+	assert.InDelta(t, 4.0, stats.Price, 1e-9)
+	assert.InDelta(t, 4_000, stats.Value, 1e-6)
+	assert.InDelta(t, 5_000, stats.HoldValue, 1e-6)
+	assert.InDelta(t, 50, stats.FeeValue, 1e-9)
+	assert.InDelta(t, -0.2, stats.ImpermanentLoss, 1e-9)
+	assert.InDelta(t, 50.0/4_000*365/30, stats.FeeAPR, 1e-9)
+
+	entry := lm.Portfolio.Positions["uniswap-v3-1"]
+	require.NotNil(t, entry)
+	assert.Equal(t, portfolio.PositionLiquidity, entry.Type)
+	assert.Equal(t, stats.Pair, entry.Asset)
+	assert.Equal(t, 0, entry.Size.Cmp(big.NewFloat(2_000)))
+	assert.InDelta(t, -0.2, entry.ImpermanentLoss, 1e-9)
+	assert.InDelta(t, stats.FeeAPR, entry.FeeAPR, 1e-12)
+	pnl, _ := entry.Pnl.Float64()
+	assert.InDelta(t, 2_050, pnl, 1e-6)
+
+	// Back at the entry price the position closes with its deposits and fees
+	pause(func() { chain.SetV3Price(chain.V3Pool, poolmath.Q96) })
+	amount0, amount1, err := lm.Close(position.TokenID)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(1_010).String(), amount0.String())
+	assert.Equal(t, defitest.Ether(1_010).String(), amount1.String())
+	assert.Equal(t, defitest.Ether(1_010).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+	assert.Empty(t, lm.Positions())
+
+	assert.Equal(t, portfolio.PositionClosed, entry.Status)
+	pnl, _ = entry.Pnl.Float64()
+	assert.InDelta(t, 20, pnl, 1e-6)
+	assert.InDelta(t, 1, entry.PnlPercent, 1e-9)
+
+	_, err = lm.Position(position.TokenID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid token ID")
+}
+
+func TestUniswapV3LPRecentersOutOfRangePosition(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	lm, _, pause := newLPManager(t, chain, cm)
+	lm.Policy = RecenterRange{Width: 0.1}
+
+	uv3 := NewUniswapV3Manager(cm)
+	uv3.Allowances = lm.Allowances
+	lm.Swapper = uv3
+
+	position, err := lm.Open(chain.WETH, chain.USDC, FeeTierMedium, defitest.Ether(1_000), defitest.Ether(1_000))
+	require.NoError(t, err)
+	assert.Equal(t, -960, position.TickLower)
+	assert.Equal(t, 960, position.TickUpper)
+
+	require.NoError(t, lm.Manage())
+	assert.Equal(t, []*big.Int{position.TokenID}, lm.Positions())
+
+	// token0 rises 21%, above the range, and the router swaps at that price
+	pool, err := lm.Pool(chain.WETH, chain.USDC, FeeTierMedium)
+	require.NoError(t, err)
+	pause(func() {
+		chain.SetRate(pool.Token0, pool.Token1, big.NewInt(1_210_000_000_000_000_000))
+		chain.SetRate(pool.Token1, pool.Token0, big.NewInt(826_446_280_991_735_537))
+		chain.SetV3Price(chain.V3Pool, new(big.Int).Div(new(big.Int).Mul(poolmath.Q96, big.NewInt(11)), big.NewInt(10)))
+	})
+
+	require.NoError(t, lm.Manage())
+	ids := lm.Positions()
+	require.Len(t, ids, 1)
+	assert.Equal(t, int64(2), ids[0].Int64())
+
+	pool, err = lm.Pool(chain.WETH, chain.USDC, FeeTierMedium)
+	require.NoError(t, err)
+	rebalanced, err := lm.Position(ids[0])
+	require.NoError(t, err)
+	assert.True(t, rebalanced.InRange(pool.Tick))
+	assert.Less(t, rebalanced.TickLower, pool.Tick-800)
+	assert.Greater(t, rebalanced.TickUpper, pool.Tick+800)
+
+	// Surplus token0 was swapped so both sides carry about equal value
+	account0 := chain.BalanceOf(pool.Token0, chain.Account)
+	account1 := chain.BalanceOf(pool.Token1, chain.Account)
+	assert.Zero(t, account0.Sign())
+	assert.Zero(t, account1.Sign())
+	assert.Equal(t, portfolio.PositionClosed, lm.Portfolio.Positions["uniswap-v3-1"].Status)
+	assert.Equal(t, portfolio.PositionOpen, lm.Portfolio.Positions["uniswap-v3-2"].Status)
+}
+
+func TestRangePolicies(t *testing.T) {
+	pool := &LPPoolState{Tick: 1_000, TickSpacing: 60}
+
+	lower, upper := StaticRange{}.Range(pool)
+	assert.Equal(t, -887220, lower)
+	assert.Equal(t, 887220, upper)
+
+	// 10% either side is 954 ticks, widened to the spacing
+	lower, upper = StaticRange{Width: 0.1}.Range(pool)
+	assert.Equal(t, 0, lower)
+	assert.Equal(t, 1_980, upper)
+	assert.Zero(t, lower%60)
+
+	inside := &LPPosition{TickLower: lower, TickUpper: upper}
+	outside := &LPPosition{TickLower: -1_200, TickUpper: 600}
+	assert.False(t, StaticRange{Width: 0.1}.ShouldRebalance(pool, outside))
+	assert.False(t, RecenterRange{Width: 0.1}.ShouldRebalance(pool, inside))
+	assert.True(t, RecenterRange{Width: 0.1}.ShouldRebalance(pool, outside))
+
+	// Two standard deviations of 2% daily moves over a week
+	volatile := VolatilityRange{Volatility: 0.02, Periods: 7, Multiplier: 2, Tolerance: 0.25}
+	lower, upper = volatile.Range(pool)
+	half := math.Log1p(math.Expm1(2*0.02*math.Sqrt(7))) / math.Log(1.0001)
+	assert.InDelta(t, 2*half, float64(upper-lower), 120)
+	assert.LessOrEqual(t, lower, 1_000-int(half))
+	assert.GreaterOrEqual(t, upper, 1_000+int(half))
+
+	position := &LPPosition{TickLower: lower, TickUpper: upper}
+	assert.False(t, volatile.ShouldRebalance(pool, position))
+
+	// Volatility halving narrows the target past the tolerance
+	calmer := volatile
+	calmer.Volatility = 0.01
+	assert.True(t, calmer.ShouldRebalance(pool, position))
+	calmer.Tolerance = 0
+	assert.False(t, calmer.ShouldRebalance(pool, position))
+	assert.True(t, calmer.ShouldRebalance(pool, outside))
+}
+
+func TestUniswapV3LPExecuteAction(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	lm, _, _ := newLPManager(t, chain, cm)
+	engine := NewStrategyEngine()
+	engine.Liquidity = lm
+
+	engine.executeAction(StrategyAction{
+		ID:   "provide",
+		Type: ActionProvideLiquidity,
+		Parameters: map[string]interface{}{
+			"token_a":  "WETH",
+			"token_b":  "USDC",
+			"amount_a": 250.0,
+			"amount_b": 250.0,
+		},
+	})
+	require.Len(t, lm.Positions(), 1)
+	position, err := lm.Position(lm.Positions()[0])
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(250).String(), position.Liquidity.String())
+	assert.Equal(t, defitest.Ether(750).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+
+	venues := &Venues{ContractManager: cm}
+	err = venues.ExecuteAction(&TradingStrategy{}, StrategyAction{Type: ActionRemoveLiquidity})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no liquidity manager")
+
+	venues.Liquidity = lm
+	require.NoError(t, venues.ExecuteAction(&TradingStrategy{}, StrategyAction{
+		Type:       ActionRemoveLiquidity,
+		Parameters: map[string]interface{}{"token_a": "USDC", "token_b": "WETH"},
+	}))
+	assert.Empty(t, lm.Positions())
+	assert.Equal(t, defitest.Ether(1_000).String(), chain.BalanceOf(chain.WETH, chain.Account).String())
+
+	err = venues.ExecuteAction(&TradingStrategy{}, StrategyAction{
+		Type:       ActionRemoveLiquidity,
+		Parameters: map[string]interface{}{"token_a": "USDC", "token_b": "WETH"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no USDC/WETH liquidity position")
+}
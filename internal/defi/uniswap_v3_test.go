@@ -0,0 +1,175 @@
+package defi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+func TestEncodePath(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+
+	path, err := EncodePath(SwapRoute{Tokens: []common.Address{usdc, weth, dai}, Fees: []uint24{FeeTierLow, FeeTierMedium}})
+	require.NoError(t, err)
+	want := "a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" + "0001f4" +
+		"c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2" + "000bb8" +
+		"6b175474e89094c44da98b954eedeac495271d0f"
+	assert.Equal(t, want, common.Bytes2Hex(path))
+
+	_, err = EncodePath(SwapRoute{Tokens: []common.Address{usdc, weth}, Fees: []uint24{FeeTierLow, FeeTierHigh}})
+	assert.Error(t, err)
+	_, err = EncodePath(SwapRoute{Tokens: []common.Address{usdc}})
+	assert.Error(t, err)
+}
+
+func TestApplySlippage(t *testing.T) {
+	amount := big.NewInt(1_000)
+	assert.Equal(t, "995", ApplySlippage(amount, 0.005).String())
+	assert.Equal(t, "1000", ApplySlippage(amount, 0).String())
+	assert.Equal(t, "0", ApplySlippage(amount, 1).String())
+
+	uv3 := &UniswapV3Manager{RiskManager: &RiskManager{MaxSlippage: 0.01}}
+	assert.Equal(t, defitest.Ether(99).String(), uv3.MinimumAmountOut(defitest.Ether(100)).String())
+}
+
+func TestUniswapV3QuotesAcrossFeeTiers(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	uv3 := NewUniswapV3Manager(cm)
+
+	// The 0.05% pool trades at a worse price than the other tiers
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+	chain.SetPoolRate(chain.USDC, chain.WETH, uint32(FeeTierLow), big.NewInt(4e14))
+
+	amountIn := defitest.Ether(100)
+	params := SwapParams{TokenIn: chain.USDC, TokenOut: chain.WETH, AmountIn: amountIn}
+	quote, err := uv3.GetSwapQuote(params)
+	require.NoError(t, err)
+	assert.Equal(t, "49850000000000000", quote.String()) // 0.05 WETH less the 0.3% fee
+
+	params.Fee = FeeTierLow
+	quote, err = uv3.GetSwapQuote(params)
+	require.NoError(t, err)
+	assert.Equal(t, "39980000000000000", quote.String())
+
+	single, err := uv3.QuoteExactInputSingle(chain.USDC, chain.WETH, FeeTierHigh, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, "49500000000000000", single.AmountOut.String())
+	assert.Equal(t, uint64(defitest.QuoteGasPerHop), single.GasEstimate)
+
+	fee, err := uv3.GetOptimalFeeTier(chain.USDC, chain.WETH, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, FeeTierMedium, fee)
+
+	chain.SetPoolRate(chain.USDC, chain.WETH, uint32(FeeTierLow), big.NewInt(5e14))
+	fee, err = uv3.GetOptimalFeeTier(chain.USDC, chain.WETH, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, FeeTierLow, fee)
+}
+
+func TestUniswapV3MultiHopRouting(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	uv3 := NewUniswapV3Manager(cm)
+
+	dai := chain.Deploy(defitest.ERC20Code())
+	chain.Mint(dai, chain.Router, defitest.Liquidity)
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(1_000))
+
+	// USDC buys twice as much DAI through WETH as it does directly
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+	chain.SetRate(chain.WETH, dai, defitest.Ether(2_000))
+	chain.SetRate(chain.USDC, dai, big.NewInt(5e17))
+
+	amountIn := defitest.Ether(100)
+	best, err := uv3.FindBestRoute(chain.USDC, dai, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, SwapRoute{
+		Tokens: []common.Address{chain.USDC, chain.WETH, dai},
+		Fees:   []uint24{FeeTierLow, FeeTierLow},
+	}, best.Route)
+	assert.Equal(t, "99900025000000000000", best.AmountOut.String())
+	assert.Equal(t, uint64(2*defitest.QuoteGasPerHop), best.GasEstimate)
+	require.NotNil(t, best.GasCost)
+	assert.Positive(t, best.GasCost.Sign())
+
+	tx, err := cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, amountIn)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+
+	tx, err = uv3.ExecuteRoute(best, chain.Account, CreateDeadline(10))
+	require.NoError(t, err)
+	method, err := cm.Contracts["uniswap_v3_router"].ABI.MethodById(tx.Data()[:4])
+	require.NoError(t, err)
+	assert.Equal(t, UniswapV3ExactInput, method.Name)
+	assert.Equal(t, types.ReceiptStatusSuccessful, mine(t, chain, cm, tx).Status)
+
+	assert.Equal(t, best.AmountOut.String(), chain.BalanceOf(dai, chain.Account).String())
+	assert.Equal(t, defitest.Ether(900).String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+
+	// The router enforces the minimum output along the whole path
+	tx, err = cm.TransactContract("erc20_USDC", ERC20Approve, big.NewInt(0), chain.Router, amountIn)
+	require.NoError(t, err)
+	mine(t, chain, cm, tx)
+	_, err = uv3.ExecuteMultiHopSwap(MultiHopSwapParams{
+		Route:            best.Route,
+		Recipient:        chain.Account,
+		Deadline:         CreateDeadline(10),
+		AmountIn:         amountIn,
+		AmountOutMinimum: new(big.Int).Add(best.AmountOut, big.NewInt(1)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Too little received")
+}
+
+func TestUniswapV3RouteSelectionAccountsForGas(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	uv3 := NewUniswapV3Manager(cm)
+
+	dai := chain.Deploy(defitest.ERC20Code())
+	chain.Mint(dai, chain.Router, defitest.Liquidity)
+
+	// Routing through WETH pays 0.1% more but costs a second pool's gas
+	chain.SetRate(chain.USDC, chain.WETH, big.NewInt(5e14))
+	chain.SetRate(chain.WETH, dai, defitest.Ether(2_000))
+	chain.SetPoolRate(chain.USDC, dai, uint32(FeeTierLow), big.NewInt(998_500_000_000_000_000))
+
+	amountIn := defitest.Ether(1)
+	best, err := uv3.FindBestRoute(chain.USDC, dai, amountIn)
+	require.NoError(t, err)
+	assert.Equal(t, SwapRoute{
+		Tokens: []common.Address{chain.USDC, dai},
+		Fees:   []uint24{FeeTierLow},
+	}, best.Route)
+
+	multiHop, err := uv3.QuoteExactInput(SwapRoute{
+		Tokens: []common.Address{chain.USDC, chain.WETH, dai},
+		Fees:   []uint24{FeeTierLow, FeeTierLow},
+	}, amountIn)
+	require.NoError(t, err)
+	assert.Greater(t, multiHop.AmountOut.Cmp(best.AmountOut), 0)
+}
+
+func TestUniswapV3QuoterUnavailable(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	require.NoError(t, cm.SetContractAddress("uniswap_v3_quoter", chain.Account))
+	uv3 := NewUniswapV3Manager(cm)
+
+	_, err := uv3.GetSwapQuote(SwapParams{TokenIn: chain.USDC, TokenOut: chain.WETH, AmountIn: defitest.Ether(1)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Uniswap V3 pool")
+
+	_, err = uv3.FindBestRoute(chain.USDC, chain.WETH, defitest.Ether(1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Uniswap V3 route")
+}
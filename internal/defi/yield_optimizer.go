@@ -0,0 +1,527 @@
+package defi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Yield optimizer defaults
+const (
+	// DefaultYieldHorizon is how long a migration has to pay for itself
+	DefaultYieldHorizon = 30 * 24 * time.Hour
+	// DefaultHarvestInterval is how often rewards are claimed and compounded
+	DefaultHarvestInterval = 24 * time.Hour
+	// DefaultMigrationGas is the gas of a withdrawal and a supply
+	DefaultMigrationGas = 500_000
+	// DefaultYieldSlippage is the share of a migrated balance assumed lost
+	// to moving it, and the tolerance on reward swaps
+	DefaultYieldSlippage = 0.001
+)
+
+// RewardClaimer is a lending venue paying incentive rewards on supplies
+type RewardClaimer interface {
+	// ClaimRewards claims the rewards earned by supplying asset to the
+	// manager's account, once the claim has settled, and returns the reward
+	// token and the amount claimed
+	ClaimRewards(asset common.Address) (common.Address, *big.Int, error)
+}
+
+// MigrationPlan weighs moving a supply to the venue paying the most. Gain is
+// the extra interest the move earns over the horizon and Cost the gas and
+// slippage it loses, both in asset base units.
+type MigrationPlan struct {
+	From     LendingProtocol
+	To       LendingProtocol
+	FromRate float64
+	ToRate   float64
+	Amount   *big.Int
+	Gain     *big.Int
+	Cost     *big.Int
+}
+
+// Worthwhile reports whether the move pays for itself within the horizon
+func (p *MigrationPlan) Worthwhile() bool {
+	return p.From != p.To && p.Gain.Cmp(p.Cost) > 0
+}
+
+func (p *MigrationPlan) String() string {
+	return fmt.Sprintf("%s %.3f%% -> %s %.3f%%", p.From.Name(), p.FromRate*100, p.To.Name(), p.ToRate*100)
+}
+
+// YieldOptimizer keeps an asset supplied to the lending venue paying the
+// most. It polls the venues' supply APYs and migrates when the extra
+// interest over Horizon beats the gas and slippage of moving, harvests and
+// compounds rewards every HarvestInterval, and records the realized APY net
+// of gas into Performance.
+type YieldOptimizer struct {
+	Venues *Venues
+	Asset  common.Address
+
+	// Horizon is how long a migration has to pay for itself
+	Horizon time.Duration
+
+	// Slippage is the share of a migrated balance assumed lost to moving it,
+	// and the tolerance on reward swaps
+	Slippage float64
+
+	// MigrationGas is the gas a migration is priced at, and GasPrice, when
+	// set, the price used instead of the backend's suggestion
+	MigrationGas uint64
+	GasPrice     *big.Int
+
+	HarvestInterval time.Duration
+
+	// WaitTimeout bounds the wait for each transaction the optimizer sends;
+	// DefaultMonitorTimeout by default
+	WaitTimeout time.Duration
+
+	// Performance receives the realized return since the first deposit as
+	// TotalReturn and its annualized APY as AnnualizedReturn
+	Performance *StrategyPerformance
+
+	Clock Clock
+
+	mu          sync.Mutex
+	venue       LendingProtocol
+	principal   *big.Int
+	gasSpent    *big.Int
+	startedAt   time.Time
+	lastHarvest time.Time
+
+	// idle is what a migration withdrew but failed to supply to venue; the
+	// next rebalance supplies it first
+	idle *big.Int
+}
+
+// NewYieldOptimizer creates an optimizer supplying asset to the venues'
+// lending markets
+func NewYieldOptimizer(venues *Venues, asset common.Address) *YieldOptimizer {
+	return &YieldOptimizer{
+		Venues:          venues,
+		Asset:           asset,
+		Horizon:         DefaultYieldHorizon,
+		Slippage:        DefaultYieldSlippage,
+		MigrationGas:    DefaultMigrationGas,
+		HarvestInterval: DefaultHarvestInterval,
+		WaitTimeout:     DefaultMonitorTimeout,
+		Performance:     &StrategyPerformance{},
+		Clock:           SystemClock,
+		principal:       new(big.Int),
+		idle:            new(big.Int),
+		gasSpent:        new(big.Int),
+	}
+}
+
+// Rates polls every lending venue's supply rate for the asset, best first.
+// Venues that cannot quote the asset are skipped.
+func (yo *YieldOptimizer) Rates() ([]LendingRate, error) {
+	var rates []LendingRate
+	var lastErr error
+	for _, venue := range yo.Venues.Lending {
+		rate, err := venue.SupplyRate(yo.Asset)
+		if err != nil {
+			log.Printf("Skipping %s supply rate for %s: %v", venue.Name(), yo.Asset.Hex(), err)
+			lastErr = err
+			continue
+		}
+		rates = append(rates, LendingRate{Venue: venue, Rate: rate})
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("no supply rate for %s: %v", yo.Asset.Hex(), lastErr)
+	}
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Rate > rates[j].Rate })
+	return rates, nil
+}
+
+// Current returns the venue holding the supply and its balance, or a nil
+// venue when nothing is supplied. Until the optimizer has deposited it
+// adopts the venue holding the largest supply, so funds supplied before it
+// started count as its principal.
+func (yo *YieldOptimizer) Current() (LendingProtocol, *big.Int, error) {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+	return yo.current()
+}
+
+func (yo *YieldOptimizer) current() (LendingProtocol, *big.Int, error) {
+	if yo.venue != nil {
+		balance, err := yo.venue.SupplyBalance(yo.Asset)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get %s balance: %v", yo.venue.Name(), err)
+		}
+		return yo.venue, balance, nil
+	}
+
+	var held LendingProtocol
+	balance := new(big.Int)
+	for _, venue := range yo.Venues.Lending {
+		// Venues not listing the asset hold none of it
+		supplied, err := venue.SupplyBalance(yo.Asset)
+		if err != nil {
+			continue
+		}
+		if supplied.Cmp(balance) > 0 {
+			held, balance = venue, supplied
+		}
+	}
+	if held != nil {
+		yo.venue = held
+		yo.principal.Set(balance)
+		yo.startedAt = yo.now()
+		yo.lastHarvest = yo.startedAt
+	}
+	return held, balance, nil
+}
+
+// Deposit supplies amount of the asset to the venue holding the supply, or
+// the one paying the most before the first deposit, and adds it to the
+// principal
+func (yo *YieldOptimizer) Deposit(amount *big.Int) error {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+
+	venue, _, err := yo.current()
+	if err != nil {
+		return err
+	}
+	if venue == nil {
+		rates, err := yo.Rates()
+		if err != nil {
+			return err
+		}
+		venue = rates[0].Venue
+	}
+	if err := yo.supply(venue, amount); err != nil {
+		return err
+	}
+
+	if yo.venue == nil {
+		yo.venue = venue
+		yo.startedAt = yo.now()
+		yo.lastHarvest = yo.startedAt
+	}
+	yo.principal.Add(yo.principal, amount)
+	return nil
+}
+
+// Plan weighs migrating the supply to the venue paying the most
+func (yo *YieldOptimizer) Plan() (*MigrationPlan, error) {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+	return yo.plan()
+}
+
+func (yo *YieldOptimizer) plan() (*MigrationPlan, error) {
+	venue, balance, err := yo.current()
+	if err != nil {
+		return nil, err
+	}
+	if venue == nil || balance.Sign() == 0 {
+		return nil, fmt.Errorf("no %s supplied", yo.Asset.Hex())
+	}
+	rates, err := yo.Rates()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MigrationPlan{
+		From:   venue,
+		To:     rates[0].Venue,
+		ToRate: rates[0].Rate,
+		Amount: balance,
+		Gain:   new(big.Int),
+		Cost:   new(big.Int),
+	}
+	for _, rate := range rates {
+		if rate.Venue == venue {
+			plan.FromRate = rate.Rate
+		}
+	}
+	if plan.To == venue {
+		return plan, nil
+	}
+
+	// Both rates compound over the horizon on the whole balance
+	years := yo.Horizon.Seconds() / secondsPerYear
+	growth := math.Pow(1+plan.ToRate, years) - math.Pow(1+plan.FromRate, years)
+	plan.Gain, _ = new(big.Float).Mul(new(big.Float).SetInt(balance), big.NewFloat(growth)).Int(nil)
+	gas, err := yo.gasCost(yo.MigrationGas)
+	if err != nil {
+		return nil, err
+	}
+	plan.Cost.Sub(balance, ApplySlippage(balance, yo.Slippage))
+	plan.Cost.Add(plan.Cost, gas)
+	return plan, nil
+}
+
+// Rebalance migrates the supply when the plan is worthwhile and returns the
+// plan it weighed. The amount a migration actually withdrew is supplied to
+// the new venue; if that supply fails it is kept as idle and supplied again
+// before the next plan.
+func (yo *YieldOptimizer) Rebalance() (*MigrationPlan, error) {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+	return yo.rebalance()
+}
+
+func (yo *YieldOptimizer) rebalance() (*MigrationPlan, error) {
+	if err := yo.supplyIdle(); err != nil {
+		return nil, err
+	}
+	plan, err := yo.plan()
+	if err != nil || !plan.Worthwhile() {
+		return plan, err
+	}
+
+	log.Printf("Migrating %s of %s: %s", plan.Amount, yo.Asset.Hex(), plan.String())
+	before, err := yo.walletBalance()
+	if err != nil {
+		return plan, err
+	}
+	tx, err := plan.From.Withdraw(yo.Asset, plan.Amount)
+	if err != nil {
+		return plan, fmt.Errorf("failed to withdraw from %s: %v", plan.From.Name(), err)
+	}
+	if err := yo.wait(tx, "withdraw"); err != nil {
+		return plan, err
+	}
+
+	// From here the funds belong to the new venue, supplied or idle
+	yo.venue = plan.To
+	received := plan.Amount
+	if after, err := yo.walletBalance(); err == nil {
+		received = after.Sub(after, before)
+	}
+	if err := yo.supply(plan.To, received); err != nil {
+		yo.idle.Set(received)
+		return plan, fmt.Errorf("withdrew %s but left it idle: %v", received, err)
+	}
+	return plan, nil
+}
+
+// supplyIdle supplies what a failed migration left in the account, up to
+// the account's balance, to the venue holding the supply
+func (yo *YieldOptimizer) supplyIdle() error {
+	if yo.idle.Sign() == 0 || yo.venue == nil {
+		return nil
+	}
+	balance, err := yo.walletBalance()
+	if err != nil {
+		return err
+	}
+	amount := new(big.Int).Set(yo.idle)
+	if balance.Cmp(amount) < 0 {
+		amount = balance
+	}
+	if amount.Sign() > 0 {
+		if err := yo.supply(yo.venue, amount); err != nil {
+			return fmt.Errorf("failed to supply idle %s: %v", amount, err)
+		}
+	}
+	yo.idle.SetInt64(0)
+	return nil
+}
+
+// walletBalance returns the manager's account's balance of the asset
+func (yo *YieldOptimizer) walletBalance() (*big.Int, error) {
+	cm := yo.Venues.ContractManager
+	balance, err := cm.tokenUint(yo.Asset, ERC20BalanceOf, cm.Transactor.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+	return balance, nil
+}
+
+// Harvest claims the supplying venue's rewards, swaps them into the asset on
+// the DEX with the best output and supplies the proceeds, returning how much
+// was compounded. Venues that are not RewardClaimers have nothing to
+// harvest.
+func (yo *YieldOptimizer) Harvest() (*big.Int, error) {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+	return yo.harvest()
+}
+
+func (yo *YieldOptimizer) harvest() (*big.Int, error) {
+	venue, _, err := yo.current()
+	if err != nil {
+		return nil, err
+	}
+	yo.lastHarvest = yo.now()
+	claimer, ok := venue.(RewardClaimer)
+	if !ok {
+		return new(big.Int), nil
+	}
+
+	token, amount, err := claimer.ClaimRewards(yo.Asset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim %s rewards: %v", venue.Name(), err)
+	}
+	if amount.Sign() == 0 {
+		return new(big.Int), nil
+	}
+
+	proceeds := amount
+	if token != yo.Asset {
+		before, err := yo.walletBalance()
+		if err != nil {
+			return nil, err
+		}
+		tx, _, err := yo.Venues.SwapBest(token, yo.Asset, amount, yo.Slippage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to swap rewards: %v", err)
+		}
+		if err := yo.wait(tx, "reward swap"); err != nil {
+			return nil, err
+		}
+		after, err := yo.walletBalance()
+		if err != nil {
+			return nil, err
+		}
+		proceeds = new(big.Int).Sub(after, before)
+	}
+
+	if err := yo.supply(venue, proceeds); err != nil {
+		return nil, err
+	}
+	log.Printf("Compounded %s of %s on %s", proceeds, yo.Asset.Hex(), venue.Name())
+	return proceeds, nil
+}
+
+// RealizedAPY returns the supply's return on principal since the first
+// deposit, net of the gas spent, annualized, and records it into
+// Performance
+func (yo *YieldOptimizer) RealizedAPY() (float64, error) {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+	return yo.realizedAPY()
+}
+
+func (yo *YieldOptimizer) realizedAPY() (float64, error) {
+	venue, balance, err := yo.current()
+	if err != nil {
+		return 0, err
+	}
+	if venue == nil || yo.principal.Sign() == 0 {
+		return 0, fmt.Errorf("no %s supplied", yo.Asset.Hex())
+	}
+
+	earned := new(big.Int).Sub(balance, yo.principal)
+	earned.Sub(earned, yo.gasSpent)
+	total, _ := new(big.Float).Quo(new(big.Float).SetInt(earned), new(big.Float).SetInt(yo.principal)).Float64()
+
+	var apy float64
+	if elapsed := yo.now().Sub(yo.startedAt).Seconds(); elapsed > 0 {
+		apy = math.Pow(1+total, secondsPerYear/elapsed) - 1
+	}
+	if yo.Performance != nil {
+		yo.Performance.TotalReturn = total
+		yo.Performance.AnnualizedReturn = apy
+	}
+	return apy, nil
+}
+
+// Step migrates the supply when worthwhile, harvests once the harvest
+// interval has passed and records the realized APY, returning the migration
+// plan it weighed
+func (yo *YieldOptimizer) Step() (*MigrationPlan, error) {
+	yo.mu.Lock()
+	defer yo.mu.Unlock()
+
+	plan, err := yo.rebalance()
+	if err != nil {
+		return plan, err
+	}
+	if yo.now().Sub(yo.lastHarvest) >= yo.HarvestInterval {
+		if _, err := yo.harvest(); err != nil {
+			return plan, err
+		}
+	}
+	if _, err := yo.realizedAPY(); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// supply supplies amount of the asset to venue and waits for it
+func (yo *YieldOptimizer) supply(venue LendingProtocol, amount *big.Int) error {
+	tx, err := venue.Supply(yo.Asset, amount)
+	if err != nil {
+		return fmt.Errorf("failed to supply to %s: %v", venue.Name(), err)
+	}
+	return yo.wait(tx, "supply")
+}
+
+// wait waits up to WaitTimeout for a transaction to succeed and charges its
+// gas to the realized return
+func (yo *YieldOptimizer) wait(tx *types.Transaction, what string) error {
+	ctx, cancel := waitContext(yo.WaitTimeout)
+	defer cancel()
+	receipt, err := yo.Venues.ContractManager.WaitForTransaction(ctx, tx.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to wait for %s: %v", what, err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%s transaction %s failed", what, tx.Hash().Hex())
+	}
+
+	if receipt.EffectiveGasPrice != nil {
+		wei := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		cost, err := yo.nativeToAsset(wei)
+		if err != nil {
+			log.Printf("Failed to price %s gas: %v", what, err)
+			return nil
+		}
+		yo.gasSpent.Add(yo.gasSpent, cost)
+	}
+	return nil
+}
+
+// gasCost prices gas units in asset base units at GasPrice or the backend's
+// suggested price
+func (yo *YieldOptimizer) gasCost(gas uint64) (*big.Int, error) {
+	price := yo.GasPrice
+	if price == nil {
+		var err error
+		if price, err = yo.Venues.ContractManager.Client.SuggestGasPrice(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %v", err)
+		}
+	}
+	return yo.nativeToAsset(new(big.Int).Mul(price, new(big.Int).SetUint64(gas)))
+}
+
+// nativeToAsset converts wei to asset base units at the best DEX output for
+// one WETH
+func (yo *YieldOptimizer) nativeToAsset(wei *big.Int) (*big.Int, error) {
+	weth, err := yo.Venues.token("WETH")
+	if err != nil {
+		return nil, err
+	}
+	if wei.Sign() == 0 || weth == yo.Asset {
+		return wei, nil
+	}
+
+	one := big.NewInt(1e18)
+	quote, err := yo.Venues.BestQuote(weth, yo.Asset, one)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price gas: %v", err)
+	}
+	cost := new(big.Int).Mul(wei, quote.AmountOut)
+	return cost.Div(cost, one), nil
+}
+
+func (yo *YieldOptimizer) now() time.Time {
+	if yo.Clock == nil {
+		return time.Now()
+	}
+	return yo.Clock.Now()
+}
@@ -0,0 +1,210 @@
+package defi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/defi/defitest"
+)
+
+// rewardingVenue pays a fixed WETH reward on every claim, minted while
+// autoCommit is paused
+type rewardingVenue struct {
+	LendingProtocol
+	chain  *defitest.Chain
+	pause  func(func())
+	reward *big.Int
+}
+
+func (v *rewardingVenue) ClaimRewards(asset common.Address) (common.Address, *big.Int, error) {
+	v.pause(func() { v.chain.Mint(v.chain.WETH, v.chain.Account, v.reward) })
+	return v.chain.WETH, v.reward, nil
+}
+
+// failingVenue fails supplies while fail is set
+type failingVenue struct {
+	LendingProtocol
+	fail bool
+}
+
+func (v *failingVenue) Supply(asset common.Address, amount *big.Int) (*types.Transaction, error) {
+	if v.fail {
+		return nil, errors.New("supply paused")
+	}
+	return v.LendingProtocol.Supply(asset, amount)
+}
+
+// listAaveUSDC relists USDC on Aave with the pool backing its aToken, so
+// supplies show up as aToken balances
+func listAaveUSDC(chain *defitest.Chain, liquidityRate float64) {
+	chain.ListReserve(chain.USDC, defitest.ReserveParams{
+		Configuration:      reserveConfiguration(7500, 8000, 10500, 18, 0),
+		LiquidityRate:      rayRate(liquidityRate),
+		VariableBorrowRate: rayRate(0.05),
+		StableBorrowRate:   rayRate(0.07),
+		AToken:             chain.Pool,
+	})
+}
+
+func TestYieldOptimizerMigratesAndCompounds(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+	compound := &rewardingVenue{LendingProtocol: venues.Lending[1], chain: chain, reward: defitest.Ether(1)}
+	venues.Lending[1] = compound
+	listAaveUSDC(chain, 0.05)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(5_000))
+	for _, spender := range []common.Address{chain.Pool, chain.Comet, chain.CurvePool, chain.Router} {
+		chain.Approve(chain.Key, chain.USDC, spender, MaxRepay)
+		chain.Approve(chain.Key, chain.WETH, spender, MaxRepay)
+	}
+	pause := autoCommit(t, chain, cm)
+	compound.pause = pause
+
+	clock := NewSimulatedClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	yo := NewYieldOptimizer(venues, chain.USDC)
+	yo.Clock = clock
+	yo.GasPrice = big.NewInt(1e9)
+
+	rates, err := yo.Rates()
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	assert.Equal(t, "aave", rates[0].Venue.Name())
+
+	// The first deposit goes to the best rate
+	require.NoError(t, yo.Deposit(defitest.Ether(5_000)))
+	venue, balance, err := yo.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "aave", venue.Name())
+	assert.Equal(t, defitest.Ether(5_000).String(), balance.String())
+	plan, err := yo.Plan()
+	require.NoError(t, err)
+	assert.False(t, plan.Worthwhile())
+
+	// Compound paying 0.7% more earns less over 30 days than the 0.1%
+	// slippage allowance costs
+	pause(func() { listAaveUSDC(chain, 0.025) })
+	plan, err = yo.Rebalance()
+	require.NoError(t, err)
+	assert.Equal(t, "compound", plan.To.Name())
+	assert.False(t, plan.Worthwhile())
+	assert.Less(t, plan.Gain.Cmp(defitest.Ether(3)), 0)
+	assert.Greater(t, plan.Cost.Cmp(defitest.Ether(5)), 0)
+	assert.Less(t, plan.Cost.Cmp(new(big.Int).Add(defitest.Ether(5), big.NewInt(1e15))), 0)
+	assert.Equal(t, defitest.Ether(5_000).String(), chain.PoolAccount(chain.Account).Collateral.String())
+
+	// At 2.2% more the move pays for itself
+	pause(func() { listAaveUSDC(chain, 0.01) })
+	plan, err = yo.Step()
+	require.NoError(t, err)
+	assert.True(t, plan.Worthwhile())
+	assert.Greater(t, plan.Gain.Cmp(plan.Cost), 0)
+	assert.Zero(t, chain.PoolAccount(chain.Account).Collateral.Sign())
+	supplied, err := compound.SupplyBalance(chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(5_000).String(), supplied.String())
+
+	// Gas is charged against the realized return until rewards are harvested
+	assert.Negative(t, yo.Performance.TotalReturn)
+
+	// A day later the WETH reward is sold for USDC and supplied
+	clock.Advance(DefaultHarvestInterval)
+	_, err = yo.Step()
+	require.NoError(t, err)
+	supplied, err = compound.SupplyBalance(chain.USDC)
+	require.NoError(t, err)
+	compounded := new(big.Int).Sub(supplied, defitest.Ether(5_000))
+	assert.Equal(t, "9996", compounded.String()[:4])
+	assert.Less(t, compounded.Cmp(defitest.Ether(1)), 0)
+	assert.Zero(t, chain.BalanceOf(chain.WETH, chain.Account).Sign())
+
+	total := yo.Performance.TotalReturn
+	assert.Positive(t, total)
+	assert.Less(t, total, 0.9996/5_000)
+	apy, err := yo.RealizedAPY()
+	require.NoError(t, err)
+	assert.Equal(t, apy, yo.Performance.AnnualizedReturn)
+	assert.Greater(t, apy, 365*total)
+}
+
+func TestYieldOptimizerSuppliesIdleFunds(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+	compound := &failingVenue{LendingProtocol: venues.Lending[1]}
+	venues.Lending[1] = compound
+	listAaveUSDC(chain, 0.05)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(5_000))
+	chain.Approve(chain.Key, chain.USDC, chain.Pool, MaxRepay)
+	chain.Approve(chain.Key, chain.USDC, chain.Comet, MaxRepay)
+	pause := autoCommit(t, chain, cm)
+
+	yo := NewYieldOptimizer(venues, chain.USDC)
+	yo.GasPrice = big.NewInt(1e9)
+	require.NoError(t, yo.Deposit(defitest.Ether(5_000)))
+
+	// The withdrawal lands but the new venue refuses it
+	pause(func() { listAaveUSDC(chain, 0.01) })
+	compound.fail = true
+	_, err := yo.Rebalance()
+	require.ErrorContains(t, err, "idle")
+	assert.Zero(t, chain.PoolAccount(chain.Account).Collateral.Sign())
+	assert.Equal(t, defitest.Ether(5_000).String(), chain.BalanceOf(chain.USDC, chain.Account).String())
+
+	// The next rebalance supplies it before planning
+	compound.fail = false
+	plan, err := yo.Rebalance()
+	require.NoError(t, err)
+	assert.Equal(t, "compound", plan.From.Name())
+	assert.False(t, plan.Worthwhile())
+	supplied, err := compound.SupplyBalance(chain.USDC)
+	require.NoError(t, err)
+	assert.Equal(t, defitest.Ether(5_000).String(), supplied.String())
+	assert.Zero(t, chain.BalanceOf(chain.USDC, chain.Account).Sign())
+}
+
+func TestYieldOptimizerAdoptsExistingSupply(t *testing.T) {
+	chain := defitest.NewChain(t)
+	cm := newSimulatedContractManager(t, chain)
+	venues := newVenues(t, chain, cm)
+	listAaveUSDC(chain, 0.05)
+
+	chain.Mint(chain.USDC, chain.Account, defitest.Ether(100))
+	chain.Approve(chain.Key, chain.USDC, chain.Comet, MaxRepay)
+	autoCommit(t, chain, cm)
+
+	yo := NewYieldOptimizer(venues, chain.USDC)
+	_, err := yo.Plan()
+	require.Error(t, err)
+	_, err = yo.RealizedAPY()
+	require.Error(t, err)
+
+	tx, err := venues.Lending[1].Supply(chain.USDC, defitest.Ether(100))
+	require.NoError(t, err)
+	_, err = cm.WaitForTransaction(context.Background(), tx.Hash())
+	require.NoError(t, err)
+
+	venue, balance, err := yo.Current()
+	require.NoError(t, err)
+	assert.Equal(t, "compound", venue.Name())
+	assert.Equal(t, defitest.Ether(100).String(), balance.String())
+
+	// Venues without rewards have nothing to harvest
+	harvested, err := yo.Harvest()
+	require.NoError(t, err)
+	assert.Zero(t, harvested.Sign())
+
+	apy, err := yo.RealizedAPY()
+	require.NoError(t, err)
+	assert.Zero(t, apy)
+}
@@ -0,0 +1,305 @@
+// Package indicators implements rolling-window technical indicators over price series.
+//
+// Every function reads the most recent values of the series it is given, so callers can
+// pass an entire history buffer and get the indicator value for the latest bar.
+package indicators
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrInsufficientData is returned when a series is shorter than the indicator period
+var ErrInsufficientData = errors.New("insufficient data for indicator")
+
+// ErrInvalidPeriod is returned when an indicator period is not positive
+var ErrInvalidPeriod = errors.New("indicator period must be positive")
+
+// MACDResult holds the MACD line, its signal line and their difference
+type MACDResult struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// BollingerResult holds Bollinger Bands for the latest bar
+type BollingerResult struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+	StdDev float64
+}
+
+// Width returns the band width relative to the middle band
+func (b BollingerResult) Width() float64 {
+	if b.Middle == 0 {
+		return 0
+	}
+	return (b.Upper - b.Lower) / b.Middle
+}
+
+// Position returns how many standard deviations price sits from the middle band
+func (b BollingerResult) Position(price float64) float64 {
+	if b.StdDev == 0 {
+		return 0
+	}
+	return (price - b.Middle) / b.StdDev
+}
+
+func checkPeriod(values []float64, period, required int) error {
+	if period <= 0 {
+		return ErrInvalidPeriod
+	}
+	if len(values) < required {
+		return ErrInsufficientData
+	}
+	return nil
+}
+
+// SMA returns the simple moving average of the last period values
+func SMA(values []float64, period int) (float64, error) {
+	if err := checkPeriod(values, period, period); err != nil {
+		return 0, err
+	}
+	return mean(values[len(values)-period:]), nil
+}
+
+// EMASeries returns the exponential moving average for every bar from index period-1 onward.
+// The average is seeded with the SMA of the first period values.
+func EMASeries(values []float64, period int) ([]float64, error) {
+	if err := checkPeriod(values, period, period); err != nil {
+		return nil, err
+	}
+
+	k := 2 / float64(period+1)
+	series := make([]float64, 0, len(values)-period+1)
+	ema := mean(values[:period])
+	series = append(series, ema)
+	for _, v := range values[period:] {
+		ema = v*k + ema*(1-k)
+		series = append(series, ema)
+	}
+	return series, nil
+}
+
+// EMA returns the exponential moving average of the series at the latest bar
+func EMA(values []float64, period int) (float64, error) {
+	series, err := EMASeries(values, period)
+	if err != nil {
+		return 0, err
+	}
+	return series[len(series)-1], nil
+}
+
+// RSI returns the relative strength index using Wilder's smoothing
+func RSI(values []float64, period int) (float64, error) {
+	if err := checkPeriod(values, period, period+1); err != nil {
+		return 0, err
+	}
+
+	gain, loss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	avgGain := gain / float64(period)
+	avgLoss := loss / float64(period)
+
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		up, down := 0.0, 0.0
+		if change > 0 {
+			up = change
+		} else {
+			down = -change
+		}
+		avgGain = (avgGain*float64(period-1) + up) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + down) / float64(period)
+	}
+
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50, nil
+		}
+		return 100, nil
+	}
+	rs := avgGain / avgLoss
+	return 100 - 100/(1+rs), nil
+}
+
+// MACDLine returns the difference between the fast and slow EMAs at the latest
+// bar, which unlike the full MACD needs no more than slow values
+func MACDLine(values []float64, fast, slow int) (float64, error) {
+	if err := checkMACD(fast, slow); err != nil {
+		return 0, err
+	}
+	if len(values) < slow {
+		return 0, ErrInsufficientData
+	}
+
+	fastEMA, _ := EMA(values, fast)
+	slowEMA, _ := EMA(values, slow)
+	return fastEMA - slowEMA, nil
+}
+
+// MACD returns the moving average convergence divergence at the latest bar
+func MACD(values []float64, fast, slow, signal int) (MACDResult, error) {
+	if signal <= 0 {
+		return MACDResult{}, ErrInvalidPeriod
+	}
+	if err := checkMACD(fast, slow); err != nil {
+		return MACDResult{}, err
+	}
+	if len(values) < slow+signal-1 {
+		return MACDResult{}, ErrInsufficientData
+	}
+
+	fastEMA, _ := EMASeries(values, fast)
+	slowEMA, _ := EMASeries(values, slow)
+
+	// Align the fast series with the slow one, which starts slow-fast bars later
+	offset := slow - fast
+	line := make([]float64, len(slowEMA))
+	for i := range slowEMA {
+		line[i] = fastEMA[i+offset] - slowEMA[i]
+	}
+
+	signalSeries, err := EMASeries(line, signal)
+	if err != nil {
+		return MACDResult{}, err
+	}
+
+	result := MACDResult{
+		MACD:   line[len(line)-1],
+		Signal: signalSeries[len(signalSeries)-1],
+	}
+	result.Histogram = result.MACD - result.Signal
+	return result, nil
+}
+
+func checkMACD(fast, slow int) error {
+	if fast <= 0 || slow <= 0 {
+		return ErrInvalidPeriod
+	}
+	if fast >= slow {
+		return errors.New("MACD fast period must be shorter than slow period")
+	}
+	return nil
+}
+
+// Bollinger returns Bollinger Bands of width k population standard deviations
+func Bollinger(values []float64, period int, k float64) (BollingerResult, error) {
+	if err := checkPeriod(values, period, period); err != nil {
+		return BollingerResult{}, err
+	}
+
+	window := values[len(values)-period:]
+	middle := mean(window)
+	sd := stdDev(window, middle)
+
+	return BollingerResult{
+		Upper:  middle + k*sd,
+		Middle: middle,
+		Lower:  middle - k*sd,
+		StdDev: sd,
+	}, nil
+}
+
+// ATR returns the average true range using Wilder's smoothing
+func ATR(high, low, close []float64, period int) (float64, error) {
+	if len(high) != len(close) || len(low) != len(close) {
+		return 0, errors.New("ATR series must have equal length")
+	}
+	if err := checkPeriod(close, period, period+1); err != nil {
+		return 0, err
+	}
+
+	trueRange := func(i int) float64 {
+		return math.Max(high[i]-low[i], math.Max(math.Abs(high[i]-close[i-1]), math.Abs(low[i]-close[i-1])))
+	}
+
+	atr := 0.0
+	for i := 1; i <= period; i++ {
+		atr += trueRange(i)
+	}
+	atr /= float64(period)
+
+	for i := period + 1; i < len(close); i++ {
+		atr = (atr*float64(period-1) + trueRange(i)) / float64(period)
+	}
+	return atr, nil
+}
+
+// ZScore returns how many population standard deviations the latest value is from
+// the mean of the last period values
+func ZScore(values []float64, period int) (float64, error) {
+	if err := checkPeriod(values, period, period); err != nil {
+		return 0, err
+	}
+
+	window := values[len(values)-period:]
+	m := mean(window)
+	sd := stdDev(window, m)
+	if sd == 0 {
+		return 0, nil
+	}
+	return (window[len(window)-1] - m) / sd, nil
+}
+
+// VWAP returns the volume-weighted average price of the last period bars (all bars when period <= 0)
+func VWAP(prices, volumes []float64, period int) (float64, error) {
+	if len(prices) != len(volumes) {
+		return 0, errors.New("VWAP series must have equal length")
+	}
+	if period <= 0 {
+		period = len(prices)
+	}
+	if len(prices) < period || period == 0 {
+		return 0, ErrInsufficientData
+	}
+
+	start := len(prices) - period
+	notional, volume := 0.0, 0.0
+	for i := start; i < len(prices); i++ {
+		notional += prices[i] * volumes[i]
+		volume += volumes[i]
+	}
+	if volume == 0 {
+		return 0, ErrInsufficientData
+	}
+	return notional / volume, nil
+}
+
+// VolumeRatio returns the latest volume divided by the average of the period volumes before it
+func VolumeRatio(volumes []float64, period int) (float64, error) {
+	if err := checkPeriod(volumes, period, period+1); err != nil {
+		return 0, err
+	}
+
+	average := mean(volumes[len(volumes)-period-1 : len(volumes)-1])
+	if average == 0 {
+		return 0, ErrInsufficientData
+	}
+	return volumes[len(volumes)-1] / average, nil
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// stdDev returns the population standard deviation of values around m
+func stdDev(values []float64, m float64) float64 {
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - m) * (v - m)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
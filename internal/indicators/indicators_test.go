@@ -0,0 +1,157 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wilderCloses is the 14-period RSI worked example popularised by StockCharts
+var wilderCloses = []float64{
+	44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245,
+	45.8433, 46.0826, 45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028,
+	46.0328, 46.4116, 46.2222,
+}
+
+func TestSMA(t *testing.T) {
+	sma, err := SMA([]float64{1, 2, 3, 4, 5, 6}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 4.5, sma)
+
+	_, err = SMA([]float64{1, 2}, 3)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+
+	_, err = SMA([]float64{1, 2}, 0)
+	assert.ErrorIs(t, err, ErrInvalidPeriod)
+}
+
+func TestEMA(t *testing.T) {
+	series, err := EMASeries([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 5)
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{3, 4, 5, 6, 7, 8}, series, 1e-9)
+
+	ema, err := EMA([]float64{22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29, 22.15}, 10)
+	require.NoError(t, err)
+	// 10-day SMA seed of 22.221 followed by one step with k = 2/11
+	assert.InDelta(t, 22.208091, ema, 1e-6)
+}
+
+func TestRSI(t *testing.T) {
+	expected := []float64{70.53, 66.32, 66.55, 69.41, 66.36}
+	for i, want := range expected {
+		rsi, err := RSI(wilderCloses[:15+i], 14)
+		require.NoError(t, err)
+		assert.InDelta(t, want, rsi, 0.01, "bar %d", 15+i)
+	}
+
+	_, err := RSI(wilderCloses[:14], 14)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+
+	rsi, err := RSI([]float64{1, 2, 3, 4}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, rsi)
+
+	rsi, err = RSI([]float64{5, 5, 5, 5}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, rsi)
+}
+
+func TestMACD(t *testing.T) {
+	// On a linear series each EMA lags price by slope*(period-1)/2, so the MACD line
+	// is slope*(slow-fast)/2 and the signal line converges to the same value
+	values := make([]float64, 40)
+	for i := range values {
+		values[i] = float64(i + 1)
+	}
+
+	result, err := MACD(values, 12, 26, 9)
+	require.NoError(t, err)
+	assert.InDelta(t, 7.0, result.MACD, 1e-9)
+	assert.InDelta(t, 7.0, result.Signal, 1e-9)
+	assert.InDelta(t, 0.0, result.Histogram, 1e-9)
+
+	_, err = MACD(values[:33], 12, 26, 9)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+
+	_, err = MACD(values, 26, 12, 9)
+	assert.Error(t, err)
+
+	// The MACD line alone is available from the slow period on
+	line, err := MACDLine(values[:26], 12, 26)
+	require.NoError(t, err)
+	assert.InDelta(t, result.MACD, line, 1e-9)
+
+	_, err = MACDLine(values[:25], 12, 26)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+
+	_, err = MACDLine(values, 12, 12)
+	assert.Error(t, err)
+}
+
+func TestBollinger(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	bands, err := Bollinger(values, 8, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, bands.Middle)
+	assert.Equal(t, 2.0, bands.StdDev)
+	assert.Equal(t, 9.0, bands.Upper)
+	assert.Equal(t, 1.0, bands.Lower)
+	assert.Equal(t, 1.6, bands.Width())
+	assert.Equal(t, 2.0, bands.Position(9))
+	assert.Equal(t, -1.5, bands.Position(2))
+}
+
+func TestATR(t *testing.T) {
+	high := []float64{10, 12, 13, 12}
+	low := []float64{8, 9, 11, 10}
+	close := []float64{9, 11, 12, 11}
+
+	// True ranges are 3, 2, 2: seeded with (3+2)/2, then (2.5*1 + 2)/2
+	atr, err := ATR(high, low, close, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2.25, atr)
+
+	_, err = ATR(high, low[:3], close, 2)
+	assert.Error(t, err)
+
+	_, err = ATR(high, low, close, 4)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+}
+
+func TestZScore(t *testing.T) {
+	z, err := ZScore([]float64{100, 2, 4, 4, 4, 5, 5, 7, 9}, 8)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, z)
+
+	z, err = ZScore([]float64{3, 3, 3}, 3)
+	require.NoError(t, err)
+	assert.Zero(t, z)
+}
+
+func TestVWAP(t *testing.T) {
+	vwap, err := VWAP([]float64{10, 20}, []float64{1, 3}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 17.5, vwap)
+
+	vwap, err = VWAP([]float64{50, 10, 20}, []float64{100, 1, 3}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 17.5, vwap)
+
+	_, err = VWAP([]float64{10, 20}, []float64{0, 0}, 0)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+}
+
+func TestVolumeRatio(t *testing.T) {
+	ratio, err := VolumeRatio([]float64{100, 100, 100, 200}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, ratio)
+
+	ratio, err = VolumeRatio([]float64{50, 100, 300, 100}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, ratio)
+
+	_, err = VolumeRatio([]float64{100, 100}, 2)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+}
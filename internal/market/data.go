@@ -3,14 +3,20 @@ package market
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/pkg/mcpclient"
 )
 
 type Data struct {
+	// mu guards Prices, Protocols and lastUpdate once the data is shared,
+	// as with a WebSocketService updating it in the background
+	mu sync.RWMutex
+
 	Prices     map[string]PriceData
 	Protocols  []ProtocolData
+	History    *PriceHistory
 	lastUpdate time.Time
 	pythClient *mcpclient.PythClient
 }
@@ -44,12 +50,16 @@ func NewData() *Data {
 			{Name: "Compound", TVL: 2.3e9, APY: 2.8, Category: "Lending"},
 			{Name: "Curve", TVL: 3.1e9, APY: 4.5, Category: "StableSwap"},
 		},
+		History:    NewPriceHistory(DefaultHistorySize),
 		lastUpdate: time.Now(),
 		pythClient: mcpclient.NewPythClient(),
 	}
 
 	// Initialize with real Pyth data
 	data.updateFromPyth()
+	data.mu.Lock()
+	data.recordHistory()
+	data.mu.Unlock()
 
 	return data
 }
@@ -58,6 +68,9 @@ func (d *Data) UpdatePrices() {
 	// First try to get real prices from Pyth Network
 	d.updateFromPyth()
 
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	// If Pyth update failed or we need to simulate some changes
 	for symbol, priceData := range d.Prices {
 		// Add small random variations to simulate market dynamics
@@ -86,23 +99,58 @@ func (d *Data) UpdatePrices() {
 	}
 
 	d.lastUpdate = time.Now()
+	d.recordHistory()
+}
+
+// recordHistory appends the current prices to the per-symbol price history.
+// The caller holds mu.
+func (d *Data) recordHistory() {
+	if d.History == nil {
+		return
+	}
+
+	for symbol, priceData := range d.Prices {
+		d.History.AddPrice(symbol, priceData.Price, priceData.Volume, d.lastUpdate)
+	}
 }
 
 func (d *Data) GetLastUpdate() time.Time {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.lastUpdate
 }
 
 func (d *Data) GetPrice(symbol string) (PriceData, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	price, exists := d.Prices[symbol]
 	return price, exists
 }
 
+// SetPrice records a price fetched from another source, such as the
+// terminal's own price feed
+func (d *Data) SetPrice(price PriceData) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Prices[price.Symbol] = price
+}
+
+// GetAllPrices returns a copy of the current prices
 func (d *Data) GetAllPrices() map[string]PriceData {
-	return d.Prices
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	prices := make(map[string]PriceData, len(d.Prices))
+	for symbol, price := range d.Prices {
+		prices[symbol] = price
+	}
+	return prices
 }
 
+// GetProtocols returns a copy of the current protocol data
 func (d *Data) GetProtocols() []ProtocolData {
-	return d.Protocols
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]ProtocolData(nil), d.Protocols...)
 }
 
 func (d *Data) updateFromPyth() {
@@ -134,11 +182,13 @@ func (d *Data) updateFromPyth() {
 			continue
 		}
 
+		d.mu.Lock()
 		if existing, exists := d.Prices[symbol]; exists {
 			existing.Price = pythData.Price
 			existing.Change24h = pythData.Change24h
 			existing.Volume = pythData.Volume
 			d.Prices[symbol] = existing
 		}
+		d.mu.Unlock()
 	}
 }
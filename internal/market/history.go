@@ -0,0 +1,128 @@
+package market
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of candles kept per symbol when no size is given
+const DefaultHistorySize = 500
+
+// Candle represents a single OHLCV bar for a symbol
+type Candle struct {
+	Symbol string    `json:"symbol"`
+	Time   time.Time `json:"timestamp"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+// PriceHistory keeps a bounded, per-symbol buffer of candles
+type PriceHistory struct {
+	mu      sync.RWMutex
+	size    int
+	candles map[string][]Candle
+}
+
+// NewPriceHistory creates a price history that retains up to size candles per symbol
+func NewPriceHistory(size int) *PriceHistory {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+
+	return &PriceHistory{
+		size:    size,
+		candles: make(map[string][]Candle),
+	}
+}
+
+// VenueSymbol returns the history key for a trading pair quoted on a specific venue
+func VenueSymbol(venue, pair string) string {
+	return venue + ":" + pair
+}
+
+// Add appends a candle to the history of its symbol, dropping the oldest when full
+func (h *PriceHistory) Add(candle Candle) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series := append(h.candles[candle.Symbol], candle)
+	if len(series) > h.size {
+		series = series[len(series)-h.size:]
+	}
+	h.candles[candle.Symbol] = series
+}
+
+// AddPrice records a price observation as a flat candle
+func (h *PriceHistory) AddPrice(symbol string, price, volume float64, at time.Time) {
+	h.Add(Candle{
+		Symbol: symbol,
+		Time:   at,
+		Open:   price,
+		High:   price,
+		Low:    price,
+		Close:  price,
+		Volume: volume,
+	})
+}
+
+// Candles returns a copy of the last n candles for a symbol (all when n <= 0)
+func (h *PriceHistory) Candles(symbol string, n int) []Candle {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	series := h.candles[symbol]
+	if n > 0 && n < len(series) {
+		series = series[len(series)-n:]
+	}
+
+	out := make([]Candle, len(series))
+	copy(out, series)
+	return out
+}
+
+// Closes returns the last n closing prices for a symbol (all when n <= 0)
+func (h *PriceHistory) Closes(symbol string, n int) []float64 {
+	candles := h.Candles(symbol, n)
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+	return closes
+}
+
+// Latest returns the most recent candle for a symbol
+func (h *PriceHistory) Latest(symbol string) (Candle, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	series := h.candles[symbol]
+	if len(series) == 0 {
+		return Candle{}, false
+	}
+	return series[len(series)-1], true
+}
+
+// Len returns the number of candles held for a symbol
+func (h *PriceHistory) Len(symbol string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.candles[symbol])
+}
+
+// Symbols returns the symbols present in the history in sorted order
+func (h *PriceHistory) Symbols() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	symbols := make([]string, 0, len(h.candles))
+	for symbol := range h.candles {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,60 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceHistoryBounded(t *testing.T) {
+	history := NewPriceHistory(3)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		history.AddPrice("ETH", float64(100+i), 10, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	if got := history.Len("ETH"); got != 3 {
+		t.Fatalf("Expected 3 candles, got %d", got)
+	}
+
+	closes := history.Closes("ETH", 0)
+	expected := []float64{102, 103, 104}
+	for i, want := range expected {
+		if closes[i] != want {
+			t.Errorf("Close %d: expected %.0f, got %.0f", i, want, closes[i])
+		}
+	}
+
+	if last := history.Closes("ETH", 2); len(last) != 2 || last[1] != 104 {
+		t.Errorf("Expected last two closes ending at 104, got %v", last)
+	}
+
+	latest, ok := history.Latest("ETH")
+	if !ok || latest.Close != 104 {
+		t.Errorf("Expected latest close 104, got %v (ok=%v)", latest.Close, ok)
+	}
+
+	if _, ok := history.Latest("BTC"); ok {
+		t.Error("Expected no candles for BTC")
+	}
+}
+
+func TestDataRecordsHistory(t *testing.T) {
+	marketData := NewData()
+
+	before := marketData.History.Len("ETH")
+	if before == 0 {
+		t.Fatal("Expected initial prices to be recorded")
+	}
+
+	marketData.UpdatePrices()
+
+	if got := marketData.History.Len("ETH"); got != before+1 {
+		t.Errorf("Expected %d ETH candles after update, got %d", before+1, got)
+	}
+
+	latest, _ := marketData.History.Latest("ETH")
+	if price, _ := marketData.GetPrice("ETH"); latest.Close != price.Price {
+		t.Errorf("Expected latest close %.2f to match current price %.2f", latest.Close, price.Price)
+	}
+}
@@ -20,6 +20,9 @@ type WebSocketService struct {
 	isRunning  bool
 	stopChan   chan struct{}
 	broadcast  chan []byte
+
+	arbitrageMu sync.RWMutex
+	arbitrage   []ArbitrageUpdate
 }
 
 // WebSocketMessage represents a message sent over WebSocket
@@ -47,6 +50,21 @@ type ProtocolUpdate struct {
 	Timestamp int64   `json:"timestamp"`
 }
 
+// ArbitrageUpdate represents a detected arbitrage opportunity. Amounts are
+// decimal strings in base units of the cycle's first token.
+type ArbitrageUpdate struct {
+	ID        string   `json:"id"`
+	Path      []string `json:"path"`
+	Venues    []string `json:"venues"`
+	AmountIn  string   `json:"amount_in"`
+	AmountOut string   `json:"amount_out"`
+	GasCost   string   `json:"gas_cost"`
+	Profit    string   `json:"profit"`
+	ProfitUSD float64  `json:"profit_usd"`
+	ProfitPct float64  `json:"profit_pct"`
+	Timestamp int64    `json:"timestamp"`
+}
+
 // NewWebSocketService creates a new WebSocket service
 func NewWebSocketService(marketData *Data) *WebSocketService {
 	return &WebSocketService{
@@ -206,6 +224,20 @@ func (ws *WebSocketService) sendInitialData(conn *websocket.Conn) {
 	}
 
 	ws.sendMessage(conn, protocolMessage)
+
+	// Send the latest arbitrage scan
+	ws.arbitrageMu.RLock()
+	arbitrage := ws.arbitrage
+	ws.arbitrageMu.RUnlock()
+	if len(arbitrage) > 0 {
+		ws.sendMessage(conn, WebSocketMessage{
+			Type: "initial_arbitrage",
+			Payload: map[string]interface{}{
+				"opportunities": arbitrage,
+			},
+			Time: time.Now(),
+		})
+	}
 }
 
 // handleClientMessage processes messages from clients
@@ -368,6 +400,22 @@ func (ws *WebSocketService) broadcastProtocolUpdates() {
 	ws.broadcastMessage(message)
 }
 
+// BroadcastArbitrage sends a scan's ranked opportunities to all clients and
+// keeps them for clients that connect later
+func (ws *WebSocketService) BroadcastArbitrage(updates []ArbitrageUpdate) {
+	ws.arbitrageMu.Lock()
+	ws.arbitrage = updates
+	ws.arbitrageMu.Unlock()
+
+	ws.broadcastMessage(WebSocketMessage{
+		Type: "arbitrage_update",
+		Payload: map[string]interface{}{
+			"opportunities": updates,
+		},
+		Time: time.Now(),
+	})
+}
+
 // sendMessage sends a message to a specific client
 func (ws *WebSocketService) sendMessage(conn *websocket.Conn, message WebSocketMessage) {
 	data, err := json.Marshal(message)
@@ -126,6 +126,54 @@ func TestWebSocketConnection(t *testing.T) {
 	}
 }
 
+func TestArbitrageSentToNewClients(t *testing.T) {
+	service := NewWebSocketService(NewData())
+	service.BroadcastArbitrage([]ArbitrageUpdate{{
+		ID:        "WETH>USDC>WETH",
+		Path:      []string{"WETH", "USDC", "WETH"},
+		Venues:    []string{"uniswap_v2:WETH/USDC", "sushiswap:WETH/USDC"},
+		Profit:    "1000",
+		ProfitUSD: 12.5,
+	}})
+
+	select {
+	case data := <-service.broadcast:
+		var wsMsg WebSocketMessage
+		if err := json.Unmarshal(data, &wsMsg); err != nil || wsMsg.Type != "arbitrage_update" {
+			t.Errorf("Expected an arbitrage_update broadcast, got %s (%v)", data, err)
+		}
+	default:
+		t.Fatal("Arbitrage update was not queued for broadcast")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(service.handleWebSocket))
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	// The latest scan follows the initial prices and protocols
+	var wsMsg struct {
+		Type    string `json:"type"`
+		Payload struct {
+			Opportunities []ArbitrageUpdate `json:"opportunities"`
+		} `json:"payload"`
+	}
+	for i := 0; i < 3; i++ {
+		if err := conn.ReadJSON(&wsMsg); err != nil {
+			t.Fatalf("Failed to read initial message %d: %v", i, err)
+		}
+	}
+	if wsMsg.Type != "initial_arbitrage" {
+		t.Fatalf("Expected initial_arbitrage message, got %s", wsMsg.Type)
+	}
+	if len(wsMsg.Payload.Opportunities) != 1 || wsMsg.Payload.Opportunities[0].ProfitUSD != 12.5 {
+		t.Errorf("Unexpected opportunities: %+v", wsMsg.Payload.Opportunities)
+	}
+}
+
 func TestHealthEndpoint(t *testing.T) {
 	marketData := NewData()
 	service := NewWebSocketService(marketData)
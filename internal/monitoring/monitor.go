@@ -67,6 +67,9 @@ type Metrics struct {
 	PositionsOpened    *prometheus.CounterVec
 	PositionsClosed    *prometheus.CounterVec
 	PositionPnl        *prometheus.HistogramVec
+
+	// Rate limiting metrics
+	RateLimitDecisions *prometheus.CounterVec
 }
 
 // HealthCheck represents a health check function
@@ -205,6 +208,12 @@ func registerMetrics() *Metrics {
 			Help:    "Position profit and loss distribution",
 			Buckets: []float64{-1000, -500, -100, -50, -10, 0, 10, 50, 100, 500, 1000},
 		}, []string{"asset", "type"}),
+
+		// Rate limiting metrics
+		RateLimitDecisions: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "aegis_rate_limit_decisions_total",
+			Help: "Total number of rate limiter decisions by server, route and outcome",
+		}, []string{"server", "route", "decision"}),
 	}
 }
 
@@ -279,6 +288,11 @@ func (m *Monitor) AddHealthCheck(name string, check HealthCheck) {
 	m.logger.Debug("Added health check", logging.WithString("name", name))
 }
 
+// Alerts returns the monitor's alert manager
+func (m *Monitor) Alerts() *AlertManager {
+	return m.alerts
+}
+
 // RecordTrade records trade metrics
 func (m *Monitor) RecordTrade(success bool, volume float64, latency time.Duration, strategy string) {
 	if !m.cfg.Enabled || m.metrics == nil {
@@ -396,6 +410,19 @@ func (m *Monitor) UpdateStrategyMetrics(strategy string, performance, riskScore
 	m.metrics.StrategyRiskScore.WithLabelValues(strategy).Set(riskScore)
 }
 
+// RecordRateLimit records a rate limiter decision for a route
+func (m *Monitor) RecordRateLimit(server, route string, allowed bool) {
+	if !m.cfg.Enabled || m.metrics == nil {
+		return
+	}
+
+	decision := "allowed"
+	if !allowed {
+		decision = "rejected"
+	}
+	m.metrics.RateLimitDecisions.WithLabelValues(server, route, decision).Inc()
+}
+
 // healthHandler handles health check requests
 func (m *Monitor) healthHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -95,6 +95,7 @@ func TestMonitorRecording(t *testing.T) {
 
 	monitor.UpdateSystemMetrics(5, 1024*1024*1024, 25.5, 50)
 	monitor.UpdateStrategyMetrics("mean_reversion", 15.5, 0.3)
+	monitor.RecordRateLimit("api", "/api/v1/portfolio", false)
 
 	// Metrics should be nil when disabled
 	assert.Nil(t, monitor.metrics)
@@ -0,0 +1,20 @@
+package portfolio
+
+import "errors"
+
+var (
+	// ErrPortfolioNotFound is returned when no portfolio has the requested ID
+	ErrPortfolioNotFound = errors.New("portfolio not found")
+
+	// ErrPortfolioExists is returned when creating a portfolio with an ID already in use
+	ErrPortfolioExists = errors.New("portfolio already exists")
+
+	// ErrAssetExists is returned when adding an asset the portfolio already holds
+	ErrAssetExists = errors.New("asset already exists in portfolio")
+
+	// ErrNoTargetAllocations is returned when rebalancing a portfolio without target allocations
+	ErrNoTargetAllocations = errors.New("portfolio has no target allocations")
+
+	// ErrInsufficientCash is returned when a purchase costs more than the portfolio's cash balance
+	ErrInsufficientCash = errors.New("insufficient cash balance")
+)
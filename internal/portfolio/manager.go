@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/storage"
 )
 
 // PortfolioManager manages multiple portfolios
@@ -17,6 +19,7 @@ type PortfolioManager struct {
 	mu         sync.RWMutex
 	logger     logging.Logger
 	monitor    *monitoring.Monitor
+	repo       storage.Repository // nil keeps portfolios in memory only
 }
 
 // NewPortfolioManager creates a new portfolio manager
@@ -28,16 +31,24 @@ func NewPortfolioManager(logger logging.Logger, monitor *monitoring.Monitor) *Po
 	}
 }
 
-// CreatePortfolio creates a new portfolio
-func (pm *PortfolioManager) CreatePortfolio(id, name string, riskProfile RiskProfile) (*Portfolio, error) {
+// CreatePortfolio creates a new portfolio holding initialCash, which may be
+// nil for none. The cash is persisted with the portfolio's first record.
+func (pm *PortfolioManager) CreatePortfolio(id, name string, riskProfile RiskProfile, initialCash *big.Float) (*Portfolio, error) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
 	if _, exists := pm.portfolios[id]; exists {
-		return nil, fmt.Errorf("portfolio %s already exists", id)
+		return nil, fmt.Errorf("%w: %s", ErrPortfolioExists, id)
 	}
 
 	portfolio := NewPortfolio(id, name, riskProfile, pm.logger, pm.monitor)
+	portfolio.repo = pm.repo
+	if initialCash != nil {
+		portfolio.CashBalance = new(big.Float).Set(initialCash)
+	}
+	if err := portfolio.saveRecord(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to persist portfolio %s: %w", id, err)
+	}
 	pm.portfolios[id] = portfolio
 
 	pm.logger.Info("Created portfolio",
@@ -56,7 +67,7 @@ func (pm *PortfolioManager) GetPortfolio(id string) (*Portfolio, error) {
 
 	portfolio, exists := pm.portfolios[id]
 	if !exists {
-		return nil, fmt.Errorf("portfolio %s not found", id)
+		return nil, fmt.Errorf("%w: %s", ErrPortfolioNotFound, id)
 	}
 
 	return portfolio, nil
@@ -68,7 +79,13 @@ func (pm *PortfolioManager) DeletePortfolio(id string) error {
 	defer pm.mu.Unlock()
 
 	if _, exists := pm.portfolios[id]; !exists {
-		return fmt.Errorf("portfolio %s not found", id)
+		return fmt.Errorf("%w: %s", ErrPortfolioNotFound, id)
+	}
+
+	if pm.repo != nil {
+		if err := pm.repo.DeletePortfolio(context.Background(), id); err != nil {
+			return fmt.Errorf("failed to delete persisted portfolio %s: %w", id, err)
+		}
 	}
 
 	delete(pm.portfolios, id)
@@ -103,15 +120,18 @@ func (pm *PortfolioManager) GetTotalValue() *big.Float {
 	return total
 }
 
-// RebalancePortfolio rebalances a portfolio to target allocations
-func (pm *PortfolioManager) RebalancePortfolio(ctx context.Context, portfolioID string) error {
+// RebalancePortfolio rebalances a portfolio to target allocations and returns the executed actions
+func (pm *PortfolioManager) RebalancePortfolio(ctx context.Context, portfolioID string) ([]RebalanceAction, error) {
 	portfolio, err := pm.GetPortfolio(portfolioID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	currentAllocation := portfolio.GetAssetAllocation()
 	targetAllocation := portfolio.RiskProfile.TargetAllocations
+	if len(targetAllocation) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoTargetAllocations, portfolioID)
+	}
 
 	// Calculate rebalancing actions
 	rebalanceActions := calculateRebalanceActions(currentAllocation, targetAllocation, portfolio.GetTotalValue())
@@ -120,7 +140,7 @@ func (pm *PortfolioManager) RebalancePortfolio(ctx context.Context, portfolioID
 	for _, action := range rebalanceActions {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		default:
 			if err := pm.executeRebalanceAction(ctx, portfolio, action); err != nil {
 				pm.logger.Error("Failed to execute rebalance action",
@@ -128,18 +148,31 @@ func (pm *PortfolioManager) RebalancePortfolio(ctx context.Context, portfolioID
 					logging.WithString("asset", action.Asset),
 					logging.WithError(err),
 				)
-				return err
+				return nil, err
+			}
+			if err := pm.recordRebalanceTransaction(ctx, portfolio, action, time.Now()); err != nil {
+				pm.logger.Error("Failed to record rebalance transaction",
+					logging.WithString("portfolio", portfolioID),
+					logging.WithString("asset", action.Asset),
+					logging.WithError(err),
+				)
 			}
 		}
 	}
 
+	portfolio.mu.Lock()
 	portfolio.LastRebalance = time.Now()
+	err = portfolio.saveRecord(ctx)
+	portfolio.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to persist portfolio %s: %w", portfolioID, err)
+	}
 	pm.logger.Info("Portfolio rebalanced",
 		logging.WithString("portfolio", portfolioID),
 		logging.WithInt("actions_executed", len(rebalanceActions)),
 	)
 
-	return nil
+	return rebalanceActions, nil
 }
 
 // RebalanceAction represents a rebalancing action
@@ -191,6 +224,11 @@ func calculateRebalanceActions(current, target map[string]float64, totalValue *b
 		}
 	}
 
+	// Keep the execution order stable across runs
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Asset < actions[j].Asset
+	})
+
 	return actions
 }
 
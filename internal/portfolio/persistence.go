@@ -0,0 +1,195 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/storage"
+)
+
+// AttachRepository makes the manager persist portfolios to repo and loads the portfolios already stored there
+func (pm *PortfolioManager) AttachRepository(ctx context.Context, repo storage.Repository) error {
+	records, err := repo.ListPortfolios(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load portfolios: %w", err)
+	}
+
+	loaded := make(map[string]*Portfolio, len(records))
+	for _, record := range records {
+		portfolio, err := pm.loadPortfolio(ctx, repo, record)
+		if err != nil {
+			return err
+		}
+		loaded[record.ID] = portfolio
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.repo = repo
+	for id, portfolio := range loaded {
+		pm.portfolios[id] = portfolio
+	}
+
+	pm.logger.Info("Loaded portfolios from storage", logging.WithInt("count", len(loaded)))
+	return nil
+}
+
+// loadPortfolio rebuilds a portfolio with its assets and positions from storage
+func (pm *PortfolioManager) loadPortfolio(ctx context.Context, repo storage.Repository, record *storage.PortfolioRecord) (*Portfolio, error) {
+	riskProfile := RiskProfile{
+		Type:              RiskType(record.RiskProfile.Type),
+		MaxDrawdown:       record.RiskProfile.MaxDrawdown,
+		MaxPositionSize:   record.RiskProfile.MaxPositionSize,
+		MaxLeverage:       record.RiskProfile.MaxLeverage,
+		TargetAllocations: record.RiskProfile.TargetAllocations,
+	}
+
+	portfolio := NewPortfolio(record.ID, record.Name, riskProfile, pm.logger, pm.monitor)
+	if record.CashBalance != nil {
+		portfolio.CashBalance = record.CashBalance
+	}
+	portfolio.RebalanceAt = record.RebalanceAt
+	portfolio.LastRebalance = record.LastRebalance
+	portfolio.createdAt = record.CreatedAt
+	portfolio.repo = repo
+
+	assets, err := repo.ListAssets(ctx, record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load assets of portfolio %s: %w", record.ID, err)
+	}
+	for _, a := range assets {
+		portfolio.Assets[a.Symbol] = &Asset{
+			Symbol:     a.Symbol,
+			Name:       a.Name,
+			Amount:     a.Amount,
+			ValueUSD:   a.ValueUSD,
+			PriceUSD:   a.PriceUSD,
+			Allocation: a.Allocation,
+			APY:        a.APY,
+			RiskScore:  a.RiskScore,
+			LastUpdate: a.LastUpdate,
+		}
+	}
+
+	positions, err := repo.ListPositions(ctx, record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load positions of portfolio %s: %w", record.ID, err)
+	}
+	for _, p := range positions {
+		portfolio.Positions[p.ID] = &Position{
+			ID:           p.ID,
+			Asset:        p.Asset,
+			Type:         PositionType(p.Type),
+			Size:         p.Size,
+			EntryPrice:   p.EntryPrice,
+			CurrentPrice: p.CurrentPrice,
+			Pnl:          p.Pnl,
+			PnlPercent:   p.PnlPercent,
+			Leverage:     p.Leverage,
+			Status:       PositionStatus(p.Status),
+			OpenedAt:     p.OpenedAt,
+			ClosedAt:     p.ClosedAt,
+
+			ImpermanentLoss: p.ImpermanentLoss,
+			FeeAPR:          p.FeeAPR,
+		}
+	}
+
+	return portfolio, nil
+}
+
+// saveRecord persists the portfolio's own fields; callers must hold the portfolio lock
+func (p *Portfolio) saveRecord(ctx context.Context) error {
+	if p.repo == nil {
+		return nil
+	}
+
+	if p.createdAt.IsZero() {
+		p.createdAt = time.Now()
+	}
+
+	return p.repo.SavePortfolio(ctx, &storage.PortfolioRecord{
+		ID:          p.ID,
+		Name:        p.Name,
+		CashBalance: p.CashBalance,
+		RiskProfile: storage.RiskProfileRecord{
+			Type:              string(p.RiskProfile.Type),
+			MaxDrawdown:       p.RiskProfile.MaxDrawdown,
+			MaxPositionSize:   p.RiskProfile.MaxPositionSize,
+			MaxLeverage:       p.RiskProfile.MaxLeverage,
+			TargetAllocations: p.RiskProfile.TargetAllocations,
+		},
+		RebalanceAt:   p.RebalanceAt,
+		LastRebalance: p.LastRebalance,
+		CreatedAt:     p.createdAt,
+		UpdatedAt:     time.Now(),
+	})
+}
+
+// saveAsset persists an asset; callers must hold the portfolio lock
+func (p *Portfolio) saveAsset(ctx context.Context, asset *Asset) error {
+	if p.repo == nil {
+		return nil
+	}
+
+	return p.repo.SaveAsset(ctx, &storage.AssetRecord{
+		PortfolioID: p.ID,
+		Symbol:      asset.Symbol,
+		Name:        asset.Name,
+		Amount:      asset.Amount,
+		ValueUSD:    asset.ValueUSD,
+		PriceUSD:    asset.PriceUSD,
+		Allocation:  asset.Allocation,
+		APY:         asset.APY,
+		RiskScore:   asset.RiskScore,
+		LastUpdate:  asset.LastUpdate,
+	})
+}
+
+// savePosition persists a position; callers must hold the portfolio lock
+func (p *Portfolio) savePosition(ctx context.Context, position *Position) error {
+	if p.repo == nil {
+		return nil
+	}
+
+	return p.repo.SavePosition(ctx, &storage.PositionRecord{
+		PortfolioID:  p.ID,
+		ID:           position.ID,
+		Asset:        position.Asset,
+		Type:         string(position.Type),
+		Size:         position.Size,
+		EntryPrice:   position.EntryPrice,
+		CurrentPrice: position.CurrentPrice,
+		Pnl:          position.Pnl,
+		PnlPercent:   position.PnlPercent,
+		Leverage:     position.Leverage,
+		Status:       string(position.Status),
+		OpenedAt:     position.OpenedAt,
+		ClosedAt:     position.ClosedAt,
+
+		ImpermanentLoss: position.ImpermanentLoss,
+		FeeAPR:          position.FeeAPR,
+	})
+}
+
+// recordRebalanceTransaction stores an executed rebalance action
+func (pm *PortfolioManager) recordRebalanceTransaction(ctx context.Context, portfolio *Portfolio, action RebalanceAction, executedAt time.Time) error {
+	if pm.repo == nil {
+		return nil
+	}
+
+	return pm.repo.SaveTransaction(ctx, &storage.TransactionRecord{
+		ID:          fmt.Sprintf("rebalance-%s-%s-%d", portfolio.ID, action.Asset, executedAt.UnixNano()),
+		PortfolioID: portfolio.ID,
+		Asset:       action.Asset,
+		Side:        string(action.Action),
+		Amount:      new(big.Float).Set(action.Amount),
+		Status:      "executed",
+		Reason:      action.Reason,
+		ExecutedAt:  executedAt,
+	})
+}
@@ -1,13 +1,16 @@
 package portfolio
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/storage"
 )
 
 // Asset represents a portfolio asset
@@ -37,6 +40,12 @@ type Position struct {
 	Status       PositionStatus
 	OpenedAt     time.Time
 	ClosedAt     *time.Time
+
+	// ImpermanentLoss and FeeAPR track liquidity positions: the position's
+	// value against holding its deposits, as a fraction, and the annualized
+	// fees earned per unit of value
+	ImpermanentLoss float64
+	FeeAPR          float64
 }
 
 type PositionType string
@@ -44,6 +53,11 @@ type PositionType string
 const (
 	PositionLong  PositionType = "long"
 	PositionShort PositionType = "short"
+
+	// PositionLiquidity is an AMM liquidity position. Its Size is the value
+	// deposited, in the pool's quote token, and its P&L is marked with
+	// UpdatePosition rather than derived from prices.
+	PositionLiquidity PositionType = "liquidity"
 )
 
 type PositionStatus string
@@ -68,6 +82,8 @@ type Portfolio struct {
 	mu            sync.RWMutex
 	logger        logging.Logger
 	monitor       *monitoring.Monitor
+	repo          storage.Repository // nil when the portfolio is not persisted
+	createdAt     time.Time
 }
 
 // RiskProfile defines portfolio risk tolerance
@@ -99,7 +115,31 @@ func NewPortfolio(id, name string, riskProfile RiskProfile, logger logging.Logge
 		RiskProfile: riskProfile,
 		logger:      logger,
 		monitor:     monitor,
+		createdAt:   time.Now(),
+	}
+}
+
+// CreatedAt returns when the portfolio was created
+func (p *Portfolio) CreatedAt() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.createdAt
+}
+
+// SetCashBalance replaces the portfolio's cash balance
+func (p *Portfolio) SetCashBalance(amount *big.Float) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous := p.CashBalance
+	p.CashBalance = new(big.Float).Set(amount)
+	if err := p.saveRecord(context.Background()); err != nil {
+		p.CashBalance = previous
+		return fmt.Errorf("failed to persist portfolio %s: %w", p.ID, err)
 	}
+
+	return nil
 }
 
 // AddAsset adds an asset to the portfolio
@@ -108,10 +148,15 @@ func (p *Portfolio) AddAsset(asset *Asset) error {
 	defer p.mu.Unlock()
 
 	if _, exists := p.Assets[asset.Symbol]; exists {
-		return fmt.Errorf("asset %s already exists in portfolio", asset.Symbol)
+		return fmt.Errorf("%w: %s", ErrAssetExists, asset.Symbol)
 	}
 
 	p.Assets[asset.Symbol] = asset
+	if err := p.saveAsset(context.Background(), asset); err != nil {
+		delete(p.Assets, asset.Symbol)
+		return fmt.Errorf("failed to persist asset %s: %w", asset.Symbol, err)
+	}
+
 	amountFloat, _ := asset.Amount.Float64()
 	p.logger.Info("Added asset to portfolio",
 		logging.WithString("portfolio", p.ID),
@@ -136,11 +181,17 @@ func (p *Portfolio) UpdateAsset(symbol string, amount, priceUSD *big.Float) erro
 		return fmt.Errorf("asset %s not found in portfolio", symbol)
 	}
 
+	previous := *asset
 	asset.Amount = amount
 	asset.PriceUSD = priceUSD
 	asset.ValueUSD = new(big.Float).Mul(amount, priceUSD)
 	asset.LastUpdate = time.Now()
 
+	if err := p.saveAsset(context.Background(), asset); err != nil {
+		*asset = previous
+		return fmt.Errorf("failed to persist asset %s: %w", symbol, err)
+	}
+
 	amountFloat, _ := amount.Float64()
 	priceFloat, _ := priceUSD.Float64()
 	p.logger.Debug("Updated asset",
@@ -162,6 +213,12 @@ func (p *Portfolio) RemoveAsset(symbol string) error {
 		return fmt.Errorf("asset %s not found in portfolio", symbol)
 	}
 
+	if p.repo != nil {
+		if err := p.repo.DeleteAsset(context.Background(), p.ID, symbol); err != nil {
+			return fmt.Errorf("failed to delete persisted asset %s: %w", symbol, err)
+		}
+	}
+
 	delete(p.Assets, symbol)
 	p.logger.Info("Removed asset from portfolio",
 		logging.WithString("portfolio", p.ID),
@@ -187,7 +244,56 @@ func (p *Portfolio) OpenPosition(position *Position) error {
 	position.Status = PositionOpen
 	position.OpenedAt = time.Now()
 	p.Positions[position.ID] = position
+	if err := p.savePosition(context.Background(), position); err != nil {
+		delete(p.Positions, position.ID)
+		return fmt.Errorf("failed to persist position %s: %w", position.ID, err)
+	}
+
+	p.positionOpened(position)
+	return nil
+}
+
+// BuyPosition opens a position paid for with cost from the cash balance. The
+// balance is checked, debited and the position opened under one lock; when
+// either change cannot be persisted, neither is kept.
+func (p *Portfolio) BuyPosition(position *Position, cost *big.Float) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.Positions[position.ID]; exists {
+		return fmt.Errorf("position %s already exists", position.ID)
+	}
+	if cost.Cmp(p.CashBalance) > 0 {
+		costFloat, _ := cost.Float64()
+		cashFloat, _ := p.CashBalance.Float64()
+		return fmt.Errorf("%w: cost %.2f exceeds cash %.2f", ErrInsufficientCash, costFloat, cashFloat)
+	}
+
+	previous := p.CashBalance
+	p.CashBalance = new(big.Float).Sub(previous, cost)
+	if err := p.saveRecord(context.Background()); err != nil {
+		p.CashBalance = previous
+		return fmt.Errorf("failed to persist portfolio %s: %w", p.ID, err)
+	}
 
+	position.Status = PositionOpen
+	position.OpenedAt = time.Now()
+	p.Positions[position.ID] = position
+	if err := p.savePosition(context.Background(), position); err != nil {
+		delete(p.Positions, position.ID)
+		p.CashBalance = previous
+		if restoreErr := p.saveRecord(context.Background()); restoreErr != nil {
+			return fmt.Errorf("failed to persist position %s: %w (and failed to restore cash balance: %v)", position.ID, err, restoreErr)
+		}
+		return fmt.Errorf("failed to persist position %s: %w", position.ID, err)
+	}
+
+	p.positionOpened(position)
+	return nil
+}
+
+// positionOpened logs and records a newly opened position; callers must hold the portfolio lock
+func (p *Portfolio) positionOpened(position *Position) {
 	sizeFloat, _ := position.Size.Float64()
 	p.logger.Info("Opened position",
 		logging.WithString("portfolio", p.ID),
@@ -201,8 +307,6 @@ func (p *Portfolio) OpenPosition(position *Position) error {
 		p.monitor.RecordPortfolioUpdate(p.ID, len(p.Assets), len(p.Positions))
 		p.monitor.RecordPositionOpened(position.Asset, string(position.Type))
 	}
-
-	return nil
 }
 
 // ClosePosition closes an existing position
@@ -219,13 +323,19 @@ func (p *Portfolio) ClosePosition(positionID string, exitPrice *big.Float) (*Pos
 		return nil, fmt.Errorf("position %s is not open", positionID)
 	}
 
+	previous := *position
 	position.CurrentPrice = exitPrice
 	position.Status = PositionClosed
 	now := time.Now()
 	position.ClosedAt = &now
 
-	// Calculate P&L
-	if position.Type == PositionLong {
+	// Calculate P&L; a liquidity position keeps the P&L it was last marked
+	// with, since its value is not size times price
+	if position.Type == PositionLiquidity {
+		if position.Pnl == nil {
+			position.Pnl = new(big.Float)
+		}
+	} else if position.Type == PositionLong {
 		position.Pnl = new(big.Float).Sub(
 			new(big.Float).Mul(position.Size, exitPrice),
 			new(big.Float).Mul(position.Size, position.EntryPrice),
@@ -238,12 +348,20 @@ func (p *Portfolio) ClosePosition(positionID string, exitPrice *big.Float) (*Pos
 	}
 
 	entryValue := new(big.Float).Mul(position.Size, position.EntryPrice)
+	if position.Type == PositionLiquidity {
+		entryValue = position.Size
+	}
 	if entryValue.Cmp(big.NewFloat(0)) != 0 {
 		pnlFloat, _ := position.Pnl.Float64()
 		entryFloat, _ := entryValue.Float64()
 		position.PnlPercent = (pnlFloat / entryFloat) * 100
 	}
 
+	if err := p.savePosition(context.Background(), position); err != nil {
+		*position = previous
+		return nil, fmt.Errorf("failed to persist position %s: %w", positionID, err)
+	}
+
 	pnlFloat, _ := position.Pnl.Float64()
 	p.logger.Info("Closed position",
 		logging.WithString("portfolio", p.ID),
@@ -260,6 +378,50 @@ func (p *Portfolio) ClosePosition(positionID string, exitPrice *big.Float) (*Pos
 	return position, nil
 }
 
+// UpdatePosition marks an open position to market with the update's current
+// price, size and P&L, where set, and its liquidity metrics. PnlPercent is
+// taken against the entry value, which for a liquidity position is its Size.
+func (p *Portfolio) UpdatePosition(update *Position) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	position, exists := p.Positions[update.ID]
+	if !exists {
+		return fmt.Errorf("position %s not found", update.ID)
+	}
+	if position.Status != PositionOpen {
+		return fmt.Errorf("position %s is not open", update.ID)
+	}
+
+	previous := *position
+	if update.Size != nil {
+		position.Size = update.Size
+	}
+	if update.CurrentPrice != nil {
+		position.CurrentPrice = update.CurrentPrice
+	}
+	if update.Pnl != nil {
+		position.Pnl = update.Pnl
+		entryValue := position.Size
+		if position.Type != PositionLiquidity {
+			entryValue = new(big.Float).Mul(position.Size, position.EntryPrice)
+		}
+		if entryValue.Sign() != 0 {
+			pnlFloat, _ := position.Pnl.Float64()
+			entryFloat, _ := entryValue.Float64()
+			position.PnlPercent = (pnlFloat / entryFloat) * 100
+		}
+	}
+	position.ImpermanentLoss = update.ImpermanentLoss
+	position.FeeAPR = update.FeeAPR
+
+	if err := p.savePosition(context.Background(), position); err != nil {
+		*position = previous
+		return fmt.Errorf("failed to persist position %s: %w", update.ID, err)
+	}
+	return nil
+}
+
 // GetTotalValue calculates the total portfolio value
 func (p *Portfolio) GetTotalValue() *big.Float {
 	p.mu.RLock()
@@ -303,6 +465,41 @@ func (p *Portfolio) GetAssetAllocation() map[string]float64 {
 	return allocation
 }
 
+// GetAssets returns the portfolio assets ordered by symbol
+func (p *Portfolio) GetAssets() []*Asset {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	assets := make([]*Asset, 0, len(p.Assets))
+	for _, asset := range p.Assets {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Symbol < assets[j].Symbol
+	})
+
+	return assets
+}
+
+// GetPositions returns all positions ordered by opening time
+func (p *Portfolio) GetPositions() []*Position {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	positions := make([]*Position, 0, len(p.Positions))
+	for _, position := range p.Positions {
+		positions = append(positions, position)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].OpenedAt.Equal(positions[j].OpenedAt) {
+			return positions[i].ID < positions[j].ID
+		}
+		return positions[i].OpenedAt.Before(positions[j].OpenedAt)
+	})
+
+	return positions
+}
+
 // GetOpenPositions returns all open positions
 func (p *Portfolio) GetOpenPositions() []*Position {
 	p.mu.RLock()
@@ -1,13 +1,20 @@
 package portfolio
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/logging"
 	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/monitoring"
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -115,12 +122,29 @@ func TestPortfolio_UpdateAsset(t *testing.T) {
 	assert.Equal(t, newPrice, updatedAsset.PriceUSD)
 	assert.Equal(t, big.NewFloat(48000), updatedAsset.ValueUSD) // 15 * 3200
 
+	// An update that cannot be persisted is not kept
+	portfolio.repo = failingAssets{Repository: storage.NewMemoryStore()}
+	err = portfolio.UpdateAsset("ETH", big.NewFloat(20), big.NewFloat(3300))
+	require.Error(t, err)
+	assert.Equal(t, newAmount, updatedAsset.Amount)
+	assert.Equal(t, newPrice, updatedAsset.PriceUSD)
+	portfolio.repo = nil
+
 	// Test updating non-existent asset
 	err = portfolio.UpdateAsset("BTC", newAmount, newPrice)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not found")
 }
 
+// failingAssets is a repository that cannot persist assets
+type failingAssets struct {
+	storage.Repository
+}
+
+func (failingAssets) SaveAsset(ctx context.Context, asset *storage.AssetRecord) error {
+	return errors.New("disk full")
+}
+
 func TestPortfolio_RemoveAsset(t *testing.T) {
 	setup := setupTest(t)
 
@@ -173,6 +197,14 @@ func TestPortfolio_OpenClosePosition(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "already exists")
 
+	// A close that cannot be persisted leaves the position open
+	portfolio.repo = failingPositions{Repository: storage.NewMemoryStore()}
+	_, err = portfolio.ClosePosition("pos-1", big.NewFloat(55000))
+	require.Error(t, err)
+	assert.Equal(t, PositionOpen, portfolio.Positions["pos-1"].Status)
+	assert.Nil(t, portfolio.Positions["pos-1"].ClosedAt)
+	portfolio.repo = nil
+
 	// Close position
 	closedPosition, err := portfolio.ClosePosition("pos-1", big.NewFloat(55000))
 	require.NoError(t, err)
@@ -186,6 +218,95 @@ func TestPortfolio_OpenClosePosition(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found")
 }
 
+// failingPositions is a repository that cannot persist positions
+type failingPositions struct {
+	storage.Repository
+}
+
+func (failingPositions) SavePosition(ctx context.Context, position *storage.PositionRecord) error {
+	return errors.New("disk full")
+}
+
+func TestPortfolio_BuyPosition(t *testing.T) {
+	setup := setupTest(t)
+
+	portfolio := NewPortfolio("test", "Test", RiskProfile{}, setup.logger, setup.monitor)
+	require.NoError(t, portfolio.SetCashBalance(big.NewFloat(1000)))
+
+	// Concurrent purchases can never spend more than the balance
+	var wg sync.WaitGroup
+	var bought atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := portfolio.BuyPosition(&Position{
+				ID:         fmt.Sprintf("pos-%d", i),
+				Asset:      "ETH",
+				Type:       PositionLong,
+				Size:       big.NewFloat(0.05),
+				EntryPrice: big.NewFloat(2000),
+			}, big.NewFloat(100))
+			if err == nil {
+				bought.Add(1)
+			} else {
+				assert.ErrorIs(t, err, ErrInsufficientCash)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), bought.Load())
+	assert.Len(t, portfolio.GetOpenPositions(), 10)
+	assert.Zero(t, portfolio.GetPortfolioStats().CashBalance.Sign())
+
+	// A position that cannot be persisted is not paid for
+	require.NoError(t, portfolio.SetCashBalance(big.NewFloat(500)))
+	portfolio.repo = failingPositions{Repository: storage.NewMemoryStore()}
+	err := portfolio.BuyPosition(&Position{ID: "unsaved", Asset: "ETH", Type: PositionLong, Size: big.NewFloat(0.1), EntryPrice: big.NewFloat(2000)}, big.NewFloat(200))
+	require.Error(t, err)
+	assert.NotContains(t, portfolio.Positions, "unsaved")
+	assert.Equal(t, 0, portfolio.GetPortfolioStats().CashBalance.Cmp(big.NewFloat(500)))
+}
+
+func TestPortfolio_UpdateLiquidityPosition(t *testing.T) {
+	setup := setupTest(t)
+
+	portfolio := NewPortfolio("test", "Test", RiskProfile{}, setup.logger, setup.monitor)
+	require.NoError(t, portfolio.OpenPosition(&Position{
+		ID:           "lp-1",
+		Asset:        "WETH/USDC",
+		Type:         PositionLiquidity,
+		Size:         big.NewFloat(4000),
+		EntryPrice:   big.NewFloat(2000),
+		CurrentPrice: big.NewFloat(2000),
+	}))
+
+	// Marked down by impermanent loss but earning fees
+	require.NoError(t, portfolio.UpdatePosition(&Position{
+		ID:              "lp-1",
+		CurrentPrice:    big.NewFloat(2500),
+		Pnl:             big.NewFloat(-100),
+		ImpermanentLoss: -0.006,
+		FeeAPR:          0.12,
+	}))
+	position := portfolio.Positions["lp-1"]
+	assert.Equal(t, 0, position.Size.Cmp(big.NewFloat(4000)))
+	assert.Equal(t, 0, position.CurrentPrice.Cmp(big.NewFloat(2500)))
+	assert.Equal(t, -2.5, position.PnlPercent)
+	assert.Equal(t, -0.006, position.ImpermanentLoss)
+	assert.Equal(t, 0.12, position.FeeAPR)
+
+	// Closing keeps the marked P&L instead of pricing the size
+	closed, err := portfolio.ClosePosition("lp-1", big.NewFloat(2500))
+	require.NoError(t, err)
+	assert.Equal(t, 0, closed.Pnl.Cmp(big.NewFloat(-100)))
+	assert.Equal(t, -2.5, closed.PnlPercent)
+
+	err = portfolio.UpdatePosition(&Position{ID: "lp-1", Pnl: big.NewFloat(0)})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not open")
+}
+
 func TestPortfolio_GetTotalValue(t *testing.T) {
 	setup := setupTest(t)
 
@@ -346,3 +467,62 @@ func TestPortfolio_GetPortfolioStats(t *testing.T) {
 	assert.NotNil(t, stats.AssetAllocation)
 	assert.WithinDuration(t, time.Now(), stats.LastUpdate, time.Second)
 }
+
+func TestPortfolioManager_Persistence(t *testing.T) {
+	setup := setupTest(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aegis.db")
+
+	repo, err := storage.NewFileStore(path)
+	require.NoError(t, err)
+
+	manager := NewPortfolioManager(setup.logger, setup.monitor)
+	require.NoError(t, manager.AttachRepository(ctx, repo))
+
+	portfolio, err := manager.CreatePortfolio("main", "Main", RiskProfile{
+		Type:              RiskModerate,
+		TargetAllocations: map[string]float64{"ETH": 100},
+	}, big.NewFloat(1000))
+	require.NoError(t, err)
+	require.NoError(t, portfolio.AddAsset(&Asset{
+		Symbol:   "ETH",
+		Amount:   big.NewFloat(2),
+		PriceUSD: big.NewFloat(2000),
+		ValueUSD: big.NewFloat(4000),
+	}))
+	require.NoError(t, portfolio.OpenPosition(&Position{
+		ID:         "pos-1",
+		Asset:      "ETH",
+		Type:       PositionLong,
+		Size:       big.NewFloat(1),
+		EntryPrice: big.NewFloat(1800),
+	}))
+	_, err = portfolio.ClosePosition("pos-1", big.NewFloat(2000))
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// A new manager over the same file sees everything written before the restart
+	reopened, err := storage.NewFileStore(path)
+	require.NoError(t, err)
+	restarted := NewPortfolioManager(setup.logger, setup.monitor)
+	require.NoError(t, restarted.AttachRepository(ctx, reopened))
+
+	restored, err := restarted.GetPortfolio("main")
+	require.NoError(t, err)
+	assert.Equal(t, "Main", restored.Name)
+	assert.Equal(t, RiskModerate, restored.RiskProfile.Type)
+	assert.Equal(t, 0, restored.CashBalance.Cmp(big.NewFloat(1000)))
+	require.Contains(t, restored.Assets, "ETH")
+	assert.Equal(t, 0, restored.Assets["ETH"].ValueUSD.Cmp(big.NewFloat(4000)))
+
+	require.Contains(t, restored.Positions, "pos-1")
+	position := restored.Positions["pos-1"]
+	assert.Equal(t, PositionClosed, position.Status)
+	assert.NotNil(t, position.ClosedAt)
+	assert.Equal(t, 0, position.Pnl.Cmp(big.NewFloat(200)))
+
+	require.NoError(t, restored.RemoveAsset("ETH"))
+	require.NoError(t, restarted.DeletePortfolio("main"))
+	_, err = reopened.GetPortfolio(ctx, "main")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
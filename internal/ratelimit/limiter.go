@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Rule is a token-bucket refill rate and capacity
+type Rule struct {
+	Rate  float64 // tokens added per second
+	Burst int     // bucket capacity
+}
+
+// Decision is the outcome of a single Allow call
+type Decision struct {
+	Allowed    bool
+	Limit      int           // bucket capacity
+	Remaining  int           // whole tokens left after this request
+	RetryAfter time.Duration // wait until a token is available, zero when allowed
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds for the Retry-After header
+func (d Decision) RetryAfterSeconds() int {
+	seconds := int(math.Ceil(d.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps one token bucket per key. Buckets that have been idle long
+// enough to refill completely are dropped, since a fresh bucket is identical.
+type Limiter struct {
+	rule    Rule
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// NewLimiter creates a limiter applying rule to every key
+func NewLimiter(rule Rule) *Limiter {
+	return &Limiter{
+		rule:    rule,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket for key if one is available
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	capacity := float64(l.rule.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*l.rule.Rate)
+		b.last = now
+	}
+
+	decision := Decision{Limit: l.rule.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = time.Duration((1 - b.tokens) / l.rule.Rate * float64(time.Second))
+	}
+	decision.Remaining = int(b.tokens)
+
+	return decision
+}
+
+// refillTime is how long an empty bucket takes to fill up
+func (l *Limiter) refillTime() time.Duration {
+	return time.Duration(float64(l.rule.Burst) / l.rule.Rate * float64(time.Second))
+}
+
+// sweep drops full buckets at most once per refill period
+func (l *Limiter) sweep(now time.Time) {
+	idle := l.refillTime()
+	if now.Sub(l.swept) < idle {
+		return
+	}
+	l.swept = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, key)
+		}
+	}
+}
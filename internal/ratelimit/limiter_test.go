@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is advanced manually by tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(rule Rule) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	limiter := NewLimiter(rule)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	limiter, clock := newTestLimiter(Rule{Rate: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		decision := limiter.Allow("client")
+		require.True(t, decision.Allowed, "request %d should fit in the burst", i)
+		assert.Equal(t, 2-i, decision.Remaining)
+		assert.Equal(t, 3, decision.Limit)
+	}
+
+	decision := limiter.Allow("client")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+	assert.Equal(t, 1, decision.RetryAfterSeconds())
+
+	clock.Advance(250 * time.Millisecond)
+	decision = limiter.Allow("client")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 250*time.Millisecond, decision.RetryAfter)
+
+	clock.Advance(250 * time.Millisecond)
+	assert.True(t, limiter.Allow("client").Allowed)
+	assert.False(t, limiter.Allow("client").Allowed)
+
+	// Refill never exceeds the burst
+	clock.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("client").Allowed)
+	}
+	assert.False(t, limiter.Allow("client").Allowed)
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	limiter, _ := newTestLimiter(Rule{Rate: 1, Burst: 1})
+
+	assert.True(t, limiter.Allow("a").Allowed)
+	assert.False(t, limiter.Allow("a").Allowed)
+	assert.True(t, limiter.Allow("b").Allowed)
+}
+
+func TestLimiterRetryAfterSecondsRoundsUp(t *testing.T) {
+	limiter, _ := newTestLimiter(Rule{Rate: 0.25, Burst: 1})
+
+	limiter.Allow("slow")
+	decision := limiter.Allow("slow")
+	require.False(t, decision.Allowed)
+	assert.Equal(t, 4*time.Second, decision.RetryAfter)
+	assert.Equal(t, 4, decision.RetryAfterSeconds())
+
+	assert.Equal(t, 2, Decision{RetryAfter: 1100 * time.Millisecond}.RetryAfterSeconds())
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	limiter, clock := newTestLimiter(Rule{Rate: 10, Burst: 10})
+
+	limiter.Allow("idle")
+	clock.Advance(500 * time.Millisecond)
+	limiter.Allow("busy")
+	assert.Len(t, limiter.buckets, 2)
+
+	// "idle" has refilled completely, "busy" has not
+	clock.Advance(700 * time.Millisecond)
+	limiter.Allow("busy")
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "busy")
+}
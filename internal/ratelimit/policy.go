@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+)
+
+// Recorder receives every limiter decision, typically to export metrics.
+// monitoring.Monitor implements it.
+type Recorder interface {
+	RecordRateLimit(server, route string, allowed bool)
+}
+
+// Policy applies a default limit and optional per-route limits to clients
+type Policy struct {
+	enabled  bool
+	fallback *Limiter
+	routes   map[string]*Limiter
+}
+
+// NewPolicy builds a policy from configuration. A disabled configuration
+// yields a policy that allows every request.
+func NewPolicy(cfg config.RateLimitConfig) *Policy {
+	p := &Policy{enabled: cfg.Enabled, routes: make(map[string]*Limiter)}
+	if !cfg.Enabled {
+		return p
+	}
+
+	p.fallback = NewLimiter(Rule{Rate: cfg.RequestsPerSecond, Burst: cfg.Burst})
+	for route, rule := range cfg.Routes {
+		p.routes[route] = NewLimiter(Rule{Rate: rule.RequestsPerSecond, Burst: rule.Burst})
+	}
+
+	return p
+}
+
+// Enabled reports whether the policy limits requests at all
+func (p *Policy) Enabled() bool {
+	return p.enabled
+}
+
+// Allow charges one request by client against the limiter for method and
+// route, where route is the path template that matched the request.
+// "METHOD route" entries take precedence over bare route entries, and routes
+// without an entry share the default limiter.
+func (p *Policy) Allow(method, route, client string) Decision {
+	if !p.enabled {
+		return Decision{Allowed: true}
+	}
+
+	if limiter, ok := p.routes[method+" "+route]; ok {
+		return limiter.Allow(client)
+	}
+	if limiter, ok := p.routes[route]; ok {
+		return limiter.Allow(client)
+	}
+	return p.fallback.Allow(client)
+}
+
+// SetHeaders writes the rate limit headers for a decision, including
+// Retry-After when the request was rejected
+func SetHeaders(w http.ResponseWriter, d Decision) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	if !d.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(d.RetryAfterSeconds()))
+	}
+}
+
+// ClientIP returns the IP address of the peer that sent the request.
+// Forwarding headers are ignored because they are set by the client.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	return host
+}
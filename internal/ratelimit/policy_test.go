@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+)
+
+func TestPolicyRoutes(t *testing.T) {
+	policy := NewPolicy(config.RateLimitConfig{
+		Enabled:           true,
+		RequestsPerSecond: 1,
+		Burst:             2,
+		Routes: map[string]config.RateLimitRule{
+			"POST /tools/{name}": {RequestsPerSecond: 1, Burst: 1},
+			"/tools":             {RequestsPerSecond: 1, Burst: 3},
+		},
+	})
+	assert.True(t, policy.Enabled())
+
+	// Method-specific entry
+	assert.True(t, policy.Allow("POST", "/tools/{name}", "ip:1").Allowed)
+	assert.False(t, policy.Allow("POST", "/tools/{name}", "ip:1").Allowed)
+	assert.True(t, policy.Allow("POST", "/tools/{name}", "ip:2").Allowed)
+
+	// Bare path entry matches any method
+	for i := 0; i < 3; i++ {
+		assert.True(t, policy.Allow("GET", "/tools", "ip:1").Allowed)
+	}
+	assert.False(t, policy.Allow("GET", "/tools", "ip:1").Allowed)
+
+	// Unlisted routes share the default bucket
+	assert.True(t, policy.Allow("GET", "/", "ip:1").Allowed)
+	assert.True(t, policy.Allow("GET", "/tools/{name}", "ip:1").Allowed)
+	decision := policy.Allow("DELETE", "/", "ip:1")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 2, decision.Limit)
+}
+
+func TestDisabledPolicyAllowsEverything(t *testing.T) {
+	policy := NewPolicy(config.RateLimitConfig{Enabled: false, RequestsPerSecond: 1, Burst: 1})
+	assert.False(t, policy.Enabled())
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, policy.Allow("GET", "/", "ip:1").Allowed)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SetHeaders(rec, Decision{Allowed: true, Limit: 5, Remaining: 4})
+	assert.Equal(t, "5", rec.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "4", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+
+	rec = httptest.NewRecorder()
+	SetHeaders(rec, Decision{Limit: 5, RetryAfter: 2500 * time.Millisecond})
+	assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	assert.Equal(t, "203.0.113.7", ClientIP(req))
+
+	req.RemoteAddr = "[2001:db8::1]:443"
+	assert.Equal(t, "2001:db8::1", ClientIP(req))
+
+	req.RemoteAddr = "unix-socket"
+	assert.Equal(t, "unix-socket", ClientIP(req))
+}
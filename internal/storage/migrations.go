@@ -0,0 +1,69 @@
+package storage
+
+import "fmt"
+
+// migration upgrades a database to Version
+type migration struct {
+	Version     int
+	Description string
+	Apply       func(db *database) error
+}
+
+// migrations are applied in order to bring a database up to the latest schema.
+// Append new migrations; never edit or reorder released ones.
+var migrations = []migration{
+	{
+		Version:     1,
+		Description: "portfolios, assets and positions",
+		Apply: func(db *database) error {
+			if db.Portfolios == nil {
+				db.Portfolios = make(map[string]*PortfolioRecord)
+			}
+			if db.Assets == nil {
+				db.Assets = make(map[string]map[string]*AssetRecord)
+			}
+			if db.Positions == nil {
+				db.Positions = make(map[string]map[string]*PositionRecord)
+			}
+			return nil
+		},
+	},
+	{
+		// Executed transactions live in an append-only journal next to the
+		// database file, loaded by NewFileStore
+		Version:     2,
+		Description: "strategy runs and executed transactions",
+		Apply: func(db *database) error {
+			if db.StrategyRuns == nil {
+				db.StrategyRuns = make(map[string]*StrategyRunRecord)
+			}
+			if db.Transactions == nil {
+				db.Transactions = make([]*TransactionRecord, 0)
+			}
+			return nil
+		},
+	},
+}
+
+// LatestSchemaVersion is the schema version produced by the last migration
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// migrate applies every migration newer than the database's schema version
+func migrate(db *database) error {
+	if db.SchemaVersion > LatestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", db.SchemaVersion, LatestSchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.Version <= db.SchemaVersion {
+			continue
+		}
+		if err := m.Apply(db); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		db.SchemaVersion = m.Version
+	}
+	return nil
+}
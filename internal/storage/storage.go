@@ -0,0 +1,154 @@
+// Package storage persists portfolios, positions, strategy runs and executed transactions.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+)
+
+// Supported values for config.DatabaseConfig.Driver
+const (
+	DriverMemory = "memory"
+	DriverFile   = "file"
+)
+
+// DefaultPath is the database file used by the file driver when no path is configured
+const DefaultPath = "data/aegis.db"
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// Repository stores portfolio state and execution history
+type Repository interface {
+	SavePortfolio(ctx context.Context, portfolio *PortfolioRecord) error
+	GetPortfolio(ctx context.Context, id string) (*PortfolioRecord, error)
+	ListPortfolios(ctx context.Context) ([]*PortfolioRecord, error)
+	// DeletePortfolio removes a portfolio together with its assets and positions
+	DeletePortfolio(ctx context.Context, id string) error
+
+	SaveAsset(ctx context.Context, asset *AssetRecord) error
+	ListAssets(ctx context.Context, portfolioID string) ([]*AssetRecord, error)
+	DeleteAsset(ctx context.Context, portfolioID, symbol string) error
+
+	SavePosition(ctx context.Context, position *PositionRecord) error
+	ListPositions(ctx context.Context, portfolioID string) ([]*PositionRecord, error)
+
+	SaveStrategyRun(ctx context.Context, run *StrategyRunRecord) error
+	GetStrategyRun(ctx context.Context, id string) (*StrategyRunRecord, error)
+	ListStrategyRuns(ctx context.Context, strategyID string) ([]*StrategyRunRecord, error)
+
+	SaveTransaction(ctx context.Context, tx *TransactionRecord) error
+	ListTransactions(ctx context.Context, portfolioID string) ([]*TransactionRecord, error)
+
+	Close() error
+}
+
+// PortfolioRecord is the stored form of a portfolio
+type PortfolioRecord struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	CashBalance   *big.Float        `json:"cash_balance"`
+	RiskProfile   RiskProfileRecord `json:"risk_profile"`
+	RebalanceAt   time.Time         `json:"rebalance_at"`
+	LastRebalance time.Time         `json:"last_rebalance"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// RiskProfileRecord is the stored form of a portfolio risk profile
+type RiskProfileRecord struct {
+	Type              string             `json:"type"`
+	MaxDrawdown       float64            `json:"max_drawdown"`
+	MaxPositionSize   float64            `json:"max_position_size"`
+	MaxLeverage       float64            `json:"max_leverage"`
+	TargetAllocations map[string]float64 `json:"target_allocations"`
+}
+
+// AssetRecord is the stored form of a portfolio asset
+type AssetRecord struct {
+	PortfolioID string     `json:"portfolio_id"`
+	Symbol      string     `json:"symbol"`
+	Name        string     `json:"name"`
+	Amount      *big.Float `json:"amount"`
+	ValueUSD    *big.Float `json:"value_usd"`
+	PriceUSD    *big.Float `json:"price_usd"`
+	Allocation  float64    `json:"allocation"`
+	APY         float64    `json:"apy"`
+	RiskScore   float64    `json:"risk_score"`
+	LastUpdate  time.Time  `json:"last_update"`
+}
+
+// PositionRecord is the stored form of a trading position
+type PositionRecord struct {
+	PortfolioID  string     `json:"portfolio_id"`
+	ID           string     `json:"id"`
+	Asset        string     `json:"asset"`
+	Type         string     `json:"type"`
+	Size         *big.Float `json:"size"`
+	EntryPrice   *big.Float `json:"entry_price"`
+	CurrentPrice *big.Float `json:"current_price"`
+	Pnl          *big.Float `json:"pnl"`
+	PnlPercent   float64    `json:"pnl_percent"`
+	Leverage     float64    `json:"leverage"`
+	Status       string     `json:"status"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+
+	ImpermanentLoss float64 `json:"impermanent_loss,omitempty"`
+	FeeAPR          float64 `json:"fee_apr,omitempty"`
+}
+
+// StrategyRunRecord records a live or backtest execution of a strategy
+type StrategyRunRecord struct {
+	ID          string            `json:"id"`
+	StrategyID  string            `json:"strategy_id"`
+	Mode        string            `json:"mode"` // "live", "paper" or "backtest"
+	Status      string            `json:"status"`
+	StartedAt   time.Time         `json:"started_at"`
+	FinishedAt  *time.Time        `json:"finished_at,omitempty"`
+	Trades      int               `json:"trades"`
+	TotalReturn float64           `json:"total_return"`
+	SharpeRatio float64           `json:"sharpe_ratio"`
+	MaxDrawdown float64           `json:"max_drawdown"`
+	Error       string            `json:"error,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// TransactionRecord records an executed trade or on-chain transaction
+type TransactionRecord struct {
+	ID            string     `json:"id"`
+	PortfolioID   string     `json:"portfolio_id"`
+	StrategyRunID string     `json:"strategy_run_id,omitempty"`
+	Chain         string     `json:"chain,omitempty"`
+	Hash          string     `json:"hash,omitempty"`
+	Asset         string     `json:"asset"`
+	Side          string     `json:"side"`
+	Amount        *big.Float `json:"amount"`
+	Price         *big.Float `json:"price,omitempty"`
+	Fee           *big.Float `json:"fee,omitempty"`
+	Status        string     `json:"status"`
+	Reason        string     `json:"reason,omitempty"`
+	ExecutedAt    time.Time  `json:"executed_at"`
+}
+
+// Open creates the repository selected by cfg.Driver
+func Open(cfg *config.DatabaseConfig) (Repository, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case DriverMemory:
+		return NewMemoryStore(), nil
+	case DriverFile, "":
+		path := cfg.Path
+		if path == "" {
+			path = DefaultPath
+		}
+		return NewFileStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
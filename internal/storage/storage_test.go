@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BlockCraftsman/Aegis-Defi-Agent/internal/config"
+)
+
+func TestOpen(t *testing.T) {
+	repo, err := Open(&config.DatabaseConfig{Driver: "memory"})
+	require.NoError(t, err)
+	assert.IsType(t, &Store{}, repo)
+	require.NoError(t, repo.Close())
+
+	path := filepath.Join(t.TempDir(), "nested", "aegis.db")
+	repo, err = Open(&config.DatabaseConfig{Driver: "file", Path: path})
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+	assert.FileExists(t, path)
+
+	_, err = Open(&config.DatabaseConfig{Driver: "postgres"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported database driver")
+}
+
+func TestFileStorePersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aegis.db")
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), store.SchemaVersion())
+
+	opened := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.SavePortfolio(ctx, &PortfolioRecord{
+		ID:          "main",
+		Name:        "Main Portfolio",
+		CashBalance: big.NewFloat(2500.5),
+		RiskProfile: RiskProfileRecord{
+			Type:              "moderate",
+			MaxLeverage:       2,
+			TargetAllocations: map[string]float64{"ETH": 60, "USDC": 40},
+		},
+	}))
+	require.NoError(t, store.SaveAsset(ctx, &AssetRecord{
+		PortfolioID: "main",
+		Symbol:      "ETH",
+		Amount:      big.NewFloat(1.5),
+		PriceUSD:    big.NewFloat(2000),
+		ValueUSD:    big.NewFloat(3000),
+	}))
+	require.NoError(t, store.SavePosition(ctx, &PositionRecord{
+		PortfolioID: "main",
+		ID:          "pos-1",
+		Asset:       "ETH",
+		Type:        "long",
+		Size:        big.NewFloat(1),
+		EntryPrice:  big.NewFloat(1900),
+		Status:      "open",
+		OpenedAt:    opened,
+	}))
+	require.NoError(t, store.SaveStrategyRun(ctx, &StrategyRunRecord{
+		ID:         "run-1",
+		StrategyID: "mean_reversion_eth",
+		Mode:       "backtest",
+		Status:     "completed",
+		StartedAt:  opened,
+		Trades:     4,
+	}))
+	require.NoError(t, store.SaveTransaction(ctx, &TransactionRecord{
+		ID:          "tx-1",
+		PortfolioID: "main",
+		Asset:       "ETH",
+		Side:        "buy",
+		Amount:      big.NewFloat(1),
+		Price:       big.NewFloat(1900),
+		Status:      "confirmed",
+		ExecutedAt:  opened,
+	}))
+	require.NoError(t, store.Close())
+
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	portfolio, err := reopened.GetPortfolio(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, "Main Portfolio", portfolio.Name)
+	assert.Equal(t, 0, portfolio.CashBalance.Cmp(big.NewFloat(2500.5)))
+	assert.Equal(t, 60.0, portfolio.RiskProfile.TargetAllocations["ETH"])
+
+	assets, err := reopened.ListAssets(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, 0, assets[0].Amount.Cmp(big.NewFloat(1.5)))
+
+	positions, err := reopened.ListPositions(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.True(t, positions[0].OpenedAt.Equal(opened))
+	assert.Nil(t, positions[0].ClosedAt)
+
+	runs, err := reopened.ListStrategyRuns(ctx, "mean_reversion_eth")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, 4, runs[0].Trades)
+
+	txs, err := reopened.ListTransactions(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "buy", txs[0].Side)
+
+	// Deleting a portfolio cascades to its assets and positions
+	require.NoError(t, reopened.DeletePortfolio(ctx, "main"))
+	_, err = reopened.GetPortfolio(ctx, "main")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assets, err = reopened.ListAssets(ctx, "main")
+	require.NoError(t, err)
+	assert.Empty(t, assets)
+}
+
+func TestStoreValidation(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	err := store.SaveAsset(ctx, &AssetRecord{PortfolioID: "missing", Symbol: "ETH"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = store.DeletePortfolio(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.Error(t, store.SavePortfolio(ctx, &PortfolioRecord{}))
+
+	tx := &TransactionRecord{ID: "tx-1", Asset: "ETH"}
+	require.NoError(t, store.SaveTransaction(ctx, tx))
+	require.Error(t, store.SaveTransaction(ctx, tx))
+
+	// Stored records are copies of the caller's values
+	require.NoError(t, store.SavePortfolio(ctx, &PortfolioRecord{ID: "p", Name: "before"}))
+	record, err := store.GetPortfolio(ctx, "p")
+	require.NoError(t, err)
+	record.Name = "after"
+	record, err = store.GetPortfolio(ctx, "p")
+	require.NoError(t, err)
+	assert.Equal(t, "before", record.Name)
+}
+
+func TestMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aegis.db")
+
+	// A version 1 database predates strategy runs and transactions
+	legacy := `{"schema_version":1,"portfolios":{"old":{"id":"old","name":"Legacy"}},"assets":{},"positions":{}}`
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0o600))
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, LatestSchemaVersion(), store.SchemaVersion())
+
+	portfolio, err := store.GetPortfolio(context.Background(), "old")
+	require.NoError(t, err)
+	assert.Equal(t, "Legacy", portfolio.Name)
+	require.NoError(t, store.SaveStrategyRun(context.Background(), &StrategyRunRecord{ID: "run-1"}))
+
+	// Databases written by a newer release are rejected
+	require.NoError(t, os.WriteFile(path, []byte(`{"schema_version":99}`), 0o600))
+	_, err = NewFileStore(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than supported")
+}
+
+func TestStoreIsolatesRecordsAndRollsBack(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aegis.db")
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	cash := big.NewFloat(100)
+	allocations := map[string]float64{"ETH": 50}
+	require.NoError(t, store.SavePortfolio(ctx, &PortfolioRecord{
+		ID:          "main",
+		CashBalance: cash,
+		RiskProfile: RiskProfileRecord{TargetAllocations: allocations},
+	}))
+
+	// Neither the saved values nor returned records alias stored state
+	cash.SetInt64(1)
+	allocations["ETH"] = 1
+	record, err := store.GetPortfolio(ctx, "main")
+	require.NoError(t, err)
+	record.CashBalance.SetInt64(2)
+	record.RiskProfile.TargetAllocations["ETH"] = 2
+	record, err = store.GetPortfolio(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, 0, record.CashBalance.Cmp(big.NewFloat(100)))
+	assert.Equal(t, 50.0, record.RiskProfile.TargetAllocations["ETH"])
+
+	// A write that cannot be persisted leaves the store unchanged
+	require.NoError(t, os.Mkdir(path+".tmp", 0o755))
+	require.Error(t, store.SavePortfolio(ctx, &PortfolioRecord{ID: "other"}))
+	require.Error(t, store.DeletePortfolio(ctx, "main"))
+	portfolios, err := store.ListPortfolios(ctx)
+	require.NoError(t, err)
+	require.Len(t, portfolios, 1)
+	assert.Equal(t, "main", portfolios[0].ID)
+}
+
+func TestTransactionJournal(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "aegis.db")
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.SaveTransaction(ctx, &TransactionRecord{
+		ID:         "tx-1",
+		Asset:      "ETH",
+		Side:       "buy",
+		ExecutedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "tx-1")
+
+	// New transactions are appended without rewriting the database
+	require.NoError(t, store.SaveTransaction(ctx, &TransactionRecord{
+		ID:         "tx-2",
+		Asset:      "ETH",
+		Side:       "sell",
+		Amount:     big.NewFloat(1),
+		ExecutedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}))
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, after)
+
+	// A torn final line from an interrupted append is ignored on reopen
+	f, err := os.OpenFile(path+".transactions", os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":"tx-3","as`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+	txs, err := reopened.ListTransactions(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, "tx-1", txs[0].ID)
+	assert.Equal(t, "tx-2", txs[1].ID)
+	assert.Equal(t, 0, txs[1].Amount.Cmp(big.NewFloat(1)))
+	require.Error(t, reopened.SaveTransaction(ctx, &TransactionRecord{ID: "tx-1"}))
+
+	require.NoError(t, reopened.SaveTransaction(ctx, &TransactionRecord{ID: "tx-3", ExecutedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)}))
+	reopened, err = NewFileStore(path)
+	require.NoError(t, err)
+	txs, err = reopened.ListTransactions(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, txs, 3)
+}
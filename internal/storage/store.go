@@ -0,0 +1,508 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// database is the full persisted state of a store. Records are never modified
+// in place, so copies of the database may share them.
+type database struct {
+	SchemaVersion int                                   `json:"schema_version"`
+	Portfolios    map[string]*PortfolioRecord           `json:"portfolios"`
+	Assets        map[string]map[string]*AssetRecord    `json:"assets"`    // portfolio -> symbol
+	Positions     map[string]map[string]*PositionRecord `json:"positions"` // portfolio -> position
+	StrategyRuns  map[string]*StrategyRunRecord         `json:"strategy_runs"`
+	// Transactions are journaled separately from the database file
+	Transactions []*TransactionRecord `json:"-"`
+}
+
+// clone copies the database's maps so they can be changed without affecting db
+func (db *database) clone() *database {
+	out := *db
+	out.Portfolios = maps.Clone(db.Portfolios)
+	out.Assets = make(map[string]map[string]*AssetRecord, len(db.Assets))
+	for id, assets := range db.Assets {
+		out.Assets[id] = maps.Clone(assets)
+	}
+	out.Positions = make(map[string]map[string]*PositionRecord, len(db.Positions))
+	for id, positions := range db.Positions {
+		out.Positions[id] = maps.Clone(positions)
+	}
+	out.StrategyRuns = maps.Clone(db.StrategyRuns)
+	return &out
+}
+
+// Store is an embedded repository kept in memory and, when backed by a file,
+// written through to disk on every change. Executed transactions are appended
+// to a journal next to the database file instead of rewriting it.
+type Store struct {
+	mu   sync.RWMutex
+	db   *database
+	path string
+}
+
+// NewMemoryStore creates a repository that is not persisted
+func NewMemoryStore() *Store {
+	db := &database{}
+	// Migrations on an empty database cannot fail
+	_ = migrate(db)
+	return &Store{db: db}
+}
+
+// NewFileStore opens or creates a file-backed repository at path, applying any pending schema migrations
+func NewFileStore(path string) (*Store, error) {
+	db := &database{}
+
+	data, err := os.ReadFile(path)
+	created := os.IsNotExist(err)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, db); err != nil {
+			return nil, fmt.Errorf("failed to decode database %s: %w", path, err)
+		}
+	case created:
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to read database %s: %w", path, err)
+	}
+
+	version := db.SchemaVersion
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
+	s := &Store{db: db, path: path}
+
+	journaled, err := readJournal(s.journalPath())
+	if err != nil {
+		return nil, err
+	}
+	db.Transactions = journaled
+
+	if created || version != db.SchemaVersion {
+		if err := s.flush(db); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SchemaVersion returns the schema version of the open database
+func (s *Store) SchemaVersion() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.SchemaVersion
+}
+
+// flush writes db to disk atomically without its journaled transactions;
+// callers must hold the write lock
+func (s *Store) flush(db *database) error {
+	if s.path == "" {
+		return nil
+	}
+
+	persisted := *db
+	persisted.Transactions = nil
+	data, err := json.Marshal(&persisted)
+	if err != nil {
+		return fmt.Errorf("failed to encode database: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+	return nil
+}
+
+// writeFileSync writes data to path and syncs it to disk before returning
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync database: %w", err)
+	}
+	return f.Close()
+}
+
+// journalPath returns the file transactions are appended to
+func (s *Store) journalPath() string {
+	return s.path + ".transactions"
+}
+
+// appendJournal writes records to the end of the transaction journal, one JSON document per line
+func (s *Store) appendJournal(records ...*TransactionRecord) error {
+	if s.path == "" || len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode transaction %s: %w", record.ID, err)
+		}
+	}
+
+	f, err := os.OpenFile(s.journalPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction journal: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write transaction journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync transaction journal: %w", err)
+	}
+	return f.Close()
+}
+
+// readJournal reads a transaction journal
+func readJournal(path string) ([]*TransactionRecord, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction journal: %w", err)
+	}
+
+	// Every complete record ends in a newline, so anything after the last one
+	// is an interrupted write and is cut off before appending again
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		if err := os.Truncate(path, int64(complete)); err != nil {
+			return nil, fmt.Errorf("failed to repair transaction journal: %w", err)
+		}
+		data = data[:complete]
+	}
+
+	var records []*TransactionRecord
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		record := &TransactionRecord{}
+		if err := json.Unmarshal(line, record); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction journal line %d: %w", i+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// update applies fn to a copy of the database under the write lock and keeps
+// the copy once it is persisted, so a failed write leaves the store unchanged
+func (s *Store) update(ctx context.Context, fn func(db *database) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.db.clone()
+	if err := fn(next); err != nil {
+		return err
+	}
+	if err := s.flush(next); err != nil {
+		return err
+	}
+	s.db = next
+	return nil
+}
+
+// SavePortfolio inserts or replaces a portfolio
+func (s *Store) SavePortfolio(ctx context.Context, portfolio *PortfolioRecord) error {
+	if portfolio.ID == "" {
+		return fmt.Errorf("portfolio ID is required")
+	}
+
+	record := portfolio.clone()
+	return s.update(ctx, func(db *database) error {
+		db.Portfolios[record.ID] = record
+		return nil
+	})
+}
+
+// GetPortfolio returns a portfolio by ID
+func (s *Store) GetPortfolio(ctx context.Context, id string) (*PortfolioRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.db.Portfolios[id]
+	if !ok {
+		return nil, fmt.Errorf("portfolio %s: %w", id, ErrNotFound)
+	}
+	return record.clone(), nil
+}
+
+// ListPortfolios returns all portfolios ordered by ID
+func (s *Store) ListPortfolios(ctx context.Context) ([]*PortfolioRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*PortfolioRecord, 0, len(s.db.Portfolios))
+	for _, record := range s.db.Portfolios {
+		records = append(records, record.clone())
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// DeletePortfolio removes a portfolio with its assets and positions
+func (s *Store) DeletePortfolio(ctx context.Context, id string) error {
+	return s.update(ctx, func(db *database) error {
+		if _, ok := db.Portfolios[id]; !ok {
+			return fmt.Errorf("portfolio %s: %w", id, ErrNotFound)
+		}
+		delete(db.Portfolios, id)
+		delete(db.Assets, id)
+		delete(db.Positions, id)
+		return nil
+	})
+}
+
+// SaveAsset inserts or replaces an asset of an existing portfolio
+func (s *Store) SaveAsset(ctx context.Context, asset *AssetRecord) error {
+	record := asset.clone()
+	return s.update(ctx, func(db *database) error {
+		if _, ok := db.Portfolios[record.PortfolioID]; !ok {
+			return fmt.Errorf("portfolio %s: %w", record.PortfolioID, ErrNotFound)
+		}
+		if db.Assets[record.PortfolioID] == nil {
+			db.Assets[record.PortfolioID] = make(map[string]*AssetRecord)
+		}
+		db.Assets[record.PortfolioID][record.Symbol] = record
+		return nil
+	})
+}
+
+// ListAssets returns the assets of a portfolio ordered by symbol
+func (s *Store) ListAssets(ctx context.Context, portfolioID string) ([]*AssetRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*AssetRecord, 0, len(s.db.Assets[portfolioID]))
+	for _, record := range s.db.Assets[portfolioID] {
+		records = append(records, record.clone())
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Symbol < records[j].Symbol })
+	return records, nil
+}
+
+// DeleteAsset removes an asset from a portfolio
+func (s *Store) DeleteAsset(ctx context.Context, portfolioID, symbol string) error {
+	return s.update(ctx, func(db *database) error {
+		if _, ok := db.Assets[portfolioID][symbol]; !ok {
+			return fmt.Errorf("asset %s in portfolio %s: %w", symbol, portfolioID, ErrNotFound)
+		}
+		delete(db.Assets[portfolioID], symbol)
+		return nil
+	})
+}
+
+// SavePosition inserts or replaces a position of an existing portfolio
+func (s *Store) SavePosition(ctx context.Context, position *PositionRecord) error {
+	record := position.clone()
+	return s.update(ctx, func(db *database) error {
+		if _, ok := db.Portfolios[record.PortfolioID]; !ok {
+			return fmt.Errorf("portfolio %s: %w", record.PortfolioID, ErrNotFound)
+		}
+		if db.Positions[record.PortfolioID] == nil {
+			db.Positions[record.PortfolioID] = make(map[string]*PositionRecord)
+		}
+		db.Positions[record.PortfolioID][record.ID] = record
+		return nil
+	})
+}
+
+// ListPositions returns the positions of a portfolio ordered by opening time
+func (s *Store) ListPositions(ctx context.Context, portfolioID string) ([]*PositionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*PositionRecord, 0, len(s.db.Positions[portfolioID]))
+	for _, record := range s.db.Positions[portfolioID] {
+		records = append(records, record.clone())
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].OpenedAt.Equal(records[j].OpenedAt) {
+			return records[i].OpenedAt.Before(records[j].OpenedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+// SaveStrategyRun inserts or replaces a strategy run
+func (s *Store) SaveStrategyRun(ctx context.Context, run *StrategyRunRecord) error {
+	if run.ID == "" {
+		return fmt.Errorf("strategy run ID is required")
+	}
+
+	record := run.clone()
+	return s.update(ctx, func(db *database) error {
+		db.StrategyRuns[record.ID] = record
+		return nil
+	})
+}
+
+// GetStrategyRun returns a strategy run by ID
+func (s *Store) GetStrategyRun(ctx context.Context, id string) (*StrategyRunRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.db.StrategyRuns[id]
+	if !ok {
+		return nil, fmt.Errorf("strategy run %s: %w", id, ErrNotFound)
+	}
+	return record.clone(), nil
+}
+
+// ListStrategyRuns returns the runs of a strategy (all runs when strategyID is empty), oldest first
+func (s *Store) ListStrategyRuns(ctx context.Context, strategyID string) ([]*StrategyRunRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*StrategyRunRecord, 0)
+	for _, record := range s.db.StrategyRuns {
+		if strategyID == "" || record.StrategyID == strategyID {
+			records = append(records, record.clone())
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].StartedAt.Equal(records[j].StartedAt) {
+			return records[i].StartedAt.Before(records[j].StartedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
+
+// SaveTransaction appends an executed transaction to the journal; transactions are immutable once stored
+func (s *Store) SaveTransaction(ctx context.Context, tx *TransactionRecord) error {
+	if tx.ID == "" {
+		return fmt.Errorf("transaction ID is required")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	record := tx.clone()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.db.Transactions {
+		if existing.ID == record.ID {
+			return fmt.Errorf("transaction %s already exists", record.ID)
+		}
+	}
+	if err := s.appendJournal(record); err != nil {
+		return err
+	}
+	s.db.Transactions = append(s.db.Transactions, record)
+	return nil
+}
+
+// ListTransactions returns the transactions of a portfolio (all when portfolioID is empty) in execution order
+func (s *Store) ListTransactions(ctx context.Context, portfolioID string) ([]*TransactionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := make([]*TransactionRecord, 0)
+	for _, record := range s.db.Transactions {
+		if portfolioID == "" || record.PortfolioID == portfolioID {
+			records = append(records, record.clone())
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].ExecutedAt.Before(records[j].ExecutedAt) })
+	return records, nil
+}
+
+// Close flushes the store; the store must not be used afterwards
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush(s.db)
+}
+
+func cloneFloat(x *big.Float) *big.Float {
+	if x == nil {
+		return nil
+	}
+	return new(big.Float).Copy(x)
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	out := *t
+	return &out
+}
+
+func (r *PortfolioRecord) clone() *PortfolioRecord {
+	out := *r
+	out.CashBalance = cloneFloat(r.CashBalance)
+	out.RiskProfile.TargetAllocations = maps.Clone(r.RiskProfile.TargetAllocations)
+	return &out
+}
+
+func (r *AssetRecord) clone() *AssetRecord {
+	out := *r
+	out.Amount = cloneFloat(r.Amount)
+	out.ValueUSD = cloneFloat(r.ValueUSD)
+	out.PriceUSD = cloneFloat(r.PriceUSD)
+	return &out
+}
+
+func (r *PositionRecord) clone() *PositionRecord {
+	out := *r
+	out.Size = cloneFloat(r.Size)
+	out.EntryPrice = cloneFloat(r.EntryPrice)
+	out.CurrentPrice = cloneFloat(r.CurrentPrice)
+	out.Pnl = cloneFloat(r.Pnl)
+	out.ClosedAt = cloneTime(r.ClosedAt)
+	return &out
+}
+
+func (r *StrategyRunRecord) clone() *StrategyRunRecord {
+	out := *r
+	out.FinishedAt = cloneTime(r.FinishedAt)
+	out.Metadata = maps.Clone(r.Metadata)
+	return &out
+}
+
+func (r *TransactionRecord) clone() *TransactionRecord {
+	out := *r
+	out.Amount = cloneFloat(r.Amount)
+	out.Price = cloneFloat(r.Price)
+	out.Fee = cloneFloat(r.Fee)
+	return &out
+}